@@ -3,11 +3,16 @@ package app
 import (
 	"errors"
 	"fmt"
+	"os"
 
 	"github.com/hkdb/aerion/internal/account"
+	"github.com/hkdb/aerion/internal/auth"
 	"github.com/hkdb/aerion/internal/certificate"
 	"github.com/hkdb/aerion/internal/imap"
+	"github.com/hkdb/aerion/internal/jmap"
 	"github.com/hkdb/aerion/internal/logging"
+	"github.com/hkdb/aerion/internal/maildir"
+	"github.com/hkdb/aerion/internal/pop3"
 )
 
 // ============================================================================
@@ -45,12 +50,19 @@ func (a *App) AddAccount(config account.AccountConfig) (*account.Account, error)
 		}
 	}
 
+	// Local folders kept in a Maildir tree need its root to sync
+	if acc.IsLocal() && acc.MaildirPath != "" {
+		if err := maildir.Tree(acc.MaildirPath).Create(); err != nil {
+			log.Warn().Err(err).Str("account_id", acc.ID).Msg("Failed to create Maildir tree")
+		}
+	}
+
 	// Scale database connection pool for new account
 	a.updateDBConnectionPool()
 
-	// Start IDLE for the new account
+	// Start IDLE (or JMAP push) for the new account
 	if a.idleManager != nil && acc.Enabled {
-		a.idleManager.StartAccount(acc.ID, acc.Name)
+		a.startAccountPush(acc)
 	}
 
 	log.Info().Str("account_id", acc.ID).Str("email", acc.Email).Msg("Account created")
@@ -71,6 +83,17 @@ func (a *App) UpdateAccount(id string, config account.AccountConfig) (*account.A
 		return nil, fmt.Errorf("account not found: %s", id)
 	}
 
+	// A Maildir tree holds the messages of its account, so it can be moved
+	// but not dropped
+	if existingAcc.IsLocal() && existingAcc.MaildirPath != "" && config.MaildirPath != existingAcc.MaildirPath {
+		if config.MaildirPath == "" {
+			return nil, fmt.Errorf("the Maildir path can't be cleared, the account's messages are stored there")
+		}
+		if err := maildir.Tree(config.MaildirPath).Check(); err != nil {
+			return nil, fmt.Errorf("move the Maildir tree to the new path first: %w", err)
+		}
+	}
+
 	// Validate folder mappings if any are set
 	folderPaths := map[string]string{
 		"sent":    config.SentFolderPath,
@@ -111,6 +134,15 @@ func (a *App) UpdateAccount(id string, config account.AccountConfig) (*account.A
 		}
 	}
 
+	// Reconnect JMAP with the new settings on next use
+	a.jmapManager.CloseAccount(id)
+
+	if acc.IsLocal() && acc.MaildirPath != "" {
+		if err := maildir.Tree(acc.MaildirPath).Create(); err != nil {
+			log.Warn().Err(err).Str("account_id", id).Msg("Failed to create Maildir tree")
+		}
+	}
+
 	// If sync period changed, cancel any running sync and trigger a new one
 	if syncPeriodChanged && a.syncScheduler != nil {
 		log.Info().
@@ -135,13 +167,14 @@ func (a *App) UpdateAccount(id string, config account.AccountConfig) (*account.A
 func (a *App) RemoveAccount(id string) error {
 	log := logging.WithComponent("app")
 
-	// Stop IDLE for this account
+	// Stop IDLE (or JMAP push) for this account
 	if a.idleManager != nil {
-		a.idleManager.StopAccount(id)
+		a.stopAccountPush(id)
 	}
 
-	// Close any IMAP connections for this account
+	// Close any IMAP connections or JMAP session for this account
 	a.imapPool.CloseAccount(id)
+	a.jmapManager.CloseAccount(id)
 
 	// Delete from database (cascades to folders, messages, etc.)
 	if err := a.accountStore.Delete(id); err != nil {
@@ -174,11 +207,11 @@ func (a *App) SetAccountEnabled(id string, enabled bool) error {
 			// Start IDLE for the account
 			acc, err := a.accountStore.Get(id)
 			if err == nil && acc != nil {
-				a.idleManager.StartAccount(acc.ID, acc.Name)
+				a.startAccountPush(acc)
 			}
 		} else {
 			// Stop IDLE for the account
-			a.idleManager.StopAccount(id)
+			a.stopAccountPush(id)
 		}
 	}
 
@@ -220,6 +253,16 @@ func (a *App) SetDefaultIdentity(accountID, identityID string) error {
 	return a.accountStore.SetDefaultIdentity(accountID, identityID)
 }
 
+// GetAuthMechanisms returns the SASL mechanisms an account can pin instead
+// of letting the strongest one the server offers be chosen
+func (a *App) GetAuthMechanisms() []string {
+	var mechanisms []string
+	for _, m := range auth.Mechanisms() {
+		mechanisms = append(mechanisms, string(m))
+	}
+	return mechanisms
+}
+
 // ============================================================================
 // Connection Testing
 // ============================================================================
@@ -243,6 +286,11 @@ func (a *App) TestConnection(config account.AccountConfig) ConnectionTestResult
 		return ConnectionTestResult{Error: err.Error()}
 	}
 
+	// Local folders have no server; only the Maildir tree can be checked
+	if config.Protocol == account.ProtocolLocal {
+		return a.testMaildirTree(config)
+	}
+
 	// For OAuth2 accounts, skip login test during account creation
 	if config.AuthType == account.AuthOAuth2 {
 		log.Info().
@@ -252,6 +300,13 @@ func (a *App) TestConnection(config account.AccountConfig) ConnectionTestResult
 		return ConnectionTestResult{Success: true}
 	}
 
+	if config.Protocol == account.ProtocolJMAP {
+		return a.testJMAPConnection(config)
+	}
+	if config.Protocol == account.ProtocolPOP3 {
+		return a.testPOP3Connection(config)
+	}
+
 	// Create a temporary IMAP client to test connection
 	clientConfig := imap.DefaultConfig()
 	clientConfig.Host = config.IMAPHost
@@ -260,7 +315,12 @@ func (a *App) TestConnection(config account.AccountConfig) ConnectionTestResult
 	clientConfig.Username = config.Username
 	clientConfig.Password = config.Password
 	clientConfig.AuthType = imap.AuthTypePassword
-	clientConfig.TLSConfig = certificate.BuildTLSConfig(config.IMAPHost, a.certStore)
+	clientConfig.AuthMechanism = auth.Mechanism(config.AuthMechanism)
+	tlsConfig, err := certificate.BuildClientTLSConfig(config.IMAPHost, a.certStore, a.credStore, config.ClientCertID)
+	if err != nil {
+		return ConnectionTestResult{Error: err.Error()}
+	}
+	clientConfig.TLSConfig = tlsConfig
 
 	client := imap.NewClient(clientConfig)
 
@@ -285,3 +345,117 @@ func (a *App) TestConnection(config account.AccountConfig) ConnectionTestResult
 	log.Info().Str("host", config.IMAPHost).Msg("Connection test successful")
 	return ConnectionTestResult{Success: true}
 }
+
+// testJMAPConnection fetches the JMAP session resource, which requires
+// valid credentials, and checks the server offers mail
+func (a *App) testJMAPConnection(config account.AccountConfig) ConnectionTestResult {
+	log := logging.WithComponent("app")
+
+	clientConfig := jmap.DefaultConfig()
+	clientConfig.SessionURL = config.JMAPSessionURL
+	clientConfig.Username = config.Username
+	clientConfig.Password = config.Password
+	tlsConfig, err := jmapTLSConfig(a.certStore, a.credStore, config.ClientCertID)
+	if err != nil {
+		return ConnectionTestResult{Error: err.Error()}
+	}
+	clientConfig.TLSConfig = tlsConfig
+
+	client := jmap.NewClient(clientConfig)
+	if _, err := client.Connect(a.ctx); err != nil {
+		var certErr *certificate.Error
+		if errors.As(err, &certErr) {
+			return ConnectionTestResult{
+				CertificateRequired: true,
+				Certificate:         certErr.Info,
+			}
+		}
+		log.Error().Err(err).Msg("JMAP connection test failed")
+		return ConnectionTestResult{Error: fmt.Sprintf("failed to connect: %v", err)}
+	}
+
+	log.Info().Str("sessionURL", config.JMAPSessionURL).Msg("JMAP connection test successful")
+	return ConnectionTestResult{Success: true}
+}
+
+// testPOP3Connection logs in to the POP3 server and lists the maildrop
+func (a *App) testPOP3Connection(config account.AccountConfig) ConnectionTestResult {
+	log := logging.WithComponent("app")
+
+	clientConfig := pop3.DefaultConfig()
+	clientConfig.Host = config.POP3Host
+	clientConfig.Port = config.POP3Port
+	clientConfig.Security = pop3.SecurityType(config.POP3Security)
+	clientConfig.Username = config.Username
+	clientConfig.Password = config.Password
+	clientConfig.AuthType = pop3.AuthTypePassword
+	if config.POP3APOP {
+		clientConfig.AuthType = pop3.AuthTypeAPOP
+	}
+	tlsConfig, err := certificate.BuildClientTLSConfig(config.POP3Host, a.certStore, a.credStore, config.ClientCertID)
+	if err != nil {
+		return ConnectionTestResult{Error: err.Error()}
+	}
+	clientConfig.TLSConfig = tlsConfig
+
+	client := pop3.NewClient(clientConfig)
+
+	if err := client.Connect(); err != nil {
+		var certErr *certificate.Error
+		if errors.As(err, &certErr) {
+			return ConnectionTestResult{
+				CertificateRequired: true,
+				Certificate:         certErr.Info,
+			}
+		}
+		log.Error().Err(err).Msg("POP3 connection test failed")
+		return ConnectionTestResult{Error: fmt.Sprintf("failed to connect: %v", err)}
+	}
+	defer client.Close()
+
+	if err := client.Login(); err != nil {
+		log.Error().Err(err).Msg("POP3 login test failed")
+		return ConnectionTestResult{Error: fmt.Sprintf("failed to login: %v", err)}
+	}
+
+	if _, err := client.ListMessages(); err != nil {
+		log.Error().Err(err).Msg("POP3 listing test failed")
+		return ConnectionTestResult{Error: fmt.Sprintf("failed to list messages: %v", err)}
+	}
+
+	log.Info().Str("host", config.POP3Host).Msg("POP3 connection test successful")
+	return ConnectionTestResult{Success: true}
+}
+
+// testMaildirTree checks that the Maildir tree of a local folders account
+// can be created and written to
+func (a *App) testMaildirTree(config account.AccountConfig) ConnectionTestResult {
+	log := logging.WithComponent("app")
+
+	if config.MaildirPath == "" {
+		return ConnectionTestResult{Success: true}
+	}
+
+	tree := maildir.Tree(config.MaildirPath)
+	if err := tree.Create(); err != nil {
+		log.Error().Err(err).Msg("Maildir tree test failed")
+		return ConnectionTestResult{Error: err.Error()}
+	}
+
+	probe, err := os.CreateTemp(config.MaildirPath, ".aerion-test-*")
+	if err != nil {
+		log.Error().Err(err).Msg("Maildir tree test failed")
+		return ConnectionTestResult{Error: fmt.Sprintf("Maildir tree is not writable: %v", err)}
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	folders, err := tree.Folders()
+	if err != nil {
+		log.Error().Err(err).Msg("Maildir tree test failed")
+		return ConnectionTestResult{Error: err.Error()}
+	}
+
+	log.Info().Str("path", config.MaildirPath).Int("folders", len(folders)).Msg("Maildir tree test successful")
+	return ConnectionTestResult{Success: true}
+}
@@ -1,15 +1,16 @@
 package app
 
 import (
-	"context"
 	"fmt"
 	"time"
 
 	goImap "github.com/emersion/go-imap/v2"
 	"github.com/hkdb/aerion/internal/folder"
 	"github.com/hkdb/aerion/internal/imap"
+	"github.com/hkdb/aerion/internal/jmap"
 	"github.com/hkdb/aerion/internal/logging"
 	"github.com/hkdb/aerion/internal/message"
+	"github.com/hkdb/aerion/internal/pendingop"
 	"github.com/hkdb/aerion/internal/undo"
 	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
@@ -20,6 +21,13 @@ import (
 func (a *App) withIMAPRetry(accountID string, op func(conn *imap.Client) error) error {
 	log := logging.WithComponent("app.imapRetry")
 
+	if a.isJMAPAccount(accountID) {
+		return errJMAPUnsupported
+	}
+	if a.isLocalAccount(accountID) {
+		return errLocalUnsupported
+	}
+
 	poolConn, err := a.imapPool.GetConnection(a.ctx, accountID)
 	if err != nil {
 		return fmt.Errorf("failed to get IMAP connection: %w", err)
@@ -88,8 +96,6 @@ func (a *App) MarkAsUnread(messageIDs []string) error {
 }
 
 func (a *App) setReadStatus(messageIDs []string, isRead bool) error {
-	log := logging.WithComponent("app")
-
 	if len(messageIDs) == 0 {
 		return nil
 	}
@@ -110,64 +116,22 @@ func (a *App) setReadStatus(messageIDs []string, isRead bool) error {
 	}
 
 	// Update local DB first (local-first)
-	isReadPtr := &isRead
-	if err := a.messageStore.UpdateFlagsBatch(messageIDs, isReadPtr, nil); err != nil {
-		return fmt.Errorf("failed to update local flags: %w", err)
+	if err := a.setFlagLocal(messageIDs, byFolder, "read", isRead); err != nil {
+		return err
 	}
 
-	// Emit event for UI update with flag state
-	wailsRuntime.EventsEmit(a.ctx, "messages:flagsChanged", map[string]interface{}{
-		"messageIds": messageIDs,
-		"isRead":     isRead,
-	})
-
-	// Update folder unread counts in background to avoid blocking other DB operations
-	go func() {
-		folderCounts := make(map[string]int)
-		for folderID := range byFolder {
-			unreadCount, err := a.messageStore.CountUnreadByFolder(folderID)
-			if err != nil {
-				log.Error().Err(err).Str("folderID", folderID).Msg("Failed to count unread messages")
-				continue
-			}
-			folderObj, err := a.folderStore.Get(folderID)
-			if err != nil || folderObj == nil {
-				log.Error().Err(err).Str("folderID", folderID).Msg("Failed to get folder")
-				continue
-			}
-			if err := a.folderStore.UpdateCounts(folderID, folderObj.TotalCount, unreadCount); err != nil {
-				log.Error().Err(err).Str("folderID", folderID).Msg("Failed to update folder counts")
-				continue
-			}
-			folderCounts[folderID] = unreadCount
-		}
-		if len(folderCounts) > 0 {
-			wailsRuntime.EventsEmit(a.ctx, "folders:countsChanged", folderCounts)
-		}
-	}()
-
 	// Sync to IMAP in background with retry
 	go func() {
 		for folderID, msgs := range byFolder {
-			var err error
-			for attempt := 1; attempt <= 3; attempt++ {
-				err = a.syncFlagsToIMAP(msgs, folderID, "read", isRead)
-				if err == nil {
-					break
-				}
-				log.Warn().Err(err).Int("attempt", attempt).Str("folderID", folderID).Msg("Failed to sync read flags to IMAP, retrying...")
-				time.Sleep(time.Duration(attempt) * time.Second)
-			}
-			if err != nil {
-				log.Error().Err(err).Str("folderID", folderID).Msg("Failed to sync read flags to IMAP after 3 attempts")
-			}
+			a.syncFlagsWithRetry(msgs, folderID, "read", isRead)
 		}
 	}()
 
-	// Create undo command
+	// Create undo command (undo replays IMAP commands, so not on JMAP or
+	// local accounts)
 	firstMsg := messages[0]
 	folderObj, _ := a.folderStore.Get(firstMsg.FolderID)
-	if folderObj != nil {
+	if folderObj != nil && !a.isJMAPAccount(firstMsg.AccountID) && !a.isLocalAccount(firstMsg.AccountID) {
 		uids := make([]uint32, len(messages))
 		for i, m := range messages {
 			uids[i] = m.UID
@@ -206,8 +170,6 @@ func (a *App) Unstar(messageIDs []string) error {
 }
 
 func (a *App) setStarredStatus(messageIDs []string, isStarred bool) error {
-	log := logging.WithComponent("app")
-
 	if len(messageIDs) == 0 {
 		return nil
 	}
@@ -226,35 +188,22 @@ func (a *App) setStarredStatus(messageIDs []string, isStarred bool) error {
 	}
 
 	// Update local DB first
-	isStarredPtr := &isStarred
-	if err := a.messageStore.UpdateFlagsBatch(messageIDs, nil, isStarredPtr); err != nil {
-		return fmt.Errorf("failed to update local flags: %w", err)
+	if err := a.setFlagLocal(messageIDs, byFolder, "starred", isStarred); err != nil {
+		return err
 	}
 
-	wailsRuntime.EventsEmit(a.ctx, "messages:flagsChanged", messageIDs)
-
 	// Sync to IMAP in background with retry
 	go func() {
 		for folderID, msgs := range byFolder {
-			var err error
-			for attempt := 1; attempt <= 3; attempt++ {
-				err = a.syncFlagsToIMAP(msgs, folderID, "starred", isStarred)
-				if err == nil {
-					break
-				}
-				log.Warn().Err(err).Int("attempt", attempt).Str("folderID", folderID).Msg("Failed to sync starred flags to IMAP, retrying...")
-				time.Sleep(time.Duration(attempt) * time.Second)
-			}
-			if err != nil {
-				log.Error().Err(err).Str("folderID", folderID).Msg("Failed to sync starred flags to IMAP after 3 attempts")
-			}
+			a.syncFlagsWithRetry(msgs, folderID, "starred", isStarred)
 		}
 	}()
 
-	// Create undo command
+	// Create undo command (undo replays IMAP commands, so not on JMAP or
+	// local accounts)
 	firstMsg := messages[0]
 	folderObj, _ := a.folderStore.Get(firstMsg.FolderID)
-	if folderObj != nil {
+	if folderObj != nil && !a.isJMAPAccount(firstMsg.AccountID) && !a.isLocalAccount(firstMsg.AccountID) {
 		uids := make([]uint32, len(messages))
 		for i, m := range messages {
 			uids[i] = m.UID
@@ -282,6 +231,102 @@ func (a *App) setStarredStatus(messageIDs []string, isStarred bool) error {
 	return nil
 }
 
+// setFlagLocal sets the read or starred flag in the database and tells the
+// frontend. It leaves the server and the undo stack alone.
+func (a *App) setFlagLocal(messageIDs []string, byFolder map[string][]*message.Message, flagType string, flagValue bool) error {
+	if flagType == "starred" {
+		if err := a.messageStore.UpdateFlagsBatch(messageIDs, nil, &flagValue); err != nil {
+			return fmt.Errorf("failed to update local flags: %w", err)
+		}
+		wailsRuntime.EventsEmit(a.ctx, "messages:flagsChanged", messageIDs)
+		return nil
+	}
+
+	if err := a.messageStore.UpdateFlagsBatch(messageIDs, &flagValue, nil); err != nil {
+		return fmt.Errorf("failed to update local flags: %w", err)
+	}
+
+	// Emit event for UI update with flag state
+	wailsRuntime.EventsEmit(a.ctx, "messages:flagsChanged", map[string]interface{}{
+		"messageIds": messageIDs,
+		"isRead":     flagValue,
+	})
+
+	// Update folder unread counts in background to avoid blocking other DB operations
+	go a.updateUnreadCounts(byFolder)
+	return nil
+}
+
+// updateUnreadCounts recounts the unread messages of each folder in byFolder
+// and sends the new counts to the frontend
+func (a *App) updateUnreadCounts(byFolder map[string][]*message.Message) {
+	log := logging.WithComponent("app")
+
+	folderCounts := make(map[string]int)
+	for folderID := range byFolder {
+		unreadCount, err := a.messageStore.CountUnreadByFolder(folderID)
+		if err != nil {
+			log.Error().Err(err).Str("folderID", folderID).Msg("Failed to count unread messages")
+			continue
+		}
+		folderObj, err := a.folderStore.Get(folderID)
+		if err != nil || folderObj == nil {
+			log.Error().Err(err).Str("folderID", folderID).Msg("Failed to get folder")
+			continue
+		}
+		if err := a.folderStore.UpdateCounts(folderID, folderObj.TotalCount, unreadCount); err != nil {
+			log.Error().Err(err).Str("folderID", folderID).Msg("Failed to update folder counts")
+			continue
+		}
+		folderCounts[folderID] = unreadCount
+	}
+	if len(folderCounts) > 0 {
+		wailsRuntime.EventsEmit(a.ctx, "folders:countsChanged", folderCounts)
+	}
+}
+
+// syncFlagsWithRetry syncs a flag change for one folder to IMAP, retrying
+// transient failures. If the server stays unreachable, the change is queued
+// and replayed once connectivity returns.
+func (a *App) syncFlagsWithRetry(messages []*message.Message, folderID, flagType string, flagValue bool) {
+	log := logging.WithComponent("app")
+
+	opType := pendingop.TypeRemoveFlags
+	if flagValue {
+		opType = pendingop.TypeAddFlags
+	}
+	op := a.newPendingOperation(opType, messages, folderID)
+	if op != nil {
+		op.Flags = []string{string(imapFlagFor(flagType))}
+	}
+
+	_, err := a.runOrQueue(op, func() error {
+		var err error
+		for attempt := 1; attempt <= 3; attempt++ {
+			err = a.syncFlagsToIMAP(messages, folderID, flagType, flagValue)
+			if err == nil {
+				return nil
+			}
+			log.Warn().Err(err).Int("attempt", attempt).Str("flag", flagType).Str("folderID", folderID).Msg("Failed to sync flags to IMAP, retrying...")
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+		return err
+	})
+	if err != nil {
+		log.Error().Err(err).Str("flag", flagType).Str("folderID", folderID).Msg("Failed to sync flags to IMAP after 3 attempts")
+	}
+}
+
+// imapFlagFor maps a flag type used by the frontend to its IMAP flag
+func imapFlagFor(flagType string) goImap.Flag {
+	switch flagType {
+	case "starred":
+		return goImap.FlagFlagged
+	default:
+		return goImap.FlagSeen
+	}
+}
+
 // syncFlagsToIMAP syncs flag changes to IMAP server
 func (a *App) syncFlagsToIMAP(messages []*message.Message, folderID, flagType string, flagValue bool) error {
 	if len(messages) == 0 {
@@ -293,19 +338,25 @@ func (a *App) syncFlagsToIMAP(messages []*message.Message, folderID, flagType st
 		return fmt.Errorf("folder not found: %s", folderID)
 	}
 
+	flag := imapFlagFor(flagType)
+
+	// Local folders have no server copy to update, only Maildir files
+	if a.isLocalAccount(messages[0].AccountID) {
+		ids := make([]string, len(messages))
+		for i, m := range messages {
+			ids[i] = m.ID
+		}
+		return a.syncEngine.WriteMaildirFlags(ids)
+	}
+	if a.isJMAPAccount(messages[0].AccountID) {
+		return a.setJMAPKeywords(messages, []string{jmap.KeywordForFlag(string(flag))}, flagValue)
+	}
+
 	uids := make([]goImap.UID, len(messages))
 	for i, m := range messages {
 		uids[i] = goImap.UID(m.UID)
 	}
 
-	var flag goImap.Flag
-	switch flagType {
-	case "read":
-		flag = goImap.FlagSeen
-	case "starred":
-		flag = goImap.FlagFlagged
-	}
-
 	return a.withIMAPRetry(messages[0].AccountID, func(conn *imap.Client) error {
 		if _, err := conn.SelectMailbox(a.ctx, folderObj.Path); err != nil {
 			return fmt.Errorf("failed to select mailbox: %w", err)
@@ -338,6 +389,9 @@ func (a *App) MoveToFolder(messageIDs []string, destFolderID string) error {
 	if err != nil || destFolder == nil {
 		return fmt.Errorf("destination folder not found: %s", destFolderID)
 	}
+	if destFolder.AccountID != messages[0].AccountID {
+		return a.transferToLocalFolder(messages, destFolder, true)
+	}
 
 	// Group by source folder
 	byFolder := make(map[string][]*message.Message)
@@ -346,7 +400,124 @@ func (a *App) MoveToFolder(messageIDs []string, destFolderID string) error {
 	}
 
 	// Update local DB first
-	if err := a.messageStore.MoveMessages(messageIDs, destFolderID); err != nil {
+	if err := a.moveMessagesLocal(messages[0].AccountID, messageIDs, byFolder, destFolderID); err != nil {
+		return err
+	}
+
+	// Local folders have no server copy, so the local move is the whole move
+	if a.isLocalAccount(messages[0].AccountID) {
+		return nil
+	}
+
+	// Create undo command for each source folder. Destination UIDs are filled
+	// in once the IMAP move reports them.
+	undoCmds := make(map[string]*undo.MoveCommand)
+	for sourceFolderID, msgs := range byFolder {
+		sourceFolder, _ := a.folderStore.Get(sourceFolderID)
+		if sourceFolder == nil || a.isJMAPAccount(msgs[0].AccountID) {
+			continue
+		}
+
+		msgIDs := make([]string, len(msgs))
+		headerIDs := make([]string, len(msgs))
+		for i, m := range msgs {
+			msgIDs[i] = m.ID
+			headerIDs[i] = m.MessageID
+		}
+
+		cmd := undo.NewMoveCommand(
+			a.ctx,
+			a,
+			msgs[0].AccountID,
+			msgIDs,
+			headerIDs,
+			sourceFolderID,
+			sourceFolder.Path,
+			destFolderID,
+			destFolder.Path,
+			fmt.Sprintf("Move to %s", destFolder.Name),
+		)
+		a.undoStack.Push(cmd)
+		undoCmds[sourceFolderID] = cmd
+	}
+
+	// Sync to IMAP in background (MOVE), then sync destination to get correct UIDs.
+	// Use SyncFolder instead of calling SyncMessages/FetchBodiesInBackground directly
+	// so that back-to-back moves to the same folder are serialized — the second call
+	// cancels the first and starts fresh, preventing the first sync from deleting
+	// locally-moved messages whose IMAP COPY hasn't completed yet.
+	go func() {
+		// A move that never ran can't be undone on the server
+		defer func() {
+			for _, cmd := range undoCmds {
+				cmd.Fail(fmt.Errorf("move was not carried out"))
+			}
+		}()
+
+		anyQueued := false
+		for sourceFolderID, msgs := range byFolder {
+			op := a.newPendingOperation(pendingop.TypeMove, msgs, sourceFolderID)
+			if op != nil {
+				op.DestFolderID = destFolder.ID
+				op.DestFolderPath = destFolder.Path
+			}
+
+			var newUIDs []uint32
+			queued, err := a.runOrQueue(op, func() error {
+				var err error
+				newUIDs, err = a.moveMessagesToIMAP(msgs, sourceFolderID, destFolder)
+				return err
+			})
+			cmd := undoCmds[sourceFolderID]
+			if err != nil {
+				log.Error().Err(err).
+					Str("sourceFolderID", sourceFolderID).
+					Str("destFolderID", destFolderID).
+					Msg("Failed to move messages on IMAP")
+				if cmd != nil {
+					cmd.Fail(err)
+				}
+				return
+			}
+			if queued {
+				anyQueued = true
+				if cmd != nil {
+					cmd.Fail(fmt.Errorf("move is queued until the server can be reached"))
+				}
+				continue
+			}
+			if cmd != nil {
+				cmd.SetNewUIDs(newUIDs)
+			}
+		}
+
+		// Sync destination folder so moved messages get correct UIDs (headers + bodies)
+		// and clean up temporary negative-UID rows left by MoveMessages.
+		// If part of the move was queued, this happens after replay instead.
+		if len(messages) > 0 && !anyQueued {
+			a.syncAfterMove(messages[0].AccountID, destFolderID)
+		}
+	}()
+
+	return nil
+}
+
+// moveMessagesLocal moves messages to a folder in the database, or on disk
+// for local folders, and updates the frontend. It leaves the server and the
+// undo stack alone.
+func (a *App) moveMessagesLocal(accountID string, messageIDs []string, byFolder map[string][]*message.Message, destFolderID string) error {
+	log := logging.WithComponent("app")
+
+	var err error
+	if a.isLocalAccount(accountID) {
+		if err := a.syncEngine.MoveMaildirMessages(messageIDs, destFolderID); err != nil {
+			return fmt.Errorf("failed to move Maildir files: %w", err)
+		}
+		err = a.messageStore.MoveMessagesLocal(messageIDs, destFolderID)
+	} else {
+		err = a.messageStore.MoveMessages(messageIDs, destFolderID)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to move messages locally: %w", err)
 	}
 
@@ -403,85 +574,22 @@ func (a *App) MoveToFolder(messageIDs []string, destFolderID string) error {
 		}
 	}()
 
-	// Sync to IMAP in background (COPY + DELETE), then sync destination to get correct UIDs.
-	// Use SyncFolder instead of calling SyncMessages/FetchBodiesInBackground directly
-	// so that back-to-back moves to the same folder are serialized — the second call
-	// cancels the first and starts fresh, preventing the first sync from deleting
-	// locally-moved messages whose IMAP COPY hasn't completed yet.
-	go func() {
-		for sourceFolderID, msgs := range byFolder {
-			if err := a.moveMessagesToIMAP(msgs, sourceFolderID, destFolder); err != nil {
-				log.Error().Err(err).
-					Str("sourceFolderID", sourceFolderID).
-					Str("destFolderID", destFolderID).
-					Msg("Failed to move messages on IMAP")
-				return
-			}
-		}
-
-		// Sync destination folder so moved messages get correct UIDs (headers + bodies).
-		// Clear the debounce timestamp so this request isn't silently dropped.
-		if len(messages) > 0 {
-			accountID := messages[0].AccountID
-			syncKey := accountID + ":" + destFolderID
-			a.syncMu.Lock()
-			delete(a.syncLastRequest, syncKey)
-			a.syncMu.Unlock()
-
-			if err := a.SyncFolder(accountID, destFolderID); err != nil && err != context.Canceled {
-				log.Warn().Err(err).Str("destFolderID", destFolderID).Msg("Failed to sync destination folder after move")
-			}
-
-			// Clean up temporary negative-UID rows left by MoveMessages.
-			// The sync above fetched the real messages with correct UIDs.
-			if err := a.messageStore.DeleteTempUIDs(destFolderID); err != nil {
-				log.Warn().Err(err).Str("destFolderID", destFolderID).Msg("Failed to clean up temp UIDs after move")
-			}
-		}
-	}()
-
-	// Create undo command for each source folder
-	for sourceFolderID, msgs := range byFolder {
-		sourceFolder, _ := a.folderStore.Get(sourceFolderID)
-		if sourceFolder == nil {
-			continue
-		}
-
-		msgIDs := make([]string, len(msgs))
-		uids := make([]uint32, len(msgs))
-		for i, m := range msgs {
-			msgIDs[i] = m.ID
-			uids[i] = m.UID
-		}
-
-		cmd := undo.NewMoveCommand(
-			a.ctx,
-			a,
-			msgs[0].AccountID,
-			msgIDs,
-			uids,
-			sourceFolderID,
-			sourceFolder.Path,
-			destFolderID,
-			destFolder.Path,
-			fmt.Sprintf("Move to %s", destFolder.Name),
-		)
-		a.undoStack.Push(cmd)
-	}
-
 	return nil
 }
 
-func (a *App) moveMessagesToIMAP(messages []*message.Message, sourceFolderID string, destFolder *folder.Folder) error {
+// moveMessagesToIMAP moves messages from one source folder on the server.
+// Returns the destination UIDs in the same order as messages, or nil if the
+// server didn't report them (no UIDPLUS).
+func (a *App) moveMessagesToIMAP(messages []*message.Message, sourceFolderID string, destFolder *folder.Folder) ([]uint32, error) {
 	log := logging.WithComponent("app.moveMessagesToIMAP")
 
 	if len(messages) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	sourceFolder, err := a.folderStore.Get(sourceFolderID)
 	if err != nil || sourceFolder == nil {
-		return fmt.Errorf("source folder not found")
+		return nil, fmt.Errorf("source folder not found")
 	}
 
 	// Collect UIDs for logging
@@ -497,11 +605,21 @@ func (a *App) moveMessagesToIMAP(messages []*message.Message, sourceFolderID str
 		Int("count", len(messages)).
 		Msg("Starting IMAP move operation")
 
+	// JMAP moves by Email ID; the destination sync assigns the UIDs
+	if a.isJMAPAccount(messages[0].AccountID) {
+		if err := a.moveJMAPMessages(messages, sourceFolderID, destFolder.ID); err != nil {
+			log.Error().Err(err).Msg("JMAP move operation failed")
+			return nil, err
+		}
+		return nil, nil
+	}
+
 	uids := make([]goImap.UID, len(messages))
 	for i, m := range messages {
 		uids[i] = goImap.UID(m.UID)
 	}
 
+	var mapping map[goImap.UID]goImap.UID
 	err = a.withIMAPRetry(messages[0].AccountID, func(conn *imap.Client) error {
 		// Select source mailbox
 		log.Debug().Str("mailbox", sourceFolder.Path).Msg("Selecting source mailbox")
@@ -509,17 +627,12 @@ func (a *App) moveMessagesToIMAP(messages []*message.Message, sourceFolderID str
 			return fmt.Errorf("failed to select source mailbox: %w", err)
 		}
 
-		// COPY to destination
-		log.Debug().Str("destMailbox", destFolder.Path).Msg("Copying messages to destination")
-		if _, err := conn.CopyMessages(uids, destFolder.Path); err != nil {
-			return fmt.Errorf("failed to copy messages: %w", err)
-		}
-		log.Debug().Msg("Messages copied successfully")
-
-		// DELETE from source
-		log.Debug().Msg("Deleting messages from source (marking deleted + expunge)")
-		if err := conn.DeleteMessagesByUID(uids); err != nil {
-			return fmt.Errorf("failed to delete messages from source: %w", err)
+		// MOVE to destination (falls back to COPY + DELETE without the MOVE extension)
+		log.Debug().Str("destMailbox", destFolder.Path).Msg("Moving messages to destination")
+		var err error
+		mapping, err = conn.MoveMessages(uids, destFolder.Path)
+		if err != nil {
+			return fmt.Errorf("failed to move messages: %w", err)
 		}
 
 		return nil
@@ -527,16 +640,29 @@ func (a *App) moveMessagesToIMAP(messages []*message.Message, sourceFolderID str
 
 	if err != nil {
 		log.Error().Err(err).Msg("IMAP move operation failed")
-		return err
+		return nil, err
 	}
 
 	log.Info().
 		Str("sourceFolder", sourceFolder.Path).
 		Str("destFolder", destFolder.Path).
 		Int("count", len(messages)).
+		Int("mapped", len(mapping)).
 		Msg("IMAP move operation completed successfully")
 
-	return nil
+	if len(mapping) == 0 {
+		return nil, nil
+	}
+	newUIDs := make([]uint32, len(messages))
+	for i, m := range messages {
+		destUID, ok := mapping[goImap.UID(m.UID)]
+		if !ok {
+			return nil, nil
+		}
+		newUIDs[i] = uint32(destUID)
+	}
+
+	return newUIDs, nil
 }
 
 // CopyToFolder copies messages to a specified folder (keeps original)
@@ -560,6 +686,9 @@ func (a *App) CopyToFolder(messageIDs []string, destFolderID string) error {
 	if err != nil || destFolder == nil {
 		return fmt.Errorf("destination folder not found: %s", destFolderID)
 	}
+	if destFolder.AccountID != messages[0].AccountID {
+		return a.transferToLocalFolder(messages, destFolder, false)
+	}
 
 	// Group by source folder
 	byFolder := make(map[string][]*message.Message)
@@ -567,19 +696,48 @@ func (a *App) CopyToFolder(messageIDs []string, destFolderID string) error {
 		byFolder[m.FolderID] = append(byFolder[m.FolderID], m)
 	}
 
+	// Local folders are copied in the database right away
+	if a.isLocalAccount(messages[0].AccountID) {
+		copyIDs, err := a.messageStore.CopyMessagesLocal(messageIDs, destFolderID)
+		if err != nil {
+			return fmt.Errorf("failed to copy messages locally: %w", err)
+		}
+		if err := a.syncEngine.CopyMaildirMessages(messageIDs, copyIDs); err != nil {
+			return fmt.Errorf("failed to copy Maildir files: %w", err)
+		}
+		a.refreshLocalFolder(messages[0].AccountID, destFolderID)
+		wailsRuntime.EventsEmit(a.ctx, "messages:copied", map[string]interface{}{
+			"messageIds":   messageIDs,
+			"destFolderId": destFolderID,
+		})
+		return nil
+	}
+
 	// Copy on IMAP (no local DB change - messages stay in source folder)
 	go func() {
+		anyQueued := false
 		for sourceFolderID, msgs := range byFolder {
-			if err := a.copyMessagesToIMAP(msgs, sourceFolderID, destFolder); err != nil {
+			op := a.newPendingOperation(pendingop.TypeCopy, msgs, sourceFolderID)
+			if op != nil {
+				op.DestFolderID = destFolder.ID
+				op.DestFolderPath = destFolder.Path
+			}
+
+			queued, err := a.runOrQueue(op, func() error {
+				return a.copyMessagesToIMAP(msgs, sourceFolderID, destFolder)
+			})
+			if err != nil {
 				log.Error().Err(err).
 					Str("sourceFolderID", sourceFolderID).
 					Str("destFolderID", destFolderID).
 					Msg("Failed to copy messages on IMAP")
 			}
+			anyQueued = anyQueued || queued
 		}
 
 		// After copy completes, sync destination folder to fetch new copies
-		if len(messages) > 0 {
+		// (if queued, the destination is synced after replay instead)
+		if len(messages) > 0 && !anyQueued {
 			// Get account sync period (0 means all messages)
 			syncPeriodDays := 30
 			if acc, err := a.accountStore.Get(messages[0].AccountID); err == nil && acc != nil {
@@ -610,6 +768,10 @@ func (a *App) copyMessagesToIMAP(messages []*message.Message, sourceFolderID str
 		return fmt.Errorf("source folder not found")
 	}
 
+	if a.isJMAPAccount(messages[0].AccountID) {
+		return a.copyJMAPMessages(messages, destFolder.ID)
+	}
+
 	uids := make([]goImap.UID, len(messages))
 	for i, m := range messages {
 		uids[i] = goImap.UID(m.UID)
@@ -752,6 +914,13 @@ func (a *App) DeletePermanently(messageIDs []string) error {
 		byFolder[m.FolderID] = append(byFolder[m.FolderID], m)
 	}
 
+	// Local folders kept in a Maildir tree lose the files too
+	if a.isLocalAccount(messages[0].AccountID) {
+		if err := a.syncEngine.RemoveMaildirMessages(messageIDs); err != nil {
+			return fmt.Errorf("failed to delete Maildir files: %w", err)
+		}
+	}
+
 	// Delete from local DB first
 	if err := a.messageStore.DeleteBatch(messageIDs); err != nil {
 		return fmt.Errorf("failed to delete messages locally: %w", err)
@@ -791,7 +960,11 @@ func (a *App) DeletePermanently(messageIDs []string) error {
 	// Delete from IMAP in background
 	go func() {
 		for folderID, msgs := range byFolder {
-			if err := a.deleteMessagesFromIMAP(msgs, folderID); err != nil {
+			op := a.newPendingOperation(pendingop.TypeExpunge, msgs, folderID)
+			_, err := a.runOrQueue(op, func() error {
+				return a.deleteMessagesFromIMAP(msgs, folderID)
+			})
+			if err != nil {
 				log.Error().Err(err).Str("folderID", folderID).Msg("Failed to delete messages from IMAP")
 			}
 		}
@@ -813,6 +986,13 @@ func (a *App) deleteMessagesFromIMAP(messages []*message.Message, folderID strin
 		return fmt.Errorf("folder not found")
 	}
 
+	if a.isLocalAccount(messages[0].AccountID) {
+		return nil
+	}
+	if a.isJMAPAccount(messages[0].AccountID) {
+		return a.removeJMAPMessages(messages, folderID)
+	}
+
 	uids := make([]goImap.UID, len(messages))
 	for i, m := range messages {
 		uids[i] = goImap.UID(m.UID)
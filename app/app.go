@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/hkdb/aerion/internal/account"
+	"github.com/hkdb/aerion/internal/auth"
 	"github.com/hkdb/aerion/internal/appstate"
 	"github.com/hkdb/aerion/internal/carddav"
 	"github.com/hkdb/aerion/internal/certificate"
@@ -19,15 +20,23 @@ import (
 	"github.com/hkdb/aerion/internal/folder"
 	"github.com/hkdb/aerion/internal/imap"
 	"github.com/hkdb/aerion/internal/ipc"
+	"github.com/hkdb/aerion/internal/jmap"
 	"github.com/hkdb/aerion/internal/logging"
+	"github.com/hkdb/aerion/internal/mailmerge"
 	"github.com/hkdb/aerion/internal/message"
 	"github.com/hkdb/aerion/internal/notification"
 	"github.com/hkdb/aerion/internal/oauth2"
+	"github.com/hkdb/aerion/internal/outbox"
+	"github.com/hkdb/aerion/internal/pendingop"
 	"github.com/hkdb/aerion/internal/platform"
+	"github.com/hkdb/aerion/internal/send"
 	"github.com/hkdb/aerion/internal/settings"
 	"github.com/hkdb/aerion/internal/pgp"
+	"github.com/hkdb/aerion/internal/pop3"
+	"github.com/hkdb/aerion/internal/rules"
 	"github.com/hkdb/aerion/internal/smime"
 	"github.com/hkdb/aerion/internal/sync"
+	"github.com/hkdb/aerion/internal/template"
 	"github.com/hkdb/aerion/internal/undo"
 	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
@@ -58,6 +67,11 @@ type App struct {
 	attachmentStore     *message.AttachmentStore
 	contactStore        *contact.Store
 	draftStore          *draft.Store
+	pendingOpStore      *pendingop.Store
+	outboxStore         *outbox.Store
+	rulesStore          *rules.Store
+	templateStore       *template.Store
+	mailMergeStore      *mailmerge.Store
 	settingsStore       *settings.Store
 	appStateStore       *appstate.Store
 	imageAllowlistStore *settings.ImageAllowlistStore
@@ -66,16 +80,32 @@ type App struct {
 	imapPool   *imap.Pool
 	syncEngine *sync.Engine
 
+	// JMAP accounts
+	jmapManager *jmap.Manager
+	jmapStore   *jmap.Store
+
+	// Downloaded UIDLs of POP3 accounts
+	pop3Store *pop3.Store
+
 	// Background sync (polling + IDLE)
 	syncScheduler *sync.Scheduler
 	idleManager   *imap.IdleManager
 
+	// EventSource push listeners of JMAP accounts, keyed by account ID
+	jmapPush   map[string]context.CancelFunc
+	jmapPushMu goSync.Mutex
+
 	// Credentials (keyring with fallback)
 	credStore *credentials.Store
 
 	// Certificate trust store (TOFU)
 	certStore *certificate.Store
 
+	// Send pipeline and outbox background delivery
+	sendService     *send.Service
+	outboxSender    *outbox.Sender
+	mailMergeRunner *mailmerge.Runner
+
 	// CardDAV
 	carddavStore     *carddav.Store
 	carddavSyncer    *carddav.Syncer
@@ -130,6 +160,12 @@ type App struct {
 	wakeSyncing     bool                          // guards syncAfterWake against concurrent calls
 	syncMu          goSync.Mutex                  // protects sync maps
 
+	// Serializes replay of the offline operation queue
+	pendingReplayMu goSync.Mutex
+
+	// Serializes rule runs so new mail is filtered exactly once
+	rulesMu goSync.Mutex
+
 	// Sleep/wake detection for auto-sync on wake
 	sleepWakeMonitor platform.SleepWakeMonitor
 
@@ -231,6 +267,13 @@ func (a *App) Startup(ctx context.Context) {
 	a.attachmentStore = message.NewAttachmentStore(db)
 	a.contactStore = contact.NewStore(db.DB)
 	a.draftStore = draft.NewStore(db)
+	a.pendingOpStore = pendingop.NewStore(db)
+	a.outboxStore = outbox.NewStore(db)
+	a.rulesStore = rules.NewStore(db)
+	a.templateStore = template.NewStore(db)
+	a.mailMergeStore = mailmerge.NewStore(db)
+	a.jmapStore = jmap.NewStore(db)
+	a.pop3Store = pop3.NewStore(db)
 	a.settingsStore = settings.NewStore(db)
 	a.appStateStore = appstate.NewStore(db.DB)
 	a.imageAllowlistStore = settings.NewImageAllowlistStore(db)
@@ -283,6 +326,14 @@ func (a *App) Startup(ctx context.Context) {
 	a.syncEngine.SetSMIMEVerifier(a.smimeVerifier)
 	a.syncEngine.SetPGPVerifier(a.pgpVerifier)
 
+	// JMAP accounts sync through a shared client per account
+	a.jmapManager = jmap.NewManager(a.getJMAPConfig)
+	a.jmapPush = make(map[string]context.CancelFunc)
+	a.syncEngine.SetJMAP(a.jmapManager, a.jmapStore)
+
+	// POP3 accounts download into local folders
+	a.syncEngine.SetPOP3(a.pop3Store, a.getPOP3Config)
+
 	// Set up sync progress callback to emit events to frontend
 	a.syncEngine.SetProgressCallback(func(progress sync.SyncProgress) {
 		wailsRuntime.EventsEmit(ctx, "sync:progress", map[string]interface{}{
@@ -301,7 +352,7 @@ func (a *App) Startup(ctx context.Context) {
 	go a.db.StartCheckpointRoutine(ctx)
 
 	// Initialize CardDAV syncer and scheduler
-	a.carddavSyncer = carddav.NewSyncer(a.carddavStore, a.credStore)
+	a.carddavSyncer = carddav.NewSyncer(a.carddavStore, a.credStore, a.certStore)
 	a.carddavScheduler = carddav.NewScheduler(a.carddavSyncer, a.carddavStore)
 
 	// Wire up network connectivity check so CardDAV scheduler skips ticks when offline
@@ -348,6 +399,9 @@ func (a *App) Startup(ctx context.Context) {
 	// Initialize undo stack (max 50 commands, 30 second timeout)
 	a.undoStack = undo.NewStack(50, 30*time.Second)
 
+	// Load OAuth providers added by the administrator or user
+	oauth2.LoadProviderFiles(a.paths.OAuthProviderPaths()...)
+
 	// Initialize OAuth2 manager for token refresh
 	a.oauth2Manager = oauth2.NewManager()
 
@@ -365,6 +419,11 @@ func (a *App) Startup(ctx context.Context) {
 	// Initialize and start background email sync (polling + IDLE)
 	a.initBackgroundSync(ctx)
 
+	// Start delivering queued, scheduled and retrying outgoing messages
+	a.initSendService()
+	a.initOutboxSender(ctx)
+	a.initMailMergeRunner()
+
 	// Sync any pending drafts from previous sessions
 	go a.syncAllPendingDrafts()
 
@@ -375,6 +434,9 @@ func (a *App) Startup(ctx context.Context) {
 	a.syncContexts = make(map[string]context.CancelFunc)
 	a.syncLastRequest = make(map[string]time.Time)
 
+	// Replay IMAP operations queued while offline in a previous session
+	go a.replayPendingOperations()
+
 	// Initialize desktop notifications with click handling
 	a.initNotifications(ctx)
 
@@ -576,6 +638,11 @@ func (a *App) Shutdown(ctx context.Context) {
 		log.Info().Msg("IDLE manager stopped")
 	}
 
+	// Stop JMAP push
+	if a.jmapPush != nil {
+		a.stopAllJMAPPush()
+	}
+
 	// Stop sleep/wake monitor
 	if a.sleepWakeMonitor != nil {
 		a.sleepWakeMonitor.Stop()
@@ -600,6 +667,18 @@ func (a *App) Shutdown(ctx context.Context) {
 		log.Info().Msg("Notification listener stopped")
 	}
 
+	// Stop outbox sender (waits for an in-flight delivery)
+	if a.outboxSender != nil {
+		a.outboxSender.Stop()
+		log.Info().Msg("Outbox sender stopped")
+	}
+
+	// Stop mail merge jobs (they resume on next start)
+	if a.mailMergeRunner != nil {
+		a.mailMergeRunner.Stop()
+		log.Info().Msg("Mail merge runner stopped")
+	}
+
 	// Stop CardDAV scheduler
 	if a.carddavScheduler != nil {
 		a.carddavScheduler.Stop()
@@ -659,7 +738,13 @@ func (a *App) getIMAPCredentials(accountID string) (*imap.ClientConfig, error) {
 	config.Port = acc.IMAPPort
 	config.Security = imap.SecurityType(acc.IMAPSecurity)
 	config.Username = acc.Username
-	config.TLSConfig = certificate.BuildTLSConfig(acc.IMAPHost, a.certStore)
+	tlsConfig, err := certificate.BuildClientTLSConfig(acc.IMAPHost, a.certStore, a.credStore, acc.ClientCertID)
+	if err != nil {
+		return nil, err
+	}
+	config.TLSConfig = tlsConfig
+	config.Compress = acc.IMAPCompress
+	config.AuthMechanism = auth.Mechanism(acc.AuthMechanism)
 
 	// Handle authentication based on auth type
 	if acc.AuthType == account.AuthOAuth2 {
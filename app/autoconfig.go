@@ -0,0 +1,117 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hkdb/aerion/internal/account"
+	"github.com/hkdb/aerion/internal/autoconfig"
+	"github.com/hkdb/aerion/internal/logging"
+)
+
+// autoconfigTimeout bounds the whole lookup, probes excluded
+const autoconfigTimeout = 20 * time.Second
+
+// autoconfigMaxProbes is how many candidates are tried with TestConnection
+// before giving up and returning the best one
+const autoconfigMaxProbes = 3
+
+// AutoconfigResult is the configuration found for an email address
+type AutoconfigResult struct {
+	Config        account.AccountConfig  `json:"config"`                  // Best candidate, probed if possible
+	Source        autoconfig.Source      `json:"source"`                  // Where Config came from
+	OAuthProvider string                 `json:"oauthProvider,omitempty"` // Sign in with this provider instead of a password
+	Probe         ConnectionTestResult   `json:"probe"`                   // TestConnection result for Config, if Probed
+	Probed        bool                   `json:"probed"`                  // False if no candidate was tested
+	NeedsConfirm  bool                   `json:"needsConfirm"`            // Config is unverified and wasn't tested with the password
+	Candidates    []autoconfig.Candidate `json:"candidates"`              // All candidates, best first
+}
+
+// AutoconfigureAccount looks up the server settings for an email address
+// and tests them. Candidates are tried best first until one connects; if
+// none does, the best is returned with its failed probe. Without a password
+// the settings are returned unprobed. Unverified candidates (plain HTTP
+// autoconfig, guessed hosts) only get the password once the user confirmed
+// them and calls again with confirmUnverified set.
+func (a *App) AutoconfigureAccount(email, displayName, password string, confirmUnverified bool) (*AutoconfigResult, error) {
+	log := logging.WithComponent("app")
+
+	ctx, cancel := context.WithTimeout(a.ctx, autoconfigTimeout)
+	defer cancel()
+
+	candidates, err := autoconfig.Discover(ctx, email)
+	if err != nil {
+		if errors.Is(err, autoconfig.ErrNotFound) || errors.Is(err, autoconfig.ErrInvalidEmail) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to look up account settings: %w", err)
+	}
+
+	if displayName == "" {
+		displayName, _, _ = strings.Cut(email, "@")
+	}
+	for i := range candidates {
+		candidates[i].Config.DisplayName = displayName
+		candidates[i].Config.Password = password
+	}
+
+	result := &AutoconfigResult{Candidates: candidates}
+	use := func(c autoconfig.Candidate) {
+		result.Config = c.Config
+		result.Source = c.Source
+		result.OAuthProvider = c.OAuthProvider
+	}
+	use(candidates[0])
+
+	result.NeedsConfirm = candidates[0].Unverified && !confirmUnverified
+
+	// OAuth candidates are tested after sign-in, the rest need a password
+	if password == "" {
+		return result, nil
+	}
+
+	probes := 0
+	for i, c := range candidates {
+		if probes == autoconfigMaxProbes {
+			break
+		}
+		if c.Unverified && !confirmUnverified {
+			continue
+		}
+		probe := a.TestConnection(c.Config)
+		probes++
+		if i == 0 {
+			result.Probed = true
+			result.Probe = probe
+		}
+		// A certificate the user hasn't accepted isn't a wrong setting
+		if probe.Success || probe.CertificateRequired {
+			use(c)
+			result.Probed = true
+			result.Probe = probe
+			result.NeedsConfirm = false
+			break
+		}
+		log.Debug().
+			Str("source", string(c.Source)).
+			Str("error", probe.Error).
+			Msg("Autoconfig candidate failed probe")
+	}
+
+	// Never hand the password back to the frontend
+	result.Config.Password = ""
+	for i := range result.Candidates {
+		result.Candidates[i].Config.Password = ""
+	}
+
+	log.Info().
+		Str("source", string(result.Source)).
+		Bool("success", result.Probe.Success).
+		Int("candidates", len(candidates)).
+		Msg("Account autoconfiguration finished")
+
+	return result, nil
+}
@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/hkdb/aerion/internal/folder"
@@ -65,6 +66,9 @@ func (a *App) initBackgroundSync(ctx context.Context) {
 		a.idleManager.SetConnectivityCheck(a.networkMonitor.IsConnected)
 	}
 
+	// Watch user-selected folders in addition to INBOX
+	a.idleManager.SetWatchedFoldersFunc(a.getWatchedFolderPaths)
+
 	a.idleManager.Start(ctx)
 
 	// Start IDLE for all enabled accounts if online.
@@ -76,7 +80,7 @@ func (a *App) initBackgroundSync(ctx context.Context) {
 		} else {
 			for _, acc := range accounts {
 				if acc.Enabled {
-					a.idleManager.StartAccount(acc.ID, acc.Name)
+					a.startAccountPush(acc)
 				}
 			}
 		}
@@ -88,6 +92,17 @@ func (a *App) initBackgroundSync(ctx context.Context) {
 	log.Info().Msg("IDLE manager started")
 }
 
+// getWatchedFolderPaths returns the folders watched for push updates besides INBOX
+func (a *App) getWatchedFolderPaths(accountID string) []string {
+	paths, err := a.folderStore.ListWatchedPaths(accountID)
+	if err != nil {
+		log := logging.WithComponent("app.idle")
+		log.Warn().Err(err).Str("accountID", accountID).Msg("Failed to list watched folders")
+		return nil
+	}
+	return paths
+}
+
 // processIdleEvents processes mail events from IDLE connections
 func (a *App) processIdleEvents(ctx context.Context) {
 	log := logging.WithComponent("app.idle")
@@ -106,7 +121,7 @@ func (a *App) processIdleEvents(ctx context.Context) {
 
 			switch event.Type {
 			case imap.EventNewMail:
-				// New mail arrived - trigger sync for this account's INBOX
+				// New mail arrived - trigger sync for the folder it arrived in
 				go a.handleIdleNewMail(event)
 
 			case imap.EventExpunge:
@@ -130,20 +145,31 @@ func (a *App) processIdleEvents(ctx context.Context) {
 	}
 }
 
-// handleIdleNewMail handles a new mail event from IDLE
+// handleIdleNewMail handles a new mail event from IDLE/NOTIFY
 func (a *App) handleIdleNewMail(event imap.MailEvent) {
 	log := logging.WithComponent("app.idle")
 
 	log.Info().
 		Str("accountID", event.AccountID).
+		Str("folder", event.Folder).
 		Uint32("count", event.Count).
 		Msg("New mail detected via IDLE, triggering sync")
 
-	// Get the INBOX folder ID for events
-	inbox, _ := a.folderStore.GetByType(event.AccountID, folder.TypeInbox)
+	// Resolve the folder the event is for. Events without a folder (or for
+	// INBOX) go through the scheduler's INBOX sync as before.
+	var target *folder.Folder
+	if event.Folder != "" && !strings.EqualFold(event.Folder, "INBOX") {
+		target, _ = a.folderStore.GetByPath(event.AccountID, event.Folder)
+		if target == nil {
+			log.Warn().Str("folder", event.Folder).Msg("Watched folder not found locally, skipping IDLE sync")
+			return
+		}
+	} else {
+		target, _ = a.folderStore.GetByType(event.AccountID, folder.TypeInbox)
+	}
 	var folderID string
-	if inbox != nil {
-		folderID = inbox.ID
+	if target != nil {
+		folderID = target.ID
 	}
 
 	// Use composite key for sync tracking
@@ -159,7 +185,13 @@ func (a *App) handleIdleNewMail(event imap.MailEvent) {
 	a.syncMu.Unlock()
 
 	// Use the scheduler's blocking sync to get new mail info
-	newMailInfo, err := a.syncScheduler.SyncAccountInboxBlocking(event.AccountID)
+	var newMailInfo *sync.NewMailInfo
+	var err error
+	if target == nil || target.Type == folder.TypeInbox {
+		newMailInfo, err = a.syncScheduler.SyncAccountInboxBlocking(event.AccountID)
+	} else {
+		newMailInfo, err = a.syncScheduler.SyncFolderBlocking(event.AccountID, folderID)
+	}
 
 	if err != nil {
 		log.Error().Err(err).Str("accountID", event.AccountID).Msg("Failed to sync after IDLE notification")
@@ -268,6 +300,9 @@ func (a *App) handleNewMailNotification(info sync.NewMailInfo) {
 		Int("count", info.Count).
 		Msg("New mail notification")
 
+	// Filter the new messages first so moves land before the UI refreshes
+	skipNotification := a.applyRulesToNewMail(info)
+
 	// Get the most recent conversation for the notification
 	var subject, fromName, fromEmail, threadID string
 
@@ -298,7 +333,11 @@ func (a *App) handleNewMailNotification(info sync.NewMailInfo) {
 		"fromEmail":   fromEmail,
 	})
 
-	// Send system notification
+	// Send system notification unless rules asked to suppress it
+	if skipNotification {
+		log.Debug().Str("account", info.AccountName).Msg("Notification suppressed by rules")
+		return
+	}
 	a.sendSystemNotification(info, subject, fromName, fromEmail, threadID)
 }
 
@@ -401,7 +440,8 @@ func (a *App) initNetworkMonitor(ctx context.Context) {
 
 // processNetworkEvents handles network connectivity changes:
 // offline → stop IDLE, clear pool, notify frontend
-// online  → clear stale connections, full sync, restart IDLE, notify frontend
+// online  → replay queued operations, retry outbox, clear stale connections, full sync,
+// restart IDLE, notify frontend
 func (a *App) processNetworkEvents(ctx context.Context) {
 	log := logging.WithComponent("app.network")
 
@@ -421,6 +461,12 @@ func (a *App) processNetworkEvents(ctx context.Context) {
 			if event.Connected {
 				log.Info().Msg("Network connectivity restored — starting full sync")
 				wailsRuntime.EventsEmit(a.ctx, "network:online", nil)
+				// Push changes made while offline before syncing, so the
+				// sync doesn't undo them locally
+				a.replayPendingOperations()
+				if a.outboxSender != nil {
+					a.outboxSender.RetryNow()
+				}
 				a.syncAfterWake()
 			} else {
 				log.Info().Msg("Network connectivity lost — stopping IDLE and clearing pool")
@@ -429,6 +475,7 @@ func (a *App) processNetworkEvents(ctx context.Context) {
 				if a.idleManager != nil {
 					a.idleManager.Stop()
 				}
+				a.stopAllJMAPPush()
 				if a.imapPool != nil {
 					a.imapPool.CloseAll()
 				}
@@ -494,6 +541,7 @@ func (a *App) handleSystemSleep() {
 	if a.idleManager != nil {
 		a.idleManager.Stop()
 	}
+	a.stopAllJMAPPush()
 
 	// Close all IMAP pool connections to avoid stale connections on wake
 	if a.imapPool != nil {
@@ -658,7 +706,7 @@ func (a *App) restartIDLE() {
 
 	for _, acc := range accounts {
 		if acc.Enabled {
-			a.idleManager.StartAccount(acc.ID, acc.Name)
+			a.startAccountPush(acc)
 		}
 	}
 	log.Info().Int("accounts", len(accounts)).Msg("IDLE restarted for accounts")
@@ -27,14 +27,23 @@ type LinkedAccountInfo struct {
 // CardDAV Contact Source API - Exposed to frontend via Wails bindings
 // ============================================================================
 
-// DiscoverCardDAVAddressbooks discovers available addressbooks from a CardDAV server
-func (a *App) DiscoverCardDAVAddressbooks(url, username, password string) ([]carddav.AddressbookInfo, error) {
-	return carddav.DiscoverAddressbooks(url, username, password)
+// DiscoverCardDAVAddressbooks discovers available addressbooks from a CardDAV server,
+// presenting the client certificate with clientCertID if it's not empty
+func (a *App) DiscoverCardDAVAddressbooks(url, username, password, clientCertID string) ([]carddav.AddressbookInfo, error) {
+	tlsConfig, err := a.certStore.ClientCertTLSConfig(clientCertID, a.credStore)
+	if err != nil {
+		return nil, err
+	}
+	return carddav.DiscoverAddressbooks(url, username, password, tlsConfig)
 }
 
 // TestCardDAVConnection tests connection to a CardDAV server
-func (a *App) TestCardDAVConnection(url, username, password string) error {
-	return carddav.TestConnection(url, username, password)
+func (a *App) TestCardDAVConnection(url, username, password, clientCertID string) error {
+	tlsConfig, err := a.certStore.ClientCertTLSConfig(clientCertID, a.credStore)
+	if err != nil {
+		return err
+	}
+	return carddav.TestConnection(url, username, password, tlsConfig)
 }
 
 // GetContactSources returns all configured contact sources
@@ -1,8 +1,12 @@
 package app
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/hkdb/aerion/internal/certificate"
 	"github.com/hkdb/aerion/internal/logging"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // ============================================================================
@@ -42,3 +46,91 @@ func (a *App) RemoveTrustedCertificate(fingerprint string) error {
 	log.Info().Str("fingerprint", fingerprint).Msg("Removing trusted certificate")
 	return a.certStore.Remove(fingerprint)
 }
+
+// ============================================================================
+// Client Certificate API - Exposed to frontend via Wails bindings
+// ============================================================================
+
+// PickClientCertificateFile opens a file picker for .p12/.pfx files and returns the path
+func (a *App) PickClientCertificateFile() (string, error) {
+	path, err := wailsRuntime.OpenFileDialog(a.ctx, wailsRuntime.OpenDialogOptions{
+		Title: "Select Client Certificate",
+		Filters: []wailsRuntime.FileFilter{
+			{
+				DisplayName: "PKCS#12 Files (*.p12, *.pfx)",
+				Pattern:     "*.p12;*.pfx",
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to open file dialog: %w", err)
+	}
+	return path, nil
+}
+
+// ImportClientCertificateFromPath imports a TLS client certificate from a
+// PKCS#12 file. Accounts and CardDAV sources present it once they select it.
+func (a *App) ImportClientCertificateFromPath(filePath, password string) (*certificate.ClientCertificate, error) {
+	log := logging.WithComponent("app.certificate")
+
+	if filePath == "" {
+		return nil, fmt.Errorf("no file selected")
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate file: %w", err)
+	}
+
+	privateKeyPEM, certChainPEM, cert, err := certificate.ImportPKCS12(data, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import certificate: %w", err)
+	}
+
+	newID := cert.ID
+	if _, err := a.certStore.SaveClientCertificate(cert, certChainPEM); err != nil {
+		return nil, fmt.Errorf("failed to save certificate: %w", err)
+	}
+
+	// Store the private key securely
+	if err := a.credStore.SetClientCertPrivateKey(cert.ID, privateKeyPEM); err != nil {
+		// Rollback, unless the certificate had been imported before
+		if cert.ID == newID {
+			a.certStore.DeleteClientCertificate(cert.ID)
+		}
+		return nil, fmt.Errorf("failed to store private key: %w", err)
+	}
+
+	log.Info().
+		Str("id", cert.ID).
+		Str("subject", cert.Subject).
+		Msg("Imported client certificate")
+	return cert, nil
+}
+
+// ListClientCertificates returns the imported TLS client certificates
+func (a *App) ListClientCertificates() ([]*certificate.ClientCertificate, error) {
+	certs, err := a.certStore.ListClientCertificates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list client certificates: %w", err)
+	}
+	if certs == nil {
+		return []*certificate.ClientCertificate{}, nil
+	}
+	return certs, nil
+}
+
+// DeleteClientCertificate removes a TLS client certificate and its private
+// key. Accounts and CardDAV sources that presented it stop doing so.
+func (a *App) DeleteClientCertificate(certID string) error {
+	// Delete private key first
+	if err := a.credStore.DeleteClientCertPrivateKey(certID); err != nil {
+		return fmt.Errorf("failed to delete private key: %w", err)
+	}
+
+	if err := a.certStore.DeleteClientCertificate(certID); err != nil {
+		return fmt.Errorf("failed to delete certificate: %w", err)
+	}
+
+	return nil
+}
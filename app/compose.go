@@ -8,13 +8,14 @@ import (
 	"strings"
 	"time"
 
-	goImap "github.com/emersion/go-imap/v2"
 	"github.com/hkdb/aerion/internal/account"
+	"github.com/hkdb/aerion/internal/auth"
 	"github.com/hkdb/aerion/internal/certificate"
 	"github.com/hkdb/aerion/internal/folder"
-	"github.com/hkdb/aerion/internal/imap"
 	"github.com/hkdb/aerion/internal/logging"
 	"github.com/hkdb/aerion/internal/message"
+	"github.com/hkdb/aerion/internal/outbox"
+	"github.com/hkdb/aerion/internal/settings"
 	"github.com/hkdb/aerion/internal/smtp"
 	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
@@ -33,7 +34,29 @@ type ComposerAttachment struct {
 
 // SendMessage sends an email via SMTP.
 // The message is composed in the frontend and sent to the backend.
-func (a *App) SendMessage(accountID string, msg smtp.ComposeMessage) error {
+// With an undo-send delay configured, the message waits in the outbox for the
+// delay and the outbox entry is returned so the frontend can offer undo.
+// Otherwise it's delivered right away: nil is returned once sent, or the
+// queued entry if the server couldn't be reached and it will be retried.
+func (a *App) SendMessage(accountID string, msg smtp.ComposeMessage) (*outbox.Message, error) {
+	delay, err := a.settingsStore.GetUndoSendDelay()
+	if err != nil {
+		delay = settings.DefaultUndoSendDelay
+	}
+	return a.queueMessage(accountID, msg, time.Now().Add(time.Duration(delay)*time.Second), false)
+}
+
+// ScheduleMessage queues a message to be sent at sendAt ("send later")
+func (a *App) ScheduleMessage(accountID string, msg smtp.ComposeMessage, sendAt time.Time) (*outbox.Message, error) {
+	if !sendAt.After(time.Now()) {
+		return nil, fmt.Errorf("send time must be in the future")
+	}
+	return a.queueMessage(accountID, msg, sendAt, true)
+}
+
+// queueMessage builds the final message and adds it to the outbox. Messages
+// that are due now are delivered before returning.
+func (a *App) queueMessage(accountID string, msg smtp.ComposeMessage, sendAt time.Time, scheduled bool) (*outbox.Message, error) {
 	log := logging.WithComponent("app")
 
 	log.Info().
@@ -41,140 +64,57 @@ func (a *App) SendMessage(accountID string, msg smtp.ComposeMessage) error {
 		Str("from", msg.From.Address).
 		Int("toCount", len(msg.To)).
 		Str("subject", msg.Subject).
+		Bool("scheduled", scheduled).
 		Msg("Sending message")
 
-	// Get account
-	acc, err := a.accountStore.Get(accountID)
-	if err != nil {
-		return fmt.Errorf("failed to get account: %w", err)
-	}
-	if acc == nil {
-		return fmt.Errorf("account not found: %s", accountID)
-	}
-
-	// Build RFC822 message
-	rawMsg, err := msg.ToRFC822()
+	m, err := a.sendService.Prepare(accountID, msg, sendAt, scheduled)
 	if err != nil {
-		return fmt.Errorf("failed to build message: %w", err)
-	}
-
-	// The sender's email determines which cert/key to use
-	fromEmail := msg.From.Address
-
-	// S/MIME signing (if configured for this account/message)
-	if a.shouldSignMessage(accountID, msg.SignMessage) {
-		signedMsg, signErr := a.smimeSigner.SignMessage(accountID, fromEmail, rawMsg)
-		if signErr != nil {
-			return fmt.Errorf("failed to sign message: %w", signErr)
-		}
-		rawMsg = signedMsg
-		log.Info().Str("accountID", accountID).Msg("Message signed with S/MIME")
-	}
-
-	// S/MIME encryption (if configured for this account/message) — sign-then-encrypt per RFC 5751
-	if a.shouldEncryptMessage(accountID, msg.EncryptMessage) {
-		encryptedMsg, encErr := a.smimeEncryptor.EncryptMessage(accountID, fromEmail, msg.AllRecipients(), rawMsg)
-		if encErr != nil {
-			return fmt.Errorf("failed to encrypt message: %w", encErr)
-		}
-		rawMsg = encryptedMsg
-		log.Info().Str("accountID", accountID).Msg("Message encrypted with S/MIME")
-	}
-
-	// PGP signing (mutually exclusive with S/MIME — only if S/MIME sign was not applied)
-	if !msg.SignMessage && a.shouldPGPSignMessage(accountID, msg.PGPSignMessage) {
-		signedMsg, signErr := a.pgpSigner.SignMessage(accountID, fromEmail, rawMsg)
-		if signErr != nil {
-			return fmt.Errorf("failed to PGP sign message: %w", signErr)
-		}
-		rawMsg = signedMsg
-		log.Info().Str("accountID", accountID).Msg("Message signed with PGP")
+		return nil, err
 	}
 
-	// PGP encryption (mutually exclusive with S/MIME — only if S/MIME encrypt was not applied)
-	if !msg.EncryptMessage && a.shouldPGPEncryptMessage(accountID, msg.PGPEncryptMessage) {
-		encryptedMsg, encErr := a.pgpEncryptor.EncryptMessage(accountID, fromEmail, msg.AllRecipients(), rawMsg)
-		if encErr != nil {
-			return fmt.Errorf("failed to PGP encrypt message: %w", encErr)
-		}
-		rawMsg = encryptedMsg
-		log.Info().Str("accountID", accountID).Msg("Message encrypted with PGP")
-	}
-
-	// Create SMTP client config
-	smtpConfig := smtp.DefaultConfig()
-	smtpConfig.Host = acc.SMTPHost
-	smtpConfig.Port = acc.SMTPPort
-	smtpConfig.Security = smtp.SecurityType(acc.SMTPSecurity)
-	smtpConfig.Username = acc.Username
-	smtpConfig.TLSConfig = certificate.BuildTLSConfig(acc.SMTPHost, a.certStore)
-
-	// Handle authentication based on auth type
-	if acc.AuthType == account.AuthOAuth2 {
-		// Get valid OAuth token (refreshing if needed)
-		tokens, err := a.getValidOAuthToken(accountID)
-		if err != nil {
-			return fmt.Errorf("failed to get OAuth token: %w", err)
-		}
-		smtpConfig.AuthType = smtp.AuthTypeOAuth2
-		smtpConfig.AccessToken = tokens.AccessToken
-	} else {
-		// Default to password authentication
-		password, err := a.credStore.GetPassword(accountID)
-		if err != nil {
-			return fmt.Errorf("failed to get password: %w", err)
-		}
-		smtpConfig.AuthType = smtp.AuthTypePassword
-		smtpConfig.Password = password
-	}
+	// Add recipients to local contacts
+	a.sendService.HarvestContacts(msg)
 
-	client := smtp.NewClient(smtpConfig)
+	return a.submitOutboxMessage(m)
+}
 
-	// Connect
-	if err := client.Connect(); err != nil {
-		return fmt.Errorf("failed to connect to SMTP server: %w", err)
-	}
-	defer client.Close()
+// submitOutboxMessage adds a prepared message to the outbox. Messages that
+// are due now are delivered before returning: nil is returned once sent, or
+// the queued entry if it will be retried.
+func (a *App) submitOutboxMessage(m *outbox.Message) (*outbox.Message, error) {
+	log := logging.WithComponent("app")
 
-	// Login
-	if err := client.Login(); err != nil {
-		return fmt.Errorf("failed to login to SMTP server: %w", err)
+	// Claim it up front when sending now so the background sender leaves it alone
+	immediate := m.SendAt.Before(time.Now().Add(time.Second))
+	if immediate {
+		m.Status = outbox.StatusSending
 	}
 
-	// Send
-	recipients := msg.AllRecipients()
-	if len(recipients) == 0 {
-		return fmt.Errorf("no recipients specified")
+	if err := a.outboxStore.Create(m); err != nil {
+		return nil, err
 	}
 
-	if err := client.SendMail(msg.From.Address, recipients, rawMsg); err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
+	if !immediate {
+		wailsRuntime.EventsEmit(a.ctx, "outbox:queued", m)
+		a.outboxSender.Wake()
+		return m, nil
 	}
 
-	// Save to Sent folder (using IMAP APPEND) if provider doesn't auto-save
-	if !providerAutoSavesSentMail(acc.IMAPHost) {
-		log.Debug().Str("host", acc.IMAPHost).Msg("Provider doesn't auto-save, using IMAP APPEND")
-		if err := a.saveToSentFolder(accountID, acc, rawMsg); err != nil {
-			log.Warn().Err(err).Msg("Failed to save message to Sent folder")
-			// Don't fail the send operation if saving fails
+	if err := a.outboxSender.Deliver(m); err != nil {
+		if m.Status == outbox.StatusQueued {
+			// Temporary failure: it stays in the outbox and is retried
+			a.emitOutboxChanged(m)
+			return m, nil
 		}
-	} else {
-		log.Debug().Str("host", acc.IMAPHost).Msg("Provider auto-saves sent mail, skipping manual save")
-	}
-
-	// Add recipients to local contacts
-	for _, to := range msg.To {
-		a.contactStore.AddOrUpdate(to.Address, to.Name)
-	}
-	for _, cc := range msg.Cc {
-		a.contactStore.AddOrUpdate(cc.Address, cc.Name)
+		// Rejected: keep the composer open instead of leaving it in the outbox
+		if delErr := a.outboxStore.Delete(m.ID); delErr != nil {
+			log.Warn().Err(delErr).Int64("id", m.ID).Msg("Failed to remove rejected message from outbox")
+		}
+		return nil, err
 	}
 
-	// Sync sent folder to get the sent message
-	go a.syncSentFolder(accountID)
-
-	log.Info().Str("accountID", accountID).Msg("Message sent successfully")
-	return nil
+	log.Info().Str("accountID", m.AccountID).Msg("Message sent successfully")
+	return nil, nil
 }
 
 // syncSentFolder syncs the Sent folder for an account after sending a message
@@ -218,81 +158,9 @@ func (a *App) syncSentFolder(accountID string) error {
 	return nil
 }
 
-// saveToSentFolder appends the sent message to the Sent folder via IMAP
-func (a *App) saveToSentFolder(accountID string, acc *account.Account, rawMsg []byte) error {
-	log := logging.WithComponent("app")
-
-	// Get the Sent folder path (from account mapping or auto-detected)
-	sentFolder, err := a.GetSpecialFolder(accountID, folder.TypeSent)
-	if err != nil || sentFolder == nil {
-		// Fall back to account's configured sent folder path
-		if acc.SentFolderPath == "" {
-			return fmt.Errorf("no Sent folder configured or detected")
-		}
-	}
-
-	sentPath := acc.SentFolderPath
-	if sentPath == "" && sentFolder != nil {
-		sentPath = sentFolder.Path
-	}
-
-	log.Debug().
-		Str("account_id", accountID).
-		Str("sent_path", sentPath).
-		Msg("Saving sent message to folder via IMAP APPEND")
-
-	// Create IMAP client
-	clientConfig := imap.DefaultConfig()
-	clientConfig.Host = acc.IMAPHost
-	clientConfig.Port = acc.IMAPPort
-	clientConfig.Security = imap.SecurityType(acc.IMAPSecurity)
-	clientConfig.Username = acc.Username
-	clientConfig.TLSConfig = certificate.BuildTLSConfig(acc.IMAPHost, a.certStore)
-
-	// Handle authentication based on auth type
-	if acc.AuthType == account.AuthOAuth2 {
-		tokens, err := a.getValidOAuthToken(accountID)
-		if err != nil {
-			return fmt.Errorf("failed to get OAuth token: %w", err)
-		}
-		clientConfig.AuthType = imap.AuthTypeOAuth2
-		clientConfig.AccessToken = tokens.AccessToken
-	} else {
-		password, err := a.credStore.GetPassword(accountID)
-		if err != nil {
-			return fmt.Errorf("failed to get password: %w", err)
-		}
-		clientConfig.AuthType = imap.AuthTypePassword
-		clientConfig.Password = password
-	}
-
-	imapClient := imap.NewClient(clientConfig)
-	if err := imapClient.Connect(); err != nil {
-		return fmt.Errorf("failed to connect to IMAP: %w", err)
-	}
-	defer imapClient.Close()
-
-	if err := imapClient.Login(); err != nil {
-		return fmt.Errorf("failed to login to IMAP: %w", err)
-	}
-
-	// Append message with \Seen flag
-	flags := []goImap.Flag{goImap.FlagSeen}
-	_, err = imapClient.AppendMessage(sentPath, flags, time.Now(), rawMsg)
-	if err != nil {
-		return fmt.Errorf("failed to append to Sent folder: %w", err)
-	}
-
-	log.Info().
-		Str("account_id", accountID).
-		Str("sent_path", sentPath).
-		Msg("Message saved to Sent folder")
-
-	return nil
-}
-
 // PrepareReply prepares a reply message structure from an existing message.
-// mode can be "reply", "reply-all", or "forward"
+// mode can be "reply", "reply-all", "forward", or "forward-attachment",
+// which attaches the original message as is instead of quoting it
 func (a *App) PrepareReply(messageID, mode string) (*smtp.ComposeMessage, error) {
 	log := logging.WithComponent("app")
 	log.Debug().Str("messageID", messageID).Str("mode", mode).Msg("Preparing reply message")
@@ -312,37 +180,13 @@ func (a *App) PrepareReply(messageID, mode string) (*smtp.ComposeMessage, error)
 		return nil, fmt.Errorf("failed to get identities: %w", err)
 	}
 
-	// Find the default identity or first identity
-	var fromIdentity *account.Identity
-	for _, id := range identities {
-		if id.IsDefault {
-			fromIdentity = id
-			break
-		}
-	}
-	if fromIdentity == nil && len(identities) > 0 {
-		fromIdentity = identities[0]
-	}
-	if fromIdentity == nil {
-		acc, _ := a.accountStore.Get(msg.AccountID)
-		if acc != nil {
-			fromIdentity = &account.Identity{
-				Email: acc.Email,
-				Name:  acc.Name,
-			}
-		}
-	}
-
-	// Build the From address
-	from := smtp.Address{}
-	if fromIdentity != nil {
-		from = smtp.Address{Name: fromIdentity.Name, Address: fromIdentity.Email}
-	}
+	// Build the From address from the default identity
+	from := a.fromAddressForIdentities(msg.AccountID, identities)
 
 	// Build subject with Re: or Fwd: prefix
 	subject := msg.Subject
 	switch mode {
-	case "forward":
+	case "forward", "forward-attachment":
 		if !strings.HasPrefix(strings.ToLower(subject), "fwd:") && !strings.HasPrefix(strings.ToLower(subject), "fw:") {
 			subject = "Fwd: " + subject
 		}
@@ -395,7 +239,7 @@ func (a *App) PrepareReply(messageID, mode string) (*smtp.ComposeMessage, error)
 		if len(to) == 0 && len(cc) == 0 && len(originalFrom) > 0 {
 			to = originalFrom
 		}
-	case "forward":
+	case "forward", "forward-attachment":
 		// Leave To empty for user to fill in
 	}
 
@@ -407,13 +251,21 @@ func (a *App) PrepareReply(messageID, mode string) (*smtp.ComposeMessage, error)
 	}
 
 	var htmlBody, textBody string
-	if mode == "forward" {
+	var attachments []smtp.Attachment
+	switch mode {
+	case "forward-attachment":
+		raw, err := a.syncEngine.FetchRawMessage(a.ctx, msg.AccountID, msg.FolderID, msg.UID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch message: %w", err)
+		}
+		attachments = []smtp.Attachment{messageAttachment(msg.Subject, raw)}
+	case "forward":
 		// Forward format
 		htmlBody = fmt.Sprintf("<p></p><p></p><p>---------- Forwarded message ----------<br>From: %s<br>Subject: %s<br>Date: %s<br>To: %s</p><p></p>%s",
 			escapeHTML(sender), escapeHTML(msg.Subject), escapeHTML(dateStr), escapeHTML(msg.ToList), msg.BodyHTML)
 		textBody = fmt.Sprintf("\n\n---------- Forwarded message ----------\nFrom: %s\nSubject: %s\nDate: %s\nTo: %s\n\n%s",
 			sender, msg.Subject, dateStr, msg.ToList, msg.BodyText)
-	} else {
+	default:
 		// Reply format
 		citation := fmt.Sprintf("On %s, %s wrote:", dateStr, sender)
 		htmlBody = fmt.Sprintf("<p></p><p></p><p>%s</p><blockquote type=\"cite\">%s</blockquote>", escapeHTML(citation), msg.BodyHTML)
@@ -432,19 +284,74 @@ func (a *App) PrepareReply(messageID, mode string) (*smtp.ComposeMessage, error)
 	}
 
 	return &smtp.ComposeMessage{
-		From:       from,
-		To:         to,
-		Cc:         cc,
-		Subject:    subject,
-		HTMLBody:   htmlBody,
-		TextBody:   textBody,
-		InReplyTo:  ensureAngleBrackets(msg.MessageID),
-		References: refs,
+		From:        from,
+		To:          to,
+		Cc:          cc,
+		Subject:     subject,
+		HTMLBody:    htmlBody,
+		TextBody:    textBody,
+		Attachments: attachments,
+		InReplyTo:   ensureAngleBrackets(msg.MessageID),
+		References:  refs,
 	}, nil
 }
 
-// TestSMTPConnection tests SMTP connection settings
-func (a *App) TestSMTPConnection(host string, port int, security, username, password string) error {
+// RedirectMessage sends a message on to other recipients as it is, with
+// Resent-* headers added, so it still appears to come from its original
+// sender. Replies go to the sender, not to this account. Returns the outbox
+// entry if the server couldn't be reached and it will be retried.
+func (a *App) RedirectMessage(messageID string, to []smtp.Address) (*outbox.Message, error) {
+	log := logging.WithComponent("app")
+
+	msg, err := a.messageStore.Get(messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	if msg == nil {
+		return nil, fmt.Errorf("message not found: %s", messageID)
+	}
+
+	raw, err := a.syncEngine.FetchRawMessage(a.ctx, msg.AccountID, msg.FolderID, msg.UID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch message: %w", err)
+	}
+
+	from, err := a.defaultFromAddress(msg.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := a.sendService.PrepareRedirect(msg.AccountID, raw, msg.Subject, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("accountID", msg.AccountID).
+		Int("toCount", len(to)).
+		Str("subject", msg.Subject).
+		Msg("Redirecting message")
+
+	return a.submitOutboxMessage(m)
+}
+
+// messageAttachment attaches a message's raw source as a .eml file named
+// after its subject
+func messageAttachment(subject string, raw []byte) smtp.Attachment {
+	filename := strings.TrimSpace(subject)
+	if filename == "" {
+		filename = "message"
+	}
+	return smtp.Attachment{
+		Filename:    filename + ".eml",
+		ContentType: "message/rfc822",
+		Content:     raw,
+	}
+}
+
+// TestSMTPConnection tests SMTP connection settings, with the account's
+// client certificate and pinned SASL mechanism (both may be empty)
+func (a *App) TestSMTPConnection(host string, port int, security, username, password, clientCertID, authMechanism string) error {
 	log := logging.WithComponent("app")
 
 	// Map security string to type
@@ -468,7 +375,12 @@ func (a *App) TestSMTPConnection(host string, port int, security, username, pass
 	config.Username = username
 	config.Password = password
 	config.AuthType = smtp.AuthTypePassword
-	config.TLSConfig = certificate.BuildTLSConfig(host, a.certStore)
+	config.AuthMechanism = auth.Mechanism(authMechanism)
+	tlsConfig, err := certificate.BuildClientTLSConfig(host, a.certStore, a.credStore, clientCertID)
+	if err != nil {
+		return err
+	}
+	config.TLSConfig = tlsConfig
 
 	client := smtp.NewClient(config)
 
@@ -563,6 +475,44 @@ func (a *App) readFileAsAttachment(filePath string) (*ComposerAttachment, error)
 // Helper Functions
 // ============================================================================
 
+// defaultFromAddress returns the address of an account's default identity
+func (a *App) defaultFromAddress(accountID string) (smtp.Address, error) {
+	identities, err := a.accountStore.GetIdentities(accountID)
+	if err != nil {
+		return smtp.Address{}, fmt.Errorf("failed to get identities: %w", err)
+	}
+	return a.fromAddressForIdentities(accountID, identities), nil
+}
+
+// fromAddressForIdentities picks the default identity, falling back to the
+// first identity and then the account itself
+func (a *App) fromAddressForIdentities(accountID string, identities []*account.Identity) smtp.Address {
+	var fromIdentity *account.Identity
+	for _, id := range identities {
+		if id.IsDefault {
+			fromIdentity = id
+			break
+		}
+	}
+	if fromIdentity == nil && len(identities) > 0 {
+		fromIdentity = identities[0]
+	}
+	if fromIdentity == nil {
+		acc, _ := a.accountStore.Get(accountID)
+		if acc != nil {
+			fromIdentity = &account.Identity{
+				Email: acc.Email,
+				Name:  acc.Name,
+			}
+		}
+	}
+
+	if fromIdentity == nil {
+		return smtp.Address{}
+	}
+	return smtp.Address{Name: fromIdentity.Name, Address: fromIdentity.Email}
+}
+
 // parseAddressList parses a JSON array of addresses or comma-separated string
 func parseAddressList(s string) []smtp.Address {
 	if s == "" {
@@ -663,22 +613,6 @@ func quoteText(s string) string {
 	return strings.Join(lines, "\n")
 }
 
-// providerAutoSavesSentMail checks if a mail provider automatically saves sent messages
-func providerAutoSavesSentMail(host string) bool {
-	host = strings.ToLower(host)
-	autoSaveProviders := []string{
-		"imap.gmail.com",       // Gmail
-		"outlook.office365.com", // Microsoft 365
-		"imap-mail.outlook.com", // Outlook.com
-	}
-	for _, provider := range autoSaveProviders {
-		if strings.Contains(host, provider) {
-			return true
-		}
-	}
-	return false
-}
-
 // addressListToJSON converts an address list to JSON string
 func addressListToJSON(addrs []smtp.Address) string {
 	if len(addrs) == 0 {
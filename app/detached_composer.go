@@ -13,6 +13,7 @@ import (
 
 	goImap "github.com/emersion/go-imap/v2"
 	"github.com/hkdb/aerion/internal/account"
+	"github.com/hkdb/aerion/internal/auth"
 	"github.com/hkdb/aerion/internal/certificate"
 	"github.com/hkdb/aerion/internal/contact"
 	"github.com/hkdb/aerion/internal/credentials"
@@ -21,14 +22,20 @@ import (
 	"github.com/hkdb/aerion/internal/folder"
 	"github.com/hkdb/aerion/internal/imap"
 	"github.com/hkdb/aerion/internal/ipc"
+	"github.com/hkdb/aerion/internal/jmap"
 	"github.com/hkdb/aerion/internal/logging"
+	"github.com/hkdb/aerion/internal/maildir"
 	"github.com/hkdb/aerion/internal/message"
 	"github.com/hkdb/aerion/internal/oauth2"
+	"github.com/hkdb/aerion/internal/outbox"
+	"github.com/hkdb/aerion/internal/pendingop"
 	"github.com/hkdb/aerion/internal/platform"
+	"github.com/hkdb/aerion/internal/send"
 	"github.com/hkdb/aerion/internal/settings"
 	"github.com/hkdb/aerion/internal/pgp"
 	"github.com/hkdb/aerion/internal/smime"
 	"github.com/hkdb/aerion/internal/smtp"
+	"github.com/hkdb/aerion/internal/template"
 	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
@@ -39,6 +46,7 @@ type ComposerConfig struct {
 	Mode       string // "new", "reply", "reply-all", "forward"
 	MessageID  string // Original message ID (for reply/forward)
 	DraftID    string // Draft ID to resume editing
+	TemplateID string // Template to start from (not for drafts)
 }
 
 // ComposeMode represents the compose mode data returned to the frontend.
@@ -63,15 +71,18 @@ type ComposerApp struct {
 	ipcToken  string
 
 	// Database (shared with main window, read-only for most operations)
-	db            *database.DB
-	accountStore  *account.Store
-	folderStore   *folder.Store
-	messageStore  *message.Store
-	contactStore  *contact.Store
-	draftStore    *draft.Store
-	credStore     *credentials.Store
-	certStore     *certificate.Store
-	settingsStore *settings.Store
+	db             *database.DB
+	accountStore   *account.Store
+	folderStore    *folder.Store
+	messageStore   *message.Store
+	contactStore   *contact.Store
+	draftStore     *draft.Store
+	pendingOpStore *pendingop.Store
+	outboxStore    *outbox.Store
+	credStore      *credentials.Store
+	certStore      *certificate.Store
+	settingsStore  *settings.Store
+	templateStore  *template.Store
 
 	// IMAP pool for sending/draft operations
 	imapPool *imap.Pool
@@ -91,6 +102,9 @@ type ComposerApp struct {
 	pgpEncryptor *pgp.Encryptor
 	pgpDecryptor *pgp.Decryptor
 
+	// Send pipeline shared with the main window
+	sendService *send.Service
+
 	// Paths
 	paths *platform.Paths
 
@@ -149,7 +163,10 @@ func (c *ComposerApp) Startup(ctx context.Context) {
 	c.messageStore = message.NewStore(db)
 	c.contactStore = contact.NewStore(db.DB)
 	c.draftStore = draft.NewStore(db)
+	c.pendingOpStore = pendingop.NewStore(db)
+	c.outboxStore = outbox.NewStore(db)
 	c.settingsStore = settings.NewStore(db)
+	c.templateStore = template.NewStore(db)
 
 	// Initialize credential store
 	credStore, err := credentials.NewStore(db.DB, paths.Data)
@@ -173,11 +190,28 @@ func (c *ComposerApp) Startup(ctx context.Context) {
 	c.pgpEncryptor = pgp.NewEncryptor(c.pgpStore, credStore, log)
 	c.pgpDecryptor = pgp.NewDecryptor(c.pgpStore, credStore, log)
 
+	// Initialize the send pipeline. Accounts with local folders are sent by
+	// the main window, so no local filer is set.
+	c.sendService = send.NewService(c.accountStore, c.folderStore, c.contactStore, credStore, c.certStore, c.getValidOAuthToken)
+	c.sendService.AddProtector(send.NewSMIMEProtector(c.smimeStore, c.smimeSigner, c.smimeEncryptor))
+	c.sendService.AddProtector(send.NewPGPProtector(c.pgpStore, c.pgpSigner, c.pgpEncryptor))
+	c.sendService.SetJMAPSubmitter(func(acc *account.Account, from string, recipients []string, raw []byte) error {
+		client, err := c.jmapClient(acc)
+		if err != nil {
+			return err
+		}
+		return submitJMAP(c.ctx, client, c.folderStore, acc.ID, from, recipients, raw)
+	})
+	c.sendService.SetAppendQueue(c.pendingOpStore.Enqueue)
+
 	// Initialize IMAP pool for send/draft operations
 	poolConfig := imap.DefaultPoolConfig()
 	poolConfig.MaxConnections = 1 // Composer only needs 1 connection
 	c.imapPool = imap.NewPool(poolConfig, c.getIMAPCredentials)
 
+	// Load OAuth providers added by the administrator or user
+	oauth2.LoadProviderFiles(c.paths.OAuthProviderPaths()...)
+
 	// Initialize OAuth2 manager for token refresh
 	c.oauth2Manager = oauth2.NewManager()
 
@@ -392,6 +426,22 @@ func (c *ComposerApp) notifyMessageSent(folderID int64) {
 	c.ipcClient.Send(msg)
 }
 
+// notifyOutboxQueued tells the main window a message was added to the outbox.
+func (c *ComposerApp) notifyOutboxQueued(outboxID int64) {
+	if c.ipcClient == nil {
+		return
+	}
+
+	msg, err := ipc.NewMessage(ipc.TypeOutboxQueued, ipc.OutboxQueuedPayload{
+		AccountID: c.config.AccountID,
+		OutboxID:  outboxID,
+	})
+	if err != nil {
+		return
+	}
+	c.ipcClient.Send(msg)
+}
+
 // notifyDraftSaved sends a draft-saved notification to the main window.
 func (c *ComposerApp) notifyDraftSaved(draftID string) {
 	if c.ipcClient == nil {
@@ -439,7 +489,12 @@ func (c *ComposerApp) getIMAPCredentials(accountID string) (*imap.ClientConfig,
 	config.Port = acc.IMAPPort
 	config.Security = imap.SecurityType(acc.IMAPSecurity)
 	config.Username = acc.Username
-	config.TLSConfig = certificate.BuildTLSConfig(acc.IMAPHost, c.certStore)
+	config.AuthMechanism = auth.Mechanism(acc.AuthMechanism)
+	tlsConfig, err := certificate.BuildClientTLSConfig(acc.IMAPHost, c.certStore, c.credStore, acc.ClientCertID)
+	if err != nil {
+		return nil, err
+	}
+	config.TLSConfig = tlsConfig
 
 	// Handle authentication based on auth type
 	if acc.AuthType == account.AuthOAuth2 {
@@ -600,9 +655,13 @@ func (c *ComposerApp) PrepareReply() (*smtp.ComposeMessage, error) {
 			from = smtp.Address{Address: fromIdentity.Email, Name: fromIdentity.Name}
 		}
 
-		return &smtp.ComposeMessage{
+		c.composeMessage = &smtp.ComposeMessage{
 			From: from,
-		}, nil
+		}
+		if err := c.applyTemplate(c.composeMessage, nil); err != nil {
+			return nil, err
+		}
+		return c.composeMessage, nil
 	}
 
 	// For reply/forward, use the same logic as main app
@@ -617,149 +676,158 @@ func (c *ComposerApp) PrepareReply() (*smtp.ComposeMessage, error) {
 
 	c.originalMessage = msg
 	c.composeMessage = c.buildReplyMessage(msg, c.config.Mode)
+	if err := c.applyTemplate(c.composeMessage, msg); err != nil {
+		return nil, err
+	}
 	return c.composeMessage, nil
 }
 
-// SearchContacts searches for contacts matching the query.
-func (c *ComposerApp) SearchContacts(query string, limit int) ([]*contact.Contact, error) {
-	return c.contactStore.Search(query, limit)
+// applyTemplate starts msg from the template the window was opened with,
+// if any
+func (c *ComposerApp) applyTemplate(msg *smtp.ComposeMessage, original *message.Message) error {
+	if c.config.TemplateID == "" {
+		return nil
+	}
+	t, err := c.templateStore.Get(c.config.TemplateID)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return fmt.Errorf("template not found: %s", c.config.TemplateID)
+	}
+	template.Apply(msg, renderTemplate(t, msg, original))
+	return nil
 }
 
-// SendMessage sends the composed email.
-func (c *ComposerApp) SendMessage(msg smtp.ComposeMessage) error {
-	log := logging.WithComponent("composer")
-
-	log.Info().
-		Str("from", msg.From.Address).
-		Int("toCount", len(msg.To)).
-		Str("subject", msg.Subject).
-		Msg("Sending message")
+// GetTemplates returns the templates available to the account.
+func (c *ComposerApp) GetTemplates() ([]*template.Template, error) {
+	return c.templateStore.List(c.config.AccountID)
+}
 
-	// Get account for SMTP settings
-	acc, err := c.accountStore.Get(c.config.AccountID)
-	if err != nil {
-		return fmt.Errorf("failed to get account: %w", err)
+// SaveMessageAsTemplate saves the message being composed as a new template.
+func (c *ComposerApp) SaveMessageAsTemplate(name string, shared bool, msg smtp.ComposeMessage) (*template.Template, error) {
+	accountID := c.config.AccountID
+	if shared {
+		accountID = ""
 	}
-
-	// Build RFC822 message
-	rawMsg, err := msg.ToRFC822()
-	if err != nil {
-		return fmt.Errorf("failed to build message: %w", err)
+	t := template.FromMessage(accountID, name, &msg)
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" {
+		return nil, fmt.Errorf("template name is required")
+	}
+	if err := c.templateStore.Save(t); err != nil {
+		return nil, err
 	}
+	return t, nil
+}
 
-	// The sender's email determines which cert/key to use
-	fromEmail := msg.From.Address
+// DeleteTemplate removes a template.
+func (c *ComposerApp) DeleteTemplate(id string) error {
+	return c.templateStore.Delete(id)
+}
 
-	// S/MIME signing (if configured for this account/message)
-	if c.shouldSignMessage(msg.SignMessage) {
-		signedMsg, signErr := c.smimeSigner.SignMessage(c.config.AccountID, fromEmail, rawMsg)
-		if signErr != nil {
-			return fmt.Errorf("failed to sign message: %w", signErr)
-		}
-		rawMsg = signedMsg
-		log.Info().Str("accountID", c.config.AccountID).Msg("Message signed with S/MIME")
+// RenderTemplate fills in a template's variables for the message being
+// composed, so it can be inserted.
+func (c *ComposerApp) RenderTemplate(templateID string, msg smtp.ComposeMessage) (*smtp.ComposeMessage, error) {
+	t, err := c.templateStore.Get(templateID)
+	if err != nil {
+		return nil, err
 	}
-
-	// S/MIME encryption (if configured for this account/message)
-	if c.shouldEncryptMessage(msg.EncryptMessage) {
-		encryptedMsg, encErr := c.smimeEncryptor.EncryptMessage(c.config.AccountID, fromEmail, msg.AllRecipients(), rawMsg)
-		if encErr != nil {
-			return fmt.Errorf("failed to encrypt message: %w", encErr)
-		}
-		rawMsg = encryptedMsg
-		log.Info().Str("accountID", c.config.AccountID).Msg("Message encrypted with S/MIME")
+	if t == nil {
+		return nil, fmt.Errorf("template not found: %s", templateID)
 	}
-
-	// PGP signing (mutually exclusive with S/MIME)
-	if !msg.SignMessage && c.shouldPGPSignMessage(msg.PGPSignMessage) {
-		signedMsg, signErr := c.pgpSigner.SignMessage(c.config.AccountID, fromEmail, rawMsg)
-		if signErr != nil {
-			return fmt.Errorf("failed to PGP sign message: %w", signErr)
-		}
-		rawMsg = signedMsg
-		log.Info().Str("accountID", c.config.AccountID).Msg("Message signed with PGP")
+	original, err := c.GetOriginalMessage()
+	if err != nil {
+		return nil, err
 	}
+	return renderTemplate(t, &msg, original), nil
+}
 
-	// PGP encryption (mutually exclusive with S/MIME)
-	if !msg.EncryptMessage && c.shouldPGPEncryptMessage(msg.PGPEncryptMessage) {
-		encryptedMsg, encErr := c.pgpEncryptor.EncryptMessage(c.config.AccountID, fromEmail, msg.AllRecipients(), rawMsg)
-		if encErr != nil {
-			return fmt.Errorf("failed to PGP encrypt message: %w", encErr)
-		}
-		rawMsg = encryptedMsg
-		log.Info().Str("accountID", c.config.AccountID).Msg("Message encrypted with PGP")
-	}
+// SearchContacts searches for contacts matching the query.
+func (c *ComposerApp) SearchContacts(query string, limit int) ([]*contact.Contact, error) {
+	return c.contactStore.Search(query, limit)
+}
 
-	// Create SMTP client config
-	smtpConfig := smtp.DefaultConfig()
-	smtpConfig.Host = acc.SMTPHost
-	smtpConfig.Port = acc.SMTPPort
-	smtpConfig.Security = smtp.SecurityType(acc.SMTPSecurity)
-	smtpConfig.Username = acc.Username
-	smtpConfig.TLSConfig = certificate.BuildTLSConfig(acc.SMTPHost, c.certStore)
+// SendMessage sends the composed email. With an undo-send delay configured,
+// the message is handed to the main window's outbox and returned so it can be
+// undone; otherwise it's sent directly and nil is returned. If the server
+// can't be reached, the message is queued in the outbox and retried.
+func (c *ComposerApp) SendMessage(msg smtp.ComposeMessage) (*outbox.Message, error) {
+	delay, err := c.settingsStore.GetUndoSendDelay()
+	if err != nil {
+		delay = settings.DefaultUndoSendDelay
+	}
+	return c.queueMessage(msg, time.Now().Add(time.Duration(delay)*time.Second), false)
+}
 
-	// Handle authentication based on auth type
-	if acc.AuthType == account.AuthOAuth2 {
-		// Get valid OAuth token (refreshing if needed)
-		tokens, err := c.getValidOAuthToken(c.config.AccountID)
-		if err != nil {
-			return fmt.Errorf("failed to get OAuth token: %w", err)
-		}
-		smtpConfig.AuthType = smtp.AuthTypeOAuth2
-		smtpConfig.AccessToken = tokens.AccessToken
-	} else {
-		// Default to password authentication
-		password, err := c.credStore.GetPassword(c.config.AccountID)
-		if err != nil {
-			return fmt.Errorf("failed to get password: %w", err)
-		}
-		smtpConfig.AuthType = smtp.AuthTypePassword
-		smtpConfig.Password = password
+// ScheduleMessage hands the composed email to the outbox to be sent at sendAt.
+func (c *ComposerApp) ScheduleMessage(msg smtp.ComposeMessage, sendAt time.Time) (*outbox.Message, error) {
+	if !sendAt.After(time.Now()) {
+		return nil, fmt.Errorf("send time must be in the future")
 	}
+	return c.queueMessage(msg, sendAt, true)
+}
 
-	client := smtp.NewClient(smtpConfig)
+// queueMessage builds the final message and either sends it right away or
+// adds it to the outbox for the main window to deliver.
+func (c *ComposerApp) queueMessage(msg smtp.ComposeMessage, sendAt time.Time, scheduled bool) (*outbox.Message, error) {
+	log := logging.WithComponent("composer")
 
-	if err := client.Connect(); err != nil {
-		return fmt.Errorf("failed to connect to SMTP: %w", err)
-	}
-	defer client.Close()
+	log.Info().
+		Str("from", msg.From.Address).
+		Int("toCount", len(msg.To)).
+		Str("subject", msg.Subject).
+		Bool("scheduled", scheduled).
+		Msg("Sending message")
 
-	if err := client.Login(); err != nil {
-		return fmt.Errorf("failed to login to SMTP: %w", err)
+	// Get account to decide whether this window can deliver it
+	acc, err := c.accountStore.Get(c.config.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	if acc == nil {
+		return nil, fmt.Errorf("account not found: %s", c.config.AccountID)
 	}
 
-	recipients := msg.AllRecipients()
-	if len(recipients) == 0 {
-		return fmt.Errorf("no recipients")
+	m, err := c.sendService.Prepare(c.config.AccountID, msg, sendAt, scheduled)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := client.SendMail(msg.From.Address, recipients, rawMsg); err != nil {
-		return fmt.Errorf("failed to send: %w", err)
+	// Local accounts go through the main window's outbox, which files the
+	// sent copy in the local Sent folder
+	if m.SendAt.Before(time.Now().Add(time.Second)) && !acc.HasLocalFolders() {
+		err := c.sendService.Deliver(m)
+		if err == nil {
+			m = nil
+		} else if !send.IsTemporaryError(err) {
+			return nil, err
+		} else {
+			// Server unreachable: let the main window's outbox retry it
+			log.Warn().Err(err).Msg("Temporary send failure, queueing in outbox")
+			m.LastError = err.Error()
+		}
 	}
 
-	// Save to Sent folder if provider doesn't auto-save
-	if !providerAutoSavesSentMail(acc.IMAPHost) {
-		log.Debug().Str("host", acc.IMAPHost).Msg("Provider doesn't auto-save, using IMAP APPEND")
-		if err := c.saveToSentFolder(acc, rawMsg); err != nil {
-			log.Warn().Err(err).Msg("Failed to save message to Sent folder")
+	if m != nil {
+		if err := c.outboxStore.Create(m); err != nil {
+			return nil, err
 		}
-	} else {
-		log.Debug().Str("host", acc.IMAPHost).Msg("Provider auto-saves sent mail")
+		c.notifyOutboxQueued(m.ID)
 	}
 
 	// Add recipients to contacts
-	for _, to := range msg.To {
-		c.contactStore.AddOrUpdate(to.Address, to.Name)
-	}
-	for _, cc := range msg.Cc {
-		c.contactStore.AddOrUpdate(cc.Address, cc.Name)
-	}
+	c.sendService.HarvestContacts(msg)
 
 	// Delete draft if we were editing one
 	if c.currentDraft != nil {
 		c.draftStore.Delete(c.currentDraft.ID)
 	}
 
+	if m != nil {
+		return m, nil
+	}
+
 	// Get sent folder ID for notification
 	sentFolder, _ := c.folderStore.GetByType(c.config.AccountID, folder.TypeSent)
 	var sentFolderID int64
@@ -771,80 +839,20 @@ func (c *ComposerApp) SendMessage(msg smtp.ComposeMessage) error {
 	c.notifyMessageSent(sentFolderID)
 
 	log.Info().Msg("Message sent successfully")
-	return nil
+	return nil, nil
 }
 
-// saveToSentFolder appends the sent message to the Sent folder via IMAP.
-// Used for providers that don't automatically save sent messages.
-func (c *ComposerApp) saveToSentFolder(acc *account.Account, rawMsg []byte) error {
-	log := logging.WithComponent("composer")
-
-	// Get the Sent folder path
-	sentFolder, err := c.folderStore.GetByType(acc.ID, folder.TypeSent)
-	if err != nil || sentFolder == nil {
-		if acc.SentFolderPath == "" {
-			return fmt.Errorf("no Sent folder configured or detected")
-		}
-	}
-
-	sentPath := acc.SentFolderPath
-	if sentPath == "" && sentFolder != nil {
-		sentPath = sentFolder.Path
-	}
-
-	log.Debug().
-		Str("account_id", acc.ID).
-		Str("sent_path", sentPath).
-		Msg("Saving sent message to folder via IMAP APPEND")
-
-	// Create IMAP client
-	clientConfig := imap.DefaultConfig()
-	clientConfig.Host = acc.IMAPHost
-	clientConfig.Port = acc.IMAPPort
-	clientConfig.Security = imap.SecurityType(acc.IMAPSecurity)
-	clientConfig.Username = acc.Username
-	clientConfig.TLSConfig = certificate.BuildTLSConfig(acc.IMAPHost, c.certStore)
-
-	// Handle authentication
-	if acc.AuthType == account.AuthOAuth2 {
-		tokens, err := c.getValidOAuthToken(acc.ID)
-		if err != nil {
-			return fmt.Errorf("failed to get OAuth token: %w", err)
-		}
-		clientConfig.AuthType = imap.AuthTypeOAuth2
-		clientConfig.AccessToken = tokens.AccessToken
-	} else {
-		password, err := c.credStore.GetPassword(acc.ID)
-		if err != nil {
-			return fmt.Errorf("failed to get password: %w", err)
-		}
-		clientConfig.AuthType = imap.AuthTypePassword
-		clientConfig.Password = password
-	}
-
-	imapClient := imap.NewClient(clientConfig)
-	if err := imapClient.Connect(); err != nil {
-		return fmt.Errorf("failed to connect to IMAP: %w", err)
-	}
-	defer imapClient.Close()
-
-	if err := imapClient.Login(); err != nil {
-		return fmt.Errorf("failed to login to IMAP: %w", err)
-	}
-
-	// Append message with \Seen flag
-	flags := []goImap.Flag{goImap.FlagSeen}
-	_, err = imapClient.AppendMessage(sentPath, flags, time.Now(), rawMsg)
+// jmapClient connects a JMAP client for a JMAP account
+func (c *ComposerApp) jmapClient(acc *account.Account) (*jmap.Client, error) {
+	config, err := jmapConfigForAccount(acc, c.credStore, c.certStore, c.getValidOAuthToken)
 	if err != nil {
-		return fmt.Errorf("failed to append to Sent folder: %w", err)
+		return nil, err
 	}
-
-	log.Info().
-		Str("account_id", acc.ID).
-		Str("sent_path", sentPath).
-		Msg("Message saved to Sent folder")
-
-	return nil
+	client := jmap.NewClient(*config)
+	if _, err := client.Connect(c.ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to JMAP server: %w", err)
+	}
+	return client, nil
 }
 
 // SaveDraft saves the current compose state as a draft.
@@ -1034,7 +1042,16 @@ func (c *ComposerApp) DeleteDraft(draftID string) error {
 	// If synced to IMAP, delete from server first
 	if draftToDelete.IsSynced() {
 		draftsFolder, _ := c.folderStore.GetByType(c.config.AccountID, folder.TypeDrafts)
-		if draftsFolder != nil {
+		acc, _ := c.accountStore.Get(c.config.AccountID)
+		if draftsFolder != nil && acc != nil && acc.IsJMAP() {
+			if err := c.destroyJMAPDraft(acc, draftsFolder.ID, draftToDelete.IMAPUID); err != nil {
+				log.Warn().Err(err).Uint32("uid", draftToDelete.IMAPUID).Msg("Failed to delete draft from JMAP server")
+			}
+		} else if draftsFolder != nil && acc != nil && acc.HasLocalFolders() {
+			if err := c.removeLocalDraft(acc, draftsFolder, draftToDelete.IMAPUID); err != nil {
+				log.Warn().Err(err).Uint32("uid", draftToDelete.IMAPUID).Msg("Failed to delete draft from local Drafts folder")
+			}
+		} else if draftsFolder != nil {
 			poolConn, err := c.imapPool.GetConnection(c.ctx, c.config.AccountID)
 			if err == nil {
 				defer c.imapPool.Release(poolConn)
@@ -1070,6 +1087,51 @@ func (c *ComposerApp) DeleteDraft(draftID string) error {
 	return nil
 }
 
+// destroyJMAPDraft destroys the server copy of a draft listed at folderID/uid
+func (c *ComposerApp) destroyJMAPDraft(acc *account.Account, folderID string, uid uint32) error {
+	m, err := c.messageStore.GetByUID(folderID, uid)
+	if err != nil || m == nil {
+		return err
+	}
+	remoteIDs, err := c.messageStore.GetRemoteIDs([]string{m.ID})
+	if err != nil || remoteIDs[m.ID] == "" {
+		return err
+	}
+
+	client, err := c.jmapClient(acc)
+	if err != nil {
+		return err
+	}
+	return client.DestroyEmails(c.ctx, []string{remoteIDs[m.ID]})
+}
+
+// removeLocalDraft deletes a draft from a local Drafts folder, along with
+// its file on accounts kept in a Maildir tree
+func (c *ComposerApp) removeLocalDraft(acc *account.Account, draftsFolder *folder.Folder, uid uint32) error {
+	m, err := c.messageStore.GetByUID(draftsFolder.ID, uid)
+	if err != nil || m == nil {
+		return err
+	}
+
+	if acc.IsLocal() && acc.MaildirPath != "" {
+		keys, err := c.messageStore.GetRemoteIDs([]string{m.ID})
+		if err != nil {
+			return err
+		}
+		if key := keys[m.ID]; key != "" {
+			dir, err := maildir.Tree(acc.MaildirPath).Folder(draftsFolder.Path)
+			if err != nil {
+				return err
+			}
+			if err := dir.Remove(key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return c.messageStore.Delete(m.ID)
+}
+
 // syncDraftToIMAP syncs a draft to the IMAP server.
 // This runs in a background goroutine and emits events to this window's frontend.
 func (c *ComposerApp) syncDraftToIMAP(localDraft *draft.Draft, msg smtp.ComposeMessage) {
@@ -1094,6 +1156,14 @@ func (c *ComposerApp) syncDraftToIMAP(localDraft *draft.Draft, msg smtp.ComposeM
 		return
 	}
 
+	// JMAP drafts stay pending; the main window uploads them with its session.
+	// Local drafts too, since the main window's sync engine stores them.
+	if acc, _ := c.accountStore.Get(c.config.AccountID); acc != nil && (acc.IsJMAP() || acc.HasLocalFolders()) {
+		log.Debug().Str("draftID", localDraft.ID).Msg("Leaving draft for the main window to store")
+		c.notifyDraftSaved(localDraft.ID)
+		return
+	}
+
 	// Get IMAP connection from pool
 	poolConn, err := c.imapPool.GetConnection(c.ctx, c.config.AccountID)
 	if err != nil {
@@ -1665,62 +1735,6 @@ func (c *ComposerApp) getHKPServers() []string {
 	return urls
 }
 
-// shouldPGPSignMessage determines whether a message should be PGP signed.
-func (c *ComposerApp) shouldPGPSignMessage(perMessageOverride bool) bool {
-	if perMessageOverride {
-		return c.HasPGPKey()
-	}
-
-	policy, err := c.pgpStore.GetSignPolicy(c.config.AccountID)
-	if err != nil || policy != "always" {
-		return false
-	}
-
-	return c.HasPGPKey()
-}
-
-// shouldPGPEncryptMessage determines whether a message should be PGP encrypted.
-func (c *ComposerApp) shouldPGPEncryptMessage(perMessageOverride bool) bool {
-	if perMessageOverride {
-		return c.HasPGPKey()
-	}
-
-	policy, err := c.pgpStore.GetEncryptPolicy(c.config.AccountID)
-	if err != nil || policy != "always" {
-		return false
-	}
-
-	return c.HasPGPKey()
-}
-
-// shouldSignMessage determines whether a message should be S/MIME signed.
-func (c *ComposerApp) shouldSignMessage(perMessageOverride bool) bool {
-	if perMessageOverride {
-		return c.HasSMIMECertificate()
-	}
-
-	policy, err := c.smimeStore.GetSignPolicy(c.config.AccountID)
-	if err != nil || policy != "always" {
-		return false
-	}
-
-	return c.HasSMIMECertificate()
-}
-
-// shouldEncryptMessage determines whether a message should be S/MIME encrypted.
-func (c *ComposerApp) shouldEncryptMessage(perMessageOverride bool) bool {
-	if perMessageOverride {
-		return c.HasSMIMECertificate()
-	}
-
-	policy, err := c.smimeStore.GetEncryptPolicy(c.config.AccountID)
-	if err != nil || policy != "always" {
-		return false
-	}
-
-	return c.HasSMIMECertificate()
-}
-
 // getDraftIdentityEmail returns the email address for the draft's identity.
 // Falls back to the account email if the identity cannot be resolved.
 func (c *ComposerApp) getDraftIdentityEmail(d *draft.Draft) string {
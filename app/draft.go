@@ -191,27 +191,6 @@ func (a *App) syncDraftToIMAP(localDraft *draft.Draft, msg smtp.ComposeMessage)
 		return
 	}
 
-	// Get IMAP connection from pool
-	poolConn, err := a.imapPool.GetConnection(a.ctx, localDraft.AccountID)
-	if err != nil {
-		log.Warn().Err(err).Msg("Failed to get IMAP connection, will retry later")
-		a.draftStore.UpdateSyncStatus(localDraft.ID, draft.SyncStatusFailed, 0, "", err.Error())
-		emitSyncStatus(draft.SyncStatusFailed, 0, err.Error())
-		return
-	}
-	defer a.imapPool.Release(poolConn)
-
-	conn := poolConn.Client()
-
-	// Delete old IMAP draft if it exists
-	if localDraft.IMAPUID > 0 && localDraft.FolderID != "" {
-		if _, err := conn.SelectMailbox(a.ctx, draftsFolder.Path); err == nil {
-			if err := conn.DeleteMessageByUID(goImap.UID(localDraft.IMAPUID)); err != nil {
-				log.Warn().Err(err).Uint32("uid", localDraft.IMAPUID).Msg("Failed to delete old draft from IMAP")
-			}
-		}
-	}
-
 	// Build RFC822 message
 	rawMsg, err := msg.ToRFC822()
 	if err != nil {
@@ -270,6 +249,61 @@ func (a *App) syncDraftToIMAP(localDraft *draft.Draft, msg smtp.ComposeMessage)
 		log.Debug().Str("draftID", localDraft.ID).Msg("Draft PGP encrypted for IMAP sync")
 	}
 
+	// JMAP accounts store the draft with Email/import instead
+	if a.isJMAPAccount(localDraft.AccountID) {
+		uid, err := a.storeJMAPDraft(localDraft, draftsFolder, rawMsg)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to store draft on JMAP server")
+			a.draftStore.UpdateSyncStatus(localDraft.ID, draft.SyncStatusFailed, 0, "", err.Error())
+			emitSyncStatus(draft.SyncStatusFailed, 0, err.Error())
+			return
+		}
+		if err := a.draftStore.UpdateSyncStatus(localDraft.ID, draft.SyncStatusSynced, uid, draftsFolder.ID, ""); err != nil {
+			log.Warn().Err(err).Msg("Failed to update draft sync status")
+		}
+		emitSyncStatus(draft.SyncStatusSynced, uid, "")
+		log.Info().Str("id", localDraft.ID).Uint32("uid", uid).Msg("Draft synced to JMAP")
+		return
+	}
+
+	// Local Drafts folders take the draft directly
+	if a.isLocalAccount(localDraft.AccountID) {
+		uid, err := a.storeLocalDraft(localDraft, draftsFolder, rawMsg)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to store draft in local Drafts folder")
+			a.draftStore.UpdateSyncStatus(localDraft.ID, draft.SyncStatusFailed, 0, "", err.Error())
+			emitSyncStatus(draft.SyncStatusFailed, 0, err.Error())
+			return
+		}
+		if err := a.draftStore.UpdateSyncStatus(localDraft.ID, draft.SyncStatusSynced, uid, draftsFolder.ID, ""); err != nil {
+			log.Warn().Err(err).Msg("Failed to update draft sync status")
+		}
+		emitSyncStatus(draft.SyncStatusSynced, uid, "")
+		log.Info().Str("id", localDraft.ID).Uint32("uid", uid).Msg("Draft stored locally")
+		return
+	}
+
+	// Get IMAP connection from pool
+	poolConn, err := a.imapPool.GetConnection(a.ctx, localDraft.AccountID)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get IMAP connection, will retry later")
+		a.draftStore.UpdateSyncStatus(localDraft.ID, draft.SyncStatusFailed, 0, "", err.Error())
+		emitSyncStatus(draft.SyncStatusFailed, 0, err.Error())
+		return
+	}
+	defer a.imapPool.Release(poolConn)
+
+	conn := poolConn.Client()
+
+	// Delete old IMAP draft if it exists
+	if localDraft.IMAPUID > 0 && localDraft.FolderID != "" {
+		if _, err := conn.SelectMailbox(a.ctx, draftsFolder.Path); err == nil {
+			if err := conn.DeleteMessageByUID(goImap.UID(localDraft.IMAPUID)); err != nil {
+				log.Warn().Err(err).Uint32("uid", localDraft.IMAPUID).Msg("Failed to delete old draft from IMAP")
+			}
+		}
+	}
+
 	// Append to IMAP Drafts folder with \Draft and \Seen flags
 	flags := []goImap.Flag{goImap.FlagDraft, goImap.FlagSeen}
 	uid, err := conn.AppendMessage(draftsFolder.Path, flags, time.Now(), rawMsg)
@@ -457,7 +491,15 @@ func (a *App) DeleteDraft(draftID string) error {
 	var draftsFolder *folder.Folder
 	if d.IsSynced() {
 		draftsFolder, _ = a.GetSpecialFolder(d.AccountID, folder.TypeDrafts)
-		if draftsFolder != nil {
+		if draftsFolder != nil && a.isJMAPAccount(d.AccountID) {
+			if err := a.destroyJMAPMessageAt(d.AccountID, draftsFolder.ID, d.IMAPUID); err != nil {
+				log.Warn().Err(err).Uint32("uid", d.IMAPUID).Msg("Failed to delete draft from JMAP server")
+			}
+		} else if draftsFolder != nil && a.isLocalAccount(d.AccountID) {
+			if err := a.deleteLocalMessageAt(draftsFolder.ID, d.IMAPUID); err != nil {
+				log.Warn().Err(err).Uint32("uid", d.IMAPUID).Msg("Failed to delete draft from local Drafts folder")
+			}
+		} else if draftsFolder != nil {
 			poolConn, err := a.imapPool.GetConnection(a.ctx, d.AccountID)
 			if err == nil {
 				defer a.imapPool.Release(poolConn)
@@ -0,0 +1,303 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/hkdb/aerion/internal/mbox"
+	"github.com/hkdb/aerion/internal/message"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Export formats
+const (
+	ExportFormatMbox = "mbox" // A single mboxrd file
+	ExportFormatEML  = "eml"  // A directory of .eml files, one per message
+)
+
+// exportBatchSize is how many messages are loaded from the database at once
+const exportBatchSize = 100
+
+// ============================================================================
+// Export API
+// ============================================================================
+
+// ExportFolder exports every message in a folder. Returns the chosen file
+// or directory, or "" if the user cancelled. The export runs in the
+// background and reports "export:progress" and "export:complete" events.
+func (a *App) ExportFolder(folderID, format string) (string, error) {
+	f, err := a.folderStore.Get(folderID)
+	if err != nil || f == nil {
+		return "", fmt.Errorf("folder not found: %s", folderID)
+	}
+
+	ids, err := a.messageStore.GetAllIDsByFolder(folderID)
+	if err != nil {
+		return "", fmt.Errorf("failed to list messages: %w", err)
+	}
+
+	return a.exportMessages(ids, f.Name, format)
+}
+
+// ExportConversation exports the messages of a conversation
+func (a *App) ExportConversation(threadID, folderID, format string) (string, error) {
+	conv, err := a.messageStore.GetConversation(threadID, folderID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if conv == nil || len(conv.Messages) == 0 {
+		return "", fmt.Errorf("conversation not found: %s", threadID)
+	}
+
+	ids := make([]string, len(conv.Messages))
+	for i, m := range conv.Messages {
+		ids[i] = m.ID
+	}
+
+	return a.exportMessages(ids, conv.Subject, format)
+}
+
+// ExportSearchResults exports the messages of every conversation matching
+// a search in a folder
+func (a *App) ExportSearchResults(folderID, query, filter, format string) (string, error) {
+	// A limit of -1 returns all results
+	results, _, err := a.messageStore.SearchConversations(folderID, query, 0, -1, filter)
+	if err != nil {
+		return "", fmt.Errorf("failed to search: %w", err)
+	}
+
+	var ids []string
+	for _, r := range results {
+		ids = append(ids, r.MessageIDs...)
+	}
+
+	return a.exportMessages(ids, "Search "+query, format)
+}
+
+// ExportMessages exports the given messages
+func (a *App) ExportMessages(messageIDs []string, format string) (string, error) {
+	return a.exportMessages(messageIDs, "Messages", format)
+}
+
+// ============================================================================
+// Export helpers
+// ============================================================================
+
+// exportMessages asks where to export to and starts the export
+func (a *App) exportMessages(messageIDs []string, name, format string) (string, error) {
+	log := logging.WithComponent("app")
+
+	if format != ExportFormatMbox && format != ExportFormatEML {
+		return "", fmt.Errorf("unsupported export format: %s", format)
+	}
+	if len(messageIDs) == 0 {
+		return "", fmt.Errorf("no messages to export")
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	defaultDir := filepath.Join(homeDir, "Downloads")
+
+	filename := exportFilename(name)
+	if filename == "" {
+		filename = "Messages"
+	}
+
+	var path string
+	if format == ExportFormatMbox {
+		path, err = wailsRuntime.SaveFileDialog(a.ctx, wailsRuntime.SaveDialogOptions{
+			DefaultDirectory: defaultDir,
+			DefaultFilename:  filename + ".mbox",
+			Title:            "Export Messages",
+			Filters: []wailsRuntime.FileFilter{
+				{
+					DisplayName: "Mailbox Files (*.mbox)",
+					Pattern:     "*.mbox",
+				},
+			},
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to show save dialog: %w", err)
+		}
+	} else {
+		path, err = wailsRuntime.OpenDirectoryDialog(a.ctx, wailsRuntime.OpenDialogOptions{
+			DefaultDirectory:     defaultDir,
+			Title:                "Export Messages",
+			CanCreateDirectories: true,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to show folder dialog: %w", err)
+		}
+	}
+
+	// User cancelled the dialog
+	if path == "" {
+		return "", nil
+	}
+
+	log.Info().
+		Str("path", path).
+		Str("format", format).
+		Int("count", len(messageIDs)).
+		Msg("Exporting messages")
+
+	go a.runExport(messageIDs, path, format)
+
+	return path, nil
+}
+
+// runExport writes the messages to path, reporting progress as it goes
+func (a *App) runExport(messageIDs []string, path, format string) {
+	log := logging.WithComponent("app")
+
+	var writer *mbox.Writer
+	var file *os.File
+	if format == ExportFormatMbox {
+		var err error
+		file, err = os.Create(path)
+		if err != nil {
+			log.Error().Err(err).Str("path", path).Msg("Failed to create mbox file")
+			a.emitExportComplete(path, 0, len(messageIDs), err)
+			return
+		}
+		writer = mbox.NewWriter(file)
+	}
+
+	exported, failed := 0, 0
+	var writeErr error
+	for start := 0; start < len(messageIDs) && writeErr == nil; start += exportBatchSize {
+		end := min(start+exportBatchSize, len(messageIDs))
+
+		messages, err := a.messageStore.GetByIDs(messageIDs[start:end])
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to load messages for export")
+			failed += end - start
+			continue
+		}
+		failed += end - start - len(messages)
+
+		for _, m := range messages {
+			raw, err := a.syncEngine.FetchRawMessage(a.ctx, m.AccountID, m.FolderID, m.UID)
+			if err != nil {
+				log.Warn().Err(err).Str("messageId", m.ID).Msg("Failed to fetch message for export")
+				failed++
+				continue
+			}
+			raw = mbox.WithStatus(raw, statusFlags(m))
+
+			if writer != nil {
+				date := m.ReceivedAt
+				if date.IsZero() {
+					date = m.Date
+				}
+				if err := writer.WriteMessage(raw, m.FromEmail, date); err != nil {
+					writeErr = err
+					break
+				}
+			} else if err := writeEML(path, m, raw); err != nil {
+				writeErr = err
+				break
+			}
+			exported++
+
+			wailsRuntime.EventsEmit(a.ctx, "export:progress", map[string]interface{}{
+				"path":     path,
+				"exported": exported,
+				"total":    len(messageIDs),
+			})
+		}
+	}
+
+	if writer != nil {
+		if err := writer.Flush(); err != nil && writeErr == nil {
+			writeErr = err
+		}
+		if err := file.Close(); err != nil && writeErr == nil {
+			writeErr = err
+		}
+	}
+
+	if writeErr != nil {
+		log.Error().Err(writeErr).Str("path", path).Msg("Export failed")
+		failed = len(messageIDs) - exported
+	}
+	a.emitExportComplete(path, exported, failed, writeErr)
+
+	log.Info().
+		Str("path", path).
+		Int("exported", exported).
+		Int("failed", failed).
+		Msg("Export finished")
+}
+
+// emitExportComplete tells the frontend an export has finished
+func (a *App) emitExportComplete(path string, exported, failed int, err error) {
+	data := map[string]interface{}{
+		"path":     path,
+		"exported": exported,
+		"failed":   failed,
+	}
+	if err != nil {
+		data["error"] = err.Error()
+	}
+	wailsRuntime.EventsEmit(a.ctx, "export:complete", data)
+}
+
+// statusFlags returns a message's flags as mbox Status/X-Status flags
+func statusFlags(m *message.Message) mbox.Flags {
+	return mbox.Flags{
+		Seen:     m.IsRead,
+		Answered: m.IsAnswered,
+		Flagged:  m.IsStarred,
+		Deleted:  m.IsDeleted,
+		Draft:    m.IsDraft,
+	}
+}
+
+// writeEML writes a message to its own .eml file in dir
+func writeEML(dir string, m *message.Message, raw []byte) error {
+	name := m.Date.Format("2006-01-02 150405")
+	if subject := exportFilename(m.Subject); subject != "" {
+		name += " " + subject
+	}
+
+	path := filepath.Join(dir, name+".eml")
+	for i := 2; ; i++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if os.IsExist(err) {
+			path = filepath.Join(dir, fmt.Sprintf("%s (%d).eml", name, i))
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create file: %w", err)
+		}
+		if _, err := f.Write(raw); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		return f.Close()
+	}
+}
+
+// exportFilename makes a string safe to use as a file name
+func exportFilename(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, s)
+	s = strings.Trim(strings.TrimSpace(s), ".")
+
+	// Keep names well under file system limits
+	if runes := []rune(s); len(runes) > 80 {
+		s = strings.TrimSpace(string(runes[:80]))
+	}
+	return s
+}
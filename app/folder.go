@@ -2,9 +2,12 @@ package app
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/hkdb/aerion/internal/folder"
+	"github.com/hkdb/aerion/internal/imap"
 	"github.com/hkdb/aerion/internal/logging"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // ============================================================================
@@ -48,6 +51,39 @@ func (a *App) SyncFolders(accountID string) error {
 	return err
 }
 
+// SetFolderWatched sets whether a folder gets push updates (IDLE/NOTIFY) for
+// new mail, e.g. folders that server-side filters deliver into. INBOX is
+// always watched.
+func (a *App) SetFolderWatched(folderID string, watched bool) error {
+	log := logging.WithComponent("app")
+
+	f, err := a.folderStore.Get(folderID)
+	if err != nil {
+		return fmt.Errorf("failed to get folder: %w", err)
+	}
+	if f == nil {
+		return fmt.Errorf("folder not found: %s", folderID)
+	}
+
+	if err := a.folderStore.SetWatched(folderID, watched); err != nil {
+		return err
+	}
+
+	// Restart push connections so the new folder set takes effect
+	if a.idleManager != nil {
+		acc, err := a.accountStore.Get(f.AccountID)
+		if err == nil && acc != nil && acc.Enabled {
+			go a.restartAccountPush(acc)
+		}
+	}
+
+	log.Info().
+		Str("folder", f.Path).
+		Bool("watched", watched).
+		Msg("Folder watch setting changed")
+	return nil
+}
+
 // GetAccountFoldersForMapping returns all folders for an account (for folder mapping UI).
 // Triggers a folder sync if no folders exist yet.
 func (a *App) GetAccountFoldersForMapping(accountID string) ([]*folder.Folder, error) {
@@ -120,3 +156,392 @@ func (a *App) GetSpecialFolder(accountID string, folderType folder.Type) (*folde
 	// Fall back to auto-detected type
 	return a.folderStore.GetByType(accountID, folderType)
 }
+
+// ============================================================================
+// Folder Management API - Exposed to frontend via Wails bindings
+// ============================================================================
+
+// CreateFolder creates a folder on the server. parentID may be empty to
+// create a top-level folder.
+func (a *App) CreateFolder(accountID, parentID, name string) (*folder.Folder, error) {
+	log := logging.WithComponent("app")
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("folder name is required")
+	}
+
+	var parent *folder.Folder
+	if parentID != "" {
+		var err error
+		parent, err = a.folderStore.Get(parentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get parent folder: %w", err)
+		}
+		if parent == nil || parent.AccountID != accountID {
+			return nil, fmt.Errorf("parent folder not found: %s", parentID)
+		}
+	}
+
+	if a.isLocalAccount(accountID) {
+		return a.createLocalFolder(accountID, parent, name)
+	}
+
+	var path string
+	var status *imap.Mailbox
+	err := a.withIMAPRetry(accountID, func(conn *imap.Client) error {
+		delim, err := conn.GetDelimiter()
+		if err != nil {
+			return err
+		}
+		if err := validateFolderName(name, delim); err != nil {
+			return err
+		}
+
+		path = name
+		if parent != nil {
+			if delim == "" {
+				return fmt.Errorf("server does not support subfolders")
+			}
+			path = parent.Path + delim + name
+		}
+
+		if err := conn.CreateMailbox(path); err != nil {
+			return err
+		}
+		if err := conn.SetSubscribed(path, true); err != nil {
+			log.Warn().Err(err).Str("path", path).Msg("Failed to subscribe to new folder")
+		}
+
+		// Best effort: sync fills these in otherwise
+		status, err = conn.GetMailboxStatus(a.ctx, path)
+		if err != nil {
+			log.Warn().Err(err).Str("path", path).Msg("Failed to get status of new folder")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create folder: %w", err)
+	}
+
+	f := &folder.Folder{
+		AccountID:  accountID,
+		Name:       name,
+		Path:       path,
+		Type:       folder.TypeFolder,
+		Subscribed: true,
+	}
+	if parent != nil {
+		f.ParentID = parent.ID
+	}
+	if status != nil {
+		f.UIDValidity = status.UIDValidity
+		f.UIDNext = status.UIDNext
+		f.HighestModSeq = status.HighestModSeq
+		f.TotalCount = int(status.Messages)
+		f.UnreadCount = int(status.Unseen)
+	}
+
+	// Upsert in case a concurrent folder sync already picked it up
+	if err := a.folderStore.Upsert(f); err != nil {
+		return nil, err
+	}
+
+	log.Info().Str("account", accountID).Str("path", path).Msg("Folder created")
+	a.emitFoldersChanged(accountID)
+	return f, nil
+}
+
+// RenameFolder renames a folder in place. Subfolders follow it.
+func (a *App) RenameFolder(folderID, newName string) error {
+	f, err := a.getManageableFolder(folderID)
+	if err != nil {
+		return err
+	}
+
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return fmt.Errorf("folder name is required")
+	}
+	if newName == f.Name {
+		return nil
+	}
+
+	return a.relocateFolder(f, newName, func(delim string) (string, string, error) {
+		if err := validateFolderName(newName, delim); err != nil {
+			return "", "", err
+		}
+		return parentPath(f.Path, delim) + newName, f.ParentID, nil
+	})
+}
+
+// MoveFolder moves a folder (and its subfolders) under newParentID, or to
+// the top level if newParentID is empty
+func (a *App) MoveFolder(folderID, newParentID string) error {
+	f, err := a.getManageableFolder(folderID)
+	if err != nil {
+		return err
+	}
+	if newParentID == f.ParentID {
+		return nil
+	}
+
+	var newParent *folder.Folder
+	if newParentID != "" {
+		newParent, err = a.folderStore.Get(newParentID)
+		if err != nil {
+			return fmt.Errorf("failed to get destination folder: %w", err)
+		}
+		if newParent == nil || newParent.AccountID != f.AccountID {
+			return fmt.Errorf("destination folder not found: %s", newParentID)
+		}
+	}
+
+	return a.relocateFolder(f, f.Name, func(delim string) (string, string, error) {
+		if newParent == nil {
+			return f.Name, "", nil
+		}
+		if delim == "" {
+			return "", "", fmt.Errorf("server does not support subfolders")
+		}
+		if newParent.ID == f.ID || strings.HasPrefix(newParent.Path, f.Path+delim) {
+			return "", "", fmt.Errorf("cannot move a folder into itself")
+		}
+		return newParent.Path + delim + f.Name, newParent.ID, nil
+	})
+}
+
+// relocateFolder renames a folder on the server and updates the local rows
+// of it and its subfolders. target computes the new path and parent ID once
+// the hierarchy delimiter is known.
+func (a *App) relocateFolder(f *folder.Folder, newName string, target func(delim string) (newPath, newParentID string, err error)) error {
+	log := logging.WithComponent("app")
+
+	if a.isLocalAccount(f.AccountID) {
+		return a.relocateLocalFolder(f, newName, target)
+	}
+
+	var delim, newPath, newParentID string
+	var tree []*folder.Folder
+	err := a.withIMAPRetry(f.AccountID, func(conn *imap.Client) error {
+		var err error
+		delim, err = conn.GetDelimiter()
+		if err != nil {
+			return err
+		}
+		newPath, newParentID, err = target(delim)
+		if err != nil {
+			return err
+		}
+
+		tree, err = a.folderSubtree(f, delim)
+		if err != nil {
+			return err
+		}
+
+		if err := conn.RenameMailbox(f.Path, newPath); err != nil {
+			return err
+		}
+
+		// RENAME doesn't carry subscriptions over (RFC 3501 6.3.5)
+		for _, sf := range tree {
+			if !sf.Subscribed {
+				continue
+			}
+			renamed := newPath + strings.TrimPrefix(sf.Path, f.Path)
+			if err := conn.SetSubscribed(renamed, true); err != nil {
+				log.Warn().Err(err).Str("path", renamed).Msg("Failed to subscribe to renamed folder")
+			}
+			if err := conn.SetSubscribed(sf.Path, false); err != nil {
+				log.Debug().Err(err).Str("path", sf.Path).Msg("Failed to unsubscribe old folder path")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rename folder: %w", err)
+	}
+
+	// Folder IDs don't change, so messages and FTS data stay attached
+	if err := a.folderStore.Rename(f.ID, newName, newPath, newParentID, delim); err != nil {
+		return err
+	}
+
+	log.Info().
+		Str("account", f.AccountID).
+		Str("from", f.Path).
+		Str("to", newPath).
+		Msg("Folder renamed")
+
+	a.restartIdleIfWatched(f.AccountID, tree)
+	a.emitFoldersChanged(f.AccountID)
+	return nil
+}
+
+// DeleteFolder deletes a folder and its subfolders on the server, along with
+// their local messages
+func (a *App) DeleteFolder(folderID string) error {
+	log := logging.WithComponent("app")
+
+	f, err := a.getManageableFolder(folderID)
+	if err != nil {
+		return err
+	}
+
+	if a.isLocalAccount(f.AccountID) {
+		return a.deleteLocalFolder(f)
+	}
+
+	var tree []*folder.Folder
+	err = a.withIMAPRetry(f.AccountID, func(conn *imap.Client) error {
+		delim, err := conn.GetDelimiter()
+		if err != nil {
+			return err
+		}
+		tree, err = a.folderSubtree(f, delim)
+		if err != nil {
+			return err
+		}
+
+		// Deepest first: DELETE leaves inferior mailboxes in place. A
+		// retry after a dropped connection finds the folders deleted before
+		// the drop already gone.
+		for _, sf := range tree {
+			if err := conn.DeleteMailbox(sf.Path); err != nil {
+				if !imap.IsNonExistent(err) {
+					return err
+				}
+				log.Debug().Str("path", sf.Path).Msg("Folder already deleted on the server")
+			}
+			if sf.Subscribed {
+				if err := conn.SetSubscribed(sf.Path, false); err != nil {
+					log.Debug().Err(err).Str("path", sf.Path).Msg("Failed to unsubscribe deleted folder")
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete folder: %w", err)
+	}
+
+	// Delete messages explicitly so the FTS delete trigger runs for each row;
+	// the folder delete then cascades to fts_index_status
+	for _, sf := range tree {
+		if err := a.messageStore.DeleteByFolder(sf.ID); err != nil {
+			return fmt.Errorf("failed to delete folder messages: %w", err)
+		}
+		if err := a.folderStore.Delete(sf.ID); err != nil {
+			return err
+		}
+	}
+
+	log.Info().Str("account", f.AccountID).Str("path", f.Path).Int("folders", len(tree)).Msg("Folder deleted")
+
+	a.restartIdleIfWatched(f.AccountID, tree)
+	a.emitFoldersChanged(f.AccountID)
+	return nil
+}
+
+// SetFolderSubscribed subscribes to or unsubscribes from a folder on the server
+func (a *App) SetFolderSubscribed(folderID string, subscribed bool) error {
+	f, err := a.folderStore.Get(folderID)
+	if err != nil {
+		return fmt.Errorf("failed to get folder: %w", err)
+	}
+	if f == nil {
+		return fmt.Errorf("folder not found: %s", folderID)
+	}
+
+	// Local folders only have the local flag
+	if !a.isLocalAccount(f.AccountID) {
+		err = a.withIMAPRetry(f.AccountID, func(conn *imap.Client) error {
+			return conn.SetSubscribed(f.Path, subscribed)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := a.folderStore.SetSubscribed(folderID, subscribed); err != nil {
+		return err
+	}
+
+	a.emitFoldersChanged(f.AccountID)
+	return nil
+}
+
+// getManageableFolder returns a folder that may be renamed, moved or deleted
+func (a *App) getManageableFolder(folderID string) (*folder.Folder, error) {
+	f, err := a.folderStore.Get(folderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get folder: %w", err)
+	}
+	if f == nil {
+		return nil, fmt.Errorf("folder not found: %s", folderID)
+	}
+	if !f.CanDelete() {
+		return nil, fmt.Errorf("cannot modify special folder: %s", f.Name)
+	}
+	return f, nil
+}
+
+// folderSubtree returns a folder's descendants (deepest first) followed by
+// the folder itself. Special folders can't be buried in a subtree that is
+// renamed or deleted, since account mappings point at their paths.
+func (a *App) folderSubtree(f *folder.Folder, delim string) ([]*folder.Folder, error) {
+	descendants, err := a.folderStore.ListDescendants(f.AccountID, f.Path, delim)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range descendants {
+		if !d.CanDelete() {
+			return nil, fmt.Errorf("folder contains special folder: %s", d.Path)
+		}
+	}
+	return append(descendants, f), nil
+}
+
+// restartIdleIfWatched restarts push connections when any of the folders is
+// watched, since their paths changed or they no longer exist
+func (a *App) restartIdleIfWatched(accountID string, folders []*folder.Folder) {
+	if a.idleManager == nil {
+		return
+	}
+	for _, f := range folders {
+		if f.Watched {
+			acc, err := a.accountStore.Get(accountID)
+			if err == nil && acc != nil && acc.Enabled {
+				go a.restartAccountPush(acc)
+			}
+			return
+		}
+	}
+}
+
+// emitFoldersChanged tells the frontend to reload an account's folder tree
+func (a *App) emitFoldersChanged(accountID string) {
+	wailsRuntime.EventsEmit(a.ctx, "folders:changed", map[string]interface{}{
+		"accountId": accountID,
+	})
+}
+
+// validateFolderName rejects names that would create an unintended hierarchy
+func validateFolderName(name, delim string) error {
+	if delim != "" && strings.Contains(name, delim) {
+		return fmt.Errorf("folder name cannot contain %q", delim)
+	}
+	return nil
+}
+
+// parentPath returns the path prefix (including the trailing delimiter) of a
+// folder path, or "" for top-level folders
+func parentPath(path, delim string) string {
+	if delim == "" {
+		return ""
+	}
+	if i := strings.LastIndex(path, delim); i >= 0 {
+		return path[:i+len(delim)]
+	}
+	return ""
+}
@@ -0,0 +1,261 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	goImap "github.com/emersion/go-imap/v2"
+	"github.com/hkdb/aerion/internal/folder"
+	"github.com/hkdb/aerion/internal/imap"
+	"github.com/hkdb/aerion/internal/jmap"
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/hkdb/aerion/internal/mbox"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ============================================================================
+// Import API
+// ============================================================================
+
+// ImportMessages asks for mbox or .eml files and imports them into a
+// folder. Returns the chosen files, or nil if the user cancelled.
+func (a *App) ImportMessages(folderID string) ([]string, error) {
+	paths, err := wailsRuntime.OpenMultipleFilesDialog(a.ctx, wailsRuntime.OpenDialogOptions{
+		Title: "Import Messages",
+		Filters: []wailsRuntime.FileFilter{
+			{
+				DisplayName: "Mail Files (*.mbox, *.eml)",
+				Pattern:     "*.mbox;*.mbx;*.eml",
+			},
+			{
+				DisplayName: "All Files",
+				Pattern:     "*",
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file dialog: %w", err)
+	}
+
+	// User cancelled the dialog
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	return paths, a.ImportFiles(folderID, paths)
+}
+
+// ImportFiles imports mbox and .eml files into a folder. Files ending in
+// .eml hold a single message; anything else is read as an mbox. Flags in
+// Status/X-Status headers are kept. The import runs in the background and
+// reports "import:progress" and "import:complete" events.
+func (a *App) ImportFiles(folderID string, paths []string) error {
+	f, err := a.folderStore.Get(folderID)
+	if err != nil || f == nil {
+		return fmt.Errorf("folder not found: %s", folderID)
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("failed to open %s: %w", path, err)
+		}
+	}
+
+	go a.runImport(f, paths)
+
+	return nil
+}
+
+// ============================================================================
+// Import helpers
+// ============================================================================
+
+// runImport imports the messages in paths into f, reporting progress as it
+// goes
+func (a *App) runImport(f *folder.Folder, paths []string) {
+	log := logging.WithComponent("app")
+
+	total := 0
+	for _, path := range paths {
+		n, err := countImportMessages(path)
+		if err != nil {
+			log.Warn().Err(err).Str("path", path).Msg("Failed to count messages to import")
+		}
+		total += n
+	}
+
+	emitProgress := func(imported, failed int) {
+		wailsRuntime.EventsEmit(a.ctx, "import:progress", map[string]interface{}{
+			"accountId": f.AccountID,
+			"folderId":  f.ID,
+			"imported":  imported,
+			"failed":    failed,
+			"total":     total,
+		})
+	}
+	emitProgress(0, 0)
+
+	imported, failed := 0, 0
+	for _, path := range paths {
+		err := readImportFile(path, func(msg *mbox.Message) {
+			if err := a.importMessage(f, msg); err != nil {
+				log.Warn().Err(err).Str("path", path).Msg("Failed to import message")
+				failed++
+			} else {
+				imported++
+			}
+			emitProgress(imported, failed)
+		})
+		if err != nil {
+			log.Error().Err(err).Str("path", path).Msg("Failed to read import file")
+		}
+	}
+
+	// Pick up the imported messages
+	if a.isLocalAccount(f.AccountID) {
+		a.refreshLocalFolder(f.AccountID, f.ID)
+	} else if imported > 0 {
+		syncPeriodDays := 30
+		if acc, err := a.accountStore.Get(f.AccountID); err == nil && acc != nil {
+			syncPeriodDays = acc.SyncPeriodDays
+		}
+		if err := a.syncEngine.SyncMessages(a.ctx, f.AccountID, f.ID, syncPeriodDays); err != nil {
+			log.Warn().Err(err).Str("folderID", f.ID).Msg("Failed to sync folder after import")
+		}
+	}
+
+	wailsRuntime.EventsEmit(a.ctx, "import:complete", map[string]interface{}{
+		"accountId": f.AccountID,
+		"folderId":  f.ID,
+		"imported":  imported,
+		"failed":    failed,
+	})
+
+	log.Info().
+		Str("folder", f.Path).
+		Int("files", len(paths)).
+		Int("imported", imported).
+		Int("failed", failed).
+		Msg("Import finished")
+}
+
+// importMessage adds one message to f: straight into the database for
+// local folders, with JMAP Email/import or IMAP APPEND otherwise
+func (a *App) importMessage(f *folder.Folder, msg *mbox.Message) error {
+	var flags []goImap.Flag
+	if status, ok := mbox.StatusFlags(msg.Raw); ok {
+		flags = importFlags(status)
+	}
+	date := importDate(msg)
+
+	switch {
+	case a.isJMAPAccount(f.AccountID):
+		mailboxID, err := a.jmapMailboxID(f.ID)
+		if err != nil {
+			return err
+		}
+		keywords := make([]string, len(flags))
+		for i, flag := range flags {
+			keywords[i] = string(flag)
+		}
+		return a.withJMAP(f.AccountID, func(client *jmap.Client) error {
+			_, err := client.ImportEmail(a.ctx, msg.Raw, []string{mailboxID}, jmapKeywords(keywords), date)
+			return err
+		})
+
+	case a.isLocalAccount(f.AccountID):
+		_, err := a.syncEngine.StoreLocalMessage(f.AccountID, f.ID, msg.Raw, flags)
+		return err
+
+	default:
+		return a.withIMAPRetry(f.AccountID, func(conn *imap.Client) error {
+			_, err := conn.AppendMessage(f.Path, flags, date, msg.Raw)
+			return err
+		})
+	}
+}
+
+// importFlags returns the IMAP flags for mbox status flags. Deleted isn't
+// carried over, so imported messages aren't expunged on the next sync.
+func importFlags(status mbox.Flags) []goImap.Flag {
+	var flags []goImap.Flag
+	if status.Seen {
+		flags = append(flags, goImap.FlagSeen)
+	}
+	if status.Answered {
+		flags = append(flags, goImap.FlagAnswered)
+	}
+	if status.Flagged {
+		flags = append(flags, goImap.FlagFlagged)
+	}
+	if status.Draft {
+		flags = append(flags, goImap.FlagDraft)
+	}
+	return flags
+}
+
+// importDate returns the date a message was received: the mbox separator
+// date if there is one, else its Date header
+func importDate(msg *mbox.Message) time.Time {
+	if !msg.Date.IsZero() {
+		return msg.Date
+	}
+	if m, err := mail.ReadMessage(bytes.NewReader(msg.Raw)); err == nil {
+		if date, err := m.Header.Date(); err == nil {
+			return date
+		}
+	}
+	return time.Now()
+}
+
+// isEMLFile reports whether a file holds a single message rather than an mbox
+func isEMLFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".eml")
+}
+
+// readImportFile calls fn for each message in an mbox or .eml file
+func readImportFile(path string, fn func(msg *mbox.Message)) error {
+	if isEMLFile(path) {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fn(&mbox.Message{Raw: raw})
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	r := mbox.NewReader(file)
+	for {
+		msg, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fn(msg)
+	}
+}
+
+// countImportMessages returns how many messages an mbox or .eml file holds
+func countImportMessages(path string) (int, error) {
+	if isEMLFile(path) {
+		return 1, nil
+	}
+
+	count := 0
+	err := readImportFile(path, func(*mbox.Message) { count++ })
+	return count, err
+}
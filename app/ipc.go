@@ -66,6 +66,14 @@ func (a *App) handleIPCMessage(clientID string, msg ipc.Message) {
 		}
 		a.handleComposerMessageSent(payload)
 
+	case ipc.TypeOutboxQueued:
+		var payload ipc.OutboxQueuedPayload
+		if err := msg.ParsePayload(&payload); err != nil {
+			log.Error().Err(err).Msg("Failed to parse outbox_queued payload")
+			return
+		}
+		a.handleComposerOutboxQueued(payload)
+
 	case ipc.TypeDraftSaved:
 		var payload ipc.DraftSavedPayload
 		if err := msg.ParsePayload(&payload); err != nil {
@@ -121,6 +129,30 @@ func (a *App) handleComposerMessageSent(payload ipc.MessageSentPayload) {
 	}()
 }
 
+// handleComposerOutboxQueued is called when a composer adds a message to the
+// outbox. The main window owns delivery, so wake the sender and let the
+// frontend offer undo.
+func (a *App) handleComposerOutboxQueued(payload ipc.OutboxQueuedPayload) {
+	log := logging.WithComponent("app.ipc")
+
+	log.Info().
+		Str("accountID", payload.AccountID).
+		Int64("outboxID", payload.OutboxID).
+		Msg("Composer queued message notification")
+
+	m, err := a.outboxStore.Get(payload.OutboxID)
+	if err != nil {
+		log.Warn().Err(err).Int64("outboxID", payload.OutboxID).Msg("Failed to get queued message")
+	}
+	if m != nil {
+		wailsRuntime.EventsEmit(a.ctx, "outbox:queued", m)
+	}
+
+	if a.outboxSender != nil {
+		a.outboxSender.Wake()
+	}
+}
+
 // handleComposerDraftSaved is called when a composer saves a draft.
 // The composer window handles its own IMAP sync directly. We sync the Drafts
 // folder here so the main window's folder view shows the newly uploaded draft.
@@ -138,6 +170,12 @@ func (a *App) handleComposerDraftSaved(payload ipc.DraftSavedPayload) {
 		"draftId":   payload.DraftID,
 	})
 
+	// Composers leave JMAP and local drafts for this window to store
+	if a.isJMAPAccount(payload.AccountID) || a.isLocalAccount(payload.AccountID) {
+		go a.SyncPendingDrafts(payload.AccountID)
+		return
+	}
+
 	// Sync the Drafts folder to pick up the newly uploaded draft
 	// The notification is sent after the composer's IMAP upload completes,
 	// so we can sync immediately
@@ -183,9 +221,10 @@ func (a *App) handleComposerDraftDeleted(payload ipc.DraftDeletedPayload) {
 	}()
 }
 
-// OpenComposerWindow spawns a new detached composer window.
+// OpenComposerWindow spawns a new detached composer window, optionally
+// starting from a template.
 // This creates a separate process of the same executable with composer mode flags.
-func (a *App) OpenComposerWindow(accountID, mode, messageID, draftID string) error {
+func (a *App) OpenComposerWindow(accountID, mode, messageID, draftID, templateID string) error {
 	log := logging.WithComponent("app.ipc")
 
 	if a.ipcServer == nil || a.ipcTokenMgr == nil {
@@ -213,6 +252,9 @@ func (a *App) OpenComposerWindow(accountID, mode, messageID, draftID string) err
 			args = append(args, "--message-id", messageID)
 		}
 	}
+	if draftID == "" && templateID != "" {
+		args = append(args, "--template-id", templateID)
+	}
 
 	log.Info().
 		Str("execPath", execPath).
@@ -0,0 +1,554 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"time"
+
+	goImap "github.com/emersion/go-imap/v2"
+	"github.com/hkdb/aerion/internal/account"
+	"github.com/hkdb/aerion/internal/certificate"
+	"github.com/hkdb/aerion/internal/credentials"
+	"github.com/hkdb/aerion/internal/draft"
+	"github.com/hkdb/aerion/internal/folder"
+	"github.com/hkdb/aerion/internal/imap"
+	"github.com/hkdb/aerion/internal/jmap"
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/hkdb/aerion/internal/message"
+	"github.com/hkdb/aerion/internal/pendingop"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// errJMAPUnsupported is returned by IMAP-only features on JMAP accounts
+var errJMAPUnsupported = errors.New("not supported for JMAP accounts")
+
+// Bounds of the delay between EventSource reconnects
+const (
+	jmapPushRetryMin = 5 * time.Second
+	jmapPushRetryMax = 5 * time.Minute
+)
+
+// getJMAPConfig returns the JMAP client config for an account
+func (a *App) getJMAPConfig(accountID string) (*jmap.ClientConfig, error) {
+	acc, err := a.accountStore.Get(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if acc == nil {
+		return nil, fmt.Errorf("account not found: %s", accountID)
+	}
+	return jmapConfigForAccount(acc, a.credStore, a.certStore, a.getValidOAuthToken)
+}
+
+// jmapConfigForAccount builds the JMAP client config for an account,
+// including credentials. getToken returns a valid OAuth2 token.
+func jmapConfigForAccount(acc *account.Account, credStore *credentials.Store, certStore *certificate.Store, getToken func(accountID string) (*credentials.OAuthTokens, error)) (*jmap.ClientConfig, error) {
+	config := jmap.DefaultConfig()
+	config.SessionURL = acc.JMAPSessionURL
+	config.Username = acc.Username
+	tlsConfig, err := jmapTLSConfig(certStore, credStore, acc.ClientCertID)
+	if err != nil {
+		return nil, err
+	}
+	config.TLSConfig = tlsConfig
+
+	if acc.AuthType == account.AuthOAuth2 {
+		tokens, err := getToken(acc.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get OAuth token: %w", err)
+		}
+		config.AuthType = jmap.AuthTypeOAuth2
+		config.AccessToken = tokens.AccessToken
+	} else {
+		password, err := credStore.GetPassword(acc.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get password: %w", err)
+		}
+		config.AuthType = jmap.AuthTypePassword
+		config.Password = password
+	}
+
+	return &config, nil
+}
+
+// jmapTLSConfig returns the per-host TLS config for a JMAP client. The
+// session may send API and blob requests to other hosts, so the client
+// certificate is loaded once and presented to each of them.
+func jmapTLSConfig(certStore *certificate.Store, credStore *credentials.Store, clientCertID string) (func(host string) *tls.Config, error) {
+	var clientCert *tls.Certificate
+	if clientCertID != "" {
+		cert, err := certStore.LoadClientCertificate(clientCertID, credStore)
+		if err != nil {
+			return nil, err
+		}
+		clientCert = cert
+	}
+	return func(host string) *tls.Config {
+		config := certificate.BuildTLSConfig(host, certStore)
+		if clientCert != nil {
+			config.Certificates = []tls.Certificate{*clientCert}
+		}
+		return config
+	}, nil
+}
+
+// isJMAPAccount reports whether an account talks JMAP instead of IMAP/SMTP
+func (a *App) isJMAPAccount(accountID string) bool {
+	acc, err := a.accountStore.Get(accountID)
+	return err == nil && acc != nil && acc.IsJMAP()
+}
+
+// isServerUnreachable reports whether err means the account's server
+// couldn't be reached, so the operation is worth queueing for later
+func isServerUnreachable(err error) bool {
+	return imap.IsConnectionError(err) || jmap.IsTemporaryError(err)
+}
+
+// withJMAP runs op with the account's JMAP client
+func (a *App) withJMAP(accountID string, op func(client *jmap.Client) error) error {
+	client, err := a.jmapManager.Client(a.ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to connect to JMAP server: %w", err)
+	}
+	return op(client)
+}
+
+// jmapEmailIDs returns the server-side Email IDs of messages, without
+// duplicates. An Email in several folders has one message row per folder.
+func (a *App) jmapEmailIDs(messages []*message.Message) ([]string, error) {
+	messageIDs := make([]string, len(messages))
+	for i, m := range messages {
+		messageIDs[i] = m.ID
+	}
+
+	remoteIDs, err := a.messageStore.GetRemoteIDs(messageIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	seen := make(map[string]bool, len(remoteIDs))
+	for _, id := range messageIDs {
+		remoteID, ok := remoteIDs[id]
+		if !ok || seen[remoteID] {
+			continue
+		}
+		seen[remoteID] = true
+		ids = append(ids, remoteID)
+	}
+	return ids, nil
+}
+
+// jmapMailboxID returns the JMAP Mailbox a folder mirrors
+func (a *App) jmapMailboxID(folderID string) (string, error) {
+	mailboxID, err := a.folderStore.GetRemoteID(folderID)
+	if err != nil {
+		return "", err
+	}
+	if mailboxID == "" {
+		return "", fmt.Errorf("folder %s has no JMAP mailbox", folderID)
+	}
+	return mailboxID, nil
+}
+
+// specialMailboxID returns the JMAP Mailbox of an account's special folder,
+// or "" if it has none
+func specialMailboxID(folderStore *folder.Store, accountID string, folderType folder.Type) string {
+	f, err := folderStore.GetByType(accountID, folderType)
+	if err != nil || f == nil {
+		return ""
+	}
+	mailboxID, _ := folderStore.GetRemoteID(f.ID)
+	return mailboxID
+}
+
+// ============================================================================
+// Message actions
+// ============================================================================
+
+// setJMAPKeywords adds or removes keywords on the server copies of messages
+func (a *App) setJMAPKeywords(messages []*message.Message, keywords []string, add bool) error {
+	ids, err := a.jmapEmailIDs(messages)
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	return a.withJMAP(messages[0].AccountID, func(client *jmap.Client) error {
+		return client.SetKeywords(a.ctx, ids, keywords, add)
+	})
+}
+
+// jmapKeywords maps IMAP flags and keywords to JMAP keywords
+func jmapKeywords(flags []string) []string {
+	keywords := make([]string, len(flags))
+	for i, f := range flags {
+		keywords[i] = jmap.KeywordForFlag(f)
+	}
+	return keywords
+}
+
+// moveJMAPMessages moves messages from one folder to another on the server
+func (a *App) moveJMAPMessages(messages []*message.Message, sourceFolderID, destFolderID string) error {
+	ids, err := a.jmapEmailIDs(messages)
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	fromID, err := a.jmapMailboxID(sourceFolderID)
+	if err != nil {
+		return err
+	}
+	toID, err := a.jmapMailboxID(destFolderID)
+	if err != nil {
+		return err
+	}
+	return a.withJMAP(messages[0].AccountID, func(client *jmap.Client) error {
+		return client.MoveEmails(a.ctx, ids, fromID, toID)
+	})
+}
+
+// copyJMAPMessages adds messages to another folder on the server
+func (a *App) copyJMAPMessages(messages []*message.Message, destFolderID string) error {
+	ids, err := a.jmapEmailIDs(messages)
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	toID, err := a.jmapMailboxID(destFolderID)
+	if err != nil {
+		return err
+	}
+	return a.withJMAP(messages[0].AccountID, func(client *jmap.Client) error {
+		return client.CopyEmails(a.ctx, ids, toID)
+	})
+}
+
+// removeJMAPMessages removes messages from a folder on the server. Emails
+// in no other folder are destroyed.
+func (a *App) removeJMAPMessages(messages []*message.Message, folderID string) error {
+	ids, err := a.jmapEmailIDs(messages)
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	mailboxID, err := a.jmapMailboxID(folderID)
+	if err != nil {
+		return err
+	}
+	return a.withJMAP(messages[0].AccountID, func(client *jmap.Client) error {
+		return client.RemoveEmails(a.ctx, ids, mailboxID)
+	})
+}
+
+// destroyJMAPMessageAt destroys the Email listed at folderID/uid
+func (a *App) destroyJMAPMessageAt(accountID, folderID string, uid uint32) error {
+	m, err := a.messageStore.GetByUID(folderID, uid)
+	if err != nil || m == nil {
+		return err
+	}
+	ids, err := a.jmapEmailIDs([]*message.Message{m})
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	return a.withJMAP(accountID, func(client *jmap.Client) error {
+		return client.DestroyEmails(a.ctx, ids)
+	})
+}
+
+// replayJMAPOperation applies a queued operation on a JMAP account. The
+// operation addresses Emails by ID, so UIDVALIDITY doesn't apply.
+func (a *App) replayJMAPOperation(op *pendingop.Operation) error {
+	if op.Type == pendingop.TypeAppend {
+		mailboxID, err := a.jmapMailboxID(op.FolderID)
+		if err != nil {
+			return err
+		}
+		return a.withJMAP(op.AccountID, func(client *jmap.Client) error {
+			if _, err := client.ImportEmail(a.ctx, op.Data, []string{mailboxID}, jmapKeywords(op.Flags), op.CreatedAt); err != nil {
+				return fmt.Errorf("failed to import message: %w", err)
+			}
+			return nil
+		})
+	}
+
+	if len(op.RemoteIDs) == 0 {
+		if op.Type == pendingop.TypeExpunge {
+			return nil
+		}
+		return fmt.Errorf("%w: messages have no server-side IDs", errOperationConflict)
+	}
+
+	var mailboxID, destMailboxID string
+	var err error
+	switch op.Type {
+	case pendingop.TypeMove, pendingop.TypeExpunge:
+		if mailboxID, err = a.jmapMailboxID(op.FolderID); err != nil {
+			return fmt.Errorf("%w: %v", errOperationConflict, err)
+		}
+	}
+	switch op.Type {
+	case pendingop.TypeMove, pendingop.TypeCopy:
+		if destMailboxID, err = a.jmapMailboxID(op.DestFolderID); err != nil {
+			return fmt.Errorf("%w: %v", errOperationConflict, err)
+		}
+	}
+
+	return a.withJMAP(op.AccountID, func(client *jmap.Client) error {
+		switch op.Type {
+		case pendingop.TypeAddFlags:
+			return client.SetKeywords(a.ctx, op.RemoteIDs, jmapKeywords(op.Flags), true)
+		case pendingop.TypeRemoveFlags:
+			return client.SetKeywords(a.ctx, op.RemoteIDs, jmapKeywords(op.Flags), false)
+		case pendingop.TypeMove:
+			return client.MoveEmails(a.ctx, op.RemoteIDs, mailboxID, destMailboxID)
+		case pendingop.TypeCopy:
+			return client.CopyEmails(a.ctx, op.RemoteIDs, destMailboxID)
+		case pendingop.TypeExpunge:
+			return client.RemoveEmails(a.ctx, op.RemoteIDs, mailboxID)
+		default:
+			return fmt.Errorf("unknown operation type: %s", op.Type)
+		}
+	})
+}
+
+// fetchJMAPRuleHeaders downloads each message and parses its header block.
+// Local folders go through here too, reading the stored sources.
+func (a *App) fetchJMAPRuleHeaders(accountID, folderID string, messages []*message.Message) (map[goImap.UID]textproto.MIMEHeader, error) {
+	headers := make(map[goImap.UID]textproto.MIMEHeader, len(messages))
+	for _, m := range messages {
+		if m.UID == 0 {
+			continue
+		}
+		raw, err := a.syncEngine.FetchRawMessage(a.ctx, accountID, folderID, m.UID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch message headers: %w", err)
+		}
+		h, err := textproto.NewReader(bufio.NewReader(bytes.NewReader(raw))).ReadMIMEHeader()
+		if err != nil && len(h) == 0 {
+			continue
+		}
+		headers[goImap.UID(m.UID)] = h
+	}
+	return headers, nil
+}
+
+// ============================================================================
+// Drafts and sending
+// ============================================================================
+
+// storeJMAPDraft uploads a draft into the Drafts mailbox, replacing its
+// previous copy, and returns the UID it's listed under locally
+func (a *App) storeJMAPDraft(localDraft *draft.Draft, draftsFolder *folder.Folder, raw []byte) (uint32, error) {
+	log := logging.WithComponent("app.jmap")
+
+	mailboxID, err := a.jmapMailboxID(draftsFolder.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	if localDraft.IMAPUID > 0 && localDraft.FolderID != "" {
+		if err := a.destroyJMAPMessageAt(localDraft.AccountID, draftsFolder.ID, localDraft.IMAPUID); err != nil {
+			log.Warn().Err(err).Uint32("uid", localDraft.IMAPUID).Msg("Failed to delete old draft from JMAP server")
+		}
+	}
+
+	var emailID string
+	err = a.withJMAP(localDraft.AccountID, func(client *jmap.Client) error {
+		var err error
+		emailID, err = client.ImportEmail(a.ctx, raw, []string{mailboxID}, []string{jmap.KeywordDraft, jmap.KeywordSeen}, time.Now())
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to import draft: %w", err)
+	}
+
+	// Pull the new Email in so it gets a local UID
+	syncPeriodDays := 30
+	if acc, accErr := a.accountStore.Get(localDraft.AccountID); accErr == nil && acc != nil {
+		syncPeriodDays = acc.SyncPeriodDays
+	}
+	if err := a.syncEngine.SyncMessages(a.ctx, localDraft.AccountID, draftsFolder.ID, syncPeriodDays); err != nil {
+		log.Warn().Err(err).Msg("Failed to sync Drafts folder after draft upload")
+	}
+	wailsRuntime.EventsEmit(a.ctx, "folder:synced", map[string]interface{}{
+		"accountId": localDraft.AccountID,
+		"folderId":  draftsFolder.ID,
+	})
+
+	refs, err := a.messageStore.ListByRemoteIDs(localDraft.AccountID, []string{emailID})
+	if err != nil {
+		return 0, err
+	}
+	for _, ref := range refs {
+		if ref.FolderID == draftsFolder.ID {
+			return ref.UID, nil
+		}
+	}
+	return 0, nil
+}
+
+// submitJMAP sends a message through the account's JMAP server. The message
+// is imported into Drafts and submitted; the server files it in Sent.
+func submitJMAP(ctx context.Context, client *jmap.Client, folderStore *folder.Store, accountID, from string, recipients []string, raw []byte) error {
+	draftsID := specialMailboxID(folderStore, accountID, folder.TypeDrafts)
+	sentID := specialMailboxID(folderStore, accountID, folder.TypeSent)
+
+	importInto := draftsID
+	if importInto == "" {
+		importInto = sentID
+	}
+	if importInto == "" {
+		return fmt.Errorf("no Drafts or Sent mailbox to send from")
+	}
+
+	identities, err := client.GetIdentities(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get sending identities: %w", err)
+	}
+	identity := jmap.IdentityFor(identities, from)
+	if identity == nil {
+		return fmt.Errorf("no sending identity on server for %s", from)
+	}
+
+	emailID, err := client.ImportEmail(ctx, raw, []string{importInto}, []string{jmap.KeywordDraft, jmap.KeywordSeen}, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to upload message: %w", err)
+	}
+
+	envelope := jmap.Envelope{MailFrom: from, RcptTo: recipients}
+	if err := client.Submit(ctx, emailID, identity.ID, envelope, draftsID, sentID); err != nil {
+		// Don't leave the unsent copy behind as a draft
+		if destroyErr := client.DestroyEmails(ctx, []string{emailID}); destroyErr != nil {
+			log := logging.WithComponent("app.jmap")
+			log.Warn().Err(destroyErr).Str("emailID", emailID).Msg("Failed to remove unsent message")
+		}
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return nil
+}
+
+// ============================================================================
+// Push
+// ============================================================================
+
+// startAccountPush starts real-time change notifications for an account:
+// EventSource push on JMAP accounts, IDLE otherwise. POP3 and local folders
+// accounts have no push, so they rely on the sync interval.
+func (a *App) startAccountPush(acc *account.Account) {
+	if acc.IsJMAP() {
+		a.startJMAPPush(acc.ID)
+		return
+	}
+	if acc.HasLocalFolders() {
+		return
+	}
+	a.idleManager.StartAccount(acc.ID, acc.Name)
+}
+
+// restartAccountPush restarts IDLE so it picks up changed watched folders.
+// JMAP push covers every mailbox already.
+func (a *App) restartAccountPush(acc *account.Account) {
+	if acc.IsJMAP() || acc.HasLocalFolders() {
+		return
+	}
+	a.idleManager.RestartAccount(acc.ID, acc.Name)
+}
+
+// stopAccountPush stops real-time change notifications for an account
+func (a *App) stopAccountPush(accountID string) {
+	a.stopJMAPPush(accountID)
+	a.idleManager.StopAccount(accountID)
+}
+
+// startJMAPPush listens on an account's EventSource until stopped
+func (a *App) startJMAPPush(accountID string) {
+	a.jmapPushMu.Lock()
+	defer a.jmapPushMu.Unlock()
+
+	if _, running := a.jmapPush[accountID]; running {
+		return
+	}
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.jmapPush[accountID] = cancel
+	go a.runJMAPPush(ctx, accountID)
+}
+
+// stopJMAPPush stops an account's EventSource listener
+func (a *App) stopJMAPPush(accountID string) {
+	a.jmapPushMu.Lock()
+	defer a.jmapPushMu.Unlock()
+
+	if cancel, ok := a.jmapPush[accountID]; ok {
+		cancel()
+		delete(a.jmapPush, accountID)
+	}
+}
+
+// stopAllJMAPPush stops every EventSource listener, e.g. when going offline
+func (a *App) stopAllJMAPPush() {
+	a.jmapPushMu.Lock()
+	defer a.jmapPushMu.Unlock()
+
+	for accountID, cancel := range a.jmapPush {
+		cancel()
+		delete(a.jmapPush, accountID)
+	}
+}
+
+// runJMAPPush keeps an account's EventSource connected, reconnecting with
+// backoff. Without connectivity it waits instead of reconnecting.
+func (a *App) runJMAPPush(ctx context.Context, accountID string) {
+	log := logging.WithComponent("app.jmap-push")
+
+	delay := jmapPushRetryMin
+	for {
+		if a.networkMonitor == nil || a.networkMonitor.IsConnected() {
+			started := time.Now()
+			err := a.withJMAP(accountID, func(client *jmap.Client) error {
+				return client.Listen(ctx, []string{"Email", "Mailbox"}, func(changed map[string]string) {
+					a.handleJMAPStateChange(accountID, changed)
+				})
+			})
+			if ctx.Err() != nil {
+				return
+			}
+			// A connection that held for a while starts the backoff over
+			if time.Since(started) > jmapPushRetryMax {
+				delay = jmapPushRetryMin
+			}
+			log.Warn().Err(err).
+				Str("accountID", accountID).
+				Dur("retryIn", delay).
+				Msg("JMAP push disconnected")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, jmapPushRetryMax)
+	}
+}
+
+// handleJMAPStateChange syncs what a push notification says has changed.
+// Email changes are account-wide; the INBOX sync applies all of them.
+func (a *App) handleJMAPStateChange(accountID string, changed map[string]string) {
+	log := logging.WithComponent("app.jmap-push")
+
+	if _, ok := changed["Mailbox"]; ok {
+		if err := a.syncEngine.SyncFolders(a.ctx, accountID); err != nil {
+			log.Warn().Err(err).Str("accountID", accountID).Msg("Failed to sync folders after push")
+		} else {
+			a.emitFoldersChanged(accountID)
+		}
+	}
+
+	if _, ok := changed["Email"]; ok {
+		go a.handleIdleNewMail(imap.MailEvent{
+			Type:      imap.EventNewMail,
+			AccountID: accountID,
+		})
+	}
+}
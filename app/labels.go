@@ -0,0 +1,116 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/hkdb/aerion/internal/imap"
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/hkdb/aerion/internal/message"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ============================================================================
+// Gmail Labels API - Exposed to frontend via Wails bindings
+// ============================================================================
+
+// GetGmailLabels returns the labels in use on a Gmail account's messages,
+// including system labels such as \Inbox and \Important
+func (a *App) GetGmailLabels(accountID string) ([]string, error) {
+	return a.messageStore.ListGmailLabels(accountID)
+}
+
+// GetMessageGmailLabels returns the labels of a message. Empty for messages
+// from accounts without Gmail extensions.
+func (a *App) GetMessageGmailLabels(messageID string) ([]string, error) {
+	return a.messageStore.GetGmailLabels(messageID)
+}
+
+// AddGmailLabels adds labels to messages on the server. Adding a label
+// that has no folder yet creates it.
+func (a *App) AddGmailLabels(messageIDs []string, labels []string) error {
+	return a.updateGmailLabels(messageIDs, labels, true)
+}
+
+// RemoveGmailLabels removes labels from messages on the server. Removing
+// \Inbox archives a message.
+func (a *App) RemoveGmailLabels(messageIDs []string, labels []string) error {
+	return a.updateGmailLabels(messageIDs, labels, false)
+}
+
+// updateGmailLabels stores a label change on the server with STORE
+// X-GM-LABELS, one SELECT per folder the messages were synced from, then
+// updates the local label set
+func (a *App) updateGmailLabels(messageIDs []string, labels []string, add bool) error {
+	log := logging.WithComponent("app.labels")
+
+	if len(messageIDs) == 0 || len(labels) == 0 {
+		return nil
+	}
+
+	messages, err := a.messageStore.GetByIDs(messageIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get messages: %w", err)
+	}
+	gmIDs, err := a.messageStore.GetGmailMsgIDs(messageIDs)
+	if err != nil {
+		return err
+	}
+
+	// Group by account, then folder
+	byAccount := make(map[string]map[string][]*message.Message)
+	for _, m := range messages {
+		if _, ok := gmIDs[m.ID]; !ok {
+			return fmt.Errorf("message has no Gmail labels: %s", m.ID)
+		}
+		if byAccount[m.AccountID] == nil {
+			byAccount[m.AccountID] = make(map[string][]*message.Message)
+		}
+		byAccount[m.AccountID][m.FolderID] = append(byAccount[m.AccountID][m.FolderID], m)
+	}
+
+	for accountID, byFolder := range byAccount {
+		err := a.imapPool.WithGmail(accountID, func(gm *imap.GmailConn) error {
+			for folderID, folderMessages := range byFolder {
+				folderObj, err := a.folderStore.Get(folderID)
+				if err != nil || folderObj == nil {
+					return fmt.Errorf("folder not found: %s", folderID)
+				}
+				if err := gm.Select(folderObj.Path); err != nil {
+					return err
+				}
+
+				uids := make([]uint32, len(folderMessages))
+				for i, m := range folderMessages {
+					uids[i] = m.UID
+				}
+				if err := gm.StoreLabels(uids, labels, add); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		var msgIDs []uint64
+		for _, folderMessages := range byFolder {
+			for _, m := range folderMessages {
+				msgIDs = append(msgIDs, gmIDs[m.ID])
+			}
+		}
+		if err := a.messageStore.UpdateGmailLabels(accountID, msgIDs, labels, add); err != nil {
+			return err
+		}
+
+		log.Info().
+			Str("account", accountID).
+			Int("messages", len(msgIDs)).
+			Strs("labels", labels).
+			Bool("add", add).
+			Msg("Updated Gmail labels")
+	}
+
+	wailsRuntime.EventsEmit(a.ctx, "messages:labelsChanged", messageIDs)
+	return nil
+}
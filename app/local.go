@@ -0,0 +1,354 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	goImap "github.com/emersion/go-imap/v2"
+	"github.com/hkdb/aerion/internal/account"
+	"github.com/hkdb/aerion/internal/certificate"
+	"github.com/hkdb/aerion/internal/credentials"
+	"github.com/hkdb/aerion/internal/draft"
+	"github.com/hkdb/aerion/internal/folder"
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/hkdb/aerion/internal/message"
+	"github.com/hkdb/aerion/internal/pop3"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// localFolderDelimiter separates the levels of local folder paths
+const localFolderDelimiter = "/"
+
+// errLocalUnsupported is returned by server-only features on accounts whose
+// folders exist only locally
+var errLocalUnsupported = errors.New("not supported for local folders")
+
+// getPOP3Config returns the POP3 client config for an account
+func (a *App) getPOP3Config(accountID string) (*pop3.ClientConfig, error) {
+	acc, err := a.accountStore.Get(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if acc == nil {
+		return nil, fmt.Errorf("account not found: %s", accountID)
+	}
+	return pop3ConfigForAccount(acc, a.credStore, a.certStore, a.getValidOAuthToken)
+}
+
+// pop3ConfigForAccount builds the POP3 client config for an account,
+// including credentials. getToken returns a valid OAuth2 token.
+func pop3ConfigForAccount(acc *account.Account, credStore *credentials.Store, certStore *certificate.Store, getToken func(accountID string) (*credentials.OAuthTokens, error)) (*pop3.ClientConfig, error) {
+	config := pop3.DefaultConfig()
+	config.Host = acc.POP3Host
+	config.Port = acc.POP3Port
+	config.Security = pop3.SecurityType(acc.POP3Security)
+	config.Username = acc.Username
+	tlsConfig, err := certificate.BuildClientTLSConfig(acc.POP3Host, certStore, credStore, acc.ClientCertID)
+	if err != nil {
+		return nil, err
+	}
+	config.TLSConfig = tlsConfig
+
+	if acc.AuthType == account.AuthOAuth2 {
+		tokens, err := getToken(acc.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get OAuth token: %w", err)
+		}
+		config.AuthType = pop3.AuthTypeOAuth2
+		config.AccessToken = tokens.AccessToken
+	} else {
+		password, err := credStore.GetPassword(acc.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get password: %w", err)
+		}
+		config.AuthType = pop3.AuthTypePassword
+		if acc.POP3APOP {
+			config.AuthType = pop3.AuthTypeAPOP
+		}
+		config.Password = password
+	}
+
+	return &config, nil
+}
+
+// isLocalAccount reports whether an account's folders exist only in the
+// local database, so changes to them never go to a server
+func (a *App) isLocalAccount(accountID string) bool {
+	acc, err := a.accountStore.Get(accountID)
+	return err == nil && acc != nil && acc.HasLocalFolders()
+}
+
+// refreshLocalFolder recounts a local folder and tells the frontend to
+// reload it
+func (a *App) refreshLocalFolder(accountID, folderID string) {
+	log := logging.WithComponent("app")
+
+	total, err := a.messageStore.CountByFolder(folderID)
+	if err != nil {
+		log.Warn().Err(err).Str("folderID", folderID).Msg("Failed to count messages")
+		return
+	}
+	unread, err := a.messageStore.CountUnreadByFolder(folderID)
+	if err != nil {
+		log.Warn().Err(err).Str("folderID", folderID).Msg("Failed to count unread messages")
+		return
+	}
+	if err := a.folderStore.UpdateCounts(folderID, total, unread); err != nil {
+		log.Warn().Err(err).Str("folderID", folderID).Msg("Failed to update folder counts")
+		return
+	}
+
+	wailsRuntime.EventsEmit(a.ctx, "folders:countsChanged", map[string]int{folderID: unread})
+	wailsRuntime.EventsEmit(a.ctx, "folder:synced", map[string]interface{}{
+		"accountId": accountID,
+		"folderId":  folderID,
+	})
+}
+
+// storeLocalDraft saves a draft into a local Drafts folder, replacing the
+// copy saved before. Returns the UID of the new copy.
+func (a *App) storeLocalDraft(localDraft *draft.Draft, draftsFolder *folder.Folder, raw []byte) (uint32, error) {
+	if localDraft.IMAPUID > 0 && localDraft.FolderID != "" {
+		if err := a.deleteLocalMessageAt(draftsFolder.ID, localDraft.IMAPUID); err != nil {
+			return 0, err
+		}
+	}
+
+	m, err := a.syncEngine.StoreLocalMessage(localDraft.AccountID, draftsFolder.ID, raw, []goImap.Flag{goImap.FlagDraft, goImap.FlagSeen})
+	if err != nil {
+		return 0, fmt.Errorf("failed to store draft: %w", err)
+	}
+
+	a.refreshLocalFolder(localDraft.AccountID, draftsFolder.ID)
+	return m.UID, nil
+}
+
+// saveToLocalSent files a sent message in the account's local Sent folder
+func (a *App) saveToLocalSent(accountID string, raw []byte) error {
+	sentFolder, err := a.folderStore.GetByType(accountID, folder.TypeSent)
+	if err != nil {
+		return fmt.Errorf("failed to get Sent folder: %w", err)
+	}
+	if sentFolder == nil {
+		return fmt.Errorf("no Sent folder found")
+	}
+
+	if _, err := a.syncEngine.StoreLocalMessage(accountID, sentFolder.ID, raw, []goImap.Flag{goImap.FlagSeen}); err != nil {
+		return fmt.Errorf("failed to store sent message: %w", err)
+	}
+
+	a.refreshLocalFolder(accountID, sentFolder.ID)
+	return nil
+}
+
+// deleteLocalMessageAt deletes the message stored at folderID/uid, if any
+func (a *App) deleteLocalMessageAt(folderID string, uid uint32) error {
+	m, err := a.messageStore.GetByUID(folderID, uid)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return nil
+	}
+	if err := a.syncEngine.RemoveMaildirMessages([]string{m.ID}); err != nil {
+		return err
+	}
+	return a.messageStore.Delete(m.ID)
+}
+
+// transferToLocalFolder copies or moves messages of another account into a
+// folder of a local-folder account, such as to archive them off the server.
+// Each message's source is fetched and stored as a new message; a move then
+// deletes the originals as DeletePermanently does. Runs in the background.
+func (a *App) transferToLocalFolder(messages []*message.Message, destFolder *folder.Folder, move bool) error {
+	log := logging.WithComponent("app")
+
+	if !a.isLocalAccount(destFolder.AccountID) {
+		return fmt.Errorf("messages can only be moved to another account's local folders")
+	}
+
+	go func() {
+		var transferred []string
+		for i, m := range messages {
+			raw, err := a.syncEngine.FetchRawMessage(a.ctx, m.AccountID, m.FolderID, m.UID)
+			if err != nil {
+				log.Error().Err(err).Str("messageId", m.ID).Msg("Failed to fetch message for transfer")
+				continue
+			}
+			if _, err := a.syncEngine.StoreLocalMessage(destFolder.AccountID, destFolder.ID, raw, messageFlags(m)); err != nil {
+				log.Error().Err(err).Str("messageId", m.ID).Msg("Failed to store transferred message")
+				continue
+			}
+			transferred = append(transferred, m.ID)
+
+			wailsRuntime.EventsEmit(a.ctx, "messages:transferProgress", map[string]interface{}{
+				"destFolderId": destFolder.ID,
+				"current":      i + 1,
+				"total":        len(messages),
+			})
+		}
+
+		a.refreshLocalFolder(destFolder.AccountID, destFolder.ID)
+
+		event := "messages:copied"
+		if move {
+			event = "messages:moved"
+			if err := a.DeletePermanently(transferred); err != nil {
+				log.Error().Err(err).Msg("Failed to delete transferred messages from source")
+			}
+		}
+		wailsRuntime.EventsEmit(a.ctx, event, map[string]interface{}{
+			"messageIds":   transferred,
+			"destFolderId": destFolder.ID,
+		})
+
+		log.Info().
+			Str("destFolder", destFolder.Path).
+			Int("transferred", len(transferred)).
+			Int("requested", len(messages)).
+			Bool("move", move).
+			Msg("Messages transferred to local folder")
+	}()
+
+	return nil
+}
+
+// messageFlags returns the IMAP flags and keywords set on a message
+func messageFlags(m *message.Message) []goImap.Flag {
+	var flags []goImap.Flag
+	if m.IsRead {
+		flags = append(flags, goImap.FlagSeen)
+	}
+	if m.IsStarred {
+		flags = append(flags, goImap.FlagFlagged)
+	}
+	if m.IsAnswered {
+		flags = append(flags, goImap.FlagAnswered)
+	}
+	if m.IsForwarded {
+		flags = append(flags, "$Forwarded")
+	}
+	if m.IsDraft {
+		flags = append(flags, goImap.FlagDraft)
+	}
+	for _, tag := range m.Tags {
+		flags = append(flags, goImap.Flag(tag))
+	}
+	return flags
+}
+
+// ============================================================================
+// Folders
+// ============================================================================
+
+// createLocalFolder creates a folder of a local-folder account
+func (a *App) createLocalFolder(accountID string, parent *folder.Folder, name string) (*folder.Folder, error) {
+	log := logging.WithComponent("app")
+
+	if err := validateFolderName(name, localFolderDelimiter); err != nil {
+		return nil, err
+	}
+
+	path := name
+	if parent != nil {
+		path = parent.Path + localFolderDelimiter + name
+	}
+
+	existing, err := a.folderStore.GetByPath(accountID, path)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("folder already exists: %s", path)
+	}
+
+	f := &folder.Folder{
+		AccountID:  accountID,
+		Name:       name,
+		Path:       path,
+		Type:       folder.TypeFolder,
+		Subscribed: true,
+	}
+	if parent != nil {
+		f.ParentID = parent.ID
+	}
+	if err := a.syncEngine.CreateMaildirFolder(accountID, path); err != nil {
+		return nil, err
+	}
+	if err := a.folderStore.Create(f); err != nil {
+		return nil, err
+	}
+
+	log.Info().Str("account", accountID).Str("path", path).Msg("Local folder created")
+	a.emitFoldersChanged(accountID)
+	return f, nil
+}
+
+// relocateLocalFolder renames or moves a folder of a local-folder account,
+// along with its subfolders
+func (a *App) relocateLocalFolder(f *folder.Folder, newName string, target func(delim string) (newPath, newParentID string, err error)) error {
+	log := logging.WithComponent("app")
+
+	newPath, newParentID, err := target(localFolderDelimiter)
+	if err != nil {
+		return err
+	}
+	if _, err := a.folderSubtree(f, localFolderDelimiter); err != nil {
+		return err
+	}
+
+	existing, err := a.folderStore.GetByPath(f.AccountID, newPath)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != f.ID {
+		return fmt.Errorf("folder already exists: %s", newPath)
+	}
+
+	if err := a.syncEngine.RenameMaildirFolder(f.AccountID, f.Path, newPath); err != nil {
+		return err
+	}
+	if err := a.folderStore.Rename(f.ID, newName, newPath, newParentID, localFolderDelimiter); err != nil {
+		return err
+	}
+
+	log.Info().
+		Str("account", f.AccountID).
+		Str("from", f.Path).
+		Str("to", newPath).
+		Msg("Local folder renamed")
+
+	a.emitFoldersChanged(f.AccountID)
+	return nil
+}
+
+// deleteLocalFolder deletes a folder of a local-folder account, its
+// subfolders and all their messages
+func (a *App) deleteLocalFolder(f *folder.Folder) error {
+	log := logging.WithComponent("app")
+
+	tree, err := a.folderSubtree(f, localFolderDelimiter)
+	if err != nil {
+		return err
+	}
+
+	// Subfolders are directories inside the folder's Maildir directory
+	if err := a.syncEngine.RemoveMaildirFolder(f.AccountID, f.Path); err != nil {
+		return err
+	}
+
+	// Delete messages explicitly so the FTS delete trigger runs for each row
+	for _, sf := range tree {
+		if err := a.messageStore.DeleteByFolder(sf.ID); err != nil {
+			return fmt.Errorf("failed to delete folder messages: %w", err)
+		}
+		if err := a.folderStore.Delete(sf.ID); err != nil {
+			return err
+		}
+	}
+
+	log.Info().Str("account", f.AccountID).Str("path", f.Path).Int("folders", len(tree)).Msg("Local folder deleted")
+
+	a.emitFoldersChanged(f.AccountID)
+	return nil
+}
@@ -0,0 +1,200 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/hkdb/aerion/internal/mailmerge"
+	"github.com/hkdb/aerion/internal/send"
+	"github.com/hkdb/aerion/internal/smtp"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ============================================================================
+// Mail Merge API - Exposed to frontend via Wails bindings
+// ============================================================================
+
+// LoadMailMergeCSV opens a file picker for a CSV recipient list and parses
+// it. Returns nil if the picker was cancelled.
+func (a *App) LoadMailMergeCSV() (*mailmerge.List, error) {
+	path, err := wailsRuntime.OpenFileDialog(a.ctx, wailsRuntime.OpenDialogOptions{
+		Title: "Select Recipient List",
+		Filters: []wailsRuntime.FileFilter{
+			{
+				DisplayName: "CSV Files (*.csv)",
+				Pattern:     "*.csv",
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file dialog: %w", err)
+	}
+	if path == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV: %w", err)
+	}
+	defer f.Close()
+
+	return mailmerge.ParseCSV(f)
+}
+
+// GetAddressbookRecipients returns an addressbook's contacts as a mail
+// merge recipient list
+func (a *App) GetAddressbookRecipients(addressbookID string) (*mailmerge.List, error) {
+	contacts, err := a.carddavStore.ListContacts(addressbookID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &mailmerge.List{}
+	seen := make(map[string]bool, len(contacts))
+	for _, c := range contacts {
+		key := strings.ToLower(c.Email)
+		if key == "" || seen[key] {
+			list.Skipped++
+			continue
+		}
+		seen[key] = true
+		list.Recipients = append(list.Recipients, &mailmerge.Recipient{
+			Email:  c.Email,
+			Name:   c.DisplayName,
+			Fields: map[string]string{},
+		})
+	}
+	return list, nil
+}
+
+// PreviewMailMerge renders the message one recipient would get
+func (a *App) PreviewMailMerge(msg smtp.ComposeMessage, recipient mailmerge.Recipient) smtp.ComposeMessage {
+	return mailmerge.Render(msg, &recipient, time.Now())
+}
+
+// StartMailMerge creates a mail merge job sending msg to each recipient
+// separately and starts it. A rate of 0 uses the default.
+func (a *App) StartMailMerge(accountID, name string, msg smtp.ComposeMessage, recipients []*mailmerge.Recipient, ratePerMinute int) (*mailmerge.Job, error) {
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("no recipients")
+	}
+	if ratePerMinute <= 0 {
+		ratePerMinute = mailmerge.DefaultRatePerMinute
+	}
+	if ratePerMinute > mailmerge.MaxRatePerMinute {
+		ratePerMinute = mailmerge.MaxRatePerMinute
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = msg.Subject
+	}
+
+	job := &mailmerge.Job{
+		AccountID:     accountID,
+		Name:          name,
+		Message:       msg,
+		RatePerMinute: ratePerMinute,
+		Status:        mailmerge.JobRunning,
+	}
+	if err := a.mailMergeStore.CreateJob(job, recipients); err != nil {
+		return nil, err
+	}
+
+	log := logging.WithComponent("app")
+	log.Info().
+		Str("id", job.ID).
+		Int("recipients", job.Total).
+		Int("rate", ratePerMinute).
+		Msg("Mail merge job started")
+
+	if err := a.mailMergeRunner.Start(job.ID); err != nil {
+		return nil, err
+	}
+	a.emitMailMergeChanged(accountID)
+	return job, nil
+}
+
+// GetMailMergeJobs returns an account's mail merge jobs, newest first
+func (a *App) GetMailMergeJobs(accountID string) ([]*mailmerge.Job, error) {
+	return a.mailMergeStore.ListJobs(accountID)
+}
+
+// GetMailMergeRecipients returns a job's recipients with their delivery
+// results
+func (a *App) GetMailMergeRecipients(jobID string) ([]*mailmerge.Recipient, error) {
+	return a.mailMergeStore.ListRecipients(jobID)
+}
+
+// PauseMailMerge stops sending a job after the current message
+func (a *App) PauseMailMerge(jobID string) error {
+	return a.mailMergeRunner.Pause(jobID)
+}
+
+// ResumeMailMerge continues a paused or cancelled job
+func (a *App) ResumeMailMerge(jobID string) error {
+	return a.mailMergeRunner.Start(jobID)
+}
+
+// CancelMailMerge stops a job; recipients not yet sent are left pending
+func (a *App) CancelMailMerge(jobID string) error {
+	return a.mailMergeRunner.Cancel(jobID)
+}
+
+// RetryMailMerge sends again to a job's failed recipients
+func (a *App) RetryMailMerge(jobID string) error {
+	return a.mailMergeRunner.RetryFailed(jobID)
+}
+
+// DeleteMailMerge stops a job and removes it with its results
+func (a *App) DeleteMailMerge(jobID string) error {
+	job, err := a.mailMergeStore.GetJobSummary(jobID)
+	if err != nil {
+		return err
+	}
+	if err := a.mailMergeRunner.Delete(jobID); err != nil {
+		return err
+	}
+	if job != nil {
+		a.emitMailMergeChanged(job.AccountID)
+	}
+	return nil
+}
+
+// ============================================================================
+// Delivery
+// ============================================================================
+
+// initMailMergeRunner creates the mail merge runner and resumes jobs that
+// were still sending when the app last quit
+func (a *App) initMailMergeRunner() {
+	a.mailMergeRunner = mailmerge.NewRunner(a.mailMergeStore, a.sendService.SendNow, send.IsTemporaryError)
+
+	if a.networkMonitor != nil {
+		a.mailMergeRunner.SetConnectivityCheck(a.networkMonitor.IsConnected)
+	}
+
+	a.mailMergeRunner.SetProgressCallback(func(job *mailmerge.Job) {
+		wailsRuntime.EventsEmit(a.ctx, "mailmerge:progress", job)
+	})
+
+	// Messages bypass the outbox's per-message Sent sync; pick them all up
+	// at once
+	a.mailMergeRunner.SetDoneCallback(func(job *mailmerge.Job) {
+		go a.syncSentFolder(job.AccountID)
+		a.emitMailMergeChanged(job.AccountID)
+	})
+
+	a.mailMergeRunner.ResumeAll()
+}
+
+// emitMailMergeChanged tells the frontend to refresh an account's jobs
+func (a *App) emitMailMergeChanged(accountID string) {
+	wailsRuntime.EventsEmit(a.ctx, "mailmerge:changed", map[string]interface{}{
+		"accountId": accountID,
+	})
+}
@@ -32,6 +32,19 @@ func (a *App) GetMessage(id string) (*message.Message, error) {
 	return a.messageStore.Get(id)
 }
 
+// GetDeliveryReports returns the delivery status notifications and read
+// receipts received for a sent message
+func (a *App) GetDeliveryReports(messageID string) ([]*message.DeliveryReport, error) {
+	msg, err := a.messageStore.Get(messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	if msg == nil || msg.MessageID == "" {
+		return nil, nil
+	}
+	return a.messageStore.GetDeliveryReports(msg.MessageID)
+}
+
 // GetMessageSource fetches the raw RFC822 source of a message from the IMAP server
 func (a *App) GetMessageSource(messageID string) (string, error) {
 	log := logging.WithComponent("app")
@@ -92,7 +105,7 @@ func (a *App) FetchMessageBody(messageID string) (*message.Message, error) {
 
 // GetConversations returns conversations (threaded messages) for a folder with pagination
 // sortOrder can be "newest" (default) or "oldest"
-// filter can be "" (all), "unread", "starred", or "attachments"
+// filter can be "" (all), "unread", "starred", "attachments" or "tag:<keyword>"
 func (a *App) GetConversations(accountID, folderID string, offset, limit int, sortOrder, filter string) ([]*message.Conversation, error) {
 	return a.messageStore.ListConversationsByFolder(folderID, offset, limit, sortOrder, filter)
 }
@@ -1,10 +1,12 @@
 package app
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/hkdb/aerion/internal/account"
+	"github.com/hkdb/aerion/internal/auth"
 	"github.com/hkdb/aerion/internal/credentials"
 	"github.com/hkdb/aerion/internal/imap"
 	"github.com/hkdb/aerion/internal/logging"
@@ -37,6 +39,12 @@ func (a *App) StartOAuthFlow(provider string) error {
 		return fmt.Errorf("OAuth provider %s is not configured", provider)
 	}
 
+	// Providers without a browser redirect sign in with a code instead
+	if providerConfig, err := oauth2.GetProvider(provider); err == nil && providerConfig.Flow == oauth2.FlowDevice {
+		_, err := a.StartOAuthDeviceFlow(provider)
+		return err
+	}
+
 	log.Info().Str("provider", provider).Msg("Starting OAuth flow")
 
 	// Emit started event
@@ -60,33 +68,85 @@ func (a *App) StartOAuthFlow(provider string) error {
 	// Wait for callback in background
 	go func() {
 		tokens, email, err := a.oauth2Manager.WaitForCallback(a.ctx)
-		if err != nil {
-			log.Error().Err(err).Str("provider", provider).Msg("OAuth callback failed")
-			wailsRuntime.EventsEmit(a.ctx, "oauth:error", map[string]interface{}{
-				"provider": provider,
-				"error":    err.Error(),
-			})
-			return
-		}
+		a.finishOAuthFlow(provider, tokens, email, err)
+	}()
+
+	return nil
+}
+
+// StartOAuthDeviceFlow initiates the OAuth2 device authorization flow for a
+// provider, for when the browser can't reach a local callback (sandboxes,
+// remote desktops). The returned code is entered at the verification URL
+// on any device while the app polls for the tokens.
+// Emits events: oauth:started, oauth:device, oauth:success, oauth:error
+func (a *App) StartOAuthDeviceFlow(provider string) (*oauth2.DeviceAuthorization, error) {
+	log := logging.WithComponent("app.oauth")
 
-		// Store tokens temporarily for account creation
-		a.pendingOAuthTokens = tokens
-		a.pendingOAuthEmail = email
+	if !oauth2.IsProviderConfigured(provider) {
+		return nil, fmt.Errorf("OAuth provider %s is not configured", provider)
+	}
+
+	log.Info().Str("provider", provider).Msg("Starting OAuth device flow")
 
-		log.Info().
-			Str("provider", provider).
-			Str("email", email).
-			Msg("OAuth flow completed successfully")
+	wailsRuntime.EventsEmit(a.ctx, "oauth:started", map[string]interface{}{
+		"provider": provider,
+	})
 
-		// Emit success event with tokens info (frontend will handle account creation)
-		wailsRuntime.EventsEmit(a.ctx, "oauth:success", map[string]interface{}{
-			"provider":  provider,
-			"email":     email,
-			"expiresIn": tokens.ExpiresIn,
+	auth, session, err := a.oauth2Manager.StartDeviceFlow(a.ctx, provider)
+	if err != nil {
+		wailsRuntime.EventsEmit(a.ctx, "oauth:error", map[string]interface{}{
+			"provider": provider,
+			"error":    err.Error(),
 		})
+		return nil, fmt.Errorf("failed to start OAuth device flow: %w", err)
+	}
+
+	// The UI shows the code and link; the user may be on another machine
+	wailsRuntime.EventsEmit(a.ctx, "oauth:device", auth)
+
+	// Poll for the tokens in background
+	go func() {
+		tokens, email, err := a.oauth2Manager.WaitForDeviceToken(a.ctx, session)
+		a.finishOAuthFlow(provider, tokens, email, err)
 	}()
 
-	return nil
+	return auth, nil
+}
+
+// finishOAuthFlow holds the tokens of a completed flow for account creation
+// or re-authorization and tells the frontend how it went
+func (a *App) finishOAuthFlow(provider string, tokens *oauth2.TokenResponse, email string, err error) {
+	log := logging.WithComponent("app.oauth")
+
+	if errors.Is(err, oauth2.ErrAuthCancelled) {
+		// Cancelled or replaced by a newer flow, which reports for itself
+		log.Debug().Str("provider", provider).Msg("OAuth flow cancelled")
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Str("provider", provider).Msg("OAuth flow failed")
+		wailsRuntime.EventsEmit(a.ctx, "oauth:error", map[string]interface{}{
+			"provider": provider,
+			"error":    err.Error(),
+		})
+		return
+	}
+
+	// Store tokens temporarily for account creation
+	a.pendingOAuthTokens = tokens
+	a.pendingOAuthEmail = email
+
+	log.Info().
+		Str("provider", provider).
+		Str("email", email).
+		Msg("OAuth flow completed successfully")
+
+	// Emit success event with tokens info (frontend will handle account creation)
+	wailsRuntime.EventsEmit(a.ctx, "oauth:success", map[string]interface{}{
+		"provider":  provider,
+		"email":     email,
+		"expiresIn": tokens.ExpiresIn,
+	})
 }
 
 // CompleteOAuthAccountSetup completes account setup after successful OAuth flow.
@@ -120,46 +180,27 @@ func (a *App) CompleteOAuthAccountSetup(provider, email, accountName, displayNam
 		return nil, fmt.Errorf("unknown provider: %w", err)
 	}
 
-	// Build account config based on provider
-	var config account.AccountConfig
-	switch provider {
-	case "google":
-		config = account.AccountConfig{
-			Name:           accountName,
-			DisplayName:    displayName,
-			Color:          color,
-			Email:          email,
-			Username:       email,
-			AuthType:       account.AuthOAuth2,
-			IMAPHost:       "imap.gmail.com",
-			IMAPPort:       993,
-			IMAPSecurity:   account.SecurityTLS,
-			SMTPHost:       "smtp.gmail.com",
-			SMTPPort:       587,
-			SMTPSecurity:   account.SecurityStartTLS,
-			SyncPeriodDays: 180,
-			SyncInterval:   30,
-		}
-	case "microsoft":
-		config = account.AccountConfig{
-			Name:           accountName,
-			DisplayName:    displayName,
-			Color:          color,
-			Email:          email,
-			Username:       email,
-			AuthType:       account.AuthOAuth2,
-			IMAPHost:       "outlook.office365.com",
-			IMAPPort:       993,
-			IMAPSecurity:   account.SecurityTLS,
-			SMTPHost:       "smtp.office365.com",
-			SMTPPort:       587,
-			SMTPSecurity:   account.SecurityStartTLS,
-			SyncPeriodDays: 180,
-			SyncInterval:   30,
-		}
-	default:
+	// Build account config from the provider's servers
+	servers := providerConfig.Servers
+	if servers == nil {
 		return nil, fmt.Errorf("unsupported OAuth provider: %s", provider)
 	}
+	config := account.AccountConfig{
+		Name:           accountName,
+		DisplayName:    displayName,
+		Color:          color,
+		Email:          email,
+		Username:       email,
+		AuthType:       account.AuthOAuth2,
+		IMAPHost:       servers.IMAPHost,
+		IMAPPort:       servers.IMAPPort,
+		IMAPSecurity:   account.SecurityType(servers.IMAPSecurity),
+		SMTPHost:       servers.SMTPHost,
+		SMTPPort:       servers.SMTPPort,
+		SMTPSecurity:   account.SecurityType(servers.SMTPSecurity),
+		SyncPeriodDays: 180,
+		SyncInterval:   30,
+	}
 
 	// Create the account
 	acc, err := a.accountStore.Create(&config)
@@ -347,7 +388,7 @@ func (a *App) GetOAuthStatus(accountID string) (*OAuthStatus, error) {
 }
 
 // IsOAuthConfigured returns whether OAuth is configured for a provider.
-// This checks if the client ID was provided at build time.
+// This checks if a client ID was provided at build time or in a provider file.
 func (a *App) IsOAuthConfigured(provider string) bool {
 	return oauth2.IsProviderConfigured(provider)
 }
@@ -363,6 +404,39 @@ func (a *App) GetConfiguredOAuthProviders() []string {
 	return configured
 }
 
+// OAuthProviderInfo describes a mail OAuth provider for the account setup UI
+type OAuthProviderInfo struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Configured  bool   `json:"configured"`
+	Flow        string `json:"flow"`       // "loopback" or "device"
+	DeviceFlow  bool   `json:"deviceFlow"` // Can sign in with a code on another device
+}
+
+// GetOAuthProviders returns the mail OAuth providers in the registry,
+// including ones from provider files
+func (a *App) GetOAuthProviders() []OAuthProviderInfo {
+	var providers []OAuthProviderInfo
+	for _, name := range oauth2.SupportedProviders() {
+		p, err := oauth2.GetProvider(name)
+		if err != nil {
+			continue
+		}
+		flow := p.Flow
+		if flow == "" {
+			flow = oauth2.FlowLoopback
+		}
+		providers = append(providers, OAuthProviderInfo{
+			Name:        p.Name,
+			DisplayName: p.DisplayName,
+			Configured:  p.ClientID != "",
+			Flow:        string(flow),
+			DeviceFlow:  p.SupportsDeviceFlow(),
+		})
+	}
+	return providers
+}
+
 // ReauthorizeAccount initiates re-authorization for an existing OAuth account.
 // This is used when tokens have expired and refresh has failed.
 func (a *App) ReauthorizeAccount(accountID string) error {
@@ -395,6 +469,37 @@ func (a *App) ReauthorizeAccount(accountID string) error {
 	return a.StartOAuthFlow(provider)
 }
 
+// ReauthorizeAccountWithDeviceCode is ReauthorizeAccount using the device
+// authorization flow, for when the browser can't reach the app.
+func (a *App) ReauthorizeAccountWithDeviceCode(accountID string) (*oauth2.DeviceAuthorization, error) {
+	log := logging.WithComponent("app.oauth")
+
+	acc, err := a.accountStore.Get(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	if acc == nil {
+		return nil, fmt.Errorf("account not found: %s", accountID)
+	}
+
+	if acc.AuthType != account.AuthOAuth2 {
+		return nil, fmt.Errorf("account is not an OAuth account")
+	}
+
+	provider, err := a.credStore.GetOAuthProvider(accountID)
+	if err != nil || provider == "" {
+		return nil, fmt.Errorf("could not determine OAuth provider for account")
+	}
+
+	log.Info().
+		Str("accountID", accountID).
+		Str("provider", provider).
+		Msg("Starting device code re-authorization for account")
+
+	// Frontend will handle storing new tokens
+	return a.StartOAuthDeviceFlow(provider)
+}
+
 // TestOAuthConnection tests the connection for an OAuth account.
 // This verifies that the stored tokens work for IMAP access.
 func (a *App) TestOAuthConnection(accountID string) error {
@@ -424,6 +529,7 @@ func (a *App) TestOAuthConnection(accountID string) error {
 	clientConfig.Port = acc.IMAPPort
 	clientConfig.Security = imap.SecurityType(acc.IMAPSecurity)
 	clientConfig.Username = acc.Username
+	clientConfig.AuthMechanism = auth.Mechanism(acc.AuthMechanism)
 	clientConfig.AuthType = imap.AuthTypeOAuth2
 	clientConfig.AccessToken = tokens.AccessToken
 
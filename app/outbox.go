@@ -0,0 +1,159 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hkdb/aerion/internal/account"
+	"github.com/hkdb/aerion/internal/jmap"
+	"github.com/hkdb/aerion/internal/outbox"
+	"github.com/hkdb/aerion/internal/send"
+	"github.com/hkdb/aerion/internal/smtp"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ============================================================================
+// Outbox API - Exposed to frontend via Wails bindings
+// ============================================================================
+
+// GetOutbox returns the messages waiting to be sent for an account, including
+// scheduled, retrying and failed ones. An empty accountID returns the outbox
+// for all accounts.
+func (a *App) GetOutbox(accountID string) ([]*outbox.Message, error) {
+	return a.outboxStore.List(accountID)
+}
+
+// CancelOutboxMessage removes a message from the outbox without sending it
+func (a *App) CancelOutboxMessage(id int64) error {
+	m, err := a.outboxStore.Get(id)
+	if err != nil {
+		return err
+	}
+
+	cancelled, err := a.outboxStore.Cancel(id)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return fmt.Errorf("message is already being sent")
+	}
+
+	a.emitOutboxChanged(m)
+	return nil
+}
+
+// EditOutboxMessage takes a message out of the outbox and returns it for the
+// composer (undo send). Fails if delivery has already started or the message
+// was encrypted.
+func (a *App) EditOutboxMessage(id int64) (*smtp.ComposeMessage, error) {
+	m, err := a.outboxStore.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, fmt.Errorf("message is no longer in the outbox")
+	}
+	if !m.Editable {
+		return nil, fmt.Errorf("encrypted messages can't be edited")
+	}
+
+	var msg smtp.ComposeMessage
+	if err := json.Unmarshal(m.ComposeData, &msg); err != nil {
+		return nil, fmt.Errorf("failed to parse outbox message: %w", err)
+	}
+
+	cancelled, err := a.outboxStore.Cancel(id)
+	if err != nil {
+		return nil, err
+	}
+	if !cancelled {
+		return nil, fmt.Errorf("message is already being sent")
+	}
+
+	a.emitOutboxChanged(m)
+	return &msg, nil
+}
+
+// SendOutboxMessageNow sends a scheduled, retrying or failed message right away
+func (a *App) SendOutboxMessageNow(id int64) error {
+	m, err := a.outboxStore.Get(id)
+	if err != nil {
+		return err
+	}
+
+	requeued, err := a.outboxStore.Requeue(id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !requeued {
+		return fmt.Errorf("message is already being sent")
+	}
+
+	a.emitOutboxChanged(m)
+	a.outboxSender.Wake()
+	return nil
+}
+
+// ============================================================================
+// Delivery
+// ============================================================================
+
+// initSendService sets up the send pipeline used by the composer, the outbox
+// and rules that forward mail
+func (a *App) initSendService() {
+	a.sendService = send.NewService(a.accountStore, a.folderStore, a.contactStore, a.credStore, a.certStore, a.getValidOAuthToken)
+
+	// S/MIME first (sign-then-encrypt per RFC 5751), PGP only where S/MIME
+	// wasn't asked for
+	a.sendService.AddProtector(send.NewSMIMEProtector(a.smimeStore, a.smimeSigner, a.smimeEncryptor))
+	a.sendService.AddProtector(send.NewPGPProtector(a.pgpStore, a.pgpSigner, a.pgpEncryptor))
+
+	a.sendService.SetJMAPSubmitter(func(acc *account.Account, from string, recipients []string, raw []byte) error {
+		return a.withJMAP(acc.ID, func(client *jmap.Client) error {
+			return submitJMAP(a.ctx, client, a.folderStore, acc.ID, from, recipients, raw)
+		})
+	})
+	a.sendService.SetLocalFiler(a.saveToLocalSent)
+	a.sendService.SetAppendQueue(a.queuePendingOperation)
+
+	// Sync the Sent folder to pick up the sent message
+	a.sendService.AddPostProcessor(func(acc *account.Account, m *outbox.Message) {
+		go a.syncSentFolder(acc.ID)
+	})
+}
+
+// initOutboxSender creates and starts the background outbox sender
+func (a *App) initOutboxSender(ctx context.Context) {
+	a.outboxSender = outbox.NewSender(a.outboxStore, a.sendService.Deliver, send.IsTemporaryError)
+
+	// Leave due messages queued while offline; processNetworkEvents wakes the
+	// sender when connectivity returns
+	if a.networkMonitor != nil {
+		a.outboxSender.SetConnectivityCheck(a.networkMonitor.IsConnected)
+	}
+
+	a.outboxSender.SetResultCallback(func(m *outbox.Message, err error) {
+		switch {
+		case err == nil:
+			wailsRuntime.EventsEmit(a.ctx, "outbox:sent", m)
+		case m.Status == outbox.StatusFailed:
+			wailsRuntime.EventsEmit(a.ctx, "outbox:failed", m)
+		}
+		a.emitOutboxChanged(m)
+	})
+
+	a.outboxSender.Start(ctx)
+}
+
+// emitOutboxChanged tells the frontend to refresh the outbox for m's account
+func (a *App) emitOutboxChanged(m *outbox.Message) {
+	var accountID string
+	if m != nil {
+		accountID = m.AccountID
+	}
+	wailsRuntime.EventsEmit(a.ctx, "outbox:changed", map[string]interface{}{
+		"accountId": accountID,
+	})
+}
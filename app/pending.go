@@ -0,0 +1,350 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	goImap "github.com/emersion/go-imap/v2"
+	"github.com/hkdb/aerion/internal/imap"
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/hkdb/aerion/internal/message"
+	"github.com/hkdb/aerion/internal/pendingop"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// errOperationConflict marks a queued operation that can no longer be applied
+// because the server state changed while we were offline
+var errOperationConflict = errors.New("conflict")
+
+// ============================================================================
+// Pending Operations API - Exposed to frontend via Wails bindings
+// ============================================================================
+
+// GetPendingOperations returns the queued IMAP operations for an account in
+// replay order, including ones that hit a conflict or kept failing.
+// An empty accountID returns the queue for all accounts.
+func (a *App) GetPendingOperations(accountID string) ([]*pendingop.Operation, error) {
+	return a.pendingOpStore.List(accountID)
+}
+
+// DismissPendingOperation removes an operation from the queue without
+// applying it
+func (a *App) DismissPendingOperation(id int64) error {
+	if err := a.pendingOpStore.Delete(id); err != nil {
+		return err
+	}
+	wailsRuntime.EventsEmit(a.ctx, "pendingOperations:changed", nil)
+	return nil
+}
+
+// ============================================================================
+// Queueing
+// ============================================================================
+
+// newPendingOperation builds a queue entry for messages in one folder.
+// Returns nil if the folder no longer exists.
+func (a *App) newPendingOperation(opType pendingop.Type, messages []*message.Message, folderID string) *pendingop.Operation {
+	// Local folders are never out of sync with a server
+	if len(messages) == 0 || a.isLocalAccount(messages[0].AccountID) {
+		return nil
+	}
+
+	folderObj, err := a.folderStore.Get(folderID)
+	if err != nil || folderObj == nil {
+		return nil
+	}
+
+	uids := make([]uint32, len(messages))
+	for i, m := range messages {
+		uids[i] = m.UID
+	}
+
+	op := &pendingop.Operation{
+		AccountID:   messages[0].AccountID,
+		Type:        opType,
+		FolderID:    folderObj.ID,
+		FolderPath:  folderObj.Path,
+		UIDValidity: folderObj.UIDValidity,
+		UIDs:        uids,
+	}
+	if a.isJMAPAccount(op.AccountID) {
+		op.RemoteIDs, _ = a.jmapEmailIDs(messages)
+	}
+	return op
+}
+
+// runOrQueue runs an IMAP operation, or queues it for replay when the server
+// can't be reached. If the account already has queued operations, op is
+// queued behind them without running so the server sees changes in the order
+// they were made. Returns true if op was queued.
+func (a *App) runOrQueue(op *pendingop.Operation, run func() error) (bool, error) {
+	return a.runOrQueueAll([]*pendingop.Operation{op}, run)
+}
+
+// runOrQueueAll is runOrQueue for a run that makes several changes in one
+// account, described in order by ops. If the server can't be reached, all of
+// ops are queued, including any the run already got through, so each one
+// must be safe to apply twice up to the last. Nil entries are skipped.
+func (a *App) runOrQueueAll(ops []*pendingop.Operation, run func() error) (bool, error) {
+	log := logging.WithComponent("app.pending")
+
+	var queue []*pendingop.Operation
+	for _, op := range ops {
+		if op != nil {
+			queue = append(queue, op)
+		}
+	}
+	if len(queue) == 0 {
+		return false, run()
+	}
+	accountID := queue[0].AccountID
+
+	hasPending, err := a.pendingOpStore.HasPending(accountID)
+	if err != nil {
+		log.Warn().Err(err).Str("account", accountID).Msg("Failed to check pending operations")
+	}
+
+	if !hasPending {
+		err := run()
+		if err == nil || !isServerUnreachable(err) {
+			return false, err
+		}
+		for _, op := range queue {
+			log.Info().Err(err).
+				Str("account", accountID).
+				Str("type", string(op.Type)).
+				Msg("Server unreachable, queueing operation for replay")
+		}
+	}
+
+	for _, op := range queue {
+		if err := a.queuePendingOperation(op); err != nil {
+			return false, err
+		}
+	}
+
+	// Queued behind earlier operations while online: replay now rather than
+	// waiting for the next reconnect
+	if hasPending {
+		go a.replayAccountOperations(accountID)
+	}
+
+	return true, nil
+}
+
+// queuePendingOperation persists op and notifies the frontend
+func (a *App) queuePendingOperation(op *pendingop.Operation) error {
+	if err := a.pendingOpStore.Enqueue(op); err != nil {
+		return err
+	}
+	wailsRuntime.EventsEmit(a.ctx, "pendingOperations:changed", map[string]interface{}{
+		"accountId": op.AccountID,
+	})
+	return nil
+}
+
+// ============================================================================
+// Replay
+// ============================================================================
+
+// replayPendingOperations replays queued operations for every account.
+// Called when connectivity returns and on startup.
+func (a *App) replayPendingOperations() {
+	log := logging.WithComponent("app.pending")
+
+	accountIDs, err := a.pendingOpStore.ListAccountsWithPending()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list accounts with pending operations")
+		return
+	}
+
+	for _, accountID := range accountIDs {
+		a.replayAccountOperations(accountID)
+	}
+}
+
+// replayAccountOperations replays an account's queue in order. Replay stops
+// at the first connection error, leaving the rest queued for next time.
+// Operations the server rejects are retried up to pendingop.MaxAttempts
+// times; conflicts are kept in the queue for the user to see.
+func (a *App) replayAccountOperations(accountID string) {
+	log := logging.WithComponent("app.pending")
+
+	a.pendingReplayMu.Lock()
+	defer a.pendingReplayMu.Unlock()
+
+	// Folders whose contents changed and need a sync once the queue is drained
+	touched := make(map[string]bool)
+	replayed := 0
+
+	for {
+		ops, err := a.pendingOpStore.ListPending(accountID)
+		if err != nil {
+			log.Error().Err(err).Str("account", accountID).Msg("Failed to list pending operations")
+			return
+		}
+		if len(ops) == 0 {
+			break
+		}
+
+		for _, op := range ops {
+			err := a.replayOperation(op)
+			switch {
+			case err == nil:
+				if err := a.pendingOpStore.Delete(op.ID); err != nil {
+					log.Error().Err(err).Int64("id", op.ID).Msg("Failed to remove replayed operation")
+					return
+				}
+				replayed++
+				switch op.Type {
+				case pendingop.TypeMove, pendingop.TypeCopy:
+					touched[op.DestFolderID] = true
+				case pendingop.TypeAppend:
+					touched[op.FolderID] = true
+				}
+
+			case errors.Is(err, errOperationConflict):
+				log.Warn().Err(err).
+					Int64("id", op.ID).
+					Str("type", string(op.Type)).
+					Str("folder", op.FolderPath).
+					Msg("Pending operation conflicts with server state, not applied")
+				if err := a.pendingOpStore.MarkConflict(op.ID, err.Error()); err != nil {
+					log.Error().Err(err).Int64("id", op.ID).Msg("Failed to mark operation as conflicted")
+					return
+				}
+				// Local state no longer matches what we queued against
+				touched[op.FolderID] = true
+				touched[op.DestFolderID] = true
+
+			case isServerUnreachable(err):
+				log.Info().Err(err).Str("account", accountID).Msg("Server unreachable, pausing replay")
+				wailsRuntime.EventsEmit(a.ctx, "pendingOperations:changed", map[string]interface{}{
+					"accountId": accountID,
+				})
+				return
+
+			default:
+				log.Warn().Err(err).
+					Int64("id", op.ID).
+					Str("type", string(op.Type)).
+					Int("attempt", op.Attempts+1).
+					Msg("Pending operation rejected by server")
+				if err := a.pendingOpStore.RecordFailure(op.ID, err.Error()); err != nil {
+					log.Error().Err(err).Int64("id", op.ID).Msg("Failed to record operation failure")
+					return
+				}
+			}
+		}
+	}
+
+	if replayed > 0 {
+		log.Info().Str("account", accountID).Int("count", replayed).Msg("Replayed pending operations")
+	}
+	wailsRuntime.EventsEmit(a.ctx, "pendingOperations:changed", map[string]interface{}{
+		"accountId": accountID,
+	})
+
+	for folderID := range touched {
+		if folderID != "" {
+			a.syncAfterMove(accountID, folderID)
+		}
+	}
+}
+
+// replayOperation applies a single queued operation on the server.
+// Returns an error wrapping errOperationConflict if the mailbox's UIDVALIDITY
+// changed or none of the messages exist anymore.
+func (a *App) replayOperation(op *pendingop.Operation) error {
+	log := logging.WithComponent("app.pending")
+
+	if a.isJMAPAccount(op.AccountID) {
+		return a.replayJMAPOperation(op)
+	}
+
+	flags := make([]goImap.Flag, len(op.Flags))
+	for i, f := range op.Flags {
+		flags[i] = goImap.Flag(f)
+	}
+
+	return a.withIMAPRetry(op.AccountID, func(conn *imap.Client) error {
+		if op.Type == pendingop.TypeAppend {
+			if _, err := conn.AppendMessage(op.FolderPath, flags, op.CreatedAt, op.Data); err != nil {
+				return fmt.Errorf("failed to append message: %w", err)
+			}
+			return nil
+		}
+
+		mb, err := conn.SelectMailbox(a.ctx, op.FolderPath)
+		if err != nil {
+			return fmt.Errorf("failed to select mailbox: %w", err)
+		}
+		if op.UIDValidity != 0 && mb.UIDValidity != op.UIDValidity {
+			return fmt.Errorf("%w: UIDVALIDITY of %s changed from %d to %d",
+				errOperationConflict, op.FolderPath, op.UIDValidity, mb.UIDValidity)
+		}
+
+		queued := make([]goImap.UID, len(op.UIDs))
+		for i, uid := range op.UIDs {
+			queued[i] = goImap.UID(uid)
+		}
+		uids, err := conn.ExistingUIDs(queued)
+		if err != nil {
+			return err
+		}
+		if len(uids) < len(queued) {
+			log.Info().
+				Int64("id", op.ID).
+				Int("queued", len(queued)).
+				Int("remaining", len(uids)).
+				Msg("Some messages vanished from the server since the operation was queued")
+		}
+		if len(uids) == 0 {
+			if op.Type == pendingop.TypeExpunge {
+				// Already gone, which is what we wanted
+				return nil
+			}
+			return fmt.Errorf("%w: messages no longer exist in %s", errOperationConflict, op.FolderPath)
+		}
+
+		switch op.Type {
+		case pendingop.TypeAddFlags:
+			return conn.AddMessageFlags(uids, flags)
+		case pendingop.TypeRemoveFlags:
+			return conn.RemoveMessageFlags(uids, flags)
+		case pendingop.TypeMove:
+			_, err = conn.MoveMessages(uids, op.DestFolderPath)
+			return err
+		case pendingop.TypeCopy:
+			_, err = conn.CopyMessages(uids, op.DestFolderPath)
+			return err
+		case pendingop.TypeExpunge:
+			return conn.DeleteMessagesByUID(uids)
+		default:
+			return fmt.Errorf("unknown operation type: %s", op.Type)
+		}
+	})
+}
+
+// syncAfterMove syncs a folder that messages were moved or copied into so
+// they get their real UIDs, then drops the temporary rows MoveMessages left
+// behind
+func (a *App) syncAfterMove(accountID, folderID string) {
+	log := logging.WithComponent("app")
+
+	// Clear the debounce timestamp so this request isn't silently dropped
+	syncKey := accountID + ":" + folderID
+	a.syncMu.Lock()
+	delete(a.syncLastRequest, syncKey)
+	a.syncMu.Unlock()
+
+	if err := a.SyncFolder(accountID, folderID); err != nil && err != context.Canceled {
+		log.Warn().Err(err).Str("folderID", folderID).Msg("Failed to sync folder after move")
+	}
+
+	// The sync above fetched the real messages with correct UIDs
+	if err := a.messageStore.DeleteTempUIDs(folderID); err != nil {
+		log.Warn().Err(err).Str("folderID", folderID).Msg("Failed to clean up temp UIDs after move")
+	}
+}
@@ -179,34 +179,6 @@ func (a *App) GetPGPKeyForEmail(accountID, email string) (*pgp.Key, error) {
 	return key, nil
 }
 
-// shouldPGPSignMessage determines whether a message should be PGP signed
-func (a *App) shouldPGPSignMessage(accountID string, perMessageOverride bool) bool {
-	if perMessageOverride {
-		return a.HasPGPKey(accountID)
-	}
-
-	policy, err := a.pgpStore.GetSignPolicy(accountID)
-	if err != nil || policy != "always" {
-		return false
-	}
-
-	return a.HasPGPKey(accountID)
-}
-
-// shouldPGPEncryptMessage determines whether a message should be PGP encrypted
-func (a *App) shouldPGPEncryptMessage(accountID string, perMessageOverride bool) bool {
-	if perMessageOverride {
-		return a.HasPGPKey(accountID)
-	}
-
-	policy, err := a.pgpStore.GetEncryptPolicy(accountID)
-	if err != nil || policy != "always" {
-		return false
-	}
-
-	return a.HasPGPKey(accountID)
-}
-
 // CheckRecipientPGPKeys checks which recipients have PGP public keys available
 func (a *App) CheckRecipientPGPKeys(emails []string) (map[string]bool, error) {
 	armoredKeys, err := a.pgpStore.GetSenderKeyArmoreds(emails)
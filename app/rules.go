@@ -0,0 +1,705 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/textproto"
+	"strings"
+	"time"
+
+	goImap "github.com/emersion/go-imap/v2"
+	"github.com/hkdb/aerion/internal/folder"
+	"github.com/hkdb/aerion/internal/imap"
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/hkdb/aerion/internal/message"
+	"github.com/hkdb/aerion/internal/outbox"
+	"github.com/hkdb/aerion/internal/pendingop"
+	"github.com/hkdb/aerion/internal/rules"
+	"github.com/hkdb/aerion/internal/smtp"
+	"github.com/hkdb/aerion/internal/sync"
+)
+
+// rulesBatchSize limits how many messages are loaded per query, and how many
+// messages' headers are fetched per IMAP command when a rule needs headers
+// that aren't stored locally
+const rulesBatchSize = 500
+
+// ============================================================================
+// Rules API - Exposed to frontend via Wails bindings
+// ============================================================================
+
+// GetRules returns an account's rules in the order they are evaluated
+func (a *App) GetRules(accountID string) ([]*rules.Rule, error) {
+	return a.rulesStore.List(accountID)
+}
+
+// SaveRule creates or updates a rule. New rules are added to the end of the
+// list and apply to mail arriving from now on.
+func (a *App) SaveRule(rule rules.Rule) (*rules.Rule, error) {
+	if err := rules.Validate(&rule); err != nil {
+		return nil, err
+	}
+
+	// Move/copy destinations must belong to the same account
+	for _, action := range rule.Actions {
+		if action.Type != rules.ActionMove && action.Type != rules.ActionCopy {
+			continue
+		}
+		dest, err := a.folderStore.Get(action.Value)
+		if err != nil || dest == nil || dest.AccountID != rule.AccountID {
+			return nil, fmt.Errorf("destination folder not found: %s", action.Value)
+		}
+	}
+
+	if err := a.rulesStore.Save(&rule); err != nil {
+		return nil, err
+	}
+
+	// Start filtering from the current end of the inbox so the first run
+	// doesn't sweep up mail that was already there
+	if inbox, err := a.folderStore.GetByType(rule.AccountID, folder.TypeInbox); err == nil && inbox != nil {
+		a.rulesMu.Lock()
+		a.rulesWatermark(inbox)
+		a.rulesMu.Unlock()
+	}
+
+	return &rule, nil
+}
+
+// DeleteRule removes a rule
+func (a *App) DeleteRule(id string) error {
+	return a.rulesStore.Delete(id)
+}
+
+// ReorderRules sets the evaluation order of an account's rules
+func (a *App) ReorderRules(accountID string, ruleIDs []string) error {
+	return a.rulesStore.Reorder(accountID, ruleIDs)
+}
+
+// RunRulesOnFolder applies the account's enabled rules to every message in a
+// folder right away. Returns the number of messages that matched.
+func (a *App) RunRulesOnFolder(accountID, folderID string) (int, error) {
+	log := logging.WithComponent("app.rules")
+
+	ids, err := a.messageStore.GetAllIDsByFolder(folderID)
+	if err != nil {
+		return 0, err
+	}
+
+	a.rulesMu.Lock()
+	defer a.rulesMu.Unlock()
+
+	messages, err := a.getMessagesBatched(ids)
+	if err != nil {
+		return 0, err
+	}
+
+	matches, err := a.evaluateRules(accountID, folderID, messages)
+	if err != nil {
+		return 0, err
+	}
+
+	a.applyRuleActions(accountID, folderID, matches)
+
+	log.Info().
+		Str("account", accountID).
+		Str("folder", folderID).
+		Int("matched", len(matches)).
+		Msg("Ran rules on folder")
+
+	return len(matches), nil
+}
+
+// PreviewRulesOnFolder reports which messages in a folder the account's
+// enabled rules would match, and what they would do, without changing
+// anything (dry run)
+func (a *App) PreviewRulesOnFolder(accountID, folderID string) ([]*rules.Match, error) {
+	ids, err := a.messageStore.GetAllIDsByFolder(folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := a.getMessagesBatched(ids)
+	if err != nil {
+		return nil, err
+	}
+	return a.evaluateRules(accountID, folderID, messages)
+}
+
+// ============================================================================
+// New Mail
+// ============================================================================
+
+// applyRulesToNewMail runs the account's rules on inbox messages that arrived
+// since the last run. Returns true if every new message matched a rule that
+// suppresses notifications.
+func (a *App) applyRulesToNewMail(info sync.NewMailInfo) bool {
+	log := logging.WithComponent("app.rules")
+
+	inbox, err := a.folderStore.Get(info.FolderID)
+	if err != nil || inbox == nil || inbox.Type != folder.TypeInbox {
+		return false
+	}
+
+	a.rulesMu.Lock()
+	defer a.rulesMu.Unlock()
+
+	lastUID, ok := a.rulesWatermark(inbox)
+	if !ok {
+		return false
+	}
+
+	ids, err := a.messageStore.GetIDsAboveUID(inbox.ID, lastUID)
+	if err != nil {
+		log.Error().Err(err).Str("folder", inbox.ID).Msg("Failed to list new messages for rules")
+		return false
+	}
+	messages, err := a.getMessagesBatched(ids)
+	if err != nil || len(messages) == 0 {
+		return false
+	}
+
+	// Only advance past messages actually seen, in case more arrived meanwhile
+	highest := lastUID
+	for _, m := range messages {
+		highest = max(highest, m.UID)
+	}
+
+	matches, err := a.evaluateRules(info.AccountID, inbox.ID, messages)
+	if err != nil {
+		// Leave the watermark alone so these messages are tried again
+		log.Warn().Err(err).Str("account", info.AccountID).Msg("Failed to evaluate rules on new mail")
+		return false
+	}
+
+	// Advance before acting so a failed action isn't repeated on every sync
+	if err := a.rulesStore.SetLastUID(inbox.ID, inbox.UIDValidity, highest); err != nil {
+		log.Error().Err(err).Str("folder", inbox.ID).Msg("Failed to save rules watermark")
+		return false
+	}
+
+	if len(matches) == 0 {
+		return false
+	}
+
+	log.Info().
+		Str("account", info.AccountID).
+		Int("new", len(messages)).
+		Int("matched", len(matches)).
+		Msg("Applying rules to new mail")
+
+	a.applyRuleActions(info.AccountID, inbox.ID, matches)
+
+	if len(matches) < len(messages) {
+		return false
+	}
+	for _, m := range matches {
+		if !hasRuleAction(m, rules.ActionSkipNotification) {
+			return false
+		}
+	}
+	return true
+}
+
+// rulesWatermark returns the highest inbox UID rules have processed. The
+// first time (or after UIDVALIDITY changes) it records the current highest
+// UID and returns ok=false, so existing mail is left alone.
+// Caller must hold rulesMu.
+func (a *App) rulesWatermark(inbox *folder.Folder) (uint32, bool) {
+	log := logging.WithComponent("app.rules")
+
+	lastUID, ok, err := a.rulesStore.GetLastUID(inbox.ID, inbox.UIDValidity)
+	if err != nil {
+		log.Error().Err(err).Str("folder", inbox.ID).Msg("Failed to get rules watermark")
+		return 0, false
+	}
+	if ok {
+		return lastUID, true
+	}
+
+	highest, err := a.messageStore.GetHighestUID(inbox.ID)
+	if err != nil {
+		log.Error().Err(err).Str("folder", inbox.ID).Msg("Failed to get highest UID for rules")
+		return 0, false
+	}
+	if err := a.rulesStore.SetLastUID(inbox.ID, inbox.UIDValidity, highest); err != nil {
+		log.Error().Err(err).Str("folder", inbox.ID).Msg("Failed to save rules watermark")
+	}
+	return 0, false
+}
+
+// ============================================================================
+// Evaluation
+// ============================================================================
+
+// evaluateRules matches the account's enabled rules against the given
+// messages in one folder. Headers are fetched from the server only when a
+// rule needs them.
+func (a *App) evaluateRules(accountID, folderID string, messages []*message.Message) ([]*rules.Match, error) {
+	ruleList, err := a.rulesStore.List(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluator := rules.NewEvaluator(ruleList)
+	if evaluator.Empty() || len(messages) == 0 {
+		return nil, nil
+	}
+
+	var headers map[goImap.UID]textproto.MIMEHeader
+	if evaluator.NeedsHeaders() {
+		headers, err = a.fetchRuleHeaders(accountID, folderID, messages)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var matches []*rules.Match
+	for _, m := range messages {
+		match := evaluator.Evaluate(&rules.Message{
+			ID:             m.ID,
+			Subject:        m.Subject,
+			From:           []rules.Address{{Name: m.FromName, Email: m.FromEmail}},
+			To:             ruleAddresses(m.ToList),
+			Cc:             ruleAddresses(m.CcList),
+			Size:           int64(m.Size),
+			HasAttachments: m.HasAttachments,
+			Header:         headers[goImap.UID(m.UID)],
+		})
+		if match != nil {
+			matches = append(matches, match)
+		}
+	}
+	return matches, nil
+}
+
+// getMessagesBatched loads messages by ID in batches, keeping each query
+// well under SQLite's bound parameter limit
+func (a *App) getMessagesBatched(ids []string) ([]*message.Message, error) {
+	var messages []*message.Message
+	for start := 0; start < len(ids); start += rulesBatchSize {
+		end := min(start+rulesBatchSize, len(ids))
+		batch, err := a.messageStore.GetByIDs(ids[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to get messages: %w", err)
+		}
+		messages = append(messages, batch...)
+	}
+	return messages, nil
+}
+
+// fetchRuleHeaders fetches the header block of each message from the server
+func (a *App) fetchRuleHeaders(accountID, folderID string, messages []*message.Message) (map[goImap.UID]textproto.MIMEHeader, error) {
+	folderObj, err := a.folderStore.Get(folderID)
+	if err != nil || folderObj == nil {
+		return nil, fmt.Errorf("folder not found: %s", folderID)
+	}
+
+	if a.isJMAPAccount(accountID) || a.isLocalAccount(accountID) {
+		return a.fetchJMAPRuleHeaders(accountID, folderID, messages)
+	}
+
+	var uids []goImap.UID
+	for _, m := range messages {
+		if m.UID > 0 {
+			uids = append(uids, goImap.UID(m.UID))
+		}
+	}
+
+	headers := make(map[goImap.UID]textproto.MIMEHeader, len(uids))
+	err = a.withIMAPRetry(accountID, func(conn *imap.Client) error {
+		if _, err := conn.SelectMailbox(a.ctx, folderObj.Path); err != nil {
+			return fmt.Errorf("failed to select mailbox: %w", err)
+		}
+
+		for start := 0; start < len(uids); start += rulesBatchSize {
+			end := min(start+rulesBatchSize, len(uids))
+			batch, err := conn.FetchHeaders(uids[start:end])
+			if err != nil {
+				return err
+			}
+			for uid, h := range batch {
+				headers[uid] = h
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch message headers: %w", err)
+	}
+	return headers, nil
+}
+
+// ruleAddresses parses a stored address list for rule matching
+func ruleAddresses(list string) []rules.Address {
+	var addrs []rules.Address
+	for _, addr := range parseAddressList(list) {
+		addrs = append(addrs, rules.Address{Name: addr.Name, Email: addr.Address})
+	}
+	return addrs
+}
+
+// hasRuleAction reports whether a match includes an action of the given type
+func hasRuleAction(m *rules.Match, actionType rules.ActionType) bool {
+	for _, action := range m.Actions {
+		if action.Type == actionType {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================================
+// Actions
+// ============================================================================
+
+// ruleBatch is the server work for the matched messages that end up in the
+// same folder
+type ruleBatch struct {
+	dest     *folder.Folder // nil if the messages stay where they are
+	messages []*message.Message
+	read     []*message.Message
+	star     []*message.Message
+	keywords map[string][]*message.Message
+}
+
+// applyRuleActions carries out matched rule actions. Rules aren't the user's
+// own actions, so nothing goes on the undo stack. Copies and forwards run
+// first, while the messages are still in their folder. Then, per
+// destination, flags and keywords are stored and the messages moved in one
+// run, so the move can't expunge them before their flags reach the server.
+// Only the first move per message applies.
+func (a *App) applyRuleActions(accountID, folderID string, matches []*rules.Match) {
+	log := logging.WithComponent("app.rules")
+
+	folderObj, err := a.folderStore.Get(folderID)
+	if err != nil || folderObj == nil {
+		log.Error().Err(err).Str("folder", folderID).Msg("Rule folder not found")
+		return
+	}
+
+	ids := make([]string, len(matches))
+	for i, m := range matches {
+		ids[i] = m.MessageID
+	}
+	messages, err := a.messageStore.GetByIDs(ids)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get messages for rule actions")
+		return
+	}
+	byID := make(map[string]*message.Message, len(messages))
+	for _, m := range messages {
+		byID[m.ID] = m
+	}
+
+	batches := make(map[string]*ruleBatch)
+	copies := make(map[string][]*message.Message)
+	forwards := make(map[string][]*message.Message)
+	var read, star []*message.Message
+	keywords := make(map[string][]*message.Message)
+
+	for _, m := range matches {
+		msg := byID[m.MessageID]
+		if msg == nil {
+			continue
+		}
+
+		dest := ""
+		for _, action := range m.Actions {
+			if action.Type == rules.ActionMove && action.Value != folderID {
+				dest = action.Value
+				break
+			}
+		}
+		b := batches[dest]
+		if b == nil {
+			b = &ruleBatch{keywords: make(map[string][]*message.Message)}
+			if dest != "" {
+				b.dest, err = a.folderStore.Get(dest)
+				if err != nil || b.dest == nil {
+					log.Error().Err(err).Str("dest", dest).Msg("Rule destination folder not found")
+				}
+			}
+			batches[dest] = b
+		}
+		b.messages = append(b.messages, msg)
+
+		done := make(map[rules.Action]bool)
+		for _, action := range m.Actions {
+			if done[action] {
+				continue
+			}
+			done[action] = true
+
+			switch action.Type {
+			case rules.ActionMarkRead:
+				read = append(read, msg)
+				b.read = append(b.read, msg)
+			case rules.ActionStar:
+				star = append(star, msg)
+				b.star = append(b.star, msg)
+			case rules.ActionAddKeyword:
+				keywords[action.Value] = append(keywords[action.Value], msg)
+				b.keywords[action.Value] = append(b.keywords[action.Value], msg)
+			case rules.ActionCopy:
+				if action.Value != folderID {
+					copies[action.Value] = append(copies[action.Value], msg)
+				}
+			case rules.ActionForward:
+				forwards[action.Value] = append(forwards[action.Value], msg)
+			}
+		}
+	}
+
+	// Local state first
+	inFolder := func(msgs []*message.Message) map[string][]*message.Message {
+		return map[string][]*message.Message{folderID: msgs}
+	}
+	if len(read) > 0 {
+		if err := a.setFlagLocal(messageIDs(read), inFolder(read), "read", true); err != nil {
+			log.Error().Err(err).Msg("Rule failed to mark messages as read")
+		}
+	}
+	if len(star) > 0 {
+		if err := a.setFlagLocal(messageIDs(star), inFolder(star), "starred", true); err != nil {
+			log.Error().Err(err).Msg("Rule failed to star messages")
+		}
+	}
+
+	// Keywords show up as tags; keep them locally if the server can't
+	keywordsAllowed, err := a.folderStore.KeywordsAllowed(folderID)
+	if err != nil {
+		log.Error().Err(err).Str("folder", folderID).Msg("Failed to check keyword support")
+	}
+	for keyword, msgs := range keywords {
+		if err := a.messageStore.AddTags(messageIDs(msgs), []string{keyword}, !keywordsAllowed); err != nil {
+			log.Error().Err(err).Str("keyword", keyword).Msg("Rule failed to add keyword")
+		}
+	}
+
+	local := a.isLocalAccount(accountID)
+	touched := make(map[string]bool)
+
+	for dest, msgs := range copies {
+		destFolder, err := a.folderStore.Get(dest)
+		if err != nil || destFolder == nil {
+			log.Error().Err(err).Str("dest", dest).Msg("Rule copy destination not found")
+			continue
+		}
+		if local || destFolder.AccountID != accountID {
+			if err := a.CopyToFolder(messageIDs(msgs), dest); err != nil {
+				log.Error().Err(err).Str("dest", dest).Msg("Rule failed to copy messages")
+			}
+			continue
+		}
+
+		op := a.newPendingOperation(pendingop.TypeCopy, msgs, folderID)
+		if op != nil {
+			op.DestFolderID = destFolder.ID
+			op.DestFolderPath = destFolder.Path
+		}
+		queued, err := a.runOrQueue(op, func() error {
+			return a.copyMessagesToIMAP(msgs, folderID, destFolder)
+		})
+		if err != nil {
+			log.Error().Err(err).Str("dest", dest).Msg("Rule failed to copy messages")
+			continue
+		}
+		if !queued {
+			touched[dest] = true
+		}
+	}
+
+	for to, msgs := range forwards {
+		for _, msg := range msgs {
+			if err := a.forwardByRule(accountID, msg.ID, to); err != nil {
+				log.Error().Err(err).Str("message", msg.ID).Msg("Rule failed to forward message")
+			}
+		}
+	}
+
+	for _, b := range batches {
+		// Same-account moves happen on the server; moves into another
+		// account's local folders are transfers done afterwards
+		serverMove := b.dest != nil && b.dest.AccountID == accountID && !local
+
+		var ops []*pendingop.Operation
+		if op := a.newPendingOperation(pendingop.TypeAddFlags, b.read, folderID); op != nil {
+			op.Flags = []string{string(goImap.FlagSeen)}
+			ops = append(ops, op)
+		}
+		if op := a.newPendingOperation(pendingop.TypeAddFlags, b.star, folderID); op != nil {
+			op.Flags = []string{string(goImap.FlagFlagged)}
+			ops = append(ops, op)
+		}
+		if keywordsAllowed {
+			for keyword, msgs := range b.keywords {
+				if op := a.newPendingOperation(pendingop.TypeAddFlags, msgs, folderID); op != nil {
+					op.Flags = []string{keyword}
+					ops = append(ops, op)
+				}
+			}
+		}
+		if serverMove {
+			if op := a.newPendingOperation(pendingop.TypeMove, b.messages, folderID); op != nil {
+				op.DestFolderID = b.dest.ID
+				op.DestFolderPath = b.dest.Path
+				ops = append(ops, op)
+			}
+		}
+
+		queued, err := a.runOrQueueAll(ops, func() error {
+			if err := a.syncFlagsToIMAP(b.read, folderID, "read", true); err != nil {
+				return err
+			}
+			if err := a.syncFlagsToIMAP(b.star, folderID, "starred", true); err != nil {
+				return err
+			}
+			if keywordsAllowed {
+				for keyword, msgs := range b.keywords {
+					if err := a.storeKeyword(msgs, folderObj, keyword); err != nil {
+						return err
+					}
+				}
+			}
+			if serverMove {
+				_, err := a.moveMessagesToIMAP(b.messages, folderID, b.dest)
+				return err
+			}
+			return nil
+		})
+		if err != nil {
+			log.Error().Err(err).Msg("Rule failed to update messages on the server")
+			continue
+		}
+
+		switch {
+		case b.dest == nil:
+		case b.dest.AccountID != accountID:
+			if err := a.transferToLocalFolder(b.messages, b.dest, true); err != nil {
+				log.Error().Err(err).Str("dest", b.dest.ID).Msg("Rule failed to move messages")
+			}
+		default:
+			if err := a.moveMessagesLocal(accountID, messageIDs(b.messages), inFolder(b.messages), b.dest.ID); err != nil {
+				log.Error().Err(err).Str("dest", b.dest.ID).Msg("Rule failed to move messages")
+				continue
+			}
+			if serverMove && !queued {
+				touched[b.dest.ID] = true
+			}
+		}
+	}
+
+	// Fetch the copied and moved messages with their new UIDs. If anything
+	// was queued, this happens after replay instead.
+	if len(touched) > 0 {
+		go func() {
+			for dest := range touched {
+				a.syncAfterMove(accountID, dest)
+			}
+		}()
+	}
+}
+
+// storeKeyword sets an IMAP keyword on messages in one folder on the server
+func (a *App) storeKeyword(messages []*message.Message, folderObj *folder.Folder, keyword string) error {
+	if len(messages) == 0 || a.isLocalAccount(messages[0].AccountID) {
+		return nil
+	}
+	if a.isJMAPAccount(messages[0].AccountID) {
+		return a.setJMAPKeywords(messages, []string{keyword}, true)
+	}
+
+	uids := make([]goImap.UID, len(messages))
+	for i, m := range messages {
+		uids[i] = goImap.UID(m.UID)
+	}
+	return a.withIMAPRetry(messages[0].AccountID, func(conn *imap.Client) error {
+		if _, err := conn.SelectMailbox(a.ctx, folderObj.Path); err != nil {
+			return fmt.Errorf("failed to select mailbox: %w", err)
+		}
+		return conn.AddMessageFlags(uids, []goImap.Flag{goImap.Flag(keyword)})
+	})
+}
+
+// forwardByRule forwards a message as an attached .eml and leaves it in the
+// outbox for background delivery
+func (a *App) forwardByRule(accountID, messageID, to string) error {
+	msg, err := a.messageStore.Get(messageID)
+	if err != nil || msg == nil {
+		return fmt.Errorf("message not found: %s", messageID)
+	}
+
+	folderObj, err := a.folderStore.Get(msg.FolderID)
+	if err != nil || folderObj == nil {
+		return fmt.Errorf("folder not found: %s", msg.FolderID)
+	}
+
+	var raw []byte
+	if a.isJMAPAccount(accountID) || a.isLocalAccount(accountID) {
+		raw, err = a.syncEngine.FetchRawMessage(a.ctx, accountID, msg.FolderID, msg.UID)
+	} else {
+		err = a.withIMAPRetry(accountID, func(conn *imap.Client) error {
+			if _, err := conn.SelectMailbox(a.ctx, folderObj.Path); err != nil {
+				return fmt.Errorf("failed to select mailbox: %w", err)
+			}
+			raw, err = conn.FetchRawMessage(goImap.UID(msg.UID))
+			return err
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch message: %w", err)
+	}
+
+	from, err := a.defaultFromAddress(accountID)
+	if err != nil {
+		return err
+	}
+
+	subject := msg.Subject
+	if !strings.HasPrefix(strings.ToLower(subject), "fwd:") && !strings.HasPrefix(strings.ToLower(subject), "fw:") {
+		subject = "Fwd: " + subject
+	}
+
+	compose := smtp.ComposeMessage{
+		From:        from,
+		To:          []smtp.Address{{Address: to}},
+		Subject:     subject,
+		Attachments: []smtp.Attachment{messageAttachment(msg.Subject, raw)},
+	}
+
+	rawMsg, _, err := a.sendService.Build(accountID, compose)
+	if err != nil {
+		return err
+	}
+	composeData, err := json.Marshal(compose)
+	if err != nil {
+		return fmt.Errorf("failed to serialize message: %w", err)
+	}
+
+	m := &outbox.Message{
+		AccountID:   accountID,
+		FromAddress: from.Address,
+		Recipients:  compose.AllRecipients(),
+		Subject:     subject,
+		RawMessage:  rawMsg,
+		ComposeData: composeData,
+		SendAt:      time.Now(),
+	}
+	if err := a.outboxStore.Create(m); err != nil {
+		return err
+	}
+
+	a.emitOutboxChanged(m)
+	a.outboxSender.Wake()
+	return nil
+}
+
+// messageIDs returns the IDs of messages
+func messageIDs(messages []*message.Message) []string {
+	ids := make([]string, len(messages))
+	for i, m := range messages {
+		ids[i] = m.ID
+	}
+	return ids
+}
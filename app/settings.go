@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/hkdb/aerion/internal/account"
-	"github.com/hkdb/aerion/internal/certificate"
 	"github.com/hkdb/aerion/internal/logging"
 	"github.com/hkdb/aerion/internal/settings"
 	"github.com/hkdb/aerion/internal/smtp"
@@ -39,6 +37,17 @@ func (a *App) SetMarkAsReadDelay(delayMs int) error {
 	return a.settingsStore.SetMarkAsReadDelay(delayMs)
 }
 
+// GetUndoSendDelay returns how long sent messages can be undone, in seconds
+// (0 = send immediately)
+func (a *App) GetUndoSendDelay() (int, error) {
+	return a.settingsStore.GetUndoSendDelay()
+}
+
+// SetUndoSendDelay sets how long sent messages can be undone (0-30 seconds)
+func (a *App) SetUndoSendDelay(seconds int) error {
+	return a.settingsStore.SetUndoSendDelay(seconds)
+}
+
 // GetMessageListDensity returns the message list density setting
 func (a *App) GetMessageListDensity() (string, error) {
 	return a.settingsStore.GetMessageListDensity()
@@ -243,46 +252,11 @@ func (a *App) SendReadReceipt(accountID, messageID string) error {
 		return fmt.Errorf("failed to build MDN: %w", err)
 	}
 
-	// Create SMTP config
-	smtpConfig := smtp.ClientConfig{
-		Host:      acc.SMTPHost,
-		Port:      acc.SMTPPort,
-		Username:  acc.Username,
-		Security:  smtp.SecurityType(acc.SMTPSecurity),
-		TLSConfig: certificate.BuildTLSConfig(acc.SMTPHost, a.certStore),
-	}
-
-	// Handle authentication based on auth type
-	if acc.AuthType == account.AuthOAuth2 {
-		// Get valid OAuth token (refreshing if needed)
-		tokens, err := a.getValidOAuthToken(accountID)
-		if err != nil {
-			return fmt.Errorf("failed to get OAuth token: %w", err)
-		}
-		smtpConfig.AuthType = smtp.AuthTypeOAuth2
-		smtpConfig.AccessToken = tokens.AccessToken
-	} else {
-		// Default to password authentication
-		password, err := a.credStore.GetPassword(accountID)
-		if err != nil {
-			return fmt.Errorf("failed to get password: %w", err)
-		}
-		smtpConfig.AuthType = smtp.AuthTypePassword
-		smtpConfig.Password = password
-	}
-
-	// Create SMTP client and connect
-	client := smtp.NewClient(smtpConfig)
-	if err := client.Connect(); err != nil {
-		return fmt.Errorf("failed to connect to SMTP: %w", err)
-	}
-	defer client.Close()
-
 	// Extract recipient email
 	recipientEmail := extractEmailFromHeader(msg.ReadReceiptTo)
 
 	// Send the MDN
-	if err := client.SendMail(fromEmail, []string{recipientEmail}, mdnBytes); err != nil {
+	if err := a.sendService.SendSMTP(acc, fromEmail, []string{recipientEmail}, mdnBytes, nil); err != nil {
 		return fmt.Errorf("failed to send read receipt: %w", err)
 	}
 
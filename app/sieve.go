@@ -0,0 +1,249 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hkdb/aerion/internal/account"
+	"github.com/hkdb/aerion/internal/certificate"
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/hkdb/aerion/internal/sieve"
+)
+
+// ============================================================================
+// Sieve API - Exposed to frontend via Wails bindings
+// ============================================================================
+
+// GetSieveCapabilities returns what the account's ManageSieve server supports.
+// Fails if the server can't be reached, which usually means the provider
+// doesn't offer ManageSieve.
+func (a *App) GetSieveCapabilities(accountID string) (*sieve.Capabilities, error) {
+	var caps sieve.Capabilities
+	err := a.withSieve(accountID, func(c *sieve.Client) error {
+		caps = c.Capabilities()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &caps, nil
+}
+
+// GetSieveScripts lists the filter scripts stored on the server
+func (a *App) GetSieveScripts(accountID string) ([]sieve.ScriptInfo, error) {
+	var scripts []sieve.ScriptInfo
+	err := a.withSieve(accountID, func(c *sieve.Client) error {
+		var err error
+		scripts, err = c.ListScripts()
+		return err
+	})
+	return scripts, err
+}
+
+// GetSieveScript returns the text of a script
+func (a *App) GetSieveScript(accountID, name string) (string, error) {
+	var content string
+	err := a.withSieve(accountID, func(c *sieve.Client) error {
+		var err error
+		content, err = c.GetScript(name)
+		return err
+	})
+	return content, err
+}
+
+// PutSieveScript creates or replaces a script. The server rejects scripts
+// with errors.
+func (a *App) PutSieveScript(accountID, name, content string) error {
+	return a.withSieve(accountID, func(c *sieve.Client) error {
+		return c.PutScript(name, content)
+	})
+}
+
+// CheckSieveScript validates a script without saving it
+func (a *App) CheckSieveScript(accountID, content string) error {
+	return a.withSieve(accountID, func(c *sieve.Client) error {
+		return c.CheckScript(content)
+	})
+}
+
+// ActivateSieveScript makes a script the active one. An empty name
+// deactivates server-side filtering.
+func (a *App) ActivateSieveScript(accountID, name string) error {
+	return a.withSieve(accountID, func(c *sieve.Client) error {
+		return c.SetActive(name)
+	})
+}
+
+// DeleteSieveScript removes a script. The active script must be deactivated
+// first.
+func (a *App) DeleteSieveScript(accountID, name string) error {
+	return a.withSieve(accountID, func(c *sieve.Client) error {
+		return c.DeleteScript(name)
+	})
+}
+
+// GetSieveRules returns the structured rules and vacation reply from
+// Aerion's managed script. Returns an empty script if it doesn't exist yet,
+// and an error if it was edited into something that can't be shown as rules.
+func (a *App) GetSieveRules(accountID string) (*sieve.Script, error) {
+	var script *sieve.Script
+	err := a.withSieve(accountID, func(c *sieve.Client) error {
+		scripts, err := c.ListScripts()
+		if err != nil {
+			return err
+		}
+		if !hasSieveScript(scripts, sieve.ManagedScriptName) {
+			script = &sieve.Script{}
+			return nil
+		}
+
+		content, err := c.GetScript(sieve.ManagedScriptName)
+		if err != nil {
+			return err
+		}
+		script, err = sieve.Parse(content)
+		return err
+	})
+	return script, err
+}
+
+// SaveSieveRules writes structured rules and the vacation reply to Aerion's
+// managed script. The script is activated unless another script is already
+// active, which is left alone; use ActivateSieveScript to switch.
+// Returns whether the managed script is active.
+func (a *App) SaveSieveRules(accountID string, script sieve.Script) (bool, error) {
+	log := logging.WithComponent("app.sieve")
+
+	for _, r := range script.Rules {
+		if r.Header == "" || r.FileInto == "" {
+			return false, fmt.Errorf("rules need a header and a destination folder")
+		}
+	}
+
+	active := false
+	err := a.withSieve(accountID, func(c *sieve.Client) error {
+		caps := c.Capabilities()
+		if len(script.Rules) > 0 && !caps.HasExtension("fileinto") {
+			return fmt.Errorf("server does not support filing into folders")
+		}
+		if script.Vacation != nil && !caps.HasExtension("vacation") {
+			return fmt.Errorf("server does not support vacation replies")
+		}
+
+		if err := c.PutScript(sieve.ManagedScriptName, sieve.Generate(&script)); err != nil {
+			return err
+		}
+
+		scripts, err := c.ListScripts()
+		if err != nil {
+			return err
+		}
+		for _, s := range scripts {
+			if s.Active {
+				active = s.Name == sieve.ManagedScriptName
+				return nil
+			}
+		}
+
+		if err := c.SetActive(sieve.ManagedScriptName); err != nil {
+			return err
+		}
+		active = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	log.Info().
+		Str("account", accountID).
+		Int("rules", len(script.Rules)).
+		Bool("active", active).
+		Msg("Saved server-side rules")
+
+	return active, nil
+}
+
+// ============================================================================
+// Connection
+// ============================================================================
+
+// withSieve connects to the account's ManageSieve server, logs in and runs op
+func (a *App) withSieve(accountID string, op func(c *sieve.Client) error) error {
+	acc, err := a.accountStore.Get(accountID)
+	if err != nil {
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+	if acc == nil {
+		return fmt.Errorf("account not found: %s", accountID)
+	}
+
+	config, err := a.sieveConfigForAccount(acc)
+	if err != nil {
+		return err
+	}
+
+	client := sieve.NewClient(config)
+	if err := client.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to ManageSieve server: %w", err)
+	}
+	defer client.Close()
+
+	if err := client.Login(); err != nil {
+		return err
+	}
+
+	if err := op(client); err != nil {
+		var respErr *sieve.ResponseError
+		if errors.As(err, &respErr) || errors.Is(err, sieve.ErrUnsupportedScript) {
+			return err
+		}
+		return fmt.Errorf("ManageSieve command failed: %w", err)
+	}
+	return nil
+}
+
+// sieveConfigForAccount builds the ManageSieve client config for an account.
+// ManageSieve runs on the IMAP host's standard port, upgraded with STARTTLS
+// unless the account connects without encryption.
+func (a *App) sieveConfigForAccount(acc *account.Account) (sieve.ClientConfig, error) {
+	config := sieve.DefaultConfig()
+	config.Host = acc.IMAPHost
+	config.Username = acc.Username
+	tlsConfig, err := certificate.BuildClientTLSConfig(acc.IMAPHost, a.certStore, a.credStore, acc.ClientCertID)
+	if err != nil {
+		return config, err
+	}
+	config.TLSConfig = tlsConfig
+	if acc.IMAPSecurity == account.SecurityNone {
+		config.Security = sieve.SecurityNone
+	}
+
+	if acc.AuthType == account.AuthOAuth2 {
+		tokens, err := a.getValidOAuthToken(acc.ID)
+		if err != nil {
+			return config, fmt.Errorf("failed to get OAuth token: %w", err)
+		}
+		config.AuthType = sieve.AuthTypeOAuth2
+		config.AccessToken = tokens.AccessToken
+	} else {
+		password, err := a.credStore.GetPassword(acc.ID)
+		if err != nil {
+			return config, fmt.Errorf("failed to get password: %w", err)
+		}
+		config.AuthType = sieve.AuthTypePassword
+		config.Password = password
+	}
+
+	return config, nil
+}
+
+// hasSieveScript reports whether a script with the given name exists
+func hasSieveScript(scripts []sieve.ScriptInfo, name string) bool {
+	for _, s := range scripts {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
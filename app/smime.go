@@ -170,42 +170,6 @@ func (a *App) GetSMIMECertificateForEmail(accountID, email string) (*smime.Certi
 	return cert, nil
 }
 
-// shouldSignMessage determines whether a message should be S/MIME signed
-func (a *App) shouldSignMessage(accountID string, perMessageOverride bool) bool {
-	if perMessageOverride {
-		return a.HasSMIMECertificate(accountID)
-	}
-
-	policy, err := a.smimeStore.GetSignPolicy(accountID)
-	if err != nil {
-		return false
-	}
-
-	if policy != "always" {
-		return false
-	}
-
-	return a.HasSMIMECertificate(accountID)
-}
-
-// shouldEncryptMessage determines whether a message should be S/MIME encrypted
-func (a *App) shouldEncryptMessage(accountID string, perMessageOverride bool) bool {
-	if perMessageOverride {
-		return a.HasSMIMECertificate(accountID)
-	}
-
-	policy, err := a.smimeStore.GetEncryptPolicy(accountID)
-	if err != nil {
-		return false
-	}
-
-	if policy != "always" {
-		return false
-	}
-
-	return a.HasSMIMECertificate(accountID)
-}
-
 // GetSMIMEEncryptPolicy returns the encryption policy for an account
 func (a *App) GetSMIMEEncryptPolicy(accountID string) (string, error) {
 	return a.smimeStore.GetEncryptPolicy(accountID)
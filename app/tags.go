@@ -0,0 +1,181 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	goImap "github.com/emersion/go-imap/v2"
+	"github.com/hkdb/aerion/internal/imap"
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/hkdb/aerion/internal/message"
+	"github.com/hkdb/aerion/internal/pendingop"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// tagColorPattern matches the #rrggbb colors tags are shown in
+var tagColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// ============================================================================
+// Tags API - Exposed to frontend via Wails bindings
+// ============================================================================
+
+// ListTags returns an account's tags with their colors and message counts
+func (a *App) ListTags(accountID string) ([]*message.Tag, error) {
+	return a.messageStore.ListTags(accountID)
+}
+
+// SetTagColor sets the color a tag is shown in (#rrggbb). An empty color
+// resets it.
+func (a *App) SetTagColor(accountID, tag, color string) error {
+	if err := message.ValidateTag(tag); err != nil {
+		return err
+	}
+	if color != "" && !tagColorPattern.MatchString(color) {
+		return fmt.Errorf("invalid color: %s", color)
+	}
+	return a.messageStore.SetTagColor(accountID, tag, color)
+}
+
+// AddTags tags messages. Tags are stored on the server as IMAP keywords, or
+// kept locally in folders whose server doesn't accept new keywords.
+func (a *App) AddTags(messageIDs []string, tags []string) error {
+	return a.setTags(messageIDs, tags, true)
+}
+
+// RemoveTags removes tags from messages
+func (a *App) RemoveTags(messageIDs []string, tags []string) error {
+	return a.setTags(messageIDs, tags, false)
+}
+
+// setTags updates tags locally first, then syncs them to the server in the
+// background, like flag changes
+func (a *App) setTags(messageIDs []string, tags []string, add bool) error {
+	if len(messageIDs) == 0 || len(tags) == 0 {
+		return nil
+	}
+	for _, tag := range tags {
+		if err := message.ValidateTag(tag); err != nil {
+			return err
+		}
+	}
+
+	messages, err := a.messageStore.GetByIDs(messageIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get messages: %w", err)
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	byFolder := make(map[string][]*message.Message)
+	for _, m := range messages {
+		byFolder[m.FolderID] = append(byFolder[m.FolderID], m)
+	}
+
+	// Update local DB first
+	serverFolders := make(map[string][]*message.Message)
+	for folderID, msgs := range byFolder {
+		ids := make([]string, len(msgs))
+		for i, m := range msgs {
+			ids[i] = m.ID
+		}
+
+		if !add {
+			if err := a.messageStore.RemoveTags(ids, tags); err != nil {
+				return err
+			}
+			serverFolders[folderID] = msgs
+			continue
+		}
+
+		allowed, err := a.folderStore.KeywordsAllowed(folderID)
+		if err != nil {
+			return err
+		}
+		if err := a.messageStore.AddTags(ids, tags, !allowed); err != nil {
+			return err
+		}
+		if allowed {
+			serverFolders[folderID] = msgs
+		}
+	}
+
+	wailsRuntime.EventsEmit(a.ctx, "messages:tagsChanged", messageIDs)
+
+	// Sync to IMAP in background with retry. Removing a tag that was only
+	// stored locally is a no-op on the server.
+	go func() {
+		for folderID, msgs := range serverFolders {
+			a.syncTagsWithRetry(msgs, folderID, tags, add)
+		}
+	}()
+
+	return nil
+}
+
+// syncTagsWithRetry stores keyword changes on the server, queueing them
+// while offline
+func (a *App) syncTagsWithRetry(messages []*message.Message, folderID string, tags []string, add bool) {
+	log := logging.WithComponent("app.tags")
+
+	opType := pendingop.TypeRemoveFlags
+	if add {
+		opType = pendingop.TypeAddFlags
+	}
+	op := a.newPendingOperation(opType, messages, folderID)
+	if op != nil {
+		op.Flags = tags
+	}
+
+	_, err := a.runOrQueue(op, func() error {
+		var err error
+		for attempt := 1; attempt <= 3; attempt++ {
+			err = a.syncTagsToIMAP(messages, folderID, tags, add)
+			if err == nil {
+				return nil
+			}
+			log.Warn().Err(err).Int("attempt", attempt).Str("folderID", folderID).Msg("Failed to sync tags to IMAP, retrying...")
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+		return err
+	})
+	if err != nil {
+		log.Error().Err(err).Str("folderID", folderID).Msg("Failed to sync tags to IMAP after 3 attempts")
+	}
+}
+
+// syncTagsToIMAP adds or removes keywords on the server
+func (a *App) syncTagsToIMAP(messages []*message.Message, folderID string, tags []string, add bool) error {
+	folderObj, err := a.folderStore.Get(folderID)
+	if err != nil || folderObj == nil {
+		return fmt.Errorf("folder not found: %s", folderID)
+	}
+
+	if a.isLocalAccount(messages[0].AccountID) {
+		return nil
+	}
+	if a.isJMAPAccount(messages[0].AccountID) {
+		return a.setJMAPKeywords(messages, jmapKeywords(tags), add)
+	}
+
+	uids := make([]goImap.UID, len(messages))
+	for i, m := range messages {
+		uids[i] = goImap.UID(m.UID)
+	}
+	flags := make([]goImap.Flag, len(tags))
+	for i, tag := range tags {
+		flags[i] = goImap.Flag(tag)
+	}
+
+	return a.withIMAPRetry(messages[0].AccountID, func(conn *imap.Client) error {
+		if _, err := conn.SelectMailbox(a.ctx, folderObj.Path); err != nil {
+			return fmt.Errorf("failed to select mailbox: %w", err)
+		}
+
+		if add {
+			return conn.AddMessageFlags(uids, flags)
+		}
+		return conn.RemoveMessageFlags(uids, flags)
+	})
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/hkdb/aerion/internal/message"
+	"github.com/hkdb/aerion/internal/smtp"
+	"github.com/hkdb/aerion/internal/template"
+)
+
+// ============================================================================
+// Templates API - Exposed to frontend via Wails bindings
+// ============================================================================
+
+// GetTemplates returns the templates available to an account, including
+// the ones shared by all accounts
+func (a *App) GetTemplates(accountID string) ([]*template.Template, error) {
+	return a.templateStore.List(accountID)
+}
+
+// SaveTemplate creates or updates a template
+func (a *App) SaveTemplate(t template.Template) (*template.Template, error) {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" {
+		return nil, fmt.Errorf("template name is required")
+	}
+	if err := a.templateStore.Save(&t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// DeleteTemplate removes a template
+func (a *App) DeleteTemplate(id string) error {
+	return a.templateStore.Delete(id)
+}
+
+// SaveMessageAsTemplate saves a message from the composer, or a draft, as a
+// new template. A shared template is available to every account.
+func (a *App) SaveMessageAsTemplate(accountID, name string, shared bool, msg smtp.ComposeMessage) (*template.Template, error) {
+	if shared {
+		accountID = ""
+	}
+	return a.SaveTemplate(*template.FromMessage(accountID, name, &msg))
+}
+
+// RenderTemplate fills in a template's variables for the message being
+// composed, so the composer can insert it. messageID is the message being
+// replied to or forwarded, if any.
+func (a *App) RenderTemplate(templateID, messageID string, msg smtp.ComposeMessage) (*smtp.ComposeMessage, error) {
+	t, err := a.templateStore.Get(templateID)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, fmt.Errorf("template not found: %s", templateID)
+	}
+
+	var original *message.Message
+	if messageID != "" {
+		if original, err = a.messageStore.Get(messageID); err != nil {
+			return nil, fmt.Errorf("failed to get message: %w", err)
+		}
+	}
+
+	return renderTemplate(t, &msg, original), nil
+}
+
+// PrepareFromTemplate prepares a new message, reply or forward that starts
+// from a template. With no messageID it's a new message from the account's
+// default identity; otherwise mode is as for PrepareReply.
+func (a *App) PrepareFromTemplate(accountID, templateID, messageID, mode string) (*smtp.ComposeMessage, error) {
+	log := logging.WithComponent("app")
+	log.Debug().Str("templateID", templateID).Str("messageID", messageID).Str("mode", mode).Msg("Preparing message from template")
+
+	t, err := a.templateStore.Get(templateID)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, fmt.Errorf("template not found: %s", templateID)
+	}
+
+	var msg *smtp.ComposeMessage
+	var original *message.Message
+	if messageID != "" {
+		if msg, err = a.PrepareReply(messageID, mode); err != nil {
+			return nil, err
+		}
+		if original, err = a.messageStore.Get(messageID); err != nil {
+			return nil, fmt.Errorf("failed to get message: %w", err)
+		}
+	} else {
+		identities, err := a.accountStore.GetIdentities(accountID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get identities: %w", err)
+		}
+		msg = &smtp.ComposeMessage{From: a.fromAddressForIdentities(accountID, identities)}
+	}
+
+	template.Apply(msg, renderTemplate(t, msg, original))
+	return msg, nil
+}
+
+// renderTemplate fills in a template's variables from the message being
+// composed and the original it replies to or forwards, which may be nil
+func renderTemplate(t *template.Template, msg *smtp.ComposeMessage, original *message.Message) *smtp.ComposeMessage {
+	vars := template.NewVars(time.Now())
+	vars.SetSender(msg.From)
+	if len(msg.To) > 0 {
+		vars.SetRecipient(msg.To[0])
+	}
+	if original != nil {
+		vars.SetOriginal(original.Subject, original.FromName, original.FromEmail, original.Date)
+	}
+	return template.Render(t, vars)
+}
@@ -2,9 +2,11 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/hkdb/aerion/internal/imap"
+	"github.com/hkdb/aerion/internal/undo"
 	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
@@ -21,6 +23,10 @@ func (a *App) Undo() (string, error) {
 	}
 
 	if err := cmd.Undo(); err != nil {
+		// Keep it for another try once the move is through
+		if errors.Is(err, undo.ErrMoveInProgress) {
+			a.undoStack.Push(cmd)
+		}
 		return "", fmt.Errorf("undo failed: %w", err)
 	}
 
@@ -50,6 +56,12 @@ func (a *App) GetUndoDescription() string {
 
 // GetIMAPConnectionForUndo implements undo.UndoContext
 func (a *App) GetIMAPConnectionForUndo(ctx context.Context, accountID string) (*imap.Client, func(), error) {
+	if a.isJMAPAccount(accountID) {
+		return nil, nil, errJMAPUnsupported
+	}
+	if a.isLocalAccount(accountID) {
+		return nil, nil, errLocalUnsupported
+	}
 	poolConn, err := a.imapPool.GetConnection(ctx, accountID)
 	if err != nil {
 		return nil, nil, err
@@ -16,6 +16,13 @@ var (
 	GoogleClientID     string
 	GoogleClientSecret string
 	MicrosoftClientID  string
+	YahooClientID      string
+	YahooClientSecret  string
+	AOLClientID        string
+	AOLClientSecret    string
+	FastmailClientID   string
+	ZohoClientID       string
+	ZohoClientSecret   string
 )
 
 func main() {
@@ -23,6 +30,13 @@ func main() {
 		"google_client_id":     GoogleClientID,
 		"google_client_secret": GoogleClientSecret,
 		"microsoft_client_id":  MicrosoftClientID,
+		"yahoo_client_id":      YahooClientID,
+		"yahoo_client_secret":  YahooClientSecret,
+		"aol_client_id":        AOLClientID,
+		"aol_client_secret":    AOLClientSecret,
+		"fastmail_client_id":   FastmailClientID,
+		"zoho_client_id":       ZohoClientID,
+		"zoho_client_secret":   ZohoClientSecret,
 	}
 	data, err := json.Marshal(creds)
 	if err != nil {
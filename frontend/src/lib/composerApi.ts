@@ -10,15 +10,21 @@
  */
 
 // @ts-ignore - Wails generated imports
-import { smtp, account, contact, app, draft, smime, pgp } from '../../wailsjs/go/models'
+import { smtp, account, contact, app, draft, smime, pgp, outbox, template } from '../../wailsjs/go/models'
 
 /**
  * Interface for composer API operations.
  * Both App and ComposerApp implement these methods with the same signatures.
  */
 export interface ComposerApi {
-  /** Send a composed email */
-  sendMessage: (accountId: string, message: smtp.ComposeMessage) => Promise<void>
+  /**
+   * Send a composed email. Resolves to the outbox entry if the message is
+   * waiting to be sent (undo-send window or retry), or null once sent.
+   */
+  sendMessage: (accountId: string, message: smtp.ComposeMessage) => Promise<outbox.Message | null>
+
+  /** Queue a composed email to be sent at a later time */
+  scheduleMessage: (accountId: string, message: smtp.ComposeMessage, sendAt: Date) => Promise<outbox.Message>
   
   /** Search contacts for autocomplete */
   searchContacts: (query: string, limit: number) => Promise<contact.Contact[]>
@@ -89,11 +95,26 @@ export interface ComposerApi {
   /** Perform a unified WKD+HKP lookup for a recipient's PGP key */
   lookupPGPKey: (email: string) => Promise<string>
 
+  /** Get the templates available to an account, including shared ones */
+  getTemplates: (accountId: string) => Promise<template.Template[]>
+
+  /** Save the message being composed as a new template */
+  saveMessageAsTemplate: (accountId: string, name: string, shared: boolean, message: smtp.ComposeMessage) => Promise<template.Template>
+
+  /** Delete a template */
+  deleteTemplate: (templateId: string) => Promise<void>
+
+  /**
+   * Fill in a template's variables for the message being composed.
+   * messageId is the message being replied to or forwarded, if any.
+   */
+  renderTemplate: (templateId: string, messageId: string, message: smtp.ComposeMessage) => Promise<smtp.ComposeMessage>
+
   /**
    * Open a detached composer window (only available in main window).
    * Returns undefined in detached composer windows.
    */
-  openComposerWindow?: (accountId: string, mode: string, messageId: string, draftId: string) => Promise<void>
+  openComposerWindow?: (accountId: string, mode: string, messageId: string, draftId: string, templateId?: string) => Promise<void>
 }
 
 /**
@@ -114,6 +135,11 @@ export function createMainWindowApi(): ComposerApi {
       const { SendMessage } = await import('../../wailsjs/go/app/App.js')
       return SendMessage(accountId, message)
     },
+
+    scheduleMessage: async (accountId: string, message: smtp.ComposeMessage, sendAt: Date) => {
+      const { ScheduleMessage } = await import('../../wailsjs/go/app/App.js')
+      return ScheduleMessage(accountId, message, sendAt.toISOString())
+    },
     
     searchContacts: async (query: string, limit: number) => {
       const { SearchContacts } = await import('../../wailsjs/go/app/App.js')
@@ -231,9 +257,29 @@ export function createMainWindowApi(): ComposerApi {
       return LookupPGPKey(email)
     },
 
-    openComposerWindow: async (accountId: string, mode: string, messageId: string, draftId: string) => {
+    getTemplates: async (accountId: string) => {
+      const { GetTemplates } = await import('../../wailsjs/go/app/App.js')
+      return (await GetTemplates(accountId)) || []
+    },
+
+    saveMessageAsTemplate: async (accountId: string, name: string, shared: boolean, message: smtp.ComposeMessage) => {
+      const { SaveMessageAsTemplate } = await import('../../wailsjs/go/app/App.js')
+      return SaveMessageAsTemplate(accountId, name, shared, message)
+    },
+
+    deleteTemplate: async (templateId: string) => {
+      const { DeleteTemplate } = await import('../../wailsjs/go/app/App.js')
+      return DeleteTemplate(templateId)
+    },
+
+    renderTemplate: async (templateId: string, messageId: string, message: smtp.ComposeMessage) => {
+      const { RenderTemplate } = await import('../../wailsjs/go/app/App.js')
+      return RenderTemplate(templateId, messageId, message)
+    },
+
+    openComposerWindow: async (accountId: string, mode: string, messageId: string, draftId: string, templateId?: string) => {
       const { OpenComposerWindow } = await import('../../wailsjs/go/app/App.js')
-      return OpenComposerWindow(accountId, mode, messageId, draftId)
+      return OpenComposerWindow(accountId, mode, messageId, draftId, templateId || '')
     },
   }
 }
@@ -249,6 +295,11 @@ export function createComposerWindowApi(accountId: string): ComposerApi {
       // ComposerApp.SendMessage doesn't take accountId (it's set in config)
       return SendMessage(message)
     },
+
+    scheduleMessage: async (_accountId: string, message: smtp.ComposeMessage, sendAt: Date) => {
+      const { ScheduleMessage } = await import('../../wailsjs/go/app/ComposerApp.js')
+      return ScheduleMessage(message, sendAt.toISOString())
+    },
     
     searchContacts: async (query: string, limit: number) => {
       const { SearchContacts } = await import('../../wailsjs/go/app/ComposerApp.js')
@@ -372,5 +423,26 @@ export function createComposerWindowApi(accountId: string): ComposerApi {
       const { LookupPGPKey } = await import('../../wailsjs/go/app/ComposerApp.js')
       return LookupPGPKey(email)
     },
+
+    getTemplates: async (_accountId: string) => {
+      const { GetTemplates } = await import('../../wailsjs/go/app/ComposerApp.js')
+      return (await GetTemplates()) || []
+    },
+
+    saveMessageAsTemplate: async (_accountId: string, name: string, shared: boolean, message: smtp.ComposeMessage) => {
+      const { SaveMessageAsTemplate } = await import('../../wailsjs/go/app/ComposerApp.js')
+      return SaveMessageAsTemplate(name, shared, message)
+    },
+
+    deleteTemplate: async (templateId: string) => {
+      const { DeleteTemplate } = await import('../../wailsjs/go/app/ComposerApp.js')
+      return DeleteTemplate(templateId)
+    },
+
+    renderTemplate: async (templateId: string, _messageId: string, message: smtp.ComposeMessage) => {
+      const { RenderTemplate } = await import('../../wailsjs/go/app/ComposerApp.js')
+      // ComposerApp knows the original message from its config
+      return RenderTemplate(templateId, message)
+    },
   }
 }
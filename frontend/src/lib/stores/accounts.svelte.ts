@@ -70,6 +70,12 @@ class AccountStore {
       this.accounts = this.accounts
     })
 
+    // Reload an account's folder tree after folders are created, renamed,
+    // moved, deleted or (un)subscribed
+    EventsOn('folders:changed', (data: { accountId: string }) => {
+      this.loadFolders(data.accountId)
+    })
+
     // Listen for sync progress updates
     EventsOn('sync:progress', (data: { accountId: string; folderId: string; fetched: number; total: number; phase: string }) => {
       // Cap percentage at 100% as a safety net
@@ -4,6 +4,7 @@
 
 import {
   StartOAuthFlow,
+  StartOAuthDeviceFlow,
   CancelOAuthFlow,
   GetOAuthStatus,
   IsOAuthConfigured,
@@ -12,6 +13,8 @@ import {
   SaveOAuthTokens,
   SavePendingOAuthTokens,
   ReauthorizeAccount,
+  ReauthorizeAccountWithDeviceCode,
+  GetOAuthProviders,
   TestOAuthConnection,
   GetAccount,
 } from '../../../wailsjs/go/app/App'
@@ -30,6 +33,15 @@ export interface OAuthFlowResult {
   expiresIn: number
 }
 
+export interface OAuthDeviceAuthorization {
+  provider: string
+  userCode: string
+  verificationUri: string
+  verificationUriComplete?: string
+  expiresAt: string
+  message?: string
+}
+
 export interface OAuthStatus {
   isOAuth: boolean
   provider: string
@@ -45,10 +57,13 @@ class OAuthStore {
   flowProvider = $state<OAuthProvider | null>(null)
   flowError = $state<string | null>(null)
   flowResult = $state<OAuthFlowResult | null>(null)
+  // Code to enter on another device, set during a device flow
+  deviceAuth = $state<OAuthDeviceAuthorization | null>(null)
 
   // Configured providers (cached)
   private configuredProviders = $state<OAuthProvider[]>([])
   private configuredLoaded = false
+  private deviceFlowProviders: string[] | null = null
 
   // Event listener cleanup tracking
   private eventsInitialized = false
@@ -66,6 +81,11 @@ class OAuthStore {
       this.flowProvider = data.provider as OAuthProvider
       this.flowError = null
       this.flowResult = null
+      this.deviceAuth = null
+    })
+
+    EventsOn('oauth:device', (data: OAuthDeviceAuthorization) => {
+      this.deviceAuth = data
     })
 
     EventsOn('oauth:success', (data: { provider: string; email: string; expiresIn: number }) => {
@@ -76,12 +96,14 @@ class OAuthStore {
         expiresIn: data.expiresIn,
       }
       this.flowError = null
+      this.deviceAuth = null
     })
 
     EventsOn('oauth:error', (data: { provider: string; error: string }) => {
       this.flowState = 'error'
       this.flowError = data.error
       this.flowResult = null
+      this.deviceAuth = null
     })
 
     EventsOn('oauth:cancelled', () => {
@@ -121,6 +143,7 @@ class OAuthStore {
     this.eventsInitialized = false
 
     EventsOff('oauth:started')
+    EventsOff('oauth:device')
     EventsOff('oauth:success')
     EventsOff('oauth:error')
     EventsOff('oauth:cancelled')
@@ -147,6 +170,37 @@ class OAuthStore {
     }
   }
 
+  /**
+   * Start the device code flow for a provider.
+   * The user enters deviceAuth.userCode at deviceAuth.verificationUri on any device.
+   */
+  async startDeviceFlow(provider: OAuthProvider): Promise<void> {
+    try {
+      this.flowState = 'pending'
+      this.flowProvider = provider
+      this.flowError = null
+      this.flowResult = null
+
+      this.deviceAuth = await StartOAuthDeviceFlow(provider)
+      // State will be updated via events
+    } catch (err) {
+      this.flowState = 'error'
+      this.flowError = err instanceof Error ? err.message : String(err)
+      throw err
+    }
+  }
+
+  /**
+   * Check if a provider can sign in with a code on another device.
+   */
+  async supportsDeviceFlow(provider: OAuthProvider): Promise<boolean> {
+    if (this.deviceFlowProviders === null) {
+      const providers = await GetOAuthProviders()
+      this.deviceFlowProviders = providers.filter((p) => p.deviceFlow).map((p) => p.name)
+    }
+    return this.deviceFlowProviders.includes(provider)
+  }
+
   /**
    * Cancel any in-progress OAuth flow.
    */
@@ -163,6 +217,7 @@ class OAuthStore {
     this.flowProvider = null
     this.flowError = null
     this.flowResult = null
+    this.deviceAuth = null
   }
 
   /**
@@ -229,8 +284,9 @@ class OAuthStore {
   /**
    * Re-authorize an account (when tokens have expired).
    * Starts OAuth flow and waits for completion, then saves new tokens.
+   * With useDeviceCode the user signs in with a code instead of a browser redirect.
    */
-  async reauthorize(accountId: string): Promise<void> {
+  async reauthorize(accountId: string, useDeviceCode = false): Promise<void> {
     // Ensure event listeners are initialized
     this.initEvents()
 
@@ -238,7 +294,11 @@ class OAuthStore {
     this.reset()
 
     // Start the OAuth flow
-    await ReauthorizeAccount(accountId)
+    if (useDeviceCode) {
+      this.deviceAuth = await ReauthorizeAccountWithDeviceCode(accountId)
+    } else {
+      await ReauthorizeAccount(accountId)
+    }
 
     // Wait for the OAuth flow to complete
     return new Promise((resolve, reject) => {
@@ -267,14 +327,14 @@ class OAuthStore {
         }
       }, 100)
 
-      // Timeout after 5 minutes
+      // Timeout after 5 minutes, or 15 for device codes
       setTimeout(() => {
         clearInterval(checkInterval)
         if (this.flowState === 'pending') {
           this.reset()
           reject(new Error('OAuth flow timed out'))
         }
-      }, 5 * 60 * 1000)
+      }, (useDeviceCode ? 15 : 5) * 60 * 1000)
     })
   }
 
@@ -16,21 +16,36 @@ import {smime} from '../models';
 import {appstate} from '../models';
 import {sync} from '../models';
 import {draft} from '../models';
+import {pendingop} from '../models';
+import {outbox} from '../models';
+import {rules} from '../models';
+import {sieve} from '../models';
+import {oauth2} from '../models';
+import {template} from '../models';
+import {mailmerge} from '../models';
 
 export function AcceptCertificate(arg1:string,arg2:certificate.CertificateInfo,arg3:boolean):Promise<void>;
 
+export function ActivateSieveScript(arg1:string,arg2:string):Promise<void>;
+
 export function AddAccount(arg1:account.AccountConfig):Promise<account.Account>;
 
 export function AddContact(arg1:string,arg2:string):Promise<void>;
 
 export function AddContactSource(arg1:carddav.SourceConfig):Promise<carddav.Source>;
 
+export function AddGmailLabels(arg1:Array<string>,arg2:Array<string>):Promise<void>;
+
 export function AddImageAllowlist(arg1:string,arg2:string):Promise<void>;
 
 export function AddPGPKeyServer(arg1:string):Promise<void>;
 
+export function AddTags(arg1:Array<string>,arg2:Array<string>):Promise<void>;
+
 export function Archive(arg1:Array<string>):Promise<void>;
 
+export function AutoconfigureAccount(arg1:string,arg2:string,arg3:string,arg4:boolean):Promise<app.AutoconfigResult>;
+
 export function BroadcastAccountUpdated(arg1:string):Promise<void>;
 
 export function BroadcastContactsUpdated(arg1:string):Promise<void>;
@@ -47,12 +62,18 @@ export function CancelContactSourceOAuthFlow():Promise<void>;
 
 export function CancelFolderSync(arg1:string,arg2:string):Promise<void>;
 
+export function CancelMailMerge(arg1:string):Promise<void>;
+
 export function CancelOAuthFlow():Promise<void>;
 
+export function CancelOutboxMessage(arg1:number):Promise<void>;
+
 export function CheckRecipientCerts(arg1:Array<string>):Promise<Record<string, boolean>>;
 
 export function CheckRecipientPGPKeys(arg1:Array<string>):Promise<Record<string, boolean>>;
 
+export function CheckSieveScript(arg1:string,arg2:string):Promise<void>;
+
 export function ClearContactSourceError(arg1:string):Promise<void>;
 
 export function CloseWindow():Promise<void>;
@@ -63,36 +84,62 @@ export function CompleteOAuthAccountSetup(arg1:string,arg2:string,arg3:string,ar
 
 export function CopyToFolder(arg1:Array<string>,arg2:string):Promise<void>;
 
+export function CreateFolder(arg1:string,arg2:string,arg3:string):Promise<folder.Folder>;
+
 export function CreateIdentity(arg1:string,arg2:account.IdentityConfig):Promise<account.Identity>;
 
+export function DeleteClientCertificate(arg1:string):Promise<void>;
+
 export function DeleteContact(arg1:string):Promise<void>;
 
 export function DeleteContactSource(arg1:string):Promise<void>;
 
 export function DeleteDraft(arg1:string):Promise<void>;
 
+export function DeleteFolder(arg1:string):Promise<void>;
+
 export function DeleteIdentity(arg1:string):Promise<void>;
 
 export function DeleteLocalMessages(arg1:Array<string>):Promise<void>;
 
+export function DeleteMailMerge(arg1:string):Promise<void>;
+
 export function DeletePGPKey(arg1:string):Promise<void>;
 
 export function DeletePGPSenderKey(arg1:string):Promise<void>;
 
 export function DeletePermanently(arg1:Array<string>):Promise<void>;
 
+export function DeleteRule(arg1:string):Promise<void>;
+
 export function DeleteSMIMECertificate(arg1:string):Promise<void>;
 
 export function DeleteSenderCert(arg1:string):Promise<void>;
 
-export function DiscoverCardDAVAddressbooks(arg1:string,arg2:string,arg3:string):Promise<Array<carddav.AddressbookInfo>>;
+export function DeleteSieveScript(arg1:string,arg2:string):Promise<void>;
+
+export function DeleteTemplate(arg1:string):Promise<void>;
+
+export function DiscoverCardDAVAddressbooks(arg1:string,arg2:string,arg3:string,arg4:string):Promise<Array<carddav.AddressbookInfo>>;
+
+export function DismissPendingOperation(arg1:number):Promise<void>;
 
 export function DownloadAttachment(arg1:string,arg2:string):Promise<string>;
 
 export function DownloadEncryptedAttachment(arg1:string,arg2:string,arg3:string):Promise<string>;
 
+export function EditOutboxMessage(arg1:number):Promise<smtp.ComposeMessage>;
+
 export function EmptyTrash(arg1:string,arg2:string):Promise<void>;
 
+export function ExportConversation(arg1:string,arg2:string,arg3:string):Promise<string>;
+
+export function ExportFolder(arg1:string,arg2:string):Promise<string>;
+
+export function ExportMessages(arg1:Array<string>,arg2:string):Promise<string>;
+
+export function ExportSearchResults(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
+
 export function FetchMessageBody(arg1:string):Promise<message.Message>;
 
 export function FetchServerMessage(arg1:string,arg2:string,arg3:number):Promise<message.Message>;
@@ -105,12 +152,16 @@ export function GetAccountFoldersForMapping(arg1:string):Promise<Array<folder.Fo
 
 export function GetAccounts():Promise<Array<account.Account>>;
 
+export function GetAddressbookRecipients(arg1:string):Promise<mailmerge.List>;
+
 export function GetAppInfo():Promise<app.AppInfo>;
 
 export function GetAttachment(arg1:string):Promise<message.Attachment>;
 
 export function GetAttachments(arg1:string):Promise<Array<message.Attachment>>;
 
+export function GetAuthMechanisms():Promise<Array<string>>;
+
 export function GetAutoDetectedFolders(arg1:string):Promise<Record<string, string>>;
 
 export function GetAutostart():Promise<boolean>;
@@ -137,6 +188,8 @@ export function GetConversationCount(arg1:string,arg2:string,arg3:string):Promis
 
 export function GetConversations(arg1:string,arg2:string,arg3:number,arg4:number,arg5:string,arg6:string):Promise<Array<message.Conversation>>;
 
+export function GetDeliveryReports(arg1:string):Promise<Array<message.DeliveryReport>>;
+
 export function GetDraft(arg1:string):Promise<smtp.ComposeMessage>;
 
 export function GetFTSIndexStatus(arg1:string):Promise<message.FTSIndexStatus>;
@@ -147,6 +200,8 @@ export function GetFolderTree(arg1:string):Promise<Array<folder.FolderTree>>;
 
 export function GetFolders(arg1:string):Promise<Array<folder.Folder>>;
 
+export function GetGmailLabels(arg1:string):Promise<Array<string>>;
+
 export function GetIMAPConnectionForUndo(arg1:context.Context,arg2:string):Promise<imap.Client>;
 
 export function GetIPCAddress():Promise<string>;
@@ -163,12 +218,18 @@ export function GetLanguage():Promise<string>;
 
 export function GetLinkedAccountsForContactSync():Promise<Array<app.LinkedAccountInfo>>;
 
+export function GetMailMergeJobs(arg1:string):Promise<Array<mailmerge.Job>>;
+
+export function GetMailMergeRecipients(arg1:string):Promise<Array<mailmerge.Recipient>>;
+
 export function GetMarkAsReadDelay():Promise<number>;
 
 export function GetMessage(arg1:string):Promise<message.Message>;
 
 export function GetMessageCount(arg1:string,arg2:string):Promise<number>;
 
+export function GetMessageGmailLabels(arg1:string):Promise<Array<string>>;
+
 export function GetMessageListDensity():Promise<string>;
 
 export function GetMessageListSortOrder():Promise<string>;
@@ -177,8 +238,12 @@ export function GetMessageSource(arg1:string):Promise<string>;
 
 export function GetMessages(arg1:string,arg2:string,arg3:number,arg4:number):Promise<Array<message.MessageHeader>>;
 
+export function GetOAuthProviders():Promise<Array<app.OAuthProviderInfo>>;
+
 export function GetOAuthStatus(arg1:string):Promise<app.OAuthStatus>;
 
+export function GetOutbox(arg1:string):Promise<Array<outbox.Message>>;
+
 export function GetPGPEncryptPolicy(arg1:string):Promise<string>;
 
 export function GetPGPKeyForEmail(arg1:string,arg2:string):Promise<pgp.Key>;
@@ -189,8 +254,12 @@ export function GetPGPSignPolicy(arg1:string):Promise<string>;
 
 export function GetPendingMailto():Promise<app.MailtoData>;
 
+export function GetPendingOperations(arg1:string):Promise<Array<pendingop.Operation>>;
+
 export function GetReadReceiptResponsePolicy():Promise<string>;
 
+export function GetRules(arg1:string):Promise<Array<rules.Rule>>;
+
 export function GetRunBackground():Promise<boolean>;
 
 export function GetSMIMECertificateForEmail(arg1:string,arg2:string):Promise<smime.Certificate>;
@@ -205,6 +274,14 @@ export function GetSearchCountUnifiedInbox(arg1:string,arg2:string):Promise<numb
 
 export function GetShowTitleBar():Promise<boolean>;
 
+export function GetSieveCapabilities(arg1:string):Promise<sieve.Capabilities>;
+
+export function GetSieveRules(arg1:string):Promise<sieve.Script>;
+
+export function GetSieveScript(arg1:string,arg2:string):Promise<string>;
+
+export function GetSieveScripts(arg1:string):Promise<Array<sieve.ScriptInfo>>;
+
 export function GetSourceAddressbooks(arg1:string):Promise<Array<carddav.Addressbook>>;
 
 export function GetSpecialFolder(arg1:string,arg2:folder.Type):Promise<folder.Folder>;
@@ -215,6 +292,8 @@ export function GetStartHiddenActive():Promise<boolean>;
 
 export function GetSystemTheme():Promise<string>;
 
+export function GetTemplates(arg1:string):Promise<Array<template.Template>>;
+
 export function GetTermsAccepted():Promise<boolean>;
 
 export function GetThemeMode():Promise<string>;
@@ -225,6 +304,8 @@ export function GetUIState():Promise<appstate.UIState>;
 
 export function GetUndoDescription():Promise<string>;
 
+export function GetUndoSendDelay():Promise<number>;
+
 export function GetUnifiedInboxConversations(arg1:number,arg2:number,arg3:string,arg4:string):Promise<Array<message.Conversation>>;
 
 export function GetUnifiedInboxCount(arg1:string):Promise<number>;
@@ -239,6 +320,12 @@ export function IMAPSearchFolder(arg1:string,arg2:string,arg3:string,arg4:number
 
 export function IgnoreReadReceipt(arg1:string,arg2:string):Promise<void>;
 
+export function ImportClientCertificateFromPath(arg1:string,arg2:string):Promise<certificate.ClientCertificate>;
+
+export function ImportFiles(arg1:string,arg2:Array<string>):Promise<void>;
+
+export function ImportMessages(arg1:string):Promise<Array<string>>;
+
 export function ImportPGPKeyFromPath(arg1:string,arg2:string,arg3:string):Promise<pgp.ImportResult>;
 
 export function ImportRecipientCert(arg1:string,arg2:string):Promise<void>;
@@ -259,6 +346,8 @@ export function IsOAuthConfigured(arg1:string):Promise<boolean>;
 
 export function LinkAccountContactSource(arg1:string,arg2:string,arg3:number):Promise<carddav.Source>;
 
+export function ListClientCertificates():Promise<Array<certificate.ClientCertificate>>;
+
 export function ListContacts(arg1:number):Promise<Array<contact.Contact>>;
 
 export function ListDrafts(arg1:string):Promise<Array<draft.Draft>>;
@@ -271,6 +360,10 @@ export function ListSMIMECertificates(arg1:string):Promise<Array<smime.Certifica
 
 export function ListSenderCerts():Promise<Array<smime.SenderCert>>;
 
+export function ListTags(arg1:string):Promise<Array<message.Tag>>;
+
+export function LoadMailMergeCSV():Promise<mailmerge.List>;
+
 export function LookupHKP(arg1:string):Promise<string>;
 
 export function LookupPGPKey(arg1:string):Promise<string>;
@@ -289,13 +382,15 @@ export function MarkAsSpam(arg1:Array<string>):Promise<void>;
 
 export function MarkAsUnread(arg1:Array<string>):Promise<void>;
 
+export function MoveFolder(arg1:string,arg2:string):Promise<void>;
+
 export function MoveLocalMessages(arg1:Array<string>,arg2:string):Promise<void>;
 
 export function MoveToFolder(arg1:Array<string>,arg2:string):Promise<void>;
 
 export function OpenAttachment(arg1:string):Promise<void>;
 
-export function OpenComposerWindow(arg1:string,arg2:string,arg3:string,arg4:string):Promise<void>;
+export function OpenComposerWindow(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string):Promise<void>;
 
 export function OpenEncryptedAttachment(arg1:string,arg2:string):Promise<void>;
 
@@ -305,8 +400,12 @@ export function OpenFolder(arg1:string):Promise<void>;
 
 export function OpenURL(arg1:string):Promise<void>;
 
+export function PauseMailMerge(arg1:string):Promise<void>;
+
 export function PickAttachmentFiles():Promise<Array<app.ComposerAttachment>>;
 
+export function PickClientCertificateFile():Promise<string>;
+
 export function PickPGPKeyFile():Promise<string>;
 
 export function PickRecipientCertFile():Promise<string>;
@@ -315,32 +414,60 @@ export function PickRecipientPGPKeyFile():Promise<string>;
 
 export function PickSMIMECertificateFile():Promise<string>;
 
+export function PrepareFromTemplate(arg1:string,arg2:string,arg3:string,arg4:string):Promise<smtp.ComposeMessage>;
+
 export function PrepareReply(arg1:string,arg2:string):Promise<smtp.ComposeMessage>;
 
+export function PreviewMailMerge(arg1:smtp.ComposeMessage,arg2:mailmerge.Recipient):Promise<smtp.ComposeMessage>;
+
+export function PreviewRulesOnFolder(arg1:string,arg2:string):Promise<Array<rules.Match>>;
+
 export function ProcessPGPMessage(arg1:string):Promise<app.PGPViewResult>;
 
 export function ProcessSMIMEMessage(arg1:string):Promise<app.SMIMEViewResult>;
 
+export function PutSieveScript(arg1:string,arg2:string,arg3:string):Promise<void>;
+
 export function QuitApp():Promise<void>;
 
 export function ReadFileAsAttachment(arg1:string):Promise<app.ComposerAttachment>;
 
 export function ReauthorizeAccount(arg1:string):Promise<void>;
 
+export function ReauthorizeAccountWithDeviceCode(arg1:string):Promise<oauth2.DeviceAuthorization>;
+
 export function RebuildFTSIndex(arg1:string):Promise<void>;
 
+export function RedirectMessage(arg1:string,arg2:Array<smtp.Address>):Promise<outbox.Message>;
+
 export function RefreshWindowConstraints():Promise<void>;
 
 export function RemoveAccount(arg1:string):Promise<void>;
 
+export function RemoveGmailLabels(arg1:Array<string>,arg2:Array<string>):Promise<void>;
+
 export function RemoveImageAllowlist(arg1:number):Promise<void>;
 
 export function RemovePGPKeyServer(arg1:number):Promise<void>;
 
+export function RemoveTags(arg1:Array<string>,arg2:Array<string>):Promise<void>;
+
 export function RemoveTrustedCertificate(arg1:string):Promise<void>;
 
+export function RenameFolder(arg1:string,arg2:string):Promise<void>;
+
+export function RenderTemplate(arg1:string,arg2:string,arg3:smtp.ComposeMessage):Promise<smtp.ComposeMessage>;
+
 export function ReorderAccounts(arg1:Array<string>):Promise<void>;
 
+export function ReorderRules(arg1:string,arg2:Array<string>):Promise<void>;
+
+export function ResumeMailMerge(arg1:string):Promise<void>;
+
+export function RetryMailMerge(arg1:string):Promise<void>;
+
+export function RunRulesOnFolder(arg1:string,arg2:string):Promise<number>;
+
 export function SaveAllAttachments(arg1:string):Promise<string>;
 
 export function SaveAllEncryptedAttachments(arg1:string):Promise<string>;
@@ -351,19 +478,31 @@ export function SaveDraft(arg1:string,arg2:smtp.ComposeMessage,arg3:string):Prom
 
 export function SaveEncryptedAttachmentAs(arg1:string,arg2:string):Promise<string>;
 
+export function SaveMessageAsTemplate(arg1:string,arg2:string,arg3:boolean,arg4:smtp.ComposeMessage):Promise<template.Template>;
+
 export function SaveOAuthTokens(arg1:string,arg2:string,arg3:string,arg4:string,arg5:number):Promise<void>;
 
 export function SavePendingOAuthTokens(arg1:string):Promise<void>;
 
+export function SaveRule(arg1:rules.Rule):Promise<rules.Rule>;
+
+export function SaveSieveRules(arg1:string,arg2:sieve.Script):Promise<boolean>;
+
+export function SaveTemplate(arg1:template.Template):Promise<template.Template>;
+
 export function SaveUIState(arg1:appstate.UIState):Promise<void>;
 
+export function ScheduleMessage(arg1:string,arg2:smtp.ComposeMessage,arg3:any):Promise<outbox.Message>;
+
 export function SearchContacts(arg1:string,arg2:number):Promise<Array<contact.Contact>>;
 
 export function SearchConversations(arg1:string,arg2:string,arg3:string,arg4:number,arg5:number,arg6:string):Promise<Array<message.ConversationSearchResult>>;
 
 export function SearchUnifiedInbox(arg1:string,arg2:number,arg3:number,arg4:string):Promise<Array<message.ConversationSearchResult>>;
 
-export function SendMessage(arg1:string,arg2:smtp.ComposeMessage):Promise<void>;
+export function SendMessage(arg1:string,arg2:smtp.ComposeMessage):Promise<outbox.Message>;
+
+export function SendOutboxMessageNow(arg1:number):Promise<void>;
 
 export function SendReadReceipt(arg1:string,arg2:string):Promise<void>;
 
@@ -379,6 +518,10 @@ export function SetDefaultPGPKey(arg1:string,arg2:string):Promise<void>;
 
 export function SetDefaultSMIMECertificate(arg1:string,arg2:string):Promise<void>;
 
+export function SetFolderSubscribed(arg1:string,arg2:boolean):Promise<void>;
+
+export function SetFolderWatched(arg1:string,arg2:boolean):Promise<void>;
+
 export function SetLanguage(arg1:string):Promise<void>;
 
 export function SetMarkAsReadDelay(arg1:number):Promise<void>;
@@ -403,16 +546,24 @@ export function SetShowTitleBar(arg1:boolean):Promise<void>;
 
 export function SetStartHidden(arg1:boolean):Promise<void>;
 
+export function SetTagColor(arg1:string,arg2:string,arg3:string):Promise<void>;
+
 export function SetTermsAccepted(arg1:boolean):Promise<void>;
 
 export function SetThemeMode(arg1:string):Promise<void>;
 
+export function SetUndoSendDelay(arg1:number):Promise<void>;
+
 export function ShowWindow():Promise<void>;
 
 export function Star(arg1:Array<string>):Promise<void>;
 
 export function StartContactsOnlyOAuthFlow(arg1:string):Promise<void>;
 
+export function StartMailMerge(arg1:string,arg2:string,arg3:smtp.ComposeMessage,arg4:Array<mailmerge.Recipient>,arg5:number):Promise<mailmerge.Job>;
+
+export function StartOAuthDeviceFlow(arg1:string):Promise<oauth2.DeviceAuthorization>;
+
 export function StartOAuthFlow(arg1:string):Promise<void>;
 
 export function SyncAccountComplete(arg1:string):Promise<void>;
@@ -429,13 +580,13 @@ export function SyncFolders(arg1:string):Promise<void>;
 
 export function SyncPendingDrafts(arg1:string):Promise<void>;
 
-export function TestCardDAVConnection(arg1:string,arg2:string,arg3:string):Promise<void>;
+export function TestCardDAVConnection(arg1:string,arg2:string,arg3:string,arg4:string):Promise<void>;
 
 export function TestConnection(arg1:account.AccountConfig):Promise<app.ConnectionTestResult>;
 
 export function TestOAuthConnection(arg1:string):Promise<void>;
 
-export function TestSMTPConnection(arg1:string,arg2:number,arg3:string,arg4:string,arg5:string):Promise<void>;
+export function TestSMTPConnection(arg1:string,arg2:number,arg3:string,arg4:string,arg5:string,arg6:string,arg7:string):Promise<void>;
 
 export function Trash(arg1:Array<string>):Promise<void>;
 
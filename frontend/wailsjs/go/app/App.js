@@ -6,6 +6,10 @@ export function AcceptCertificate(arg1, arg2, arg3) {
   return window['go']['app']['App']['AcceptCertificate'](arg1, arg2, arg3);
 }
 
+export function ActivateSieveScript(arg1, arg2) {
+  return window['go']['app']['App']['ActivateSieveScript'](arg1, arg2);
+}
+
 export function AddAccount(arg1) {
   return window['go']['app']['App']['AddAccount'](arg1);
 }
@@ -18,6 +22,10 @@ export function AddContactSource(arg1) {
   return window['go']['app']['App']['AddContactSource'](arg1);
 }
 
+export function AddGmailLabels(arg1, arg2) {
+  return window['go']['app']['App']['AddGmailLabels'](arg1, arg2);
+}
+
 export function AddImageAllowlist(arg1, arg2) {
   return window['go']['app']['App']['AddImageAllowlist'](arg1, arg2);
 }
@@ -26,10 +34,18 @@ export function AddPGPKeyServer(arg1) {
   return window['go']['app']['App']['AddPGPKeyServer'](arg1);
 }
 
+export function AddTags(arg1, arg2) {
+  return window['go']['app']['App']['AddTags'](arg1, arg2);
+}
+
 export function Archive(arg1) {
   return window['go']['app']['App']['Archive'](arg1);
 }
 
+export function AutoconfigureAccount(arg1, arg2, arg3, arg4) {
+  return window['go']['app']['App']['AutoconfigureAccount'](arg1, arg2, arg3, arg4);
+}
+
 export function BroadcastAccountUpdated(arg1) {
   return window['go']['app']['App']['BroadcastAccountUpdated'](arg1);
 }
@@ -62,10 +78,18 @@ export function CancelFolderSync(arg1, arg2) {
   return window['go']['app']['App']['CancelFolderSync'](arg1, arg2);
 }
 
+export function CancelMailMerge(arg1) {
+  return window['go']['app']['App']['CancelMailMerge'](arg1);
+}
+
 export function CancelOAuthFlow() {
   return window['go']['app']['App']['CancelOAuthFlow']();
 }
 
+export function CancelOutboxMessage(arg1) {
+  return window['go']['app']['App']['CancelOutboxMessage'](arg1);
+}
+
 export function CheckRecipientCerts(arg1) {
   return window['go']['app']['App']['CheckRecipientCerts'](arg1);
 }
@@ -74,6 +98,10 @@ export function CheckRecipientPGPKeys(arg1) {
   return window['go']['app']['App']['CheckRecipientPGPKeys'](arg1);
 }
 
+export function CheckSieveScript(arg1, arg2) {
+  return window['go']['app']['App']['CheckSieveScript'](arg1, arg2);
+}
+
 export function ClearContactSourceError(arg1) {
   return window['go']['app']['App']['ClearContactSourceError'](arg1);
 }
@@ -94,10 +122,18 @@ export function CopyToFolder(arg1, arg2) {
   return window['go']['app']['App']['CopyToFolder'](arg1, arg2);
 }
 
+export function CreateFolder(arg1, arg2, arg3) {
+  return window['go']['app']['App']['CreateFolder'](arg1, arg2, arg3);
+}
+
 export function CreateIdentity(arg1, arg2) {
   return window['go']['app']['App']['CreateIdentity'](arg1, arg2);
 }
 
+export function DeleteClientCertificate(arg1) {
+  return window['go']['app']['App']['DeleteClientCertificate'](arg1);
+}
+
 export function DeleteContact(arg1) {
   return window['go']['app']['App']['DeleteContact'](arg1);
 }
@@ -110,6 +146,10 @@ export function DeleteDraft(arg1) {
   return window['go']['app']['App']['DeleteDraft'](arg1);
 }
 
+export function DeleteFolder(arg1) {
+  return window['go']['app']['App']['DeleteFolder'](arg1);
+}
+
 export function DeleteIdentity(arg1) {
   return window['go']['app']['App']['DeleteIdentity'](arg1);
 }
@@ -118,6 +158,10 @@ export function DeleteLocalMessages(arg1) {
   return window['go']['app']['App']['DeleteLocalMessages'](arg1);
 }
 
+export function DeleteMailMerge(arg1) {
+  return window['go']['app']['App']['DeleteMailMerge'](arg1);
+}
+
 export function DeletePGPKey(arg1) {
   return window['go']['app']['App']['DeletePGPKey'](arg1);
 }
@@ -130,6 +174,10 @@ export function DeletePermanently(arg1) {
   return window['go']['app']['App']['DeletePermanently'](arg1);
 }
 
+export function DeleteRule(arg1) {
+  return window['go']['app']['App']['DeleteRule'](arg1);
+}
+
 export function DeleteSMIMECertificate(arg1) {
   return window['go']['app']['App']['DeleteSMIMECertificate'](arg1);
 }
@@ -138,8 +186,20 @@ export function DeleteSenderCert(arg1) {
   return window['go']['app']['App']['DeleteSenderCert'](arg1);
 }
 
-export function DiscoverCardDAVAddressbooks(arg1, arg2, arg3) {
-  return window['go']['app']['App']['DiscoverCardDAVAddressbooks'](arg1, arg2, arg3);
+export function DeleteSieveScript(arg1, arg2) {
+  return window['go']['app']['App']['DeleteSieveScript'](arg1, arg2);
+}
+
+export function DeleteTemplate(arg1) {
+  return window['go']['app']['App']['DeleteTemplate'](arg1);
+}
+
+export function DiscoverCardDAVAddressbooks(arg1, arg2, arg3, arg4) {
+  return window['go']['app']['App']['DiscoverCardDAVAddressbooks'](arg1, arg2, arg3, arg4);
+}
+
+export function DismissPendingOperation(arg1) {
+  return window['go']['app']['App']['DismissPendingOperation'](arg1);
 }
 
 export function DownloadAttachment(arg1, arg2) {
@@ -150,10 +210,30 @@ export function DownloadEncryptedAttachment(arg1, arg2, arg3) {
   return window['go']['app']['App']['DownloadEncryptedAttachment'](arg1, arg2, arg3);
 }
 
+export function EditOutboxMessage(arg1) {
+  return window['go']['app']['App']['EditOutboxMessage'](arg1);
+}
+
 export function EmptyTrash(arg1, arg2) {
   return window['go']['app']['App']['EmptyTrash'](arg1, arg2);
 }
 
+export function ExportConversation(arg1, arg2, arg3) {
+  return window['go']['app']['App']['ExportConversation'](arg1, arg2, arg3);
+}
+
+export function ExportFolder(arg1, arg2) {
+  return window['go']['app']['App']['ExportFolder'](arg1, arg2);
+}
+
+export function ExportMessages(arg1, arg2) {
+  return window['go']['app']['App']['ExportMessages'](arg1, arg2);
+}
+
+export function ExportSearchResults(arg1, arg2, arg3, arg4) {
+  return window['go']['app']['App']['ExportSearchResults'](arg1, arg2, arg3, arg4);
+}
+
 export function FetchMessageBody(arg1) {
   return window['go']['app']['App']['FetchMessageBody'](arg1);
 }
@@ -178,6 +258,10 @@ export function GetAccounts() {
   return window['go']['app']['App']['GetAccounts']();
 }
 
+export function GetAddressbookRecipients(arg1) {
+  return window['go']['app']['App']['GetAddressbookRecipients'](arg1);
+}
+
 export function GetAppInfo() {
   return window['go']['app']['App']['GetAppInfo']();
 }
@@ -190,6 +274,10 @@ export function GetAttachments(arg1) {
   return window['go']['app']['App']['GetAttachments'](arg1);
 }
 
+export function GetAuthMechanisms() {
+  return window['go']['app']['App']['GetAuthMechanisms']();
+}
+
 export function GetAutoDetectedFolders(arg1) {
   return window['go']['app']['App']['GetAutoDetectedFolders'](arg1);
 }
@@ -242,6 +330,10 @@ export function GetConversations(arg1, arg2, arg3, arg4, arg5, arg6) {
   return window['go']['app']['App']['GetConversations'](arg1, arg2, arg3, arg4, arg5, arg6);
 }
 
+export function GetDeliveryReports(arg1) {
+  return window['go']['app']['App']['GetDeliveryReports'](arg1);
+}
+
 export function GetDraft(arg1) {
   return window['go']['app']['App']['GetDraft'](arg1);
 }
@@ -262,6 +354,10 @@ export function GetFolders(arg1) {
   return window['go']['app']['App']['GetFolders'](arg1);
 }
 
+export function GetGmailLabels(arg1) {
+  return window['go']['app']['App']['GetGmailLabels'](arg1);
+}
+
 export function GetIMAPConnectionForUndo(arg1, arg2) {
   return window['go']['app']['App']['GetIMAPConnectionForUndo'](arg1, arg2);
 }
@@ -294,6 +390,14 @@ export function GetLinkedAccountsForContactSync() {
   return window['go']['app']['App']['GetLinkedAccountsForContactSync']();
 }
 
+export function GetMailMergeJobs(arg1) {
+  return window['go']['app']['App']['GetMailMergeJobs'](arg1);
+}
+
+export function GetMailMergeRecipients(arg1) {
+  return window['go']['app']['App']['GetMailMergeRecipients'](arg1);
+}
+
 export function GetMarkAsReadDelay() {
   return window['go']['app']['App']['GetMarkAsReadDelay']();
 }
@@ -306,6 +410,10 @@ export function GetMessageCount(arg1, arg2) {
   return window['go']['app']['App']['GetMessageCount'](arg1, arg2);
 }
 
+export function GetMessageGmailLabels(arg1) {
+  return window['go']['app']['App']['GetMessageGmailLabels'](arg1);
+}
+
 export function GetMessageListDensity() {
   return window['go']['app']['App']['GetMessageListDensity']();
 }
@@ -322,10 +430,18 @@ export function GetMessages(arg1, arg2, arg3, arg4) {
   return window['go']['app']['App']['GetMessages'](arg1, arg2, arg3, arg4);
 }
 
+export function GetOAuthProviders() {
+  return window['go']['app']['App']['GetOAuthProviders']();
+}
+
 export function GetOAuthStatus(arg1) {
   return window['go']['app']['App']['GetOAuthStatus'](arg1);
 }
 
+export function GetOutbox(arg1) {
+  return window['go']['app']['App']['GetOutbox'](arg1);
+}
+
 export function GetPGPEncryptPolicy(arg1) {
   return window['go']['app']['App']['GetPGPEncryptPolicy'](arg1);
 }
@@ -346,10 +462,18 @@ export function GetPendingMailto() {
   return window['go']['app']['App']['GetPendingMailto']();
 }
 
+export function GetPendingOperations(arg1) {
+  return window['go']['app']['App']['GetPendingOperations'](arg1);
+}
+
 export function GetReadReceiptResponsePolicy() {
   return window['go']['app']['App']['GetReadReceiptResponsePolicy']();
 }
 
+export function GetRules(arg1) {
+  return window['go']['app']['App']['GetRules'](arg1);
+}
+
 export function GetRunBackground() {
   return window['go']['app']['App']['GetRunBackground']();
 }
@@ -378,6 +502,22 @@ export function GetShowTitleBar() {
   return window['go']['app']['App']['GetShowTitleBar']();
 }
 
+export function GetSieveCapabilities(arg1) {
+  return window['go']['app']['App']['GetSieveCapabilities'](arg1);
+}
+
+export function GetSieveRules(arg1) {
+  return window['go']['app']['App']['GetSieveRules'](arg1);
+}
+
+export function GetSieveScript(arg1, arg2) {
+  return window['go']['app']['App']['GetSieveScript'](arg1, arg2);
+}
+
+export function GetSieveScripts(arg1) {
+  return window['go']['app']['App']['GetSieveScripts'](arg1);
+}
+
 export function GetSourceAddressbooks(arg1) {
   return window['go']['app']['App']['GetSourceAddressbooks'](arg1);
 }
@@ -398,6 +538,10 @@ export function GetSystemTheme() {
   return window['go']['app']['App']['GetSystemTheme']();
 }
 
+export function GetTemplates(arg1) {
+  return window['go']['app']['App']['GetTemplates'](arg1);
+}
+
 export function GetTermsAccepted() {
   return window['go']['app']['App']['GetTermsAccepted']();
 }
@@ -418,6 +562,10 @@ export function GetUndoDescription() {
   return window['go']['app']['App']['GetUndoDescription']();
 }
 
+export function GetUndoSendDelay() {
+  return window['go']['app']['App']['GetUndoSendDelay']();
+}
+
 export function GetUnifiedInboxConversations(arg1, arg2, arg3, arg4) {
   return window['go']['app']['App']['GetUnifiedInboxConversations'](arg1, arg2, arg3, arg4);
 }
@@ -446,6 +594,18 @@ export function IgnoreReadReceipt(arg1, arg2) {
   return window['go']['app']['App']['IgnoreReadReceipt'](arg1, arg2);
 }
 
+export function ImportClientCertificateFromPath(arg1, arg2) {
+  return window['go']['app']['App']['ImportClientCertificateFromPath'](arg1, arg2);
+}
+
+export function ImportFiles(arg1, arg2) {
+  return window['go']['app']['App']['ImportFiles'](arg1, arg2);
+}
+
+export function ImportMessages(arg1) {
+  return window['go']['app']['App']['ImportMessages'](arg1);
+}
+
 export function ImportPGPKeyFromPath(arg1, arg2, arg3) {
   return window['go']['app']['App']['ImportPGPKeyFromPath'](arg1, arg2, arg3);
 }
@@ -486,6 +646,10 @@ export function LinkAccountContactSource(arg1, arg2, arg3) {
   return window['go']['app']['App']['LinkAccountContactSource'](arg1, arg2, arg3);
 }
 
+export function ListClientCertificates() {
+  return window['go']['app']['App']['ListClientCertificates']();
+}
+
 export function ListContacts(arg1) {
   return window['go']['app']['App']['ListContacts'](arg1);
 }
@@ -510,6 +674,14 @@ export function ListSenderCerts() {
   return window['go']['app']['App']['ListSenderCerts']();
 }
 
+export function ListTags(arg1) {
+  return window['go']['app']['App']['ListTags'](arg1);
+}
+
+export function LoadMailMergeCSV() {
+  return window['go']['app']['App']['LoadMailMergeCSV']();
+}
+
 export function LookupHKP(arg1) {
   return window['go']['app']['App']['LookupHKP'](arg1);
 }
@@ -546,6 +718,10 @@ export function MarkAsUnread(arg1) {
   return window['go']['app']['App']['MarkAsUnread'](arg1);
 }
 
+export function MoveFolder(arg1, arg2) {
+  return window['go']['app']['App']['MoveFolder'](arg1, arg2);
+}
+
 export function MoveLocalMessages(arg1, arg2) {
   return window['go']['app']['App']['MoveLocalMessages'](arg1, arg2);
 }
@@ -558,8 +734,8 @@ export function OpenAttachment(arg1) {
   return window['go']['app']['App']['OpenAttachment'](arg1);
 }
 
-export function OpenComposerWindow(arg1, arg2, arg3, arg4) {
-  return window['go']['app']['App']['OpenComposerWindow'](arg1, arg2, arg3, arg4);
+export function OpenComposerWindow(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['app']['App']['OpenComposerWindow'](arg1, arg2, arg3, arg4, arg5);
 }
 
 export function OpenEncryptedAttachment(arg1, arg2) {
@@ -578,10 +754,18 @@ export function OpenURL(arg1) {
   return window['go']['app']['App']['OpenURL'](arg1);
 }
 
+export function PauseMailMerge(arg1) {
+  return window['go']['app']['App']['PauseMailMerge'](arg1);
+}
+
 export function PickAttachmentFiles() {
   return window['go']['app']['App']['PickAttachmentFiles']();
 }
 
+export function PickClientCertificateFile() {
+  return window['go']['app']['App']['PickClientCertificateFile']();
+}
+
 export function PickPGPKeyFile() {
   return window['go']['app']['App']['PickPGPKeyFile']();
 }
@@ -598,10 +782,22 @@ export function PickSMIMECertificateFile() {
   return window['go']['app']['App']['PickSMIMECertificateFile']();
 }
 
+export function PrepareFromTemplate(arg1, arg2, arg3, arg4) {
+  return window['go']['app']['App']['PrepareFromTemplate'](arg1, arg2, arg3, arg4);
+}
+
 export function PrepareReply(arg1, arg2) {
   return window['go']['app']['App']['PrepareReply'](arg1, arg2);
 }
 
+export function PreviewMailMerge(arg1, arg2) {
+  return window['go']['app']['App']['PreviewMailMerge'](arg1, arg2);
+}
+
+export function PreviewRulesOnFolder(arg1, arg2) {
+  return window['go']['app']['App']['PreviewRulesOnFolder'](arg1, arg2);
+}
+
 export function ProcessPGPMessage(arg1) {
   return window['go']['app']['App']['ProcessPGPMessage'](arg1);
 }
@@ -610,6 +806,10 @@ export function ProcessSMIMEMessage(arg1) {
   return window['go']['app']['App']['ProcessSMIMEMessage'](arg1);
 }
 
+export function PutSieveScript(arg1, arg2, arg3) {
+  return window['go']['app']['App']['PutSieveScript'](arg1, arg2, arg3);
+}
+
 export function QuitApp() {
   return window['go']['app']['App']['QuitApp']();
 }
@@ -622,10 +822,18 @@ export function ReauthorizeAccount(arg1) {
   return window['go']['app']['App']['ReauthorizeAccount'](arg1);
 }
 
+export function ReauthorizeAccountWithDeviceCode(arg1) {
+  return window['go']['app']['App']['ReauthorizeAccountWithDeviceCode'](arg1);
+}
+
 export function RebuildFTSIndex(arg1) {
   return window['go']['app']['App']['RebuildFTSIndex'](arg1);
 }
 
+export function RedirectMessage(arg1, arg2) {
+  return window['go']['app']['App']['RedirectMessage'](arg1, arg2);
+}
+
 export function RefreshWindowConstraints() {
   return window['go']['app']['App']['RefreshWindowConstraints']();
 }
@@ -634,6 +842,10 @@ export function RemoveAccount(arg1) {
   return window['go']['app']['App']['RemoveAccount'](arg1);
 }
 
+export function RemoveGmailLabels(arg1, arg2) {
+  return window['go']['app']['App']['RemoveGmailLabels'](arg1, arg2);
+}
+
 export function RemoveImageAllowlist(arg1) {
   return window['go']['app']['App']['RemoveImageAllowlist'](arg1);
 }
@@ -642,14 +854,42 @@ export function RemovePGPKeyServer(arg1) {
   return window['go']['app']['App']['RemovePGPKeyServer'](arg1);
 }
 
+export function RemoveTags(arg1, arg2) {
+  return window['go']['app']['App']['RemoveTags'](arg1, arg2);
+}
+
 export function RemoveTrustedCertificate(arg1) {
   return window['go']['app']['App']['RemoveTrustedCertificate'](arg1);
 }
 
+export function RenameFolder(arg1, arg2) {
+  return window['go']['app']['App']['RenameFolder'](arg1, arg2);
+}
+
+export function RenderTemplate(arg1, arg2, arg3) {
+  return window['go']['app']['App']['RenderTemplate'](arg1, arg2, arg3);
+}
+
 export function ReorderAccounts(arg1) {
   return window['go']['app']['App']['ReorderAccounts'](arg1);
 }
 
+export function ReorderRules(arg1, arg2) {
+  return window['go']['app']['App']['ReorderRules'](arg1, arg2);
+}
+
+export function ResumeMailMerge(arg1) {
+  return window['go']['app']['App']['ResumeMailMerge'](arg1);
+}
+
+export function RetryMailMerge(arg1) {
+  return window['go']['app']['App']['RetryMailMerge'](arg1);
+}
+
+export function RunRulesOnFolder(arg1, arg2) {
+  return window['go']['app']['App']['RunRulesOnFolder'](arg1, arg2);
+}
+
 export function SaveAllAttachments(arg1) {
   return window['go']['app']['App']['SaveAllAttachments'](arg1);
 }
@@ -670,6 +910,10 @@ export function SaveEncryptedAttachmentAs(arg1, arg2) {
   return window['go']['app']['App']['SaveEncryptedAttachmentAs'](arg1, arg2);
 }
 
+export function SaveMessageAsTemplate(arg1, arg2, arg3, arg4) {
+  return window['go']['app']['App']['SaveMessageAsTemplate'](arg1, arg2, arg3, arg4);
+}
+
 export function SaveOAuthTokens(arg1, arg2, arg3, arg4, arg5) {
   return window['go']['app']['App']['SaveOAuthTokens'](arg1, arg2, arg3, arg4, arg5);
 }
@@ -678,10 +922,26 @@ export function SavePendingOAuthTokens(arg1) {
   return window['go']['app']['App']['SavePendingOAuthTokens'](arg1);
 }
 
+export function SaveRule(arg1) {
+  return window['go']['app']['App']['SaveRule'](arg1);
+}
+
+export function SaveSieveRules(arg1, arg2) {
+  return window['go']['app']['App']['SaveSieveRules'](arg1, arg2);
+}
+
+export function SaveTemplate(arg1) {
+  return window['go']['app']['App']['SaveTemplate'](arg1);
+}
+
 export function SaveUIState(arg1) {
   return window['go']['app']['App']['SaveUIState'](arg1);
 }
 
+export function ScheduleMessage(arg1, arg2, arg3) {
+  return window['go']['app']['App']['ScheduleMessage'](arg1, arg2, arg3);
+}
+
 export function SearchContacts(arg1, arg2) {
   return window['go']['app']['App']['SearchContacts'](arg1, arg2);
 }
@@ -698,6 +958,10 @@ export function SendMessage(arg1, arg2) {
   return window['go']['app']['App']['SendMessage'](arg1, arg2);
 }
 
+export function SendOutboxMessageNow(arg1) {
+  return window['go']['app']['App']['SendOutboxMessageNow'](arg1);
+}
+
 export function SendReadReceipt(arg1, arg2) {
   return window['go']['app']['App']['SendReadReceipt'](arg1, arg2);
 }
@@ -726,6 +990,14 @@ export function SetDefaultSMIMECertificate(arg1, arg2) {
   return window['go']['app']['App']['SetDefaultSMIMECertificate'](arg1, arg2);
 }
 
+export function SetFolderSubscribed(arg1, arg2) {
+  return window['go']['app']['App']['SetFolderSubscribed'](arg1, arg2);
+}
+
+export function SetFolderWatched(arg1, arg2) {
+  return window['go']['app']['App']['SetFolderWatched'](arg1, arg2);
+}
+
 export function SetLanguage(arg1) {
   return window['go']['app']['App']['SetLanguage'](arg1);
 }
@@ -774,6 +1046,10 @@ export function SetStartHidden(arg1) {
   return window['go']['app']['App']['SetStartHidden'](arg1);
 }
 
+export function SetTagColor(arg1, arg2, arg3) {
+  return window['go']['app']['App']['SetTagColor'](arg1, arg2, arg3);
+}
+
 export function SetTermsAccepted(arg1) {
   return window['go']['app']['App']['SetTermsAccepted'](arg1);
 }
@@ -782,6 +1058,10 @@ export function SetThemeMode(arg1) {
   return window['go']['app']['App']['SetThemeMode'](arg1);
 }
 
+export function SetUndoSendDelay(arg1) {
+  return window['go']['app']['App']['SetUndoSendDelay'](arg1);
+}
+
 export function ShowWindow() {
   return window['go']['app']['App']['ShowWindow']();
 }
@@ -794,6 +1074,14 @@ export function StartContactsOnlyOAuthFlow(arg1) {
   return window['go']['app']['App']['StartContactsOnlyOAuthFlow'](arg1);
 }
 
+export function StartMailMerge(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['app']['App']['StartMailMerge'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function StartOAuthDeviceFlow(arg1) {
+  return window['go']['app']['App']['StartOAuthDeviceFlow'](arg1);
+}
+
 export function StartOAuthFlow(arg1) {
   return window['go']['app']['App']['StartOAuthFlow'](arg1);
 }
@@ -826,8 +1114,8 @@ export function SyncPendingDrafts(arg1) {
   return window['go']['app']['App']['SyncPendingDrafts'](arg1);
 }
 
-export function TestCardDAVConnection(arg1, arg2, arg3) {
-  return window['go']['app']['App']['TestCardDAVConnection'](arg1, arg2, arg3);
+export function TestCardDAVConnection(arg1, arg2, arg3, arg4) {
+  return window['go']['app']['App']['TestCardDAVConnection'](arg1, arg2, arg3, arg4);
 }
 
 export function TestConnection(arg1) {
@@ -838,8 +1126,8 @@ export function TestOAuthConnection(arg1) {
   return window['go']['app']['App']['TestOAuthConnection'](arg1);
 }
 
-export function TestSMTPConnection(arg1, arg2, arg3, arg4, arg5) {
-  return window['go']['app']['App']['TestSMTPConnection'](arg1, arg2, arg3, arg4, arg5);
+export function TestSMTPConnection(arg1, arg2, arg3, arg4, arg5, arg6, arg7) {
+  return window['go']['app']['App']['TestSMTPConnection'](arg1, arg2, arg3, arg4, arg5, arg6, arg7);
 }
 
 export function Trash(arg1) {
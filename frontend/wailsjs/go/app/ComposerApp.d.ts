@@ -9,6 +9,8 @@ import {smime} from '../models';
 import {draft} from '../models';
 import {contact} from '../models';
 import {context} from '../models';
+import {outbox} from '../models';
+import {template} from '../models';
 
 export function CheckRecipientCerts(arg1:Array<string>):Promise<Record<string, boolean>>;
 
@@ -18,6 +20,8 @@ export function CloseWindow():Promise<void>;
 
 export function DeleteDraft(arg1:string):Promise<void>;
 
+export function DeleteTemplate(arg1:string):Promise<void>;
+
 export function GetAccount():Promise<account.Account>;
 
 export function GetComposeMode():Promise<app.ComposeMode>;
@@ -42,6 +46,8 @@ export function GetSMIMESignPolicy():Promise<string>;
 
 export function GetSystemTheme():Promise<string>;
 
+export function GetTemplates():Promise<Array<template.Template>>;
+
 export function GetThemeMode():Promise<string>;
 
 export function HasPGPKey():Promise<boolean>;
@@ -68,11 +74,17 @@ export function PrepareReply():Promise<smtp.ComposeMessage>;
 
 export function RefreshWindowConstraints():Promise<void>;
 
+export function RenderTemplate(arg1:string,arg2:smtp.ComposeMessage):Promise<smtp.ComposeMessage>;
+
 export function SaveDraft(arg1:smtp.ComposeMessage,arg2:string):Promise<draft.Draft>;
 
+export function SaveMessageAsTemplate(arg1:string,arg2:boolean,arg3:smtp.ComposeMessage):Promise<template.Template>;
+
+export function ScheduleMessage(arg1:smtp.ComposeMessage,arg2:any):Promise<outbox.Message>;
+
 export function SearchContacts(arg1:string,arg2:number):Promise<Array<contact.Contact>>;
 
-export function SendMessage(arg1:smtp.ComposeMessage):Promise<void>;
+export function SendMessage(arg1:smtp.ComposeMessage):Promise<outbox.Message>;
 
 export function Shutdown(arg1:context.Context):Promise<void>;
 
@@ -18,6 +18,10 @@ export function DeleteDraft(arg1) {
   return window['go']['app']['ComposerApp']['DeleteDraft'](arg1);
 }
 
+export function DeleteTemplate(arg1) {
+  return window['go']['app']['ComposerApp']['DeleteTemplate'](arg1);
+}
+
 export function GetAccount() {
   return window['go']['app']['ComposerApp']['GetAccount']();
 }
@@ -66,6 +70,10 @@ export function GetSystemTheme() {
   return window['go']['app']['ComposerApp']['GetSystemTheme']();
 }
 
+export function GetTemplates() {
+  return window['go']['app']['ComposerApp']['GetTemplates']();
+}
+
 export function GetThemeMode() {
   return window['go']['app']['ComposerApp']['GetThemeMode']();
 }
@@ -118,10 +126,22 @@ export function RefreshWindowConstraints() {
   return window['go']['app']['ComposerApp']['RefreshWindowConstraints']();
 }
 
+export function RenderTemplate(arg1, arg2) {
+  return window['go']['app']['ComposerApp']['RenderTemplate'](arg1, arg2);
+}
+
 export function SaveDraft(arg1, arg2) {
   return window['go']['app']['ComposerApp']['SaveDraft'](arg1, arg2);
 }
 
+export function SaveMessageAsTemplate(arg1, arg2, arg3) {
+  return window['go']['app']['ComposerApp']['SaveMessageAsTemplate'](arg1, arg2, arg3);
+}
+
+export function ScheduleMessage(arg1, arg2) {
+  return window['go']['app']['ComposerApp']['ScheduleMessage'](arg1, arg2);
+}
+
 export function SearchContacts(arg1, arg2) {
   return window['go']['app']['ComposerApp']['SearchContacts'](arg1, arg2);
 }
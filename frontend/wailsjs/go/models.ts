@@ -4,14 +4,26 @@ export namespace account {
 	    id: string;
 	    name: string;
 	    email: string;
+	    protocol: string;
+	    jmapSessionUrl?: string;
 	    imapHost: string;
 	    imapPort: number;
 	    imapSecurity: string;
+	    imapCompress: boolean;
+	    pop3Host?: string;
+	    pop3Port?: number;
+	    pop3Security?: string;
+	    pop3Apop: boolean;
+	    pop3LeaveOnServer: boolean;
+	    pop3DeleteAfterDays: number;
+	    maildirPath?: string;
 	    smtpHost: string;
 	    smtpPort: number;
 	    smtpSecurity: string;
 	    authType: string;
 	    username: string;
+	    authMechanism: string;
+	    clientCertId: string;
 	    enabled: boolean;
 	    orderIndex: number;
 	    color: string;
@@ -39,14 +51,26 @@ export namespace account {
 	        this.id = source["id"];
 	        this.name = source["name"];
 	        this.email = source["email"];
+	        this.protocol = source["protocol"];
+	        this.jmapSessionUrl = source["jmapSessionUrl"];
 	        this.imapHost = source["imapHost"];
 	        this.imapPort = source["imapPort"];
 	        this.imapSecurity = source["imapSecurity"];
+	        this.imapCompress = source["imapCompress"];
+	        this.pop3Host = source["pop3Host"];
+	        this.pop3Port = source["pop3Port"];
+	        this.pop3Security = source["pop3Security"];
+	        this.pop3Apop = source["pop3Apop"];
+	        this.pop3LeaveOnServer = source["pop3LeaveOnServer"];
+	        this.pop3DeleteAfterDays = source["pop3DeleteAfterDays"];
+	        this.maildirPath = source["maildirPath"];
 	        this.smtpHost = source["smtpHost"];
 	        this.smtpPort = source["smtpPort"];
 	        this.smtpSecurity = source["smtpSecurity"];
 	        this.authType = source["authType"];
 	        this.username = source["username"];
+	        this.authMechanism = source["authMechanism"];
+	        this.clientCertId = source["clientCertId"];
 	        this.enabled = source["enabled"];
 	        this.orderIndex = source["orderIndex"];
 	        this.color = source["color"];
@@ -86,15 +110,27 @@ export namespace account {
 	    name: string;
 	    displayName: string;
 	    email: string;
+	    protocol: string;
+	    jmapSessionUrl: string;
 	    imapHost: string;
 	    imapPort: number;
 	    imapSecurity: string;
+	    imapCompress: boolean;
+	    pop3Host: string;
+	    pop3Port: number;
+	    pop3Security: string;
+	    pop3Apop: boolean;
+	    pop3LeaveOnServer: boolean;
+	    pop3DeleteAfterDays: number;
+	    maildirPath: string;
 	    smtpHost: string;
 	    smtpPort: number;
 	    smtpSecurity: string;
 	    authType: string;
 	    username: string;
 	    password: string;
+	    authMechanism: string;
+	    clientCertId: string;
 	    color: string;
 	    syncPeriodDays: number;
 	    syncInterval: number;
@@ -116,15 +152,27 @@ export namespace account {
 	        this.name = source["name"];
 	        this.displayName = source["displayName"];
 	        this.email = source["email"];
+	        this.protocol = source["protocol"];
+	        this.jmapSessionUrl = source["jmapSessionUrl"];
 	        this.imapHost = source["imapHost"];
 	        this.imapPort = source["imapPort"];
 	        this.imapSecurity = source["imapSecurity"];
+	        this.imapCompress = source["imapCompress"];
+	        this.pop3Host = source["pop3Host"];
+	        this.pop3Port = source["pop3Port"];
+	        this.pop3Security = source["pop3Security"];
+	        this.pop3Apop = source["pop3Apop"];
+	        this.pop3LeaveOnServer = source["pop3LeaveOnServer"];
+	        this.pop3DeleteAfterDays = source["pop3DeleteAfterDays"];
+	        this.maildirPath = source["maildirPath"];
 	        this.smtpHost = source["smtpHost"];
 	        this.smtpPort = source["smtpPort"];
 	        this.smtpSecurity = source["smtpSecurity"];
 	        this.authType = source["authType"];
 	        this.username = source["username"];
 	        this.password = source["password"];
+	        this.authMechanism = source["authMechanism"];
+	        this.clientCertId = source["clientCertId"];
 	        this.color = source["color"];
 	        this.syncPeriodDays = source["syncPeriodDays"];
 	        this.syncInterval = source["syncInterval"];
@@ -255,6 +303,48 @@ export namespace app {
 	        this.license = source["license"];
 	    }
 	}
+	export class AutoconfigResult {
+	    config: account.AccountConfig;
+	    source: string;
+	    oauthProvider?: string;
+	    probe: ConnectionTestResult;
+	    probed: boolean;
+	    needsConfirm: boolean;
+	    candidates: autoconfig.Candidate[];
+	
+	    static createFrom(source: any = {}) {
+	        return new AutoconfigResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.config = this.convertValues(source["config"], account.AccountConfig);
+	        this.source = source["source"];
+	        this.oauthProvider = source["oauthProvider"];
+	        this.probe = this.convertValues(source["probe"], ConnectionTestResult);
+	        this.probed = source["probed"];
+	        this.needsConfirm = source["needsConfirm"];
+	        this.candidates = this.convertValues(source["candidates"], autoconfig.Candidate);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class ComposeMode {
 	    accountId: string;
 	    mode: string;
@@ -419,6 +509,26 @@ export namespace app {
 	        this.body = source["body"];
 	    }
 	}
+	export class OAuthProviderInfo {
+	    name: string;
+	    displayName: string;
+	    configured: boolean;
+	    flow: string;
+	    deviceFlow: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new OAuthProviderInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.displayName = source["displayName"];
+	        this.configured = source["configured"];
+	        this.flow = source["flow"];
+	        this.deviceFlow = source["deviceFlow"];
+	    }
+	}
 	export class OAuthStatus {
 	    isOAuth: boolean;
 	    provider: string;
@@ -590,6 +700,49 @@ export namespace appstate {
 
 }
 
+export namespace autoconfig {
+	
+	export class Candidate {
+	    config: account.AccountConfig;
+	    source: string;
+	    score: number;
+	    unverified?: boolean;
+	    oauthProvider?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Candidate(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.config = this.convertValues(source["config"], account.AccountConfig);
+	        this.source = source["source"];
+	        this.score = source["score"];
+	        this.unverified = source["unverified"];
+	        this.oauthProvider = source["oauthProvider"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
 export namespace carddav {
 	
 	export class Addressbook {
@@ -658,6 +811,7 @@ export namespace carddav {
 	    url: string;
 	    username: string;
 	    account_id?: string;
+	    client_cert_id?: string;
 	    enabled: boolean;
 	    sync_interval: number;
 	    // Go type: time
@@ -681,6 +835,7 @@ export namespace carddav {
 	        this.url = source["url"];
 	        this.username = source["username"];
 	        this.account_id = source["account_id"];
+	        this.client_cert_id = source["client_cert_id"];
 	        this.enabled = source["enabled"];
 	        this.sync_interval = source["sync_interval"];
 	        this.last_synced_at = this.convertValues(source["last_synced_at"], null);
@@ -715,6 +870,7 @@ export namespace carddav {
 	    username: string;
 	    password: string;
 	    account_id?: string;
+	    client_cert_id?: string;
 	    enabled: boolean;
 	    sync_interval: number;
 	    enabled_addressbooks?: string[];
@@ -731,6 +887,7 @@ export namespace carddav {
 	        this.username = source["username"];
 	        this.password = source["password"];
 	        this.account_id = source["account_id"];
+	        this.client_cert_id = source["client_cert_id"];
 	        this.enabled = source["enabled"];
 	        this.sync_interval = source["sync_interval"];
 	        this.enabled_addressbooks = source["enabled_addressbooks"];
@@ -805,6 +962,55 @@ export namespace certificate {
 	    }
 	}
 
+	export class ClientCertificate {
+	    id: string;
+	    subject: string;
+	    issuer: string;
+	    serialNumber: string;
+	    fingerprint: string;
+	    // Go type: time
+	    notBefore: any;
+	    // Go type: time
+	    notAfter: any;
+	    isExpired: boolean;
+	    // Go type: time
+	    createdAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new ClientCertificate(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.subject = source["subject"];
+	        this.issuer = source["issuer"];
+	        this.serialNumber = source["serialNumber"];
+	        this.fingerprint = source["fingerprint"];
+	        this.notBefore = this.convertValues(source["notBefore"], null);
+	        this.notAfter = this.convertValues(source["notAfter"], null);
+	        this.isExpired = source["isExpired"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 }
 
 export namespace contact {
@@ -954,6 +1160,8 @@ export namespace folder {
 	    unreadCount: number;
 	    // Go type: time
 	    lastSync?: any;
+	    watched: boolean;
+	    subscribed: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new Folder(source);
@@ -973,6 +1181,8 @@ export namespace folder {
 	        this.totalCount = source["totalCount"];
 	        this.unreadCount = source["unreadCount"];
 	        this.lastSync = this.convertValues(source["lastSync"], null);
+	        this.watched = source["watched"];
+	        this.subscribed = source["subscribed"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -1045,6 +1255,151 @@ export namespace imap {
 
 }
 
+export namespace mailmerge {
+	
+	export class Job {
+	    id: string;
+	    accountId: string;
+	    name: string;
+	    message: smtp.ComposeMessage;
+	    ratePerMinute: number;
+	    status: string;
+	    total: number;
+	    sent: number;
+	    failed: number;
+	    pending: number;
+	    // Go type: time
+	    createdAt: any;
+	    // Go type: time
+	    updatedAt: any;
+	    // Go type: time
+	    completedAt?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Job(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.accountId = source["accountId"];
+	        this.name = source["name"];
+	        this.message = this.convertValues(source["message"], smtp.ComposeMessage);
+	        this.ratePerMinute = source["ratePerMinute"];
+	        this.status = source["status"];
+	        this.total = source["total"];
+	        this.sent = source["sent"];
+	        this.failed = source["failed"];
+	        this.pending = source["pending"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.updatedAt = this.convertValues(source["updatedAt"], null);
+	        this.completedAt = this.convertValues(source["completedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class List {
+	    recipients: Recipient[];
+	    columns: string[];
+	    skipped: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new List(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.recipients = this.convertValues(source["recipients"], Recipient);
+	        this.columns = source["columns"];
+	        this.skipped = source["skipped"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Recipient {
+	    id: number;
+	    jobId: string;
+	    position: number;
+	    email: string;
+	    name: string;
+	    fields: {[key;
+	    status: string;
+	    error?: string;
+	    attempts: number;
+	    // Go type: time
+	    sentAt?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Recipient(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.jobId = source["jobId"];
+	        this.position = source["position"];
+	        this.email = source["email"];
+	        this.name = source["name"];
+	        this.fields = this.convertValues(source["fields"],  string]);
+	        this.status = source["status"];
+	        this.error = source["error"];
+	        this.attempts = source["attempts"];
+	        this.sentAt = this.convertValues(source["sentAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
 export namespace message {
 	
 	export class Address {
@@ -1087,6 +1442,53 @@ export namespace message {
 	        this.localPath = source["localPath"];
 	    }
 	}
+	export class DeliveryReport {
+	    id: number;
+	    reportMessageId: string;
+	    originalMessageId: string;
+	    kind: string;
+	    recipient: string;
+	    action: string;
+	    status?: string;
+	    diagnostic?: string;
+	    // Go type: time
+	    reportedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new DeliveryReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.reportMessageId = source["reportMessageId"];
+	        this.originalMessageId = source["originalMessageId"];
+	        this.kind = source["kind"];
+	        this.recipient = source["recipient"];
+	        this.action = source["action"];
+	        this.status = source["status"];
+	        this.diagnostic = source["diagnostic"];
+	        this.reportedAt = this.convertValues(source["reportedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class Message {
 	    id: string;
 	    accountId: string;
@@ -1112,6 +1514,7 @@ export namespace message {
 	    isForwarded: boolean;
 	    isDraft: boolean;
 	    isDeleted: boolean;
+	    tags?: string[];
 	    size: number;
 	    hasAttachments: boolean;
 	    bodyText?: string;
@@ -1161,6 +1564,7 @@ export namespace message {
 	        this.isForwarded = source["isForwarded"];
 	        this.isDraft = source["isDraft"];
 	        this.isDeleted = source["isDeleted"];
+	        this.tags = source["tags"];
 	        this.size = source["size"];
 	        this.hasAttachments = source["hasAttachments"];
 	        this.bodyText = source["bodyText"];
@@ -1212,6 +1616,7 @@ export namespace message {
 	    participants: Address[];
 	    messageIds: string[];
 	    isEncrypted: boolean;
+	    tags?: string[];
 	    messages?: Message[];
 	    accountId?: string;
 	    accountName?: string;
@@ -1235,6 +1640,7 @@ export namespace message {
 	        this.participants = this.convertValues(source["participants"], Address);
 	        this.messageIds = source["messageIds"];
 	        this.isEncrypted = source["isEncrypted"];
+	        this.tags = source["tags"];
 	        this.messages = this.convertValues(source["messages"], Message);
 	        this.accountId = source["accountId"];
 	        this.accountName = source["accountName"];
@@ -1273,6 +1679,7 @@ export namespace message {
 	    participants: Address[];
 	    messageIds: string[];
 	    isEncrypted: boolean;
+	    tags?: string[];
 	    messages?: Message[];
 	    accountId?: string;
 	    accountName?: string;
@@ -1301,6 +1708,7 @@ export namespace message {
 	        this.participants = this.convertValues(source["participants"], Address);
 	        this.messageIds = source["messageIds"];
 	        this.isEncrypted = source["isEncrypted"];
+	        this.tags = source["tags"];
 	        this.messages = this.convertValues(source["messages"], Message);
 	        this.accountId = source["accountId"];
 	        this.accountName = source["accountName"];
@@ -1405,10 +1813,196 @@ export namespace message {
 		    return a;
 		}
 	}
-
-}
-
-export namespace pgp {
+	export class Tag {
+	    keyword: string;
+	    color?: string;
+	    count: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Tag(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.keyword = source["keyword"];
+	        this.color = source["color"];
+	        this.count = source["count"];
+	    }
+	}
+
+}
+
+export namespace oauth2 {
+	
+	export class DeviceAuthorization {
+	    provider: string;
+	    userCode: string;
+	    verificationUri: string;
+	    verificationUriComplete?: string;
+	    expiresAt: any;
+	    message?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DeviceAuthorization(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.provider = source["provider"];
+	        this.userCode = source["userCode"];
+	        this.verificationUri = source["verificationUri"];
+	        this.verificationUriComplete = source["verificationUriComplete"];
+	        this.expiresAt = this.convertValues(source["expiresAt"], null);
+	        this.message = source["message"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace outbox {
+	
+	export class Message {
+	    id: number;
+	    accountId: string;
+	    fromAddress: string;
+	    recipients: string[];
+	    subject: string;
+	    editable: boolean;
+	    dsn?: smtp.DSNOptions;
+	    // Go type: time
+	    sendAt: any;
+	    scheduled: boolean;
+	    status: string;
+	    attempts: number;
+	    lastError?: string;
+	    // Go type: time
+	    createdAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Message(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.accountId = source["accountId"];
+	        this.fromAddress = source["fromAddress"];
+	        this.recipients = source["recipients"];
+	        this.subject = source["subject"];
+	        this.editable = source["editable"];
+	        this.dsn = this.convertValues(source["dsn"], smtp.DSNOptions);
+	        this.sendAt = this.convertValues(source["sendAt"], null);
+	        this.scheduled = source["scheduled"];
+	        this.status = source["status"];
+	        this.attempts = source["attempts"];
+	        this.lastError = source["lastError"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace pendingop {
+	
+	export class Operation {
+	    id: number;
+	    accountId: string;
+	    type: string;
+	    folderId?: string;
+	    folderPath: string;
+	    uidValidity: number;
+	    uids?: number[];
+	    flags?: string[];
+	    remoteIds?: string[];
+	    destFolderId?: string;
+	    destFolderPath?: string;
+	    status: string;
+	    attempts: number;
+	    lastError?: string;
+	    // Go type: time
+	    createdAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Operation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.accountId = source["accountId"];
+	        this.type = source["type"];
+	        this.folderId = source["folderId"];
+	        this.folderPath = source["folderPath"];
+	        this.uidValidity = source["uidValidity"];
+	        this.uids = source["uids"];
+	        this.flags = source["flags"];
+	        this.remoteIds = source["remoteIds"];
+	        this.destFolderId = source["destFolderId"];
+	        this.destFolderPath = source["destFolderPath"];
+	        this.status = source["status"];
+	        this.attempts = source["attempts"];
+	        this.lastError = source["lastError"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace pgp {
 	
 	export class Key {
 	    id: string;
@@ -1579,6 +2173,135 @@ export namespace pgp {
 
 }
 
+export namespace rules {
+	
+	export class Action {
+	    type: string;
+	    value?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Action(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.value = source["value"];
+	    }
+	}
+	export class Condition {
+	    field: string;
+	    header?: string;
+	    operator: string;
+	    value: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Condition(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.field = source["field"];
+	        this.header = source["header"];
+	        this.operator = source["operator"];
+	        this.value = source["value"];
+	    }
+	}
+	export class Match {
+	    messageId: string;
+	    subject: string;
+	    fromEmail: string;
+	    ruleIds: string[];
+	    ruleNames: string[];
+	    actions: Action[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Match(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.messageId = source["messageId"];
+	        this.subject = source["subject"];
+	        this.fromEmail = source["fromEmail"];
+	        this.ruleIds = source["ruleIds"];
+	        this.ruleNames = source["ruleNames"];
+	        this.actions = this.convertValues(source["actions"], Action);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Rule {
+	    id: string;
+	    accountId: string;
+	    name: string;
+	    enabled: boolean;
+	    position: number;
+	    match: string;
+	    conditions: Condition[];
+	    actions: Action[];
+	    stopProcessing: boolean;
+	    // Go type: time
+	    createdAt: any;
+	    // Go type: time
+	    updatedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Rule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.accountId = source["accountId"];
+	        this.name = source["name"];
+	        this.enabled = source["enabled"];
+	        this.position = source["position"];
+	        this.match = source["match"];
+	        this.conditions = this.convertValues(source["conditions"], Condition);
+	        this.actions = this.convertValues(source["actions"], Action);
+	        this.stopProcessing = source["stopProcessing"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.updatedAt = this.convertValues(source["updatedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
 export namespace settings {
 	
 	export class AllowlistEntry {
@@ -1602,6 +2325,123 @@ export namespace settings {
 
 }
 
+export namespace sieve {
+	
+	export class Capabilities {
+	    implementation: string;
+	    sasl: string[];
+	    extensions: string[];
+	    startTls: boolean;
+	    version: string;
+	    maxRedirects?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Capabilities(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.implementation = source["implementation"];
+	        this.sasl = source["sasl"];
+	        this.extensions = source["extensions"];
+	        this.startTls = source["startTls"];
+	        this.version = source["version"];
+	        this.maxRedirects = source["maxRedirects"];
+	    }
+	}
+	export class Rule {
+	    name?: string;
+	    header: string;
+	    match: string;
+	    value: string;
+	    fileInto: string;
+	    stop: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Rule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.header = source["header"];
+	        this.match = source["match"];
+	        this.value = source["value"];
+	        this.fileInto = source["fileInto"];
+	        this.stop = source["stop"];
+	    }
+	}
+	export class ScriptInfo {
+	    name: string;
+	    active: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScriptInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.active = source["active"];
+	    }
+	}
+	export class Vacation {
+	    enabled: boolean;
+	    subject?: string;
+	    body: string;
+	    days?: number;
+	    addresses?: string[];
+	    from?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Vacation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.subject = source["subject"];
+	        this.body = source["body"];
+	        this.days = source["days"];
+	        this.addresses = source["addresses"];
+	        this.from = source["from"];
+	    }
+	}
+	export class Script {
+	    rules: Rule[];
+	    vacation?: Vacation;
+	
+	    static createFrom(source: any = {}) {
+	        return new Script(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.rules = this.convertValues(source["rules"], Rule);
+	        this.vacation = this.convertValues(source["vacation"], Vacation);
+	    }
+	
+	convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
 export namespace smime {
 	
 	export class Certificate {
@@ -1801,6 +2641,7 @@ export namespace smtp {
 	    encrypt_message: boolean;
 	    pgp_sign_message: boolean;
 	    pgp_encrypt_message: boolean;
+	    dsn?: DSNOptions;
 	
 	    static createFrom(source: any = {}) {
 	        return new ComposeMessage(source);
@@ -1824,6 +2665,7 @@ export namespace smtp {
 	        this.encrypt_message = source["encrypt_message"];
 	        this.pgp_sign_message = source["pgp_sign_message"];
 	        this.pgp_encrypt_message = source["pgp_encrypt_message"];
+	        this.dsn = this.convertValues(source["dsn"], DSNOptions);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -1845,6 +2687,22 @@ export namespace smtp {
 		}
 	}
 
+	export class DSNOptions {
+	    notify?: string[];
+	    return?: string;
+	    envelope_id?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DSNOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.notify = source["notify"];
+	        this.return = source["return"];
+	        this.envelope_id = source["envelope_id"];
+	    }
+	}
 }
 
 export namespace sync {
@@ -1941,3 +2799,62 @@ export namespace sync {
 
 }
 
+export namespace template {
+	
+	export class Template {
+	    id: string;
+	    accountId: string;
+	    name: string;
+	    subject: string;
+	    htmlBody: string;
+	    textBody: string;
+	    to: smtp.Address[];
+	    cc: smtp.Address[];
+	    bcc: smtp.Address[];
+	    attachments: smtp.Attachment[];
+	    // Go type: time
+	    createdAt: any;
+	    // Go type: time
+	    updatedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Template(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.accountId = source["accountId"];
+	        this.name = source["name"];
+	        this.subject = source["subject"];
+	        this.htmlBody = source["htmlBody"];
+	        this.textBody = source["textBody"];
+	        this.to = this.convertValues(source["to"], smtp.Address);
+	        this.cc = this.convertValues(source["cc"], smtp.Address);
+	        this.bcc = this.convertValues(source["bcc"], smtp.Address);
+	        this.attachments = this.convertValues(source["attachments"], smtp.Attachment);
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.updatedAt = this.convertValues(source["updatedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
@@ -10,6 +10,11 @@ var (
 	ErrIMAPHostRequired    = errors.New("IMAP host is required")
 	ErrSMTPHostRequired    = errors.New("SMTP host is required")
 	ErrUsernameRequired    = errors.New("username is required")
+	ErrPOP3HostRequired    = errors.New("POP3 host is required")
+	ErrInvalidProtocol     = errors.New("protocol must be imap, jmap, pop3 or local")
+	ErrMaildirPathRelative = errors.New("path of the Maildir tree must be absolute")
+
+	ErrJMAPSessionURLRequired = errors.New("JMAP session URL is required")
 
 	// Storage errors
 	ErrAccountNotFound = errors.New("account not found")
@@ -2,6 +2,8 @@
 package account
 
 import (
+	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -14,6 +16,16 @@ const (
 	SecurityStartTLS SecurityType = "starttls"
 )
 
+// Protocol represents the protocol an account syncs and sends mail over
+type Protocol string
+
+const (
+	ProtocolIMAP  Protocol = "imap"  // IMAP for mail, SMTP for sending
+	ProtocolJMAP  Protocol = "jmap"  // JMAP (RFC 8620/8621) for both
+	ProtocolPOP3  Protocol = "pop3"  // POP3 into local folders, SMTP for sending
+	ProtocolLocal Protocol = "local" // Local folders only, optionally kept in a Maildir tree
+)
+
 // AuthType represents the authentication method
 type AuthType string
 
@@ -28,10 +40,28 @@ type Account struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
 
+	// Protocol selects the mail backend (IMAP/SMTP or JMAP)
+	Protocol Protocol `json:"protocol"`
+
+	// JMAP settings
+	JMAPSessionURL string `json:"jmapSessionUrl,omitempty"` // Session resource, e.g. https://host/.well-known/jmap
+
 	// IMAP settings
 	IMAPHost     string       `json:"imapHost"`
 	IMAPPort     int          `json:"imapPort"`
 	IMAPSecurity SecurityType `json:"imapSecurity"`
+	IMAPCompress bool         `json:"imapCompress"` // Negotiate COMPRESS=DEFLATE when supported
+
+	// POP3 settings
+	POP3Host            string       `json:"pop3Host,omitempty"`
+	POP3Port            int          `json:"pop3Port,omitempty"`
+	POP3Security        SecurityType `json:"pop3Security,omitempty"`
+	POP3APOP            bool         `json:"pop3Apop"`            // Log in with APOP instead of USER/PASS
+	POP3LeaveOnServer   bool         `json:"pop3LeaveOnServer"`   // Keep downloaded messages in the maildrop
+	POP3DeleteAfterDays int          `json:"pop3DeleteAfterDays"` // Remove left messages after this many days (0 = never)
+
+	// Local folders settings
+	MaildirPath string `json:"maildirPath,omitempty"` // Root of the Maildir tree (empty = database only)
 
 	// SMTP settings
 	SMTPHost     string       `json:"smtpHost"`
@@ -39,8 +69,10 @@ type Account struct {
 	SMTPSecurity SecurityType `json:"smtpSecurity"`
 
 	// Authentication
-	AuthType AuthType `json:"authType"`
-	Username string   `json:"username"`
+	AuthType      AuthType `json:"authType"`
+	Username      string   `json:"username"`
+	AuthMechanism string   `json:"authMechanism"` // Pinned SASL mechanism for IMAP/SMTP (empty = strongest offered)
+	ClientCertID  string   `json:"clientCertId"`  // TLS client certificate presented to the servers (empty = none)
 
 	// State
 	Enabled    bool   `json:"enabled"`
@@ -70,6 +102,28 @@ type Account struct {
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+// IsJMAP returns true if the account syncs over JMAP instead of IMAP
+func (a *Account) IsJMAP() bool {
+	return a.Protocol == ProtocolJMAP
+}
+
+// IsPOP3 returns true if the account downloads mail over POP3
+func (a *Account) IsPOP3() bool {
+	return a.Protocol == ProtocolPOP3
+}
+
+// IsLocal returns true if the account is a local folders account, which
+// has no server at all
+func (a *Account) IsLocal() bool {
+	return a.Protocol == ProtocolLocal
+}
+
+// HasLocalFolders returns true if the account's folders exist only in the
+// local database, so moves, flags and deletes never reach a server
+func (a *Account) HasLocalFolders() bool {
+	return a.Protocol == ProtocolPOP3 || a.Protocol == ProtocolLocal
+}
+
 // GetFolderMapping returns the mapped folder path for a folder type, or empty string if not mapped
 func (a *Account) GetFolderMapping(folderType string) string {
 	switch folderType {
@@ -152,17 +206,32 @@ type AccountConfig struct {
 	DisplayName string `json:"displayName"` // Name shown to email recipients
 	Email       string `json:"email"`
 
+	Protocol       Protocol `json:"protocol"`       // Defaults to IMAP
+	JMAPSessionURL string   `json:"jmapSessionUrl"` // Defaults to https://<email domain>/.well-known/jmap
+
 	IMAPHost     string       `json:"imapHost"`
 	IMAPPort     int          `json:"imapPort"`
 	IMAPSecurity SecurityType `json:"imapSecurity"`
+	IMAPCompress bool         `json:"imapCompress"` // Negotiate COMPRESS=DEFLATE when supported
+
+	POP3Host            string       `json:"pop3Host"`
+	POP3Port            int          `json:"pop3Port"`
+	POP3Security        SecurityType `json:"pop3Security"`
+	POP3APOP            bool         `json:"pop3Apop"`
+	POP3LeaveOnServer   bool         `json:"pop3LeaveOnServer"`
+	POP3DeleteAfterDays int          `json:"pop3DeleteAfterDays"`
+
+	MaildirPath string `json:"maildirPath"` // Local folders only; empty keeps mail in the database
 
 	SMTPHost     string       `json:"smtpHost"`
 	SMTPPort     int          `json:"smtpPort"`
 	SMTPSecurity SecurityType `json:"smtpSecurity"`
 
-	AuthType AuthType `json:"authType"`
-	Username string   `json:"username"`
-	Password string   `json:"password"` // Not stored in DB, goes to keyring
+	AuthType      AuthType `json:"authType"`
+	Username      string   `json:"username"`
+	Password      string   `json:"password"`      // Not stored in DB, goes to keyring
+	AuthMechanism string   `json:"authMechanism"` // Pinned SASL mechanism (empty = strongest offered)
+	ClientCertID  string   `json:"clientCertId"`  // TLS client certificate (empty = none)
 
 	Color string `json:"color"` // Hex color for account identification
 
@@ -193,11 +262,46 @@ func (c *AccountConfig) Validate() error {
 	if c.Email == "" {
 		return ErrEmailRequired
 	}
-	if c.IMAPHost == "" {
-		return ErrIMAPHostRequired
+	if c.Protocol == "" {
+		c.Protocol = ProtocolIMAP
 	}
-	if c.SMTPHost == "" {
-		return ErrSMTPHostRequired
+	switch c.Protocol {
+	case ProtocolIMAP:
+		if c.IMAPHost == "" {
+			return ErrIMAPHostRequired
+		}
+		if c.SMTPHost == "" {
+			return ErrSMTPHostRequired
+		}
+	case ProtocolJMAP:
+		// JMAP sends through the same server, so no SMTP settings
+		if c.JMAPSessionURL == "" {
+			at := strings.LastIndex(c.Email, "@")
+			if at < 0 || at == len(c.Email)-1 {
+				return ErrJMAPSessionURLRequired
+			}
+			c.JMAPSessionURL = "https://" + c.Email[at+1:] + "/.well-known/jmap"
+		}
+	case ProtocolPOP3:
+		if c.POP3Host == "" {
+			return ErrPOP3HostRequired
+		}
+		if c.SMTPHost == "" {
+			return ErrSMTPHostRequired
+		}
+	case ProtocolLocal:
+		// No server to log in to; SMTP is optional
+		if c.MaildirPath != "" {
+			if !filepath.IsAbs(c.MaildirPath) {
+				return ErrMaildirPathRelative
+			}
+			c.MaildirPath = filepath.Clean(c.MaildirPath)
+		}
+		if c.Username == "" {
+			c.Username = c.Email
+		}
+	default:
+		return ErrInvalidProtocol
 	}
 	if c.Username == "" {
 		return ErrUsernameRequired
@@ -214,6 +318,18 @@ func (c *AccountConfig) Validate() error {
 	if c.SMTPSecurity == "" {
 		c.SMTPSecurity = SecurityStartTLS
 	}
+	if c.POP3Security == "" {
+		c.POP3Security = SecurityTLS
+	}
+	if c.POP3Port <= 0 {
+		c.POP3Port = 110
+		if c.POP3Security == SecurityTLS {
+			c.POP3Port = 995
+		}
+	}
+	if c.POP3DeleteAfterDays < 0 {
+		c.POP3DeleteAfterDays = 0
+	}
 	if c.AuthType == "" {
 		c.AuthType = AuthPassword
 	}
@@ -77,14 +77,26 @@ func (s *Store) Create(config *AccountConfig) (*Account, error) {
 		ID:                       uuid.New().String(),
 		Name:                     config.Name,
 		Email:                    config.Email,
+		Protocol:                 config.Protocol,
+		JMAPSessionURL:           config.JMAPSessionURL,
 		IMAPHost:                 config.IMAPHost,
 		IMAPPort:                 config.IMAPPort,
 		IMAPSecurity:             config.IMAPSecurity,
+		IMAPCompress:             config.IMAPCompress,
+		POP3Host:                 config.POP3Host,
+		POP3Port:                 config.POP3Port,
+		POP3Security:             config.POP3Security,
+		POP3APOP:                 config.POP3APOP,
+		POP3LeaveOnServer:        config.POP3LeaveOnServer,
+		POP3DeleteAfterDays:      config.POP3DeleteAfterDays,
+		MaildirPath:              config.MaildirPath,
 		SMTPHost:                 config.SMTPHost,
 		SMTPPort:                 config.SMTPPort,
 		SMTPSecurity:             config.SMTPSecurity,
 		AuthType:                 config.AuthType,
 		Username:                 config.Username,
+		AuthMechanism:            config.AuthMechanism,
+		ClientCertID:             config.ClientCertID,
 		Enabled:                  true,
 		OrderIndex:               maxOrder + 1,
 		Color:                    color,
@@ -104,22 +116,26 @@ func (s *Store) Create(config *AccountConfig) (*Account, error) {
 
 	_, err = s.db.Exec(`
 		INSERT INTO accounts (
-			id, name, email,
-			imap_host, imap_port, imap_security,
+			id, name, email, protocol, jmap_session_url,
+			imap_host, imap_port, imap_security, imap_compress,
+			pop3_host, pop3_port, pop3_security, pop3_apop, pop3_leave_on_server, pop3_delete_after_days,
+			maildir_path,
 			smtp_host, smtp_port, smtp_security,
-			auth_type, username,
+			auth_type, username, auth_mechanism, client_cert_id,
 			enabled, order_index, color, sync_period_days, sync_interval,
 			read_receipt_request_policy,
 			sent_folder_path, drafts_folder_path, trash_folder_path,
 			spam_folder_path, archive_folder_path, all_mail_folder_path,
 			starred_folder_path,
 			created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
-		account.ID, account.Name, account.Email,
-		account.IMAPHost, account.IMAPPort, account.IMAPSecurity,
+		account.ID, account.Name, account.Email, account.Protocol, nullableString(account.JMAPSessionURL),
+		account.IMAPHost, account.IMAPPort, account.IMAPSecurity, account.IMAPCompress,
+		nullableString(account.POP3Host), account.POP3Port, account.POP3Security, account.POP3APOP, account.POP3LeaveOnServer, account.POP3DeleteAfterDays,
+		nullableString(account.MaildirPath),
 		account.SMTPHost, account.SMTPPort, account.SMTPSecurity,
-		account.AuthType, account.Username,
+		account.AuthType, account.Username, account.AuthMechanism, account.ClientCertID,
 		account.Enabled, account.OrderIndex, account.Color, account.SyncPeriodDays, account.SyncInterval,
 		account.ReadReceiptRequestPolicy,
 		nullableString(account.SentFolderPath), nullableString(account.DraftsFolderPath), nullableString(account.TrashFolderPath),
@@ -156,12 +172,14 @@ func (s *Store) Create(config *AccountConfig) (*Account, error) {
 // Get retrieves an account by ID
 func (s *Store) Get(id string) (*Account, error) {
 	account := &Account{}
-	var sentPath, draftsPath, trashPath, spamPath, archivePath, allMailPath, starredPath sql.NullString
+	var sessionURL, pop3Host, maildirPath, sentPath, draftsPath, trashPath, spamPath, archivePath, allMailPath, starredPath sql.NullString
 	err := s.db.QueryRow(`
-		SELECT id, name, email,
-			imap_host, imap_port, imap_security,
+		SELECT id, name, email, protocol, jmap_session_url,
+			imap_host, imap_port, imap_security, imap_compress,
+			pop3_host, pop3_port, pop3_security, pop3_apop, pop3_leave_on_server, pop3_delete_after_days,
+			maildir_path,
 			smtp_host, smtp_port, smtp_security,
-			auth_type, username,
+			auth_type, username, auth_mechanism, client_cert_id,
 			enabled, order_index, color, sync_period_days, sync_interval,
 			read_receipt_request_policy,
 			sent_folder_path, drafts_folder_path, trash_folder_path,
@@ -170,10 +188,12 @@ func (s *Store) Get(id string) (*Account, error) {
 			created_at, updated_at
 		FROM accounts WHERE id = ?
 	`, id).Scan(
-		&account.ID, &account.Name, &account.Email,
-		&account.IMAPHost, &account.IMAPPort, &account.IMAPSecurity,
+		&account.ID, &account.Name, &account.Email, &account.Protocol, &sessionURL,
+		&account.IMAPHost, &account.IMAPPort, &account.IMAPSecurity, &account.IMAPCompress,
+		&pop3Host, &account.POP3Port, &account.POP3Security, &account.POP3APOP, &account.POP3LeaveOnServer, &account.POP3DeleteAfterDays,
+		&maildirPath,
 		&account.SMTPHost, &account.SMTPPort, &account.SMTPSecurity,
-		&account.AuthType, &account.Username,
+		&account.AuthType, &account.Username, &account.AuthMechanism, &account.ClientCertID,
 		&account.Enabled, &account.OrderIndex, &account.Color, &account.SyncPeriodDays, &account.SyncInterval,
 		&account.ReadReceiptRequestPolicy,
 		&sentPath, &draftsPath, &trashPath,
@@ -188,6 +208,9 @@ func (s *Store) Get(id string) (*Account, error) {
 		return nil, fmt.Errorf("failed to get account: %w", err)
 	}
 	// Map nullable strings to account fields
+	account.JMAPSessionURL = sessionURL.String
+	account.POP3Host = pop3Host.String
+	account.MaildirPath = maildirPath.String
 	account.SentFolderPath = sentPath.String
 	account.DraftsFolderPath = draftsPath.String
 	account.TrashFolderPath = trashPath.String
@@ -201,10 +224,12 @@ func (s *Store) Get(id string) (*Account, error) {
 // List retrieves all accounts ordered by order_index
 func (s *Store) List() ([]*Account, error) {
 	rows, err := s.db.Query(`
-		SELECT id, name, email,
-			imap_host, imap_port, imap_security,
+		SELECT id, name, email, protocol, jmap_session_url,
+			imap_host, imap_port, imap_security, imap_compress,
+			pop3_host, pop3_port, pop3_security, pop3_apop, pop3_leave_on_server, pop3_delete_after_days,
+			maildir_path,
 			smtp_host, smtp_port, smtp_security,
-			auth_type, username,
+			auth_type, username, auth_mechanism, client_cert_id,
 			enabled, order_index, color, sync_period_days, sync_interval,
 			read_receipt_request_policy,
 			sent_folder_path, drafts_folder_path, trash_folder_path,
@@ -221,12 +246,14 @@ func (s *Store) List() ([]*Account, error) {
 	var accounts []*Account
 	for rows.Next() {
 		account := &Account{}
-		var sentPath, draftsPath, trashPath, spamPath, archivePath, allMailPath, starredPath sql.NullString
+		var sessionURL, pop3Host, maildirPath, sentPath, draftsPath, trashPath, spamPath, archivePath, allMailPath, starredPath sql.NullString
 		err := rows.Scan(
-			&account.ID, &account.Name, &account.Email,
-			&account.IMAPHost, &account.IMAPPort, &account.IMAPSecurity,
+			&account.ID, &account.Name, &account.Email, &account.Protocol, &sessionURL,
+			&account.IMAPHost, &account.IMAPPort, &account.IMAPSecurity, &account.IMAPCompress,
+			&pop3Host, &account.POP3Port, &account.POP3Security, &account.POP3APOP, &account.POP3LeaveOnServer, &account.POP3DeleteAfterDays,
+			&maildirPath,
 			&account.SMTPHost, &account.SMTPPort, &account.SMTPSecurity,
-			&account.AuthType, &account.Username,
+			&account.AuthType, &account.Username, &account.AuthMechanism, &account.ClientCertID,
 			&account.Enabled, &account.OrderIndex, &account.Color, &account.SyncPeriodDays, &account.SyncInterval,
 			&account.ReadReceiptRequestPolicy,
 			&sentPath, &draftsPath, &trashPath,
@@ -238,6 +265,9 @@ func (s *Store) List() ([]*Account, error) {
 			return nil, fmt.Errorf("failed to scan account: %w", err)
 		}
 		// Map nullable strings to account fields
+		account.JMAPSessionURL = sessionURL.String
+		account.POP3Host = pop3Host.String
+		account.MaildirPath = maildirPath.String
 		account.SentFolderPath = sentPath.String
 		account.DraftsFolderPath = draftsPath.String
 		account.TrashFolderPath = trashPath.String
@@ -266,10 +296,12 @@ func (s *Store) Update(id string, config *AccountConfig) (*Account, error) {
 	now := time.Now()
 	_, err = s.db.Exec(`
 		UPDATE accounts SET
-			name = ?, email = ?,
-			imap_host = ?, imap_port = ?, imap_security = ?,
+			name = ?, email = ?, protocol = ?, jmap_session_url = ?,
+			imap_host = ?, imap_port = ?, imap_security = ?, imap_compress = ?,
+			pop3_host = ?, pop3_port = ?, pop3_security = ?, pop3_apop = ?, pop3_leave_on_server = ?, pop3_delete_after_days = ?,
+			maildir_path = ?,
 			smtp_host = ?, smtp_port = ?, smtp_security = ?,
-			auth_type = ?, username = ?,
+			auth_type = ?, username = ?, auth_mechanism = ?, client_cert_id = ?,
 			color = ?, sync_period_days = ?, sync_interval = ?,
 			read_receipt_request_policy = ?,
 			sent_folder_path = ?, drafts_folder_path = ?, trash_folder_path = ?,
@@ -278,10 +310,12 @@ func (s *Store) Update(id string, config *AccountConfig) (*Account, error) {
 			updated_at = ?
 		WHERE id = ?
 	`,
-		config.Name, config.Email,
-		config.IMAPHost, config.IMAPPort, config.IMAPSecurity,
+		config.Name, config.Email, config.Protocol, nullableString(config.JMAPSessionURL),
+		config.IMAPHost, config.IMAPPort, config.IMAPSecurity, config.IMAPCompress,
+		nullableString(config.POP3Host), config.POP3Port, config.POP3Security, config.POP3APOP, config.POP3LeaveOnServer, config.POP3DeleteAfterDays,
+		nullableString(config.MaildirPath),
 		config.SMTPHost, config.SMTPPort, config.SMTPSecurity,
-		config.AuthType, config.Username,
+		config.AuthType, config.Username, config.AuthMechanism, config.ClientCertID,
 		config.Color, config.SyncPeriodDays, config.SyncInterval,
 		config.ReadReceiptRequestPolicy,
 		nullableString(config.SentFolderPath), nullableString(config.DraftsFolderPath), nullableString(config.TrashFolderPath),
@@ -303,14 +337,26 @@ func (s *Store) Update(id string, config *AccountConfig) (*Account, error) {
 
 	existing.Name = config.Name
 	existing.Email = config.Email
+	existing.Protocol = config.Protocol
+	existing.JMAPSessionURL = config.JMAPSessionURL
 	existing.IMAPHost = config.IMAPHost
 	existing.IMAPPort = config.IMAPPort
 	existing.IMAPSecurity = config.IMAPSecurity
+	existing.IMAPCompress = config.IMAPCompress
+	existing.POP3Host = config.POP3Host
+	existing.POP3Port = config.POP3Port
+	existing.POP3Security = config.POP3Security
+	existing.POP3APOP = config.POP3APOP
+	existing.POP3LeaveOnServer = config.POP3LeaveOnServer
+	existing.POP3DeleteAfterDays = config.POP3DeleteAfterDays
+	existing.MaildirPath = config.MaildirPath
 	existing.SMTPHost = config.SMTPHost
 	existing.SMTPPort = config.SMTPPort
 	existing.SMTPSecurity = config.SMTPSecurity
 	existing.AuthType = config.AuthType
 	existing.Username = config.Username
+	existing.AuthMechanism = config.AuthMechanism
+	existing.ClientCertID = config.ClientCertID
 	existing.Color = config.Color
 	existing.SyncPeriodDays = config.SyncPeriodDays
 	existing.SyncInterval = config.SyncInterval
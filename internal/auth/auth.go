@@ -0,0 +1,219 @@
+// Package auth picks and runs the SASL mechanism used to log in to IMAP,
+// SMTP and other mail servers. It adds SCRAM (RFC 5802/7677) with channel
+// binding and XOAUTH2 to the mechanisms go-sasl provides.
+package auth
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/emersion/go-sasl"
+)
+
+// Mechanism is a SASL mechanism name. The empty mechanism means automatic
+// selection.
+type Mechanism string
+
+const (
+	MechanismAuto            Mechanism = ""
+	MechanismPlain           Mechanism = "PLAIN"
+	MechanismLogin           Mechanism = "LOGIN"
+	MechanismScramSHA1       Mechanism = "SCRAM-SHA-1"
+	MechanismScramSHA1Plus   Mechanism = "SCRAM-SHA-1-PLUS"
+	MechanismScramSHA256     Mechanism = "SCRAM-SHA-256"
+	MechanismScramSHA256Plus Mechanism = "SCRAM-SHA-256-PLUS"
+	MechanismXOAuth2         Mechanism = "XOAUTH2"
+	MechanismOAuthBearer     Mechanism = "OAUTHBEARER"
+	MechanismExternal        Mechanism = "EXTERNAL"
+)
+
+var (
+	// ErrNotOffered is returned when a pinned mechanism isn't advertised
+	ErrNotOffered = errors.New("server does not offer the selected authentication mechanism")
+	// ErrUnusable is returned when a pinned mechanism needs credentials or
+	// a connection the account doesn't have
+	ErrUnusable = errors.New("selected authentication mechanism cannot be used with this account")
+)
+
+// Mechanisms in order of preference for each kind of credential. Channel
+// binding protects against a man in the middle, which matters more than
+// the hash, so both -PLUS variants come first.
+var (
+	passwordMechanisms = []Mechanism{
+		MechanismScramSHA256Plus,
+		MechanismScramSHA1Plus,
+		MechanismScramSHA256,
+		MechanismScramSHA1,
+	}
+	tokenMechanisms = []Mechanism{
+		MechanismOAuthBearer,
+		MechanismXOAuth2,
+	}
+)
+
+// Credentials are what an account can log in with
+type Credentials struct {
+	Username    string
+	Password    string
+	AccessToken string // OAuth2 access token
+	ClientCert  bool   // A TLS client certificate was presented, for EXTERNAL
+
+	// Server address, sent with OAUTHBEARER
+	Host string
+	Port int
+}
+
+// Mechanisms returns the mechanisms an account can pin, for the UI
+func Mechanisms() []Mechanism {
+	return []Mechanism{
+		MechanismScramSHA256Plus,
+		MechanismScramSHA256,
+		MechanismScramSHA1Plus,
+		MechanismScramSHA1,
+		MechanismPlain,
+		MechanismLogin,
+		MechanismOAuthBearer,
+		MechanismXOAuth2,
+		MechanismExternal,
+	}
+}
+
+// Choose returns the mechanism to log in with. A pinned mechanism is used
+// if the server offers it and the credentials fit it. Otherwise the
+// strongest offered mechanism the credentials fit is chosen: SCRAM for
+// passwords, OAUTHBEARER for tokens. EXTERNAL is only chosen for accounts
+// with neither, since a server that advertises it may still not map the
+// certificate to the account, and a password or token is known to work.
+// Auto returns MechanismAuto when nothing better than the server's
+// plain login is offered, leaving the caller to log in as it always has.
+func Choose(pinned Mechanism, advertised []string, creds Credentials, tlsState *tls.ConnectionState) (Mechanism, error) {
+	if pinned != MechanismAuto {
+		if !usable(pinned, creds, tlsState) {
+			return "", fmt.Errorf("%w: %s", ErrUnusable, pinned)
+		}
+		// Servers needn't advertise PLAIN to accept it, and XOAUTH2
+		// servers often don't advertise it at all
+		if !offered(advertised, pinned) && pinned != MechanismPlain && pinned != MechanismXOAuth2 {
+			return "", fmt.Errorf("%w: %s", ErrNotOffered, pinned)
+		}
+		return pinned, nil
+	}
+
+	if creds.Password == "" && creds.AccessToken == "" && creds.ClientCert && offered(advertised, MechanismExternal) {
+		return MechanismExternal, nil
+	}
+
+	candidates := passwordMechanisms
+	if creds.AccessToken != "" {
+		candidates = tokenMechanisms
+	}
+	for _, m := range candidates {
+		if offered(advertised, m) && usable(m, creds, tlsState) {
+			return m, nil
+		}
+	}
+	return MechanismAuto, nil
+}
+
+// usable reports whether the credentials and connection fit a mechanism
+func usable(m Mechanism, creds Credentials, tlsState *tls.ConnectionState) bool {
+	switch m {
+	case MechanismScramSHA1Plus, MechanismScramSHA256Plus:
+		_, _, ok := ChannelBinding(tlsState)
+		return ok && creds.Password != ""
+	case MechanismScramSHA1, MechanismScramSHA256, MechanismPlain, MechanismLogin:
+		return creds.Password != ""
+	case MechanismOAuthBearer, MechanismXOAuth2:
+		return creds.AccessToken != ""
+	case MechanismExternal:
+		return creds.ClientCert
+	default:
+		return false
+	}
+}
+
+// offered reports whether a mechanism is in the advertised list
+func offered(advertised []string, m Mechanism) bool {
+	return slices.ContainsFunc(advertised, func(a string) bool {
+		return strings.EqualFold(a, string(m))
+	})
+}
+
+// NewClient returns a SASL client for a mechanism
+func NewClient(m Mechanism, advertised []string, creds Credentials, tlsState *tls.ConnectionState) (sasl.Client, error) {
+	switch m {
+	case MechanismPlain:
+		return sasl.NewPlainClient("", creds.Username, creds.Password), nil
+	case MechanismLogin:
+		return sasl.NewLoginClient(creds.Username, creds.Password), nil
+	case MechanismScramSHA1, MechanismScramSHA256, MechanismScramSHA1Plus, MechanismScramSHA256Plus:
+		return newScramClient(m, creds.Username, creds.Password, advertised, tlsState)
+	case MechanismXOAuth2:
+		return NewXOAuth2Client(creds.Username, creds.AccessToken), nil
+	case MechanismOAuthBearer:
+		return sasl.NewOAuthBearerClient(&sasl.OAuthBearerOptions{
+			Username: creds.Username,
+			Token:    creds.AccessToken,
+			Host:     creds.Host,
+			Port:     creds.Port,
+		}), nil
+	case MechanismExternal:
+		// The identity comes from the certificate
+		return sasl.NewExternalClient(""), nil
+	default:
+		return nil, fmt.Errorf("unsupported authentication mechanism: %s", m)
+	}
+}
+
+// ChannelBinding returns the channel binding of a TLS connection: RFC 9266
+// tls-exporter on TLS 1.3, RFC 5929 tls-unique before it
+func ChannelBinding(tlsState *tls.ConnectionState) (cbType string, data []byte, ok bool) {
+	if tlsState == nil || !tlsState.HandshakeComplete {
+		return "", nil, false
+	}
+	if tlsState.Version >= tls.VersionTLS13 {
+		ekm, err := tlsState.ExportKeyingMaterial("EXPORTER-Channel-Binding", nil, 32)
+		if err != nil {
+			return "", nil, false
+		}
+		return "tls-exporter", ekm, true
+	}
+	if len(tlsState.TLSUnique) > 0 {
+		return "tls-unique", tlsState.TLSUnique, true
+	}
+	return "", nil, false
+}
+
+// HasClientCert reports whether a TLS config presents a client
+// certificate, which SASL EXTERNAL authenticates with
+func HasClientCert(cfg *tls.Config) bool {
+	return cfg != nil && (len(cfg.Certificates) > 0 || cfg.GetClientCertificate != nil)
+}
+
+// xoauth2Client implements the XOAUTH2 mechanism
+// See: https://developers.google.com/gmail/imap/xoauth2-protocol
+type xoauth2Client struct {
+	username string
+	token    string
+}
+
+// NewXOAuth2Client creates a SASL client for XOAUTH2
+func NewXOAuth2Client(username, token string) sasl.Client {
+	return &xoauth2Client{username: username, token: token}
+}
+
+// Start sends everything in the initial response:
+// "user=" {user} "\x01" "auth=Bearer " {token} "\x01\x01"
+func (c *xoauth2Client) Start() (string, []byte, error) {
+	ir := "user=" + c.username + "\x01auth=Bearer " + c.token + "\x01\x01"
+	return string(MechanismXOAuth2), []byte(ir), nil
+}
+
+// Next answers the error challenge with an empty response so the server
+// sends its final error
+func (c *xoauth2Client) Next(challenge []byte) ([]byte, error) {
+	return []byte{}, nil
+}
@@ -0,0 +1,246 @@
+// Package autoconfig looks up the server settings for an email address the
+// way Thunderbird does: the domain's own autoconfig files, Microsoft
+// autodiscover, a bundled snapshot of Mozilla's ISPDB, RFC 6186/8314 SRV
+// records, and finally guesses based on the domain's MX records
+package autoconfig
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hkdb/aerion/internal/account"
+	"github.com/hkdb/aerion/internal/logging"
+)
+
+var (
+	// ErrInvalidEmail is returned for addresses without a domain
+	ErrInvalidEmail = errors.New("invalid email address")
+	// ErrNotFound is returned when no source knows the domain
+	ErrNotFound = errors.New("no settings found for this email address")
+)
+
+// Source is where a candidate configuration came from
+type Source string
+
+const (
+	SourceAutoconfig     Source = "autoconfig"      // https://autoconfig.<domain>/mail/config-v1.1.xml
+	SourceAutoconfigHTTP Source = "autoconfig-http" // The same file over plain HTTP
+	SourceWellKnown      Source = "well-known"      // <domain>/.well-known/autoconfig/mail/config-v1.1.xml
+	SourceAutodiscover   Source = "autodiscover"    // Microsoft autodiscover POX
+	SourceISPDB          Source = "ispdb"           // Bundled ISPDB snapshot
+	SourceSRV            Source = "srv"             // RFC 6186/8314 SRV records
+	SourceMX             Source = "mx"              // ISPDB entry of the provider hosting the domain's MX
+	SourceGuess          Source = "guess"           // Common host names that resolve
+)
+
+// baseScores ranks the sources. The domain's own files are most likely to
+// be right; guesses are a last resort. A file fetched over plain HTTP could
+// have been rewritten on the way, so it ranks below the ISPDB and SRV.
+var baseScores = map[Source]int{
+	SourceAutoconfig:     100,
+	SourceWellKnown:      100,
+	SourceAutodiscover:   90,
+	SourceISPDB:          80,
+	SourceSRV:            70,
+	SourceMX:             60,
+	SourceAutoconfigHTTP: 50,
+	SourceGuess:          20,
+}
+
+// Unverified reports whether settings from the source can't be trusted with
+// a password before the user confirms them: anyone on the network path can
+// rewrite a plain HTTP response, and guessed hosts are only names that
+// resolve.
+func (s Source) Unverified() bool {
+	return s == SourceAutoconfigHTTP || s == SourceGuess
+}
+
+// lookupTimeout bounds each network lookup
+const lookupTimeout = 10 * time.Second
+
+var (
+	httpClient = &http.Client{Timeout: lookupTimeout}
+	resolver   = net.DefaultResolver
+)
+
+// Candidate is a possible configuration for an address
+type Candidate struct {
+	Config account.AccountConfig `json:"config"`
+	Source Source                `json:"source"`
+	Score  int                   `json:"score"` // Higher is better
+
+	// Unverified is set for sources whose hosts the user must confirm
+	// before the password is sent to them
+	Unverified bool `json:"unverified,omitempty"`
+
+	// OAuthProvider is set when the server takes OAuth2 from a provider the
+	// app can sign in to, e.g. "google"
+	OAuthProvider string `json:"oauthProvider,omitempty"`
+}
+
+// address is an email address split for lookups and username templates
+type address struct {
+	email  string
+	local  string
+	domain string
+}
+
+// Discover looks up the settings for an email address and returns the
+// candidates, best first
+func Discover(ctx context.Context, email string) ([]Candidate, error) {
+	log := logging.WithComponent("autoconfig")
+
+	addr, err := parseAddress(email)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu         sync.Mutex
+		wg         sync.WaitGroup
+		candidates []Candidate
+	)
+	add := func(found []Candidate) {
+		mu.Lock()
+		candidates = append(candidates, found...)
+		mu.Unlock()
+	}
+
+	// Offline first
+	add(lookupISPDB(addr, addr.domain, SourceISPDB))
+
+	lookups := map[string]func(context.Context, address) ([]Candidate, error){
+		"autoconfig":   lookupAutoconfig,
+		"well-known":   lookupWellKnown,
+		"autodiscover": lookupAutodiscover,
+		"srv":          lookupSRV,
+		"mx":           lookupMX,
+	}
+	for name, lookup := range lookups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			found, err := lookup(ctx, addr)
+			if err != nil {
+				log.Debug().Err(err).Str("lookup", name).Str("domain", addr.domain).Msg("Lookup found nothing")
+				return
+			}
+			add(found)
+		}()
+	}
+	wg.Wait()
+
+	candidates = rank(candidates)
+	if len(candidates) == 0 {
+		return nil, ErrNotFound
+	}
+	for i := range candidates {
+		candidates[i].Unverified = candidates[i].Source.Unverified()
+	}
+
+	log.Info().
+		Str("domain", addr.domain).
+		Int("candidates", len(candidates)).
+		Str("best", string(candidates[0].Source)).
+		Msg("Discovered account settings")
+
+	return candidates, nil
+}
+
+// parseAddress splits an email address
+func parseAddress(email string) (address, error) {
+	email = strings.TrimSpace(email)
+	at := strings.LastIndex(email, "@")
+	if at <= 0 || at == len(email)-1 {
+		return address{}, ErrInvalidEmail
+	}
+	return address{
+		email:  email,
+		local:  email[:at],
+		domain: strings.ToLower(strings.TrimSuffix(email[at+1:], ".")),
+	}, nil
+}
+
+// newConfig returns a configuration with the fields every candidate shares
+func newConfig(addr address, name string) account.AccountConfig {
+	if name == "" {
+		name = addr.email
+	}
+	return account.AccountConfig{
+		Name:     name,
+		Email:    addr.email,
+		Protocol: account.ProtocolIMAP,
+		Username: addr.email,
+		AuthType: account.AuthPassword,
+	}
+}
+
+// score returns a candidate's score: its source's base score, less
+// penalties for weaker security and POP3
+func score(c *Candidate) int {
+	s := baseScores[c.Source]
+
+	cfg := &c.Config
+	var incoming account.SecurityType
+	switch cfg.Protocol {
+	case account.ProtocolIMAP:
+		incoming = cfg.IMAPSecurity
+	case account.ProtocolPOP3:
+		incoming = cfg.POP3Security
+		s -= 10
+	}
+	for _, sec := range []account.SecurityType{incoming, cfg.SMTPSecurity} {
+		switch sec {
+		case account.SecurityStartTLS:
+			s -= 2
+		case account.SecurityNone:
+			s -= 30
+		}
+	}
+	return s
+}
+
+// rank drops duplicate candidates, keeping the best scored, and sorts the
+// rest best first
+func rank(candidates []Candidate) []Candidate {
+	best := make(map[string]int)
+	var ranked []Candidate
+	for _, c := range candidates {
+		key := candidateKey(&c.Config)
+		if i, ok := best[key]; ok {
+			if c.Score > ranked[i].Score {
+				ranked[i] = c
+			}
+			continue
+		}
+		best[key] = len(ranked)
+		ranked = append(ranked, c)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+	return ranked
+}
+
+// candidateKey identifies the servers a configuration uses
+func candidateKey(cfg *account.AccountConfig) string {
+	parts := []string{string(cfg.Protocol), cfg.Username}
+	switch cfg.Protocol {
+	case account.ProtocolJMAP:
+		parts = append(parts, cfg.JMAPSessionURL)
+	case account.ProtocolPOP3:
+		parts = append(parts, strings.ToLower(cfg.POP3Host), strconv.Itoa(cfg.POP3Port), string(cfg.POP3Security))
+	default:
+		parts = append(parts, strings.ToLower(cfg.IMAPHost), strconv.Itoa(cfg.IMAPPort), string(cfg.IMAPSecurity))
+	}
+	parts = append(parts, strings.ToLower(cfg.SMTPHost), strconv.Itoa(cfg.SMTPPort), string(cfg.SMTPSecurity))
+	return strings.Join(parts, "|")
+}
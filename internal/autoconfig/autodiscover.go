@@ -0,0 +1,173 @@
+package autoconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/hkdb/aerion/internal/account"
+)
+
+// autodiscoverRequest asks for the settings of an address in the "Outlook"
+// schema, which lists IMAP, POP3 and SMTP servers
+const autodiscoverRequest = `<?xml version="1.0" encoding="utf-8"?>
+<Autodiscover xmlns="http://schemas.microsoft.com/exchange/autodiscover/outlook/requestschema/2006">
+  <Request>
+    <EMailAddress>%s</EMailAddress>
+    <AcceptableResponseSchema>http://schemas.microsoft.com/exchange/autodiscover/outlook/responseschema/2006a</AcceptableResponseSchema>
+  </Request>
+</Autodiscover>`
+
+// autodiscoverResponse is the part of an autodiscover response we use
+type autodiscoverResponse struct {
+	XMLName  xml.Name `xml:"Autodiscover"`
+	Response struct {
+		Account struct {
+			AccountType string                 `xml:"AccountType"`
+			Action      string                 `xml:"Action"`
+			Protocols   []autodiscoverProtocol `xml:"Protocol"`
+		} `xml:"Account"`
+	} `xml:"Response"`
+}
+
+// autodiscoverProtocol is one server in an autodiscover response
+type autodiscoverProtocol struct {
+	Type       string `xml:"Type"`
+	Server     string `xml:"Server"`
+	Port       int    `xml:"Port"`
+	LoginName  string `xml:"LoginName"`
+	SSL        string `xml:"SSL"`
+	Encryption string `xml:"Encryption"`
+}
+
+// lookupAutodiscover asks the domain's autodiscover service, first on the
+// domain itself and then on its autodiscover host
+func lookupAutodiscover(ctx context.Context, addr address) ([]Candidate, error) {
+	var lastErr error
+	for _, host := range []string{addr.domain, "autodiscover." + addr.domain} {
+		resp, err := fetchAutodiscover(ctx, "https://"+host+"/autodiscover/autodiscover.xml", addr)
+		if err == nil {
+			return candidatesFromAutodiscover(resp, addr), nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// fetchAutodiscover posts an autodiscover request and parses the response
+func fetchAutodiscover(ctx context.Context, rawURL string, addr address) (*autodiscoverResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, autodiscoverRequest, xmlEscape(addr.email))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024)) // 1MB limit
+	if err != nil {
+		return nil, err
+	}
+
+	var result autodiscoverResponse
+	if err := xml.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse autodiscover response: %w", err)
+	}
+	if result.Response.Account.Action != "settings" {
+		return nil, fmt.Errorf("autodiscover returned no settings")
+	}
+	return &result, nil
+}
+
+// candidatesFromAutodiscover returns a candidate for each IMAP or POP3
+// server, paired with the SMTP server
+func candidatesFromAutodiscover(resp *autodiscoverResponse, addr address) []Candidate {
+	var smtp *autodiscoverProtocol
+	for i := range resp.Response.Account.Protocols {
+		if strings.EqualFold(resp.Response.Account.Protocols[i].Type, "SMTP") {
+			smtp = &resp.Response.Account.Protocols[i]
+			break
+		}
+	}
+	if smtp == nil {
+		return nil
+	}
+
+	var candidates []Candidate
+	for _, p := range resp.Response.Account.Protocols {
+		c := Candidate{
+			Config: newConfig(addr, ""),
+			Source: SourceAutodiscover,
+		}
+		switch strings.ToUpper(p.Type) {
+		case "IMAP":
+			c.Config.IMAPHost = p.Server
+			c.Config.IMAPPort = p.Port
+			c.Config.IMAPSecurity = autodiscoverSecurity(p, 993)
+		case "POP3":
+			c.Config.Protocol = account.ProtocolPOP3
+			c.Config.POP3Host = p.Server
+			c.Config.POP3Port = p.Port
+			c.Config.POP3Security = autodiscoverSecurity(p, 995)
+		default:
+			continue
+		}
+		if p.LoginName != "" {
+			c.Config.Username = p.LoginName
+		}
+		c.Config.SMTPHost = smtp.Server
+		c.Config.SMTPPort = smtp.Port
+		c.Config.SMTPSecurity = autodiscoverSecurity(*smtp, 465)
+
+		c.Score = score(&c)
+		candidates = append(candidates, c)
+	}
+	return candidates
+}
+
+// autodiscoverSecurity maps a protocol's SSL and Encryption settings to a
+// security type. In autodiscover "TLS" means STARTTLS and "SSL" means TLS
+// from the start; with no Encryption, SSL on the implicit TLS port means
+// TLS.
+func autodiscoverSecurity(p autodiscoverProtocol, tlsPort int) account.SecurityType {
+	switch strings.ToUpper(p.Encryption) {
+	case "SSL":
+		return account.SecurityTLS
+	case "TLS":
+		return account.SecurityStartTLS
+	case "NONE":
+		return account.SecurityNone
+	}
+	if strings.EqualFold(p.SSL, "off") {
+		return account.SecurityNone
+	}
+	if p.Port == tlsPort {
+		return account.SecurityTLS
+	}
+	return account.SecurityStartTLS
+}
+
+// xmlEscape escapes text for an XML element
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
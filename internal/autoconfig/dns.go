@@ -0,0 +1,218 @@
+package autoconfig
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkdb/aerion/internal/account"
+	"golang.org/x/net/publicsuffix"
+)
+
+// srvService is an RFC 6186/8314 mail service and the security it implies
+type srvService struct {
+	name     string
+	security account.SecurityType
+}
+
+// Services in order of preference; implicit TLS is preferred (RFC 8314)
+var (
+	imapServices = []srvService{{"imaps", account.SecurityTLS}, {"imap", account.SecurityStartTLS}}
+	pop3Services = []srvService{{"pop3s", account.SecurityTLS}, {"pop3", account.SecurityStartTLS}}
+	smtpServices = []srvService{{"submissions", account.SecurityTLS}, {"submission", account.SecurityStartTLS}}
+)
+
+// srvTarget is a host and port found in an SRV record
+type srvTarget struct {
+	host     string
+	port     int
+	security account.SecurityType
+}
+
+// lookupSRV builds candidates from the domain's mail SRV records, and a
+// JMAP candidate from its _jmap._tcp record (RFC 8620)
+func lookupSRV(ctx context.Context, addr address) ([]Candidate, error) {
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+
+	var candidates []Candidate
+
+	if jmap, ok := findSRV(ctx, addr.domain, []srvService{{"jmap", account.SecurityTLS}}); ok {
+		c := Candidate{
+			Config: newConfig(addr, ""),
+			Source: SourceSRV,
+		}
+		c.Config.Protocol = account.ProtocolJMAP
+		host := jmap.host
+		if jmap.port != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(jmap.port))
+		}
+		c.Config.JMAPSessionURL = "https://" + host + "/.well-known/jmap"
+		c.Score = score(&c)
+		candidates = append(candidates, c)
+	}
+
+	smtp, ok := findSRV(ctx, addr.domain, smtpServices)
+	if ok {
+		if imap, ok := findSRV(ctx, addr.domain, imapServices); ok {
+			c := Candidate{
+				Config: newConfig(addr, ""),
+				Source: SourceSRV,
+			}
+			c.Config.IMAPHost = imap.host
+			c.Config.IMAPPort = imap.port
+			c.Config.IMAPSecurity = imap.security
+			setSMTP(&c.Config, smtp)
+			c.Score = score(&c)
+			candidates = append(candidates, c)
+		}
+		if pop3, ok := findSRV(ctx, addr.domain, pop3Services); ok {
+			c := Candidate{
+				Config: newConfig(addr, ""),
+				Source: SourceSRV,
+			}
+			c.Config.Protocol = account.ProtocolPOP3
+			c.Config.POP3Host = pop3.host
+			c.Config.POP3Port = pop3.port
+			c.Config.POP3Security = pop3.security
+			setSMTP(&c.Config, smtp)
+			c.Score = score(&c)
+			candidates = append(candidates, c)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, errors.New("no usable SRV records")
+	}
+	return candidates, nil
+}
+
+// findSRV returns the first advertised target of the first service the
+// domain has. A target of "." means the service is deliberately not
+// offered (RFC 2782), so that service is skipped.
+func findSRV(ctx context.Context, domain string, services []srvService) (srvTarget, bool) {
+	for _, svc := range services {
+		_, records, err := resolver.LookupSRV(ctx, svc.name, "tcp", domain)
+		if err != nil || len(records) == 0 {
+			continue
+		}
+		// Records come sorted by priority and weight
+		r := records[0]
+		host := strings.TrimSuffix(r.Target, ".")
+		if host == "" {
+			continue
+		}
+		return srvTarget{host: host, port: int(r.Port), security: svc.security}, true
+	}
+	return srvTarget{}, false
+}
+
+// setSMTP copies an SRV target into a configuration's SMTP settings
+func setSMTP(cfg *account.AccountConfig, smtp srvTarget) {
+	cfg.SMTPHost = smtp.host
+	cfg.SMTPPort = smtp.port
+	cfg.SMTPSecurity = smtp.security
+}
+
+// mxProviders maps MX host domains to the ISPDB domain of the provider
+// that runs them, where the two differ
+var mxProviders = map[string]string{
+	"google.com":                  "gmail.com",
+	"googlemail.com":              "gmail.com",
+	"mail.protection.outlook.com": "office365.com",
+	"messagingengine.com":         "fastmail.com",
+	"icloud.com":                  "icloud.com",
+	"zoho.com":                    "zoho.com",
+	"zoho.eu":                     "zoho.com",
+	"yandex.net":                  "yandex.ru",
+}
+
+// lookupMX finds the provider hosting the domain's mail from its MX
+// records and uses that provider's ISPDB entry. Failing that, it guesses
+// common host names on the domain.
+func lookupMX(ctx context.Context, addr address) ([]Candidate, error) {
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+
+	records, err := resolver.LookupMX(ctx, addr.domain)
+	if err == nil {
+		for _, mx := range records {
+			host := strings.ToLower(strings.TrimSuffix(mx.Host, "."))
+			if found := lookupISPDB(addr, mxProvider(host), SourceMX); len(found) > 0 {
+				return found, nil
+			}
+		}
+	}
+
+	return guess(ctx, addr)
+}
+
+// mxProvider returns the ISPDB domain to look up for an MX host
+func mxProvider(host string) string {
+	for suffix, domain := range mxProviders {
+		if host == suffix || strings.HasSuffix(host, "."+suffix) {
+			return domain
+		}
+	}
+	if domain, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return domain
+	}
+	return host
+}
+
+// guessDialTimeout bounds each port probe while guessing
+const guessDialTimeout = 3 * time.Second
+
+// guess tries the usual host names for the domain's servers and keeps the
+// first that accept connections
+func guess(ctx context.Context, addr address) ([]Candidate, error) {
+	imap, ok := probeHosts(ctx, addr.domain, []string{"imap.", "mail.", ""}, []srvTarget{
+		{port: 993, security: account.SecurityTLS},
+		{port: 143, security: account.SecurityStartTLS},
+	})
+	if !ok {
+		return nil, errors.New("no IMAP server found")
+	}
+	smtp, ok := probeHosts(ctx, addr.domain, []string{"smtp.", "mail.", ""}, []srvTarget{
+		{port: 465, security: account.SecurityTLS},
+		{port: 587, security: account.SecurityStartTLS},
+	})
+	if !ok {
+		return nil, errors.New("no SMTP server found")
+	}
+
+	c := Candidate{
+		Config: newConfig(addr, ""),
+		Source: SourceGuess,
+	}
+	c.Config.IMAPHost = imap.host
+	c.Config.IMAPPort = imap.port
+	c.Config.IMAPSecurity = imap.security
+	setSMTP(&c.Config, smtp)
+	c.Score = score(&c)
+	return []Candidate{c}, nil
+}
+
+// probeHosts returns the first prefixed host name and port that accepts a
+// TCP connection
+func probeHosts(ctx context.Context, domain string, prefixes []string, ports []srvTarget) (srvTarget, bool) {
+	dialer := net.Dialer{Timeout: guessDialTimeout}
+	for _, prefix := range prefixes {
+		host := prefix + domain
+		if _, err := resolver.LookupHost(ctx, host); err != nil {
+			continue
+		}
+		for _, p := range ports {
+			conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(p.port)))
+			if err != nil {
+				continue
+			}
+			conn.Close()
+			return srvTarget{host: host, port: p.port, security: p.security}, true
+		}
+	}
+	return srvTarget{}, false
+}
@@ -0,0 +1,59 @@
+package autoconfig
+
+import (
+	"embed"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/hkdb/aerion/internal/logging"
+)
+
+// ispdbFiles is a snapshot of the Mozilla ISPDB entries for common
+// providers, in the same format the domains publish themselves
+//
+//go:embed ispdb/*.xml
+var ispdbFiles embed.FS
+
+var (
+	ispdbOnce    sync.Once
+	ispdbDomains map[string]*clientConfig
+)
+
+// loadISPDB parses the bundled snapshot and indexes it by domain
+func loadISPDB() {
+	log := logging.WithComponent("autoconfig")
+
+	ispdbDomains = make(map[string]*clientConfig)
+	entries, err := ispdbFiles.ReadDir("ispdb")
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to read bundled ISPDB")
+		return
+	}
+	for _, entry := range entries {
+		data, err := ispdbFiles.ReadFile(path.Join("ispdb", entry.Name()))
+		if err != nil {
+			log.Error().Err(err).Str("file", entry.Name()).Msg("Failed to read ISPDB entry")
+			continue
+		}
+		cfg, err := parseClientConfig(data)
+		if err != nil {
+			log.Error().Err(err).Str("file", entry.Name()).Msg("Failed to parse ISPDB entry")
+			continue
+		}
+		for _, domain := range cfg.Provider.Domains {
+			ispdbDomains[strings.ToLower(strings.TrimSpace(domain))] = cfg
+		}
+	}
+}
+
+// lookupISPDB returns the candidates for a domain in the bundled snapshot
+func lookupISPDB(addr address, domain string, source Source) []Candidate {
+	ispdbOnce.Do(loadISPDB)
+
+	cfg, ok := ispdbDomains[domain]
+	if !ok {
+		return nil
+	}
+	return candidatesFromConfig(cfg, addr, source)
+}
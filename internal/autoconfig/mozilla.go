@@ -0,0 +1,221 @@
+package autoconfig
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hkdb/aerion/internal/account"
+	"github.com/hkdb/aerion/internal/oauth2"
+)
+
+// clientConfig is a Mozilla autoconfig file (config-v1.1.xml), the format
+// of both the ISPDB and the files domains publish themselves
+type clientConfig struct {
+	XMLName  xml.Name `xml:"clientConfig"`
+	Provider struct {
+		ID          string         `xml:"id,attr"`
+		Domains     []string       `xml:"domain"`
+		DisplayName string         `xml:"displayName"`
+		Incoming    []serverConfig `xml:"incomingServer"`
+		Outgoing    []serverConfig `xml:"outgoingServer"`
+	} `xml:"emailProvider"`
+}
+
+// serverConfig is an incomingServer or outgoingServer element
+type serverConfig struct {
+	Type           string   `xml:"type,attr"`
+	Hostname       string   `xml:"hostname"`
+	Port           int      `xml:"port"`
+	SocketType     string   `xml:"socketType"`
+	Username       string   `xml:"username"`
+	Authentication []string `xml:"authentication"`
+}
+
+// lookupAutoconfig fetches the file a domain serves on its autoconfig
+// host. Many only serve it over plain HTTP, so that is tried second, as an
+// unverified source.
+func lookupAutoconfig(ctx context.Context, addr address) ([]Candidate, error) {
+	query := "?emailaddress=" + url.QueryEscape(addr.email)
+	var lastErr error
+	for _, scheme := range []string{"https", "http"} {
+		cfg, err := fetchClientConfig(ctx, scheme+"://autoconfig."+addr.domain+"/mail/config-v1.1.xml"+query)
+		if err == nil {
+			source := SourceAutoconfig
+			if scheme == "http" {
+				source = SourceAutoconfigHTTP
+			}
+			return candidatesFromConfig(cfg, addr, source), nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// lookupWellKnown fetches the file a domain serves under .well-known
+func lookupWellKnown(ctx context.Context, addr address) ([]Candidate, error) {
+	cfg, err := fetchClientConfig(ctx, "https://"+addr.domain+"/.well-known/autoconfig/mail/config-v1.1.xml?emailaddress="+url.QueryEscape(addr.email))
+	if err != nil {
+		return nil, err
+	}
+	return candidatesFromConfig(cfg, addr, SourceWellKnown), nil
+}
+
+// fetchClientConfig downloads and parses an autoconfig file
+func fetchClientConfig(ctx context.Context, rawURL string) (*clientConfig, error) {
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024)) // 1MB limit
+	if err != nil {
+		return nil, err
+	}
+	return parseClientConfig(data)
+}
+
+// parseClientConfig parses an autoconfig file
+func parseClientConfig(data []byte) (*clientConfig, error) {
+	var cfg clientConfig
+	if err := xml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse autoconfig file: %w", err)
+	}
+	if len(cfg.Provider.Incoming) == 0 {
+		return nil, fmt.Errorf("autoconfig file lists no incoming servers")
+	}
+	return &cfg, nil
+}
+
+// candidatesFromConfig returns a candidate for each usable incoming server,
+// paired with the first usable outgoing server. Servers are listed in the
+// provider's order of preference, which is kept.
+func candidatesFromConfig(cfg *clientConfig, addr address, source Source) []Candidate {
+	var smtp *serverConfig
+	for i := range cfg.Provider.Outgoing {
+		s := &cfg.Provider.Outgoing[i]
+		if s.Type == "smtp" && usableAuth(s.Authentication) {
+			smtp = s
+			break
+		}
+	}
+	if smtp == nil {
+		return nil
+	}
+
+	var candidates []Candidate
+	for i, in := range cfg.Provider.Incoming {
+		if !usableAuth(in.Authentication) {
+			continue
+		}
+
+		c := Candidate{
+			Config: newConfig(addr, cfg.Provider.DisplayName),
+			Source: source,
+		}
+		host := expand(in.Hostname, addr)
+		switch in.Type {
+		case "imap":
+			c.Config.IMAPHost = host
+			c.Config.IMAPPort = in.Port
+			c.Config.IMAPSecurity = socketSecurity(in.SocketType)
+		case "pop3":
+			c.Config.Protocol = account.ProtocolPOP3
+			c.Config.POP3Host = host
+			c.Config.POP3Port = in.Port
+			c.Config.POP3Security = socketSecurity(in.SocketType)
+		default:
+			continue
+		}
+		if in.Username != "" {
+			c.Config.Username = expand(in.Username, addr)
+		}
+		c.Config.SMTPHost = expand(smtp.Hostname, addr)
+		c.Config.SMTPPort = smtp.Port
+		c.Config.SMTPSecurity = socketSecurity(smtp.SocketType)
+
+		if hasAuth(in.Authentication, "OAuth2") {
+			if provider := oauthProvider(host); provider != "" {
+				c.OAuthProvider = provider
+				c.Config.AuthType = account.AuthOAuth2
+			}
+		}
+
+		c.Score = score(&c) - i
+		candidates = append(candidates, c)
+	}
+	return candidates
+}
+
+// usableAuth reports whether a server takes a login the app supports. No
+// listed methods means a password.
+func usableAuth(methods []string) bool {
+	if len(methods) == 0 {
+		return true
+	}
+	for _, m := range methods {
+		switch strings.TrimSpace(m) {
+		case "password-cleartext", "plain", "password-encrypted", "secure", "OAuth2":
+			return true
+		}
+	}
+	return false
+}
+
+// hasAuth reports whether a server lists an authentication method
+func hasAuth(methods []string, method string) bool {
+	for _, m := range methods {
+		if strings.TrimSpace(m) == method {
+			return true
+		}
+	}
+	return false
+}
+
+// socketSecurity maps an autoconfig socketType to a security type
+func socketSecurity(socketType string) account.SecurityType {
+	switch strings.ToUpper(strings.TrimSpace(socketType)) {
+	case "SSL", "TLS":
+		return account.SecurityTLS
+	case "STARTTLS":
+		return account.SecurityStartTLS
+	default:
+		return account.SecurityNone
+	}
+}
+
+// expand fills in the placeholders autoconfig files use in host and user
+// names
+func expand(s string, addr address) string {
+	return strings.NewReplacer(
+		"%EMAILADDRESS%", addr.email,
+		"%EMAILLOCALPART%", addr.local,
+		"%EMAILDOMAIN%", addr.domain,
+	).Replace(strings.TrimSpace(s))
+}
+
+// oauthProvider returns the OAuth provider whose tokens a server takes, or
+// "" if there is none the app has credentials for
+func oauthProvider(host string) string {
+	p, ok := oauth2.ProviderForHost(host)
+	if !ok || !oauth2.IsProviderConfigured(p.Name) {
+		return ""
+	}
+	return p.Name
+}
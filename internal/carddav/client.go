@@ -2,6 +2,7 @@ package carddav
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -17,19 +18,31 @@ import (
 
 // Client wraps the CardDAV client with discovery and convenience methods
 type Client struct {
-	client   *carddav.Client
-	baseURL  string
-	username string
-	password string
-	log      zerolog.Logger
+	client    *carddav.Client
+	baseURL   string
+	username  string
+	password  string
+	tlsConfig *tls.Config
+	log       zerolog.Logger
 }
 
-// NewClient creates a new CardDAV client
-func NewClient(baseURL, username, password string) (*Client, error) {
-	// Create HTTP client with basic auth
-	httpClient := &http.Client{
-		Timeout: 30 * time.Second,
+// newHTTPClient creates an HTTP client, presenting the client certificate
+// in tlsConfig if there is one
+func newHTTPClient(timeout time.Duration, tlsConfig *tls.Config) *http.Client {
+	httpClient := &http.Client{Timeout: timeout}
+	if tlsConfig != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		httpClient.Transport = transport
 	}
+	return httpClient
+}
+
+// NewClient creates a new CardDAV client. tlsConfig may be nil; it's only
+// needed to present a client certificate.
+func NewClient(baseURL, username, password string, tlsConfig *tls.Config) (*Client, error) {
+	// Create HTTP client with basic auth
+	httpClient := newHTTPClient(30*time.Second, tlsConfig)
 
 	// Parse and normalize the URL
 	parsedURL, err := url.Parse(baseURL)
@@ -51,11 +64,12 @@ func NewClient(baseURL, username, password string) (*Client, error) {
 	}
 
 	return &Client{
-		client:   client,
-		baseURL:  parsedURL.String(),
-		username: username,
-		password: password,
-		log:      logging.WithComponent("carddav-client"),
+		client:    client,
+		baseURL:   parsedURL.String(),
+		username:  username,
+		password:  password,
+		tlsConfig: tlsConfig,
+		log:       logging.WithComponent("carddav-client"),
 	}, nil
 }
 
@@ -64,7 +78,7 @@ func NewClient(baseURL, username, password string) (*Client, error) {
 // 1. .well-known/carddav
 // 2. Direct PROPFIND on the URL
 // 3. Common paths (/remote.php/dav for Nextcloud, etc.)
-func DiscoverAddressbooks(baseURL, username, password string) ([]AddressbookInfo, error) {
+func DiscoverAddressbooks(baseURL, username, password string, tlsConfig *tls.Config) ([]AddressbookInfo, error) {
 	ctx := context.Background()
 	log := logging.WithComponent("carddav-discovery")
 	log.Info().Str("url", baseURL).Msg("Starting addressbook discovery")
@@ -81,7 +95,7 @@ func DiscoverAddressbooks(baseURL, username, password string) ([]AddressbookInfo
 
 	// Create HTTP client with basic auth
 	httpClient := webdav.HTTPClientWithBasicAuth(
-		&http.Client{Timeout: 30 * time.Second},
+		newHTTPClient(30*time.Second, tlsConfig),
 		username, password,
 	)
 
@@ -226,7 +240,7 @@ func (c *Client) FetchContacts(addressbookPath string) ([]ParsedContact, error)
 
 	// Create a new client for this specific addressbook
 	httpClient := webdav.HTTPClientWithBasicAuth(
-		&http.Client{Timeout: 60 * time.Second},
+		newHTTPClient(60*time.Second, c.tlsConfig),
 		c.username, c.password,
 	)
 
@@ -336,7 +350,7 @@ func (c *Client) SyncAddressbook(addressbookPath, syncToken string) (*SyncResult
 
 	// Create a new client for this specific addressbook
 	httpClient := webdav.HTTPClientWithBasicAuth(
-		&http.Client{Timeout: 60 * time.Second},
+		newHTTPClient(60*time.Second, c.tlsConfig),
 		c.username, c.password,
 	)
 
@@ -446,12 +460,12 @@ func (c *Client) fetchContactsByPath(client *carddav.Client, addressbookPath str
 }
 
 // TestConnection tests the connection to the CardDAV server
-func TestConnection(baseURL, username, password string) error {
+func TestConnection(baseURL, username, password string, tlsConfig *tls.Config) error {
 	log := logging.WithComponent("carddav-test")
 	log.Info().Str("url", baseURL).Msg("Testing CardDAV connection")
 
 	// Try to discover addressbooks - this validates credentials and connectivity
-	addressbooks, err := DiscoverAddressbooks(baseURL, username, password)
+	addressbooks, err := DiscoverAddressbooks(baseURL, username, password, tlsConfig)
 	if err != nil {
 		return fmt.Errorf("connection test failed: %w", err)
 	}
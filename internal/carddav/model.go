@@ -20,6 +20,7 @@ type Source struct {
 	URL          string     `json:"url"`          // CardDAV server URL (empty for OAuth sources)
 	Username     string     `json:"username"`     // CardDAV username (empty for OAuth sources)
 	AccountID    *string    `json:"account_id,omitempty"` // Linked email account ID (for OAuth sources using account's token)
+	ClientCertID string     `json:"client_cert_id,omitempty"` // TLS client certificate presented to the CardDAV server
 	Enabled      bool       `json:"enabled"`
 	SyncInterval int        `json:"sync_interval"` // Minutes (0 = manual only)
 	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
@@ -39,6 +40,7 @@ type SourceConfig struct {
 	Username     string     `json:"username"` // CardDAV username (empty for OAuth sources)
 	Password     string     `json:"password"` // CardDAV password, only used for create/update, not stored in DB
 	AccountID    string     `json:"account_id,omitempty"` // Linked email account ID (for OAuth sources)
+	ClientCertID string     `json:"client_cert_id,omitempty"` // TLS client certificate (CardDAV sources)
 	Enabled      bool       `json:"enabled"`
 	SyncInterval int        `json:"sync_interval"`
 
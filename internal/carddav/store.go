@@ -41,12 +41,12 @@ func (s *Store) CreateSource(config *SourceConfig) (*Source, error) {
 	}
 
 	query := `
-		INSERT INTO contact_sources (id, name, type, url, username, account_id, enabled, sync_interval, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO contact_sources (id, name, type, url, username, account_id, client_cert_id, enabled, sync_interval, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err := s.db.Exec(query,
-		id, config.Name, config.Type, config.URL, config.Username, accountID,
+		id, config.Name, config.Type, config.URL, config.Username, accountID, config.ClientCertID,
 		config.Enabled, config.SyncInterval, now)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create source: %w", err)
@@ -59,6 +59,7 @@ func (s *Store) CreateSource(config *SourceConfig) (*Source, error) {
 		URL:          config.URL,
 		Username:     config.Username,
 		AccountID:    accountID,
+		ClientCertID: config.ClientCertID,
 		Enabled:      config.Enabled,
 		SyncInterval: config.SyncInterval,
 		CreatedAt:    now,
@@ -71,7 +72,7 @@ func (s *Store) CreateSource(config *SourceConfig) (*Source, error) {
 // GetSource returns a source by ID
 func (s *Store) GetSource(id string) (*Source, error) {
 	query := `
-		SELECT id, name, type, url, username, account_id, enabled, sync_interval,
+		SELECT id, name, type, url, username, account_id, client_cert_id, enabled, sync_interval,
 		       last_synced_at, last_error, last_error_at, created_at
 		FROM contact_sources
 		WHERE id = ?
@@ -83,7 +84,7 @@ func (s *Store) GetSource(id string) (*Source, error) {
 
 	err := s.db.QueryRow(query, id).Scan(
 		&source.ID, &source.Name, &source.Type, &source.URL, &source.Username,
-		&accountID, &source.Enabled, &source.SyncInterval,
+		&accountID, &source.ClientCertID, &source.Enabled, &source.SyncInterval,
 		&lastSyncedAt, &lastError, &lastErrorAt, &source.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -111,7 +112,7 @@ func (s *Store) GetSource(id string) (*Source, error) {
 // ListSources returns all contact sources
 func (s *Store) ListSources() ([]*Source, error) {
 	query := `
-		SELECT id, name, type, url, username, account_id, enabled, sync_interval,
+		SELECT id, name, type, url, username, account_id, client_cert_id, enabled, sync_interval,
 		       last_synced_at, last_error, last_error_at, created_at
 		FROM contact_sources
 		ORDER BY created_at ASC
@@ -131,7 +132,7 @@ func (s *Store) ListSources() ([]*Source, error) {
 
 		err := rows.Scan(
 			&source.ID, &source.Name, &source.Type, &source.URL, &source.Username,
-			&accountID, &source.Enabled, &source.SyncInterval,
+			&accountID, &source.ClientCertID, &source.Enabled, &source.SyncInterval,
 			&lastSyncedAt, &lastError, &lastErrorAt, &source.CreatedAt)
 		if err != nil {
 			s.log.Warn().Err(err).Msg("Failed to scan source row")
@@ -161,12 +162,12 @@ func (s *Store) ListSources() ([]*Source, error) {
 func (s *Store) UpdateSource(id string, config *SourceConfig) error {
 	query := `
 		UPDATE contact_sources
-		SET name = ?, url = ?, username = ?, enabled = ?, sync_interval = ?
+		SET name = ?, url = ?, username = ?, client_cert_id = ?, enabled = ?, sync_interval = ?
 		WHERE id = ?
 	`
 
 	result, err := s.db.Exec(query,
-		config.Name, config.URL, config.Username, config.Enabled, config.SyncInterval, id)
+		config.Name, config.URL, config.Username, config.ClientCertID, config.Enabled, config.SyncInterval, id)
 	if err != nil {
 		return fmt.Errorf("failed to update source: %w", err)
 	}
@@ -605,6 +606,36 @@ func (s *Store) SearchContacts(query string, limit int) ([]*Contact, error) {
 	return contacts, nil
 }
 
+// ListContacts returns all contacts in an addressbook, sorted by name
+func (s *Store) ListContacts(addressbookID string) ([]*Contact, error) {
+	query := `
+		SELECT id, addressbook_id, email, display_name, href, etag, synced_at
+		FROM carddav_contacts
+		WHERE addressbook_id = ?
+		ORDER BY display_name COLLATE NOCASE ASC, email ASC
+	`
+
+	rows, err := s.db.Query(query, addressbookID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contacts: %w", err)
+	}
+	defer rows.Close()
+
+	var contacts []*Contact
+	for rows.Next() {
+		var c Contact
+		err := rows.Scan(
+			&c.ID, &c.AddressbookID, &c.Email, &c.DisplayName,
+			&c.Href, &c.ETag, &c.SyncedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan contact: %w", err)
+		}
+		contacts = append(contacts, &c)
+	}
+
+	return contacts, rows.Err()
+}
+
 // GetContactByHref returns a contact by its href (for update checks)
 func (s *Store) GetContactByHref(addressbookID, href string) (*Contact, error) {
 	query := `
@@ -650,7 +681,7 @@ func (s *Store) CountContactsForSource(sourceID string) (int, error) {
 // GetSourceByAccountID returns a contact source linked to an email account
 func (s *Store) GetSourceByAccountID(accountID string) (*Source, error) {
 	query := `
-		SELECT id, name, type, url, username, account_id, enabled, sync_interval,
+		SELECT id, name, type, url, username, account_id, client_cert_id, enabled, sync_interval,
 		       last_synced_at, last_error, last_error_at, created_at
 		FROM contact_sources
 		WHERE account_id = ?
@@ -662,7 +693,7 @@ func (s *Store) GetSourceByAccountID(accountID string) (*Source, error) {
 
 	err := s.db.QueryRow(query, accountID).Scan(
 		&source.ID, &source.Name, &source.Type, &source.URL, &source.Username,
-		&accID, &source.Enabled, &source.SyncInterval,
+		&accID, &source.ClientCertID, &source.Enabled, &source.SyncInterval,
 		&lastSyncedAt, &lastError, &lastErrorAt, &source.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
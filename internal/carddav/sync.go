@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/hkdb/aerion/internal/certificate"
 	"github.com/hkdb/aerion/internal/contact"
 	"github.com/hkdb/aerion/internal/credentials"
 	"github.com/hkdb/aerion/internal/logging"
@@ -44,6 +45,7 @@ func retryDBOperation(operation func() error, maxRetries int, baseDelay time.Dur
 type Syncer struct {
 	store            *Store
 	credStore        *credentials.Store
+	certStore        *certificate.Store
 	getAccountToken  AccessTokenGetter // Gets OAuth token from linked email account
 	getSourceToken   AccessTokenGetter // Gets OAuth token from standalone contact source
 	googleSyncer     *contact.GoogleContactsSyncer
@@ -52,10 +54,11 @@ type Syncer struct {
 }
 
 // NewSyncer creates a new contact syncer
-func NewSyncer(store *Store, credStore *credentials.Store) *Syncer {
+func NewSyncer(store *Store, credStore *credentials.Store, certStore *certificate.Store) *Syncer {
 	return &Syncer{
 		store:           store,
 		credStore:       credStore,
+		certStore:       certStore,
 		googleSyncer:    contact.NewGoogleContactsSyncer(),
 		microsoftSyncer: contact.NewMicrosoftContactsSyncer(),
 		log:             logging.WithComponent("carddav-sync"),
@@ -109,8 +112,15 @@ func (s *Syncer) syncCardDAV(source *Source) error {
 		return fmt.Errorf("failed to get password: %w", err)
 	}
 
+	tlsConfig, err := s.certStore.ClientCertTLSConfig(source.ClientCertID, s.credStore)
+	if err != nil {
+		syncErr := fmt.Sprintf("failed to load client certificate: %v", err)
+		s.store.UpdateSourceSyncStatus(source.ID, syncErr)
+		return fmt.Errorf("failed to load client certificate: %w", err)
+	}
+
 	// Create CardDAV client
-	client, err := NewClient(source.URL, source.Username, password)
+	client, err := NewClient(source.URL, source.Username, password, tlsConfig)
 	if err != nil {
 		syncErr := fmt.Sprintf("failed to connect: %v", err)
 		s.store.UpdateSourceSyncStatus(source.ID, syncErr)
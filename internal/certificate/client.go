@@ -0,0 +1,214 @@
+package certificate
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hkdb/aerion/internal/credentials"
+	gopkcs12 "software.sslmate.com/src/go-pkcs12"
+)
+
+// ClientCertificate is a TLS client certificate presented to servers that
+// require mutual TLS. Accounts and CardDAV sources reference it by ID.
+type ClientCertificate struct {
+	ID           string    `json:"id"`
+	Subject      string    `json:"subject"`
+	Issuer       string    `json:"issuer"`
+	SerialNumber string    `json:"serialNumber"`
+	Fingerprint  string    `json:"fingerprint"`
+	NotBefore    time.Time `json:"notBefore"`
+	NotAfter     time.Time `json:"notAfter"`
+	IsExpired    bool      `json:"isExpired"` // Computed, not stored
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// ImportPKCS12 parses a PKCS#12 file holding a client certificate.
+// Returns the private key as PEM bytes, the certificate chain as PEM string,
+// and parsed certificate metadata.
+func ImportPKCS12(data []byte, password string) (privateKeyPEM []byte, certChainPEM string, cert *ClientCertificate, err error) {
+	privateKey, leafCert, caCerts, err := gopkcs12.DecodeChain(data, password)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to decode PKCS#12: %w", err)
+	}
+
+	pkcs8Bytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+	privateKeyPEM = pem.EncodeToMemory(&pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: pkcs8Bytes,
+	})
+
+	// Leaf first, then intermediates, the order tls.X509KeyPair expects
+	chainPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: leafCert.Raw,
+	})
+	for _, ca := range caCerts {
+		chainPEM = append(chainPEM, pem.EncodeToMemory(&pem.Block{
+			Type:  "CERTIFICATE",
+			Bytes: ca.Raw,
+		})...)
+	}
+
+	cert = &ClientCertificate{
+		ID:           uuid.New().String(),
+		Subject:      formatDN(leafCert.Subject.CommonName, leafCert.Subject.Organization),
+		Issuer:       formatDN(leafCert.Issuer.CommonName, leafCert.Issuer.Organization),
+		SerialNumber: leafCert.SerialNumber.String(),
+		Fingerprint:  Fingerprint(leafCert.Raw),
+		NotBefore:    leafCert.NotBefore,
+		NotAfter:     leafCert.NotAfter,
+		IsExpired:    time.Now().After(leafCert.NotAfter),
+		CreatedAt:    time.Now(),
+	}
+
+	return privateKeyPEM, string(chainPEM), cert, nil
+}
+
+// SaveClientCertificate stores a client certificate. Importing the same
+// certificate again returns the ID it was first stored under.
+func (s *Store) SaveClientCertificate(cert *ClientCertificate, certChainPEM string) (string, error) {
+	if cert.ID == "" {
+		cert.ID = uuid.New().String()
+	}
+
+	var id string
+	err := s.db.QueryRow(`
+		INSERT INTO client_certificates (id, subject, issuer, serial_number, fingerprint,
+			not_before, not_after, cert_chain_pem, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(fingerprint) DO UPDATE SET cert_chain_pem = excluded.cert_chain_pem
+		RETURNING id`,
+		cert.ID, cert.Subject, cert.Issuer, cert.SerialNumber, cert.Fingerprint,
+		cert.NotBefore, cert.NotAfter, certChainPEM, time.Now(),
+	).Scan(&id)
+	if err != nil {
+		return "", err
+	}
+	cert.ID = id
+	return id, nil
+}
+
+// GetClientCertificate retrieves a client certificate by ID, returning the
+// cert and its PEM chain
+func (s *Store) GetClientCertificate(id string) (*ClientCertificate, string, error) {
+	cert := &ClientCertificate{}
+	var certChainPEM string
+
+	err := s.db.QueryRow(`
+		SELECT id, subject, issuer, serial_number, fingerprint, not_before, not_after,
+			cert_chain_pem, created_at
+		FROM client_certificates WHERE id = ?`, id,
+	).Scan(
+		&cert.ID, &cert.Subject, &cert.Issuer, &cert.SerialNumber, &cert.Fingerprint,
+		&cert.NotBefore, &cert.NotAfter, &certChainPEM, &cert.CreatedAt,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+
+	cert.IsExpired = time.Now().After(cert.NotAfter)
+	return cert, certChainPEM, nil
+}
+
+// ListClientCertificates returns all client certificates
+func (s *Store) ListClientCertificates() ([]*ClientCertificate, error) {
+	rows, err := s.db.Query(`
+		SELECT id, subject, issuer, serial_number, fingerprint, not_before, not_after, created_at
+		FROM client_certificates ORDER BY created_at DESC`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var certs []*ClientCertificate
+	for rows.Next() {
+		cert := &ClientCertificate{}
+		if err := rows.Scan(
+			&cert.ID, &cert.Subject, &cert.Issuer, &cert.SerialNumber, &cert.Fingerprint,
+			&cert.NotBefore, &cert.NotAfter, &cert.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		cert.IsExpired = time.Now().After(cert.NotAfter)
+		certs = append(certs, cert)
+	}
+	return certs, rows.Err()
+}
+
+// DeleteClientCertificate removes a client certificate and stops accounts
+// and CardDAV sources from presenting it
+func (s *Store) DeleteClientCertificate(id string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE accounts SET client_cert_id = '' WHERE client_cert_id = ?", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE contact_sources SET client_cert_id = '' WHERE client_cert_id = ?", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM client_certificates WHERE id = ?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// LoadClientCertificate returns a client certificate with its private key,
+// ready to present in a TLS handshake
+func (s *Store) LoadClientCertificate(id string, credStore *credentials.Store) (*tls.Certificate, error) {
+	_, certChainPEM, err := s.GetClientCertificate(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+	privateKeyPEM, err := credStore.GetClientCertPrivateKey(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate key: %w", err)
+	}
+	cert, err := tls.X509KeyPair([]byte(certChainPEM), privateKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client certificate: %w", err)
+	}
+	return &cert, nil
+}
+
+// BuildClientTLSConfig returns BuildTLSConfig's config presenting the client
+// certificate with the given ID. No certificate is presented if the ID is
+// empty.
+func BuildClientTLSConfig(host string, store *Store, credStore *credentials.Store, clientCertID string) (*tls.Config, error) {
+	config := BuildTLSConfig(host, store)
+	if clientCertID == "" {
+		return config, nil
+	}
+	cert, err := store.LoadClientCertificate(clientCertID, credStore)
+	if err != nil {
+		return nil, err
+	}
+	config.Certificates = []tls.Certificate{*cert}
+	return config, nil
+}
+
+// ClientCertTLSConfig returns a config that only presents the client
+// certificate with the given ID, leaving server verification to the system
+// CAs, for HTTP clients that don't use the trust store. Returns nil if the
+// ID is empty.
+func (s *Store) ClientCertTLSConfig(id string, credStore *credentials.Store) (*tls.Config, error) {
+	if id == "" {
+		return nil, nil
+	}
+	cert, err := s.LoadClientCertificate(id, credStore)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{Certificates: []tls.Certificate{*cert}}, nil
+}
@@ -266,6 +266,101 @@ func (s *Store) clearSMIMEDBPrivateKey(certID string) {
 	s.db.Exec("UPDATE smime_certificates SET encrypted_private_key = NULL WHERE id = ?", certID)
 }
 
+// SetClientCertPrivateKey stores the private key of a TLS client certificate
+func (s *Store) SetClientCertPrivateKey(certID string, privateKeyPEM []byte) error {
+	if len(privateKeyPEM) == 0 {
+		return nil
+	}
+
+	keyringKey := "clientcert:" + certID + ":private_key"
+
+	// Try OS keyring first if available
+	if s.keyringEnabled {
+		err := gokeyring.Set(serviceName, keyringKey, string(privateKeyPEM))
+		if err == nil {
+			s.log.Debug().Str("cert_id", certID).Msg("Client certificate key stored in OS keyring")
+			s.clearClientCertDBPrivateKey(certID)
+			return nil
+		}
+		s.log.Warn().Err(err).Msg("Failed to store client certificate key in OS keyring, using fallback")
+	}
+
+	// Fallback to encrypted database storage
+	encrypted, err := s.encryptor.Encrypt(string(privateKeyPEM))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt client certificate key: %w", err)
+	}
+
+	_, err = s.db.Exec(
+		"UPDATE client_certificates SET encrypted_private_key = ? WHERE id = ?",
+		encrypted, certID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store encrypted client certificate key: %w", err)
+	}
+
+	s.log.Debug().Str("cert_id", certID).Msg("Client certificate key stored in encrypted database")
+	return nil
+}
+
+// GetClientCertPrivateKey retrieves the private key of a TLS client certificate
+func (s *Store) GetClientCertPrivateKey(certID string) ([]byte, error) {
+	keyringKey := "clientcert:" + certID + ":private_key"
+
+	// Try OS keyring first if available
+	if s.keyringEnabled {
+		key, err := gokeyring.Get(serviceName, keyringKey)
+		if err == nil {
+			return []byte(key), nil
+		}
+		if err != gokeyring.ErrNotFound {
+			s.log.Warn().Err(err).Msg("Error reading client certificate key from OS keyring, trying fallback")
+		}
+	}
+
+	// Try fallback encrypted database storage
+	var encrypted sql.NullString
+	err := s.db.QueryRow(
+		"SELECT encrypted_private_key FROM client_certificates WHERE id = ?",
+		certID,
+	).Scan(&encrypted)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrCredentialNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query client certificate key: %w", err)
+	}
+
+	if !encrypted.Valid || encrypted.String == "" {
+		return nil, ErrCredentialNotFound
+	}
+
+	key, err := s.encryptor.Decrypt(encrypted.String)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt client certificate key: %w", err)
+	}
+
+	return []byte(key), nil
+}
+
+// DeleteClientCertPrivateKey removes the private key of a TLS client certificate
+func (s *Store) DeleteClientCertPrivateKey(certID string) error {
+	keyringKey := "clientcert:" + certID + ":private_key"
+
+	if s.keyringEnabled {
+		gokeyring.Delete(serviceName, keyringKey)
+	}
+
+	s.clearClientCertDBPrivateKey(certID)
+	return nil
+}
+
+// clearClientCertDBPrivateKey clears the encrypted private key from the database
+func (s *Store) clearClientCertDBPrivateKey(certID string) {
+	s.db.Exec("UPDATE client_certificates SET encrypted_private_key = NULL WHERE id = ?", certID)
+}
+
 // SetPGPPrivateKey stores a PGP private key for a keypair
 func (s *Store) SetPGPPrivateKey(keyID string, armoredKey []byte) error {
 	if len(armoredKey) == 0 {
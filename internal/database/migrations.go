@@ -690,4 +690,332 @@ var migrations = []Migration{
 				('https://pgp.mit.edu', 2);
 		`,
 	},
+	{
+		Version: 26,
+		SQL: `
+			-- Folders (besides INBOX) that get push updates via IDLE/NOTIFY
+			ALTER TABLE folders ADD COLUMN watched INTEGER NOT NULL DEFAULT 0;
+		`,
+	},
+	{
+		Version: 27,
+		SQL: `
+			-- Opt-in IMAP COMPRESS=DEFLATE (RFC 4978) per account
+			ALTER TABLE accounts ADD COLUMN imap_compress INTEGER NOT NULL DEFAULT 0;
+		`,
+	},
+	{
+		Version: 28,
+		SQL: `
+			-- Server subscription state (LIST RETURN (SUBSCRIBED))
+			ALTER TABLE folders ADD COLUMN subscribed INTEGER NOT NULL DEFAULT 1;
+		`,
+	},
+	{
+		Version: 29,
+		SQL: `
+			-- IMAP operations that couldn't reach the server, replayed in
+			-- order once connectivity returns
+			CREATE TABLE pending_operations (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+				op_type TEXT NOT NULL,
+				folder_id TEXT,
+				folder_path TEXT NOT NULL,
+				uid_validity INTEGER NOT NULL DEFAULT 0,
+				uids TEXT,
+				flags TEXT,
+				dest_folder_id TEXT,
+				dest_folder_path TEXT,
+				data BLOB,
+				status TEXT NOT NULL DEFAULT 'pending',
+				attempts INTEGER NOT NULL DEFAULT 0,
+				last_error TEXT,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE INDEX idx_pending_operations_account ON pending_operations(account_id, status, id);
+		`,
+	},
+	{
+		Version: 30,
+		SQL: `
+			-- Outbox: fully built messages waiting for their send time
+			-- (undo-send window, send later, or retry after a network error)
+			CREATE TABLE outbox (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+				from_address TEXT NOT NULL,
+				recipients TEXT NOT NULL,
+				subject TEXT NOT NULL DEFAULT '',
+				raw_message BLOB NOT NULL,
+				compose_data BLOB,
+				send_at INTEGER NOT NULL, -- unix seconds, compared in queries
+				scheduled INTEGER NOT NULL DEFAULT 0,
+				status TEXT NOT NULL DEFAULT 'queued',
+				attempts INTEGER NOT NULL DEFAULT 0,
+				last_error TEXT,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE INDEX idx_outbox_due ON outbox(status, send_at);
+			CREATE INDEX idx_outbox_account ON outbox(account_id);
+		`,
+	},
+	{
+		Version: 31,
+		SQL: `
+			-- Client-side filter rules, evaluated in position order per account
+			CREATE TABLE rules (
+				id TEXT PRIMARY KEY,
+				account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+				name TEXT NOT NULL,
+				enabled INTEGER NOT NULL DEFAULT 1,
+				position INTEGER NOT NULL DEFAULT 0,
+				match_mode TEXT NOT NULL DEFAULT 'all',
+				conditions TEXT NOT NULL,
+				actions TEXT NOT NULL,
+				stop_processing INTEGER NOT NULL DEFAULT 0,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE INDEX idx_rules_account ON rules(account_id, position);
+
+			-- Highest UID rules have already run on, so each new message is
+			-- filtered exactly once
+			CREATE TABLE rules_folder_state (
+				folder_id TEXT PRIMARY KEY REFERENCES folders(id) ON DELETE CASCADE,
+				uid_validity INTEGER NOT NULL DEFAULT 0,
+				last_uid INTEGER NOT NULL DEFAULT 0
+			);
+		`,
+	},
+	{
+		Version: 32,
+		SQL: `
+			-- Gmail's stable message ID, shared by every label (folder) copy
+			ALTER TABLE messages ADD COLUMN gm_msgid INTEGER;
+			CREATE INDEX idx_messages_gm_msgid ON messages(account_id, gm_msgid);
+
+			-- Gmail labels per message, keyed by X-GM-MSGID so a message seen
+			-- through several label folders has one label set
+			CREATE TABLE gmail_labels (
+				account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+				gm_msgid INTEGER NOT NULL,
+				label TEXT NOT NULL,
+				PRIMARY KEY (account_id, gm_msgid, label)
+			);
+
+			CREATE INDEX idx_gmail_labels_label ON gmail_labels(account_id, label);
+		`,
+	},
+	{
+		Version: 33,
+		SQL: `
+			-- IMAP keywords ($Label1, $Todo, custom words) shown as tags.
+			-- local_only tags live in folders whose server can't store
+			-- keywords and are kept across flag syncs.
+			CREATE TABLE message_tags (
+				message_id TEXT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+				keyword TEXT NOT NULL COLLATE NOCASE,
+				local_only INTEGER NOT NULL DEFAULT 0,
+				PRIMARY KEY (message_id, keyword)
+			);
+
+			CREATE INDEX idx_message_tags_keyword ON message_tags(keyword);
+
+			-- Per-account tag colors
+			CREATE TABLE tags (
+				account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+				keyword TEXT NOT NULL COLLATE NOCASE,
+				color TEXT NOT NULL,
+				PRIMARY KEY (account_id, keyword)
+			);
+
+			-- Whether the server accepts new keywords (PERMANENTFLAGS \*)
+			ALTER TABLE folders ADD COLUMN keywords_allowed INTEGER NOT NULL DEFAULT 1;
+		`,
+	},
+	{
+		Version: 34,
+		SQL: `
+			-- Protocol an account syncs over: 'imap' (IMAP/SMTP) or 'jmap'
+			ALTER TABLE accounts ADD COLUMN protocol TEXT NOT NULL DEFAULT 'imap';
+			ALTER TABLE accounts ADD COLUMN jmap_session_url TEXT;
+
+			-- Server-side IDs of JMAP Mailbox and Email objects. Messages keep
+			-- a synthetic UID so the rest of the app can treat them alike.
+			ALTER TABLE folders ADD COLUMN remote_id TEXT;
+			ALTER TABLE messages ADD COLUMN remote_id TEXT;
+			CREATE INDEX idx_messages_remote_id ON messages(account_id, remote_id);
+
+			-- Queued operations on JMAP accounts address Emails by ID, since
+			-- synthetic UIDs change when messages move
+			ALTER TABLE pending_operations ADD COLUMN remote_ids TEXT;
+
+			-- Last JMAP state string seen per account and data type, the
+			-- starting point for the next /changes call
+			CREATE TABLE jmap_states (
+				account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+				type TEXT NOT NULL,
+				state TEXT NOT NULL,
+				PRIMARY KEY (account_id, type)
+			);
+		`,
+	},
+	{
+		Version: 35,
+		SQL: `
+			-- POP3 accounts download into local-only folders; SMTP settings are
+			-- shared with IMAP accounts
+			ALTER TABLE accounts ADD COLUMN pop3_host TEXT;
+			ALTER TABLE accounts ADD COLUMN pop3_port INTEGER NOT NULL DEFAULT 995;
+			ALTER TABLE accounts ADD COLUMN pop3_security TEXT NOT NULL DEFAULT 'tls';
+			ALTER TABLE accounts ADD COLUMN pop3_apop INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE accounts ADD COLUMN pop3_leave_on_server INTEGER NOT NULL DEFAULT 1;
+			ALTER TABLE accounts ADD COLUMN pop3_delete_after_days INTEGER NOT NULL DEFAULT 0;
+
+			-- Messages already downloaded from a POP3 maildrop, by UIDL, so
+			-- messages left on the server aren't downloaded twice
+			CREATE TABLE pop3_uidls (
+				account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+				uidl TEXT NOT NULL,
+				downloaded_at DATETIME NOT NULL,
+				PRIMARY KEY (account_id, uidl)
+			);
+
+			-- Raw RFC822 source of messages that have no server copy to fetch
+			-- it from again
+			CREATE TABLE message_sources (
+				message_id TEXT PRIMARY KEY REFERENCES messages(id) ON DELETE CASCADE,
+				raw BLOB NOT NULL
+			);
+		`,
+	},
+	{
+		Version: 36,
+		SQL: `
+			-- Local folders accounts can mirror their folders to a Maildir
+			-- tree; NULL keeps their mail in the database only
+			ALTER TABLE accounts ADD COLUMN maildir_path TEXT;
+		`,
+	},
+	{
+		Version: 37,
+		SQL: `
+			-- SASL mechanism pinned for IMAP/SMTP logins; empty lets Aerion
+			-- choose the strongest one the server offers
+			ALTER TABLE accounts ADD COLUMN auth_mechanism TEXT NOT NULL DEFAULT '';
+		`,
+	},
+	{
+		Version: 38,
+		SQL: `
+			-- TLS client certificates for servers that require mutual TLS.
+			-- The private key lives in the keyring, or encrypted here as a
+			-- fallback.
+			CREATE TABLE client_certificates (
+				id TEXT PRIMARY KEY,
+				subject TEXT NOT NULL,
+				issuer TEXT NOT NULL,
+				serial_number TEXT NOT NULL,
+				fingerprint TEXT NOT NULL UNIQUE,
+				not_before DATETIME NOT NULL,
+				not_after DATETIME NOT NULL,
+				cert_chain_pem TEXT NOT NULL,
+				encrypted_private_key TEXT,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			);
+
+			-- Client certificate presented to an account's servers or a
+			-- CardDAV server; empty presents none
+			ALTER TABLE accounts ADD COLUMN client_cert_id TEXT NOT NULL DEFAULT '';
+			ALTER TABLE contact_sources ADD COLUMN client_cert_id TEXT NOT NULL DEFAULT '';
+		`,
+	},
+	{
+		Version: 39,
+		SQL: `
+			-- Delivery status notifications (RFC 3461) requested for an
+			-- outbox message: JSON-serialized smtp.DSNOptions, NULL for none
+			ALTER TABLE outbox ADD COLUMN dsn TEXT;
+
+			-- Per-recipient results parsed from incoming delivery status
+			-- notifications (RFC 3464) and read receipts (RFC 8098), linked to
+			-- the sent message by its Message-ID
+			CREATE TABLE delivery_reports (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				report_message_id TEXT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+				original_message_id TEXT NOT NULL,
+				kind TEXT NOT NULL,
+				recipient TEXT NOT NULL DEFAULT '',
+				action TEXT NOT NULL,
+				status TEXT NOT NULL DEFAULT '',
+				diagnostic TEXT NOT NULL DEFAULT '',
+				reported_at DATETIME NOT NULL,
+				UNIQUE(report_message_id, recipient)
+			);
+			CREATE INDEX idx_delivery_reports_original ON delivery_reports(original_message_id);
+		`,
+	},
+	{
+		Version: 40,
+		SQL: `
+			-- Message templates and canned responses. account_id is NULL for
+			-- templates shared by all accounts. Recipient and attachment lists
+			-- are JSON-serialized.
+			CREATE TABLE templates (
+				id TEXT PRIMARY KEY,
+				account_id TEXT REFERENCES accounts(id) ON DELETE CASCADE,
+				name TEXT NOT NULL,
+				subject TEXT NOT NULL DEFAULT '',
+				html_body TEXT NOT NULL DEFAULT '',
+				text_body TEXT NOT NULL DEFAULT '',
+				to_list TEXT NOT NULL DEFAULT '[]',
+				cc_list TEXT NOT NULL DEFAULT '[]',
+				bcc_list TEXT NOT NULL DEFAULT '[]',
+				attachments BLOB,
+				created_at DATETIME NOT NULL,
+				updated_at DATETIME NOT NULL
+			);
+			CREATE INDEX idx_templates_account ON templates(account_id);
+		`,
+	},
+	{
+		Version: 41,
+		SQL: `
+			-- Mail merge jobs: a message with template variables sent
+			-- separately to each recipient. message is the JSON-serialized
+			-- smtp.ComposeMessage.
+			CREATE TABLE mail_merge_jobs (
+				id TEXT PRIMARY KEY,
+				account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+				name TEXT NOT NULL,
+				message BLOB NOT NULL,
+				rate_per_minute INTEGER NOT NULL,
+				status TEXT NOT NULL,
+				created_at DATETIME NOT NULL,
+				updated_at DATETIME NOT NULL,
+				completed_at DATETIME
+			);
+			CREATE INDEX idx_mail_merge_jobs_account ON mail_merge_jobs(account_id);
+
+			-- One row per recipient, so an interrupted job resumes with the
+			-- ones still pending. fields holds the row's variables as JSON.
+			CREATE TABLE mail_merge_recipients (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				job_id TEXT NOT NULL REFERENCES mail_merge_jobs(id) ON DELETE CASCADE,
+				position INTEGER NOT NULL,
+				email TEXT NOT NULL,
+				name TEXT NOT NULL DEFAULT '',
+				fields TEXT NOT NULL DEFAULT '{}',
+				status TEXT NOT NULL DEFAULT 'pending',
+				error TEXT NOT NULL DEFAULT '',
+				attempts INTEGER NOT NULL DEFAULT 0,
+				sent_at DATETIME
+			);
+			CREATE INDEX idx_mail_merge_recipients_job ON mail_merge_recipients(job_id, status, position);
+		`,
+	},
 }
@@ -40,6 +40,12 @@ type Folder struct {
 
 	// Sync state
 	LastSync *time.Time `json:"lastSync,omitempty"`
+
+	// Watched folders get push updates (IDLE/NOTIFY) like INBOX does
+	Watched bool `json:"watched"`
+
+	// Subscribed mirrors the server's subscription list
+	Subscribed bool `json:"subscribed"`
 }
 
 // IsSpecial returns true if this is a special folder (inbox, sent, etc.)
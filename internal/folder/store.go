@@ -3,6 +3,8 @@ package folder
 import (
 	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -30,7 +32,7 @@ func (s *Store) List(accountID string) ([]*Folder, error) {
 	query := `
 		SELECT id, account_id, name, path, folder_type, parent_id,
 		       uid_validity, uid_next, highest_mod_seq,
-		       total_count, unread_count, last_sync
+		       total_count, unread_count, last_sync, watched, subscribed
 		FROM folders
 		WHERE account_id = ?
 		ORDER BY name
@@ -53,7 +55,7 @@ func (s *Store) List(accountID string) ([]*Folder, error) {
 		err := rows.Scan(
 			&f.ID, &f.AccountID, &f.Name, &f.Path, &f.Type, &parentID,
 			&uidValidity, &uidNext, &highestModSeq,
-			&f.TotalCount, &f.UnreadCount, &lastSync,
+			&f.TotalCount, &f.UnreadCount, &lastSync, &f.Watched, &f.Subscribed,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan folder: %w", err)
@@ -86,7 +88,7 @@ func (s *Store) Get(id string) (*Folder, error) {
 	query := `
 		SELECT id, account_id, name, path, folder_type, parent_id,
 		       uid_validity, uid_next, highest_mod_seq,
-		       total_count, unread_count, last_sync
+		       total_count, unread_count, last_sync, watched, subscribed
 		FROM folders
 		WHERE id = ?
 	`
@@ -100,7 +102,7 @@ func (s *Store) Get(id string) (*Folder, error) {
 	err := s.db.QueryRow(query, id).Scan(
 		&f.ID, &f.AccountID, &f.Name, &f.Path, &f.Type, &parentID,
 		&uidValidity, &uidNext, &highestModSeq,
-		&f.TotalCount, &f.UnreadCount, &lastSync,
+		&f.TotalCount, &f.UnreadCount, &lastSync, &f.Watched, &f.Subscribed,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -133,7 +135,7 @@ func (s *Store) GetByPath(accountID, path string) (*Folder, error) {
 	query := `
 		SELECT id, account_id, name, path, folder_type, parent_id,
 		       uid_validity, uid_next, highest_mod_seq,
-		       total_count, unread_count, last_sync
+		       total_count, unread_count, last_sync, watched, subscribed
 		FROM folders
 		WHERE account_id = ? AND path = ?
 	`
@@ -147,7 +149,7 @@ func (s *Store) GetByPath(accountID, path string) (*Folder, error) {
 	err := s.db.QueryRow(query, accountID, path).Scan(
 		&f.ID, &f.AccountID, &f.Name, &f.Path, &f.Type, &parentID,
 		&uidValidity, &uidNext, &highestModSeq,
-		&f.TotalCount, &f.UnreadCount, &lastSync,
+		&f.TotalCount, &f.UnreadCount, &lastSync, &f.Watched, &f.Subscribed,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -184,8 +186,8 @@ func (s *Store) Create(f *Folder) error {
 	query := `
 		INSERT INTO folders (id, account_id, name, path, folder_type, parent_id,
 		                     uid_validity, uid_next, highest_mod_seq,
-		                     total_count, unread_count, last_sync)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		                     total_count, unread_count, last_sync, subscribed)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	var parentID interface{}
@@ -201,7 +203,7 @@ func (s *Store) Create(f *Folder) error {
 	_, err := s.db.Exec(query,
 		f.ID, f.AccountID, f.Name, f.Path, f.Type, parentID,
 		f.UIDValidity, f.UIDNext, f.HighestModSeq,
-		f.TotalCount, f.UnreadCount, lastSync,
+		f.TotalCount, f.UnreadCount, lastSync, f.Subscribed,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create folder: %w", err)
@@ -227,7 +229,8 @@ func (s *Store) Update(f *Folder) error {
 			highest_mod_seq = ?,
 			total_count = ?,
 			unread_count = ?,
-			last_sync = ?
+			last_sync = ?,
+			subscribed = ?
 		WHERE id = ?
 	`
 
@@ -244,7 +247,7 @@ func (s *Store) Update(f *Folder) error {
 	_, err := s.db.Exec(query,
 		f.Name, f.Type, parentID,
 		f.UIDValidity, f.UIDNext, f.HighestModSeq,
-		f.TotalCount, f.UnreadCount, lastSync,
+		f.TotalCount, f.UnreadCount, lastSync, f.Subscribed,
 		f.ID,
 	)
 	if err != nil {
@@ -304,6 +307,184 @@ func (s *Store) DeleteByAccount(accountID string) error {
 	return nil
 }
 
+// SetWatched sets whether a folder gets push updates (IDLE/NOTIFY)
+func (s *Store) SetWatched(id string, watched bool) error {
+	_, err := s.db.Exec("UPDATE folders SET watched = ? WHERE id = ?", watched, id)
+	if err != nil {
+		return fmt.Errorf("failed to set folder watched: %w", err)
+	}
+	return nil
+}
+
+// SetKeywordsAllowed records whether the server stores new keywords in a
+// folder (PERMANENTFLAGS \*)
+func (s *Store) SetKeywordsAllowed(id string, allowed bool) error {
+	_, err := s.db.Exec("UPDATE folders SET keywords_allowed = ? WHERE id = ?", allowed, id)
+	if err != nil {
+		return fmt.Errorf("failed to set folder keyword support: %w", err)
+	}
+	return nil
+}
+
+// KeywordsAllowed reports whether the server stores new keywords in a folder.
+// True until a sync has said otherwise.
+func (s *Store) KeywordsAllowed(id string) (bool, error) {
+	var allowed bool
+	err := s.db.QueryRow("SELECT keywords_allowed FROM folders WHERE id = ?", id).Scan(&allowed)
+	if err != nil {
+		return false, fmt.Errorf("failed to get folder keyword support: %w", err)
+	}
+	return allowed, nil
+}
+
+// SetRemoteID records the server-side ID of a folder (a JMAP Mailbox id)
+func (s *Store) SetRemoteID(id, remoteID string) error {
+	_, err := s.db.Exec("UPDATE folders SET remote_id = ? WHERE id = ?", remoteID, id)
+	if err != nil {
+		return fmt.Errorf("failed to set folder remote ID: %w", err)
+	}
+	return nil
+}
+
+// GetRemoteID returns the server-side ID of a folder, or "" if it has none
+func (s *Store) GetRemoteID(id string) (string, error) {
+	var remoteID sql.NullString
+	err := s.db.QueryRow("SELECT remote_id FROM folders WHERE id = ?", id).Scan(&remoteID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get folder remote ID: %w", err)
+	}
+	return remoteID.String, nil
+}
+
+// ListRemoteIDs returns the server-side IDs of an account's folders, keyed
+// by folder ID. Folders without one are omitted.
+func (s *Store) ListRemoteIDs(accountID string) (map[string]string, error) {
+	rows, err := s.db.Query(
+		"SELECT id, remote_id FROM folders WHERE account_id = ? AND remote_id IS NOT NULL",
+		accountID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folder remote IDs: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]string)
+	for rows.Next() {
+		var id, remoteID string
+		if err := rows.Scan(&id, &remoteID); err != nil {
+			return nil, fmt.Errorf("failed to scan folder remote ID: %w", err)
+		}
+		ids[id] = remoteID
+	}
+	return ids, rows.Err()
+}
+
+// ListWatchedPaths returns the paths of folders watched for push updates.
+// INBOX is always watched and isn't included unless explicitly flagged.
+func (s *Store) ListWatchedPaths(accountID string) ([]string, error) {
+	rows, err := s.db.Query(
+		"SELECT path FROM folders WHERE account_id = ? AND watched = 1 ORDER BY name",
+		accountID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query watched folders: %w", err)
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, fmt.Errorf("failed to scan watched folder: %w", err)
+		}
+		paths = append(paths, path)
+	}
+
+	return paths, rows.Err()
+}
+
+// SetSubscribed sets whether a folder is subscribed on the server
+func (s *Store) SetSubscribed(id string, subscribed bool) error {
+	_, err := s.db.Exec("UPDATE folders SET subscribed = ? WHERE id = ?", subscribed, id)
+	if err != nil {
+		return fmt.Errorf("failed to set folder subscribed: %w", err)
+	}
+	return nil
+}
+
+// ListDescendants returns all folders below the given path, deepest first
+func (s *Store) ListDescendants(accountID, path, delimiter string) ([]*Folder, error) {
+	if delimiter == "" {
+		return nil, nil
+	}
+
+	folders, err := s.List(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := path + delimiter
+	var descendants []*Folder
+	for _, f := range folders {
+		if strings.HasPrefix(f.Path, prefix) {
+			descendants = append(descendants, f)
+		}
+	}
+
+	sort.SliceStable(descendants, func(i, j int) bool {
+		return strings.Count(descendants[i].Path, delimiter) > strings.Count(descendants[j].Path, delimiter)
+	})
+	return descendants, nil
+}
+
+// Rename moves a folder to a new path and parent, rewriting the paths of its
+// descendants to match. Folder IDs are kept, so messages stay attached.
+func (s *Store) Rename(id, newName, newPath, newParentID, delimiter string) error {
+	f, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	if f == nil {
+		return fmt.Errorf("folder not found: %s", id)
+	}
+
+	descendants, err := s.ListDescendants(f.AccountID, f.Path, delimiter)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var parentID interface{}
+	if newParentID != "" {
+		parentID = newParentID
+	}
+
+	_, err = tx.Exec(
+		"UPDATE folders SET name = ?, path = ?, parent_id = ? WHERE id = ?",
+		newName, newPath, parentID, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to rename folder: %w", err)
+	}
+
+	for _, d := range descendants {
+		_, err = tx.Exec(
+			"UPDATE folders SET path = ? WHERE id = ?",
+			newPath+strings.TrimPrefix(d.Path, f.Path), d.ID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to rename subfolder: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
 // Upsert creates or updates a folder based on account_id and path
 func (s *Store) Upsert(f *Folder) error {
 	existing, err := s.GetByPath(f.AccountID, f.Path)
@@ -326,7 +507,7 @@ func (s *Store) GetByType(accountID string, folderType Type) (*Folder, error) {
 	query := `
 		SELECT id, account_id, name, path, folder_type, parent_id,
 		       uid_validity, uid_next, highest_mod_seq,
-		       total_count, unread_count, last_sync
+		       total_count, unread_count, last_sync, watched, subscribed
 		FROM folders
 		WHERE account_id = ? AND folder_type = ?
 		LIMIT 1
@@ -341,7 +522,7 @@ func (s *Store) GetByType(accountID string, folderType Type) (*Folder, error) {
 	err := s.db.QueryRow(query, accountID, folderType).Scan(
 		&f.ID, &f.AccountID, &f.Name, &f.Path, &f.Type, &parentID,
 		&uidValidity, &uidNext, &highestModSeq,
-		&f.TotalCount, &f.UnreadCount, &lastSync,
+		&f.TotalCount, &f.UnreadCount, &lastSync, &f.Watched, &f.Subscribed,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
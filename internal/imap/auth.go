@@ -0,0 +1,37 @@
+package imap
+
+import (
+	"crypto/tls"
+
+	"github.com/emersion/go-sasl"
+	"github.com/hkdb/aerion/internal/auth"
+)
+
+// saslCredentials returns what a config can log in with
+func saslCredentials(cfg *ClientConfig) auth.Credentials {
+	creds := auth.Credentials{
+		Username:   cfg.Username,
+		Host:       cfg.Host,
+		Port:       cfg.Port,
+		ClientCert: auth.HasClientCert(cfg.TLSConfig),
+	}
+	if cfg.AuthType == AuthTypeOAuth2 {
+		creds.AccessToken = cfg.AccessToken
+	} else {
+		creds.Password = cfg.Password
+	}
+	return creds
+}
+
+// saslClientFor returns the SASL client to log in with, or nil when the
+// account doesn't pin a mechanism and the server offers nothing better
+// than LOGIN (or XOAUTH2 for OAuth accounts)
+func saslClientFor(cfg *ClientConfig, advertised []string, tlsState *tls.ConnectionState) (auth.Mechanism, sasl.Client, error) {
+	creds := saslCredentials(cfg)
+	mech, err := auth.Choose(cfg.AuthMechanism, advertised, creds, tlsState)
+	if err != nil || mech == auth.MechanismAuto {
+		return mech, nil, err
+	}
+	client, err := auth.NewClient(mech, advertised, creds, tlsState)
+	return mech, client, err
+}
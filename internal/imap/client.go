@@ -2,15 +2,23 @@
 package imap
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/emersion/go-imap/v2"
 	"github.com/emersion/go-imap/v2/imapclient"
 	"github.com/emersion/go-sasl"
+	"github.com/hkdb/aerion/internal/auth"
 	"github.com/hkdb/aerion/internal/logging"
 	"github.com/rs/zerolog"
 )
@@ -65,13 +73,27 @@ type ClientConfig struct {
 	AuthType    AuthType // "password" or "oauth2" (defaults to "password")
 	AccessToken string   // OAuth2 access token (when AuthType is "oauth2")
 
+	// AuthMechanism pins the SASL mechanism; empty picks the strongest the
+	// server offers. Channel binding (-PLUS) needs implicit TLS, since
+	// go-imap doesn't expose the connection it upgrades for STARTTLS.
+	AuthMechanism auth.Mechanism
+
 	// Timeouts
 	ConnectTimeout time.Duration
 	ReadTimeout    time.Duration
 	WriteTimeout   time.Duration
 
-	// TLS config (optional, used for certificate TOFU verification)
+	// TLS config (optional, used for certificate TOFU verification and to
+	// present a client certificate)
 	TLSConfig *tls.Config
+
+	// Compress negotiates COMPRESS=DEFLATE after login when the server
+	// supports it. Not available over STARTTLS, where go-imap owns the
+	// TLS upgrade and the stream can't be wrapped above it.
+	Compress bool
+
+	// Traffic receives byte counts for this connection (optional)
+	Traffic *TrafficCounters
 }
 
 // DefaultConfig returns a ClientConfig with sensible defaults
@@ -89,8 +111,12 @@ func DefaultConfig() ClientConfig {
 type Client struct {
 	config ClientConfig
 	client *imapclient.Client
+	conn   *compressConn // nil for STARTTLS connections
 	caps   imap.CapSet
+	tls    *tls.ConnectionState // nil unless we did the TLS handshake ourselves
 	log    zerolog.Logger
+
+	permFlags permanentFlagsTap // Tells a missing PERMANENTFLAGS from an empty one
 }
 
 // NewClient creates a new IMAP client but does not connect
@@ -103,7 +129,7 @@ func NewClient(config ClientConfig) *Client {
 
 // Connect establishes a connection to the IMAP server and logs in
 func (c *Client) Connect() error {
-	addr := fmt.Sprintf("%s:%d", c.config.Host, c.config.Port)
+	addr := net.JoinHostPort(c.config.Host, strconv.Itoa(c.config.Port))
 
 	c.log.Debug().
 		Str("host", c.config.Host).
@@ -114,7 +140,7 @@ func (c *Client) Connect() error {
 		Msg("Connecting to IMAP server")
 
 	var err error
-	options := &imapclient.Options{}
+	options := &imapclient.Options{DebugWriter: &c.permFlags}
 
 	// Create a dialer with connect timeout
 	dialer := &net.Dialer{
@@ -133,6 +159,8 @@ func (c *Client) Connect() error {
 		if dialErr != nil {
 			return fmt.Errorf("failed to connect with TLS: %w", dialErr)
 		}
+		state := rawConn.ConnectionState()
+		c.tls = &state
 
 		// Wrap with deadline connection for read/write timeouts
 		wrappedConn := &deadlineConn{
@@ -141,7 +169,8 @@ func (c *Client) Connect() error {
 			writeTimeout: c.config.WriteTimeout,
 		}
 
-		c.client = imapclient.New(wrappedConn, options)
+		c.conn = newCompressConn(wrappedConn, c.config.Traffic)
+		c.client = imapclient.New(c.conn, options)
 
 	case SecurityStartTLS:
 		// Connect plain first, then upgrade (port 143)
@@ -168,7 +197,8 @@ func (c *Client) Connect() error {
 			writeTimeout: c.config.WriteTimeout,
 		}
 
-		c.client = imapclient.New(wrappedConn, options)
+		c.conn = newCompressConn(wrappedConn, c.config.Traffic)
+		c.client = imapclient.New(c.conn, options)
 	}
 
 	// Wait for server greeting
@@ -218,9 +248,21 @@ func (c *Client) Login() error {
 		Str("authType", string(authType)).
 		Msg("Logging in")
 
-	var err error
-	switch authType {
-	case AuthTypeOAuth2:
+	mech, saslClient, err := saslClientFor(&c.config, c.caps.AuthMechanisms(), c.tls)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case saslClient != nil:
+		c.log.Debug().Str("mechanism", string(mech)).Msg("Authenticating with SASL")
+		if err := c.client.Authenticate(saslClient); err != nil {
+			return fmt.Errorf("%s authentication failed: %w", mech, err)
+		}
+		if err := auth.Verified(saslClient); err != nil {
+			return fmt.Errorf("%s authentication failed: %w", mech, err)
+		}
+	case authType == AuthTypeOAuth2:
 		err = c.loginOAuth2()
 	default:
 		err = c.loginPassword()
@@ -237,6 +279,36 @@ func (c *Client) Login() error {
 		Str("username", c.config.Username).
 		Msg("Logged in successfully")
 
+	if c.config.Compress {
+		if err := c.enableCompression(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// enableCompression negotiates COMPRESS=DEFLATE if the server supports it.
+// Servers without it (or refusing it) are used uncompressed.
+func (c *Client) enableCompression() error {
+	if c.conn == nil {
+		c.log.Debug().Msg("COMPRESS not available over STARTTLS, continuing uncompressed")
+		return nil
+	}
+	if !c.caps.Has(CapCompressDeflate) {
+		c.log.Debug().Msg("Server does not support COMPRESS=DEFLATE")
+		return nil
+	}
+
+	if err := c.conn.startCompression(); err != nil {
+		if errors.Is(err, errCommandRejected) {
+			c.log.Warn().Err(err).Msg("Server refused COMPRESS, continuing uncompressed")
+			return nil
+		}
+		return fmt.Errorf("failed to enable compression: %w", err)
+	}
+
+	c.log.Debug().Msg("COMPRESS=DEFLATE enabled")
 	return nil
 }
 
@@ -349,6 +421,7 @@ type Mailbox struct {
 	Delimiter  string
 	Attributes []string
 	Type       FolderType
+	Subscribed bool // Always true when the server can't report subscriptions
 
 	// Status info (populated by Status or Select)
 	UIDValidity   uint32
@@ -356,6 +429,10 @@ type Mailbox struct {
 	Messages      uint32
 	Unseen        uint32
 	HighestModSeq uint64
+
+	// AllowsKeywords is set by Select when new keywords can be stored
+	// permanently: PERMANENTFLAGS includes \* or wasn't sent at all
+	AllowsKeywords bool
 }
 
 // FolderType represents the type of folder
@@ -381,8 +458,14 @@ func (c *Client) ListMailboxes() ([]*Mailbox, error) {
 
 	c.log.Debug().Msg("Listing mailboxes")
 
-	// List all mailboxes
-	listCmd := c.client.List("", "*", nil)
+	// List all mailboxes, asking for subscription state when the server
+	// supports LIST-EXTENDED
+	var listOptions *imap.ListOptions
+	reportsSubscribed := c.caps.Has(imap.CapListExtended)
+	if reportsSubscribed {
+		listOptions = &imap.ListOptions{ReturnSubscribed: true}
+	}
+	listCmd := c.client.List("", "*", listOptions)
 
 	var mailboxes []*Mailbox
 	for {
@@ -400,6 +483,7 @@ func (c *Client) ListMailboxes() ([]*Mailbox, error) {
 		for i, attr := range mbox.Attrs {
 			mb.Attributes[i] = string(attr)
 		}
+		mb.Subscribed = !reportsSubscribed || hasMailboxAttr(mbox.Attrs, imap.MailboxAttrSubscribed)
 
 		// Determine folder type from attributes
 		mb.Type = determineFolderType(mbox.Mailbox, mbox.Attrs)
@@ -528,6 +612,16 @@ func hasSpecialUseAttr(attrs []string) bool {
 	return false
 }
 
+// hasMailboxAttr checks if attrs contains attr (case-insensitive)
+func hasMailboxAttr(attrs []imap.MailboxAttr, attr imap.MailboxAttr) bool {
+	for _, a := range attrs {
+		if strings.EqualFold(string(a), string(attr)) {
+			return true
+		}
+	}
+	return false
+}
+
 // SelectMailbox selects a mailbox and returns its status.
 // Uses a goroutine to allow context cancellation since Wait() blocks indefinitely.
 func (c *Client) SelectMailbox(ctx context.Context, name string) (*Mailbox, error) {
@@ -542,18 +636,28 @@ func (c *Client) SelectMailbox(ctx context.Context, name string) (*Mailbox, erro
 		data *imap.SelectData
 		err  error
 	}
+	// Request CONDSTORE on SELECT so the server reports HIGHESTMODSEQ and
+	// includes MODSEQ in FETCH responses (RFC 7162)
+	var options *imap.SelectOptions
+	if c.SupportsCondStore() {
+		options = &imap.SelectOptions{CondStore: true}
+	}
+
 	resultCh := make(chan selectResult, 1)
+	c.permFlags.start()
 	go func() {
-		data, err := c.client.Select(name, nil).Wait()
+		data, err := c.client.Select(name, options).Wait()
 		resultCh <- selectResult{data, err}
 	}()
 
 	// Wait for either result or context cancellation
 	select {
 	case <-ctx.Done():
+		c.permFlags.stop()
 		c.log.Debug().Str("mailbox", name).Msg("Select cancelled by context")
 		return nil, ctx.Err()
 	case result := <-resultCh:
+		sentPermanentFlags := c.permFlags.stop()
 		if result.err != nil {
 			return nil, fmt.Errorf("failed to select mailbox: %w", result.err)
 		}
@@ -570,6 +674,15 @@ func (c *Client) SelectMailbox(ctx context.Context, name string) (*Mailbox, erro
 			mb.HighestModSeq = result.data.HighestModSeq
 		}
 
+		// Without PERMANENTFLAGS all flags are permanent (RFC 3501 7.1),
+		// but an empty list, which go-imap also reports as nil, allows none
+		mb.AllowsKeywords = result.data.PermanentFlags == nil && !sentPermanentFlags
+		for _, flag := range result.data.PermanentFlags {
+			if flag == imap.FlagWildcard {
+				mb.AllowsKeywords = true
+			}
+		}
+
 		c.log.Debug().
 			Str("mailbox", name).
 			Uint32("messages", result.data.NumMessages).
@@ -645,6 +758,95 @@ func (c *Client) GetMailboxStatus(ctx context.Context, name string) (*Mailbox, e
 	}
 }
 
+// GetDelimiter returns the server's hierarchy delimiter (empty for flat
+// namespaces)
+func (c *Client) GetDelimiter() (string, error) {
+	if c.client == nil {
+		return "", fmt.Errorf("not connected")
+	}
+
+	// LIST "" "" returns the delimiter and root without listing mailboxes
+	mailboxes, err := c.client.List("", "", nil).Collect()
+	if err != nil {
+		return "", fmt.Errorf("failed to get hierarchy delimiter: %w", err)
+	}
+	for _, mbox := range mailboxes {
+		if mbox.Delim != 0 {
+			return string(mbox.Delim), nil
+		}
+	}
+	return "", nil
+}
+
+// CreateMailbox creates a mailbox
+func (c *Client) CreateMailbox(name string) error {
+	if c.client == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	c.log.Debug().Str("mailbox", name).Msg("Creating mailbox")
+
+	if err := c.client.Create(name, nil).Wait(); err != nil {
+		return fmt.Errorf("failed to create mailbox: %w", err)
+	}
+	return nil
+}
+
+// RenameMailbox renames a mailbox. The server renames its children too.
+func (c *Client) RenameMailbox(name, newName string) error {
+	if c.client == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	c.log.Debug().Str("mailbox", name).Str("newName", newName).Msg("Renaming mailbox")
+
+	if err := c.client.Rename(name, newName, nil).Wait(); err != nil {
+		return fmt.Errorf("failed to rename mailbox: %w", err)
+	}
+	return nil
+}
+
+// DeleteMailbox deletes a mailbox. Children are not deleted by the server.
+func (c *Client) DeleteMailbox(name string) error {
+	if c.client == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	c.log.Debug().Str("mailbox", name).Msg("Deleting mailbox")
+
+	if err := c.client.Delete(name).Wait(); err != nil {
+		return fmt.Errorf("failed to delete mailbox: %w", err)
+	}
+	return nil
+}
+
+// IsNonExistent reports whether the server refused a command because the
+// mailbox doesn't exist (RFC 5530 NONEXISTENT)
+func IsNonExistent(err error) bool {
+	var imapErr *imap.Error
+	return errors.As(err, &imapErr) && imapErr.Code == imap.ResponseCodeNonExistent
+}
+
+// SetSubscribed subscribes to or unsubscribes from a mailbox
+func (c *Client) SetSubscribed(name string, subscribed bool) error {
+	if c.client == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	c.log.Debug().Str("mailbox", name).Bool("subscribed", subscribed).Msg("Setting mailbox subscription")
+
+	var err error
+	if subscribed {
+		err = c.client.Subscribe(name).Wait()
+	} else {
+		err = c.client.Unsubscribe(name).Wait()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update subscription: %w", err)
+	}
+	return nil
+}
+
 // RawClient returns the underlying imapclient.Client
 // Use with caution - mainly for advanced operations
 func (c *Client) RawClient() *imapclient.Client {
@@ -834,7 +1036,8 @@ func (c *Client) RemoveMessageFlags(uids []imap.UID, flags []imap.Flag) error {
 
 // CopyMessages copies messages to destination mailbox by UID
 // The source mailbox must already be selected before calling this method
-// Returns the new UIDs in the destination mailbox (if server supports UIDPLUS)
+// Returns the new UIDs in the destination mailbox, in the same order as uids
+// (if server supports UIDPLUS, otherwise nil)
 func (c *Client) CopyMessages(uids []imap.UID, destMailbox string) ([]imap.UID, error) {
 	if c.client == nil {
 		return nil, fmt.Errorf("not connected")
@@ -848,6 +1051,22 @@ func (c *Client) CopyMessages(uids []imap.UID, destMailbox string) ([]imap.UID,
 		Str("destMailbox", destMailbox).
 		Msg("Copying messages")
 
+	mapping, err := c.copyMessages(uids, destMailbox)
+	if err != nil {
+		return nil, err
+	}
+
+	c.log.Debug().
+		Int("count", len(uids)).
+		Str("destMailbox", destMailbox).
+		Msg("Messages copied successfully")
+
+	return orderedDestUIDs(uids, mapping), nil
+}
+
+// copyMessages issues UID COPY and returns the source->destination UID mapping
+// from the COPYUID response code (nil if the server doesn't support UIDPLUS)
+func (c *Client) copyMessages(uids []imap.UID, destMailbox string) (map[imap.UID]imap.UID, error) {
 	uidSet := imap.UIDSet{}
 	for _, uid := range uids {
 		uidSet.AddNum(uid)
@@ -858,21 +1077,110 @@ func (c *Client) CopyMessages(uids []imap.UID, destMailbox string) ([]imap.UID,
 	if err != nil {
 		return nil, fmt.Errorf("failed to copy messages: %w", err)
 	}
+	if copyData == nil {
+		return nil, nil
+	}
+
+	return uidMapping(copyData.SourceUIDs, copyData.DestUIDs), nil
+}
+
+// MoveMessages moves messages to destination mailbox by UID
+// The source mailbox must already be selected before calling this method
+// Uses UID MOVE (RFC 6851) when the server advertises MOVE so the operation is
+// atomic; otherwise falls back to COPY + STORE \Deleted + EXPUNGE.
+// Returns the source->destination UID mapping from COPYUID (UIDPLUS). The map
+// is nil if the server didn't report one.
+func (c *Client) MoveMessages(uids []imap.UID, destMailbox string) (map[imap.UID]imap.UID, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+	if len(uids) == 0 {
+		return nil, nil
+	}
+
+	if !c.caps.Has(imap.CapMove) {
+		c.log.Debug().
+			Interface("uids", uidsToUint32s(uids)).
+			Str("destMailbox", destMailbox).
+			Msg("Server lacks MOVE, moving messages with COPY + EXPUNGE")
+
+		mapping, err := c.copyMessages(uids, destMailbox)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.DeleteMessagesByUID(uids); err != nil {
+			return nil, fmt.Errorf("failed to delete messages from source: %w", err)
+		}
+		return mapping, nil
+	}
 
-	// Extract destination UIDs if available (UIDPLUS extension)
-	var destUIDs []imap.UID
-	if copyData != nil && copyData.DestUIDs != nil {
-		// The DestUIDs is a UIDSet - for now we just log that it's available
-		// Full extraction would require iterating the UIDSet ranges
-		c.log.Debug().Msg("Messages copied with UIDPLUS, destination UIDs available")
+	c.log.Debug().
+		Interface("uids", uidsToUint32s(uids)).
+		Str("destMailbox", destMailbox).
+		Msg("Moving messages")
+
+	uidSet := imap.UIDSet{}
+	for _, uid := range uids {
+		uidSet.AddNum(uid)
+	}
+
+	moveData, err := c.client.Move(uidSet, destMailbox).Wait()
+	if err != nil {
+		return nil, fmt.Errorf("failed to move messages: %w", err)
+	}
+
+	var mapping map[imap.UID]imap.UID
+	if moveData != nil {
+		sourceUIDs, srcOK := moveData.SourceUIDs.(imap.UIDSet)
+		destUIDs, destOK := moveData.DestUIDs.(imap.UIDSet)
+		if srcOK && destOK {
+			mapping = uidMapping(sourceUIDs, destUIDs)
+		}
 	}
 
 	c.log.Debug().
 		Int("count", len(uids)).
+		Int("mapped", len(mapping)).
 		Str("destMailbox", destMailbox).
-		Msg("Messages copied successfully")
+		Msg("Messages moved successfully")
+
+	return mapping, nil
+}
+
+// uidMapping pairs the source and destination sets of a COPYUID response code.
+// RFC 4315 orders both sets so the Nth source UID maps to the Nth destination UID.
+func uidMapping(source, dest imap.UIDSet) map[imap.UID]imap.UID {
+	sourceUIDs, ok := source.Nums()
+	if !ok {
+		return nil
+	}
+	destUIDs, ok := dest.Nums()
+	if !ok || len(sourceUIDs) != len(destUIDs) || len(sourceUIDs) == 0 {
+		return nil
+	}
+
+	mapping := make(map[imap.UID]imap.UID, len(sourceUIDs))
+	for i, uid := range sourceUIDs {
+		mapping[uid] = destUIDs[i]
+	}
+	return mapping
+}
 
-	return destUIDs, nil
+// orderedDestUIDs returns the destination UIDs in the order of uids,
+// or nil if the mapping doesn't cover every UID
+func orderedDestUIDs(uids []imap.UID, mapping map[imap.UID]imap.UID) []imap.UID {
+	if len(mapping) == 0 {
+		return nil
+	}
+	destUIDs := make([]imap.UID, len(uids))
+	for i, uid := range uids {
+		destUID, ok := mapping[uid]
+		if !ok {
+			return nil
+		}
+		destUIDs[i] = destUID
+	}
+	return destUIDs
 }
 
 // DeleteMessagesByUID marks multiple messages as deleted and expunges them
@@ -931,6 +1239,165 @@ func (c *Client) DeleteMessagesByUID(uids []imap.UID) error {
 	return nil
 }
 
+// ExistingUIDs returns the subset of uids still present in the selected
+// mailbox, in the order given. Used to detect messages that were expunged
+// by another client before a queued operation could run.
+// The mailbox must already be selected before calling this method
+func (c *Client) ExistingUIDs(uids []imap.UID) ([]imap.UID, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+	if len(uids) == 0 {
+		return nil, nil
+	}
+
+	uidSet := imap.UIDSet{}
+	for _, uid := range uids {
+		uidSet.AddNum(uid)
+	}
+
+	data, err := c.client.UIDSearch(&imap.SearchCriteria{
+		UID: []imap.UIDSet{uidSet},
+	}, nil).Wait()
+	if err != nil {
+		return nil, fmt.Errorf("UID search failed: %w", err)
+	}
+
+	found := make(map[imap.UID]bool)
+	for _, uid := range data.AllUIDs() {
+		found[uid] = true
+	}
+
+	existing := make([]imap.UID, 0, len(uids))
+	for _, uid := range uids {
+		if found[uid] {
+			existing = append(existing, uid)
+		}
+	}
+	return existing, nil
+}
+
+// FindByMessageID returns the UIDs of messages in the selected mailbox whose
+// Message-ID header contains messageID (given without angle brackets)
+// The mailbox must already be selected before calling this method
+func (c *Client) FindByMessageID(messageID string) ([]imap.UID, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	data, err := c.client.UIDSearch(&imap.SearchCriteria{
+		Header: []imap.SearchCriteriaHeaderField{{Key: "Message-ID", Value: messageID}},
+	}, nil).Wait()
+	if err != nil {
+		return nil, fmt.Errorf("UID search failed: %w", err)
+	}
+	return data.AllUIDs(), nil
+}
+
+// FetchHeaders returns the parsed header block of each message, keyed by UID.
+// Messages that no longer exist are omitted from the result.
+// The mailbox must already be selected before calling this method
+func (c *Client) FetchHeaders(uids []imap.UID) (map[imap.UID]textproto.MIMEHeader, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+	if len(uids) == 0 {
+		return nil, nil
+	}
+
+	sections, err := c.fetchBodySection(uids, imap.PartSpecifierHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[imap.UID]textproto.MIMEHeader, len(sections))
+	for uid, raw := range sections {
+		r := textproto.NewReader(bufio.NewReader(bytes.NewReader(raw)))
+		header, err := r.ReadMIMEHeader()
+		if err != nil && len(header) == 0 {
+			c.log.Warn().Err(err).Uint32("uid", uint32(uid)).Msg("Failed to parse message header")
+			continue
+		}
+		headers[uid] = header
+	}
+	return headers, nil
+}
+
+// FetchRawMessage returns the full RFC 822 source of a message without
+// setting \Seen.
+// The mailbox must already be selected before calling this method
+func (c *Client) FetchRawMessage(uid imap.UID) ([]byte, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	sections, err := c.fetchBodySection([]imap.UID{uid}, imap.PartSpecifierNone)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, ok := sections[uid]
+	if !ok {
+		return nil, fmt.Errorf("message not found on server")
+	}
+	return raw, nil
+}
+
+// fetchBodySection peeks a body section of each message, keyed by UID
+func (c *Client) fetchBodySection(uids []imap.UID, specifier imap.PartSpecifier) (map[imap.UID][]byte, error) {
+	uidSet := imap.UIDSet{}
+	for _, uid := range uids {
+		uidSet.AddNum(uid)
+	}
+
+	fetchCmd := c.client.Fetch(uidSet, &imap.FetchOptions{
+		UID: true,
+		BodySection: []*imap.FetchItemBodySection{
+			{Specifier: specifier, Peek: true},
+		},
+	})
+
+	results := make(map[imap.UID][]byte, len(uids))
+	for {
+		msg := fetchCmd.Next()
+		if msg == nil {
+			break
+		}
+
+		var uid imap.UID
+		var raw []byte
+		for {
+			item := msg.Next()
+			if item == nil {
+				break
+			}
+
+			switch data := item.(type) {
+			case imapclient.FetchItemDataUID:
+				uid = data.UID
+			case imapclient.FetchItemDataBodySection:
+				if data.Literal != nil {
+					b, err := io.ReadAll(data.Literal)
+					if err != nil {
+						fetchCmd.Close()
+						return nil, fmt.Errorf("failed to read message data: %w", err)
+					}
+					raw = b
+				}
+			}
+		}
+
+		if uid != 0 && raw != nil {
+			results[uid] = raw
+		}
+	}
+
+	if err := fetchCmd.Close(); err != nil {
+		return nil, fmt.Errorf("fetch failed: %w", err)
+	}
+	return results, nil
+}
+
 // uidsToUint32s converts a slice of imap.UID to uint32 for logging
 func uidsToUint32s(uids []imap.UID) []uint32 {
 	result := make([]uint32, len(uids))
@@ -0,0 +1,272 @@
+package imap
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/emersion/go-imap/v2"
+)
+
+// COMPRESS=DEFLATE (RFC 4978) support.
+//
+// go-imap's client has no COMPRESS command and no way to swap the stream it
+// reads from, so compression is handled one layer down: compressConn sits
+// between imapclient and the socket. To negotiate, it writes the COMPRESS
+// command itself and picks the tagged response out of the incoming stream
+// before imapclient sees it. Once the server answers OK, both directions
+// switch to raw DEFLATE.
+
+// CapCompressDeflate is the capability advertised by servers supporting RFC 4978
+const CapCompressDeflate imap.Cap = "COMPRESS=DEFLATE"
+
+const (
+	// compressTag tags our COMPRESS command. imapclient numbers its own
+	// tags "T1", "T2", ... so this can't collide.
+	compressTag = "Z1"
+
+	// compressTimeout bounds how long we wait for the COMPRESS response
+	compressTimeout = 30 * time.Second
+)
+
+// errCompressTimeout is returned when the server never answers COMPRESS. The
+// stream state is unknown at that point, so the connection must be dropped.
+var errCompressTimeout = errors.New("timed out waiting for COMPRESS response")
+
+// TrafficCounters accumulates byte counts across IMAP connections. Wire
+// counts are what crossed the socket; decompressed counts are the IMAP
+// protocol bytes on the other side of the compression layer. The two are
+// equal for connections that didn't negotiate compression.
+type TrafficCounters struct {
+	wireRead          atomic.Int64
+	wireWritten       atomic.Int64
+	decompressedRead  atomic.Int64
+	decompressedWrite atomic.Int64
+	compressed        atomic.Int64
+}
+
+// WireBytesRead returns the bytes read from the network
+func (t *TrafficCounters) WireBytesRead() int64 { return t.wireRead.Load() }
+
+// WireBytesWritten returns the bytes written to the network
+func (t *TrafficCounters) WireBytesWritten() int64 { return t.wireWritten.Load() }
+
+// DecompressedBytesRead returns the IMAP bytes received after decompression
+func (t *TrafficCounters) DecompressedBytesRead() int64 { return t.decompressedRead.Load() }
+
+// DecompressedBytesWritten returns the IMAP bytes sent before compression
+func (t *TrafficCounters) DecompressedBytesWritten() int64 { return t.decompressedWrite.Load() }
+
+// CompressedConnections returns how many connections negotiated compression
+func (t *TrafficCounters) CompressedConnections() int64 { return t.compressed.Load() }
+
+// compressConn wraps a connection, counting traffic and optionally switching
+// to DEFLATE once negotiated
+type compressConn struct {
+	net.Conn
+	counters *TrafficCounters
+
+	// wmu serializes writes, and is held for the whole negotiation so
+	// imapclient can't send anything until the server has answered
+	wmu      sync.Mutex
+	deflater *flate.Writer
+
+	mu       sync.Mutex
+	pending  bool       // COMPRESS sent, tagged response not seen yet
+	partial  []byte     // incomplete line read while pending
+	out      []byte     // plain bytes queued for the reader
+	inflater io.Reader  // set once compression is active
+	result   chan error // receives the outcome of negotiation
+}
+
+// newCompressConn wraps conn. counters may be nil.
+func newCompressConn(conn net.Conn, counters *TrafficCounters) *compressConn {
+	if counters == nil {
+		counters = &TrafficCounters{}
+	}
+	return &compressConn{
+		Conn:     conn,
+		counters: counters,
+	}
+}
+
+// Read returns decompressed IMAP data
+func (c *compressConn) Read(p []byte) (int, error) {
+	for {
+		c.mu.Lock()
+		if len(c.out) > 0 {
+			n := copy(p, c.out)
+			c.out = c.out[n:]
+			c.mu.Unlock()
+			c.counters.decompressedRead.Add(int64(n))
+			return n, nil
+		}
+		inflater := c.inflater
+		c.mu.Unlock()
+
+		if inflater != nil {
+			n, err := inflater.Read(p)
+			c.counters.decompressedRead.Add(int64(n))
+			return n, err
+		}
+
+		n, err := c.Conn.Read(p)
+		c.counters.wireRead.Add(int64(n))
+
+		// The pending check must come after the read returns: the server's
+		// answer may arrive while imapclient is already blocked in Read.
+		c.mu.Lock()
+		pending := c.pending
+		c.mu.Unlock()
+		if !pending || n == 0 {
+			c.counters.decompressedRead.Add(int64(n))
+			return n, err
+		}
+
+		c.scanPending(p[:n])
+		if err != nil {
+			c.failPending(err)
+			return 0, err
+		}
+	}
+}
+
+// scanPending looks for the tagged COMPRESS response in data read while
+// negotiation is pending. Other lines are queued for imapclient as-is; bytes
+// following an OK are the start of the compressed stream.
+func (c *compressConn) scanPending(data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.partial = append(c.partial, data...)
+	for {
+		i := bytes.IndexByte(c.partial, '\n')
+		if i < 0 {
+			return
+		}
+		line := c.partial[:i+1]
+		rest := c.partial[i+1:]
+
+		if !bytes.HasPrefix(line, []byte(compressTag+" ")) {
+			c.out = append(c.out, line...)
+			c.partial = rest
+			continue
+		}
+
+		c.pending = false
+		c.partial = nil
+		if !isTaggedOK(line, compressTag) {
+			// Still uncompressed; hand anything after the response through
+			c.out = append(c.out, rest...)
+			c.result <- fmt.Errorf("%w: %s", errCommandRejected, bytes.TrimSpace(line[len(compressTag)+1:]))
+			return
+		}
+
+		remaining := append([]byte(nil), rest...)
+		c.inflater = flate.NewReader(io.MultiReader(
+			bytes.NewReader(remaining),
+			&countingReader{r: c.Conn, n: &c.counters.wireRead},
+		))
+		c.result <- nil
+		return
+	}
+}
+
+// failPending aborts a pending negotiation after a read error
+func (c *compressConn) failPending(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pending {
+		c.pending = false
+		c.result <- err
+	}
+}
+
+// Write compresses p (once compression is active) and flushes it, so every
+// command reaches the server immediately
+func (c *compressConn) Write(p []byte) (int, error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	if c.deflater == nil {
+		n, err := c.Conn.Write(p)
+		c.counters.wireWritten.Add(int64(n))
+		c.counters.decompressedWrite.Add(int64(n))
+		return n, err
+	}
+
+	n, err := c.deflater.Write(p)
+	if err == nil {
+		err = c.deflater.Flush()
+	}
+	c.counters.decompressedWrite.Add(int64(n))
+	return n, err
+}
+
+// startCompression sends COMPRESS DEFLATE and waits for the server's answer.
+// It must only be called while the connection is idle (no command in flight).
+// A rejected command leaves the connection usable without compression; any
+// other error leaves it in an unknown state.
+func (c *compressConn) startCompression() error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	result := make(chan error, 1)
+	c.mu.Lock()
+	c.pending = true
+	c.result = result
+	c.mu.Unlock()
+
+	cmd := compressTag + " COMPRESS DEFLATE\r\n"
+	n, err := c.Conn.Write([]byte(cmd))
+	c.counters.wireWritten.Add(int64(n))
+	c.counters.decompressedWrite.Add(int64(n))
+	if err != nil {
+		c.failPending(err)
+		return fmt.Errorf("failed to send COMPRESS: %w", err)
+	}
+
+	select {
+	case err := <-result:
+		if err != nil {
+			return err
+		}
+	case <-time.After(compressTimeout):
+		return errCompressTimeout
+	}
+
+	// Errors from the compressor surface on Write
+	c.deflater, _ = flate.NewWriter(&countingWriter{w: c.Conn, n: &c.counters.wireWritten}, flate.DefaultCompression)
+	c.counters.compressed.Add(1)
+	return nil
+}
+
+// countingReader counts bytes read from r
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n.Add(int64(n))
+	return n, err
+}
+
+// countingWriter counts bytes written to w
+type countingWriter struct {
+	w io.Writer
+	n *atomic.Int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,356 @@
+package imap
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+
+	"github.com/emersion/go-imap/v2"
+)
+
+// Gmail's IMAP extensions (X-GM-MSGID, X-GM-THRID, X-GM-LABELS) add FETCH and
+// STORE items go-imap's client can neither request nor parse. GmailConn runs
+// them over a side connection built on the same minimal protocol code NOTIFY
+// uses.
+// See: https://developers.google.com/gmail/imap/imap-extensions
+
+// CapGmailExt is advertised by servers supporting Gmail's IMAP extensions
+const CapGmailExt imap.Cap = "X-GM-EXT-1"
+
+// gmailFetchBatchSize keeps each FETCH well within the command timeout
+const gmailFetchBatchSize = 1000
+
+// GmailAttrs are Gmail's per-message attributes
+type GmailAttrs struct {
+	MsgID    uint64   // Stable across all labels (folders) the message appears in
+	ThreadID uint64   // Gmail's conversation ID
+	Labels   []string // Labels other than the one being viewed
+}
+
+// GmailConn is a connection for Gmail extension commands
+type GmailConn struct {
+	conn *notifyConn
+}
+
+// dialGmail opens a side connection for Gmail extension commands
+func dialGmail(config *ClientConfig) (*GmailConn, error) {
+	conn, err := dialNotifyConn(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.authenticate(config); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &GmailConn{conn: conn}, nil
+}
+
+// gmailSide is an account's shared Gmail side connection. mu serializes its
+// users, since each selects its own mailbox.
+type gmailSide struct {
+	mu       sync.Mutex
+	conn     *GmailConn
+	lastUsed time.Time
+}
+
+// close logs out of the connection, if open. The caller holds mu.
+func (s *gmailSide) close() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// WithGmail runs fn on the account's Gmail side connection. The account's
+// server must advertise CapGmailExt. The connection is opened on first use
+// and kept until it idles out or the pool closes the account, so syncs don't
+// pay for a new login each time; a stale one is replaced and fn retried.
+func (p *Pool) WithGmail(accountID string, fn func(gm *GmailConn) error) error {
+	p.mu.Lock()
+	side := p.gmail[accountID]
+	if side == nil {
+		side = &gmailSide{}
+		p.gmail[accountID] = side
+	}
+	p.mu.Unlock()
+
+	side.mu.Lock()
+	defer side.mu.Unlock()
+	defer func() { side.lastUsed = time.Now() }()
+
+	reused := side.conn != nil
+	for {
+		if side.conn == nil {
+			config, err := p.getCredentials(accountID)
+			if err != nil {
+				return fmt.Errorf("failed to get credentials: %w", err)
+			}
+			gm, err := dialGmail(config)
+			if err != nil {
+				return err
+			}
+			side.conn = gm
+		}
+
+		err := fn(side.conn)
+		if err == nil || errors.Is(err, errCommandRejected) {
+			return err
+		}
+		// Anything else leaves the connection in an unknown state
+		side.close()
+		if !reused || !IsConnectionError(err) {
+			return err
+		}
+		p.log.Debug().Err(err).Str("account", accountID).Msg("Gmail side connection went stale, reconnecting")
+		reused = false
+	}
+}
+
+// closeGmail closes an account's Gmail side connection once its current
+// user is done. The caller holds p.mu.
+func (p *Pool) closeGmail(accountID string) {
+	side, ok := p.gmail[accountID]
+	if !ok {
+		return
+	}
+	delete(p.gmail, accountID)
+	go func() {
+		side.mu.Lock()
+		defer side.mu.Unlock()
+		side.close()
+	}()
+}
+
+// Select selects a mailbox for subsequent commands
+func (g *GmailConn) Select(mailbox string) error {
+	if err := g.conn.command("SELECT "+quoteIMAPString(encodeMailboxUTF7(mailbox)), nil); err != nil {
+		return fmt.Errorf("failed to select mailbox: %w", err)
+	}
+	return nil
+}
+
+// FetchAttrs returns the Gmail attributes of messages in the selected
+// mailbox, keyed by UID. With changedSince > 0 only messages whose metadata
+// changed after that mod-sequence are returned (CONDSTORE).
+func (g *GmailConn) FetchAttrs(uids []uint32, changedSince uint64) (map[uint32]*GmailAttrs, error) {
+	results := make(map[uint32]*GmailAttrs, len(uids))
+	for start := 0; start < len(uids); start += gmailFetchBatchSize {
+		end := min(start+gmailFetchBatchSize, len(uids))
+		cmd := "UID FETCH " + uidSetString(uids[start:end]) + " (X-GM-MSGID X-GM-THRID X-GM-LABELS)"
+		if changedSince > 0 {
+			cmd += " (CHANGEDSINCE " + strconv.FormatUint(changedSince, 10) + ")"
+		}
+
+		err := g.conn.command(cmd, func(resp []byte) {
+			uid, attrs, ok := parseGmailFetch(resp)
+			if ok {
+				results[uid] = attrs
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch Gmail attributes: %w", err)
+		}
+	}
+	return results, nil
+}
+
+// StoreLabels adds labels to (or removes them from) messages in the selected
+// mailbox
+func (g *GmailConn) StoreLabels(uids []uint32, labels []string, add bool) error {
+	if len(uids) == 0 || len(labels) == 0 {
+		return nil
+	}
+
+	op := "-X-GM-LABELS.SILENT"
+	if add {
+		op = "+X-GM-LABELS.SILENT"
+	}
+
+	encoded := make([]string, len(labels))
+	for i, label := range labels {
+		// System labels (\Inbox, \Starred, ...) are atoms
+		if strings.HasPrefix(label, `\`) && !strings.ContainsAny(label, " ()\"") {
+			encoded[i] = label
+		} else {
+			encoded[i] = quoteIMAPString(encodeMailboxUTF7(label))
+		}
+	}
+
+	cmd := "UID STORE " + uidSetString(uids) + " " + op + " (" + strings.Join(encoded, " ") + ")"
+	if err := g.conn.command(cmd, nil); err != nil {
+		return fmt.Errorf("failed to store labels: %w", err)
+	}
+	return nil
+}
+
+// Close logs out and closes the connection
+func (g *GmailConn) Close() {
+	g.conn.Logout()
+}
+
+// uidSetString formats UIDs as an IMAP sequence set
+func uidSetString(uids []uint32) string {
+	set := imap.UIDSet{}
+	for _, uid := range uids {
+		set.AddNum(imap.UID(uid))
+	}
+	return set.String()
+}
+
+// parseGmailFetch parses "* n FETCH (UID u X-GM-MSGID m X-GM-THRID t
+// X-GM-LABELS (...))"
+func parseGmailFetch(resp []byte) (uint32, *GmailAttrs, bool) {
+	fields := bytes.SplitN(resp, []byte(" "), 4)
+	if len(fields) < 4 || string(fields[0]) != "*" || !strings.EqualFold(string(fields[2]), "FETCH") {
+		return 0, nil, false
+	}
+
+	items, _, err := parseIMAPList(fields[3])
+	if err != nil {
+		return 0, nil, false
+	}
+
+	var uid uint32
+	attrs := &GmailAttrs{}
+	for i := 0; i+1 < len(items); i += 2 {
+		name, _ := items[i].(string)
+		switch strings.ToUpper(name) {
+		case "UID":
+			if s, ok := items[i+1].(string); ok {
+				v, _ := strconv.ParseUint(s, 10, 32)
+				uid = uint32(v)
+			}
+		case "X-GM-MSGID":
+			if s, ok := items[i+1].(string); ok {
+				attrs.MsgID, _ = strconv.ParseUint(s, 10, 64)
+			}
+		case "X-GM-THRID":
+			if s, ok := items[i+1].(string); ok {
+				attrs.ThreadID, _ = strconv.ParseUint(s, 10, 64)
+			}
+		case "X-GM-LABELS":
+			list, _ := items[i+1].([]interface{})
+			for _, item := range list {
+				if s, ok := item.(string); ok {
+					attrs.Labels = append(attrs.Labels, decodeMailboxUTF7(s))
+				}
+			}
+		}
+	}
+
+	if uid == 0 || attrs.MsgID == 0 {
+		return 0, nil, false
+	}
+	return uid, attrs, true
+}
+
+// parseIMAPList parses a parenthesized list into strings and nested lists.
+// Atoms, numbers, quoted strings and literals all become strings. Returns the
+// unparsed remainder after the closing parenthesis.
+func parseIMAPList(b []byte) ([]interface{}, []byte, error) {
+	if len(b) == 0 || b[0] != '(' {
+		return nil, nil, fmt.Errorf("expected list")
+	}
+	b = b[1:]
+
+	items := []interface{}{}
+	for {
+		b = bytes.TrimLeft(b, " ")
+		if len(b) == 0 {
+			return nil, nil, fmt.Errorf("unterminated list")
+		}
+
+		switch b[0] {
+		case ')':
+			return items, b[1:], nil
+
+		case '(':
+			list, rest, err := parseIMAPList(b)
+			if err != nil {
+				return nil, nil, err
+			}
+			items = append(items, list)
+			b = rest
+
+		case '"':
+			var sb strings.Builder
+			i := 1
+			for ; i < len(b) && b[i] != '"'; i++ {
+				if b[i] == '\\' && i+1 < len(b) {
+					i++
+				}
+				sb.WriteByte(b[i])
+			}
+			if i >= len(b) {
+				return nil, nil, fmt.Errorf("unterminated string")
+			}
+			items = append(items, sb.String())
+			b = b[i+1:]
+
+		case '{':
+			end := bytes.Index(b, []byte("}\r\n"))
+			if end < 0 {
+				return nil, nil, fmt.Errorf("malformed literal")
+			}
+			size, err := strconv.Atoi(string(b[1:end]))
+			if err != nil || end+3+size > len(b) {
+				return nil, nil, fmt.Errorf("malformed literal")
+			}
+			items = append(items, string(b[end+3:end+3+size]))
+			b = b[end+3+size:]
+
+		default:
+			end := bytes.IndexAny(b, " ()")
+			if end < 0 {
+				return nil, nil, fmt.Errorf("unterminated list")
+			}
+			items = append(items, string(b[:end]))
+			b = b[end:]
+		}
+	}
+}
+
+// decodeMailboxUTF7 decodes a modified UTF-7 name (RFC 3501 5.1.3), the
+// inverse of encodeMailboxUTF7. Malformed input is returned unchanged.
+func decodeMailboxUTF7(s string) string {
+	if !strings.Contains(s, "&") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '&' {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		end := strings.IndexByte(s[i:], '-')
+		if end < 0 {
+			return s
+		}
+		encoded := s[i+1 : i+end]
+		i += end
+
+		if encoded == "" {
+			b.WriteByte('&')
+			continue
+		}
+
+		raw, err := base64.RawStdEncoding.DecodeString(strings.ReplaceAll(encoded, ",", "/"))
+		if err != nil || len(raw)%2 != 0 {
+			return s
+		}
+		units := make([]uint16, len(raw)/2)
+		for j := range units {
+			units[j] = uint16(raw[2*j])<<8 | uint16(raw[2*j+1])
+		}
+		b.WriteString(string(utf16.Decode(units)))
+	}
+	return b.String()
+}
@@ -3,13 +3,17 @@ package imap
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/emersion/go-imap/v2/imapclient"
 	"github.com/emersion/go-sasl"
+	"github.com/hkdb/aerion/internal/auth"
 	"github.com/hkdb/aerion/internal/logging"
 	"github.com/rs/zerolog"
 )
@@ -36,6 +40,12 @@ type IdleConfig struct {
 
 	// ShutdownTimeout is how long to wait for graceful shutdown
 	ShutdownTimeout time.Duration
+
+	// MaxFolderConnections is the per-account budget of IDLE connections used
+	// when watching several folders on a server without NOTIFY (RFC 5465).
+	// Servers commonly cap concurrent connections per user (e.g. Gmail at 15,
+	// Dovecot at 10 per IP), and the sync pool needs some of those too.
+	MaxFolderConnections int
 }
 
 // DefaultIdleConfig returns sensible defaults for IDLE
@@ -48,10 +58,20 @@ func DefaultIdleConfig() IdleConfig {
 		EventSendTimeout:     2 * time.Second,  // Don't block forever on event send
 		HealthCheckEnabled:   true,             // Verify connection before IDLE
 		ShutdownTimeout:      5 * time.Second,  // Graceful shutdown timeout
+		MaxFolderConnections: 4,
 	}
 }
 
-// IdleConnection manages an IDLE connection for a single account
+// pushConnection is a connection delivering push updates for an account:
+// an IdleConnection watching one folder or a NotifyConnection watching several
+type pushConnection interface {
+	Start(ctx context.Context, events chan<- MailEvent)
+	Stop()
+	isRunning() bool
+	watching() []string
+}
+
+// IdleConnection manages an IDLE connection for a single folder of an account
 type IdleConnection struct {
 	accountID      string
 	accountName    string
@@ -65,20 +85,20 @@ type IdleConnection struct {
 	running bool
 	stopCh  chan struct{}
 	doneCh  chan struct{} // Closed when goroutine exits
-	folder  string        // Currently watching folder (usually "INBOX")
+	folder  string        // Watched folder (INBOX or a watched folder)
 	client  *imapclient.Client
 	events  chan<- MailEvent
 }
 
 // newIdleConnection creates a new IDLE connection for an account
-func newIdleConnection(accountID, accountName string, config IdleConfig, getCredentials func(accountID string) (*ClientConfig, error)) *IdleConnection {
+func newIdleConnection(accountID, accountName, folder string, config IdleConfig, getCredentials func(accountID string) (*ClientConfig, error)) *IdleConnection {
 	return &IdleConnection{
 		accountID:      accountID,
 		accountName:    accountName,
 		config:         config,
 		getCredentials: getCredentials,
-		log:            logging.WithComponent("imap-idle").With().Str("account", accountName).Logger(),
-		folder:         "INBOX",
+		log:            logging.WithComponent("imap-idle").With().Str("account", accountName).Str("folder", folder).Logger(),
+		folder:         folder,
 	}
 }
 
@@ -143,6 +163,18 @@ func (ic *IdleConnection) Stop() {
 	}
 }
 
+// isRunning reports whether the connection goroutine is still alive
+func (ic *IdleConnection) isRunning() bool {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	return ic.running
+}
+
+// watching returns the folder this connection watches
+func (ic *IdleConnection) watching() []string {
+	return []string{ic.folder}
+}
+
 // run is the main IDLE loop
 func (ic *IdleConnection) run(ctx context.Context) {
 	defer func() {
@@ -266,22 +298,12 @@ func (ic *IdleConnection) ensureConnected(ctx context.Context) error {
 		},
 	}
 
-	addr := fmt.Sprintf("%s:%d", creds.Host, creds.Port)
+	addr := net.JoinHostPort(creds.Host, strconv.Itoa(creds.Port))
 	var client *imapclient.Client
+	var conn *compressConn // set when we dial ourselves, needed for COMPRESS
+	var tlsState *tls.ConnectionState
 
 	switch SecurityType(creds.Security) {
-	case SecurityTLS:
-		if creds.TLSConfig != nil {
-			// Use custom TLS config (certificate TOFU) with manual dial
-			dialer := &net.Dialer{Timeout: 30 * time.Second}
-			rawConn, dialErr := tls.DialWithDialer(dialer, "tcp", addr, creds.TLSConfig)
-			if dialErr != nil {
-				return fmt.Errorf("failed to connect with TLS: %w", dialErr)
-			}
-			client = imapclient.New(rawConn, options)
-		} else {
-			client, err = imapclient.DialTLS(addr, options)
-		}
 	case SecurityStartTLS:
 		if creds.TLSConfig != nil {
 			options.TLSConfig = creds.TLSConfig
@@ -290,15 +312,33 @@ func (ic *IdleConnection) ensureConnected(ctx context.Context) error {
 		}
 		client, err = imapclient.DialStartTLS(addr, options)
 	case SecurityNone:
-		client, err = imapclient.DialInsecure(addr, options)
-	default:
-		if creds.TLSConfig != nil {
+		if creds.Compress {
 			dialer := &net.Dialer{Timeout: 30 * time.Second}
-			rawConn, dialErr := tls.DialWithDialer(dialer, "tcp", addr, creds.TLSConfig)
+			rawConn, dialErr := dialer.Dial("tcp", addr)
+			if dialErr != nil {
+				return fmt.Errorf("failed to connect: %w", dialErr)
+			}
+			conn = newCompressConn(rawConn, creds.Traffic)
+			client = imapclient.New(conn, options)
+		} else {
+			client, err = imapclient.DialInsecure(addr, options)
+		}
+	default: // SecurityTLS
+		if creds.TLSConfig != nil || creds.Compress {
+			// Use custom TLS config (certificate TOFU) with manual dial
+			tlsConfig := creds.TLSConfig
+			if tlsConfig == nil {
+				tlsConfig = &tls.Config{ServerName: creds.Host}
+			}
+			dialer := &net.Dialer{Timeout: 30 * time.Second}
+			rawConn, dialErr := tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
 			if dialErr != nil {
 				return fmt.Errorf("failed to connect with TLS: %w", dialErr)
 			}
-			client = imapclient.New(rawConn, options)
+			state := rawConn.ConnectionState()
+			tlsState = &state
+			conn = newCompressConn(rawConn, creds.Traffic)
+			client = imapclient.New(conn, options)
 		} else {
 			client, err = imapclient.DialTLS(addr, options)
 		}
@@ -320,8 +360,23 @@ func (ic *IdleConnection) ensureConnected(ctx context.Context) error {
 		authType = AuthTypePassword
 	}
 
-	switch authType {
-	case AuthTypeOAuth2:
+	mech, mechClient, err := saslClientFor(creds, client.Caps().AuthMechanisms(), tlsState)
+	if err != nil {
+		client.Close()
+		return err
+	}
+
+	switch {
+	case mechClient != nil:
+		if err := client.Authenticate(mechClient); err != nil {
+			client.Close()
+			return fmt.Errorf("%s authentication failed: %w", mech, err)
+		}
+		if err := auth.Verified(mechClient); err != nil {
+			client.Close()
+			return fmt.Errorf("%s authentication failed: %w", mech, err)
+		}
+	case authType == AuthTypeOAuth2:
 		saslClient := NewXOAuth2Client(creds.Username, creds.AccessToken)
 		if err := client.Authenticate(saslClient); err != nil {
 			client.Close()
@@ -338,6 +393,17 @@ func (ic *IdleConnection) ensureConnected(ctx context.Context) error {
 		}
 	}
 
+	// Negotiate compression before anything else goes over the wire
+	if creds.Compress && conn != nil && client.Caps().Has(CapCompressDeflate) {
+		if err := conn.startCompression(); err != nil {
+			if !errors.Is(err, errCommandRejected) {
+				client.Close()
+				return fmt.Errorf("failed to enable compression: %w", err)
+			}
+			ic.log.Warn().Err(err).Msg("Server refused COMPRESS, continuing uncompressed")
+		}
+	}
+
 	// Check if server supports IDLE
 	if !client.Caps().Has("IDLE") {
 		client.Close()
@@ -346,11 +412,11 @@ func (ic *IdleConnection) ensureConnected(ctx context.Context) error {
 		return fmt.Errorf("server does not support IDLE")
 	}
 
-	// Select INBOX
+	// Select the watched folder
 	selectCmd := client.Select(ic.folder, nil)
 	if _, err := selectCmd.Wait(); err != nil {
 		client.Close()
-		return fmt.Errorf("failed to select INBOX: %w", err)
+		return fmt.Errorf("failed to select %s: %w", ic.folder, err)
 	}
 
 	ic.mu.Lock()
@@ -415,34 +481,38 @@ func (ic *IdleConnection) idleCycle(ctx context.Context) error {
 	}
 }
 
-// IdleManager manages IDLE connections for multiple accounts
+// IdleManager manages push connections (IDLE/NOTIFY) for multiple accounts
 type IdleManager struct {
-	config         IdleConfig
-	getCredentials func(accountID string) (*ClientConfig, error)
-	isConnected    func() bool // optional: propagated to connections
-	log            zerolog.Logger
+	config            IdleConfig
+	getCredentials    func(accountID string) (*ClientConfig, error)
+	getWatchedFolders func(accountID string) []string // optional: folders to watch besides INBOX
+	isConnected       func() bool                     // optional: propagated to connections
+	log               zerolog.Logger
 
 	// Connections per account
-	connections map[string]*IdleConnection
+	connections map[string][]pushConnection
 	mu          sync.Mutex
 
+	// Accounts whose server turned out not to support NOTIFY
+	notifyUnsupported map[string]bool
+
 	// Event channel
 	events chan MailEvent
 
 	// Control
 	ctx    context.Context
 	cancel context.CancelFunc
-	wg     sync.WaitGroup
 }
 
 // NewIdleManager creates a new IDLE manager
 func NewIdleManager(config IdleConfig, getCredentials func(accountID string) (*ClientConfig, error)) *IdleManager {
 	return &IdleManager{
-		config:         config,
-		getCredentials: getCredentials,
-		log:            logging.WithComponent("idle-manager"),
-		connections:    make(map[string]*IdleConnection),
-		events:         make(chan MailEvent, 100),
+		config:            config,
+		getCredentials:    getCredentials,
+		log:               logging.WithComponent("idle-manager"),
+		connections:       make(map[string][]pushConnection),
+		notifyUnsupported: make(map[string]bool),
+		events:            make(chan MailEvent, 100),
 	}
 }
 
@@ -453,6 +523,13 @@ func (m *IdleManager) SetConnectivityCheck(check func() bool) {
 	m.isConnected = check
 }
 
+// SetWatchedFoldersFunc sets a function returning the folder paths to watch for
+// an account in addition to INBOX. Changes take effect on the next StartAccount
+// or RestartAccount.
+func (m *IdleManager) SetWatchedFoldersFunc(getWatchedFolders func(accountID string) []string) {
+	m.getWatchedFolders = getWatchedFolders
+}
+
 // Start starts the IDLE manager
 func (m *IdleManager) Start(ctx context.Context) {
 	m.ctx, m.cancel = context.WithCancel(ctx)
@@ -466,14 +543,15 @@ func (m *IdleManager) Stop() {
 	}
 
 	m.mu.Lock()
-	for accountID, conn := range m.connections {
-		m.log.Debug().Str("account", accountID).Msg("Stopping IDLE connection")
-		conn.Stop()
+	var all []pushConnection
+	for accountID, conns := range m.connections {
+		m.log.Debug().Str("account", accountID).Msg("Stopping IDLE connections")
+		all = append(all, conns...)
 	}
-	m.connections = make(map[string]*IdleConnection)
+	stopConnections(all)
+	m.connections = make(map[string][]pushConnection)
 	m.mu.Unlock()
 
-	m.wg.Wait()
 	m.log.Info().Msg("IDLE manager stopped")
 }
 
@@ -482,37 +560,89 @@ func (m *IdleManager) Events() <-chan MailEvent {
 	return m.events
 }
 
-// StartAccount starts IDLE for a specific account
+// StartAccount starts push updates for a specific account. INBOX is always
+// watched; additional folders come from the watched folders function. Several
+// folders share one NOTIFY connection when the server supports it, otherwise
+// each gets its own IDLE connection within MaxFolderConnections. Connections
+// still running for watched folders are kept; folders without one are
+// (re)started.
 func (m *IdleManager) StartAccount(accountID, accountName string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Check if already running
-	if conn, exists := m.connections[accountID]; exists {
-		conn.mu.Lock()
-		running := conn.running
-		conn.mu.Unlock()
-		if running {
-			m.log.Debug().Str("account", accountName).Msg("IDLE already running for account")
-			return
+	folders := m.watchedFolders(accountID)
+	useNotify := len(folders) > 1 && !m.notifyUnsupported[accountID]
+	wanted := make(map[string]bool, len(folders))
+	for _, f := range folders {
+		wanted[f] = true
+	}
+
+	// Connections whose goroutine exited (e.g., max reconnect attempts
+	// reached) or that watch folders no longer wanted are replaced
+	var kept, stale []pushConnection
+	watched := make(map[string]bool)
+	for _, conn := range m.connections[accountID] {
+		connFolders := conn.watching()
+		keep := conn.isRunning()
+		if _, isNotify := conn.(*NotifyConnection); isNotify != useNotify {
+			keep = false
+		} else if useNotify {
+			// One NOTIFY connection covers exactly the watched folders
+			keep = keep && len(kept) == 0 && len(connFolders) == len(folders)
+			for _, f := range connFolders {
+				keep = keep && wanted[f]
+			}
+		} else {
+			keep = keep && wanted[connFolders[0]] && !watched[connFolders[0]]
+		}
+
+		if !keep {
+			stale = append(stale, conn)
+			continue
+		}
+		kept = append(kept, conn)
+		for _, f := range connFolders {
+			watched[f] = true
 		}
-		// Goroutine exited (e.g., max reconnect attempts reached) — remove stale entry
-		m.log.Debug().Str("account", accountName).Msg("Replacing dead IDLE connection")
-		delete(m.connections, accountID)
 	}
 
-	// Create and start IDLE connection
-	conn := newIdleConnection(accountID, accountName, m.config, m.getCredentials)
-	conn.isConnected = m.isConnected
-	m.connections[accountID] = conn
+	var missing []string
+	for _, f := range folders {
+		if !watched[f] {
+			missing = append(missing, f)
+		}
+	}
 
-	m.wg.Add(1)
-	go func() {
-		defer m.wg.Done()
-		conn.Start(m.ctx, m.events)
-	}()
+	if len(stale) == 0 && len(missing) == 0 {
+		m.log.Debug().Str("account", accountName).Msg("IDLE already running for all watched folders")
+		return
+	}
+	if len(stale) > 0 {
+		m.log.Debug().Str("account", accountName).Int("connections", len(stale)).Msg("Replacing dead IDLE connections")
+		stopConnections(stale)
+	}
+
+	var started []pushConnection
+	if useNotify && len(missing) > 0 {
+		nc := newNotifyConnection(accountID, accountName, folders, m.config, m.getCredentials)
+		nc.isConnected = m.isConnected
+		nc.onUnsupported = func() {
+			m.fallbackToIdle(accountID, accountName, nc)
+		}
+		started = []pushConnection{nc}
+	} else if !useNotify {
+		started = m.newIdleConnections(accountID, accountName, missing, len(kept))
+	}
 
-	m.log.Info().Str("account", accountName).Msg("Started IDLE for account")
+	m.connections[accountID] = append(kept, started...)
+	m.startConnections(started)
+
+	if len(started) > 0 {
+		m.log.Info().
+			Str("account", accountName).
+			Strs("folders", missing).
+			Msg("Started IDLE for account")
+	}
 }
 
 // StopAccount stops IDLE for a specific account
@@ -520,8 +650,8 @@ func (m *IdleManager) StopAccount(accountID string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if conn, exists := m.connections[accountID]; exists {
-		conn.Stop()
+	if conns, exists := m.connections[accountID]; exists {
+		stopConnections(conns)
 		delete(m.connections, accountID)
 		m.log.Info().Str("accountID", accountID).Msg("Stopped IDLE for account")
 	}
@@ -532,3 +662,90 @@ func (m *IdleManager) RestartAccount(accountID, accountName string) {
 	m.StopAccount(accountID)
 	m.StartAccount(accountID, accountName)
 }
+
+// watchedFolders returns INBOX followed by the account's other watched folders
+func (m *IdleManager) watchedFolders(accountID string) []string {
+	folders := []string{"INBOX"}
+	if m.getWatchedFolders == nil {
+		return folders
+	}
+
+	seen := map[string]bool{"INBOX": true}
+	for _, f := range m.getWatchedFolders(accountID) {
+		key := f
+		if strings.EqualFold(f, "INBOX") {
+			key = "INBOX"
+		}
+		if f == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		folders = append(folders, f)
+	}
+	return folders
+}
+
+// newIdleConnections creates one IDLE connection per folder, up to the
+// connection budget left after inUse running connections. Folders beyond
+// the budget are synced when opened.
+func (m *IdleManager) newIdleConnections(accountID, accountName string, folders []string, inUse int) []pushConnection {
+	budget := max(max(m.config.MaxFolderConnections, 1)-inUse, 0)
+	if len(folders) > budget {
+		m.log.Warn().
+			Str("account", accountName).
+			Int("budget", budget).
+			Strs("unwatched", folders[budget:]).
+			Msg("Watched folders exceed IDLE connection budget, skipping extra folders")
+		folders = folders[:budget]
+	}
+
+	conns := make([]pushConnection, 0, len(folders))
+	for _, f := range folders {
+		conn := newIdleConnection(accountID, accountName, f, m.config, m.getCredentials)
+		conn.isConnected = m.isConnected
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+// fallbackToIdle replaces an account's NOTIFY connection with per-folder IDLE
+// connections once the server turned out not to support NOTIFY
+func (m *IdleManager) fallbackToIdle(accountID, accountName string, nc *NotifyConnection) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.notifyUnsupported[accountID] = true
+
+	// Only replace the connection if the account wasn't stopped or restarted meanwhile
+	conns := m.connections[accountID]
+	if len(conns) != 1 || conns[0] != pushConnection(nc) {
+		return
+	}
+
+	idleConns := m.newIdleConnections(accountID, accountName, nc.folders, 0)
+	m.connections[accountID] = idleConns
+	m.startConnections(idleConns)
+}
+
+// startConnections starts connections. Start only launches the connection's
+// goroutine, so each reports running as soon as this returns and a following
+// StartAccount doesn't mistake it for a dead one.
+func (m *IdleManager) startConnections(conns []pushConnection) {
+	for _, conn := range conns {
+		conn.Start(m.ctx, m.events)
+	}
+}
+
+// stopConnections stops connections in parallel so shutdown time doesn't
+// grow with the number of watched folders
+func stopConnections(conns []pushConnection) {
+	var wg sync.WaitGroup
+	for _, conn := range conns {
+		wg.Add(1)
+		go func(conn pushConnection) {
+			defer wg.Done()
+			conn.Stop()
+		}(conn)
+	}
+	wg.Wait()
+}
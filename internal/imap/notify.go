@@ -0,0 +1,857 @@
+package imap
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+
+	"github.com/emersion/go-sasl"
+	"github.com/hkdb/aerion/internal/auth"
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/rs/zerolog"
+)
+
+// NOTIFY (RFC 5465) lets a single connection receive new-mail and expunge
+// notifications for several mailboxes at once. go-imap's client has no NOTIFY
+// command and silently drops unsolicited STATUS responses, so NotifyConnection
+// speaks the small subset of IMAP it needs (greeting, STARTTLS, login,
+// CAPABILITY, NOTIFY SET, NOOP, LOGOUT) over its own connection.
+
+var (
+	// errNotifyUnsupported is returned when the server doesn't advertise NOTIFY
+	errNotifyUnsupported = errors.New("server does not support NOTIFY")
+
+	// errCommandRejected wraps tagged NO/BAD completions
+	errCommandRejected = errors.New("command rejected")
+)
+
+const (
+	// notifyCommandTimeout bounds how long we wait for a tagged response
+	notifyCommandTimeout = 30 * time.Second
+
+	// notifyMaxLiteral caps literal sizes accepted from the server. Responses on
+	// this connection are tiny; anything larger means a broken or hostile peer.
+	notifyMaxLiteral = 64 * 1024
+)
+
+// NotifyConnection watches several folders of an account over one connection
+type NotifyConnection struct {
+	accountID      string
+	accountName    string
+	folders        []string
+	config         IdleConfig
+	getCredentials func(accountID string) (*ClientConfig, error)
+	isConnected    func() bool // optional: wait for connectivity before reconnecting
+	onUnsupported  func()      // called (in its own goroutine) if the server lacks NOTIFY
+	log            zerolog.Logger
+
+	// State
+	mu      sync.Mutex
+	running bool
+	stopCh  chan struct{}
+	doneCh  chan struct{} // Closed when goroutine exits
+	conn    *notifyConn
+	events  chan<- MailEvent
+
+	// Last seen mailbox state, kept across reconnects so changes that happened
+	// while disconnected are reported once the connection is back
+	mailboxes map[string]notifyMailboxState
+}
+
+// notifyMailboxState is the last STATUS seen for a watched folder
+type notifyMailboxState struct {
+	messages uint32
+	uidNext  uint32
+}
+
+// newNotifyConnection creates a new NOTIFY connection for an account
+func newNotifyConnection(accountID, accountName string, folders []string, config IdleConfig, getCredentials func(accountID string) (*ClientConfig, error)) *NotifyConnection {
+	return &NotifyConnection{
+		accountID:      accountID,
+		accountName:    accountName,
+		folders:        folders,
+		config:         config,
+		getCredentials: getCredentials,
+		log:            logging.WithComponent("imap-notify").With().Str("account", accountName).Logger(),
+		mailboxes:      make(map[string]notifyMailboxState),
+	}
+}
+
+// sendEvent sends an event with timeout to prevent blocking
+func (nc *NotifyConnection) sendEvent(event MailEvent) {
+	select {
+	case nc.events <- event:
+		// Event sent successfully
+	case <-time.After(nc.config.EventSendTimeout):
+		nc.log.Warn().
+			Str("type", event.Type.String()).
+			Str("folder", event.Folder).
+			Msg("Event channel full, dropping event (receiver may be stuck)")
+	case <-nc.stopCh:
+		// Connection stopping, discard event
+	}
+}
+
+// Start starts the NOTIFY loop for this connection
+func (nc *NotifyConnection) Start(ctx context.Context, events chan<- MailEvent) {
+	nc.mu.Lock()
+	if nc.running {
+		nc.mu.Unlock()
+		return
+	}
+	nc.running = true
+	nc.stopCh = make(chan struct{})
+	nc.doneCh = make(chan struct{})
+	nc.events = events
+	nc.mu.Unlock()
+
+	go nc.run(ctx)
+}
+
+// Stop stops the NOTIFY connection with graceful shutdown
+func (nc *NotifyConnection) Stop() {
+	nc.mu.Lock()
+	if !nc.running {
+		nc.mu.Unlock()
+		return
+	}
+
+	nc.running = false
+	close(nc.stopCh)
+	doneCh := nc.doneCh
+	timeout := nc.config.ShutdownTimeout
+	nc.mu.Unlock()
+
+	if doneCh != nil {
+		select {
+		case <-doneCh:
+			nc.log.Debug().Msg("NOTIFY connection stopped gracefully")
+		case <-time.After(timeout):
+			nc.log.Warn().Msg("NOTIFY connection shutdown timed out, forcing close")
+			nc.mu.Lock()
+			if nc.conn != nil {
+				nc.conn.Close()
+				nc.conn = nil
+			}
+			nc.mu.Unlock()
+		}
+	}
+}
+
+// isRunning reports whether the connection goroutine is still alive
+func (nc *NotifyConnection) isRunning() bool {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	return nc.running
+}
+
+// watching returns the folders this connection watches
+func (nc *NotifyConnection) watching() []string {
+	return nc.folders
+}
+
+// run is the main NOTIFY loop
+func (nc *NotifyConnection) run(ctx context.Context) {
+	defer func() {
+		nc.mu.Lock()
+		nc.running = false
+		if nc.conn != nil {
+			nc.conn.Close()
+			nc.conn = nil
+		}
+		if nc.doneCh != nil {
+			close(nc.doneCh)
+		}
+		nc.mu.Unlock()
+	}()
+
+	backoff := nc.config.ReconnectBackoff
+	attempts := 0
+
+	for {
+		select {
+		case <-ctx.Done():
+			nc.log.Debug().Msg("Context cancelled, stopping NOTIFY")
+			return
+		case <-nc.stopCh:
+			nc.log.Debug().Msg("Stop requested, stopping NOTIFY")
+			return
+		default:
+		}
+
+		// Stop if offline — processNetworkEvents will restart push
+		// when connectivity is restored, avoiding wasteful retries
+		if nc.isConnected != nil && !nc.isConnected() {
+			nc.log.Debug().Msg("Offline, stopping NOTIFY (will restart when online)")
+			return
+		}
+
+		conn, err := nc.connect()
+		if errors.Is(err, errNotifyUnsupported) {
+			nc.log.Info().Msg("Server does not support NOTIFY, falling back to IDLE per folder")
+			if nc.onUnsupported != nil {
+				go nc.onUnsupported()
+			}
+			return
+		}
+		if err != nil {
+			attempts++
+			if attempts >= nc.config.MaxReconnectAttempts {
+				nc.log.Error().
+					Err(err).
+					Int("attempts", attempts).
+					Msg("Max reconnection attempts reached, giving up")
+				return
+			}
+
+			nc.log.Warn().
+				Err(err).
+				Dur("backoff", backoff).
+				Int("attempt", attempts).
+				Msg("Failed to connect for NOTIFY, retrying")
+
+			select {
+			case <-time.After(backoff):
+				backoff = min(backoff*2, nc.config.MaxReconnectBackoff)
+				continue
+			case <-ctx.Done():
+				return
+			case <-nc.stopCh:
+				return
+			}
+		}
+
+		// Reset backoff on successful connection
+		backoff = nc.config.ReconnectBackoff
+		attempts = 0
+
+		if err := nc.listen(ctx, conn); err != nil {
+			nc.log.Warn().Err(err).Msg("NOTIFY connection failed")
+		}
+
+		nc.mu.Lock()
+		if nc.conn != nil {
+			nc.conn.Close()
+			nc.conn = nil
+		}
+		nc.mu.Unlock()
+	}
+}
+
+// connect dials, authenticates and registers for notifications
+func (nc *NotifyConnection) connect() (*notifyConn, error) {
+	creds, err := nc.getCredentials(nc.accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := dialNotifyConn(creds)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.authenticate(creds); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	caps, err := conn.capabilities()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !caps["NOTIFY"] {
+		conn.Logout()
+		return nil, errNotifyUnsupported
+	}
+
+	// NOTIFY SET STATUS makes the server report the current STATUS of every
+	// mailbox before the tagged OK, which serves as our baseline
+	var mailboxes []string
+	for _, f := range nc.folders {
+		mailboxes = append(mailboxes, quoteIMAPString(encodeMailboxUTF7(f)))
+	}
+	cmd := fmt.Sprintf("NOTIFY SET STATUS (mailboxes (%s) (MessageNew MessageExpunge))", strings.Join(mailboxes, " "))
+	if err := conn.command(cmd, func(resp []byte) {
+		nc.handleResponse(resp, true)
+	}); err != nil {
+		conn.Logout()
+		if errors.Is(err, errCommandRejected) {
+			// e.g. NO [BADEVENT] - the server's NOTIFY can't do what we need
+			return nil, fmt.Errorf("%w: NOTIFY SET failed: %v", errNotifyUnsupported, err)
+		}
+		return nil, fmt.Errorf("NOTIFY SET failed: %w", err)
+	}
+
+	nc.mu.Lock()
+	nc.conn = conn
+	nc.mu.Unlock()
+
+	nc.log.Info().Strs("folders", nc.folders).Msg("NOTIFY connection established")
+	return conn, nil
+}
+
+// listen processes notifications until the connection fails or we're stopped.
+// A NOOP is sent every IdleTimeout to keep NAT mappings and the server's
+// autologout timer from closing an otherwise silent connection.
+func (nc *NotifyConnection) listen(ctx context.Context, conn *notifyConn) error {
+	respCh := make(chan []byte, 16)
+	errCh := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		for {
+			resp, err := conn.readResponse()
+			if err != nil {
+				errCh <- err
+				return
+			}
+			select {
+			case respCh <- resp:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	keepalive := time.NewTicker(nc.config.IdleTimeout)
+	defer keepalive.Stop()
+
+	var noopTag string
+	var noopDeadline <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			conn.Logout()
+			return nil
+
+		case <-nc.stopCh:
+			conn.Logout()
+			return nil
+
+		case err := <-errCh:
+			return fmt.Errorf("connection lost: %w", err)
+
+		case resp := <-respCh:
+			if noopTag != "" && bytes.HasPrefix(resp, []byte(noopTag+" ")) {
+				if !isTaggedOK(resp, noopTag) {
+					return fmt.Errorf("health check failed: %s", resp)
+				}
+				noopTag = ""
+				noopDeadline = nil
+				continue
+			}
+			if err := nc.handleResponse(resp, false); err != nil {
+				return err
+			}
+
+		case <-keepalive.C:
+			if !nc.config.HealthCheckEnabled || noopTag != "" {
+				continue
+			}
+			noopTag = conn.nextTag()
+			if err := conn.writeLine(noopTag + " NOOP"); err != nil {
+				return fmt.Errorf("health check failed (connection may be dead): %w", err)
+			}
+			noopDeadline = time.After(notifyCommandTimeout)
+
+		case <-noopDeadline:
+			return fmt.Errorf("health check timed out (connection may be dead)")
+		}
+	}
+}
+
+// handleResponse turns untagged STATUS responses into mail events. baseline is
+// true for the STATUS responses sent in reply to NOTIFY SET STATUS.
+func (nc *NotifyConnection) handleResponse(resp []byte, baseline bool) error {
+	switch {
+	case bytes.HasPrefix(resp, []byte("* BYE")):
+		return fmt.Errorf("server closed connection: %s", resp)
+
+	case bytes.HasPrefix(resp, []byte("* OK [NOTIFICATIONOVERFLOW]")):
+		// The server gave up tracking changes for us - resync everything
+		nc.log.Warn().Msg("NOTIFY overflow, requesting sync of all watched folders")
+		for _, f := range nc.folders {
+			nc.sendEvent(MailEvent{
+				Type:      EventNewMail,
+				AccountID: nc.accountID,
+				Folder:    f,
+			})
+		}
+		return nil
+
+	case !bytes.HasPrefix(resp, []byte("* STATUS ")):
+		return nil
+	}
+
+	name, attrs, ok := parseStatusResponse(resp)
+	if !ok {
+		nc.log.Debug().Bytes("response", resp).Msg("Ignoring unparseable STATUS response")
+		return nil
+	}
+	folder := nc.folderForMailbox(name)
+	if folder == "" {
+		return nil
+	}
+
+	prev, known := nc.mailboxes[folder]
+	state := prev
+	if v, ok := attrs["MESSAGES"]; ok {
+		state.messages = uint32(v)
+	}
+	if v, ok := attrs["UIDNEXT"]; ok {
+		state.uidNext = uint32(v)
+	}
+	nc.mailboxes[folder] = state
+
+	// The first baseline only records state; the app syncs on startup anyway
+	if baseline && !known {
+		return nil
+	}
+
+	switch {
+	case state.uidNext != prev.uidNext || !known:
+		nc.log.Info().
+			Str("folder", folder).
+			Uint32("count", state.messages).
+			Msg("New messages notification (STATUS)")
+		nc.sendEvent(MailEvent{
+			Type:      EventNewMail,
+			AccountID: nc.accountID,
+			Folder:    folder,
+			Count:     state.messages,
+		})
+	case state.messages < prev.messages:
+		nc.log.Debug().Str("folder", folder).Msg("Messages expunged")
+		nc.sendEvent(MailEvent{
+			Type:      EventExpunge,
+			AccountID: nc.accountID,
+			Folder:    folder,
+		})
+	}
+
+	return nil
+}
+
+// folderForMailbox maps a mailbox name from a server response back to the
+// folder path we registered
+func (nc *NotifyConnection) folderForMailbox(name string) string {
+	for _, f := range nc.folders {
+		encoded := encodeMailboxUTF7(f)
+		if encoded == name || (strings.EqualFold(f, "INBOX") && strings.EqualFold(name, "INBOX")) {
+			return f
+		}
+	}
+	return ""
+}
+
+// ============================================================================
+// Minimal IMAP protocol connection
+// ============================================================================
+
+// notifyConn is a bare IMAP connection for the commands NOTIFY needs
+type notifyConn struct {
+	conn    net.Conn
+	r       *bufio.Reader
+	tag     int
+	preauth bool // Server greeted with PREAUTH, no login needed
+}
+
+// dialNotifyConn connects to the server, reads the greeting and upgrades
+// to TLS if required
+func dialNotifyConn(creds *ClientConfig) (*notifyConn, error) {
+	addr := net.JoinHostPort(creds.Host, strconv.Itoa(creds.Port))
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+
+	tlsConfig := creds.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{ServerName: creds.Host}
+	}
+
+	var rawConn net.Conn
+	var err error
+	switch SecurityType(creds.Security) {
+	case SecurityStartTLS, SecurityNone:
+		rawConn, err = dialer.Dial("tcp", addr)
+	default:
+		rawConn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	c := &notifyConn{conn: rawConn, r: bufio.NewReader(rawConn)}
+
+	rawConn.SetReadDeadline(time.Now().Add(notifyCommandTimeout))
+	greeting, err := c.readResponse()
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("failed to receive greeting: %w", err)
+	}
+	c.preauth = bytes.HasPrefix(greeting, []byte("* PREAUTH"))
+	if !c.preauth && !bytes.HasPrefix(greeting, []byte("* OK")) {
+		c.Close()
+		return nil, fmt.Errorf("unexpected greeting: %s", greeting)
+	}
+
+	if SecurityType(creds.Security) == SecurityStartTLS && !c.preauth {
+		if err := c.command("STARTTLS", nil); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("STARTTLS failed: %w", err)
+		}
+		tlsConn := tls.Client(rawConn, tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		c.conn = tlsConn
+		c.r = bufio.NewReader(tlsConn)
+	}
+
+	return c, nil
+}
+
+// authenticate logs in the same way Client.Login does
+func (c *notifyConn) authenticate(creds *ClientConfig) error {
+	if c.preauth {
+		return nil
+	}
+
+	authType := creds.AuthType
+	if authType == "" {
+		authType = AuthTypePassword
+	}
+
+	caps, err := c.capabilities()
+	if err != nil {
+		return err
+	}
+
+	var advertised []string
+	for cap := range caps {
+		if mech, ok := strings.CutPrefix(cap, "AUTH="); ok {
+			advertised = append(advertised, mech)
+		}
+	}
+	var tlsState *tls.ConnectionState
+	if tlsConn, ok := c.conn.(*tls.Conn); ok {
+		state := tlsConn.ConnectionState()
+		tlsState = &state
+	}
+	mech, mechClient, err := saslClientFor(creds, advertised, tlsState)
+	if err != nil {
+		return err
+	}
+	if mechClient != nil {
+		if err := c.authenticateSASL(mechClient); err != nil {
+			return fmt.Errorf("%s authentication failed: %w", mech, err)
+		}
+		return nil
+	}
+
+	if authType == AuthTypeOAuth2 {
+		if err := c.authenticateSASL(NewXOAuth2Client(creds.Username, creds.AccessToken)); err != nil {
+			return fmt.Errorf("XOAUTH2 authentication failed: %w", err)
+		}
+		return nil
+	}
+
+	if caps["LOGINDISABLED"] {
+		if err := c.authenticateSASL(sasl.NewPlainClient("", creds.Username, creds.Password)); err != nil {
+			return fmt.Errorf("authentication failed: %w", err)
+		}
+		return nil
+	}
+
+	cmd := "LOGIN " + quoteIMAPString(creds.Username) + " " + quoteIMAPString(creds.Password)
+	if err := c.command(cmd, nil); err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	return nil
+}
+
+// authenticateSASL runs AUTHENTICATE, sending the initial response in the
+// first continuation (servers without SASL-IR support are the common case)
+func (c *notifyConn) authenticateSASL(client sasl.Client) error {
+	mech, ir, err := client.Start()
+	if err != nil {
+		return err
+	}
+
+	tag := c.nextTag()
+	if err := c.writeLine(tag + " AUTHENTICATE " + mech); err != nil {
+		return err
+	}
+
+	sentIR := false
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(notifyCommandTimeout))
+		resp, err := c.readResponse()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case bytes.HasPrefix(resp, []byte("+")):
+			var response []byte
+			if !sentIR {
+				response = ir
+				sentIR = true
+			} else {
+				challenge, _ := base64.StdEncoding.DecodeString(strings.TrimSpace(string(resp[1:])))
+				response, err = client.Next(challenge)
+				if err != nil {
+					c.writeLine("*") // Cancel the exchange
+					return err
+				}
+			}
+			if err := c.writeLine(base64.StdEncoding.EncodeToString(response)); err != nil {
+				return err
+			}
+
+		case bytes.HasPrefix(resp, []byte(tag+" ")):
+			c.conn.SetReadDeadline(time.Time{})
+			if !isTaggedOK(resp, tag) {
+				return fmt.Errorf("%w: %s", errCommandRejected, bytes.TrimPrefix(resp, []byte(tag+" ")))
+			}
+			return auth.Verified(client)
+		}
+	}
+}
+
+// capabilities returns the server's capabilities (upper-cased)
+func (c *notifyConn) capabilities() (map[string]bool, error) {
+	caps := make(map[string]bool)
+	err := c.command("CAPABILITY", func(resp []byte) {
+		if !bytes.HasPrefix(resp, []byte("* CAPABILITY ")) {
+			return
+		}
+		for _, cap := range strings.Fields(string(resp[len("* CAPABILITY "):])) {
+			caps[strings.ToUpper(cap)] = true
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("CAPABILITY failed: %w", err)
+	}
+	return caps, nil
+}
+
+// command sends a tagged command and waits for its completion. Untagged
+// responses received in the meantime are passed to untagged (if set).
+func (c *notifyConn) command(cmd string, untagged func(resp []byte)) error {
+	tag := c.nextTag()
+	if err := c.writeLine(tag + " " + cmd); err != nil {
+		return err
+	}
+
+	c.conn.SetReadDeadline(time.Now().Add(notifyCommandTimeout))
+	defer c.conn.SetReadDeadline(time.Time{})
+
+	for {
+		resp, err := c.readResponse()
+		if err != nil {
+			return err
+		}
+		if bytes.HasPrefix(resp, []byte(tag+" ")) {
+			if !isTaggedOK(resp, tag) {
+				return fmt.Errorf("%w: %s", errCommandRejected, bytes.TrimPrefix(resp, []byte(tag+" ")))
+			}
+			return nil
+		}
+		if untagged != nil && bytes.HasPrefix(resp, []byte("* ")) {
+			untagged(resp)
+		}
+	}
+}
+
+// nextTag returns a new command tag
+func (c *notifyConn) nextTag() string {
+	c.tag++
+	return "N" + strconv.Itoa(c.tag)
+}
+
+// writeLine writes a command line terminated by CRLF
+func (c *notifyConn) writeLine(line string) error {
+	c.conn.SetWriteDeadline(time.Now().Add(notifyCommandTimeout))
+	_, err := io.WriteString(c.conn, line+"\r\n")
+	return err
+}
+
+// readResponse reads one response line, including any literals it contains.
+// The trailing CRLF is stripped; literal markers and data are kept verbatim.
+func (c *notifyConn) readResponse() ([]byte, error) {
+	var resp []byte
+	for {
+		line, err := c.r.ReadBytes('\n')
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, line...)
+
+		size, ok := literalSize(line)
+		if !ok {
+			return bytes.TrimRight(resp, "\r\n"), nil
+		}
+		if size > notifyMaxLiteral {
+			return nil, fmt.Errorf("literal too large (%d bytes)", size)
+		}
+		literal := make([]byte, size)
+		if _, err := io.ReadFull(c.r, literal); err != nil {
+			return nil, err
+		}
+		resp = append(resp, literal...)
+	}
+}
+
+// Logout sends LOGOUT and closes the connection
+func (c *notifyConn) Logout() {
+	c.writeLine(c.nextTag() + " LOGOUT")
+	c.Close()
+}
+
+// Close closes the underlying connection
+func (c *notifyConn) Close() error {
+	return c.conn.Close()
+}
+
+// isTaggedOK reports whether resp is an OK completion for tag
+func isTaggedOK(resp []byte, tag string) bool {
+	return bytes.HasPrefix(bytes.ToUpper(resp), []byte(strings.ToUpper(tag)+" OK"))
+}
+
+// literalSize returns n if line ends with a literal marker "{n}"
+func literalSize(line []byte) (int, bool) {
+	line = bytes.TrimRight(line, "\r\n")
+	if len(line) < 3 || line[len(line)-1] != '}' {
+		return 0, false
+	}
+	start := bytes.LastIndexByte(line, '{')
+	if start < 0 {
+		return 0, false
+	}
+	size, err := strconv.Atoi(string(line[start+1 : len(line)-1]))
+	if err != nil || size < 0 {
+		return 0, false
+	}
+	return size, true
+}
+
+// parseStatusResponse parses "* STATUS <mailbox> (<name> <number> ...)"
+func parseStatusResponse(resp []byte) (string, map[string]uint64, bool) {
+	rest := resp[len("* STATUS "):]
+
+	var name string
+	switch {
+	case len(rest) == 0:
+		return "", nil, false
+
+	case rest[0] == '"':
+		var b strings.Builder
+		i := 1
+		for ; i < len(rest) && rest[i] != '"'; i++ {
+			if rest[i] == '\\' && i+1 < len(rest) {
+				i++
+			}
+			b.WriteByte(rest[i])
+		}
+		if i >= len(rest) {
+			return "", nil, false
+		}
+		name = b.String()
+		rest = rest[i+1:]
+
+	case rest[0] == '{':
+		end := bytes.Index(rest, []byte("}\r\n"))
+		if end < 0 {
+			return "", nil, false
+		}
+		size, err := strconv.Atoi(string(rest[1:end]))
+		if err != nil || end+3+size > len(rest) {
+			return "", nil, false
+		}
+		name = string(rest[end+3 : end+3+size])
+		rest = rest[end+3+size:]
+
+	default:
+		end := bytes.IndexByte(rest, ' ')
+		if end < 0 {
+			return "", nil, false
+		}
+		name = string(rest[:end])
+		rest = rest[end:]
+	}
+
+	rest = bytes.TrimSpace(rest)
+	if len(rest) < 2 || rest[0] != '(' || rest[len(rest)-1] != ')' {
+		return "", nil, false
+	}
+
+	attrs := make(map[string]uint64)
+	fields := strings.Fields(string(rest[1 : len(rest)-1]))
+	for i := 0; i+1 < len(fields); i += 2 {
+		v, err := strconv.ParseUint(fields[i+1], 10, 64)
+		if err != nil {
+			continue
+		}
+		attrs[strings.ToUpper(fields[i])] = v
+	}
+
+	return name, attrs, true
+}
+
+// quoteIMAPString encodes s as an IMAP quoted string
+func quoteIMAPString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// encodeMailboxUTF7 encodes a mailbox name in modified UTF-7 (RFC 3501 5.1.3),
+// the inverse of the decoding go-imap applies to LIST responses
+func encodeMailboxUTF7(name string) string {
+	var b strings.Builder
+	var pending []uint16
+
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		buf := make([]byte, 0, 2*len(pending))
+		for _, u := range pending {
+			buf = append(buf, byte(u>>8), byte(u))
+		}
+		b.WriteByte('&')
+		b.WriteString(strings.ReplaceAll(base64.RawStdEncoding.EncodeToString(buf), "/", ","))
+		b.WriteByte('-')
+		pending = pending[:0]
+	}
+
+	for _, r := range name {
+		if r >= 0x20 && r <= 0x7e {
+			flush()
+			if r == '&' {
+				b.WriteString("&-")
+			} else {
+				b.WriteRune(r)
+			}
+			continue
+		}
+		pending = utf16.AppendRune(pending, r)
+	}
+	flush()
+
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package imap
+
+import (
+	"bytes"
+	"sync"
+)
+
+// permanentFlagsCode starts the response code listing the flags a mailbox
+// stores permanently
+var permanentFlagsCode = []byte("[PERMANENTFLAGS")
+
+// permanentFlagsTap watches the responses to a SELECT for a PERMANENTFLAGS
+// response code. go-imap reports a missing PERMANENTFLAGS and an empty one
+// both as nil, but they mean opposite things: without one every flag is
+// permanent (RFC 3501 section 7.1), while "PERMANENTFLAGS ()" means none
+// are. imapclient has no hook for response codes, so the tap reads the
+// protocol stream through its debug writer.
+type permanentFlagsTap struct {
+	mu     sync.Mutex
+	active bool
+	seen   bool
+	tail   []byte // End of the last write, for a code split across writes
+}
+
+// Write is passed everything imapclient sends and receives. Only the
+// traffic of a SELECT is looked at.
+func (t *permanentFlagsTap) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.active || t.seen {
+		return len(p), nil
+	}
+	buf := bytes.ToUpper(append(t.tail, p...))
+	if bytes.Contains(buf, permanentFlagsCode) {
+		t.seen = true
+	}
+	keep := min(len(buf), len(permanentFlagsCode)-1)
+	t.tail = bytes.Clone(buf[len(buf)-keep:])
+	return len(p), nil
+}
+
+// start watches for the code until stop is called
+func (t *permanentFlagsTap) start() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.active, t.seen, t.tail = true, false, nil
+}
+
+// stop stops watching and reports whether the server sent the code
+func (t *permanentFlagsTap) stop() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.active, t.tail = false, nil
+	return t.seen
+}
@@ -107,6 +107,12 @@ type Pool struct {
 
 	// Credentials provider function
 	getCredentials func(accountID string) (*ClientConfig, error)
+
+	// Byte counts across all connections the pool has created
+	traffic TrafficCounters
+
+	// Gmail extension side connections, one per account (see WithGmail)
+	gmail map[string]*gmailSide
 }
 
 // NewPool creates a new connection pool
@@ -115,6 +121,7 @@ func NewPool(config PoolConfig, getCredentials func(accountID string) (*ClientCo
 		config:         config,
 		connections:    make(map[string][]*PooledConnection),
 		waiters:        make(map[string][]chan *PooledConnection),
+		gmail:          make(map[string]*gmailSide),
 		log:            logging.WithComponent("imap-pool"),
 		getCredentials: getCredentials,
 	}
@@ -229,6 +236,7 @@ func (p *Pool) createConnectionWithRetry(ctx context.Context, accountID string,
 		Msg("Got credentials, connecting to IMAP server")
 
 	// Create and connect the client
+	config.Traffic = &p.traffic
 	client := NewClient(*config)
 
 	// Use a goroutine with context for connection timeout
@@ -397,6 +405,8 @@ func (p *Pool) CloseAccount(accountID string) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	p.closeGmail(accountID)
+
 	conns, ok := p.connections[accountID]
 	if !ok {
 		return
@@ -434,6 +444,11 @@ func (p *Pool) CloseAll() {
 	for accountID := range p.connections {
 		accountIDs = append(accountIDs, accountID)
 	}
+	for accountID := range p.gmail {
+		if _, ok := p.connections[accountID]; !ok {
+			p.closeGmail(accountID)
+		}
+	}
 	p.mu.Unlock()
 
 	for _, accountID := range accountIDs {
@@ -478,6 +493,17 @@ func (p *Pool) CleanupIdle() {
 		}
 	}
 
+	for _, side := range p.gmail {
+		if !side.mu.TryLock() {
+			continue // In use
+		}
+		if side.conn != nil && now.Sub(side.lastUsed) > p.config.IdleTimeout {
+			side.close()
+			cleaned++
+		}
+		side.mu.Unlock()
+	}
+
 	if cleaned > 0 {
 		p.log.Debug().
 			Int("cleaned", cleaned).
@@ -508,6 +534,15 @@ type PoolStats struct {
 	ActiveConnections int
 	IdleConnections   int
 	AccountCount      int
+
+	// Traffic since the pool was created. Wire bytes are what crossed the
+	// network; decompressed bytes are the IMAP data they carried. They only
+	// differ for connections using COMPRESS=DEFLATE.
+	CompressedConnections    int64
+	WireBytesRead            int64
+	WireBytesWritten         int64
+	DecompressedBytesRead    int64
+	DecompressedBytesWritten int64
 }
 
 // GetStats returns current pool statistics
@@ -516,7 +551,12 @@ func (p *Pool) GetStats() PoolStats {
 	defer p.mu.Unlock()
 
 	stats := PoolStats{
-		AccountCount: len(p.connections),
+		AccountCount:             len(p.connections),
+		CompressedConnections:    p.traffic.CompressedConnections(),
+		WireBytesRead:            p.traffic.WireBytesRead(),
+		WireBytesWritten:         p.traffic.WireBytesWritten(),
+		DecompressedBytesRead:    p.traffic.DecompressedBytesRead(),
+		DecompressedBytesWritten: p.traffic.DecompressedBytesWritten(),
 	}
 
 	for _, conns := range p.connections {
@@ -11,6 +11,10 @@ const (
 	// TypeMessageSent indicates an email was successfully sent
 	TypeMessageSent = "message_sent"
 
+	// TypeOutboxQueued indicates a message was added to the outbox for the
+	// main window to deliver
+	TypeOutboxQueued = "outbox_queued"
+
 	// TypeDraftSaved indicates a draft was saved or updated
 	TypeDraftSaved = "draft_saved"
 
@@ -63,6 +67,12 @@ type MessageSentPayload struct {
 	FolderID  int64  `json:"folder_id"`
 }
 
+// OutboxQueuedPayload is the payload for TypeOutboxQueued messages.
+type OutboxQueuedPayload struct {
+	AccountID string `json:"account_id"`
+	OutboxID  int64  `json:"outbox_id"`
+}
+
 // DraftSavedPayload is the payload for TypeDraftSaved messages.
 type DraftSavedPayload struct {
 	AccountID string `json:"account_id"`
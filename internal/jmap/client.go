@@ -0,0 +1,445 @@
+// Package jmap provides a JMAP (RFC 8620) client for mail (RFC 8621):
+// session discovery, method calls, blob upload and download, and push over
+// EventSource
+package jmap
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/rs/zerolog"
+)
+
+// Capability URIs used by the client
+const (
+	CapCore       = "urn:ietf:params:jmap:core"
+	CapMail       = "urn:ietf:params:jmap:mail"
+	CapSubmission = "urn:ietf:params:jmap:submission"
+)
+
+// maxBlobSize caps downloads to prevent memory exhaustion
+const maxBlobSize = 50 * 1024 * 1024
+
+// AuthType represents the authentication method
+type AuthType string
+
+const (
+	AuthTypePassword AuthType = "password" // HTTP Basic
+	AuthTypeOAuth2   AuthType = "oauth2"   // Bearer token
+)
+
+// ClientConfig holds the configuration for connecting to a JMAP server
+type ClientConfig struct {
+	// SessionURL is the session resource, usually https://host/.well-known/jmap.
+	// Plain http is accepted so a local stand-in server can be used.
+	SessionURL string
+	Username   string
+	Password   string
+
+	// OAuth2 authentication
+	AuthType    AuthType // "password" or "oauth2" (defaults to "password")
+	AccessToken string   // OAuth2 access token (when AuthType is "oauth2")
+
+	// Timeout for API calls and blob transfers (push connections have none)
+	Timeout time.Duration
+
+	// TLSConfig returns the TLS config for a host (optional). The session
+	// may point the API and blob URLs at another host than SessionURL.
+	TLSConfig func(host string) *tls.Config
+}
+
+// DefaultConfig returns a ClientConfig with sensible defaults
+func DefaultConfig() ClientConfig {
+	return ClientConfig{
+		AuthType: AuthTypePassword,
+		Timeout:  60 * time.Second,
+	}
+}
+
+// Session is the JMAP session resource (RFC 8620 section 2)
+type Session struct {
+	Capabilities    map[string]json.RawMessage `json:"capabilities"`
+	Accounts        map[string]SessionAccount  `json:"accounts"`
+	PrimaryAccounts map[string]string          `json:"primaryAccounts"`
+	Username        string                     `json:"username"`
+	APIURL          string                     `json:"apiUrl"`
+	DownloadURL     string                     `json:"downloadUrl"`
+	UploadURL       string                     `json:"uploadUrl"`
+	EventSourceURL  string                     `json:"eventSourceUrl"`
+	State           string                     `json:"state"`
+}
+
+// SessionAccount is an account the user has access to
+type SessionAccount struct {
+	Name       string `json:"name"`
+	IsPersonal bool   `json:"isPersonal"`
+	IsReadOnly bool   `json:"isReadOnly"`
+}
+
+// Client is a JMAP client for one user. It is safe for concurrent use.
+type Client struct {
+	config ClientConfig
+	http   *http.Client // API calls and blobs
+	push   *http.Client // EventSource, no timeout
+	log    zerolog.Logger
+
+	mu           sync.RWMutex
+	session      *Session
+	accountID    string // Primary mail account
+	sessionStale bool   // Server reported a new session state
+}
+
+// NewClient creates a new JMAP client. The session is fetched on first use.
+func NewClient(config ClientConfig) *Client {
+	if config.AuthType == "" {
+		config.AuthType = AuthTypePassword
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.TLSConfig != nil {
+		tlsConfigFor := config.TLSConfig
+		transport.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				host = addr
+			}
+			dialer := &tls.Dialer{Config: tlsConfigFor(host)}
+			return dialer.DialContext(ctx, network, addr)
+		}
+	}
+
+	return &Client{
+		config: config,
+		http:   &http.Client{Transport: transport, Timeout: config.Timeout},
+		push:   &http.Client{Transport: transport},
+		log:    logging.WithComponent("jmap"),
+	}
+}
+
+// SessionURL returns the session resource URL the client was created with
+func (c *Client) SessionURL() string {
+	return c.config.SessionURL
+}
+
+// UpdateAuth replaces the credentials used for requests, e.g. after an
+// OAuth2 token refresh. The session is kept.
+func (c *Client) UpdateAuth(config ClientConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.config.AuthType = config.AuthType
+	c.config.Username = config.Username
+	c.config.Password = config.Password
+	c.config.AccessToken = config.AccessToken
+}
+
+// Connect fetches the session resource. It is called automatically before
+// the first request and again when the server reports a new session state.
+func (c *Client) Connect(ctx context.Context) (*Session, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.config.SessionURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid session URL: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	var session Session
+	if err := c.do(c.http, req, &session); err != nil {
+		return nil, fmt.Errorf("failed to get JMAP session: %w", err)
+	}
+
+	if _, ok := session.Capabilities[CapMail]; !ok {
+		return nil, fmt.Errorf("server does not support JMAP mail")
+	}
+	accountID := session.PrimaryAccounts[CapMail]
+	if accountID == "" {
+		return nil, fmt.Errorf("server has no primary mail account")
+	}
+
+	// Resolve relative URLs against the session URL
+	for _, u := range []*string{&session.APIURL, &session.DownloadURL, &session.UploadURL, &session.EventSourceURL} {
+		*u = c.resolveURL(*u)
+	}
+
+	c.mu.Lock()
+	c.session = &session
+	c.accountID = accountID
+	c.sessionStale = false
+	c.mu.Unlock()
+
+	c.log.Debug().
+		Str("username", session.Username).
+		Str("account", accountID).
+		Str("apiUrl", session.APIURL).
+		Msg("JMAP session established")
+
+	return &session, nil
+}
+
+// Session returns the current session, fetching it if needed
+func (c *Client) Session(ctx context.Context) (*Session, error) {
+	c.mu.RLock()
+	session, stale := c.session, c.sessionStale
+	c.mu.RUnlock()
+	if session != nil && !stale {
+		return session, nil
+	}
+	return c.Connect(ctx)
+}
+
+// AccountID returns the primary mail account ID, fetching the session if
+// needed
+func (c *Client) AccountID(ctx context.Context) (string, error) {
+	if _, err := c.Session(ctx); err != nil {
+		return "", err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.accountID, nil
+}
+
+// HasCapability reports whether the server supports a capability
+func (c *Client) HasCapability(ctx context.Context, capability string) bool {
+	session, err := c.Session(ctx)
+	if err != nil {
+		return false
+	}
+	_, ok := session.Capabilities[capability]
+	return ok
+}
+
+// Invocation is a method call or response: [name, arguments, call ID]
+type Invocation struct {
+	Name   string
+	Args   json.RawMessage
+	CallID string
+}
+
+// MarshalJSON encodes the invocation as a JSON array
+func (i Invocation) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{i.Name, i.Args, i.CallID})
+}
+
+// UnmarshalJSON decodes the invocation from a JSON array
+func (i *Invocation) UnmarshalJSON(data []byte) error {
+	var parts []json.RawMessage
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return err
+	}
+	if len(parts) != 3 {
+		return fmt.Errorf("invalid invocation: %d elements", len(parts))
+	}
+	if err := json.Unmarshal(parts[0], &i.Name); err != nil {
+		return err
+	}
+	i.Args = parts[1]
+	return json.Unmarshal(parts[2], &i.CallID)
+}
+
+// apiRequest is the body of an API request (RFC 8620 section 3.3)
+type apiRequest struct {
+	Using       []string     `json:"using"`
+	MethodCalls []Invocation `json:"methodCalls"`
+}
+
+// apiResponse is the body of an API response (RFC 8620 section 3.4)
+type apiResponse struct {
+	MethodResponses []Invocation `json:"methodResponses"`
+	SessionState    string       `json:"sessionState"`
+}
+
+// Call makes a single method call and decodes its response into result.
+// The accountId argument is filled in when args is a map without one.
+func (c *Client) Call(ctx context.Context, method string, args map[string]interface{}, result interface{}) error {
+	session, err := c.Session(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := args["accountId"]; !ok {
+		c.mu.RLock()
+		args["accountId"] = c.accountID
+		c.mu.RUnlock()
+	}
+
+	rawArgs, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s arguments: %w", method, err)
+	}
+
+	using := []string{CapCore, CapMail}
+	if strings.HasPrefix(method, "EmailSubmission/") || strings.HasPrefix(method, "Identity/") {
+		using = append(using, CapSubmission)
+	}
+
+	body, err := json.Marshal(apiRequest{
+		Using:       using,
+		MethodCalls: []Invocation{{Name: method, Args: rawArgs, CallID: "0"}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, session.APIURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	var resp apiResponse
+	if err := c.do(c.http, req, &resp); err != nil {
+		return fmt.Errorf("%s failed: %w", method, err)
+	}
+
+	if resp.SessionState != "" && resp.SessionState != session.State {
+		c.mu.Lock()
+		c.sessionStale = true
+		c.mu.Unlock()
+	}
+
+	for _, r := range resp.MethodResponses {
+		if r.CallID != "0" {
+			continue
+		}
+		if r.Name == "error" {
+			methodErr := &MethodError{}
+			if err := json.Unmarshal(r.Args, methodErr); err != nil {
+				return fmt.Errorf("%s failed: invalid error response: %w", method, err)
+			}
+			return fmt.Errorf("%s failed: %w", method, methodErr)
+		}
+		if result == nil {
+			return nil
+		}
+		if err := json.Unmarshal(r.Args, result); err != nil {
+			return fmt.Errorf("failed to decode %s response: %w", method, err)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%s failed: no response from server", method)
+}
+
+// Upload uploads a blob and returns its ID
+func (c *Client) Upload(ctx context.Context, contentType string, data []byte) (string, error) {
+	session, err := c.Session(ctx)
+	if err != nil {
+		return "", err
+	}
+	accountID, _ := c.AccountID(ctx)
+
+	uploadURL := strings.ReplaceAll(session.UploadURL, "{accountId}", url.PathEscape(accountID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	var result struct {
+		BlobID string `json:"blobId"`
+	}
+	if err := c.do(c.http, req, &result); err != nil {
+		return "", fmt.Errorf("failed to upload blob: %w", err)
+	}
+	if result.BlobID == "" {
+		return "", fmt.Errorf("failed to upload blob: no blob ID returned")
+	}
+	return result.BlobID, nil
+}
+
+// Download downloads a blob, such as the raw RFC 5322 message of an Email
+func (c *Client) Download(ctx context.Context, blobID, name, contentType string) ([]byte, error) {
+	session, err := c.Session(ctx)
+	if err != nil {
+		return nil, err
+	}
+	accountID, _ := c.AccountID(ctx)
+
+	downloadURL := strings.NewReplacer(
+		"{accountId}", url.PathEscape(accountID),
+		"{blobId}", url.PathEscape(blobID),
+		"{name}", url.PathEscape(name),
+		"{type}", url.QueryEscape(contentType),
+	).Replace(session.DownloadURL)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.authorize(req)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download blob: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkStatus(resp); err != nil {
+		return nil, fmt.Errorf("failed to download blob: %w", err)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBlobSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob: %w", err)
+	}
+	return data, nil
+}
+
+// do sends an authorized request and decodes a JSON response into result
+func (c *Client) do(client *http.Client, req *http.Request, result interface{}) error {
+	c.authorize(req)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkStatus(resp); err != nil {
+		return err
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+// authorize adds the Authorization header for the configured auth type
+func (c *Client) authorize(req *http.Request) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.config.AuthType == AuthTypeOAuth2 {
+		req.Header.Set("Authorization", "Bearer "+c.config.AccessToken)
+		return
+	}
+	req.SetBasicAuth(c.config.Username, c.config.Password)
+}
+
+// resolveURL resolves a session URL template against the session URL.
+// URL templates contain braces, so they're joined textually.
+func (c *Client) resolveURL(ref string) string {
+	if ref == "" || strings.Contains(ref, "://") {
+		return ref
+	}
+	base, err := url.Parse(c.config.SessionURL)
+	if err != nil {
+		return ref
+	}
+	if strings.HasPrefix(ref, "/") {
+		return base.Scheme + "://" + base.Host + ref
+	}
+	dir := base.Path[:strings.LastIndex(base.Path, "/")+1]
+	return base.Scheme + "://" + base.Host + dir + ref
+}
@@ -0,0 +1,283 @@
+package jmap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// standIn is a local JMAP server with one mail account whose Email state
+// moves through a fixed history
+type standIn struct {
+	*httptest.Server
+
+	mu             sync.Mutex
+	sessionFetches int
+	sinceStates    []string
+	sessionState   string
+
+	// changes maps a sinceState to the changes reported for it. States
+	// missing from it are too old to calculate changes from.
+	changes map[string]Changes
+
+	// events is written to the EventSource before it's closed, or left
+	// open without further data when hang is set
+	events string
+	hang   bool
+}
+
+func newStandIn(t *testing.T) *standIn {
+	t.Helper()
+	s := &standIn{sessionState: "session1", changes: make(map[string]Changes)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/jmap", s.handleSession)
+	mux.HandleFunc("/api", s.handleAPI)
+	mux.HandleFunc("/events", s.handleEvents)
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *standIn) handleSession(w http.ResponseWriter, r *http.Request) {
+	if user, pass, ok := r.BasicAuth(); !ok || user != "alice" || pass != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	s.mu.Lock()
+	s.sessionFetches++
+	state := s.sessionState
+	s.mu.Unlock()
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			CapCore: map[string]interface{}{},
+			CapMail: map[string]interface{}{},
+		},
+		"accounts": map[string]interface{}{
+			"a1": map[string]interface{}{"name": "alice", "isPersonal": true},
+		},
+		"primaryAccounts": map[string]string{CapMail: "a1"},
+		"username":        "alice",
+		"apiUrl":          "/api",
+		"downloadUrl":     "/download/{accountId}/{blobId}/{name}?type={type}",
+		"uploadUrl":       "/upload/{accountId}/",
+		"eventSourceUrl":  "/events?types={types}&closeafter={closeafter}&ping={ping}",
+		"state":           state,
+	})
+}
+
+func (s *standIn) handleAPI(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		MethodCalls []Invocation `json:"methodCalls"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.MethodCalls) != 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	call := req.MethodCalls[0]
+
+	var args struct {
+		AccountID  string `json:"accountId"`
+		SinceState string `json:"sinceState"`
+	}
+	json.Unmarshal(call.Args, &args)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	respond := func(name string, result interface{}) {
+		data, _ := json.Marshal(result)
+		json.NewEncoder(w).Encode(apiResponse{
+			MethodResponses: []Invocation{{Name: name, Args: data, CallID: call.CallID}},
+			SessionState:    s.sessionState,
+		})
+	}
+
+	if args.AccountID != "a1" {
+		respond("error", MethodError{Type: "accountNotFound"})
+		return
+	}
+	switch call.Name {
+	case "Email/changes":
+		s.sinceStates = append(s.sinceStates, args.SinceState)
+		changes, ok := s.changes[args.SinceState]
+		if !ok {
+			respond("error", MethodError{Type: ErrorCannotCalculateChanges})
+			return
+		}
+		respond(call.Name, changes)
+	default:
+		respond("error", MethodError{Type: "unknownMethod"})
+	}
+}
+
+func (s *standIn) handleEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("types") != "Email,Mailbox" || q.Get("closeafter") != "no" || q.Get("ping") == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	fmt.Fprint(w, s.events)
+	if s.hang {
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}
+}
+
+func (s *standIn) client() *Client {
+	config := DefaultConfig()
+	config.SessionURL = s.URL + "/.well-known/jmap"
+	config.Username = "alice"
+	config.Password = "secret"
+	config.Timeout = 5 * time.Second
+	return NewClient(config)
+}
+
+func TestEmailChangesFollowsStates(t *testing.T) {
+	s := newStandIn(t)
+	s.changes["s1"] = Changes{
+		OldState: "s1", NewState: "s2", HasMoreChanges: true,
+		Created: []string{"e1"},
+	}
+	s.changes["s2"] = Changes{
+		OldState: "s2", NewState: "s3",
+		Updated:   []string{"e2"},
+		Destroyed: []string{"e3"},
+	}
+	s.changes["s3"] = Changes{OldState: "s3", NewState: "s3"}
+
+	c := s.client()
+	ctx := context.Background()
+
+	changes, err := c.EmailChanges(ctx, "s1")
+	if err != nil {
+		t.Fatalf("EmailChanges: %v", err)
+	}
+	want := &Changes{
+		OldState:  "s1",
+		NewState:  "s3",
+		Created:   []string{"e1"},
+		Updated:   []string{"e2"},
+		Destroyed: []string{"e3"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("EmailChanges = %+v, want %+v", changes, want)
+	}
+	if got := strings.Join(s.sinceStates, ","); got != "s1,s2" {
+		t.Errorf("requested changes since %q, want \"s1,s2\"", got)
+	}
+
+	// Caught up: the state is unchanged and nothing is reported
+	changes, err = c.EmailChanges(ctx, "s3")
+	if err != nil {
+		t.Fatalf("EmailChanges: %v", err)
+	}
+	if changes.NewState != "s3" || len(changes.Created)+len(changes.Updated)+len(changes.Destroyed) != 0 {
+		t.Errorf("EmailChanges since the current state = %+v, want no changes", changes)
+	}
+}
+
+func TestEmailChangesCannotCalculate(t *testing.T) {
+	s := newStandIn(t)
+	c := s.client()
+
+	_, err := c.EmailChanges(context.Background(), "expired")
+	if err == nil {
+		t.Fatal("EmailChanges succeeded for an expired state")
+	}
+	if !IsCannotCalculateChanges(err) {
+		t.Errorf("IsCannotCalculateChanges(%v) = false", err)
+	}
+	if IsTemporaryError(err) {
+		t.Errorf("cannotCalculateChanges reported as temporary")
+	}
+}
+
+func TestSessionRefetchedWhenStateChanges(t *testing.T) {
+	s := newStandIn(t)
+	s.changes["s1"] = Changes{OldState: "s1", NewState: "s1"}
+	c := s.client()
+	ctx := context.Background()
+
+	if _, err := c.EmailChanges(ctx, "s1"); err != nil {
+		t.Fatalf("EmailChanges: %v", err)
+	}
+	s.mu.Lock()
+	s.sessionState = "session2"
+	s.mu.Unlock()
+
+	// The response carrying the new session state marks the session stale,
+	// so the call after it fetches the session again
+	for i := 0; i < 2; i++ {
+		if _, err := c.EmailChanges(ctx, "s1"); err != nil {
+			t.Fatalf("EmailChanges: %v", err)
+		}
+	}
+	if s.sessionFetches != 2 {
+		t.Errorf("session fetched %d times, want 2", s.sessionFetches)
+	}
+}
+
+func TestListenReportsStateChanges(t *testing.T) {
+	s := newStandIn(t)
+	s.events = ": ping\n\n" +
+		"event: state\n" +
+		`data: {"@type":"StateChange","changed":{"a1":{"Email":"s4","Mailbox":"m2"}}}` + "\n\n" +
+		"event: state\n" +
+		`data: {"@type":"StateChange","changed":{"other":{"Email":"x9"}}}` + "\n\n" +
+		"event: state\n" +
+		"data: {\"@type\":\"StateChange\",\n" +
+		`data: "changed":{"a1":{"Email":"s5"}}}` + "\n\n"
+
+	c := s.client()
+	var got []map[string]string
+	err := c.Listen(context.Background(), []string{"Email", "Mailbox"}, func(changed map[string]string) {
+		got = append(got, changed)
+	})
+	if err == nil || !strings.Contains(err.Error(), "closed by server") {
+		t.Errorf("Listen returned %v, want the connection closed by the server", err)
+	}
+
+	want := []map[string]string{
+		{"Email": "s4", "Mailbox": "m2"},
+		{"Email": "s5"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("state changes = %v, want %v", got, want)
+	}
+}
+
+func TestListenTimesOutWithoutPings(t *testing.T) {
+	defer func(timeout time.Duration) { pushIdleTimeout = timeout }(pushIdleTimeout)
+	pushIdleTimeout = 200 * time.Millisecond
+
+	s := newStandIn(t)
+	s.events = ": ping\n\n"
+	s.hang = true
+
+	c := s.client()
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Listen(context.Background(), []string{"Email", "Mailbox"}, func(map[string]string) {})
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, errPushTimeout) {
+			t.Errorf("Listen returned %v, want %v", err, errPushTimeout)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Listen kept waiting on a silent connection")
+	}
+}
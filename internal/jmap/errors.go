@@ -0,0 +1,130 @@
+package jmap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ErrUnauthorized indicates the server rejected the credentials
+var ErrUnauthorized = errors.New("JMAP authentication failed")
+
+// Method-level error types (RFC 8620 section 3.6.2)
+const (
+	ErrorCannotCalculateChanges = "cannotCalculateChanges"
+	ErrorServerUnavailable      = "serverUnavailable"
+	ErrorServerFail             = "serverFail"
+	ErrorStateMismatch          = "stateMismatch"
+)
+
+// MethodError is an error response to a single method call
+type MethodError struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+}
+
+func (e *MethodError) Error() string {
+	if e.Description != "" {
+		return e.Type + ": " + e.Description
+	}
+	return e.Type
+}
+
+// SetError is a per-object error in a /set or /import response
+// (RFC 8620 section 5.3)
+type SetError struct {
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Properties  []string `json:"properties,omitempty"`
+}
+
+func (e *SetError) Error() string {
+	msg := e.Type
+	if len(e.Properties) > 0 {
+		msg += " (" + strings.Join(e.Properties, ", ") + ")"
+	}
+	if e.Description != "" {
+		msg += ": " + e.Description
+	}
+	return msg
+}
+
+// StatusError is a request-level failure reported with an HTTP status,
+// including RFC 7807 problem details when the server sent them
+type StatusError struct {
+	Code   int
+	Type   string
+	Detail string
+}
+
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("HTTP %d", e.Code)
+	if e.Type != "" {
+		msg += " " + e.Type
+	}
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return msg
+}
+
+// checkStatus turns a non-2xx response into an error
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return ErrUnauthorized
+	}
+
+	statusErr := &StatusError{Code: resp.StatusCode}
+	var problem struct {
+		Type   string `json:"type"`
+		Detail string `json:"detail"`
+	}
+	if body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024)); err == nil {
+		if json.Unmarshal(body, &problem) == nil {
+			statusErr.Type = problem.Type
+			statusErr.Detail = problem.Detail
+		}
+	}
+	return statusErr
+}
+
+// IsCannotCalculateChanges reports whether a /changes call failed because
+// the server no longer has the changes since the given state. The client
+// has to resync from scratch.
+func IsCannotCalculateChanges(err error) bool {
+	var methodErr *MethodError
+	return errors.As(err, &methodErr) && methodErr.Type == ErrorCannotCalculateChanges
+}
+
+// IsTemporaryError reports whether a failed request is worth retrying later:
+// network failures, rate limiting, server errors and unavailable servers
+func IsTemporaryError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var methodErr *MethodError
+	if errors.As(err, &methodErr) {
+		return methodErr.Type == ErrorServerUnavailable || methodErr.Type == ErrorServerFail
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code == http.StatusTooManyRequests || statusErr.Code >= 500
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	// Dial, DNS and read/write failures
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
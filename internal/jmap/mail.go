@@ -0,0 +1,517 @@
+package jmap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Batch limits, kept under the maxObjectsInGet/maxObjectsInSet most
+// servers advertise
+const (
+	getBatchSize   = 100
+	setBatchSize   = 100
+	queryPageSize  = 500
+	maxChangesPage = 500
+)
+
+// Mailbox roles (RFC 8621 section 2, from the IMAP special-use registry)
+const (
+	RoleInbox   = "inbox"
+	RoleSent    = "sent"
+	RoleDrafts  = "drafts"
+	RoleTrash   = "trash"
+	RoleJunk    = "junk"
+	RoleArchive = "archive"
+	RoleAll     = "all"
+	RoleFlagged = "flagged"
+)
+
+// Keywords with a meaning defined by RFC 8621 section 4.1.1
+const (
+	KeywordSeen      = "$seen"
+	KeywordFlagged   = "$flagged"
+	KeywordAnswered  = "$answered"
+	KeywordDraft     = "$draft"
+	KeywordForwarded = "$forwarded"
+)
+
+// Mailbox is a JMAP Mailbox object
+type Mailbox struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	ParentID     string `json:"parentId,omitempty"`
+	Role         string `json:"role,omitempty"`
+	SortOrder    int    `json:"sortOrder"`
+	TotalEmails  int    `json:"totalEmails"`
+	UnreadEmails int    `json:"unreadEmails"`
+	IsSubscribed bool   `json:"isSubscribed"`
+}
+
+// EmailAddress is a name and address from a header
+type EmailAddress struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Email is a JMAP Email object, limited to the properties in emailProperties
+type Email struct {
+	ID            string          `json:"id"`
+	BlobID        string          `json:"blobId"`
+	ThreadID      string          `json:"threadId"`
+	MailboxIDs    map[string]bool `json:"mailboxIds"`
+	Keywords      map[string]bool `json:"keywords"`
+	Size          int             `json:"size"`
+	ReceivedAt    time.Time       `json:"receivedAt"`
+	MessageID     []string        `json:"messageId"`
+	InReplyTo     []string        `json:"inReplyTo"`
+	References    []string        `json:"references"`
+	From          []EmailAddress  `json:"from"`
+	To            []EmailAddress  `json:"to"`
+	Cc            []EmailAddress  `json:"cc"`
+	Bcc           []EmailAddress  `json:"bcc"`
+	ReplyTo       []EmailAddress  `json:"replyTo"`
+	Subject       string          `json:"subject"`
+	SentAt        *time.Time      `json:"sentAt"`
+	HasAttachment bool            `json:"hasAttachment"`
+	Preview       string          `json:"preview"`
+}
+
+// emailProperties are fetched for every Email. Bodies are downloaded as
+// raw blobs and parsed like IMAP messages.
+var emailProperties = []string{
+	"id", "blobId", "threadId", "mailboxIds", "keywords", "size", "receivedAt",
+	"messageId", "inReplyTo", "references", "from", "to", "cc", "bcc", "replyTo",
+	"subject", "sentAt", "hasAttachment", "preview",
+}
+
+// Identity is a JMAP Identity, an address the user may send from
+type Identity struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Changes is the result of a /changes call
+type Changes struct {
+	OldState       string   `json:"oldState"`
+	NewState       string   `json:"newState"`
+	HasMoreChanges bool     `json:"hasMoreChanges"`
+	Created        []string `json:"created"`
+	Updated        []string `json:"updated"`
+	Destroyed      []string `json:"destroyed"`
+}
+
+// EmailFilter is a FilterCondition for Email/query
+type EmailFilter struct {
+	InMailbox string     `json:"inMailbox,omitempty"`
+	After     *time.Time `json:"after,omitempty"`
+	Text      string     `json:"text,omitempty"`
+}
+
+// setResponse is the common part of /set and /import responses
+type setResponse struct {
+	NewState     string                     `json:"newState"`
+	Created      map[string]*Email          `json:"created"`
+	NotCreated   map[string]*SetError       `json:"notCreated"`
+	NotUpdated   map[string]*SetError       `json:"notUpdated"`
+	NotDestroyed map[string]*SetError       `json:"notDestroyed"`
+	Updated      map[string]map[string]bool `json:"updated"`
+}
+
+// firstError returns one of the per-object errors, if any
+func (r *setResponse) firstError() error {
+	for _, errs := range []map[string]*SetError{r.NotCreated, r.NotUpdated, r.NotDestroyed} {
+		for id, setErr := range errs {
+			return fmt.Errorf("%s: %w", id, setErr)
+		}
+	}
+	return nil
+}
+
+// GetMailboxes returns all mailboxes and the Mailbox state
+func (c *Client) GetMailboxes(ctx context.Context) ([]*Mailbox, string, error) {
+	var resp struct {
+		State string     `json:"state"`
+		List  []*Mailbox `json:"list"`
+	}
+	if err := c.Call(ctx, "Mailbox/get", map[string]interface{}{"ids": nil}, &resp); err != nil {
+		return nil, "", err
+	}
+	return resp.List, resp.State, nil
+}
+
+// MailboxPaths returns the slash-separated path of each mailbox, keyed by
+// mailbox ID
+func MailboxPaths(mailboxes []*Mailbox) map[string]string {
+	byID := make(map[string]*Mailbox, len(mailboxes))
+	for _, mb := range mailboxes {
+		byID[mb.ID] = mb
+	}
+
+	paths := make(map[string]string, len(mailboxes))
+	var pathOf func(mb *Mailbox, depth int) string
+	pathOf = func(mb *Mailbox, depth int) string {
+		if p, ok := paths[mb.ID]; ok {
+			return p
+		}
+		p := mb.Name
+		// Depth guard against parent cycles from a broken server
+		if parent, ok := byID[mb.ParentID]; ok && depth < 32 {
+			p = pathOf(parent, depth+1) + "/" + mb.Name
+		}
+		paths[mb.ID] = p
+		return p
+	}
+	for _, mb := range mailboxes {
+		pathOf(mb, 0)
+	}
+	return paths
+}
+
+// EmailState returns the current Email state without fetching anything
+func (c *Client) EmailState(ctx context.Context) (string, error) {
+	var resp struct {
+		State string `json:"state"`
+	}
+	err := c.Call(ctx, "Email/get", map[string]interface{}{
+		"ids":        []string{},
+		"properties": []string{"id"},
+	}, &resp)
+	return resp.State, err
+}
+
+// QueryEmails returns the IDs of all Emails matching a filter, newest first
+func (c *Client) QueryEmails(ctx context.Context, filter EmailFilter) ([]string, error) {
+	var ids []string
+	for {
+		var resp struct {
+			IDs   []string `json:"ids"`
+			Total int      `json:"total"`
+		}
+		err := c.Call(ctx, "Email/query", map[string]interface{}{
+			"filter":         filter,
+			"sort":           []map[string]interface{}{{"property": "receivedAt", "isAscending": false}},
+			"position":       len(ids),
+			"limit":          queryPageSize,
+			"calculateTotal": true,
+		}, &resp)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, resp.IDs...)
+		if len(resp.IDs) == 0 || len(ids) >= resp.Total {
+			return ids, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// GetEmails fetches Emails by ID. IDs the server doesn't know are skipped.
+func (c *Client) GetEmails(ctx context.Context, ids []string) ([]*Email, error) {
+	var emails []*Email
+	for start := 0; start < len(ids); start += getBatchSize {
+		end := min(start+getBatchSize, len(ids))
+		var resp struct {
+			List []*Email `json:"list"`
+		}
+		err := c.Call(ctx, "Email/get", map[string]interface{}{
+			"ids":        ids[start:end],
+			"properties": emailProperties,
+		}, &resp)
+		if err != nil {
+			return nil, err
+		}
+		emails = append(emails, resp.List...)
+	}
+	return emails, nil
+}
+
+// EmailChanges returns Email changes since a state, following
+// hasMoreChanges until caught up. Fails with cannotCalculateChanges when
+// the state is too old.
+func (c *Client) EmailChanges(ctx context.Context, sinceState string) (*Changes, error) {
+	all := &Changes{OldState: sinceState, NewState: sinceState}
+	for {
+		var resp Changes
+		err := c.Call(ctx, "Email/changes", map[string]interface{}{
+			"sinceState": all.NewState,
+			"maxChanges": maxChangesPage,
+		}, &resp)
+		if err != nil {
+			return nil, err
+		}
+		all.Created = append(all.Created, resp.Created...)
+		all.Updated = append(all.Updated, resp.Updated...)
+		all.Destroyed = append(all.Destroyed, resp.Destroyed...)
+		all.NewState = resp.NewState
+		if !resp.HasMoreChanges || resp.NewState == resp.OldState {
+			return all, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// SetKeywords adds or removes keywords on Emails
+func (c *Client) SetKeywords(ctx context.Context, ids []string, keywords []string, add bool) error {
+	patch := make(map[string]interface{}, len(keywords))
+	for _, kw := range keywords {
+		if add {
+			patch["keywords/"+kw] = true
+		} else {
+			patch["keywords/"+kw] = nil
+		}
+	}
+	return c.updateEmails(ctx, ids, patch)
+}
+
+// MoveEmails moves Emails from one mailbox to another. Other mailboxes an
+// Email is in are left alone.
+func (c *Client) MoveEmails(ctx context.Context, ids []string, fromMailboxID, toMailboxID string) error {
+	return c.updateEmails(ctx, ids, map[string]interface{}{
+		"mailboxIds/" + fromMailboxID: nil,
+		"mailboxIds/" + toMailboxID:   true,
+	})
+}
+
+// CopyEmails adds Emails to a mailbox. Within an account a JMAP Email is
+// one object in several mailboxes, so no new Email is created.
+func (c *Client) CopyEmails(ctx context.Context, ids []string, toMailboxID string) error {
+	return c.updateEmails(ctx, ids, map[string]interface{}{
+		"mailboxIds/" + toMailboxID: true,
+	})
+}
+
+// RemoveEmails removes Emails from a mailbox. Emails in no other mailbox
+// are destroyed, since an Email must belong to at least one.
+func (c *Client) RemoveEmails(ctx context.Context, ids []string, mailboxID string) error {
+	var resp struct {
+		List []*Email `json:"list"`
+	}
+	err := c.Call(ctx, "Email/get", map[string]interface{}{
+		"ids":        ids,
+		"properties": []string{"id", "mailboxIds"},
+	}, &resp)
+	if err != nil {
+		return err
+	}
+
+	var destroy, unlink []string
+	for _, email := range resp.List {
+		others := 0
+		for id, in := range email.MailboxIDs {
+			if in && id != mailboxID {
+				others++
+			}
+		}
+		if others == 0 {
+			destroy = append(destroy, email.ID)
+		} else {
+			unlink = append(unlink, email.ID)
+		}
+	}
+
+	if len(unlink) > 0 {
+		if err := c.updateEmails(ctx, unlink, map[string]interface{}{"mailboxIds/" + mailboxID: nil}); err != nil {
+			return err
+		}
+	}
+	return c.DestroyEmails(ctx, destroy)
+}
+
+// DestroyEmails permanently deletes Emails
+func (c *Client) DestroyEmails(ctx context.Context, ids []string) error {
+	for start := 0; start < len(ids); start += setBatchSize {
+		end := min(start+setBatchSize, len(ids))
+		var resp setResponse
+		if err := c.Call(ctx, "Email/set", map[string]interface{}{"destroy": ids[start:end]}, &resp); err != nil {
+			return err
+		}
+		if err := resp.firstError(); err != nil {
+			// Already gone, which is what we wanted
+			var setErr *SetError
+			if !errors.As(err, &setErr) || setErr.Type != "notFound" {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// updateEmails applies the same patch to Emails with Email/set
+func (c *Client) updateEmails(ctx context.Context, ids []string, patch map[string]interface{}) error {
+	for start := 0; start < len(ids); start += setBatchSize {
+		end := min(start+setBatchSize, len(ids))
+		update := make(map[string]interface{}, end-start)
+		for _, id := range ids[start:end] {
+			update[id] = patch
+		}
+		var resp setResponse
+		if err := c.Call(ctx, "Email/set", map[string]interface{}{"update": update}, &resp); err != nil {
+			return err
+		}
+		if err := resp.firstError(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportEmail uploads a raw RFC 5322 message and adds it to mailboxes
+// with the given keywords. Returns the new Email's ID.
+func (c *Client) ImportEmail(ctx context.Context, raw []byte, mailboxIDs []string, keywords []string, receivedAt time.Time) (string, error) {
+	blobID, err := c.Upload(ctx, "message/rfc822", raw)
+	if err != nil {
+		return "", err
+	}
+
+	mailboxes := make(map[string]bool, len(mailboxIDs))
+	for _, id := range mailboxIDs {
+		mailboxes[id] = true
+	}
+	kws := make(map[string]bool, len(keywords))
+	for _, kw := range keywords {
+		kws[kw] = true
+	}
+
+	var resp setResponse
+	err = c.Call(ctx, "Email/import", map[string]interface{}{
+		"emails": map[string]interface{}{
+			"import": map[string]interface{}{
+				"blobId":     blobID,
+				"mailboxIds": mailboxes,
+				"keywords":   kws,
+				"receivedAt": receivedAt.UTC().Format(time.RFC3339),
+			},
+		},
+	}, &resp)
+	if err != nil {
+		return "", err
+	}
+	if err := resp.firstError(); err != nil {
+		return "", err
+	}
+	created := resp.Created["import"]
+	if created == nil || created.ID == "" {
+		return "", fmt.Errorf("Email/import failed: no Email created")
+	}
+	return created.ID, nil
+}
+
+// GetIdentities returns the identities the user may send from
+func (c *Client) GetIdentities(ctx context.Context) ([]*Identity, error) {
+	var resp struct {
+		List []*Identity `json:"list"`
+	}
+	if err := c.Call(ctx, "Identity/get", map[string]interface{}{"ids": nil}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.List, nil
+}
+
+// IdentityFor returns the identity matching a From address, falling back
+// to one on the same domain and then the first identity
+func IdentityFor(identities []*Identity, from string) *Identity {
+	if len(identities) == 0 {
+		return nil
+	}
+	from = strings.ToLower(from)
+	for _, id := range identities {
+		if strings.ToLower(id.Email) == from {
+			return id
+		}
+	}
+	// Wildcard identities look like *@example.com
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		for _, id := range identities {
+			if strings.ToLower(id.Email) == "*"+from[at:] {
+				return id
+			}
+		}
+	}
+	return identities[0]
+}
+
+// Envelope is the SMTP envelope of a submission
+type Envelope struct {
+	MailFrom string
+	RcptTo   []string
+}
+
+// Submit sends an Email with EmailSubmission/set. On success the server
+// moves it from draftsMailboxID (if set) to sentMailboxID and clears
+// $draft. With no sent mailbox the Email is destroyed after sending.
+func (c *Client) Submit(ctx context.Context, emailID, identityID string, envelope Envelope, draftsMailboxID, sentMailboxID string) error {
+	rcptTo := make([]map[string]string, len(envelope.RcptTo))
+	for i, rcpt := range envelope.RcptTo {
+		rcptTo[i] = map[string]string{"email": rcpt}
+	}
+
+	args := map[string]interface{}{
+		"create": map[string]interface{}{
+			"send": map[string]interface{}{
+				"identityId": identityID,
+				"emailId":    emailID,
+				"envelope": map[string]interface{}{
+					"mailFrom": map[string]string{"email": envelope.MailFrom},
+					"rcptTo":   rcptTo,
+				},
+			},
+		},
+	}
+
+	if sentMailboxID != "" {
+		update := map[string]interface{}{
+			"keywords/" + KeywordDraft:    nil,
+			"keywords/" + KeywordSeen:     true,
+			"mailboxIds/" + sentMailboxID: true,
+		}
+		if draftsMailboxID != "" && draftsMailboxID != sentMailboxID {
+			update["mailboxIds/"+draftsMailboxID] = nil
+		}
+		args["onSuccessUpdateEmail"] = map[string]interface{}{"#send": update}
+	} else {
+		args["onSuccessDestroyEmail"] = []string{"#send"}
+	}
+
+	var resp setResponse
+	if err := c.Call(ctx, "EmailSubmission/set", args, &resp); err != nil {
+		return err
+	}
+	return resp.firstError()
+}
+
+// KeywordForFlag maps an IMAP flag to its JMAP keyword. Keywords are
+// case-insensitive and servers return them lowercased.
+func KeywordForFlag(flag string) string {
+	switch strings.ToLower(flag) {
+	case `\seen`:
+		return KeywordSeen
+	case `\flagged`:
+		return KeywordFlagged
+	case `\answered`:
+		return KeywordAnswered
+	case `\draft`:
+		return KeywordDraft
+	}
+	return strings.ToLower(flag)
+}
+
+// SortedKeywords returns the keywords set on an Email
+func (e *Email) SortedKeywords() []string {
+	keywords := make([]string, 0, len(e.Keywords))
+	for kw, set := range e.Keywords {
+		if set {
+			keywords = append(keywords, kw)
+		}
+	}
+	sort.Strings(keywords)
+	return keywords
+}
@@ -0,0 +1,59 @@
+package jmap
+
+import (
+	"context"
+	"sync"
+)
+
+// Manager keeps one Client per account so sessions are reused across
+// syncs, actions and push
+type Manager struct {
+	// Credentials provider function
+	getConfig func(accountID string) (*ClientConfig, error)
+
+	mu      sync.Mutex
+	clients map[string]*Client
+}
+
+// NewManager creates a client manager. getConfig is called on every
+// Client call so refreshed OAuth2 tokens are picked up.
+func NewManager(getConfig func(accountID string) (*ClientConfig, error)) *Manager {
+	return &Manager{
+		getConfig: getConfig,
+		clients:   make(map[string]*Client),
+	}
+}
+
+// Client returns the client for an account, creating and connecting it on
+// first use
+func (m *Manager) Client(ctx context.Context, accountID string) (*Client, error) {
+	config, err := m.getConfig(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	client, ok := m.clients[accountID]
+	if ok && client.SessionURL() == config.SessionURL {
+		client.UpdateAuth(*config)
+		m.mu.Unlock()
+		return client, nil
+	}
+	client = NewClient(*config)
+	m.clients[accountID] = client
+	m.mu.Unlock()
+
+	if _, err := client.Connect(ctx); err != nil {
+		m.CloseAccount(accountID)
+		return nil, err
+	}
+	return client, nil
+}
+
+// CloseAccount drops the client for an account, e.g. after it was edited
+// or deleted
+func (m *Manager) CloseAccount(accountID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.clients, accountID)
+}
@@ -0,0 +1,143 @@
+package jmap
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// pushPingSeconds asks the server to send a ping this often, so a dead
+// connection is noticed
+const pushPingSeconds = 60
+
+// pushIdleTimeout is how long Listen waits for any line, pings included,
+// before giving the connection up as dead
+var pushIdleTimeout = 2*pushPingSeconds*time.Second + 30*time.Second
+
+// errPushTimeout reports an EventSource connection that went silent
+var errPushTimeout = errors.New("event source timed out waiting for ping")
+
+// stateChange is the data of a "state" event (RFC 8620 section 7.1)
+type stateChange struct {
+	Type    string                       `json:"@type"`
+	Changed map[string]map[string]string `json:"changed"`
+}
+
+// Listen opens the session's EventSource and calls onChange with the new
+// state of each changed data type ("Email", "Mailbox", ...) in the primary
+// mail account. It blocks until ctx is done or the connection drops; the
+// caller reconnects.
+func (c *Client) Listen(ctx context.Context, types []string, onChange func(changed map[string]string)) error {
+	session, err := c.Session(ctx)
+	if err != nil {
+		return err
+	}
+	if session.EventSourceURL == "" {
+		return fmt.Errorf("server does not support push")
+	}
+	accountID, err := c.AccountID(ctx)
+	if err != nil {
+		return err
+	}
+
+	typeList := "*"
+	if len(types) > 0 {
+		typeList = strings.Join(types, ",")
+	}
+	eventURL := strings.NewReplacer(
+		"{types}", typeList,
+		"{closeafter}", "no",
+		"{ping}", fmt.Sprint(pushPingSeconds),
+	).Replace(session.EventSourceURL)
+
+	// A half-open connection never ends the read; the watchdog cancels the
+	// request once nothing, not even a ping, arrived for pushIdleTimeout
+	reqCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	watchdog := time.AfterFunc(pushIdleTimeout, func() { cancel(errPushTimeout) })
+	defer watchdog.Stop()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, eventURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	c.authorize(req)
+
+	resp, err := c.push.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to event source: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkStatus(resp); err != nil {
+		return fmt.Errorf("failed to connect to event source: %w", err)
+	}
+
+	c.log.Debug().Str("account", accountID).Msg("Listening for JMAP push events")
+
+	// Server-sent events: "field: value" lines, blank line ends an event
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var event string
+	var data strings.Builder
+	for scanner.Scan() {
+		watchdog.Reset(pushIdleTimeout)
+		line := scanner.Text()
+		if line == "" {
+			if event == "state" || (event == "" && data.Len() > 0) {
+				c.dispatchStateChange(accountID, data.String(), onChange)
+			}
+			event = ""
+			data.Reset()
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // Comment
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if errors.Is(context.Cause(reqCtx), errPushTimeout) {
+		return errPushTimeout
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("event source connection lost: %w", err)
+	}
+	return fmt.Errorf("event source closed by server")
+}
+
+// dispatchStateChange decodes a state event and reports changes in accountID
+func (c *Client) dispatchStateChange(accountID, data string, onChange func(changed map[string]string)) {
+	var change stateChange
+	if err := json.Unmarshal([]byte(data), &change); err != nil {
+		c.log.Debug().Err(err).Msg("Ignoring malformed push event")
+		return
+	}
+	if change.Type != "" && change.Type != "StateChange" {
+		return
+	}
+	if changed := change.Changed[accountID]; len(changed) > 0 {
+		onChange(changed)
+	}
+}
@@ -0,0 +1,56 @@
+package jmap
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/hkdb/aerion/internal/database"
+)
+
+// Store persists the JMAP state strings delta sync resumes from
+type Store struct {
+	db *database.DB
+}
+
+// NewStore creates a new JMAP state store
+func NewStore(db *database.DB) *Store {
+	return &Store{db: db}
+}
+
+// GetState returns the last state seen for a data type ("Email",
+// "Mailbox"), or "" if the account hasn't been synced yet
+func (s *Store) GetState(accountID, dataType string) (string, error) {
+	var state string
+	err := s.db.QueryRow(
+		"SELECT state FROM jmap_states WHERE account_id = ? AND type = ?",
+		accountID, dataType,
+	).Scan(&state)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get JMAP state: %w", err)
+	}
+	return state, nil
+}
+
+// SetState records the state a data type has been synced up to
+func (s *Store) SetState(accountID, dataType, state string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO jmap_states (account_id, type, state) VALUES (?, ?, ?)
+		ON CONFLICT(account_id, type) DO UPDATE SET state = excluded.state
+	`, accountID, dataType, state)
+	if err != nil {
+		return fmt.Errorf("failed to set JMAP state: %w", err)
+	}
+	return nil
+}
+
+// DeleteState forgets a data type's state, forcing a full resync
+func (s *Store) DeleteState(accountID, dataType string) error {
+	_, err := s.db.Exec("DELETE FROM jmap_states WHERE account_id = ? AND type = ?", accountID, dataType)
+	if err != nil {
+		return fmt.Errorf("failed to delete JMAP state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,392 @@
+// Package maildir reads and writes Maildir folders, so local folders can be
+// shared with other Maildir tools such as mbsync, offlineimap or mutt
+package maildir
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNotFound is returned when no file in a Maildir has the given key
+var ErrNotFound = errors.New("message not found in Maildir")
+
+// Flag letters of the info part of a file name
+const (
+	FlagDraft   = 'D'
+	FlagFlagged = 'F'
+	FlagPassed  = 'P' // Forwarded
+	FlagReplied = 'R'
+	FlagSeen    = 'S'
+	FlagTrashed = 'T' // Marked for deletion
+)
+
+// infoSeparator starts the info part of a file name. Windows doesn't allow
+// colons in file names, so tools there use a semicolon instead.
+var infoSeparator = ":"
+
+func init() {
+	if runtime.GOOS == "windows" {
+		infoSeparator = ";"
+	}
+}
+
+// mbsyncUID matches the UID mbsync keeps in file names. It's only valid in
+// the folder mbsync saw the file in, so it's dropped when a file moves.
+var mbsyncUID = regexp.MustCompile(`,U=[0-9]+`)
+
+// deliveries makes unique names unique within this process
+var deliveries atomic.Uint64
+
+// Dir is a single Maildir folder: a directory holding cur, new and tmp
+type Dir string
+
+// Entry is a message file in a Maildir folder
+type Entry struct {
+	Key   string // Unique name, without the info part
+	Flags string // Flag letters, sorted
+	New   bool   // Still in new, not yet seen by a mail reader
+}
+
+// Create creates the folder's cur, new and tmp directories if missing
+func (d Dir) Create() error {
+	for _, sub := range []string{"cur", "new", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(string(d), sub), 0o700); err != nil {
+			return fmt.Errorf("failed to create Maildir folder: %w", err)
+		}
+	}
+	return nil
+}
+
+// IsMaildir reports whether the directory is a Maildir folder
+func (d Dir) IsMaildir() bool {
+	for _, sub := range []string{"cur", "new"} {
+		info, err := os.Stat(filepath.Join(string(d), sub))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// List returns the messages in new and cur
+func (d Dir) List() ([]Entry, error) {
+	idx, err := d.Index()
+	if err != nil {
+		return nil, err
+	}
+	return idx.entries, nil
+}
+
+// Deliver writes a message into the folder and returns its key. The file
+// is written to tmp first, so other readers never see it half written.
+func (d Dir) Deliver(raw []byte, flags string) (string, error) {
+	key := uniqueName()
+	tmp := filepath.Join(string(d), "tmp", key)
+
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Maildir file: %w", err)
+	}
+	if _, err := f.Write(raw); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to write Maildir file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to write Maildir file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to write Maildir file: %w", err)
+	}
+
+	if err := os.Rename(tmp, d.curPath(key, flags)); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to deliver Maildir file: %w", err)
+	}
+	return key, nil
+}
+
+// Read returns the raw message stored under key
+func (d Dir) Read(key string) ([]byte, error) {
+	path, _, err := d.find(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+// SetFlags replaces a message's flags. Messages still in new are moved to
+// cur, as a mail reader does once it has seen them.
+func (d Dir) SetFlags(key, flags string) error {
+	path, _, err := d.find(key)
+	if err != nil {
+		return err
+	}
+	_, err = d.setFlags(path, key, flags)
+	return err
+}
+
+// Remove deletes a message. Messages already gone are ignored.
+func (d Dir) Remove(key string) error {
+	path, _, err := d.find(key)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return removeFile(path)
+}
+
+// MoveTo moves a message into another folder, keeping its flags, and
+// returns its key there
+func (d Dir) MoveTo(key string, dest Dir) (string, error) {
+	path, flags, err := d.find(key)
+	if err != nil {
+		return "", err
+	}
+	return moveFile(path, key, flags, dest)
+}
+
+// CopyTo copies a message into another folder, keeping its flags, and
+// returns the key of the copy
+func (d Dir) CopyTo(key string, dest Dir) (string, error) {
+	path, flags, err := d.find(key)
+	if err != nil {
+		return "", err
+	}
+	return copyFile(path, flags, dest)
+}
+
+// find returns the path and flags of the file stored under key. It lists
+// the folder, so operations on many messages should use an Index instead.
+func (d Dir) find(key string) (string, string, error) {
+	// Files in new usually have no info part yet
+	newPath := filepath.Join(string(d), "new", key)
+	if _, err := os.Stat(newPath); err == nil {
+		return newPath, "", nil
+	}
+
+	idx, err := d.Index()
+	if err != nil {
+		return "", "", err
+	}
+	return idx.find(key)
+}
+
+// Index maps the keys of a folder's messages to their files. Looking up
+// many messages through one Index lists the folder once rather than once
+// per message. It only tracks changes made through it.
+type Index struct {
+	dir     Dir
+	entries []Entry
+	files   map[string]indexedFile
+}
+
+// indexedFile is where the file of an indexed message is
+type indexedFile struct {
+	path  string
+	flags string
+}
+
+// Index lists the folder's messages for lookups by key
+func (d Dir) Index() (*Index, error) {
+	idx := &Index{dir: d, files: make(map[string]indexedFile)}
+	for _, sub := range []string{"new", "cur"} {
+		files, err := os.ReadDir(filepath.Join(string(d), sub))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list Maildir folder: %w", err)
+		}
+		for _, f := range files {
+			if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+				continue
+			}
+			key, flags := parseName(f.Name())
+			idx.entries = append(idx.entries, Entry{Key: key, Flags: flags, New: sub == "new"})
+			idx.files[key] = indexedFile{path: filepath.Join(string(d), sub, f.Name()), flags: flags}
+		}
+	}
+	return idx, nil
+}
+
+// Entries returns the messages in new and cur when the index was built
+func (x *Index) Entries() []Entry {
+	return x.entries
+}
+
+// Read returns the raw message stored under key
+func (x *Index) Read(key string) ([]byte, error) {
+	path, _, err := x.find(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+// SetFlags replaces a message's flags, moving it to cur if it's in new
+func (x *Index) SetFlags(key, flags string) error {
+	path, _, err := x.find(key)
+	if err != nil {
+		return err
+	}
+	newPath, err := x.dir.setFlags(path, key, flags)
+	if err != nil {
+		return err
+	}
+	x.files[key] = indexedFile{path: newPath, flags: NormalizeFlags(flags)}
+	return nil
+}
+
+// Remove deletes a message. Messages already gone are ignored.
+func (x *Index) Remove(key string) error {
+	path, _, err := x.find(key)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err := removeFile(path); err != nil {
+		return err
+	}
+	delete(x.files, key)
+	return nil
+}
+
+// MoveTo moves a message into another folder, keeping its flags, and
+// returns its key there
+func (x *Index) MoveTo(key string, dest Dir) (string, error) {
+	path, flags, err := x.find(key)
+	if err != nil {
+		return "", err
+	}
+	newKey, err := moveFile(path, key, flags, dest)
+	if err != nil {
+		return "", err
+	}
+	delete(x.files, key)
+	return newKey, nil
+}
+
+// CopyTo copies a message into another folder, keeping its flags, and
+// returns the key of the copy
+func (x *Index) CopyTo(key string, dest Dir) (string, error) {
+	path, flags, err := x.find(key)
+	if err != nil {
+		return "", err
+	}
+	return copyFile(path, flags, dest)
+}
+
+// find returns the path and flags of the file stored under key
+func (x *Index) find(key string) (string, string, error) {
+	f, ok := x.files[key]
+	if !ok {
+		return "", "", ErrNotFound
+	}
+	return f.path, f.flags, nil
+}
+
+// setFlags renames the file at path to carry flags in cur and returns its
+// new path
+func (d Dir) setFlags(path, key, flags string) (string, error) {
+	newPath := d.curPath(key, flags)
+	if newPath == path {
+		return path, nil
+	}
+	if err := os.Rename(path, newPath); err != nil {
+		return "", fmt.Errorf("failed to set Maildir flags: %w", err)
+	}
+	return newPath, nil
+}
+
+// removeFile deletes a message file, ignoring one that's already gone
+func removeFile(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove Maildir file: %w", err)
+	}
+	return nil
+}
+
+// moveFile moves the file of key into dest and returns its key there
+func moveFile(path, key, flags string, dest Dir) (string, error) {
+	newKey := mbsyncUID.ReplaceAllString(key, "")
+	newPath := dest.curPath(newKey, flags)
+	if _, err := os.Stat(newPath); err == nil {
+		// Should never happen with unique names, but don't overwrite
+		newKey = uniqueName()
+		newPath = dest.curPath(newKey, flags)
+	}
+
+	if err := os.Rename(path, newPath); err != nil {
+		return "", fmt.Errorf("failed to move Maildir file: %w", err)
+	}
+	return newKey, nil
+}
+
+// copyFile delivers a copy of the file at path into dest
+func copyFile(path, flags string, dest Dir) (string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Maildir file: %w", err)
+	}
+	return dest.Deliver(raw, flags)
+}
+
+// curPath returns the path of a message in cur with the given flags
+func (d Dir) curPath(key, flags string) string {
+	return filepath.Join(string(d), "cur", key+infoSeparator+"2,"+NormalizeFlags(flags))
+}
+
+// parseName splits a file name into its key and flags
+func parseName(name string) (string, string) {
+	i := strings.LastIndex(name, infoSeparator+"2,")
+	if i < 0 {
+		return name, ""
+	}
+	return name[:i], NormalizeFlags(name[i+3:])
+}
+
+// NormalizeFlags sorts flag letters and drops duplicates, as the info part
+// requires
+func NormalizeFlags(flags string) string {
+	letters := []byte(flags)
+	sort.Slice(letters, func(i, j int) bool { return letters[i] < letters[j] })
+
+	var b strings.Builder
+	for i, c := range letters {
+		if i > 0 && c == letters[i-1] {
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// uniqueName returns a new file name in the time.Mpid.host form
+func uniqueName() string {
+	now := time.Now()
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "localhost"
+	}
+	host = strings.NewReplacer("/", `\057`, ":", `\072`, ";", `\073`).Replace(host)
+
+	return strconv.FormatInt(now.Unix(), 10) +
+		".M" + strconv.Itoa(now.Nanosecond()/1000) +
+		"P" + strconv.Itoa(os.Getpid()) +
+		"Q" + strconv.FormatUint(deliveries.Add(1), 10) +
+		"." + host
+}
@@ -0,0 +1,126 @@
+package maildir
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Tree is a tree of Maildir folders in the verbatim layout mbsync
+// ("SubFolders Verbatim") and offlineimap (sep = /) use: the folder
+// Archive/2024 is the directory <root>/Archive/2024.
+type Tree string
+
+// Create creates the tree's root directory if missing
+func (t Tree) Create() error {
+	if err := os.MkdirAll(string(t), 0o700); err != nil {
+		return fmt.Errorf("failed to create Maildir tree: %w", err)
+	}
+	return nil
+}
+
+// Check returns an error unless the tree's root directory exists. A missing
+// root usually means the disk holding it isn't mounted, and must not be
+// mistaken for a tree whose messages were all deleted.
+func (t Tree) Check() error {
+	info, err := os.Stat(string(t))
+	if err != nil {
+		return fmt.Errorf("Maildir tree not available: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("Maildir tree is not a directory: %s", t)
+	}
+	return nil
+}
+
+// Folder returns the Maildir folder at a "/"-separated folder path
+func (t Tree) Folder(path string) (Dir, error) {
+	parts := strings.Split(path, "/")
+	for _, part := range parts {
+		switch part {
+		case "", ".", "..", "cur", "new", "tmp":
+			return "", fmt.Errorf("invalid Maildir folder name: %q", part)
+		}
+		if strings.ContainsAny(part, `/\`) {
+			return "", fmt.Errorf("invalid Maildir folder name: %q", part)
+		}
+	}
+	return Dir(filepath.Join(append([]string{string(t)}, parts...)...)), nil
+}
+
+// Folders returns the paths of the Maildir folders in the tree
+func (t Tree) Folders() ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(string(t), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == string(t) && errors.Is(err, os.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if !d.IsDir() || path == string(t) {
+			return nil
+		}
+
+		switch name := d.Name(); {
+		case name == "cur" || name == "new" || name == "tmp":
+			return fs.SkipDir
+		case strings.HasPrefix(name, "."):
+			return fs.SkipDir
+		}
+
+		if Dir(path).IsMaildir() {
+			rel, err := filepath.Rel(string(t), path)
+			if err != nil {
+				return err
+			}
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan Maildir tree: %w", err)
+	}
+	return paths, nil
+}
+
+// RenameFolder renames a folder along with its subfolders
+func (t Tree) RenameFolder(oldPath, newPath string) error {
+	from, err := t.Folder(oldPath)
+	if err != nil {
+		return err
+	}
+	to, err := t.Folder(newPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(string(to)); err == nil {
+		return fmt.Errorf("folder already exists in Maildir tree: %s", newPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(string(to)), 0o700); err != nil {
+		return fmt.Errorf("failed to create Maildir folder: %w", err)
+	}
+	if err := os.Rename(string(from), string(to)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return to.Create()
+		}
+		return fmt.Errorf("failed to rename Maildir folder: %w", err)
+	}
+	return nil
+}
+
+// RemoveFolder deletes a folder along with its subfolders and messages
+func (t Tree) RemoveFolder(path string) error {
+	dir, err := t.Folder(path)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(string(dir)); err != nil {
+		return fmt.Errorf("failed to remove Maildir folder: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package mailmerge
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/mail"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Column names recognized for the recipient's address and name, after
+// FieldKey normalization and lowercasing
+var (
+	emailColumns     = []string{"email", "emailaddress", "mail"}
+	nameColumns      = []string{"name", "fullname", "displayname"}
+	firstNameColumns = []string{"firstname", "givenname"}
+	lastNameColumns  = []string{"lastname", "surname", "familyname"}
+)
+
+// ParseCSV reads a recipient list from CSV with a header row. The address
+// comes from an "Email" column, and the name from a "Name" column or
+// "First Name" and "Last Name" columns. Every column is available as a
+// variable. Rows without a valid address, and repeated addresses, are
+// skipped and counted.
+func ParseCSV(r io.Reader) (*List, error) {
+	br := bufio.NewReader(r)
+
+	// Drop a UTF-8 byte order mark, as written by spreadsheet exports
+	if bom, _ := br.Peek(3); bytes.Equal(bom, []byte{0xEF, 0xBB, 0xBF}) {
+		br.Discard(3)
+	}
+
+	// Spreadsheets in locales with decimal commas export with semicolons
+	firstLine, _ := br.Peek(4096)
+	if i := bytes.IndexByte(firstLine, '\n'); i >= 0 {
+		firstLine = firstLine[:i]
+	}
+
+	reader := csv.NewReader(br)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	list := &List{}
+	keys := make([]string, len(header))
+	emailCol, nameCol, firstCol, lastCol := -1, -1, -1, -1
+	for i, h := range header {
+		keys[i] = FieldKey(h)
+		if keys[i] != "" && !slices.Contains(list.Columns, keys[i]) {
+			list.Columns = append(list.Columns, keys[i])
+		}
+		lower := strings.ToLower(keys[i])
+		switch {
+		case emailCol < 0 && slices.Contains(emailColumns, lower):
+			emailCol = i
+		case nameCol < 0 && slices.Contains(nameColumns, lower):
+			nameCol = i
+		case firstCol < 0 && slices.Contains(firstNameColumns, lower):
+			firstCol = i
+		case lastCol < 0 && slices.Contains(lastNameColumns, lower):
+			lastCol = i
+		}
+	}
+	if emailCol < 0 {
+		return nil, fmt.Errorf("CSV has no email column")
+	}
+
+	seen := make(map[string]bool)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+
+		cell := func(i int) string {
+			if i < 0 || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		addr, err := mail.ParseAddress(cell(emailCol))
+		if err != nil || seen[strings.ToLower(addr.Address)] {
+			list.Skipped++
+			continue
+		}
+		seen[strings.ToLower(addr.Address)] = true
+
+		name := cell(nameCol)
+		if name == "" {
+			name = strings.TrimSpace(cell(firstCol) + " " + cell(lastCol))
+		}
+		if name == "" {
+			name = addr.Name
+		}
+
+		fields := make(map[string]string, len(keys))
+		for i, key := range keys {
+			if key != "" {
+				fields[key] = cell(i)
+			}
+		}
+		// "Ann <ann@example.com>" in the email column is just the address
+		// as a variable
+		fields[keys[emailCol]] = addr.Address
+
+		list.Recipients = append(list.Recipients, &Recipient{
+			Email:  addr.Address,
+			Name:   name,
+			Fields: fields,
+		})
+	}
+
+	return list, nil
+}
+
+// FieldKey turns a column name into a variable name by dropping spaces and
+// punctuation: "First Name" becomes "FirstName", usable as {{firstName}}
+// (variables are case-insensitive). Underscores are kept.
+func FieldKey(column string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, column)
+}
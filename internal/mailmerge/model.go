@@ -0,0 +1,93 @@
+// Package mailmerge sends individual copies of a message, with template
+// variables filled in per recipient, to a list read from CSV or an
+// addressbook. Delivery is rate-limited and every recipient's result is
+// recorded, so an interrupted job resumes where it left off.
+package mailmerge
+
+import (
+	"time"
+
+	"github.com/hkdb/aerion/internal/smtp"
+)
+
+const (
+	// DefaultRatePerMinute is the send rate used when a job doesn't set one
+	DefaultRatePerMinute = 20
+	// MaxRatePerMinute caps the send rate to stay under provider limits
+	MaxRatePerMinute = 120
+	// MaxAttempts is how often a recipient is tried after temporary errors
+	// before it's marked failed
+	MaxAttempts = 3
+)
+
+// JobStatus is the state of a mail merge job
+type JobStatus string
+
+const (
+	JobRunning   JobStatus = "running"
+	JobPaused    JobStatus = "paused"
+	JobCompleted JobStatus = "completed"
+	JobCancelled JobStatus = "cancelled"
+)
+
+// RecipientStatus is the delivery state of one recipient
+type RecipientStatus string
+
+const (
+	RecipientPending RecipientStatus = "pending"
+	RecipientSent    RecipientStatus = "sent"
+	RecipientFailed  RecipientStatus = "failed"
+)
+
+// Job is a message sent separately to each of its recipients
+type Job struct {
+	ID        string `json:"id"`
+	AccountID string `json:"accountId"`
+	Name      string `json:"name"`
+
+	// Message is the template: its subject and bodies may use variables
+	// like {{firstName}}. To, Cc and Bcc are ignored; each copy goes to
+	// one recipient only.
+	Message smtp.ComposeMessage `json:"message"`
+
+	RatePerMinute int       `json:"ratePerMinute"`
+	Status        JobStatus `json:"status"`
+
+	// Counts by recipient status, filled in when a job is loaded
+	Total   int `json:"total"`
+	Sent    int `json:"sent"`
+	Failed  int `json:"failed"`
+	Pending int `json:"pending"`
+
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// Recipient is one row of a job's recipient list
+type Recipient struct {
+	ID       int64  `json:"id"`
+	JobID    string `json:"jobId"`
+	Position int    `json:"position"`
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+
+	// Fields are the row's variables, keyed by column name with spaces and
+	// punctuation removed ("First Name" -> "FirstName")
+	Fields map[string]string `json:"fields"`
+
+	Status   RecipientStatus `json:"status"`
+	Error    string          `json:"error,omitempty"`
+	Attempts int             `json:"attempts"`
+	SentAt   *time.Time      `json:"sentAt,omitempty"`
+}
+
+// List is a recipient list read from CSV or an addressbook
+type List struct {
+	Recipients []*Recipient `json:"recipients"`
+	// Columns are the variable names each recipient's Fields has, in
+	// column order
+	Columns []string `json:"columns"`
+	// Skipped counts rows without a valid address, or with a repeated one
+	Skipped int `json:"skipped"`
+}
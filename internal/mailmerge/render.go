@@ -0,0 +1,35 @@
+package mailmerge
+
+import (
+	"time"
+
+	"github.com/hkdb/aerion/internal/smtp"
+	"github.com/hkdb/aerion/internal/template"
+)
+
+// Render builds one recipient's copy of a job's message: addressed to them
+// alone, with variables filled in from their row. Besides the row's own
+// columns, the usual template variables such as {{recipient.firstName}}
+// and {{date}} are available; a column of the same name takes precedence.
+func Render(msg smtp.ComposeMessage, r *Recipient, now time.Time) smtp.ComposeMessage {
+	addr := smtp.Address{Name: r.Name, Address: r.Email}
+
+	vars := template.NewVars(now)
+	vars.SetSender(msg.From)
+	vars.SetRecipient(addr)
+	for key, value := range r.Fields {
+		vars.Set(key, value)
+		vars.Set("recipient."+key, value)
+	}
+
+	out := msg
+	out.To = []smtp.Address{addr}
+	out.Cc = nil
+	out.Bcc = nil
+	out.InReplyTo = ""
+	out.References = nil
+	out.Subject = vars.Expand(msg.Subject, false)
+	out.HTMLBody = vars.Expand(msg.HTMLBody, true)
+	out.TextBody = vars.Expand(msg.TextBody, false)
+	return out
+}
@@ -0,0 +1,294 @@
+package mailmerge
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/hkdb/aerion/internal/smtp"
+	"github.com/rs/zerolog"
+)
+
+// SendFunc delivers one rendered message from an account
+type SendFunc func(accountID string, msg smtp.ComposeMessage) error
+
+// retryDelay is how long a job waits after a temporary failure before
+// trying the same recipient again
+const retryDelay = 2 * time.Minute
+
+// Runner delivers running jobs in the background, one goroutine per job
+type Runner struct {
+	store *Store
+	send  SendFunc
+	log   zerolog.Logger
+
+	// Callbacks
+	isTemporary func(error) bool // classifies delivery errors as retryable
+	isConnected func() bool      // optional: hold off sending while offline
+	onProgress  func(job *Job)   // optional: called after each recipient and status change
+	onDone      func(job *Job)   // optional: called when a job has no recipients left
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	running map[string]*jobRun
+}
+
+// jobRun is a job's delivery goroutine
+type jobRun struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewRunner creates a new mail merge runner. isTemporary decides whether a
+// failed delivery is retried or the recipient marked failed.
+func NewRunner(store *Store, send SendFunc, isTemporary func(error) bool) *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Runner{
+		store:       store,
+		send:        send,
+		isTemporary: isTemporary,
+		log:         logging.WithComponent("mailmerge-runner"),
+		ctx:         ctx,
+		cancel:      cancel,
+		running:     make(map[string]*jobRun),
+	}
+}
+
+// SetConnectivityCheck sets a function to check network connectivity.
+// When set, jobs wait while offline instead of using up attempts.
+func (r *Runner) SetConnectivityCheck(check func() bool) {
+	r.isConnected = check
+}
+
+// SetProgressCallback sets a function called with a job's current counts
+// after every recipient and status change
+func (r *Runner) SetProgressCallback(cb func(job *Job)) {
+	r.onProgress = cb
+}
+
+// SetDoneCallback sets a function called when a job finishes sending
+func (r *Runner) SetDoneCallback(cb func(job *Job)) {
+	r.onDone = cb
+}
+
+// ResumeAll restarts every job left running by a previous session
+func (r *Runner) ResumeAll() {
+	jobs, err := r.store.ListJobsByStatus(JobRunning)
+	if err != nil {
+		r.log.Error().Err(err).Msg("Failed to list running mail merge jobs")
+		return
+	}
+	for _, job := range jobs {
+		r.log.Info().Str("id", job.ID).Int("pending", job.Pending).Msg("Resuming mail merge job")
+		r.start(job.ID)
+	}
+}
+
+// Start sets a job running and starts delivering it
+func (r *Runner) Start(jobID string) error {
+	if err := r.store.SetStatus(jobID, JobRunning); err != nil {
+		return err
+	}
+	r.start(jobID)
+	r.notify(jobID)
+	return nil
+}
+
+// Pause stops delivering a job after the current message; Start resumes it
+func (r *Runner) Pause(jobID string) error {
+	r.stop(jobID)
+	if err := r.store.SetStatus(jobID, JobPaused); err != nil {
+		return err
+	}
+	r.notify(jobID)
+	return nil
+}
+
+// Cancel stops a job for good. Recipients not yet sent stay pending.
+func (r *Runner) Cancel(jobID string) error {
+	r.stop(jobID)
+	if err := r.store.SetStatus(jobID, JobCancelled); err != nil {
+		return err
+	}
+	r.notify(jobID)
+	return nil
+}
+
+// RetryFailed queues a job's failed recipients again and restarts it
+func (r *Runner) RetryFailed(jobID string) error {
+	n, err := r.store.ResetFailed(jobID)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("no failed recipients to retry")
+	}
+	return r.Start(jobID)
+}
+
+// Delete stops a job and removes it
+func (r *Runner) Delete(jobID string) error {
+	r.stop(jobID)
+	return r.store.DeleteJob(jobID)
+}
+
+// Stop stops all jobs, waiting for in-flight deliveries. Jobs keep their
+// running status so ResumeAll picks them up next time.
+func (r *Runner) Stop() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+// start launches a job's delivery goroutine unless it's already running
+func (r *Runner) start(jobID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.running[jobID]; ok || r.ctx.Err() != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(r.ctx)
+	run := &jobRun{cancel: cancel, done: make(chan struct{})}
+	r.running[jobID] = run
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer close(run.done)
+		r.run(ctx, jobID)
+
+		r.mu.Lock()
+		if r.running[jobID] == run {
+			delete(r.running, jobID)
+		}
+		r.mu.Unlock()
+	}()
+}
+
+// stop cancels a job's delivery goroutine and waits for its current
+// message, so a restarted job can't send to the same recipient twice
+func (r *Runner) stop(jobID string) {
+	r.mu.Lock()
+	run, ok := r.running[jobID]
+	delete(r.running, jobID)
+	r.mu.Unlock()
+
+	if ok {
+		run.cancel()
+		<-run.done
+	}
+}
+
+// run delivers a job's pending recipients one by one at the job's rate
+func (r *Runner) run(ctx context.Context, jobID string) {
+	log := r.log.With().Str("id", jobID).Logger()
+
+	job, err := r.store.GetJob(jobID)
+	if err != nil || job == nil {
+		log.Error().Err(err).Msg("Failed to load mail merge job")
+		return
+	}
+
+	rate := job.RatePerMinute
+	if rate <= 0 || rate > MaxRatePerMinute {
+		rate = DefaultRatePerMinute
+	}
+	interval := time.Minute / time.Duration(rate)
+
+	for {
+		recipient, err := r.store.NextPending(jobID)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to get next mail merge recipient")
+			return
+		}
+		if recipient == nil {
+			break
+		}
+
+		wait := interval
+		if r.isConnected != nil && !r.isConnected() {
+			if !sleep(ctx, wait) {
+				return
+			}
+			continue
+		}
+
+		msg := Render(job.Message, recipient, time.Now())
+		sendErr := r.send(job.AccountID, msg)
+		switch {
+		case sendErr == nil:
+			if err := r.store.MarkSent(recipient.ID); err != nil {
+				log.Error().Err(err).Msg("Failed to mark mail merge recipient sent")
+				return
+			}
+
+		case r.isTemporary != nil && r.isTemporary(sendErr) && recipient.Attempts+1 < MaxAttempts:
+			log.Warn().Err(sendErr).Str("email", recipient.Email).Msg("Temporary mail merge failure, will retry")
+			if err := r.store.MarkAttempt(recipient.ID, sendErr, false); err != nil {
+				log.Error().Err(err).Msg("Failed to record mail merge attempt")
+				return
+			}
+			wait = retryDelay
+
+		default:
+			log.Error().Err(sendErr).Str("email", recipient.Email).Msg("Mail merge message rejected")
+			if err := r.store.MarkAttempt(recipient.ID, sendErr, true); err != nil {
+				log.Error().Err(err).Msg("Failed to record mail merge failure")
+				return
+			}
+		}
+		r.notify(jobID)
+
+		if !sleep(ctx, wait) {
+			return
+		}
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+	if err := r.store.SetStatus(jobID, JobCompleted); err != nil {
+		log.Error().Err(err).Msg("Failed to complete mail merge job")
+		return
+	}
+	log.Info().Msg("Mail merge job completed")
+
+	job, err = r.store.GetJobSummary(jobID)
+	if err != nil || job == nil {
+		return
+	}
+	if r.onProgress != nil {
+		r.onProgress(job)
+	}
+	if r.onDone != nil {
+		r.onDone(job)
+	}
+}
+
+// notify reports a job's current counts to the progress callback
+func (r *Runner) notify(jobID string) {
+	if r.onProgress == nil {
+		return
+	}
+	job, err := r.store.GetJobSummary(jobID)
+	if err != nil || job == nil {
+		return
+	}
+	r.onProgress(job)
+}
+
+// sleep waits for d, returning false if ctx is cancelled first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,304 @@
+package mailmerge
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hkdb/aerion/internal/database"
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/rs/zerolog"
+)
+
+// Store provides mail merge job persistence
+type Store struct {
+	db  *database.DB
+	log zerolog.Logger
+}
+
+// NewStore creates a new mail merge store
+func NewStore(db *database.DB) *Store {
+	return &Store{
+		db:  db,
+		log: logging.WithComponent("mailmerge-store"),
+	}
+}
+
+// jobColumns selects a job with its recipient counts
+const jobColumns = `
+	SELECT j.id, j.account_id, j.name, j.rate_per_minute, j.status,
+		j.created_at, j.updated_at, j.completed_at,
+		COUNT(r.id),
+		COALESCE(SUM(r.status = 'sent'), 0),
+		COALESCE(SUM(r.status = 'failed'), 0),
+		COALESCE(SUM(r.status = 'pending'), 0)
+	FROM mail_merge_jobs j
+	LEFT JOIN mail_merge_recipients r ON r.job_id = j.id
+`
+
+// CreateJob stores a new job and its recipients
+func (s *Store) CreateJob(job *Job, recipients []*Recipient) error {
+	message, err := json.Marshal(job.Message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	job.ID = uuid.New().String()
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	_, err = tx.Exec(`
+		INSERT INTO mail_merge_jobs (
+			id, account_id, name, message, rate_per_minute, status, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, job.ID, job.AccountID, job.Name, message, job.RatePerMinute, job.Status, job.CreatedAt, job.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create mail merge job: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO mail_merge_recipients (job_id, position, email, name, fields, status)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare recipient insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for i, r := range recipients {
+		fields, err := json.Marshal(r.Fields)
+		if err != nil {
+			return fmt.Errorf("failed to marshal recipient fields: %w", err)
+		}
+		r.JobID = job.ID
+		r.Position = i
+		r.Status = RecipientPending
+		result, err := stmt.Exec(r.JobID, r.Position, r.Email, r.Name, string(fields), r.Status)
+		if err != nil {
+			return fmt.Errorf("failed to add recipient: %w", err)
+		}
+		r.ID, _ = result.LastInsertId()
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	job.Total = len(recipients)
+	job.Pending = len(recipients)
+	s.log.Debug().Str("id", job.ID).Int("recipients", job.Total).Msg("Created mail merge job")
+	return nil
+}
+
+// GetJob returns a job with its message, or nil if it doesn't exist
+func (s *Store) GetJob(id string) (*Job, error) {
+	job, err := s.GetJobSummary(id)
+	if err != nil || job == nil {
+		return job, err
+	}
+
+	var message []byte
+	if err := s.db.QueryRow("SELECT message FROM mail_merge_jobs WHERE id = ?", id).Scan(&message); err != nil {
+		return nil, fmt.Errorf("failed to get mail merge message: %w", err)
+	}
+	if err := json.Unmarshal(message, &job.Message); err != nil {
+		return nil, fmt.Errorf("failed to parse mail merge message: %w", err)
+	}
+	return job, nil
+}
+
+// GetJobSummary returns a job with its counts but without its message, or
+// nil if it doesn't exist
+func (s *Store) GetJobSummary(id string) (*Job, error) {
+	jobs, err := s.listJobs(" WHERE j.id = ? GROUP BY j.id", id)
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+	return jobs[0], nil
+}
+
+// ListJobs returns an account's jobs, newest first, without their message
+func (s *Store) ListJobs(accountID string) ([]*Job, error) {
+	return s.listJobs(" WHERE j.account_id = ? GROUP BY j.id ORDER BY j.created_at DESC", accountID)
+}
+
+// ListJobsByStatus returns the jobs in a status across all accounts,
+// without their message
+func (s *Store) ListJobsByStatus(status JobStatus) ([]*Job, error) {
+	return s.listJobs(" WHERE j.status = ? GROUP BY j.id ORDER BY j.created_at", status)
+}
+
+func (s *Store) listJobs(where string, args ...interface{}) ([]*Job, error) {
+	rows, err := s.db.Query(jobColumns+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mail merge jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// SetStatus changes a job's status, recording when it completed
+func (s *Store) SetStatus(id string, status JobStatus) error {
+	now := time.Now()
+	var completedAt *time.Time
+	if status == JobCompleted || status == JobCancelled {
+		completedAt = &now
+	}
+	_, err := s.db.Exec(
+		"UPDATE mail_merge_jobs SET status = ?, updated_at = ?, completed_at = ? WHERE id = ?",
+		status, now, completedAt, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update mail merge job: %w", err)
+	}
+	return nil
+}
+
+// DeleteJob removes a job and its recipients
+func (s *Store) DeleteJob(id string) error {
+	if _, err := s.db.Exec("DELETE FROM mail_merge_jobs WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete mail merge job: %w", err)
+	}
+	return nil
+}
+
+// ListRecipients returns a job's recipients in list order
+func (s *Store) ListRecipients(jobID string) ([]*Recipient, error) {
+	rows, err := s.db.Query(`
+		SELECT id, job_id, position, email, name, fields, status, error, attempts, sent_at
+		FROM mail_merge_recipients
+		WHERE job_id = ?
+		ORDER BY position
+	`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mail merge recipients: %w", err)
+	}
+	defer rows.Close()
+
+	var recipients []*Recipient
+	for rows.Next() {
+		r, err := scanRecipient(rows)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients, rows.Err()
+}
+
+// NextPending returns the first recipient still to be sent, or nil if
+// there are none
+func (s *Store) NextPending(jobID string) (*Recipient, error) {
+	rows, err := s.db.Query(`
+		SELECT id, job_id, position, email, name, fields, status, error, attempts, sent_at
+		FROM mail_merge_recipients
+		WHERE job_id = ? AND status = ?
+		ORDER BY position
+		LIMIT 1
+	`, jobID, RecipientPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get next mail merge recipient: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	return scanRecipient(rows)
+}
+
+// MarkSent records a successful delivery
+func (s *Store) MarkSent(id int64) error {
+	_, err := s.db.Exec(
+		"UPDATE mail_merge_recipients SET status = ?, error = '', attempts = attempts + 1, sent_at = ? WHERE id = ?",
+		RecipientSent, time.Now(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update mail merge recipient: %w", err)
+	}
+	return nil
+}
+
+// MarkAttempt records a failed delivery. The recipient stays pending for
+// another try unless failed is set.
+func (s *Store) MarkAttempt(id int64, sendErr error, failed bool) error {
+	status := RecipientPending
+	if failed {
+		status = RecipientFailed
+	}
+	_, err := s.db.Exec(
+		"UPDATE mail_merge_recipients SET status = ?, error = ?, attempts = attempts + 1 WHERE id = ?",
+		status, sendErr.Error(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update mail merge recipient: %w", err)
+	}
+	return nil
+}
+
+// ResetFailed returns a job's failed recipients to pending so they're
+// tried again. Returns how many there were.
+func (s *Store) ResetFailed(jobID string) (int, error) {
+	result, err := s.db.Exec(
+		"UPDATE mail_merge_recipients SET status = ?, error = '', attempts = 0 WHERE job_id = ? AND status = ?",
+		RecipientPending, jobID, RecipientFailed,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset mail merge recipients: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return int(n), nil
+}
+
+func scanJob(rows *sql.Rows) (*Job, error) {
+	job := &Job{}
+	var completedAt sql.NullTime
+	if err := rows.Scan(
+		&job.ID, &job.AccountID, &job.Name, &job.RatePerMinute, &job.Status,
+		&job.CreatedAt, &job.UpdatedAt, &completedAt,
+		&job.Total, &job.Sent, &job.Failed, &job.Pending,
+	); err != nil {
+		return nil, fmt.Errorf("failed to scan mail merge job: %w", err)
+	}
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+	return job, nil
+}
+
+func scanRecipient(rows *sql.Rows) (*Recipient, error) {
+	r := &Recipient{}
+	var fields string
+	var sentAt sql.NullTime
+	if err := rows.Scan(
+		&r.ID, &r.JobID, &r.Position, &r.Email, &r.Name, &fields,
+		&r.Status, &r.Error, &r.Attempts, &sentAt,
+	); err != nil {
+		return nil, fmt.Errorf("failed to scan mail merge recipient: %w", err)
+	}
+	if err := json.Unmarshal([]byte(fields), &r.Fields); err != nil {
+		return nil, fmt.Errorf("failed to parse mail merge recipient fields: %w", err)
+	}
+	if sentAt.Valid {
+		r.SentAt = &sentAt.Time
+	}
+	return r, nil
+}
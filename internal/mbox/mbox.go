@@ -0,0 +1,186 @@
+// Package mbox reads and writes mbox files in the mboxrd format, where body
+// lines starting with "From " are quoted with ">" and quoted lines get one
+// more ">" so the quoting can be undone exactly
+package mbox
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// defaultSender is used on the separator line of messages with no sender
+const defaultSender = "MAILER-DAEMON"
+
+// Message is a message read from an mbox file
+type Message struct {
+	Raw  []byte    // RFC 5322 message with CRLF line endings
+	Date time.Time // Date on the separator line, zero if unreadable
+}
+
+// Reader reads the messages of an mbox file one at a time
+type Reader struct {
+	r    *bufio.Reader
+	next []byte // Separator line of the next message, read ahead
+	err  error
+}
+
+// NewReader returns a Reader reading from r
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReaderSize(r, 64*1024)}
+}
+
+// Next returns the next message, or io.EOF after the last one
+func (r *Reader) Next() (*Message, error) {
+	if r.next == nil {
+		// Skip anything before the first separator
+		for {
+			line, err := r.readLine()
+			if err != nil {
+				return nil, err
+			}
+			if isSeparator(line) {
+				r.next = line
+				break
+			}
+		}
+	}
+
+	msg := &Message{Date: separatorDate(r.next)}
+	r.next = nil
+
+	var buf bytes.Buffer
+	blank := false // Previous line was empty
+	for {
+		line, err := r.readLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if blank && isSeparator(line) {
+			r.next = line
+			break
+		}
+		if blank {
+			// The held-back empty line turned out to be part of the message
+			buf.WriteString("\r\n")
+		}
+
+		if len(line) == 0 {
+			blank = true
+			continue
+		}
+		blank = false
+		buf.Write(unquote(line))
+		buf.WriteString("\r\n")
+	}
+
+	// The empty line before a separator belongs to the mbox, not the message
+	msg.Raw = buf.Bytes()
+	return msg, nil
+}
+
+// readLine returns the next line without its line ending
+func (r *Reader) readLine() ([]byte, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	line, err := r.r.ReadBytes('\n')
+	if err != nil {
+		if err != io.EOF || len(line) == 0 {
+			r.err = err
+			return nil, err
+		}
+		r.err = io.EOF
+	}
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	return line, nil
+}
+
+// isSeparator reports whether a line starts a new message
+func isSeparator(line []byte) bool {
+	return bytes.HasPrefix(line, []byte("From "))
+}
+
+// separatorDate parses the asctime date at the end of a separator line
+func separatorDate(line []byte) time.Time {
+	fields := strings.Fields(string(line))
+	if len(fields) < 7 {
+		return time.Time{}
+	}
+	// From sender Mon Jan  2 15:04:05 2006
+	date := strings.Join(fields[len(fields)-5:], " ")
+	t, err := time.Parse("Mon Jan 2 15:04:05 2006", date)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// unquote removes one ">" from a quoted From line
+func unquote(line []byte) []byte {
+	rest := bytes.TrimLeft(line, ">")
+	if len(rest) < len(line) && bytes.HasPrefix(rest, []byte("From ")) {
+		return line[1:]
+	}
+	return line
+}
+
+// Writer writes messages to an mbox file
+type Writer struct {
+	w *bufio.Writer
+}
+
+// NewWriter returns a Writer writing to w. Call Flush when done.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// WriteMessage appends a message. sender and date go on the separator
+// line; lines use LF endings, as mbox readers expect.
+func (w *Writer) WriteMessage(raw []byte, sender string, date time.Time) error {
+	if sender == "" || strings.ContainsAny(sender, " \t\r\n") {
+		sender = defaultSender
+	}
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	if _, err := fmt.Fprintf(w.w, "From %s %s\n", sender, date.UTC().Format(time.ANSIC)); err != nil {
+		return err
+	}
+
+	lines := bytes.Split(raw, []byte("\n"))
+	// A trailing line ending doesn't start another line
+	if len(lines) > 0 && len(bytes.TrimSuffix(lines[len(lines)-1], []byte("\r"))) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	for _, line := range lines {
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if bytes.HasPrefix(bytes.TrimLeft(line, ">"), []byte("From ")) {
+			if err := w.w.WriteByte('>'); err != nil {
+				return err
+			}
+		}
+		if _, err := w.w.Write(line); err != nil {
+			return err
+		}
+		if err := w.w.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+
+	// Messages are separated by an empty line
+	return w.w.WriteByte('\n')
+}
+
+// Flush writes any buffered data
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
@@ -0,0 +1,134 @@
+package mbox
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Flags are the message flags mbox mail readers keep in the Status and
+// X-Status headers
+type Flags struct {
+	Seen     bool // Status: R
+	Answered bool // X-Status: A
+	Flagged  bool // X-Status: F
+	Deleted  bool // X-Status: D
+	Draft    bool // X-Status: T
+}
+
+// StatusFlags reads the flags from a message's Status and X-Status
+// headers. ok is false if the message has neither.
+func StatusFlags(raw []byte) (flags Flags, ok bool) {
+	for _, field := range headerFields(raw) {
+		name, value, found := strings.Cut(field, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "status":
+			ok = true
+			flags.Seen = strings.ContainsRune(value, 'R')
+		case "x-status":
+			ok = true
+			flags.Answered = strings.ContainsRune(value, 'A')
+			flags.Flagged = strings.ContainsRune(value, 'F')
+			flags.Deleted = strings.ContainsRune(value, 'D')
+			flags.Draft = strings.ContainsRune(value, 'T')
+		}
+	}
+	return flags, ok
+}
+
+// WithStatus returns the message with its Status and X-Status headers set
+// to flags, replacing any it had
+func WithStatus(raw []byte, flags Flags) []byte {
+	end := headerEnd(raw)
+	header, body := raw[:end], raw[end:]
+
+	var out bytes.Buffer
+	out.Grow(len(raw) + 32)
+
+	skipping := false
+	for _, line := range bytes.SplitAfter(header, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		// Continuation lines belong to the field before them
+		if line[0] == ' ' || line[0] == '\t' {
+			if !skipping {
+				out.Write(line)
+			}
+			continue
+		}
+		name, _, _ := bytes.Cut(line, []byte(":"))
+		name = bytes.ToLower(bytes.TrimSpace(name))
+		skipping = bytes.Equal(name, []byte("status")) || bytes.Equal(name, []byte("x-status"))
+		if !skipping {
+			out.Write(line)
+		}
+	}
+
+	if out.Len() > 0 && !bytes.HasSuffix(out.Bytes(), []byte("\n")) {
+		out.WriteString("\r\n")
+	}
+
+	status := "O"
+	if flags.Seen {
+		status = "RO"
+	}
+	out.WriteString("Status: " + status + "\r\n")
+
+	var xstatus string
+	if flags.Answered {
+		xstatus += "A"
+	}
+	if flags.Flagged {
+		xstatus += "F"
+	}
+	if flags.Deleted {
+		xstatus += "D"
+	}
+	if flags.Draft {
+		xstatus += "T"
+	}
+	if xstatus != "" {
+		out.WriteString("X-Status: " + xstatus + "\r\n")
+	}
+
+	out.Write(body)
+	return out.Bytes()
+}
+
+// headerEnd returns the offset of the empty line ending the header, or
+// the length of raw if there is none
+func headerEnd(raw []byte) int {
+	if bytes.HasPrefix(raw, []byte("\r\n")) || bytes.HasPrefix(raw, []byte("\n")) {
+		return 0
+	}
+	crlf := bytes.Index(raw, []byte("\r\n\r\n"))
+	lf := bytes.Index(raw, []byte("\n\n"))
+	switch {
+	case crlf >= 0 && (lf < 0 || crlf < lf):
+		return crlf + 2
+	case lf >= 0:
+		return lf + 1
+	}
+	return len(raw)
+}
+
+// headerFields returns the unfolded fields of a message's header
+func headerFields(raw []byte) []string {
+	var fields []string
+	for _, line := range strings.Split(string(raw[:headerEnd(raw)]), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(fields) > 0 {
+			fields[len(fields)-1] += " " + strings.TrimSpace(line)
+			continue
+		}
+		fields = append(fields, line)
+	}
+	return fields
+}
@@ -0,0 +1,195 @@
+package message
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GmailAttrsUpdate holds Gmail's attributes for a message, from X-GM-MSGID,
+// X-GM-THRID and X-GM-LABELS
+type GmailAttrsUpdate struct {
+	UID      uint32
+	MsgID    uint64
+	ThreadID uint64
+	Labels   []string
+}
+
+// GmailThreadID returns the thread_id used for messages in a Gmail
+// conversation. Gmail's own threading replaces header-based threading so all
+// copies of a message (one per label folder) land in the same conversation.
+func GmailThreadID(thrid uint64) string {
+	return fmt.Sprintf("gm-thrid-%d", thrid)
+}
+
+// ApplyGmailAttrs stores Gmail message IDs, thread IDs and labels for
+// messages in a folder. Labels replace any previously stored for the same
+// Gmail message, since X-GM-LABELS always returns the full set.
+func (s *Store) ApplyGmailAttrs(accountID, folderID string, updates []GmailAttrsUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, u := range updates {
+		_, err := tx.Exec(`
+			UPDATE messages SET gm_msgid = ?, thread_id = ?
+			WHERE folder_id = ? AND uid = ?
+		`, int64(u.MsgID), GmailThreadID(u.ThreadID), folderID, u.UID)
+		if err != nil {
+			return fmt.Errorf("failed to update Gmail attributes: %w", err)
+		}
+
+		if _, err := tx.Exec("DELETE FROM gmail_labels WHERE account_id = ? AND gm_msgid = ?", accountID, int64(u.MsgID)); err != nil {
+			return fmt.Errorf("failed to clear labels: %w", err)
+		}
+		for _, label := range u.Labels {
+			if _, err := tx.Exec(
+				"INSERT OR IGNORE INTO gmail_labels (account_id, gm_msgid, label) VALUES (?, ?, ?)",
+				accountID, int64(u.MsgID), label,
+			); err != nil {
+				return fmt.Errorf("failed to insert label: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// PruneGmailLabels removes labels of Gmail messages no longer stored in any
+// folder
+func (s *Store) PruneGmailLabels(accountID string) error {
+	_, err := s.db.Exec(`
+		DELETE FROM gmail_labels
+		WHERE account_id = ?
+		AND gm_msgid NOT IN (
+			SELECT gm_msgid FROM messages WHERE account_id = ? AND gm_msgid IS NOT NULL
+		)
+	`, accountID, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to prune labels: %w", err)
+	}
+	return nil
+}
+
+// ListGmailLabels returns every label in use on an account's messages
+func (s *Store) ListGmailLabels(accountID string) ([]string, error) {
+	rows, err := s.db.Query(
+		"SELECT DISTINCT label FROM gmail_labels WHERE account_id = ? ORDER BY label",
+		accountID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list labels: %w", err)
+	}
+	defer rows.Close()
+
+	labels := []string{}
+	for rows.Next() {
+		var label string
+		if err := rows.Scan(&label); err != nil {
+			return nil, fmt.Errorf("failed to scan label: %w", err)
+		}
+		labels = append(labels, label)
+	}
+	return labels, rows.Err()
+}
+
+// GetGmailLabels returns the labels of a message
+func (s *Store) GetGmailLabels(messageID string) ([]string, error) {
+	rows, err := s.db.Query(`
+		SELECT gl.label FROM gmail_labels gl
+		JOIN messages m ON m.account_id = gl.account_id AND m.gm_msgid = gl.gm_msgid
+		WHERE m.id = ?
+		ORDER BY gl.label
+	`, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get labels: %w", err)
+	}
+	defer rows.Close()
+
+	labels := []string{}
+	for rows.Next() {
+		var label string
+		if err := rows.Scan(&label); err != nil {
+			return nil, fmt.Errorf("failed to scan label: %w", err)
+		}
+		labels = append(labels, label)
+	}
+	return labels, rows.Err()
+}
+
+// GetGmailMsgIDs returns the Gmail message IDs of messages, keyed by message
+// ID. Messages without one (not yet synced, or not Gmail) are omitted.
+func (s *Store) GetGmailMsgIDs(messageIDs []string) (map[string]uint64, error) {
+	result := make(map[string]uint64)
+	if len(messageIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders := make([]string, len(messageIDs))
+	args := make([]interface{}, len(messageIDs))
+	for i, id := range messageIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := fmt.Sprintf(
+		"SELECT id, gm_msgid FROM messages WHERE gm_msgid IS NOT NULL AND id IN (%s)",
+		strings.Join(placeholders, ", "),
+	)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Gmail message IDs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var msgID int64
+		if err := rows.Scan(&id, &msgID); err != nil {
+			return nil, fmt.Errorf("failed to scan Gmail message ID: %w", err)
+		}
+		result[id] = uint64(msgID)
+	}
+	return result, rows.Err()
+}
+
+// UpdateGmailLabels adds or removes labels on Gmail messages locally, after
+// the change has been stored on the server
+func (s *Store) UpdateGmailLabels(accountID string, msgIDs []uint64, labels []string, add bool) error {
+	if len(msgIDs) == 0 || len(labels) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := "DELETE FROM gmail_labels WHERE account_id = ? AND gm_msgid = ? AND label = ?"
+	if add {
+		query = "INSERT OR IGNORE INTO gmail_labels (account_id, gm_msgid, label) VALUES (?, ?, ?)"
+	}
+
+	for _, msgID := range msgIDs {
+		for _, label := range labels {
+			if _, err := tx.Exec(query, accountID, int64(msgID), label); err != nil {
+				return fmt.Errorf("failed to update label: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package message
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// SaveRaw stores the raw RFC822 source of a message that has no server
+// copy to fetch it from again
+func (s *Store) SaveRaw(messageID string, raw []byte) error {
+	_, err := s.db.Exec(`
+		INSERT INTO message_sources (message_id, raw) VALUES (?, ?)
+		ON CONFLICT(message_id) DO UPDATE SET raw = excluded.raw
+	`, messageID, raw)
+	if err != nil {
+		return fmt.Errorf("failed to save raw message: %w", err)
+	}
+	return nil
+}
+
+// GetRaw returns the stored raw source of a message, or nil if there is none
+func (s *Store) GetRaw(messageID string) ([]byte, error) {
+	var raw []byte
+	err := s.db.QueryRow("SELECT raw FROM message_sources WHERE message_id = ?", messageID).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get raw message: %w", err)
+	}
+	return raw, nil
+}
+
+// ListFlagsByFolder returns the flags of every message in a folder, keyed
+// by message ID
+func (s *Store) ListFlagsByFolder(folderID string) (map[string]FlagUpdate, error) {
+	rows, err := s.db.Query(`
+		SELECT id, uid, is_read, is_starred, is_answered, is_forwarded, is_draft, is_deleted
+		FROM messages WHERE folder_id = ?
+	`, folderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list message flags: %w", err)
+	}
+	defer rows.Close()
+
+	flags := make(map[string]FlagUpdate)
+	for rows.Next() {
+		var id string
+		var uid int64
+		var f FlagUpdate
+		if err := rows.Scan(&id, &uid, &f.IsRead, &f.IsStarred, &f.IsAnswered, &f.IsForwarded, &f.IsDraft, &f.IsDeleted); err != nil {
+			return nil, fmt.Errorf("failed to scan message flags: %w", err)
+		}
+		if uid > 0 {
+			f.UID = uint32(uid)
+		}
+		flags[id] = f
+	}
+	return flags, rows.Err()
+}
+
+// MoveMessagesLocal moves messages into a local-only folder. Unlike
+// MoveMessages, which leaves a placeholder UID for the next sync to
+// replace, the messages get their final UIDs right away.
+func (s *Store) MoveMessagesLocal(ids []string, newFolderID string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	uid, err := highestUIDTx(tx, newFolderID)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		uid++
+		if _, err := tx.Exec("UPDATE messages SET folder_id = ?, uid = ? WHERE id = ?", newFolderID, uid, id); err != nil {
+			return fmt.Errorf("failed to move message: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// CopyMessagesLocal copies messages into a local-only folder, along with
+// their tags, attachments and raw source. Returns the IDs of the copies.
+func (s *Store) CopyMessagesLocal(ids []string, folderID string) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	columns, err := s.messageColumns()
+	if err != nil {
+		return nil, err
+	}
+	// Everything but the row's identity and location is copied as is
+	var copied []string
+	for _, col := range columns {
+		if col != "id" && col != "folder_id" && col != "uid" {
+			copied = append(copied, col)
+		}
+	}
+	colList := strings.Join(copied, ", ")
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	uid, err := highestUIDTx(tx, folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	newIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		newID := uuid.New().String()
+		uid++
+
+		query := fmt.Sprintf(
+			"INSERT INTO messages (id, folder_id, uid, %s) SELECT ?, ?, ?, %s FROM messages WHERE id = ?",
+			colList, colList,
+		)
+		if _, err := tx.Exec(query, newID, folderID, uid, id); err != nil {
+			return nil, fmt.Errorf("failed to copy message: %w", err)
+		}
+
+		if _, err := tx.Exec(`
+			INSERT INTO message_tags (message_id, keyword, local_only)
+			SELECT ?, keyword, local_only FROM message_tags WHERE message_id = ?
+		`, newID, id); err != nil {
+			return nil, fmt.Errorf("failed to copy tags: %w", err)
+		}
+
+		if _, err := tx.Exec(`
+			INSERT INTO attachments (id, message_id, filename, content_type, size, content_id, is_inline, local_path, content)
+			SELECT lower(hex(randomblob(16))), ?, filename, content_type, size, content_id, is_inline, local_path, content
+			FROM attachments WHERE message_id = ?
+		`, newID, id); err != nil {
+			return nil, fmt.Errorf("failed to copy attachments: %w", err)
+		}
+
+		if _, err := tx.Exec(
+			"INSERT INTO message_sources (message_id, raw) SELECT ?, raw FROM message_sources WHERE message_id = ?",
+			newID, id,
+		); err != nil {
+			return nil, fmt.Errorf("failed to copy raw message: %w", err)
+		}
+
+		newIDs = append(newIDs, newID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return newIDs, nil
+}
+
+// messageColumns returns the column names of the messages table
+func (s *Store) messageColumns() ([]string, error) {
+	rows, err := s.db.Query("SELECT name FROM pragma_table_info('messages')")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message columns: %w", err)
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan column name: %w", err)
+		}
+		columns = append(columns, name)
+	}
+	return columns, rows.Err()
+}
+
+// highestUIDTx returns the highest UID in a folder within a transaction
+func highestUIDTx(tx *sql.Tx, folderID string) (uint32, error) {
+	var uid sql.NullInt64
+	if err := tx.QueryRow("SELECT MAX(uid) FROM messages WHERE folder_id = ?", folderID).Scan(&uid); err != nil {
+		return 0, fmt.Errorf("failed to get highest UID: %w", err)
+	}
+	if uid.Valid && uid.Int64 > 0 {
+		return uint32(uid.Int64), nil
+	}
+	return 0, nil
+}
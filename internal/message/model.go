@@ -41,6 +41,9 @@ type Message struct {
 	IsDraft     bool `json:"isDraft"`
 	IsDeleted   bool `json:"isDeleted"`
 
+	// Tags are IMAP keywords ($Label1, $Todo, custom words)
+	Tags []string `json:"tags,omitempty"`
+
 	// Size and attachments
 	Size           int  `json:"size"`
 	HasAttachments bool `json:"hasAttachments"`
@@ -131,6 +134,7 @@ type Conversation struct {
 	Participants   []Address  `json:"participants"`
 	MessageIDs     []string   `json:"messageIds"`         // Message IDs for context menu actions
 	IsEncrypted    bool       `json:"isEncrypted"`        // Any message in thread is encrypted (S/MIME or PGP)
+	Tags           []string   `json:"tags,omitempty"`     // Tags on any message in the thread
 	Messages       []*Message `json:"messages,omitempty"` // Only populated when fetching full conversation
 
 	// For unified inbox view - populated when querying across accounts
@@ -0,0 +1,145 @@
+package message
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RemoteRef ties a local message row to its server-side object (a JMAP
+// Email). An Email in several mailboxes has one row per folder.
+type RemoteRef struct {
+	ID       string
+	FolderID string
+	UID      uint32
+	RemoteID string
+}
+
+// JMAPThreadID returns the thread_id used for messages in a JMAP thread.
+// The server's threading replaces header-based threading, like Gmail's.
+func JMAPThreadID(threadID string) string {
+	return "jmap-" + threadID
+}
+
+// SetRemoteID records the server-side ID of a message and the synthetic
+// UID it's listed under in its folder
+func (s *Store) SetRemoteID(id string, uid uint32, remoteID string) error {
+	_, err := s.db.Exec("UPDATE messages SET uid = ?, remote_id = ? WHERE id = ?", uid, remoteID, id)
+	if err != nil {
+		return fmt.Errorf("failed to set message remote ID: %w", err)
+	}
+	return nil
+}
+
+// GetRemoteIDs returns the server-side IDs of messages, keyed by message
+// ID. Messages without one are omitted.
+func (s *Store) GetRemoteIDs(messageIDs []string) (map[string]string, error) {
+	result := make(map[string]string)
+	if len(messageIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders := make([]string, len(messageIDs))
+	args := make([]interface{}, len(messageIDs))
+	for i, id := range messageIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := fmt.Sprintf(
+		"SELECT id, remote_id FROM messages WHERE remote_id IS NOT NULL AND id IN (%s)",
+		strings.Join(placeholders, ", "),
+	)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query remote IDs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, remoteID string
+		if err := rows.Scan(&id, &remoteID); err != nil {
+			return nil, fmt.Errorf("failed to scan remote ID: %w", err)
+		}
+		result[id] = remoteID
+	}
+	return result, rows.Err()
+}
+
+// ListByRemoteIDs returns every local copy of the given server-side objects
+func (s *Store) ListByRemoteIDs(accountID string, remoteIDs []string) ([]RemoteRef, error) {
+	if len(remoteIDs) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(remoteIDs))
+	args := []interface{}{accountID}
+	for i, id := range remoteIDs {
+		placeholders[i] = "?"
+		args = append(args, id)
+	}
+
+	query := fmt.Sprintf(
+		"SELECT id, folder_id, uid, remote_id FROM messages WHERE account_id = ? AND remote_id IN (%s)",
+		strings.Join(placeholders, ", "),
+	)
+	return s.queryRemoteRefs(query, args...)
+}
+
+// ListRemoteRefsByFolder returns the messages in a folder that are tied to
+// server-side objects, limited to those dated since (zero = all)
+func (s *Store) ListRemoteRefsByFolder(folderID string, since time.Time) ([]RemoteRef, error) {
+	return s.queryRemoteRefs(
+		"SELECT id, folder_id, uid, remote_id FROM messages WHERE folder_id = ? AND remote_id IS NOT NULL AND date >= ?",
+		folderID, since,
+	)
+}
+
+// queryRemoteRefs runs a query selecting id, folder_id, uid, remote_id
+func (s *Store) queryRemoteRefs(query string, args ...interface{}) ([]RemoteRef, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query remote refs: %w", err)
+	}
+	defer rows.Close()
+
+	var refs []RemoteRef
+	for rows.Next() {
+		var ref RemoteRef
+		var uid int64
+		if err := rows.Scan(&ref.ID, &ref.FolderID, &uid, &ref.RemoteID); err != nil {
+			return nil, fmt.Errorf("failed to scan remote ref: %w", err)
+		}
+		// Rows MoveMessages left behind carry a negative placeholder UID
+		if uid > 0 {
+			ref.UID = uint32(uid)
+		}
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
+
+// DeleteByRemoteIDs deletes every local copy of the given server-side
+// objects
+func (s *Store) DeleteByRemoteIDs(accountID string, remoteIDs []string) error {
+	if len(remoteIDs) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(remoteIDs))
+	args := []interface{}{accountID}
+	for i, id := range remoteIDs {
+		placeholders[i] = "?"
+		args = append(args, id)
+	}
+
+	query := fmt.Sprintf(
+		"DELETE FROM messages WHERE account_id = ? AND remote_id IN (%s)",
+		strings.Join(placeholders, ", "),
+	)
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to delete messages by remote ID: %w", err)
+	}
+	return nil
+}
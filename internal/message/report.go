@@ -0,0 +1,101 @@
+package message
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ReportKind distinguishes delivery status notifications from read receipts
+type ReportKind string
+
+const (
+	// ReportDSN is a delivery status notification (RFC 3464)
+	ReportDSN ReportKind = "dsn"
+	// ReportMDN is a message disposition notification, or read receipt (RFC 8098)
+	ReportMDN ReportKind = "mdn"
+)
+
+// DeliveryReport is one recipient's result from an incoming DSN or MDN,
+// linked to the sent message by its Message-ID
+type DeliveryReport struct {
+	ID                int64      `json:"id"`
+	ReportMessageID   string     `json:"reportMessageId"`   // Database ID of the report message
+	OriginalMessageID string     `json:"originalMessageId"` // Message-ID header of the sent message, without angle brackets
+	Kind              ReportKind `json:"kind"`
+	Recipient         string     `json:"recipient"`
+
+	// Action is the DSN action (delivered, delayed, failed, relayed,
+	// expanded) or the MDN disposition type (displayed, deleted, ...)
+	Action     string    `json:"action"`
+	Status     string    `json:"status,omitempty"`     // DSN status code, e.g. 5.1.1
+	Diagnostic string    `json:"diagnostic,omitempty"` // Remote server's explanation
+	ReportedAt time.Time `json:"reportedAt"`
+}
+
+// SaveDeliveryReports stores the reports parsed from a report message,
+// replacing any stored for it before
+func (s *Store) SaveDeliveryReports(reportMessageID string, reports []*DeliveryReport) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM delivery_reports WHERE report_message_id = ?", reportMessageID); err != nil {
+		return fmt.Errorf("failed to clear delivery reports: %w", err)
+	}
+
+	for _, r := range reports {
+		r.ReportMessageID = reportMessageID
+		if r.ReportedAt.IsZero() {
+			r.ReportedAt = time.Now().UTC()
+		}
+		_, err := tx.Exec(`
+			INSERT OR REPLACE INTO delivery_reports (
+				report_message_id, original_message_id, kind, recipient,
+				action, status, diagnostic, reported_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, r.ReportMessageID, r.OriginalMessageID, r.Kind, strings.ToLower(r.Recipient),
+			r.Action, r.Status, r.Diagnostic, r.ReportedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save delivery report: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetDeliveryReports returns the reports received for a sent message,
+// identified by its Message-ID header, oldest first
+func (s *Store) GetDeliveryReports(originalMessageID string) ([]*DeliveryReport, error) {
+	originalMessageID = strings.Trim(originalMessageID, "<>")
+	if originalMessageID == "" {
+		return nil, nil
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, report_message_id, original_message_id, kind, recipient,
+		       action, status, diagnostic, reported_at
+		FROM delivery_reports
+		WHERE original_message_id = ?
+		ORDER BY reported_at ASC, id ASC
+	`, originalMessageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery reports: %w", err)
+	}
+	defer rows.Close()
+
+	var reports []*DeliveryReport
+	for rows.Next() {
+		r := &DeliveryReport{}
+		if err := rows.Scan(
+			&r.ID, &r.ReportMessageID, &r.OriginalMessageID, &r.Kind, &r.Recipient,
+			&r.Action, &r.Status, &r.Diagnostic, &r.ReportedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan delivery report: %w", err)
+		}
+		reports = append(reports, r)
+	}
+	return reports, rows.Err()
+}
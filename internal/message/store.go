@@ -40,6 +40,9 @@ func filterHavingClause(filter, prefix string) string {
 	case "attachments":
 		return fmt.Sprintf(" HAVING MAX(CASE WHEN %shas_attachments = 1 THEN 1 ELSE 0 END) = 1", prefix)
 	default:
+		if keyword, ok := tagFilter(filter); ok {
+			return fmt.Sprintf(" HAVING MAX(CASE WHEN %sid IN (%s) THEN 1 ELSE 0 END) = 1", prefix, tagSubquery(keyword))
+		}
 		return ""
 	}
 }
@@ -55,6 +58,9 @@ func filterWhereClause(filter, prefix string) string {
 	case "attachments":
 		return fmt.Sprintf(" AND %shas_attachments = 1", prefix)
 	default:
+		if keyword, ok := tagFilter(filter); ok {
+			return fmt.Sprintf(" AND %sid IN (%s)", prefix, tagSubquery(keyword))
+		}
 		return ""
 	}
 }
@@ -195,6 +201,8 @@ func (s *Store) ListConversationsUnifiedInbox(offset, limit int, sortOrder, filt
 		conversations = append(conversations, c)
 	}
 
+	s.attachConversationTags(conversations)
+
 	return conversations, nil
 }
 
@@ -368,6 +376,26 @@ func (s *Store) GetAllIDsByFolder(folderID string) ([]string, error) {
 	return ids, nil
 }
 
+// GetIDsAboveUID returns the IDs of messages in a folder with a UID greater
+// than uid, in UID order
+func (s *Store) GetIDsAboveUID(folderID string, uid uint32) ([]string, error) {
+	rows, err := s.db.Query("SELECT id FROM messages WHERE folder_id = ? AND uid > ? ORDER BY uid", folderID, uid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query messages: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan message id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
 // Get returns a full message by ID
 func (s *Store) Get(id string) (*Message, error) {
 	query := `
@@ -468,6 +496,11 @@ func (s *Store) Get(id string) (*Message, error) {
 		m.ReceivedAt = parseTimeString(receivedAtStr.String)
 	}
 
+	m.Tags, err = s.GetTags(id)
+	if err != nil {
+		return nil, err
+	}
+
 	return m, nil
 }
 
@@ -620,6 +653,10 @@ func (s *Store) Create(m *Message) error {
 		return fmt.Errorf("failed to create message: %w", err)
 	}
 
+	if err := s.insertTags(m.ID, m.Tags); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -697,6 +734,7 @@ type FlagUpdate struct {
 	IsForwarded bool
 	IsDraft     bool
 	IsDeleted   bool
+	Tags        []string // Keywords shown as tags
 }
 
 // UpdateFlagsByUIDBatch updates flags for multiple messages in a single transaction.
@@ -728,6 +766,9 @@ func (s *Store) UpdateFlagsByUIDBatch(folderID string, updates []FlagUpdate) err
 		if err != nil {
 			return fmt.Errorf("failed to update flags for UID %d: %w", u.UID, err)
 		}
+		if err := replaceServerTags(tx, folderID, u.UID, u.Tags); err != nil {
+			return err
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -1283,6 +1324,8 @@ func (s *Store) ListConversationsByFolder(folderID string, offset, limit int, so
 		conversations = append(conversations, c)
 	}
 
+	s.attachConversationTags(conversations)
+
 	return conversations, nil
 }
 
@@ -1551,6 +1594,16 @@ func (s *Store) GetConversation(threadID, folderID string) (*Conversation, error
 	// Get participants
 	c.Participants, _ = s.getConversationParticipants(threadID, folderID)
 
+	// Get tags
+	for _, m := range c.Messages {
+		m.Tags, _ = s.GetTags(m.ID)
+		for _, tag := range m.Tags {
+			if !containsFold(c.Tags, tag) {
+				c.Tags = append(c.Tags, tag)
+			}
+		}
+	}
+
 	return c, nil
 }
 
@@ -1640,11 +1693,13 @@ func (s *Store) ReconcileThreads(accountID, messageID, threadID string) (int, er
 	// Find messages that have in_reply_to pointing to this message's ID
 	// and update their thread_id to match
 	// We check multiple formats of the message ID (with/without angle brackets)
+	// Gmail messages keep the thread Gmail assigned (X-GM-THRID)
 	query := `
 		UPDATE messages 
 		SET thread_id = ?
 		WHERE account_id = ? 
 		AND thread_id != ?
+		AND gm_msgid IS NULL
 		AND (
 			REPLACE(REPLACE(in_reply_to, '<', ''), '>', '') = ?
 			OR in_reply_to = ?
@@ -2080,6 +2135,8 @@ func (s *Store) SearchConversations(folderID, query string, offset, limit int, f
 		results = append(results, c)
 	}
 
+	s.attachSearchResultTags(results)
+
 	return results, totalCount, nil
 }
 
@@ -2202,6 +2259,8 @@ func (s *Store) SearchConversationsUnifiedInbox(query string, offset, limit int,
 		results = append(results, c)
 	}
 
+	s.attachSearchResultTags(results)
+
 	return results, totalCount, nil
 }
 
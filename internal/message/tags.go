@@ -0,0 +1,288 @@
+package message
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Tag is an IMAP keyword used as a user tag
+type Tag struct {
+	Keyword string `json:"keyword"`
+	Color   string `json:"color,omitempty"`
+	Count   int    `json:"count"` // Messages carrying the tag
+}
+
+// systemKeywords are keywords servers and clients set for their own
+// bookkeeping. They aren't shown as tags.
+var systemKeywords = map[string]bool{
+	"$forwarded":       true,
+	"$mdnsent":         true,
+	"$junk":            true,
+	"$notjunk":         true,
+	"junk":             true,
+	"nonjunk":          true,
+	"notjunk":          true,
+	"$phishing":        true,
+	"$submitpending":   true,
+	"$submitted":       true,
+	"$recent":          true,
+	"$hasattachment":   true,
+	"$hasnoattachment": true,
+}
+
+// IsTagKeyword reports whether an IMAP flag is a keyword shown as a tag.
+// System flags (\Seen, ...) and bookkeeping keywords are not.
+func IsTagKeyword(flag string) bool {
+	if flag == "" || strings.HasPrefix(flag, `\`) {
+		return false
+	}
+	return !systemKeywords[strings.ToLower(flag)]
+}
+
+// ValidateTag checks that a tag can be stored as an IMAP keyword, which
+// must be an atom (RFC 3501 section 9)
+func ValidateTag(keyword string) error {
+	if keyword == "" {
+		return fmt.Errorf("tag is empty")
+	}
+	if !IsTagKeyword(keyword) {
+		return fmt.Errorf("reserved tag: %s", keyword)
+	}
+	for _, r := range keyword {
+		if r <= 0x20 || r >= 0x7f || strings.ContainsRune(`(){%*"\]`, r) {
+			return fmt.Errorf("tag contains invalid character %q: %s", r, keyword)
+		}
+	}
+	return nil
+}
+
+// tagFilter returns the tag name of a "tag:<keyword>" list filter
+func tagFilter(filter string) (string, bool) {
+	keyword, ok := strings.CutPrefix(filter, "tag:")
+	return keyword, ok && keyword != ""
+}
+
+// tagSubquery selects the IDs of messages carrying a tag. The keyword is
+// inlined as a quoted literal since the filter helpers return plain SQL.
+func tagSubquery(keyword string) string {
+	return "SELECT message_id FROM message_tags WHERE keyword = '" + strings.ReplaceAll(keyword, "'", "''") + "'"
+}
+
+// ListTags returns an account's tags: keywords on its messages plus tags
+// that only have a color assigned. Keywords match case-insensitively; the
+// spelling on messages wins.
+func (s *Store) ListTags(accountID string) ([]*Tag, error) {
+	rows, err := s.db.Query(`
+		SELECT COALESCE(MAX(CASE WHEN count > 0 THEN keyword END), MAX(keyword)),
+		       MAX(color), SUM(count)
+		FROM (
+			SELECT keyword, color, 0 AS count FROM tags WHERE account_id = ?
+			UNION ALL
+			SELECT mt.keyword, NULL, COUNT(*)
+			FROM message_tags mt
+			JOIN messages m ON m.id = mt.message_id
+			WHERE m.account_id = ?
+			GROUP BY mt.keyword
+		)
+		GROUP BY keyword COLLATE NOCASE
+		ORDER BY keyword COLLATE NOCASE
+	`, accountID, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []*Tag{}
+	for rows.Next() {
+		t := &Tag{}
+		var color sql.NullString
+		if err := rows.Scan(&t.Keyword, &color, &t.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		t.Color = color.String
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// SetTagColor sets a tag's color for an account. An empty color removes it.
+func (s *Store) SetTagColor(accountID, keyword, color string) error {
+	var err error
+	if color == "" {
+		_, err = s.db.Exec("DELETE FROM tags WHERE account_id = ? AND keyword = ?", accountID, keyword)
+	} else {
+		_, err = s.db.Exec(`
+			INSERT INTO tags (account_id, keyword, color) VALUES (?, ?, ?)
+			ON CONFLICT(account_id, keyword) DO UPDATE SET color = excluded.color
+		`, accountID, keyword, color)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set tag color: %w", err)
+	}
+	return nil
+}
+
+// GetTags returns the tags of a message
+func (s *Store) GetTags(messageID string) ([]string, error) {
+	rows, err := s.db.Query(
+		"SELECT keyword FROM message_tags WHERE message_id = ? ORDER BY keyword",
+		messageID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var keyword string
+		if err := rows.Scan(&keyword); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, keyword)
+	}
+	return tags, rows.Err()
+}
+
+// AddTags tags messages. localOnly marks tags the server can't store, so
+// flag syncs leave them alone.
+func (s *Store) AddTags(messageIDs, keywords []string, localOnly bool) error {
+	return s.updateTags(messageIDs, keywords,
+		"INSERT OR REPLACE INTO message_tags (message_id, keyword, local_only) VALUES (?, ?, ?)", localOnly)
+}
+
+// RemoveTags removes tags from messages
+func (s *Store) RemoveTags(messageIDs, keywords []string) error {
+	return s.updateTags(messageIDs, keywords,
+		"DELETE FROM message_tags WHERE message_id = ? AND keyword = ?", nil)
+}
+
+// updateTags runs a per-message, per-keyword statement in one transaction
+func (s *Store) updateTags(messageIDs, keywords []string, query string, extra interface{}) error {
+	if len(messageIDs) == 0 || len(keywords) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, id := range messageIDs {
+		for _, keyword := range keywords {
+			args := []interface{}{id, keyword}
+			if extra != nil {
+				args = append(args, extra)
+			}
+			if _, err := stmt.Exec(args...); err != nil {
+				return fmt.Errorf("failed to update tags: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// insertTags stores the server keywords of a newly created message
+func (s *Store) insertTags(messageID string, keywords []string) error {
+	for _, keyword := range keywords {
+		if _, err := s.db.Exec(
+			"INSERT OR IGNORE INTO message_tags (message_id, keyword) VALUES (?, ?)",
+			messageID, keyword,
+		); err != nil {
+			return fmt.Errorf("failed to insert tag: %w", err)
+		}
+	}
+	return nil
+}
+
+// replaceServerTags replaces the server-synced tags of the message at
+// folderID/uid within a flag sync transaction. Local-only tags are kept.
+func replaceServerTags(tx *sql.Tx, folderID string, uid uint32, keywords []string) error {
+	_, err := tx.Exec(`
+		DELETE FROM message_tags
+		WHERE local_only = 0
+		AND message_id = (SELECT id FROM messages WHERE folder_id = ? AND uid = ?)
+	`, folderID, uid)
+	if err != nil {
+		return fmt.Errorf("failed to clear tags for UID %d: %w", uid, err)
+	}
+
+	for _, keyword := range keywords {
+		_, err := tx.Exec(`
+			INSERT OR IGNORE INTO message_tags (message_id, keyword)
+			SELECT id, ? FROM messages WHERE folder_id = ? AND uid = ?
+		`, keyword, folderID, uid)
+		if err != nil {
+			return fmt.Errorf("failed to insert tag for UID %d: %w", uid, err)
+		}
+	}
+	return nil
+}
+
+// attachConversationTags fills in the tags of each conversation's messages
+func (s *Store) attachConversationTags(conversations []*Conversation) {
+	var ids []interface{}
+	byMessage := make(map[string]*Conversation)
+	for _, c := range conversations {
+		for _, id := range c.MessageIDs {
+			ids = append(ids, id)
+			byMessage[id] = c
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	query := fmt.Sprintf(
+		"SELECT DISTINCT message_id, keyword FROM message_tags WHERE message_id IN (%s) ORDER BY keyword",
+		strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", "),
+	)
+	rows, err := s.db.Query(query, ids...)
+	if err != nil {
+		s.log.Warn().Err(err).Msg("Failed to get conversation tags")
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var messageID, keyword string
+		if err := rows.Scan(&messageID, &keyword); err != nil {
+			continue
+		}
+		c := byMessage[messageID]
+		if !containsFold(c.Tags, keyword) {
+			c.Tags = append(c.Tags, keyword)
+		}
+	}
+}
+
+// attachSearchResultTags fills in the tags of search results
+func (s *Store) attachSearchResultTags(results []*ConversationSearchResult) {
+	conversations := make([]*Conversation, len(results))
+	for i, r := range results {
+		conversations[i] = &r.Conversation
+	}
+	s.attachConversationTags(conversations)
+}
+
+// containsFold reports whether list contains s, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -24,6 +24,21 @@ var (
 
 	// MicrosoftClientID is the OAuth2 client ID for Microsoft/Outlook
 	MicrosoftClientID string
+
+	// YahooClientID and YahooClientSecret are the OAuth2 client for Yahoo Mail
+	YahooClientID     string
+	YahooClientSecret string
+
+	// AOLClientID and AOLClientSecret are the OAuth2 client for AOL Mail
+	AOLClientID     string
+	AOLClientSecret string
+
+	// FastmailClientID is the OAuth2 client ID for Fastmail (public client)
+	FastmailClientID string
+
+	// ZohoClientID and ZohoClientSecret are the OAuth2 client for Zoho Mail
+	ZohoClientID     string
+	ZohoClientSecret string
 )
 
 func init() {
@@ -59,6 +74,13 @@ func loadFromShim() {
 		GoogleClientID = creds["google_client_id"]
 		GoogleClientSecret = creds["google_client_secret"]
 		MicrosoftClientID = creds["microsoft_client_id"]
+		YahooClientID = creds["yahoo_client_id"]
+		YahooClientSecret = creds["yahoo_client_secret"]
+		AOLClientID = creds["aol_client_id"]
+		AOLClientSecret = creds["aol_client_secret"]
+		FastmailClientID = creds["fastmail_client_id"]
+		ZohoClientID = creds["zoho_client_id"]
+		ZohoClientSecret = creds["zoho_client_secret"]
 		return
 	}
 }
@@ -73,14 +95,9 @@ func IsMicrosoftConfigured() bool {
 	return MicrosoftClientID != ""
 }
 
-// IsProviderConfigured returns true if the specified provider has OAuth credentials
+// IsProviderConfigured returns true if the specified provider has OAuth credentials,
+// built in or from a provider file
 func IsProviderConfigured(provider string) bool {
-	switch provider {
-	case "google":
-		return IsGoogleConfigured()
-	case "microsoft":
-		return IsMicrosoftConfigured()
-	default:
-		return false
-	}
+	p, err := GetProvider(provider)
+	return err == nil && p.ClientID != ""
 }
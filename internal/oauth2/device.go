@@ -0,0 +1,300 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// deviceGrantType is the grant_type of RFC 8628 token requests
+const deviceGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+const (
+	// defaultPollInterval is used when the provider doesn't say how often
+	// to poll (RFC 8628 section 3.2)
+	defaultPollInterval = 5 * time.Second
+	// slowDownStep is added to the interval on a slow_down error
+	slowDownStep = 5 * time.Second
+	// maxPollInterval caps the interval after slow_down errors and
+	// network failures
+	maxPollInterval = 60 * time.Second
+)
+
+var (
+	// ErrDeviceCodeExpired is returned when the user didn't enter the code
+	// before it expired
+	ErrDeviceCodeExpired = errors.New("the sign-in code expired before it was used")
+	// ErrAccessDenied is returned when the user declined the authorization
+	ErrAccessDenied = errors.New("sign-in was declined")
+	// ErrAuthCancelled is returned when a flow was cancelled, either by the
+	// user or by starting another flow in its place
+	ErrAuthCancelled = errors.New("sign-in was cancelled")
+)
+
+// DeviceAuthorization is what the user needs to authorize the app on
+// another device: a code to enter at a verification URL
+type DeviceAuthorization struct {
+	Provider                string    `json:"provider"`
+	UserCode                string    `json:"userCode"`
+	VerificationURI         string    `json:"verificationUri"`
+	VerificationURIComplete string    `json:"verificationUriComplete,omitempty"` // Has the code filled in
+	ExpiresAt               time.Time `json:"expiresAt"`
+	Message                 string    `json:"message,omitempty"` // Provider's own instructions, if any
+}
+
+// DeviceSession represents an in-progress device authorization flow
+type DeviceSession struct {
+	Provider       string
+	DeviceCode     string
+	Interval       time.Duration
+	ExpiresAt      time.Time
+	ProviderConfig ProviderConfig
+	ctx            context.Context // Ends when the code expires or the flow is cancelled
+	cancel         context.CancelFunc
+}
+
+// deviceCodeResponse is the response from a device authorization endpoint
+type deviceCodeResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURL         string `json:"verification_url"` // Google's name for verification_uri
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+	Message                 string `json:"message"`
+}
+
+// SupportsDeviceFlow reports whether a provider has a device authorization
+// endpoint
+func (p ProviderConfig) SupportsDeviceFlow() bool {
+	return p.DeviceAuthURL != ""
+}
+
+// StartDeviceFlow begins the RFC 8628 device authorization flow. The
+// returned code must be shown to the user, who enters it at the
+// verification URL on any device; WaitForDeviceToken then polls for the
+// tokens of the returned session.
+func (m *Manager) StartDeviceFlow(ctx context.Context, providerName string) (*DeviceAuthorization, *DeviceSession, error) {
+	if !IsProviderConfigured(providerName) {
+		return nil, nil, fmt.Errorf("OAuth provider %s is not configured (missing client ID)", providerName)
+	}
+
+	provider, err := GetProvider(providerName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !provider.SupportsDeviceFlow() {
+		return nil, nil, fmt.Errorf("OAuth provider %s does not support the device authorization flow", providerName)
+	}
+
+	// Cancel any existing session
+	m.CancelAuthFlow()
+
+	data := url.Values{
+		"client_id": {provider.ClientID},
+		"scope":     {strings.Join(provider.Scopes, " ")},
+	}
+	if provider.ClientSecret != "" {
+		data.Set("client_secret", provider.ClientSecret)
+	}
+
+	body, status, err := m.postForm(ctx, provider.DeviceAuthURL, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if status != http.StatusOK {
+		return nil, nil, fmt.Errorf("device authorization failed (%d): %s", status, oauthError(body))
+	}
+
+	var resp deviceCodeResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse device authorization response: %w", err)
+	}
+	if resp.VerificationURI == "" {
+		resp.VerificationURI = resp.VerificationURL
+	}
+	if resp.DeviceCode == "" || resp.UserCode == "" || resp.VerificationURI == "" {
+		return nil, nil, fmt.Errorf("incomplete device authorization response")
+	}
+
+	interval := time.Duration(resp.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	expiresAt := time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	if resp.ExpiresIn <= 0 {
+		expiresAt = time.Now().Add(15 * time.Minute)
+	}
+
+	sessionCtx, cancel := context.WithDeadline(context.Background(), expiresAt)
+	session := &DeviceSession{
+		Provider:       providerName,
+		DeviceCode:     resp.DeviceCode,
+		Interval:       interval,
+		ExpiresAt:      expiresAt,
+		ProviderConfig: provider,
+		cancel:         cancel,
+		ctx:            sessionCtx,
+	}
+	m.mu.Lock()
+	m.deviceSession = session
+	m.mu.Unlock()
+
+	m.log.Info().
+		Str("provider", providerName).
+		Str("verification_uri", resp.VerificationURI).
+		Dur("interval", interval).
+		Time("expires_at", expiresAt).
+		Msg("Started OAuth device authorization flow")
+
+	return &DeviceAuthorization{
+		Provider:                providerName,
+		UserCode:                resp.UserCode,
+		VerificationURI:         resp.VerificationURI,
+		VerificationURIComplete: resp.VerificationURIComplete,
+		ExpiresAt:               expiresAt,
+		Message:                 resp.Message,
+	}, session, nil
+}
+
+// WaitForDeviceToken polls the token endpoint until the user authorizes
+// the app, declines, or the code expires. Returns the tokens and user email
+// on success, or ErrAuthCancelled once the session is cancelled.
+func (m *Manager) WaitForDeviceToken(ctx context.Context, session *DeviceSession) (*TokenResponse, string, error) {
+	if session == nil {
+		return nil, "", fmt.Errorf("no active OAuth device session")
+	}
+	provider := session.ProviderConfig
+
+	data := url.Values{
+		"grant_type":  {deviceGrantType},
+		"device_code": {session.DeviceCode},
+		"client_id":   {provider.ClientID},
+	}
+	if provider.ClientSecret != "" {
+		data.Set("client_secret", provider.ClientSecret)
+	}
+
+	interval := session.Interval
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		case <-session.ctx.Done():
+			if errors.Is(session.ctx.Err(), context.DeadlineExceeded) {
+				return nil, "", ErrDeviceCodeExpired
+			}
+			return nil, "", ErrAuthCancelled
+		case <-time.After(interval):
+		}
+
+		body, status, err := m.postForm(session.ctx, provider.TokenURL, data)
+		if err != nil {
+			if session.ctx.Err() != nil {
+				continue // Reported by the select above
+			}
+			// Back off on network errors rather than giving up
+			interval = min(interval*2, maxPollInterval)
+			m.log.Debug().Err(err).Dur("interval", interval).Msg("Device token poll failed, backing off")
+			continue
+		}
+
+		if status == http.StatusOK {
+			var tokens TokenResponse
+			if err := json.Unmarshal(body, &tokens); err != nil {
+				return nil, "", fmt.Errorf("failed to parse token response: %w", err)
+			}
+
+			email, err := m.getUserEmail(provider, &tokens)
+			if err != nil {
+				m.log.Warn().Err(err).Msg("Failed to get user email from tokens")
+				email = "" // Non-fatal, will need to be provided by user
+			}
+
+			m.clearDeviceSession(session)
+
+			m.log.Info().
+				Str("provider", session.Provider).
+				Str("email", email).
+				Msg("OAuth device authorization completed successfully")
+
+			return &tokens, email, nil
+		}
+
+		var errResp struct {
+			Error            string `json:"error"`
+			ErrorDescription string `json:"error_description"`
+		}
+		json.Unmarshal(body, &errResp)
+
+		switch errResp.Error {
+		case "authorization_pending":
+			// User hasn't finished yet
+		case "slow_down":
+			interval = min(interval+slowDownStep, maxPollInterval)
+			m.log.Debug().Dur("interval", interval).Msg("Device token poll asked to slow down")
+		case "access_denied", "authorization_declined": // Microsoft uses the latter
+			m.clearDeviceSession(session)
+			return nil, "", ErrAccessDenied
+		case "expired_token", "code_expired":
+			m.clearDeviceSession(session)
+			return nil, "", ErrDeviceCodeExpired
+		default:
+			m.clearDeviceSession(session)
+			return nil, "", fmt.Errorf("device token request failed (%d): %s - %s", status, errResp.Error, errResp.ErrorDescription)
+		}
+	}
+}
+
+// clearDeviceSession ends a device session if it is still the active one
+func (m *Manager) clearDeviceSession(session *DeviceSession) {
+	session.cancel()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.deviceSession == session {
+		m.deviceSession = nil
+	}
+}
+
+// postForm posts a form to an OAuth endpoint and returns the response body
+// and status
+func (m *Manager) postForm(ctx context.Context, endpoint string, data url.Values) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read response: %w", err)
+	}
+	return body, resp.StatusCode, nil
+}
+
+// oauthError formats the error in an OAuth error response
+func oauthError(body []byte) string {
+	var errResp struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &errResp); err != nil || errResp.Error == "" {
+		return strings.TrimSpace(string(body))
+	}
+	return errResp.Error + " - " + errResp.ErrorDescription
+}
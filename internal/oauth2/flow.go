@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hkdb/aerion/internal/logging"
@@ -45,9 +46,14 @@ type UserInfo struct {
 
 // Manager handles OAuth2 authorization flows
 type Manager struct {
-	log            zerolog.Logger
+	log zerolog.Logger
+
+	// mu guards the sessions, which Wails-bound calls replace while the
+	// goroutine waiting on a flow reads them
+	mu             sync.Mutex
 	activeSession  *AuthSession
 	callbackServer *CallbackServer
+	deviceSession  *DeviceSession
 	httpClient     *http.Client
 }
 
@@ -73,6 +79,9 @@ func (m *Manager) StartAuthFlow(ctx context.Context, providerName string) (strin
 	if err != nil {
 		return "", err
 	}
+	if provider.Flow == FlowDevice {
+		return "", fmt.Errorf("OAuth provider %s only supports the device authorization flow, use StartDeviceFlow", providerName)
+	}
 
 	return m.startAuthFlowInternal(ctx, providerName, provider, nil)
 }
@@ -106,14 +115,14 @@ func (m *Manager) startAuthFlowInternal(ctx context.Context, providerName string
 	}
 
 	// Start callback server
-	m.callbackServer = NewCallbackServer()
-	port, err := m.callbackServer.Start(ctx)
+	server := NewCallbackServer()
+	port, err := server.Start(ctx)
 	if err != nil {
 		return "", fmt.Errorf("failed to start callback server: %w", err)
 	}
 
 	// Create session
-	m.activeSession = &AuthSession{
+	session := &AuthSession{
 		Provider:       providerName,
 		State:          state,
 		CodeVerifier:   verifier,
@@ -121,6 +130,10 @@ func (m *Manager) startAuthFlowInternal(ctx context.Context, providerName string
 		CreatedAt:      time.Now(),
 		ProviderConfig: customConfig,
 	}
+	m.mu.Lock()
+	m.callbackServer = server
+	m.activeSession = session
+	m.mu.Unlock()
 
 	// Build authorization URL
 	authURL := buildAuthURL(provider, state, challenge, port)
@@ -136,12 +149,13 @@ func (m *Manager) startAuthFlowInternal(ctx context.Context, providerName string
 // WaitForCallback waits for the OAuth callback and exchanges the code for tokens
 // Returns the tokens and user email on success
 func (m *Manager) WaitForCallback(ctx context.Context) (*TokenResponse, string, error) {
-	if m.activeSession == nil || m.callbackServer == nil {
+	m.mu.Lock()
+	session, server := m.activeSession, m.callbackServer
+	m.mu.Unlock()
+	if session == nil || server == nil {
 		return nil, "", fmt.Errorf("no active OAuth session")
 	}
 
-	session := m.activeSession
-
 	// Use custom provider config if available, otherwise look up by name
 	var provider ProviderConfig
 	var err error
@@ -155,7 +169,7 @@ func (m *Manager) WaitForCallback(ctx context.Context) (*TokenResponse, string,
 	}
 
 	// Wait for callback
-	result, err := m.callbackServer.WaitForCallback(ctx)
+	result, err := server.WaitForCallback(ctx)
 	if err != nil {
 		return nil, "", fmt.Errorf("callback failed: %w", err)
 	}
@@ -184,7 +198,11 @@ func (m *Manager) WaitForCallback(ctx context.Context) (*TokenResponse, string,
 	}
 
 	// Clear session
-	m.activeSession = nil
+	m.mu.Lock()
+	if m.activeSession == session {
+		m.activeSession = nil
+	}
+	m.mu.Unlock()
 
 	m.log.Info().
 		Str("provider", session.Provider).
@@ -196,11 +214,18 @@ func (m *Manager) WaitForCallback(ctx context.Context) (*TokenResponse, string,
 
 // CancelAuthFlow cancels any active OAuth flow
 func (m *Manager) CancelAuthFlow() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if m.callbackServer != nil {
 		m.callbackServer.Stop()
 		m.callbackServer = nil
 	}
 	m.activeSession = nil
+	if m.deviceSession != nil {
+		m.deviceSession.cancel()
+		m.deviceSession = nil
+	}
 }
 
 // RefreshToken uses a refresh token to obtain a new access token
@@ -267,7 +292,9 @@ func (m *Manager) RefreshToken(providerName, refreshToken string) (*TokenRespons
 
 // HasActiveSession returns true if there's an active OAuth session
 func (m *Manager) HasActiveSession() bool {
-	return m.activeSession != nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.activeSession != nil || m.deviceSession != nil
 }
 
 // exchangeCode exchanges an authorization code for tokens
@@ -275,11 +302,13 @@ func (m *Manager) exchangeCode(provider ProviderConfig, code, codeVerifier strin
 	redirectURI := fmt.Sprintf("http://localhost:%d/callback", port)
 
 	data := url.Values{
-		"grant_type":    {"authorization_code"},
-		"code":          {code},
-		"redirect_uri":  {redirectURI},
-		"client_id":     {provider.ClientID},
-		"code_verifier": {codeVerifier},
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {redirectURI},
+		"client_id":    {provider.ClientID},
+	}
+	if provider.PKCE {
+		data.Set("code_verifier", codeVerifier)
 	}
 
 	// Add client secret if present
@@ -332,17 +361,11 @@ func (m *Manager) getUserEmail(provider ProviderConfig, tokens *TokenResponse) (
 	}
 
 	// Fall back to userinfo endpoint
-	var userinfoURL string
-	switch provider.Name {
-	case "google":
-		userinfoURL = "https://www.googleapis.com/oauth2/v2/userinfo"
-	case "microsoft":
-		userinfoURL = "https://graph.microsoft.com/v1.0/me"
-	default:
+	if provider.UserInfoURL == "" {
 		return "", fmt.Errorf("userinfo not supported for provider: %s", provider.Name)
 	}
 
-	req, err := http.NewRequest("GET", userinfoURL, nil)
+	req, err := http.NewRequest("GET", provider.UserInfoURL, nil)
 	if err != nil {
 		return "", err
 	}
@@ -387,15 +410,19 @@ func buildAuthURL(provider ProviderConfig, state, codeChallenge string, port int
 	redirectURI := fmt.Sprintf("http://localhost:%d/callback", port)
 
 	params := url.Values{
-		"client_id":             {provider.ClientID},
-		"response_type":         {"code"},
-		"redirect_uri":          {redirectURI},
-		"scope":                 {strings.Join(provider.Scopes, " ")},
-		"state":                 {state},
-		"code_challenge":        {codeChallenge},
-		"code_challenge_method": {"S256"},
-		"access_type":           {"offline"}, // Request refresh token (Google)
-		"prompt":                {"consent"}, // Force consent to get refresh token
+		"client_id":     {provider.ClientID},
+		"response_type": {"code"},
+		"redirect_uri":  {redirectURI},
+		"scope":         {strings.Join(provider.Scopes, " ")},
+		"state":         {state},
+	}
+	if provider.PKCE {
+		params.Set("code_challenge", codeChallenge)
+		params.Set("code_challenge_method", "S256")
+	}
+	// Provider-specific parameters, e.g. access_type=offline for Google
+	for k, v := range provider.AuthParams {
+		params.Set(k, v)
 	}
 
 	authURL := provider.AuthURL + "?" + params.Encode()
@@ -1,16 +1,53 @@
 package oauth2
 
-import "fmt"
+// FlowType selects how the user authorizes the app
+type FlowType string
 
-// ProviderConfig defines OAuth2 endpoints and settings for a provider
+const (
+	FlowLoopback FlowType = "loopback" // Browser redirect to a local callback server
+	FlowDevice   FlowType = "device"   // RFC 8628 device authorization grant
+)
+
+// Provider purposes
+const (
+	PurposeMail     = "mail"     // Sign in to mail accounts (IMAP/SMTP/POP3)
+	PurposeContacts = "contacts" // Contacts-only sources
+)
+
+// ProviderConfig defines OAuth2 endpoints and settings for a provider. The
+// JSON form is what provider files contain.
 type ProviderConfig struct {
-	Name         string   // Provider identifier: "google", "microsoft"
-	DisplayName  string   // Human-readable name
-	AuthURL      string   // Authorization endpoint
-	TokenURL     string   // Token exchange endpoint
-	Scopes       []string // Required OAuth scopes
-	ClientID     string   // OAuth client ID
-	ClientSecret string   // OAuth client secret (may be empty for public clients)
+	Name          string            `json:"name"`                    // Provider identifier: "google", "microsoft"
+	DisplayName   string            `json:"displayName"`             // Human-readable name
+	Purpose       string            `json:"purpose,omitempty"`       // "mail" (default) or "contacts"
+	AuthURL       string            `json:"authUrl"`                 // Authorization endpoint
+	TokenURL      string            `json:"tokenUrl"`                // Token exchange endpoint
+	DeviceAuthURL string            `json:"deviceAuthUrl,omitempty"` // Device authorization endpoint (RFC 8628)
+	UserInfoURL   string            `json:"userInfoUrl,omitempty"`   // Looked up for the email if the ID token has none
+	Scopes        []string          `json:"scopes"`                  // Required OAuth scopes
+	ClientID      string            `json:"clientId"`                // OAuth client ID
+	ClientSecret  string            `json:"clientSecret,omitempty"`  // OAuth client secret (may be empty for public clients)
+	PKCE          bool              `json:"pkce"`                    // Send an RFC 7636 code challenge
+	Flow          FlowType          `json:"flow,omitempty"`          // "loopback" (default) or "device"
+	AuthParams    map[string]string `json:"authParams,omitempty"`    // Extra authorization URL parameters
+
+	// Host patterns (path.Match syntax, e.g. "imap.zoho.*") of the servers
+	// that take this provider's tokens
+	IMAPHosts []string `json:"imapHosts,omitempty"`
+	SMTPHosts []string `json:"smtpHosts,omitempty"`
+
+	// Servers new accounts signed in with this provider use
+	Servers *MailServers `json:"servers,omitempty"`
+}
+
+// MailServers are the default servers of a provider's mail accounts
+type MailServers struct {
+	IMAPHost     string `json:"imapHost"`
+	IMAPPort     int    `json:"imapPort"`
+	IMAPSecurity string `json:"imapSecurity"` // "tls", "starttls" or "none"
+	SMTPHost     string `json:"smtpHost"`
+	SMTPPort     int    `json:"smtpPort"`
+	SMTPSecurity string `json:"smtpSecurity"`
 }
 
 // GoogleProvider returns the OAuth2 configuration for Google/Gmail
@@ -18,8 +55,12 @@ func GoogleProvider() ProviderConfig {
 	return ProviderConfig{
 		Name:        "google",
 		DisplayName: "Google",
+		Purpose:     PurposeMail,
 		AuthURL:     "https://accounts.google.com/o/oauth2/v2/auth",
 		TokenURL:    "https://oauth2.googleapis.com/token",
+		// No device flow: Google's device endpoint only serves "TVs and
+		// Limited Input devices" clients and doesn't allow the Gmail scope
+		UserInfoURL: "https://www.googleapis.com/oauth2/v2/userinfo",
 		Scopes: []string{
 			"https://mail.google.com/",                                // Full Gmail access (IMAP/SMTP)
 			"https://www.googleapis.com/auth/contacts.other.readonly", // Other contacts (for autocomplete)
@@ -29,6 +70,17 @@ func GoogleProvider() ProviderConfig {
 		},
 		ClientID:     GoogleClientID,
 		ClientSecret: GoogleClientSecret,
+		PKCE:         true,
+		AuthParams: map[string]string{
+			"access_type": "offline", // Request refresh token
+			"prompt":      "consent", // Force consent to get refresh token
+		},
+		IMAPHosts: []string{"imap.gmail.com", "imap.googlemail.com", "pop.gmail.com"},
+		SMTPHosts: []string{"smtp.gmail.com", "smtp.googlemail.com"},
+		Servers: &MailServers{
+			IMAPHost: "imap.gmail.com", IMAPPort: 993, IMAPSecurity: "tls",
+			SMTPHost: "smtp.gmail.com", SMTPPort: 587, SMTPSecurity: "starttls",
+		},
 	}
 }
 
@@ -37,9 +89,12 @@ func MicrosoftProvider() ProviderConfig {
 	return ProviderConfig{
 		Name:        "microsoft",
 		DisplayName: "Microsoft",
+		Purpose:     PurposeMail,
 		// Use "common" tenant for both personal and work/school accounts
-		AuthURL:  "https://login.microsoftonline.com/common/oauth2/v2.0/authorize",
-		TokenURL: "https://login.microsoftonline.com/common/oauth2/v2.0/token",
+		AuthURL:       "https://login.microsoftonline.com/common/oauth2/v2.0/authorize",
+		TokenURL:      "https://login.microsoftonline.com/common/oauth2/v2.0/token",
+		DeviceAuthURL: "https://login.microsoftonline.com/common/oauth2/v2.0/devicecode",
+		UserInfoURL:   "https://graph.microsoft.com/v1.0/me",
 		Scopes: []string{
 			"https://outlook.office.com/IMAP.AccessAsUser.All", // IMAP access
 			"https://outlook.office.com/SMTP.Send",             // SMTP send
@@ -51,6 +106,111 @@ func MicrosoftProvider() ProviderConfig {
 		},
 		ClientID:     MicrosoftClientID,
 		ClientSecret: "", // Public client, no secret needed
+		PKCE:         true,
+		AuthParams: map[string]string{
+			"access_type": "offline",
+			"prompt":      "consent",
+		},
+		IMAPHosts: []string{"outlook.office365.com", "outlook.live.com", "imap-mail.outlook.com"},
+		SMTPHosts: []string{"smtp.office365.com", "smtp-mail.outlook.com"},
+		Servers: &MailServers{
+			IMAPHost: "outlook.office365.com", IMAPPort: 993, IMAPSecurity: "tls",
+			SMTPHost: "smtp.office365.com", SMTPPort: 587, SMTPSecurity: "starttls",
+		},
+	}
+}
+
+// YahooProvider returns the OAuth2 configuration for Yahoo Mail
+func YahooProvider() ProviderConfig {
+	return ProviderConfig{
+		Name:         "yahoo",
+		DisplayName:  "Yahoo",
+		Purpose:      PurposeMail,
+		AuthURL:      "https://api.login.yahoo.com/oauth2/request_auth",
+		TokenURL:     "https://api.login.yahoo.com/oauth2/get_token",
+		Scopes:       []string{"mail-w"},
+		ClientID:     YahooClientID,
+		ClientSecret: YahooClientSecret,
+		PKCE:         true,
+		IMAPHosts:    []string{"imap.mail.yahoo.com", "pop.mail.yahoo.com"},
+		SMTPHosts:    []string{"smtp.mail.yahoo.com"},
+		Servers: &MailServers{
+			IMAPHost: "imap.mail.yahoo.com", IMAPPort: 993, IMAPSecurity: "tls",
+			SMTPHost: "smtp.mail.yahoo.com", SMTPPort: 465, SMTPSecurity: "tls",
+		},
+	}
+}
+
+// AOLProvider returns the OAuth2 configuration for AOL Mail
+func AOLProvider() ProviderConfig {
+	return ProviderConfig{
+		Name:         "aol",
+		DisplayName:  "AOL",
+		Purpose:      PurposeMail,
+		AuthURL:      "https://api.login.aol.com/oauth2/request_auth",
+		TokenURL:     "https://api.login.aol.com/oauth2/get_token",
+		Scopes:       []string{"mail-w"},
+		ClientID:     AOLClientID,
+		ClientSecret: AOLClientSecret,
+		PKCE:         true,
+		IMAPHosts:    []string{"imap.aol.com", "pop.aol.com"},
+		SMTPHosts:    []string{"smtp.aol.com"},
+		Servers: &MailServers{
+			IMAPHost: "imap.aol.com", IMAPPort: 993, IMAPSecurity: "tls",
+			SMTPHost: "smtp.aol.com", SMTPPort: 465, SMTPSecurity: "tls",
+		},
+	}
+}
+
+// FastmailProvider returns the OAuth2 configuration for Fastmail
+func FastmailProvider() ProviderConfig {
+	return ProviderConfig{
+		Name:        "fastmail",
+		DisplayName: "Fastmail",
+		Purpose:     PurposeMail,
+		AuthURL:     "https://api.fastmail.com/oauth/authorize",
+		TokenURL:    "https://api.fastmail.com/oauth/refresh",
+		Scopes: []string{
+			"https://www.fastmail.com/dev/protocol-imap",
+			"https://www.fastmail.com/dev/protocol-pop",
+			"https://www.fastmail.com/dev/protocol-smtp",
+			"offline_access",
+		},
+		ClientID:  FastmailClientID,
+		PKCE:      true,
+		IMAPHosts: []string{"imap.fastmail.com", "pop.fastmail.com"},
+		SMTPHosts: []string{"smtp.fastmail.com"},
+		Servers: &MailServers{
+			IMAPHost: "imap.fastmail.com", IMAPPort: 993, IMAPSecurity: "tls",
+			SMTPHost: "smtp.fastmail.com", SMTPPort: 465, SMTPSecurity: "tls",
+		},
+	}
+}
+
+// ZohoProvider returns the OAuth2 configuration for Zoho Mail. Accounts
+// in other data centers (zoho.eu, zoho.in, ...) can override the endpoints
+// in a provider file.
+func ZohoProvider() ProviderConfig {
+	return ProviderConfig{
+		Name:         "zoho",
+		DisplayName:  "Zoho",
+		Purpose:      PurposeMail,
+		AuthURL:      "https://accounts.zoho.com/oauth/v2/auth",
+		TokenURL:     "https://accounts.zoho.com/oauth/v2/token",
+		Scopes:       []string{"ZohoMail.messages.ALL", "ZohoMail.accounts.READ"},
+		ClientID:     ZohoClientID,
+		ClientSecret: ZohoClientSecret,
+		PKCE:         true,
+		AuthParams: map[string]string{
+			"access_type": "offline",
+			"prompt":      "consent",
+		},
+		IMAPHosts: []string{"imap.zoho.*", "imappro.zoho.*", "pop.zoho.*"},
+		SMTPHosts: []string{"smtp.zoho.*", "smtppro.zoho.*"},
+		Servers: &MailServers{
+			IMAPHost: "imap.zoho.com", IMAPPort: 993, IMAPSecurity: "tls",
+			SMTPHost: "smtp.zoho.com", SMTPPort: 465, SMTPSecurity: "tls",
+		},
 	}
 }
 
@@ -59,8 +219,10 @@ func GoogleContactsOnlyProvider() ProviderConfig {
 	return ProviderConfig{
 		Name:        "google-contacts",
 		DisplayName: "Google Contacts",
+		Purpose:     PurposeContacts,
 		AuthURL:     "https://accounts.google.com/o/oauth2/v2/auth",
 		TokenURL:    "https://oauth2.googleapis.com/token",
+		UserInfoURL: "https://www.googleapis.com/oauth2/v2/userinfo",
 		Scopes: []string{
 			"https://www.googleapis.com/auth/contacts.readonly", // Full contacts read access
 			"https://www.googleapis.com/auth/userinfo.email",    // Get user's email address
@@ -68,6 +230,11 @@ func GoogleContactsOnlyProvider() ProviderConfig {
 		},
 		ClientID:     GoogleClientID,
 		ClientSecret: GoogleClientSecret,
+		PKCE:         true,
+		AuthParams: map[string]string{
+			"access_type": "offline",
+			"prompt":      "consent",
+		},
 	}
 }
 
@@ -76,8 +243,10 @@ func MicrosoftContactsOnlyProvider() ProviderConfig {
 	return ProviderConfig{
 		Name:        "microsoft-contacts",
 		DisplayName: "Microsoft Contacts",
+		Purpose:     PurposeContacts,
 		AuthURL:     "https://login.microsoftonline.com/common/oauth2/v2.0/authorize",
 		TokenURL:    "https://login.microsoftonline.com/common/oauth2/v2.0/token",
+		UserInfoURL: "https://graph.microsoft.com/v1.0/me",
 		Scopes: []string{
 			"https://graph.microsoft.com/Contacts.Read", // Contacts read access
 			"offline_access",                            // Refresh tokens
@@ -86,31 +255,45 @@ func MicrosoftContactsOnlyProvider() ProviderConfig {
 		},
 		ClientID:     MicrosoftClientID,
 		ClientSecret: "", // Public client, no secret needed
+		PKCE:         true,
+		AuthParams: map[string]string{
+			"access_type": "offline",
+			"prompt":      "consent",
+		},
+	}
+}
+
+// builtinProviders returns the providers Aerion ships with, in the order
+// they are offered. Called after init so the credentials are loaded.
+func builtinProviders() []ProviderConfig {
+	return []ProviderConfig{
+		GoogleProvider(),
+		MicrosoftProvider(),
+		YahooProvider(),
+		AOLProvider(),
+		FastmailProvider(),
+		ZohoProvider(),
+		GoogleContactsOnlyProvider(),
+		MicrosoftContactsOnlyProvider(),
 	}
 }
 
 // GetProvider returns the OAuth2 configuration for the specified provider
 func GetProvider(name string) (ProviderConfig, error) {
-	switch name {
-	case "google":
-		return GoogleProvider(), nil
-	case "microsoft":
-		return MicrosoftProvider(), nil
-	case "google-contacts":
-		return GoogleContactsOnlyProvider(), nil
-	case "microsoft-contacts":
-		return MicrosoftContactsOnlyProvider(), nil
-	default:
-		return ProviderConfig{}, fmt.Errorf("unknown OAuth provider: %s", name)
-	}
+	return defaultRegistry().Get(name)
 }
 
 // SupportedProviders returns the list of supported OAuth provider names for email accounts
 func SupportedProviders() []string {
-	return []string{"google", "microsoft"}
+	return defaultRegistry().Names(PurposeMail)
 }
 
 // SupportedContactProviders returns the list of supported OAuth provider names for contacts-only sources
 func SupportedContactProviders() []string {
-	return []string{"google-contacts", "microsoft-contacts"}
+	return defaultRegistry().Names(PurposeContacts)
+}
+
+// ProviderForHost returns the mail provider whose tokens a server takes
+func ProviderForHost(host string) (ProviderConfig, bool) {
+	return defaultRegistry().ForHost(host)
 }
@@ -0,0 +1,216 @@
+package oauth2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/hkdb/aerion/internal/logging"
+)
+
+// providerFile is the format of a provider file:
+//
+//	{"providers": [{"name": "yahoo", "clientId": "..."}, ...]}
+//
+// An entry named like an existing provider only changes the fields it
+// sets, so a file can just add credentials to a built-in provider.
+type providerFile struct {
+	Providers []json.RawMessage `json:"providers"`
+}
+
+// Registry holds the known OAuth providers
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[string]ProviderConfig
+	order     []string
+}
+
+var (
+	registryOnce sync.Once
+	registry     *Registry
+)
+
+// defaultRegistry returns the registry of built-in providers plus any
+// loaded provider files
+func defaultRegistry() *Registry {
+	registryOnce.Do(func() {
+		registry = NewRegistry(builtinProviders()...)
+	})
+	return registry
+}
+
+// NewRegistry returns a registry holding the given providers
+func NewRegistry(providers ...ProviderConfig) *Registry {
+	r := &Registry{providers: make(map[string]ProviderConfig)}
+	for _, p := range providers {
+		r.set(p)
+	}
+	return r
+}
+
+// set adds or replaces a provider
+func (r *Registry) set(p ProviderConfig) {
+	if _, ok := r.providers[p.Name]; !ok {
+		r.order = append(r.order, p.Name)
+	}
+	r.providers[p.Name] = p
+}
+
+// Get returns a provider by name
+func (r *Registry) Get(name string) (ProviderConfig, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.providers[name]
+	if !ok {
+		return ProviderConfig{}, fmt.Errorf("unknown OAuth provider: %s", name)
+	}
+	return p, nil
+}
+
+// Names returns the names of the providers for a purpose, in order
+func (r *Registry) Names(purpose string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var names []string
+	for _, name := range r.order {
+		if providerPurpose(r.providers[name]) == purpose {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ForHost returns the first mail provider with an IMAP or SMTP host
+// pattern matching host
+func (r *Registry) ForHost(host string) (ProviderConfig, bool) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, name := range r.order {
+		p := r.providers[name]
+		if providerPurpose(p) != PurposeMail {
+			continue
+		}
+		if matchHost(p.IMAPHosts, host) || matchHost(p.SMTPHosts, host) {
+			return p, true
+		}
+	}
+	return ProviderConfig{}, false
+}
+
+// matchHost reports whether host matches any of the patterns
+func matchHost(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Load merges the providers in a provider file into the registry. A
+// missing file is not an error.
+func (r *Registry) Load(filePath string) error {
+	log := logging.WithComponent("oauth2")
+
+	data, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read OAuth provider file: %w", err)
+	}
+
+	var file providerFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse OAuth provider file %s: %w", filePath, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, raw := range file.Providers {
+		var entry struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(raw, &entry); err != nil || entry.Name == "" {
+			log.Warn().Str("file", filePath).Int("index", i).Msg("Skipping OAuth provider without a name")
+			continue
+		}
+
+		// Start from the existing provider so the entry only overrides
+		// what it sets
+		p := r.providers[entry.Name]
+		p.AuthParams = maps.Clone(p.AuthParams)
+		if p.Servers != nil {
+			servers := *p.Servers
+			p.Servers = &servers
+		}
+		if err := json.Unmarshal(raw, &p); err != nil {
+			log.Warn().Err(err).Str("file", filePath).Str("provider", entry.Name).Msg("Skipping invalid OAuth provider")
+			continue
+		}
+		if err := validateProvider(p); err != nil {
+			log.Warn().Err(err).Str("file", filePath).Str("provider", entry.Name).Msg("Skipping invalid OAuth provider")
+			continue
+		}
+
+		r.set(p)
+		log.Info().Str("file", filePath).Str("provider", p.Name).Msg("Loaded OAuth provider")
+	}
+	return nil
+}
+
+// validateProvider checks a provider has what its flow needs
+func validateProvider(p ProviderConfig) error {
+	if p.TokenURL == "" {
+		return fmt.Errorf("tokenUrl is required")
+	}
+	switch p.Flow {
+	case "", FlowLoopback:
+		if p.AuthURL == "" {
+			return fmt.Errorf("authUrl is required")
+		}
+	case FlowDevice:
+		if p.DeviceAuthURL == "" {
+			return fmt.Errorf("deviceAuthUrl is required for the device flow")
+		}
+	default:
+		return fmt.Errorf("unknown flow: %s", p.Flow)
+	}
+	switch providerPurpose(p) {
+	case PurposeMail, PurposeContacts:
+	default:
+		return fmt.Errorf("unknown purpose: %s", p.Purpose)
+	}
+	return nil
+}
+
+// providerPurpose returns a provider's purpose, defaulting to mail
+func providerPurpose(p ProviderConfig) string {
+	if p.Purpose == "" {
+		return PurposeMail
+	}
+	return p.Purpose
+}
+
+// LoadProviderFiles merges provider files into the default registry, in
+// order, so later files (the user's) override earlier ones (the system's)
+func LoadProviderFiles(paths ...string) {
+	log := logging.WithComponent("oauth2")
+
+	for _, p := range paths {
+		if err := defaultRegistry().Load(p); err != nil {
+			log.Error().Err(err).Str("file", p).Msg("Failed to load OAuth providers")
+		}
+	}
+}
@@ -104,7 +104,7 @@ func (s *CallbackServer) WaitForCallback(ctx context.Context) (*CallbackResult,
 		return nil, ctx.Err()
 
 	case <-s.done:
-		return nil, fmt.Errorf("callback server was stopped")
+		return nil, ErrAuthCancelled
 	}
 }
 
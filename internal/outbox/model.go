@@ -0,0 +1,53 @@
+// Package outbox provides persistent storage and background delivery of
+// outgoing messages
+package outbox
+
+import (
+	"time"
+
+	"github.com/hkdb/aerion/internal/smtp"
+)
+
+// Status represents the delivery state of an outbox message
+type Status string
+
+const (
+	// StatusQueued indicates the message is waiting for its send time
+	StatusQueued Status = "queued"
+	// StatusSending indicates delivery is in progress
+	StatusSending Status = "sending"
+	// StatusFailed indicates the server rejected the message; it stays in
+	// the outbox until the user retries or deletes it
+	StatusFailed Status = "failed"
+)
+
+// Message is a fully built (signed/encrypted) message waiting to be sent
+type Message struct {
+	ID          int64    `json:"id"`
+	AccountID   string   `json:"accountId"`
+	FromAddress string   `json:"fromAddress"`
+	Recipients  []string `json:"recipients"`
+	Subject     string   `json:"subject"`
+
+	// Final RFC 822 message handed to SMTP
+	RawMessage []byte `json:"-"`
+
+	// JSON-serialized smtp.ComposeMessage, kept so the message can be
+	// reopened in the composer. Empty for encrypted messages, whose plaintext
+	// is never stored.
+	ComposeData []byte `json:"-"`
+	Editable    bool   `json:"editable"`
+
+	// Delivery status notifications requested in the SMTP envelope
+	DSN *smtp.DSNOptions `json:"dsn,omitempty"`
+
+	// SendAt is when the message becomes due. Scheduled is set for "send
+	// later" messages, as opposed to the undo-send window or a retry.
+	SendAt    time.Time `json:"sendAt"`
+	Scheduled bool      `json:"scheduled"`
+
+	Status    Status    `json:"status"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"lastError,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
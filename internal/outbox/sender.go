@@ -0,0 +1,253 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/rs/zerolog"
+)
+
+// DeliverFunc hands a message to SMTP (and saves the Sent copy)
+type DeliverFunc func(m *Message) error
+
+// retryDelays is the backoff between attempts after a temporary failure.
+// The last entry repeats.
+var retryDelays = []time.Duration{
+	1 * time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+	30 * time.Minute,
+	1 * time.Hour,
+}
+
+// Sender delivers due outbox messages in the background
+type Sender struct {
+	store   *Store
+	deliver DeliverFunc
+	log     zerolog.Logger
+
+	// Callbacks
+	isTemporary func(error) bool            // classifies delivery errors as retryable
+	isConnected func() bool                 // optional: skip delivery when offline
+	onResult    func(m *Message, err error) // optional: called after each background delivery
+
+	// Control
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	running   bool
+	runningMu sync.Mutex
+	wake      chan struct{}
+
+	// maxWait bounds how long the loop sleeps, so messages queued by
+	// another process (detached composer) are noticed
+	maxWait time.Duration
+}
+
+// NewSender creates a new outbox sender. isTemporary decides whether a
+// failed delivery is retried later or marked failed.
+func NewSender(store *Store, deliver DeliverFunc, isTemporary func(error) bool) *Sender {
+	return &Sender{
+		store:       store,
+		deliver:     deliver,
+		isTemporary: isTemporary,
+		log:         logging.WithComponent("outbox-sender"),
+		wake:        make(chan struct{}, 1),
+		maxWait:     1 * time.Minute,
+	}
+}
+
+// SetConnectivityCheck sets a function to check network connectivity.
+// When set, due messages stay queued while offline.
+func (s *Sender) SetConnectivityCheck(check func() bool) {
+	s.isConnected = check
+}
+
+// SetResultCallback sets a function called after every background delivery
+// attempt with the delivery error (nil on success)
+func (s *Sender) SetResultCallback(cb func(m *Message, err error)) {
+	s.onResult = cb
+}
+
+// Start starts the background sender
+func (s *Sender) Start(ctx context.Context) {
+	s.runningMu.Lock()
+	defer s.runningMu.Unlock()
+
+	if s.running {
+		s.log.Warn().Msg("Sender already running")
+		return
+	}
+
+	// Anything still marked sending was interrupted by a shutdown or crash
+	if err := s.store.ResetSending(); err != nil {
+		s.log.Error().Err(err).Msg("Failed to requeue interrupted messages")
+	}
+
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.running = true
+
+	s.wg.Add(1)
+	go s.run()
+
+	s.log.Info().Msg("Outbox sender started")
+}
+
+// Stop stops the background sender, waiting for an in-flight delivery
+func (s *Sender) Stop() {
+	s.runningMu.Lock()
+	defer s.runningMu.Unlock()
+
+	if !s.running {
+		return
+	}
+
+	s.cancel()
+	s.wg.Wait()
+	s.running = false
+
+	s.log.Info().Msg("Outbox sender stopped")
+}
+
+// Wake makes the sender re-check the outbox now, e.g. after a message was
+// queued or connectivity returned
+func (s *Sender) Wake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// RetryNow skips the backoff for messages that failed temporarily and
+// wakes the sender. Called when connectivity returns.
+func (s *Sender) RetryNow() {
+	if err := s.store.ExpediteRetries(time.Now()); err != nil {
+		s.log.Error().Err(err).Msg("Failed to expedite retries")
+	}
+	s.Wake()
+}
+
+// run is the main sender loop
+func (s *Sender) run() {
+	defer s.wg.Done()
+
+	for {
+		s.sendDue()
+
+		timer := time.NewTimer(s.nextWait())
+		select {
+		case <-timer.C:
+		case <-s.wake:
+			timer.Stop()
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// nextWait returns how long to sleep until the next message is due
+func (s *Sender) nextWait() time.Duration {
+	next, err := s.store.NextSendAt()
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to get next send time")
+		return s.maxWait
+	}
+	if next == nil {
+		return s.maxWait
+	}
+
+	wait := time.Until(*next)
+	if wait < 0 {
+		// Due but skipped (offline); retry on the regular interval
+		return s.maxWait
+	}
+	if wait > s.maxWait {
+		return s.maxWait
+	}
+	// send_at has second precision; don't wake up just before it
+	return wait + time.Second
+}
+
+// sendDue delivers every queued message whose send time has passed
+func (s *Sender) sendDue() {
+	if s.isConnected != nil && !s.isConnected() {
+		s.log.Debug().Msg("Skipping outbox delivery — offline")
+		return
+	}
+
+	msgs, err := s.store.ListDue(time.Now())
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to list due outbox messages")
+		return
+	}
+
+	for _, m := range msgs {
+		if s.ctx.Err() != nil {
+			return
+		}
+
+		claimed, err := s.store.Claim(m.ID)
+		if err != nil {
+			s.log.Error().Err(err).Int64("id", m.ID).Msg("Failed to claim outbox message")
+			continue
+		}
+		if !claimed {
+			// Cancelled or sent by someone else in the meantime
+			continue
+		}
+
+		err = s.Deliver(m)
+		if s.onResult != nil {
+			s.onResult(m, err)
+		}
+	}
+}
+
+// Deliver sends a message that has already been claimed (status sending)
+// and records the outcome: sent messages are removed, temporary failures are
+// rescheduled with backoff, and anything else is marked failed. Returns the
+// delivery error. The result callback is left to the caller, so an
+// interactive send can report the outcome itself.
+func (s *Sender) Deliver(m *Message) error {
+	log := s.log.With().Int64("id", m.ID).Str("account_id", m.AccountID).Logger()
+
+	if err := s.store.RecordAttempt(m.ID); err != nil {
+		log.Warn().Err(err).Msg("Failed to record delivery attempt")
+	}
+	m.Attempts++
+
+	err := s.deliver(m)
+	switch {
+	case err == nil:
+		log.Info().Msg("Outbox message sent")
+		if err := s.store.Delete(m.ID); err != nil {
+			log.Error().Err(err).Msg("Failed to remove sent message from outbox")
+		}
+
+	case s.isTemporary != nil && s.isTemporary(err):
+		delay := retryDelays[len(retryDelays)-1]
+		if m.Attempts <= len(retryDelays) {
+			delay = retryDelays[m.Attempts-1]
+		}
+		log.Warn().Err(err).Int("attempt", m.Attempts).Dur("retryIn", delay).Msg("Temporary send failure, will retry")
+		m.Status = StatusQueued
+		m.SendAt = time.Now().Add(delay)
+		m.LastError = err.Error()
+		if err := s.store.Reschedule(m.ID, m.SendAt, m.LastError); err != nil {
+			log.Error().Err(err).Msg("Failed to reschedule outbox message")
+		}
+
+	default:
+		log.Error().Err(err).Msg("Outbox message rejected")
+		m.Status = StatusFailed
+		m.LastError = err.Error()
+		if err := s.store.MarkFailed(m.ID, m.LastError); err != nil {
+			log.Error().Err(err).Msg("Failed to mark outbox message failed")
+		}
+	}
+
+	return err
+}
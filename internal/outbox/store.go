@@ -0,0 +1,309 @@
+package outbox
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hkdb/aerion/internal/database"
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/rs/zerolog"
+)
+
+// Store provides outbox persistence
+type Store struct {
+	db  *database.DB
+	log zerolog.Logger
+}
+
+// NewStore creates a new outbox store
+func NewStore(db *database.DB) *Store {
+	return &Store{
+		db:  db,
+		log: logging.WithComponent("outbox-store"),
+	}
+}
+
+const selectColumns = `
+	SELECT id, account_id, from_address, recipients, subject,
+		raw_message, compose_data, dsn, send_at, scheduled,
+		status, attempts, last_error, created_at
+	FROM outbox
+`
+
+// Create adds a message to the outbox. Status defaults to queued.
+func (s *Store) Create(m *Message) error {
+	recipients, err := json.Marshal(m.Recipients)
+	if err != nil {
+		return fmt.Errorf("failed to marshal recipients: %w", err)
+	}
+
+	var dsn interface{}
+	if m.DSN != nil {
+		data, err := json.Marshal(m.DSN)
+		if err != nil {
+			return fmt.Errorf("failed to marshal DSN options: %w", err)
+		}
+		dsn = string(data)
+	}
+
+	if m.Status == "" {
+		m.Status = StatusQueued
+	}
+	m.CreatedAt = time.Now()
+	m.Editable = len(m.ComposeData) > 0
+
+	result, err := s.db.Exec(`
+		INSERT INTO outbox (
+			account_id, from_address, recipients, subject,
+			raw_message, compose_data, dsn, send_at, scheduled,
+			status, attempts, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0, ?)
+	`, m.AccountID, m.FromAddress, string(recipients), m.Subject,
+		m.RawMessage, nullBytes(m.ComposeData), dsn, m.SendAt.Unix(), m.Scheduled,
+		m.Status, m.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add message to outbox: %w", err)
+	}
+
+	m.ID, err = result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get outbox message ID: %w", err)
+	}
+
+	s.log.Debug().
+		Int64("id", m.ID).
+		Str("account_id", m.AccountID).
+		Time("send_at", m.SendAt).
+		Bool("scheduled", m.Scheduled).
+		Msg("Added message to outbox")
+
+	return nil
+}
+
+// Get returns an outbox message by ID, or nil if it doesn't exist
+func (s *Store) Get(id int64) (*Message, error) {
+	rows, err := s.db.Query(selectColumns+" WHERE id = ?", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outbox message: %w", err)
+	}
+	defer rows.Close()
+
+	msgs, err := s.scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 {
+		return nil, nil
+	}
+	return msgs[0], nil
+}
+
+// List returns the outbox for an account ordered by send time.
+// An empty accountID lists every account.
+func (s *Store) List(accountID string) ([]*Message, error) {
+	var rows *sql.Rows
+	var err error
+	if accountID == "" {
+		rows, err = s.db.Query(selectColumns + " ORDER BY send_at ASC, id ASC")
+	} else {
+		rows, err = s.db.Query(selectColumns+" WHERE account_id = ? ORDER BY send_at ASC, id ASC", accountID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanMessages(rows)
+}
+
+// ListDue returns queued messages whose send time has passed, oldest first
+func (s *Store) ListDue(now time.Time) ([]*Message, error) {
+	rows, err := s.db.Query(selectColumns+" WHERE status = ? AND send_at <= ? ORDER BY send_at ASC, id ASC",
+		StatusQueued, now.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due outbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanMessages(rows)
+}
+
+// NextSendAt returns the earliest send time among queued messages, or nil
+// if nothing is queued
+func (s *Store) NextSendAt() (*time.Time, error) {
+	var next sql.NullInt64
+	err := s.db.QueryRow("SELECT MIN(send_at) FROM outbox WHERE status = ?", StatusQueued).Scan(&next)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get next outbox send time: %w", err)
+	}
+	if !next.Valid {
+		return nil, nil
+	}
+	t := time.Unix(next.Int64, 0)
+	return &t, nil
+}
+
+// Claim marks a queued message as sending. Returns false if the message is
+// gone or not queued anymore (cancelled, or claimed by someone else).
+func (s *Store) Claim(id int64) (bool, error) {
+	result, err := s.db.Exec("UPDATE outbox SET status = ? WHERE id = ? AND status = ?",
+		StatusSending, id, StatusQueued)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim outbox message: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim outbox message: %w", err)
+	}
+	return n > 0, nil
+}
+
+// Reschedule queues a message again for sendAt, e.g. to retry after a
+// temporary failure or when the user picks "send now"
+func (s *Store) Reschedule(id int64, sendAt time.Time, lastError string) error {
+	_, err := s.db.Exec(`
+		UPDATE outbox SET status = ?, send_at = ?, last_error = ?
+		WHERE id = ?
+	`, StatusQueued, sendAt.Unix(), nullString(lastError), id)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule outbox message: %w", err)
+	}
+	return nil
+}
+
+// Requeue queues a waiting or failed message again for sendAt with a fresh
+// attempt count. Returns false if the message is gone or being sent.
+func (s *Store) Requeue(id int64, sendAt time.Time) (bool, error) {
+	result, err := s.db.Exec(`
+		UPDATE outbox SET status = ?, send_at = ?, attempts = 0, last_error = NULL
+		WHERE id = ? AND status != ?
+	`, StatusQueued, sendAt.Unix(), id, StatusSending)
+	if err != nil {
+		return false, fmt.Errorf("failed to requeue outbox message: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to requeue outbox message: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ExpediteRetries makes messages waiting out a retry backoff due now.
+// Messages that haven't been attempted yet keep their send time.
+func (s *Store) ExpediteRetries(now time.Time) error {
+	_, err := s.db.Exec("UPDATE outbox SET send_at = ? WHERE status = ? AND attempts > 0 AND send_at > ?",
+		now.Unix(), StatusQueued, now.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to expedite outbox retries: %w", err)
+	}
+	return nil
+}
+
+// RecordAttempt counts a delivery attempt
+func (s *Store) RecordAttempt(id int64) error {
+	_, err := s.db.Exec("UPDATE outbox SET attempts = attempts + 1 WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to record outbox attempt: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed marks a message as permanently failed
+func (s *Store) MarkFailed(id int64, lastError string) error {
+	_, err := s.db.Exec("UPDATE outbox SET status = ?, last_error = ? WHERE id = ?",
+		StatusFailed, lastError, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox message failed: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a message from the outbox
+func (s *Store) Delete(id int64) error {
+	_, err := s.db.Exec("DELETE FROM outbox WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete outbox message: %w", err)
+	}
+	return nil
+}
+
+// Cancel removes a message that isn't currently being sent. Returns false
+// if the message is gone or delivery has already started.
+func (s *Store) Cancel(id int64) (bool, error) {
+	result, err := s.db.Exec("DELETE FROM outbox WHERE id = ? AND status != ?", id, StatusSending)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel outbox message: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel outbox message: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ResetSending puts messages left in the sending state (e.g. the app quit
+// mid-delivery) back in the queue
+func (s *Store) ResetSending() error {
+	_, err := s.db.Exec("UPDATE outbox SET status = ? WHERE status = ?", StatusQueued, StatusSending)
+	if err != nil {
+		return fmt.Errorf("failed to reset sending outbox messages: %w", err)
+	}
+	return nil
+}
+
+// scanMessages scans multiple outbox messages from rows
+func (s *Store) scanMessages(rows *sql.Rows) ([]*Message, error) {
+	var msgs []*Message
+
+	for rows.Next() {
+		m := &Message{}
+		var recipients string
+		var composeData []byte
+		var dsn sql.NullString
+		var sendAt int64
+		var lastError sql.NullString
+
+		err := rows.Scan(
+			&m.ID, &m.AccountID, &m.FromAddress, &recipients, &m.Subject,
+			&m.RawMessage, &composeData, &dsn, &sendAt, &m.Scheduled,
+			&m.Status, &m.Attempts, &lastError, &m.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox message: %w", err)
+		}
+
+		if err := json.Unmarshal([]byte(recipients), &m.Recipients); err != nil {
+			s.log.Warn().Err(err).Int64("id", m.ID).Msg("Failed to parse outbox recipients")
+		}
+		if dsn.Valid {
+			if err := json.Unmarshal([]byte(dsn.String), &m.DSN); err != nil {
+				s.log.Warn().Err(err).Int64("id", m.ID).Msg("Failed to parse outbox DSN options")
+			}
+		}
+		m.ComposeData = composeData
+		m.Editable = len(composeData) > 0
+		m.SendAt = time.Unix(sendAt, 0)
+		m.LastError = lastError.String
+
+		msgs = append(msgs, m)
+	}
+
+	return msgs, rows.Err()
+}
+
+// Helper functions for nullable fields
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func nullBytes(b []byte) interface{} {
+	if len(b) == 0 {
+		return nil
+	}
+	return b
+}
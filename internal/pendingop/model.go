@@ -0,0 +1,74 @@
+// Package pendingop provides a persistent queue of IMAP operations that
+// couldn't reach the server, so they can be replayed once back online
+package pendingop
+
+import (
+	"time"
+)
+
+// Type identifies the IMAP operation to replay
+type Type string
+
+const (
+	// TypeAddFlags sets flags on messages (UID STORE +FLAGS)
+	TypeAddFlags Type = "add_flags"
+	// TypeRemoveFlags clears flags on messages (UID STORE -FLAGS)
+	TypeRemoveFlags Type = "remove_flags"
+	// TypeMove moves messages to DestFolderPath
+	TypeMove Type = "move"
+	// TypeCopy copies messages to DestFolderPath
+	TypeCopy Type = "copy"
+	// TypeExpunge permanently deletes messages
+	TypeExpunge Type = "expunge"
+	// TypeAppend uploads the raw message in Data to FolderPath
+	TypeAppend Type = "append"
+)
+
+// Status represents where an operation is in the queue
+type Status string
+
+const (
+	// StatusPending indicates the operation is waiting to be replayed
+	StatusPending Status = "pending"
+	// StatusConflict indicates the server state changed underneath the
+	// operation (UIDVALIDITY changed or all messages vanished), so it was
+	// not applied
+	StatusConflict Status = "conflict"
+	// StatusFailed indicates the server kept rejecting the operation
+	StatusFailed Status = "failed"
+)
+
+// MaxAttempts is how many times a replay may be rejected by the server
+// before the operation is marked failed. Connection errors don't count.
+const MaxAttempts = 3
+
+// Operation is a queued IMAP operation
+type Operation struct {
+	ID        int64  `json:"id"`
+	AccountID string `json:"accountId"`
+	Type      Type   `json:"type"`
+
+	// Mailbox the operation runs against. UIDValidity is the value the UIDs
+	// were valid for when queued (0 for appends).
+	FolderID    string `json:"folderId,omitempty"`
+	FolderPath  string `json:"folderPath"`
+	UIDValidity uint32 `json:"uidValidity"`
+
+	UIDs  []uint32 `json:"uids,omitempty"`
+	Flags []string `json:"flags,omitempty"`
+
+	// Server-side Email IDs, used instead of UIDs on JMAP accounts
+	RemoteIDs []string `json:"remoteIds,omitempty"`
+
+	// Destination for move/copy
+	DestFolderID   string `json:"destFolderId,omitempty"`
+	DestFolderPath string `json:"destFolderPath,omitempty"`
+
+	// Raw RFC 822 message for append
+	Data []byte `json:"-"`
+
+	Status    Status    `json:"status"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"lastError,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,250 @@
+package pendingop
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hkdb/aerion/internal/database"
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/rs/zerolog"
+)
+
+// Store provides pending operation persistence
+type Store struct {
+	db  *database.DB
+	log zerolog.Logger
+}
+
+// NewStore creates a new pending operation store
+func NewStore(db *database.DB) *Store {
+	return &Store{
+		db:  db,
+		log: logging.WithComponent("pendingop-store"),
+	}
+}
+
+const selectColumns = `
+	SELECT id, account_id, op_type, folder_id, folder_path, uid_validity,
+		uids, flags, remote_ids, dest_folder_id, dest_folder_path, data,
+		status, attempts, last_error, created_at
+	FROM pending_operations
+`
+
+// Enqueue appends an operation to the end of the account's queue
+func (s *Store) Enqueue(op *Operation) error {
+	uids, err := json.Marshal(op.UIDs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal UIDs: %w", err)
+	}
+	flags, err := json.Marshal(op.Flags)
+	if err != nil {
+		return fmt.Errorf("failed to marshal flags: %w", err)
+	}
+	var remoteIDs interface{}
+	if len(op.RemoteIDs) > 0 {
+		data, err := json.Marshal(op.RemoteIDs)
+		if err != nil {
+			return fmt.Errorf("failed to marshal remote IDs: %w", err)
+		}
+		remoteIDs = string(data)
+	}
+
+	op.Status = StatusPending
+	op.Attempts = 0
+	op.LastError = ""
+	op.CreatedAt = time.Now()
+
+	result, err := s.db.Exec(`
+		INSERT INTO pending_operations (
+			account_id, op_type, folder_id, folder_path, uid_validity,
+			uids, flags, remote_ids, dest_folder_id, dest_folder_path, data,
+			status, attempts, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0, ?)
+	`, op.AccountID, op.Type, nullString(op.FolderID), op.FolderPath, op.UIDValidity,
+		string(uids), string(flags), remoteIDs, nullString(op.DestFolderID), nullString(op.DestFolderPath), nullBytes(op.Data),
+		op.Status, op.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue pending operation: %w", err)
+	}
+
+	op.ID, err = result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get pending operation ID: %w", err)
+	}
+
+	s.log.Debug().
+		Int64("id", op.ID).
+		Str("account_id", op.AccountID).
+		Str("type", string(op.Type)).
+		Str("folder", op.FolderPath).
+		Int("uids", len(op.UIDs)).
+		Msg("Queued pending operation")
+
+	return nil
+}
+
+// List returns all queued operations for an account in queue order,
+// including conflicted and failed ones. An empty accountID lists every account.
+func (s *Store) List(accountID string) ([]*Operation, error) {
+	var rows *sql.Rows
+	var err error
+	if accountID == "" {
+		rows, err = s.db.Query(selectColumns + " ORDER BY id ASC")
+	} else {
+		rows, err = s.db.Query(selectColumns+" WHERE account_id = ? ORDER BY id ASC", accountID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending operations: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanOperations(rows)
+}
+
+// ListPending returns the operations still waiting to be replayed for an
+// account, oldest first
+func (s *Store) ListPending(accountID string) ([]*Operation, error) {
+	rows, err := s.db.Query(selectColumns+" WHERE account_id = ? AND status = ? ORDER BY id ASC", accountID, StatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending operations: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanOperations(rows)
+}
+
+// HasPending reports whether the account has operations waiting to be replayed
+func (s *Store) HasPending(accountID string) (bool, error) {
+	var count int
+	err := s.db.QueryRow(
+		"SELECT COUNT(*) FROM pending_operations WHERE account_id = ? AND status = ?",
+		accountID, StatusPending,
+	).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to count pending operations: %w", err)
+	}
+	return count > 0, nil
+}
+
+// ListAccountsWithPending returns the IDs of accounts that have operations
+// waiting to be replayed
+func (s *Store) ListAccountsWithPending() ([]string, error) {
+	rows, err := s.db.Query(
+		"SELECT DISTINCT account_id FROM pending_operations WHERE status = ?",
+		StatusPending,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounts with pending operations: %w", err)
+	}
+	defer rows.Close()
+
+	var accountIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan account ID: %w", err)
+		}
+		accountIDs = append(accountIDs, id)
+	}
+	return accountIDs, rows.Err()
+}
+
+// Delete removes an operation from the queue
+func (s *Store) Delete(id int64) error {
+	_, err := s.db.Exec("DELETE FROM pending_operations WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete pending operation: %w", err)
+	}
+	return nil
+}
+
+// MarkConflict takes an operation out of the replay queue, keeping it
+// visible with the reason it couldn't be applied
+func (s *Store) MarkConflict(id int64, reason string) error {
+	_, err := s.db.Exec(
+		"UPDATE pending_operations SET status = ?, last_error = ? WHERE id = ?",
+		StatusConflict, reason, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark pending operation as conflicted: %w", err)
+	}
+	return nil
+}
+
+// RecordFailure counts a rejected replay attempt. Once MaxAttempts is
+// reached the operation is marked failed and no longer replayed.
+func (s *Store) RecordFailure(id int64, errMsg string) error {
+	_, err := s.db.Exec(`
+		UPDATE pending_operations SET
+			attempts = attempts + 1,
+			last_error = ?,
+			status = CASE WHEN attempts + 1 >= ? THEN ? ELSE status END
+		WHERE id = ?
+	`, errMsg, MaxAttempts, StatusFailed, id)
+	if err != nil {
+		return fmt.Errorf("failed to record pending operation failure: %w", err)
+	}
+	return nil
+}
+
+// scanOperations scans multiple operations from rows
+func (s *Store) scanOperations(rows *sql.Rows) ([]*Operation, error) {
+	var ops []*Operation
+
+	for rows.Next() {
+		op := &Operation{}
+		var folderID, uids, flags, remoteIDs, destFolderID, destFolderPath, lastError sql.NullString
+		var data []byte
+
+		err := rows.Scan(
+			&op.ID, &op.AccountID, &op.Type, &folderID, &op.FolderPath, &op.UIDValidity,
+			&uids, &flags, &remoteIDs, &destFolderID, &destFolderPath, &data,
+			&op.Status, &op.Attempts, &lastError, &op.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pending operation: %w", err)
+		}
+
+		op.FolderID = folderID.String
+		op.DestFolderID = destFolderID.String
+		op.DestFolderPath = destFolderPath.String
+		op.LastError = lastError.String
+		op.Data = data
+		if uids.Valid && uids.String != "" {
+			if err := json.Unmarshal([]byte(uids.String), &op.UIDs); err != nil {
+				s.log.Warn().Err(err).Int64("id", op.ID).Msg("Failed to parse pending operation UIDs")
+			}
+		}
+		if flags.Valid && flags.String != "" {
+			if err := json.Unmarshal([]byte(flags.String), &op.Flags); err != nil {
+				s.log.Warn().Err(err).Int64("id", op.ID).Msg("Failed to parse pending operation flags")
+			}
+		}
+		if remoteIDs.Valid && remoteIDs.String != "" {
+			if err := json.Unmarshal([]byte(remoteIDs.String), &op.RemoteIDs); err != nil {
+				s.log.Warn().Err(err).Int64("id", op.ID).Msg("Failed to parse pending operation remote IDs")
+			}
+		}
+
+		ops = append(ops, op)
+	}
+
+	return ops, rows.Err()
+}
+
+// Helper functions for nullable fields
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func nullBytes(b []byte) interface{} {
+	if len(b) == 0 {
+		return nil
+	}
+	return b
+}
@@ -150,6 +150,32 @@ func (p *Paths) KeyringPath() string {
 	return filepath.Join(p.Data, "keys")
 }
 
+// SystemConfigDir returns the machine-wide configuration directory, where
+// administrators can preset defaults for every user
+func SystemConfigDir() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join("/Library", "Application Support", "Aerion")
+	case "windows":
+		programData := os.Getenv("ProgramData")
+		if programData == "" {
+			programData = `C:\ProgramData`
+		}
+		return filepath.Join(programData, "Aerion")
+	default:
+		return filepath.Join("/etc", appName)
+	}
+}
+
+// OAuthProviderPaths returns the OAuth provider files to load, the
+// system-wide one first so the user's can override it
+func (p *Paths) OAuthProviderPaths() []string {
+	return []string{
+		filepath.Join(SystemConfigDir(), "oauth-providers.json"),
+		filepath.Join(p.Config, "oauth-providers.json"),
+	}
+}
+
 // ConfigFilePath returns the path to the main config file
 func (p *Paths) ConfigFilePath() string {
 	return filepath.Join(p.Config, "config.toml")
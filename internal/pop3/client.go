@@ -0,0 +1,551 @@
+// Package pop3 provides a POP3 (RFC 1939) client for downloading mail into
+// local folders, and the UIDL bookkeeping that keeps messages left on the
+// server from being downloaded twice
+package pop3
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-sasl"
+	"github.com/hkdb/aerion/internal/imap"
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/rs/zerolog"
+)
+
+// Default ports for plain/STLS and implicit TLS connections
+const (
+	DefaultPort    = 110
+	DefaultTLSPort = 995
+)
+
+// SecurityType represents the connection security method
+type SecurityType string
+
+const (
+	SecurityNone     SecurityType = "none"
+	SecurityTLS      SecurityType = "tls"
+	SecurityStartTLS SecurityType = "starttls" // STLS (RFC 2595)
+)
+
+// AuthType represents the authentication method
+type AuthType string
+
+const (
+	AuthTypePassword AuthType = "password" // USER/PASS
+	AuthTypeAPOP     AuthType = "apop"     // APOP digest of the greeting timestamp
+	AuthTypeOAuth2   AuthType = "oauth2"   // AUTH (RFC 5034) with OAUTHBEARER or XOAUTH2
+)
+
+// ClientConfig holds the configuration for connecting to a POP3 server
+type ClientConfig struct {
+	Host     string
+	Port     int
+	Security SecurityType
+	Username string
+	Password string
+
+	// Authentication
+	AuthType    AuthType // "password", "apop" or "oauth2" (defaults to "password")
+	AccessToken string   // OAuth2 access token (when AuthType is "oauth2")
+
+	// Timeouts
+	ConnectTimeout time.Duration
+	CommandTimeout time.Duration // Single-line commands and listings
+	DataTimeout    time.Duration // Downloading one message (RETR/TOP)
+
+	// TLS config (optional)
+	TLSConfig *tls.Config
+}
+
+// DefaultConfig returns a ClientConfig with sensible defaults
+func DefaultConfig() ClientConfig {
+	return ClientConfig{
+		Port:           DefaultTLSPort,
+		Security:       SecurityTLS,
+		ConnectTimeout: 30 * time.Second,
+		CommandTimeout: 30 * time.Second,
+		DataTimeout:    5 * time.Minute,
+	}
+}
+
+// MessageInfo describes a message in the maildrop. Numbers are only valid
+// for the current session; UIDs identify a message across sessions.
+type MessageInfo struct {
+	Number int
+	Size   int
+	UID    string
+}
+
+// Client is a POP3 client
+type Client struct {
+	config    ClientConfig
+	conn      net.Conn
+	text      *textproto.Reader
+	timestamp string          // APOP timestamp from the greeting
+	caps      map[string]bool // CAPA tags (RFC 2449), upper-cased
+	sasl      []string        // Mechanisms listed by the SASL capability
+	log       zerolog.Logger
+}
+
+// NewClient creates a new POP3 client but does not connect
+func NewClient(config ClientConfig) *Client {
+	return &Client{
+		config: config,
+		log:    logging.WithComponent("pop3"),
+	}
+}
+
+// Connect establishes a connection to the server, reads the greeting and
+// upgrades to TLS when configured
+func (c *Client) Connect() error {
+	addr := net.JoinHostPort(c.config.Host, strconv.Itoa(c.config.Port))
+
+	c.log.Debug().
+		Str("host", c.config.Host).
+		Int("port", c.config.Port).
+		Str("security", string(c.config.Security)).
+		Msg("Connecting to POP3 server")
+
+	tlsConfig := c.config.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{ServerName: c.config.Host}
+	}
+
+	dialer := &net.Dialer{Timeout: c.config.ConnectTimeout}
+
+	var err error
+	switch c.config.Security {
+	case SecurityTLS:
+		c.conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+		if err != nil {
+			return fmt.Errorf("failed to connect with TLS: %w", err)
+		}
+	default:
+		c.conn, err = dialer.Dial("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("failed to connect: %w", err)
+		}
+	}
+	c.text = textproto.NewReader(bufio.NewReader(c.conn))
+
+	c.conn.SetDeadline(time.Now().Add(c.config.CommandTimeout))
+	greeting, err := c.readStatus("greeting")
+	if err != nil {
+		c.conn.Close()
+		c.conn = nil
+		return fmt.Errorf("failed to receive greeting: %w", err)
+	}
+	c.timestamp = apopTimestamp(greeting)
+
+	// CAPA is optional (RFC 2449); servers without it get the RFC 1939 basics
+	c.readCapabilities()
+
+	if c.config.Security == SecurityStartTLS {
+		if !c.caps["STLS"] {
+			c.conn.Close()
+			c.conn = nil
+			return fmt.Errorf("server does not support STLS")
+		}
+		if _, err := c.command("STLS"); err != nil {
+			c.conn.Close()
+			c.conn = nil
+			return fmt.Errorf("STLS failed: %w", err)
+		}
+
+		tlsConn := tls.Client(c.conn, tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			c.conn.Close()
+			c.conn = nil
+			return fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		c.conn = tlsConn
+		c.text = textproto.NewReader(bufio.NewReader(c.conn))
+
+		// Capabilities may change once the connection is secure
+		c.readCapabilities()
+		c.log.Debug().Msg("Upgraded connection to TLS via STLS")
+	}
+
+	c.log.Info().Str("host", c.config.Host).Msg("Connected to POP3 server")
+	return nil
+}
+
+// Login authenticates with the server
+func (c *Client) Login() error {
+	if c.conn == nil {
+		return ErrNotConnected
+	}
+
+	authType := c.config.AuthType
+	if authType == "" {
+		authType = AuthTypePassword
+	}
+
+	c.log.Debug().
+		Str("username", c.config.Username).
+		Str("authType", string(authType)).
+		Msg("Authenticating")
+
+	var err error
+	switch authType {
+	case AuthTypeAPOP:
+		err = c.loginAPOP()
+	case AuthTypeOAuth2:
+		err = c.loginOAuth2()
+	default:
+		err = c.loginPassword()
+	}
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	// Some servers only advertise UIDL and friends after login
+	c.readCapabilities()
+
+	c.log.Info().Str("username", c.config.Username).Msg("Logged in to POP3 server")
+	return nil
+}
+
+// loginPassword authenticates with USER and PASS
+func (c *Client) loginPassword() error {
+	if _, err := c.command("USER " + c.config.Username); err != nil {
+		return err
+	}
+	_, err := c.command("PASS " + c.config.Password)
+	return err
+}
+
+// loginAPOP authenticates with an MD5 digest of the greeting timestamp and
+// the password, so the password never crosses the wire
+func (c *Client) loginAPOP() error {
+	if c.timestamp == "" {
+		return ErrAPOPUnsupported
+	}
+	sum := md5.Sum([]byte(c.timestamp + c.config.Password))
+	_, err := c.command("APOP " + c.config.Username + " " + hex.EncodeToString(sum[:]))
+	return err
+}
+
+// loginOAuth2 authenticates with AUTH, preferring OAUTHBEARER over XOAUTH2
+func (c *Client) loginOAuth2() error {
+	if c.config.AccessToken == "" {
+		return fmt.Errorf("OAuth2 authentication requires an access token")
+	}
+
+	var saslClient sasl.Client
+	if c.hasSASL(sasl.OAuthBearer) {
+		saslClient = sasl.NewOAuthBearerClient(&sasl.OAuthBearerOptions{
+			Username: c.config.Username,
+			Token:    c.config.AccessToken,
+			Host:     c.config.Host,
+			Port:     c.config.Port,
+		})
+	} else {
+		saslClient = imap.NewXOAuth2Client(c.config.Username, c.config.AccessToken)
+	}
+	return c.authenticate(saslClient)
+}
+
+// authenticate runs a SASL exchange with AUTH (RFC 5034)
+func (c *Client) authenticate(saslClient sasl.Client) error {
+	mech, ir, err := saslClient.Start()
+	if err != nil {
+		return err
+	}
+
+	cmd := "AUTH " + mech
+	if ir != nil {
+		encoded := base64.StdEncoding.EncodeToString(ir)
+		if encoded == "" {
+			encoded = "="
+		}
+		cmd += " " + encoded
+	}
+	if err := c.send(cmd); err != nil {
+		return err
+	}
+
+	for {
+		line, err := c.text.ReadLine()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case strings.HasPrefix(line, "+OK"):
+			return nil
+		case strings.HasPrefix(line, "-ERR"):
+			return parseResponseError("AUTH", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+
+		// Anything else is a "+ <challenge>" continuation
+		challenge, err := base64.StdEncoding.DecodeString(strings.TrimSpace(strings.TrimPrefix(line, "+")))
+		if err != nil {
+			return fmt.Errorf("invalid authentication challenge: %w", err)
+		}
+		reply, err := saslClient.Next(challenge)
+		if err != nil {
+			// Cancel the exchange so the server answers -ERR
+			c.send("*")
+			c.text.ReadLine()
+			return err
+		}
+		if err := c.send(base64.StdEncoding.EncodeToString(reply)); err != nil {
+			return err
+		}
+	}
+}
+
+// HasCapability reports whether the server advertised a CAPA tag, e.g.
+// "UIDL" or "TOP"
+func (c *Client) HasCapability(name string) bool {
+	return c.caps[strings.ToUpper(name)]
+}
+
+// hasSASL reports whether the server offers a SASL mechanism
+func (c *Client) hasSASL(mech string) bool {
+	for _, m := range c.sasl {
+		if strings.EqualFold(m, mech) {
+			return true
+		}
+	}
+	return false
+}
+
+// readCapabilities asks for CAPA, leaving the capability set empty when
+// the server doesn't support it
+func (c *Client) readCapabilities() {
+	c.caps = make(map[string]bool)
+	c.sasl = nil
+
+	lines, err := c.multiline("CAPA", c.config.CommandTimeout)
+	if err != nil {
+		return
+	}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		tag := strings.ToUpper(fields[0])
+		c.caps[tag] = true
+		if tag == "SASL" {
+			c.sasl = fields[1:]
+		}
+	}
+}
+
+// Stat returns the number of messages in the maildrop and their total size
+func (c *Client) Stat() (count, size int, err error) {
+	text, err := c.command("STAT")
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := fmt.Sscanf(text, "%d %d", &count, &size); err != nil {
+		return 0, 0, fmt.Errorf("invalid STAT response %q", text)
+	}
+	return count, size, nil
+}
+
+// ListMessages returns every message in the maildrop with its size and
+// unique ID, in maildrop order. UIDs are left empty if the server doesn't
+// support UIDL.
+func (c *Client) ListMessages() ([]MessageInfo, error) {
+	sizes, err := c.multiline("LIST", c.config.CommandTimeout)
+	if err != nil {
+		return nil, err
+	}
+	uids, err := c.multiline("UIDL", c.config.CommandTimeout)
+	if err != nil {
+		var respErr *ResponseError
+		if !errors.As(err, &respErr) {
+			return nil, err
+		}
+		c.log.Debug().Msg("Server does not support UIDL")
+		uids = nil
+	}
+
+	byNumber := make(map[int]*MessageInfo, len(sizes))
+	messages := make([]MessageInfo, 0, len(sizes))
+	for _, line := range sizes {
+		var info MessageInfo
+		if _, err := fmt.Sscanf(line, "%d %d", &info.Number, &info.Size); err != nil {
+			continue
+		}
+		messages = append(messages, info)
+	}
+	for i := range messages {
+		byNumber[messages[i].Number] = &messages[i]
+	}
+	for _, line := range uids {
+		num, uid, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(num)
+		if err != nil {
+			continue
+		}
+		if info := byNumber[n]; info != nil {
+			info.UID = strings.TrimSpace(uid)
+		}
+	}
+	return messages, nil
+}
+
+// Top returns the header of a message and the first lines of its body
+func (c *Client) Top(number, lines int) ([]byte, error) {
+	return c.data(fmt.Sprintf("TOP %d %d", number, lines))
+}
+
+// Retrieve downloads a whole message
+func (c *Client) Retrieve(number int) ([]byte, error) {
+	return c.data(fmt.Sprintf("RETR %d", number))
+}
+
+// Delete marks a message for deletion. The server removes it when the
+// session ends with Close; dropping the connection instead keeps it.
+func (c *Client) Delete(number int) error {
+	_, err := c.command(fmt.Sprintf("DELE %d", number))
+	return err
+}
+
+// Reset unmarks every message marked for deletion in this session
+func (c *Client) Reset() error {
+	_, err := c.command("RSET")
+	return err
+}
+
+// Noop keeps the session alive
+func (c *Client) Noop() error {
+	_, err := c.command("NOOP")
+	return err
+}
+
+// Close sends QUIT, which commits deletions, and closes the connection
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+
+	_, quitErr := c.command("QUIT")
+	if quitErr != nil {
+		c.log.Debug().Err(quitErr).Msg("QUIT failed")
+	}
+
+	err := c.conn.Close()
+	c.conn = nil
+	if quitErr != nil {
+		return quitErr
+	}
+	return err
+}
+
+// send writes a command line
+func (c *Client) send(line string) error {
+	if c.conn == nil {
+		return ErrNotConnected
+	}
+	_, err := c.conn.Write([]byte(line + "\r\n"))
+	return err
+}
+
+// readStatus reads a +OK/-ERR line and returns the text after the status
+func (c *Client) readStatus(command string) (string, error) {
+	line, err := c.text.ReadLine()
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case strings.HasPrefix(line, "+OK"):
+		return strings.TrimSpace(strings.TrimPrefix(line, "+OK")), nil
+	case strings.HasPrefix(line, "-ERR"):
+		return "", parseResponseError(command, strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+	default:
+		return "", fmt.Errorf("unexpected response to %s: %q", command, line)
+	}
+}
+
+// command sends a single-line command and returns the text of its response
+func (c *Client) command(line string) (string, error) {
+	if c.conn == nil {
+		return "", ErrNotConnected
+	}
+	c.conn.SetDeadline(time.Now().Add(c.config.CommandTimeout))
+	if err := c.send(line); err != nil {
+		return "", err
+	}
+	return c.readStatus(commandName(line))
+}
+
+// multiline sends a command with a dot-terminated listing response and
+// returns its lines
+func (c *Client) multiline(line string, timeout time.Duration) ([]string, error) {
+	if c.conn == nil {
+		return nil, ErrNotConnected
+	}
+	c.conn.SetDeadline(time.Now().Add(timeout))
+	if err := c.send(line); err != nil {
+		return nil, err
+	}
+	if _, err := c.readStatus(commandName(line)); err != nil {
+		return nil, err
+	}
+	return c.text.ReadDotLines()
+}
+
+// data sends a command whose response is a dot-stuffed message and returns
+// it unstuffed, with CRLF line endings
+func (c *Client) data(line string) ([]byte, error) {
+	if c.conn == nil {
+		return nil, ErrNotConnected
+	}
+	c.conn.SetDeadline(time.Now().Add(c.config.DataTimeout))
+	if err := c.send(line); err != nil {
+		return nil, err
+	}
+	if _, err := c.readStatus(commandName(line)); err != nil {
+		return nil, err
+	}
+	raw, err := c.text.ReadDotBytes()
+	if err != nil {
+		return nil, err
+	}
+	// ReadDotBytes turns CRLF into LF; messages are stored as sent
+	return []byte(strings.ReplaceAll(string(raw), "\n", "\r\n")), nil
+}
+
+// commandName returns the verb of a command line, without arguments that
+// may hold credentials
+func commandName(line string) string {
+	name, _, _ := strings.Cut(line, " ")
+	return name
+}
+
+// apopTimestamp extracts the <...@...> timestamp from a server greeting
+func apopTimestamp(greeting string) string {
+	start := strings.Index(greeting, "<")
+	if start < 0 {
+		return ""
+	}
+	end := strings.Index(greeting[start:], ">")
+	if end < 0 {
+		return ""
+	}
+	timestamp := greeting[start : start+end+1]
+	if !strings.Contains(timestamp, "@") {
+		return ""
+	}
+	return timestamp
+}
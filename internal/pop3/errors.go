@@ -0,0 +1,68 @@
+package pop3
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+)
+
+var (
+	// ErrNotConnected indicates the client is not connected
+	ErrNotConnected = errors.New("not connected to POP3 server")
+
+	// ErrAPOPUnsupported indicates the greeting carried no APOP timestamp
+	ErrAPOPUnsupported = errors.New("POP3 server does not support APOP")
+)
+
+// ResponseError is returned when the server answers -ERR
+type ResponseError struct {
+	Command string
+	Code    string // Extended response code (RFC 2449), e.g. "IN-USE"
+	Message string
+}
+
+func (e *ResponseError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = "command failed"
+	}
+	if e.Code != "" {
+		return "pop3 server: " + e.Command + ": " + msg + " (" + e.Code + ")"
+	}
+	return "pop3 server: " + e.Command + ": " + msg
+}
+
+// parseResponseError builds a ResponseError from the text after -ERR
+func parseResponseError(command, text string) *ResponseError {
+	respErr := &ResponseError{Command: command, Message: text}
+	if strings.HasPrefix(text, "[") {
+		if end := strings.Index(text, "]"); end > 0 {
+			respErr.Code = strings.ToUpper(text[1:end])
+			respErr.Message = strings.TrimSpace(text[end+1:])
+		}
+	}
+	return respErr
+}
+
+// IsTemporaryError reports whether a failure is worth retrying later: the
+// server couldn't be reached, the connection dropped, or the maildrop is
+// locked by another session (RFC 2449 IN-USE, RFC 3206 SYS/TEMP)
+func IsTemporaryError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var respErr *ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.Code == "IN-USE" || respErr.Code == "SYS/TEMP" || respErr.Code == "LOGIN-DELAY"
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	// Dial, DNS and read/write failures
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
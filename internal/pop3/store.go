@@ -0,0 +1,77 @@
+package pop3
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hkdb/aerion/internal/database"
+)
+
+// Store remembers which maildrop messages have been downloaded, by UIDL
+type Store struct {
+	db *database.DB
+}
+
+// NewStore creates a new UIDL store
+func NewStore(db *database.DB) *Store {
+	return &Store{db: db}
+}
+
+// ListUIDLs returns the UIDLs downloaded for an account and when each was
+// downloaded
+func (s *Store) ListUIDLs(accountID string) (map[string]time.Time, error) {
+	rows, err := s.db.Query("SELECT uidl, downloaded_at FROM pop3_uidls WHERE account_id = ?", accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list UIDLs: %w", err)
+	}
+	defer rows.Close()
+
+	uidls := make(map[string]time.Time)
+	for rows.Next() {
+		var uidl string
+		var downloadedAt time.Time
+		if err := rows.Scan(&uidl, &downloadedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan UIDL: %w", err)
+		}
+		uidls[uidl] = downloadedAt
+	}
+	return uidls, rows.Err()
+}
+
+// AddUIDL records that a message has been downloaded
+func (s *Store) AddUIDL(accountID, uidl string, downloadedAt time.Time) error {
+	_, err := s.db.Exec(
+		"INSERT OR IGNORE INTO pop3_uidls (account_id, uidl, downloaded_at) VALUES (?, ?, ?)",
+		accountID, uidl, downloadedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add UIDL: %w", err)
+	}
+	return nil
+}
+
+// DeleteUIDLs forgets UIDLs that are no longer in the maildrop
+func (s *Store) DeleteUIDLs(accountID string, uidls []string) error {
+	if len(uidls) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("DELETE FROM pop3_uidls WHERE account_id = ? AND uidl = ?")
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, uidl := range uidls {
+		if _, err := stmt.Exec(accountID, uidl); err != nil {
+			return fmt.Errorf("failed to delete UIDL: %w", err)
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,288 @@
+package rules
+
+import (
+	"fmt"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hkdb/aerion/internal/message"
+)
+
+// Message is the view of a message that conditions are tested against
+type Message struct {
+	ID             string
+	Subject        string
+	From           []Address
+	To             []Address
+	Cc             []Address
+	Size           int64
+	HasAttachments bool
+
+	// Header holds the full header block. Only needed when a rule tests
+	// FieldHeader or FieldListID; see Evaluator.NeedsHeaders.
+	Header textproto.MIMEHeader
+}
+
+// Address is a display name and email address pair
+type Address struct {
+	Name  string
+	Email string
+}
+
+// Validate checks that a rule is well formed before it is saved
+func Validate(r *Rule) error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("rule name is required")
+	}
+	if r.Match != MatchAll && r.Match != MatchAny {
+		return fmt.Errorf("invalid match mode: %s", r.Match)
+	}
+	if len(r.Conditions) == 0 {
+		return fmt.Errorf("rule needs at least one condition")
+	}
+	if len(r.Actions) == 0 {
+		return fmt.Errorf("rule needs at least one action")
+	}
+
+	for _, c := range r.Conditions {
+		switch c.Field {
+		case FieldFrom, FieldTo, FieldCc, FieldSubject, FieldListID:
+		case FieldHeader:
+			if strings.TrimSpace(c.Header) == "" {
+				return fmt.Errorf("header condition needs a header name")
+			}
+		case FieldSize:
+			if _, err := strconv.ParseInt(c.Value, 10, 64); err != nil {
+				return fmt.Errorf("size must be a number of bytes: %s", c.Value)
+			}
+			if c.Operator != OpGreaterThan && c.Operator != OpLessThan && c.Operator != OpEquals {
+				return fmt.Errorf("invalid operator for size: %s", c.Operator)
+			}
+			continue
+		case FieldHasAttachment:
+			if _, err := strconv.ParseBool(c.Value); err != nil {
+				return fmt.Errorf("has attachment must be true or false: %s", c.Value)
+			}
+			continue
+		default:
+			return fmt.Errorf("invalid condition field: %s", c.Field)
+		}
+
+		switch c.Operator {
+		case OpContains, OpNotContains, OpEquals, OpStartsWith, OpEndsWith:
+		case OpMatches:
+			if _, err := compilePattern(c.Value); err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", c.Value, err)
+			}
+		default:
+			return fmt.Errorf("invalid operator for %s: %s", c.Field, c.Operator)
+		}
+	}
+
+	for _, a := range r.Actions {
+		switch a.Type {
+		case ActionMove, ActionCopy:
+			if a.Value == "" {
+				return fmt.Errorf("%s action needs a destination folder", a.Type)
+			}
+		case ActionAddKeyword:
+			if err := message.ValidateTag(a.Value); err != nil {
+				return fmt.Errorf("invalid keyword: %w", err)
+			}
+		case ActionForward:
+			if !strings.Contains(a.Value, "@") {
+				return fmt.Errorf("invalid forward address: %q", a.Value)
+			}
+		case ActionMarkRead, ActionStar, ActionSkipNotification:
+		default:
+			return fmt.Errorf("invalid action: %s", a.Type)
+		}
+	}
+
+	return nil
+}
+
+// Evaluator runs an ordered rule list against messages
+type Evaluator struct {
+	rules    []*Rule
+	patterns map[string]*regexp.Regexp
+}
+
+// NewEvaluator prepares the enabled rules for evaluation, in the order given
+func NewEvaluator(ruleList []*Rule) *Evaluator {
+	e := &Evaluator{patterns: make(map[string]*regexp.Regexp)}
+	for _, r := range ruleList {
+		if !r.Enabled {
+			continue
+		}
+		e.rules = append(e.rules, r)
+		for _, c := range r.Conditions {
+			if c.Operator != OpMatches {
+				continue
+			}
+			if re, err := compilePattern(c.Value); err == nil {
+				e.patterns[c.Value] = re
+			}
+		}
+	}
+	return e
+}
+
+// Empty reports whether there are no enabled rules to run
+func (e *Evaluator) Empty() bool {
+	return len(e.rules) == 0
+}
+
+// NeedsHeaders reports whether any rule tests a header that isn't stored
+// locally, so the caller must populate Message.Header
+func (e *Evaluator) NeedsHeaders() bool {
+	for _, r := range e.rules {
+		for _, c := range r.Conditions {
+			if c.Field == FieldHeader || c.Field == FieldListID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Evaluate returns the rules that match msg and the combined list of actions
+// to apply, honouring StopProcessing. Returns nil if nothing matched.
+func (e *Evaluator) Evaluate(msg *Message) *Match {
+	var result *Match
+	for _, r := range e.rules {
+		if !e.matchRule(r, msg) {
+			continue
+		}
+
+		if result == nil {
+			result = &Match{MessageID: msg.ID, Subject: msg.Subject}
+			if len(msg.From) > 0 {
+				result.FromEmail = msg.From[0].Email
+			}
+		}
+		result.RuleIDs = append(result.RuleIDs, r.ID)
+		result.RuleNames = append(result.RuleNames, r.Name)
+		result.Actions = append(result.Actions, r.Actions...)
+
+		if r.StopProcessing {
+			break
+		}
+	}
+	return result
+}
+
+// matchRule tests a rule's conditions according to its match mode
+func (e *Evaluator) matchRule(r *Rule, msg *Message) bool {
+	for _, c := range r.Conditions {
+		matched := e.matchCondition(c, msg)
+		if r.Match == MatchAny && matched {
+			return true
+		}
+		if r.Match != MatchAny && !matched {
+			return false
+		}
+	}
+	return r.Match != MatchAny
+}
+
+// matchCondition tests a single condition
+func (e *Evaluator) matchCondition(c Condition, msg *Message) bool {
+	switch c.Field {
+	case FieldSize:
+		limit, err := strconv.ParseInt(c.Value, 10, 64)
+		if err != nil {
+			return false
+		}
+		switch c.Operator {
+		case OpGreaterThan:
+			return msg.Size > limit
+		case OpLessThan:
+			return msg.Size < limit
+		case OpEquals:
+			return msg.Size == limit
+		}
+		return false
+	case FieldHasAttachment:
+		want, err := strconv.ParseBool(c.Value)
+		return err == nil && msg.HasAttachments == want
+	}
+
+	values := fieldValues(c, msg)
+
+	// A negative test passes only if no value contains the text
+	if c.Operator == OpNotContains {
+		return !e.anyMatch(OpContains, c.Value, values)
+	}
+	return e.anyMatch(c.Operator, c.Value, values)
+}
+
+// anyMatch reports whether any of values satisfies the operator
+func (e *Evaluator) anyMatch(op Operator, want string, values []string) bool {
+	needle := strings.ToLower(want)
+	for _, v := range values {
+		hay := strings.ToLower(v)
+		switch op {
+		case OpContains:
+			if strings.Contains(hay, needle) {
+				return true
+			}
+		case OpEquals:
+			if hay == needle {
+				return true
+			}
+		case OpStartsWith:
+			if strings.HasPrefix(hay, needle) {
+				return true
+			}
+		case OpEndsWith:
+			if strings.HasSuffix(hay, needle) {
+				return true
+			}
+		case OpMatches:
+			if re := e.patterns[want]; re != nil && re.MatchString(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fieldValues returns the strings a text condition is tested against. Address
+// fields yield both the email and the display name of each address.
+func fieldValues(c Condition, msg *Message) []string {
+	switch c.Field {
+	case FieldFrom:
+		return addressValues(msg.From)
+	case FieldTo:
+		return addressValues(msg.To)
+	case FieldCc:
+		return addressValues(msg.Cc)
+	case FieldSubject:
+		return []string{msg.Subject}
+	case FieldListID:
+		return msg.Header.Values("List-Id")
+	case FieldHeader:
+		return msg.Header.Values(c.Header)
+	}
+	return nil
+}
+
+func addressValues(addrs []Address) []string {
+	values := make([]string, 0, len(addrs)*2)
+	for _, a := range addrs {
+		values = append(values, a.Email)
+		if a.Name != "" {
+			values = append(values, a.Name)
+		}
+	}
+	return values
+}
+
+// compilePattern compiles a user-supplied regular expression. Matching is
+// case-insensitive like the other operators.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)" + pattern)
+}
@@ -0,0 +1,100 @@
+// Package rules provides client-side message filter rules that run against
+// newly synced mail
+package rules
+
+import (
+	"time"
+)
+
+// Field identifies the part of a message a condition looks at
+type Field string
+
+const (
+	FieldFrom          Field = "from"
+	FieldTo            Field = "to"
+	FieldCc            Field = "cc"
+	FieldSubject       Field = "subject"
+	FieldHeader        Field = "header" // Arbitrary header named by Condition.Header
+	FieldListID        Field = "list_id"
+	FieldSize          Field = "size"           // Message size in bytes
+	FieldHasAttachment Field = "has_attachment" // Value "true" or "false"
+)
+
+// Operator is the comparison a condition applies
+type Operator string
+
+const (
+	OpContains    Operator = "contains"
+	OpNotContains Operator = "not_contains"
+	OpEquals      Operator = "equals"
+	OpStartsWith  Operator = "starts_with"
+	OpEndsWith    Operator = "ends_with"
+	OpMatches     Operator = "matches" // Regular expression
+	OpGreaterThan Operator = "greater_than"
+	OpLessThan    Operator = "less_than"
+)
+
+// MatchMode controls how a rule combines its conditions
+type MatchMode string
+
+const (
+	MatchAll MatchMode = "all"
+	MatchAny MatchMode = "any"
+)
+
+// ActionType identifies what a rule does to a matching message
+type ActionType string
+
+const (
+	ActionMove             ActionType = "move"        // Value: destination folder ID
+	ActionCopy             ActionType = "copy"        // Value: destination folder ID
+	ActionMarkRead         ActionType = "mark_read"   // No value
+	ActionStar             ActionType = "star"        // No value
+	ActionAddKeyword       ActionType = "add_keyword" // Value: IMAP keyword
+	ActionSkipNotification ActionType = "skip_notification"
+	ActionForward          ActionType = "forward" // Value: recipient address
+)
+
+// Condition is a single test against a message
+type Condition struct {
+	Field    Field    `json:"field"`
+	Header   string   `json:"header,omitempty"` // Header name for FieldHeader
+	Operator Operator `json:"operator"`
+	Value    string   `json:"value"`
+}
+
+// Action is a single step applied to a matching message
+type Action struct {
+	Type  ActionType `json:"type"`
+	Value string     `json:"value,omitempty"`
+}
+
+// Rule is an ordered filter belonging to an account
+type Rule struct {
+	ID        string `json:"id"`
+	AccountID string `json:"accountId"`
+	Name      string `json:"name"`
+	Enabled   bool   `json:"enabled"`
+	Position  int    `json:"position"`
+
+	Match      MatchMode   `json:"match"`
+	Conditions []Condition `json:"conditions"`
+	Actions    []Action    `json:"actions"`
+
+	// StopProcessing prevents later rules from running on a message this
+	// rule matched
+	StopProcessing bool `json:"stopProcessing"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Match is the outcome of evaluating the rule list against one message
+type Match struct {
+	MessageID string   `json:"messageId"`
+	Subject   string   `json:"subject"`
+	FromEmail string   `json:"fromEmail"`
+	RuleIDs   []string `json:"ruleIds"`
+	RuleNames []string `json:"ruleNames"`
+	Actions   []Action `json:"actions"`
+}
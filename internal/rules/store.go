@@ -0,0 +1,213 @@
+package rules
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hkdb/aerion/internal/database"
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/rs/zerolog"
+)
+
+// Store provides rule persistence
+type Store struct {
+	db  *database.DB
+	log zerolog.Logger
+}
+
+// NewStore creates a new rule store
+func NewStore(db *database.DB) *Store {
+	return &Store{
+		db:  db,
+		log: logging.WithComponent("rules-store"),
+	}
+}
+
+const selectColumns = `
+	SELECT id, account_id, name, enabled, position, match_mode,
+		conditions, actions, stop_processing, created_at, updated_at
+	FROM rules
+`
+
+// List returns an account's rules in evaluation order
+func (s *Store) List(accountID string) ([]*Rule, error) {
+	rows, err := s.db.Query(selectColumns+" WHERE account_id = ? ORDER BY position, created_at", accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rules: %w", err)
+	}
+	defer rows.Close()
+
+	var list []*Rule
+	for rows.Next() {
+		r, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
+
+// Get returns a rule by ID, or nil if it doesn't exist
+func (s *Store) Get(id string) (*Rule, error) {
+	rows, err := s.db.Query(selectColumns+" WHERE id = ?", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rule: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	return scanRule(rows)
+}
+
+// Save creates a rule (appending it to the end of the list) or updates an
+// existing one. Position is left unchanged on update; use Reorder.
+func (s *Store) Save(r *Rule) error {
+	conditions, err := json.Marshal(r.Conditions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal conditions: %w", err)
+	}
+	actions, err := json.Marshal(r.Actions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal actions: %w", err)
+	}
+
+	now := time.Now()
+	r.UpdatedAt = now
+
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+		r.CreatedAt = now
+
+		var maxPos sql.NullInt64
+		if err := s.db.QueryRow("SELECT MAX(position) FROM rules WHERE account_id = ?", r.AccountID).Scan(&maxPos); err != nil {
+			return fmt.Errorf("failed to get rule position: %w", err)
+		}
+		r.Position = 0
+		if maxPos.Valid {
+			r.Position = int(maxPos.Int64) + 1
+		}
+
+		_, err = s.db.Exec(`
+			INSERT INTO rules (
+				id, account_id, name, enabled, position, match_mode,
+				conditions, actions, stop_processing, created_at, updated_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, r.ID, r.AccountID, r.Name, r.Enabled, r.Position, r.Match,
+			string(conditions), string(actions), r.StopProcessing, r.CreatedAt, r.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create rule: %w", err)
+		}
+
+		s.log.Debug().Str("id", r.ID).Str("account_id", r.AccountID).Msg("Created rule")
+		return nil
+	}
+
+	result, err := s.db.Exec(`
+		UPDATE rules SET
+			name = ?, enabled = ?, match_mode = ?, conditions = ?, actions = ?,
+			stop_processing = ?, updated_at = ?
+		WHERE id = ?
+	`, r.Name, r.Enabled, r.Match, string(conditions), string(actions),
+		r.StopProcessing, r.UpdatedAt, r.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update rule: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("rule not found: %s", r.ID)
+	}
+
+	return nil
+}
+
+// Delete removes a rule
+func (s *Store) Delete(id string) error {
+	if _, err := s.db.Exec("DELETE FROM rules WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete rule: %w", err)
+	}
+	return nil
+}
+
+// Reorder sets the evaluation order of an account's rules. ruleIDs lists the
+// rules in their new order; rules not in the list keep their relative order
+// after the listed ones.
+func (s *Store) Reorder(accountID string, ruleIDs []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Push everything past the new range first so unlisted rules sort last
+	if _, err := tx.Exec("UPDATE rules SET position = position + ? WHERE account_id = ?", len(ruleIDs), accountID); err != nil {
+		return fmt.Errorf("failed to reorder rules: %w", err)
+	}
+
+	for i, id := range ruleIDs {
+		_, err := tx.Exec("UPDATE rules SET position = ? WHERE id = ? AND account_id = ?", i, id, accountID)
+		if err != nil {
+			return fmt.Errorf("failed to reorder rules: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetLastUID returns the highest UID rules have already processed in a
+// folder. ok is false if rules have never run on the folder, or if its
+// UIDVALIDITY has changed since.
+func (s *Store) GetLastUID(folderID string, uidValidity uint32) (uint32, bool, error) {
+	var storedValidity, lastUID uint32
+	err := s.db.QueryRow(
+		"SELECT uid_validity, last_uid FROM rules_folder_state WHERE folder_id = ?", folderID,
+	).Scan(&storedValidity, &lastUID)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get rule state: %w", err)
+	}
+	if storedValidity != uidValidity {
+		return 0, false, nil
+	}
+	return lastUID, true, nil
+}
+
+// SetLastUID records the highest UID rules have processed in a folder
+func (s *Store) SetLastUID(folderID string, uidValidity, lastUID uint32) error {
+	_, err := s.db.Exec(`
+		INSERT INTO rules_folder_state (folder_id, uid_validity, last_uid)
+		VALUES (?, ?, ?)
+		ON CONFLICT(folder_id) DO UPDATE SET
+			uid_validity = excluded.uid_validity,
+			last_uid = excluded.last_uid
+	`, folderID, uidValidity, lastUID)
+	if err != nil {
+		return fmt.Errorf("failed to save rule state: %w", err)
+	}
+	return nil
+}
+
+func scanRule(rows *sql.Rows) (*Rule, error) {
+	r := &Rule{}
+	var conditions, actions string
+	if err := rows.Scan(
+		&r.ID, &r.AccountID, &r.Name, &r.Enabled, &r.Position, &r.Match,
+		&conditions, &actions, &r.StopProcessing, &r.CreatedAt, &r.UpdatedAt,
+	); err != nil {
+		return nil, fmt.Errorf("failed to scan rule: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(conditions), &r.Conditions); err != nil {
+		return nil, fmt.Errorf("failed to parse rule conditions: %w", err)
+	}
+	if err := json.Unmarshal([]byte(actions), &r.Actions); err != nil {
+		return nil, fmt.Errorf("failed to parse rule actions: %w", err)
+	}
+	return r, nil
+}
@@ -0,0 +1,129 @@
+package send
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hkdb/aerion/internal/account"
+	"github.com/hkdb/aerion/internal/auth"
+	"github.com/hkdb/aerion/internal/certificate"
+	"github.com/hkdb/aerion/internal/jmap"
+	"github.com/hkdb/aerion/internal/outbox"
+	"github.com/hkdb/aerion/internal/smtp"
+)
+
+// Transport carries out the deliver and file stages
+type Transport interface {
+	// Deliver hands a message to the account's server
+	Deliver(acc *account.Account, m *outbox.Message) error
+	// File saves the Sent copy for providers that don't do it themselves
+	File(acc *account.Account, raw []byte) error
+}
+
+// serverTransport delivers over SMTP or JMAP and files over IMAP, or in the
+// local folders of accounts that keep them on disk
+type serverTransport struct {
+	s *Service
+}
+
+// Deliver submits over JMAP for JMAP accounts and over SMTP otherwise
+func (t serverTransport) Deliver(acc *account.Account, m *outbox.Message) error {
+	if acc.IsJMAP() {
+		if t.s.submitJMAP == nil {
+			return fmt.Errorf("JMAP sending is not available")
+		}
+		return t.s.submitJMAP(acc, m.FromAddress, m.Recipients, m.RawMessage)
+	}
+	return t.s.SendSMTP(acc, m.FromAddress, m.Recipients, m.RawMessage, m.DSN)
+}
+
+// File appends the Sent copy
+func (t serverTransport) File(acc *account.Account, raw []byte) error {
+	return t.s.File(acc, raw)
+}
+
+// IsTemporaryError reports whether a failed delivery is worth retrying,
+// over SMTP or JMAP
+func IsTemporaryError(err error) bool {
+	return smtp.IsTemporaryError(err) || jmap.IsTemporaryError(err)
+}
+
+// SMTPConfig builds the SMTP client config for an account, including
+// credentials
+func (s *Service) SMTPConfig(acc *account.Account) (smtp.ClientConfig, error) {
+	smtpConfig := smtp.DefaultConfig()
+	smtpConfig.Host = acc.SMTPHost
+	smtpConfig.Port = acc.SMTPPort
+	smtpConfig.Security = smtp.SecurityType(acc.SMTPSecurity)
+	smtpConfig.Username = acc.Username
+	smtpConfig.AuthMechanism = auth.Mechanism(acc.AuthMechanism)
+	tlsConfig, err := certificate.BuildClientTLSConfig(acc.SMTPHost, s.certStore, s.credStore, acc.ClientCertID)
+	if err != nil {
+		return smtpConfig, err
+	}
+	smtpConfig.TLSConfig = tlsConfig
+
+	// Handle authentication based on auth type
+	if acc.AuthType == account.AuthOAuth2 {
+		// Get valid OAuth token (refreshing if needed)
+		tokens, err := s.getToken(acc.ID)
+		if err != nil {
+			return smtpConfig, fmt.Errorf("failed to get OAuth token: %w", err)
+		}
+		smtpConfig.AuthType = smtp.AuthTypeOAuth2
+		smtpConfig.AccessToken = tokens.AccessToken
+	} else {
+		// Default to password authentication
+		password, err := s.credStore.GetPassword(acc.ID)
+		if err != nil {
+			return smtpConfig, fmt.Errorf("failed to get password: %w", err)
+		}
+		smtpConfig.AuthType = smtp.AuthTypePassword
+		smtpConfig.Password = password
+	}
+
+	return smtpConfig, nil
+}
+
+// SendSMTP connects to the account's SMTP server and sends raw to
+// recipients, requesting delivery status notifications if dsn is set.
+// Nothing is saved to Sent.
+func (s *Service) SendSMTP(acc *account.Account, from string, recipients []string, raw []byte, dsn *smtp.DSNOptions) error {
+	smtpConfig, err := s.SMTPConfig(acc)
+	if err != nil {
+		return err
+	}
+
+	client := smtp.NewClient(smtpConfig)
+
+	if err := client.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	defer client.Close()
+
+	if err := client.Login(); err != nil {
+		return fmt.Errorf("failed to login to SMTP server: %w", err)
+	}
+
+	if err := client.SendMailDSN(from, recipients, raw, dsn); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	return nil
+}
+
+// providerAutoSavesSentMail checks if a mail provider automatically saves sent messages
+func providerAutoSavesSentMail(host string) bool {
+	host = strings.ToLower(host)
+	autoSaveProviders := []string{
+		"imap.gmail.com",        // Gmail
+		"outlook.office365.com", // Microsoft 365
+		"imap-mail.outlook.com", // Outlook.com
+	}
+	for _, provider := range autoSaveProviders {
+		if strings.Contains(host, provider) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package send
+
+import (
+	"fmt"
+	"time"
+
+	goImap "github.com/emersion/go-imap/v2"
+	"github.com/hkdb/aerion/internal/account"
+	"github.com/hkdb/aerion/internal/auth"
+	"github.com/hkdb/aerion/internal/certificate"
+	"github.com/hkdb/aerion/internal/folder"
+	"github.com/hkdb/aerion/internal/imap"
+	"github.com/hkdb/aerion/internal/pendingop"
+)
+
+// File saves a sent message to the account's Sent folder: via IMAP APPEND,
+// or in the local Sent folder of accounts that keep their folders on disk.
+// If IMAP can't be reached the copy is queued for replay.
+func (s *Service) File(acc *account.Account, raw []byte) error {
+	if acc.HasLocalFolders() {
+		if s.fileLocal == nil {
+			return fmt.Errorf("local Sent folder is not available")
+		}
+		return s.fileLocal(acc.ID, raw)
+	}
+
+	sentFolder, err := s.sentFolder(acc)
+	if err != nil || sentFolder == nil {
+		// Fall back to account's configured sent folder path
+		if acc.SentFolderPath == "" {
+			return fmt.Errorf("no Sent folder configured or detected")
+		}
+	}
+
+	sentPath := acc.SentFolderPath
+	if sentPath == "" && sentFolder != nil {
+		sentPath = sentFolder.Path
+	}
+
+	s.log.Debug().
+		Str("account_id", acc.ID).
+		Str("sent_path", sentPath).
+		Msg("Saving sent message to folder via IMAP APPEND")
+
+	clientConfig := imap.DefaultConfig()
+	clientConfig.Host = acc.IMAPHost
+	clientConfig.Port = acc.IMAPPort
+	clientConfig.Security = imap.SecurityType(acc.IMAPSecurity)
+	clientConfig.Username = acc.Username
+	clientConfig.AuthMechanism = auth.Mechanism(acc.AuthMechanism)
+	tlsConfig, err := certificate.BuildClientTLSConfig(acc.IMAPHost, s.certStore, s.credStore, acc.ClientCertID)
+	if err != nil {
+		return err
+	}
+	clientConfig.TLSConfig = tlsConfig
+
+	// Handle authentication based on auth type
+	if acc.AuthType == account.AuthOAuth2 {
+		tokens, err := s.getToken(acc.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get OAuth token: %w", err)
+		}
+		clientConfig.AuthType = imap.AuthTypeOAuth2
+		clientConfig.AccessToken = tokens.AccessToken
+	} else {
+		password, err := s.credStore.GetPassword(acc.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get password: %w", err)
+		}
+		clientConfig.AuthType = imap.AuthTypePassword
+		clientConfig.Password = password
+	}
+
+	// Append message with \Seen flag
+	flags := []goImap.Flag{goImap.FlagSeen}
+	err = func() error {
+		imapClient := imap.NewClient(clientConfig)
+		if err := imapClient.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to IMAP: %w", err)
+		}
+		defer imapClient.Close()
+
+		if err := imapClient.Login(); err != nil {
+			return fmt.Errorf("failed to login to IMAP: %w", err)
+		}
+
+		if _, err := imapClient.AppendMessage(sentPath, flags, time.Now(), raw); err != nil {
+			return fmt.Errorf("failed to append to Sent folder: %w", err)
+		}
+		return nil
+	}()
+	if imap.IsConnectionError(err) && s.queueAppend != nil {
+		// IMAP unreachable even though SMTP worked; upload the copy later
+		s.log.Info().Err(err).
+			Str("account_id", acc.ID).
+			Str("sent_path", sentPath).
+			Msg("Queueing Sent folder copy for replay")
+		op := &pendingop.Operation{
+			AccountID:  acc.ID,
+			Type:       pendingop.TypeAppend,
+			FolderPath: sentPath,
+			Flags:      []string{string(goImap.FlagSeen)},
+			Data:       raw,
+		}
+		if sentFolder != nil {
+			op.FolderID = sentFolder.ID
+		}
+		return s.queueAppend(op)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.log.Info().
+		Str("account_id", acc.ID).
+		Str("sent_path", sentPath).
+		Msg("Message saved to Sent folder")
+
+	return nil
+}
+
+// sentFolder returns the account's Sent folder, preferring a manual mapping
+// over the auto-detected one
+func (s *Service) sentFolder(acc *account.Account) (*folder.Folder, error) {
+	if mappedPath := acc.GetFolderMapping(string(folder.TypeSent)); mappedPath != "" {
+		f, err := s.folderStore.GetByPath(acc.ID, mappedPath)
+		if err != nil {
+			return nil, err
+		}
+		if f != nil {
+			return f, nil
+		}
+	}
+	return s.folderStore.GetByType(acc.ID, folder.TypeSent)
+}
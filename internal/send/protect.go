@@ -0,0 +1,160 @@
+package send
+
+import (
+	"fmt"
+
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/hkdb/aerion/internal/pgp"
+	"github.com/hkdb/aerion/internal/smime"
+	"github.com/hkdb/aerion/internal/smtp"
+)
+
+// Job is a message moving through the protect stage
+type Job struct {
+	AccountID string
+	Message   *smtp.ComposeMessage
+	Raw       []byte
+	Encrypted bool
+	MessageID string // Message-ID of the built message, without angle brackets
+}
+
+// Protector signs and/or encrypts a built message in place
+type Protector interface {
+	Protect(job *Job) error
+}
+
+// SMIMEProtector signs then encrypts with S/MIME, per RFC 5751, as the
+// message or the account policy asks
+type SMIMEProtector struct {
+	store     *smime.Store
+	signer    *smime.Signer
+	encryptor *smime.Encryptor
+}
+
+// NewSMIMEProtector creates an S/MIME protector
+func NewSMIMEProtector(store *smime.Store, signer *smime.Signer, encryptor *smime.Encryptor) *SMIMEProtector {
+	return &SMIMEProtector{store: store, signer: signer, encryptor: encryptor}
+}
+
+// Protect applies S/MIME signing and encryption
+func (p *SMIMEProtector) Protect(job *Job) error {
+	log := logging.WithComponent("send")
+	msg := job.Message
+
+	if p.ShouldSign(job.AccountID, msg.SignMessage) {
+		signed, err := p.signer.SignMessage(job.AccountID, msg.From.Address, job.Raw)
+		if err != nil {
+			return fmt.Errorf("failed to sign message: %w", err)
+		}
+		job.Raw = signed
+		log.Info().Str("accountID", job.AccountID).Msg("Message signed with S/MIME")
+	}
+
+	if p.ShouldEncrypt(job.AccountID, msg.EncryptMessage) {
+		encrypted, err := p.encryptor.EncryptMessage(job.AccountID, msg.From.Address, msg.AllRecipients(), job.Raw)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt message: %w", err)
+		}
+		job.Raw = encrypted
+		job.Encrypted = true
+		log.Info().Str("accountID", job.AccountID).Msg("Message encrypted with S/MIME")
+	}
+
+	return nil
+}
+
+// HasCertificate returns whether an account has a valid default S/MIME certificate
+func (p *SMIMEProtector) HasCertificate(accountID string) bool {
+	cert, _, err := p.store.GetDefaultCertificate(accountID)
+	return err == nil && cert != nil && !cert.IsExpired
+}
+
+// ShouldSign determines whether a message should be S/MIME signed
+func (p *SMIMEProtector) ShouldSign(accountID string, perMessageOverride bool) bool {
+	if !perMessageOverride {
+		policy, err := p.store.GetSignPolicy(accountID)
+		if err != nil || policy != "always" {
+			return false
+		}
+	}
+	return p.HasCertificate(accountID)
+}
+
+// ShouldEncrypt determines whether a message should be S/MIME encrypted
+func (p *SMIMEProtector) ShouldEncrypt(accountID string, perMessageOverride bool) bool {
+	if !perMessageOverride {
+		policy, err := p.store.GetEncryptPolicy(accountID)
+		if err != nil || policy != "always" {
+			return false
+		}
+	}
+	return p.HasCertificate(accountID)
+}
+
+// PGPProtector signs then encrypts with OpenPGP. It's mutually exclusive
+// with S/MIME: each step is skipped when S/MIME was asked to do it.
+type PGPProtector struct {
+	store     *pgp.Store
+	signer    *pgp.Signer
+	encryptor *pgp.Encryptor
+}
+
+// NewPGPProtector creates a PGP protector
+func NewPGPProtector(store *pgp.Store, signer *pgp.Signer, encryptor *pgp.Encryptor) *PGPProtector {
+	return &PGPProtector{store: store, signer: signer, encryptor: encryptor}
+}
+
+// Protect applies PGP signing and encryption
+func (p *PGPProtector) Protect(job *Job) error {
+	log := logging.WithComponent("send")
+	msg := job.Message
+
+	if !msg.SignMessage && p.ShouldSign(job.AccountID, msg.PGPSignMessage) {
+		signed, err := p.signer.SignMessage(job.AccountID, msg.From.Address, job.Raw)
+		if err != nil {
+			return fmt.Errorf("failed to PGP sign message: %w", err)
+		}
+		job.Raw = signed
+		log.Info().Str("accountID", job.AccountID).Msg("Message signed with PGP")
+	}
+
+	if !msg.EncryptMessage && p.ShouldEncrypt(job.AccountID, msg.PGPEncryptMessage) {
+		encrypted, err := p.encryptor.EncryptMessage(job.AccountID, msg.From.Address, msg.AllRecipients(), job.Raw)
+		if err != nil {
+			return fmt.Errorf("failed to PGP encrypt message: %w", err)
+		}
+		job.Raw = encrypted
+		job.Encrypted = true
+		log.Info().Str("accountID", job.AccountID).Msg("Message encrypted with PGP")
+	}
+
+	return nil
+}
+
+// HasKey returns whether an account has a valid default PGP key
+func (p *PGPProtector) HasKey(accountID string) bool {
+	key, _, err := p.store.GetDefaultKey(accountID)
+	return err == nil && key != nil && !key.IsExpired
+}
+
+// ShouldSign determines whether a message should be PGP signed
+func (p *PGPProtector) ShouldSign(accountID string, perMessageOverride bool) bool {
+	if !perMessageOverride {
+		policy, err := p.store.GetSignPolicy(accountID)
+		if err != nil || policy != "always" {
+			return false
+		}
+	}
+	return p.HasKey(accountID)
+}
+
+// ShouldEncrypt determines whether a message should be PGP encrypted
+func (p *PGPProtector) ShouldEncrypt(accountID string, perMessageOverride bool) bool {
+	if !perMessageOverride {
+		policy, err := p.store.GetEncryptPolicy(accountID)
+		if err != nil || policy != "always" {
+			return false
+		}
+	}
+	return p.HasKey(accountID)
+}
@@ -0,0 +1,308 @@
+// Package send implements the outgoing mail pipeline shared by the main
+// window and detached composer windows.
+//
+// A message passes through five stages:
+//
+//	build → protect → deliver → file → post-process
+//
+// Build turns a smtp.ComposeMessage into RFC 822, protectors sign and
+// encrypt it, deliver hands it to SMTP or JMAP, file saves the Sent copy for
+// providers that don't do it themselves, and post-processors run once the
+// message is gone (syncing the Sent folder, notifying other windows).
+package send
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/hkdb/aerion/internal/account"
+	"github.com/hkdb/aerion/internal/certificate"
+	"github.com/hkdb/aerion/internal/contact"
+	"github.com/hkdb/aerion/internal/credentials"
+	"github.com/hkdb/aerion/internal/folder"
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/hkdb/aerion/internal/outbox"
+	"github.com/hkdb/aerion/internal/pendingop"
+	"github.com/hkdb/aerion/internal/smtp"
+	"github.com/rs/zerolog"
+)
+
+// TokenFunc returns a valid OAuth token for an account, refreshing it if needed
+type TokenFunc func(accountID string) (*credentials.OAuthTokens, error)
+
+// JMAPSubmitFunc submits a built message for a JMAP account. The server
+// files it in Sent.
+type JMAPSubmitFunc func(acc *account.Account, from string, recipients []string, raw []byte) error
+
+// LocalFileFunc stores a sent message in the local Sent folder of an account
+// that keeps its folders on disk
+type LocalFileFunc func(accountID string, raw []byte) error
+
+// PostProcessFunc runs after a message was delivered
+type PostProcessFunc func(acc *account.Account, m *outbox.Message)
+
+// Service runs messages through the send pipeline
+type Service struct {
+	accountStore *account.Store
+	folderStore  *folder.Store
+	contactStore *contact.Store
+	credStore    *credentials.Store
+	certStore    *certificate.Store
+	getToken     TokenFunc
+	log          zerolog.Logger
+
+	// Pipeline stages, run in order
+	protectors     []Protector
+	transport      Transport
+	postProcessors []PostProcessFunc
+
+	// Optional hooks
+	submitJMAP  JMAPSubmitFunc                      // required for JMAP accounts
+	fileLocal   LocalFileFunc                       // required for accounts with local folders
+	queueAppend func(op *pendingop.Operation) error // queues the Sent copy while IMAP is unreachable
+}
+
+// NewService creates a send service. Protectors, post-processors and the
+// JMAP, local folder and offline hooks are added with the setters.
+func NewService(accountStore *account.Store, folderStore *folder.Store, contactStore *contact.Store, credStore *credentials.Store, certStore *certificate.Store, getToken TokenFunc) *Service {
+	s := &Service{
+		accountStore: accountStore,
+		folderStore:  folderStore,
+		contactStore: contactStore,
+		credStore:    credStore,
+		certStore:    certStore,
+		getToken:     getToken,
+		log:          logging.WithComponent("send"),
+	}
+	s.transport = serverTransport{s: s}
+	return s
+}
+
+// AddProtector appends a protector to the protect stage. Protectors run in
+// the order they were added.
+func (s *Service) AddProtector(p Protector) {
+	s.protectors = append(s.protectors, p)
+}
+
+// AddPostProcessor appends a function run after every successful delivery
+func (s *Service) AddPostProcessor(fn PostProcessFunc) {
+	s.postProcessors = append(s.postProcessors, fn)
+}
+
+// SetTransport replaces how messages are delivered and filed. By default
+// they go to the account's SMTP, JMAP and IMAP servers.
+func (s *Service) SetTransport(t Transport) {
+	s.transport = t
+}
+
+// SetJMAPSubmitter sets how messages of JMAP accounts are submitted
+func (s *Service) SetJMAPSubmitter(fn JMAPSubmitFunc) {
+	s.submitJMAP = fn
+}
+
+// SetLocalFiler sets how the Sent copy is stored for accounts with local folders
+func (s *Service) SetLocalFiler(fn LocalFileFunc) {
+	s.fileLocal = fn
+}
+
+// SetAppendQueue sets where the Sent copy is queued when IMAP can't be
+// reached after SMTP succeeded
+func (s *Service) SetAppendQueue(fn func(op *pendingop.Operation) error) {
+	s.queueAppend = fn
+}
+
+// Build runs the build and protect stages, returning the final message and
+// whether it was encrypted
+func (s *Service) Build(accountID string, msg smtp.ComposeMessage) ([]byte, bool, error) {
+	job, err := s.build(accountID, msg)
+	if err != nil {
+		return nil, false, err
+	}
+	return job.Raw, job.Encrypted, nil
+}
+
+// build runs the build and protect stages
+func (s *Service) build(accountID string, msg smtp.ComposeMessage) (*Job, error) {
+	raw, err := msg.ToRFC822()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build message: %w", err)
+	}
+
+	job := &Job{
+		AccountID: accountID,
+		Message:   &msg,
+		Raw:       raw,
+		MessageID: headerMessageID(raw),
+	}
+	for _, p := range s.protectors {
+		if err := p.Protect(job); err != nil {
+			return nil, err
+		}
+	}
+	return job, nil
+}
+
+// Prepare builds and protects a composed message and returns the outbox
+// entry for it, due at sendAt. The entry isn't stored.
+func (s *Service) Prepare(accountID string, msg smtp.ComposeMessage, sendAt time.Time, scheduled bool) (*outbox.Message, error) {
+	recipients := msg.AllRecipients()
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("no recipients specified")
+	}
+
+	job, err := s.build(accountID, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &outbox.Message{
+		AccountID:   accountID,
+		FromAddress: msg.From.Address,
+		Recipients:  recipients,
+		Subject:     msg.Subject,
+		RawMessage:  job.Raw,
+		SendAt:      sendAt,
+		Scheduled:   scheduled,
+	}
+
+	// Notifications carry the Message-ID as the envelope ID so they can be
+	// matched to the sent message
+	if msg.DSN != nil {
+		if err := msg.DSN.Validate(); err != nil {
+			return nil, err
+		}
+		dsn := *msg.DSN
+		if dsn.EnvelopeID == "" {
+			dsn.EnvelopeID = job.MessageID
+		}
+		m.DSN = &dsn
+	}
+
+	// Keep what's needed to reopen the message in the composer, except for
+	// encrypted messages whose plaintext must not be stored. Those can't be
+	// undone, so they skip the undo-send window.
+	if job.Encrypted {
+		if !scheduled {
+			m.SendAt = time.Now()
+		}
+	} else {
+		composeData, err := json.Marshal(msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize message: %w", err)
+		}
+		m.ComposeData = composeData
+	}
+
+	return m, nil
+}
+
+// PrepareRedirect builds an outbox message that redirects raw, a received
+// message, to new recipients. The original goes out unchanged apart from
+// the Resent-* headers, so it isn't signed or encrypted again, and it can't
+// be reopened in the composer.
+func (s *Service) PrepareRedirect(accountID string, raw []byte, subject string, from smtp.Address, to []smtp.Address) (*outbox.Message, error) {
+	if len(to) == 0 {
+		return nil, fmt.Errorf("no recipients specified")
+	}
+	recipients := make([]string, 0, len(to))
+	for _, addr := range to {
+		recipients = append(recipients, addr.Address)
+	}
+
+	return &outbox.Message{
+		AccountID:   accountID,
+		FromAddress: from.Address,
+		Recipients:  recipients,
+		Subject:     subject,
+		RawMessage:  smtp.Resent(raw, from, to, time.Now()),
+		SendAt:      time.Now(),
+	}, nil
+}
+
+// Deliver runs the deliver, file and post-process stages for an outbox
+// message. It matches outbox.DeliverFunc.
+func (s *Service) Deliver(m *outbox.Message) error {
+	acc, err := s.accountStore.Get(m.AccountID)
+	if err != nil {
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+	if acc == nil {
+		return fmt.Errorf("account not found: %s", m.AccountID)
+	}
+
+	if err := s.deliver(acc, m); err != nil {
+		return err
+	}
+
+	for _, fn := range s.postProcessors {
+		fn(acc, m)
+	}
+	return nil
+}
+
+// SendNow runs a composed message through every stage but post-processing
+// right away, bypassing the outbox. It's for bulk senders such as mail
+// merge, which rate-limit themselves and sync Sent once when done.
+func (s *Service) SendNow(accountID string, msg smtp.ComposeMessage) error {
+	acc, err := s.accountStore.Get(accountID)
+	if err != nil {
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+	if acc == nil {
+		return fmt.Errorf("account not found: %s", accountID)
+	}
+
+	m, err := s.Prepare(accountID, msg, time.Now(), false)
+	if err != nil {
+		return err
+	}
+	return s.deliver(acc, m)
+}
+
+// deliver runs the deliver and file stages
+func (s *Service) deliver(acc *account.Account, m *outbox.Message) error {
+	if err := s.transport.Deliver(acc, m); err != nil {
+		return err
+	}
+
+	// JMAP servers file the message in Sent as part of the submission
+	if acc.IsJMAP() {
+		return nil
+	}
+
+	// Don't fail the send if only the Sent copy couldn't be saved
+	if !providerAutoSavesSentMail(acc.IMAPHost) {
+		s.log.Debug().Str("host", acc.IMAPHost).Msg("Provider doesn't auto-save, filing Sent copy")
+		if err := s.transport.File(acc, m.RawMessage); err != nil {
+			s.log.Warn().Err(err).Msg("Failed to save message to Sent folder")
+		}
+	} else {
+		s.log.Debug().Str("host", acc.IMAPHost).Msg("Provider auto-saves sent mail, skipping manual save")
+	}
+	return nil
+}
+
+// headerMessageID returns the Message-ID of a raw message without angle
+// brackets, or "" if it has none
+func headerMessageID(raw []byte) string {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return ""
+	}
+	return strings.Trim(strings.TrimSpace(msg.Header.Get("Message-ID")), "<>")
+}
+
+// HarvestContacts adds the To and Cc recipients of a sent message to the
+// local contacts
+func (s *Service) HarvestContacts(msg smtp.ComposeMessage) {
+	for _, to := range msg.To {
+		s.contactStore.AddOrUpdate(to.Address, to.Name)
+	}
+	for _, cc := range msg.Cc {
+		s.contactStore.AddOrUpdate(cc.Address, cc.Name)
+	}
+}
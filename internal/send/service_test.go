@@ -0,0 +1,317 @@
+package send
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hkdb/aerion/internal/account"
+	"github.com/hkdb/aerion/internal/credentials"
+	"github.com/hkdb/aerion/internal/database"
+	"github.com/hkdb/aerion/internal/outbox"
+	"github.com/hkdb/aerion/internal/smtp"
+	gokeyring "github.com/zalando/go-keyring"
+)
+
+const (
+	testUsername = "alice@example.com"
+	testPassword = "secret"
+)
+
+// smtpServer is a minimal in-process SMTP server that advertises DSN,
+// accepts PLAIN logins for the test account and records the commands it
+// receives
+type smtpServer struct {
+	ln   net.Listener
+	mu   sync.Mutex
+	cmds []string
+	data []string
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := &smtpServer{ln: ln}
+	go srv.serve()
+	t.Cleanup(func() { ln.Close() })
+	return srv
+}
+
+func (srv *smtpServer) port() int {
+	return srv.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (srv *smtpServer) serve() {
+	for {
+		conn, err := srv.ln.Accept()
+		if err != nil {
+			return
+		}
+		go srv.handle(conn)
+	}
+}
+
+func (srv *smtpServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		srv.mu.Lock()
+		srv.cmds = append(srv.cmds, line)
+		srv.mu.Unlock()
+
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch verb {
+		case "EHLO":
+			reply("250-localhost")
+			reply("250-8BITMIME")
+			reply("250-AUTH PLAIN")
+			reply("250 DSN")
+		case "AUTH":
+			fields := strings.Fields(line)
+			want := "\x00" + testUsername + "\x00" + testPassword
+			if len(fields) == 3 && strings.EqualFold(fields[1], "PLAIN") {
+				if ir, err := base64.StdEncoding.DecodeString(fields[2]); err == nil && string(ir) == want {
+					reply("235 2.7.0 Authentication successful")
+					continue
+				}
+			}
+			reply("535 5.7.8 Authentication credentials invalid")
+		case "DATA":
+			reply("354 Go ahead")
+			var body []string
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				body = append(body, l)
+			}
+			srv.mu.Lock()
+			srv.data = append(srv.data, strings.Join(body, ""))
+			srv.mu.Unlock()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+// commands returns the received commands starting with prefix
+func (srv *smtpServer) commands(prefix string) []string {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	var out []string
+	for _, c := range srv.cmds {
+		if strings.HasPrefix(c, prefix) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// filingTransport delivers through the server transport and records the
+// Sent copies instead of appending them over IMAP
+type filingTransport struct {
+	serverTransport
+	filed [][]byte
+}
+
+func (t *filingTransport) File(acc *account.Account, raw []byte) error {
+	t.filed = append(t.filed, raw)
+	return nil
+}
+
+// orderProtector records when it ran and tags the message
+type orderProtector struct {
+	name  string
+	order *[]string
+}
+
+func (p orderProtector) Protect(job *Job) error {
+	*p.order = append(*p.order, p.name)
+	job.Raw = append([]byte("X-Protected-By: "+p.name+"\r\n"), job.Raw...)
+	return nil
+}
+
+// newTestService returns a service whose account sends through the SMTP
+// server on port, with its password in a credential store under the test's
+// temp dir, and the transport that records what it files
+func newTestService(t *testing.T, port int) (*Service, *account.Account, *filingTransport) {
+	t.Helper()
+	// Keep the password out of the OS keyring; the store falls back to
+	// the encrypted database
+	gokeyring.MockInitWithError(errors.New("keyring disabled in tests"))
+
+	dir := t.TempDir()
+	db, err := database.Open(filepath.Join(dir, "aerion.db"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	credStore, err := credentials.NewStore(db.DB, dir)
+	if err != nil {
+		t.Fatalf("credential store: %v", err)
+	}
+
+	accounts := account.NewStore(db)
+	acc, err := accounts.Create(&account.AccountConfig{
+		Name:         "Test",
+		DisplayName:  "Alice",
+		Email:        testUsername,
+		IMAPHost:     "imap.example.com",
+		IMAPPort:     993,
+		IMAPSecurity: account.SecurityTLS,
+		SMTPHost:     "127.0.0.1",
+		SMTPPort:     port,
+		SMTPSecurity: account.SecurityNone,
+		AuthType:     account.AuthPassword,
+		Username:     testUsername,
+	})
+	if err != nil {
+		t.Fatalf("create account: %v", err)
+	}
+	if err := credStore.SetPassword(acc.ID, testPassword); err != nil {
+		t.Fatalf("store password: %v", err)
+	}
+
+	svc := NewService(accounts, nil, nil, credStore, nil, nil)
+	transport := &filingTransport{serverTransport: serverTransport{s: svc}}
+	svc.SetTransport(transport)
+	return svc, acc, transport
+}
+
+func TestPrepareAndDeliver(t *testing.T) {
+	srv := newSMTPServer(t)
+	svc, acc, transport := newTestService(t, srv.port())
+
+	var order []string
+	svc.AddProtector(orderProtector{name: "first", order: &order})
+	svc.AddProtector(orderProtector{name: "second", order: &order})
+
+	var processed []*outbox.Message
+	svc.AddPostProcessor(func(a *account.Account, m *outbox.Message) {
+		if a.ID != acc.ID {
+			t.Errorf("post-processor got account %s, want %s", a.ID, acc.ID)
+		}
+		processed = append(processed, m)
+	})
+
+	msg := smtp.ComposeMessage{
+		From:     smtp.Address{Name: "Alice", Address: "alice@example.com"},
+		To:       []smtp.Address{{Address: "bob@example.org"}},
+		Subject:  "Pipeline",
+		TextBody: "Hello",
+		DSN: &smtp.DSNOptions{
+			Notify: []smtp.DSNNotify{smtp.DSNNotifySuccess, smtp.DSNNotifyFailure},
+			Return: smtp.DSNReturnHeaders,
+		},
+	}
+
+	m, err := svc.Prepare(acc.ID, msg, time.Now(), false)
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	if got := strings.Join(order, ","); got != "first,second" {
+		t.Errorf("protectors ran as %q, want \"first,second\"", got)
+	}
+	if m.DSN == nil || m.DSN.EnvelopeID == "" {
+		t.Fatalf("Prepare didn't set the envelope ID from the Message-ID")
+	}
+
+	if err := svc.Deliver(m); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+
+	if len(srv.commands("AUTH PLAIN")) != 1 {
+		t.Errorf("didn't log in with the stored password")
+	}
+
+	mail := srv.commands("MAIL FROM:")
+	if len(mail) != 1 {
+		t.Fatalf("got %d MAIL commands, want 1", len(mail))
+	}
+	if !strings.HasPrefix(mail[0], "MAIL FROM:<alice@example.com>") ||
+		!strings.Contains(mail[0], " RET=HDRS") ||
+		!strings.Contains(mail[0], " ENVID="+smtp.EncodeXText(m.DSN.EnvelopeID)) {
+		t.Errorf("MAIL command %q lacks the DSN parameters", mail[0])
+	}
+
+	rcpt := srv.commands("RCPT TO:")
+	if len(rcpt) != 1 {
+		t.Fatalf("got %d RCPT commands, want 1", len(rcpt))
+	}
+	if want := "RCPT TO:<bob@example.org> NOTIFY=SUCCESS,FAILURE ORCPT=rfc822;bob@example.org"; rcpt[0] != want {
+		t.Errorf("RCPT command is %q, want %q", rcpt[0], want)
+	}
+
+	srv.mu.Lock()
+	data := srv.data
+	srv.mu.Unlock()
+	if len(data) != 1 || !strings.HasPrefix(data[0], "X-Protected-By: second\r\nX-Protected-By: first\r\n") {
+		t.Errorf("delivered message wasn't protected in order")
+	}
+
+	if len(transport.filed) != 1 {
+		t.Errorf("Sent copy filed %d times, want 1", len(transport.filed))
+	}
+	if len(processed) != 1 || processed[0] != m {
+		t.Errorf("post-processor ran %d times, want once with the delivered message", len(processed))
+	}
+}
+
+func TestDeliverFailureSkipsPostProcess(t *testing.T) {
+	// Nothing listens on the closed port
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	svc, acc, transport := newTestService(t, port)
+	called := false
+	svc.AddPostProcessor(func(*account.Account, *outbox.Message) { called = true })
+
+	m, err := svc.Prepare(acc.ID, smtp.ComposeMessage{
+		From:     smtp.Address{Address: "alice@example.com"},
+		To:       []smtp.Address{{Address: "bob@example.org"}},
+		TextBody: "Hello",
+	}, time.Now(), false)
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+
+	if err := svc.Deliver(m); err == nil {
+		t.Fatalf("Deliver succeeded without a server")
+	}
+	if called || len(transport.filed) != 0 {
+		t.Errorf("failed delivery was filed or post-processed")
+	}
+}
@@ -24,6 +24,7 @@ const (
 	KeyStartHidden               = "start_hidden"
 	KeyAutostart                 = "autostart"
 	KeyLanguage                  = "language"
+	KeyUndoSendDelay             = "undo_send_delay"
 )
 
 // Density values for message list
@@ -69,6 +70,12 @@ const (
 // Default mark as read delay in milliseconds (1 second)
 const DefaultMarkAsReadDelay = 1000
 
+// Undo send delay bounds in seconds (0 = send immediately)
+const (
+	DefaultUndoSendDelay = 0
+	MaxUndoSendDelay     = 30
+)
+
 // Store provides settings persistence operations
 type Store struct {
 	db  *database.DB
@@ -328,3 +335,28 @@ func (s *Store) GetLanguage() (string, error) {
 func (s *Store) SetLanguage(language string) error {
 	return s.Set(KeyLanguage, language)
 }
+
+// GetUndoSendDelay returns how long sent messages wait in the outbox before
+// delivery, in seconds (0 = send immediately)
+func (s *Store) GetUndoSendDelay() (int, error) {
+	value, err := s.Get(KeyUndoSendDelay)
+	if err != nil {
+		return DefaultUndoSendDelay, err
+	}
+	if value == "" {
+		return DefaultUndoSendDelay, nil
+	}
+	delay, err := strconv.Atoi(value)
+	if err != nil {
+		return DefaultUndoSendDelay, nil
+	}
+	return delay, nil
+}
+
+// SetUndoSendDelay sets the undo send delay in seconds (0-30)
+func (s *Store) SetUndoSendDelay(seconds int) error {
+	if seconds < 0 || seconds > MaxUndoSendDelay {
+		return fmt.Errorf("invalid undo send delay: %d (must be 0-%d seconds)", seconds, MaxUndoSendDelay)
+	}
+	return s.Set(KeyUndoSendDelay, strconv.Itoa(seconds))
+}
@@ -0,0 +1,492 @@
+// Package sieve provides a ManageSieve (RFC 5804) client for editing
+// server-side filter scripts, and a structured model for the simple scripts
+// Aerion generates
+package sieve
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-sasl"
+	"github.com/hkdb/aerion/internal/imap"
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/rs/zerolog"
+)
+
+// DefaultPort is the IANA-assigned ManageSieve port
+const DefaultPort = 4190
+
+// SecurityType represents the connection security method
+type SecurityType string
+
+const (
+	SecurityNone     SecurityType = "none"
+	SecurityTLS      SecurityType = "tls"
+	SecurityStartTLS SecurityType = "starttls"
+)
+
+// AuthType represents the authentication method
+type AuthType string
+
+const (
+	AuthTypePassword AuthType = "password"
+	AuthTypeOAuth2   AuthType = "oauth2"
+)
+
+// ClientConfig holds the configuration for connecting to a ManageSieve server
+type ClientConfig struct {
+	Host     string
+	Port     int
+	Security SecurityType
+	Username string
+	Password string
+
+	// OAuth2 authentication
+	AuthType    AuthType // "password" or "oauth2" (defaults to "password")
+	AccessToken string   // OAuth2 access token (when AuthType is "oauth2")
+
+	// Timeouts
+	ConnectTimeout time.Duration
+	ReadTimeout    time.Duration
+	WriteTimeout   time.Duration
+
+	// TLS config (optional)
+	TLSConfig *tls.Config
+}
+
+// DefaultConfig returns a ClientConfig with sensible defaults
+func DefaultConfig() ClientConfig {
+	return ClientConfig{
+		Port:           DefaultPort,
+		Security:       SecurityStartTLS,
+		ConnectTimeout: 30 * time.Second,
+		ReadTimeout:    30 * time.Second,
+		WriteTimeout:   30 * time.Second,
+	}
+}
+
+// Capabilities are what the server advertised after connecting
+type Capabilities struct {
+	Implementation string   `json:"implementation"`
+	SASL           []string `json:"sasl"`
+	Extensions     []string `json:"extensions"` // Sieve extensions, e.g. "fileinto"
+	StartTLS       bool     `json:"startTls"`
+	Version        string   `json:"version"`
+	MaxRedirects   int      `json:"maxRedirects,omitempty"`
+}
+
+// HasExtension reports whether the server supports a Sieve extension
+func (c Capabilities) HasExtension(name string) bool {
+	for _, ext := range c.Extensions {
+		if strings.EqualFold(ext, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasSASL reports whether the server offers a SASL mechanism
+func (c Capabilities) hasSASL(mech string) bool {
+	for _, m := range c.SASL {
+		if strings.EqualFold(m, mech) {
+			return true
+		}
+	}
+	return false
+}
+
+// ScriptInfo is an entry from LISTSCRIPTS
+type ScriptInfo struct {
+	Name   string `json:"name"`
+	Active bool   `json:"active"`
+}
+
+// Client is a ManageSieve client
+type Client struct {
+	config ClientConfig
+	conn   net.Conn
+	r      *bufio.Reader
+	caps   Capabilities
+	log    zerolog.Logger
+}
+
+// NewClient creates a new ManageSieve client but does not connect
+func NewClient(config ClientConfig) *Client {
+	return &Client{
+		config: config,
+		log:    logging.WithComponent("sieve"),
+	}
+}
+
+// Connect establishes a connection to the server, reads its capabilities and
+// upgrades to TLS when configured
+func (c *Client) Connect() error {
+	addr := net.JoinHostPort(c.config.Host, strconv.Itoa(c.config.Port))
+
+	c.log.Debug().
+		Str("host", c.config.Host).
+		Int("port", c.config.Port).
+		Str("security", string(c.config.Security)).
+		Msg("Connecting to ManageSieve server")
+
+	tlsConfig := c.config.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{ServerName: c.config.Host}
+	}
+
+	dialer := &net.Dialer{Timeout: c.config.ConnectTimeout}
+
+	var err error
+	switch c.config.Security {
+	case SecurityTLS:
+		c.conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+		if err != nil {
+			return fmt.Errorf("failed to connect with TLS: %w", err)
+		}
+	default:
+		c.conn, err = dialer.Dial("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("failed to connect: %w", err)
+		}
+	}
+	c.r = bufio.NewReader(c.conn)
+
+	// The greeting is the capability list
+	if err := c.readCapabilities(); err != nil {
+		c.conn.Close()
+		return fmt.Errorf("failed to receive greeting: %w", err)
+	}
+
+	if c.config.Security == SecurityStartTLS {
+		if !c.caps.StartTLS {
+			c.conn.Close()
+			return fmt.Errorf("server does not support STARTTLS")
+		}
+		if _, err := c.command("STARTTLS"); err != nil {
+			c.conn.Close()
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+
+		tlsConn := tls.Client(c.conn, tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			c.conn.Close()
+			return fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		c.conn = tlsConn
+		c.r = bufio.NewReader(c.conn)
+
+		// The server re-sends capabilities after the upgrade
+		if err := c.readCapabilities(); err != nil {
+			c.conn.Close()
+			return fmt.Errorf("failed to read capabilities after STARTTLS: %w", err)
+		}
+	}
+
+	c.log.Debug().
+		Str("implementation", c.caps.Implementation).
+		Strs("sasl", c.caps.SASL).
+		Strs("extensions", c.caps.Extensions).
+		Msg("Server capabilities")
+
+	return nil
+}
+
+// Login authenticates with the server
+func (c *Client) Login() error {
+	if c.conn == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	var saslClient sasl.Client
+	switch c.config.AuthType {
+	case AuthTypeOAuth2:
+		if c.config.AccessToken == "" {
+			return fmt.Errorf("OAuth2 authentication requires an access token")
+		}
+		if c.caps.hasSASL(sasl.OAuthBearer) {
+			saslClient = sasl.NewOAuthBearerClient(&sasl.OAuthBearerOptions{
+				Username: c.config.Username,
+				Token:    c.config.AccessToken,
+				Host:     c.config.Host,
+				Port:     c.config.Port,
+			})
+		} else {
+			saslClient = imap.NewXOAuth2Client(c.config.Username, c.config.AccessToken)
+		}
+	default:
+		saslClient = sasl.NewPlainClient("", c.config.Username, c.config.Password)
+	}
+
+	if err := c.authenticate(saslClient); err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	// Capabilities may change once authenticated (e.g. OWNER)
+	if err := c.refreshCapabilities(); err != nil {
+		return err
+	}
+
+	c.log.Info().Str("username", c.config.Username).Msg("Logged in to ManageSieve server")
+	return nil
+}
+
+// Capabilities returns the server's advertised capabilities
+func (c *Client) Capabilities() Capabilities {
+	return c.caps
+}
+
+// ListScripts returns the scripts on the server and which one is active
+func (c *Client) ListScripts() ([]ScriptInfo, error) {
+	lines, err := c.command("LISTSCRIPTS")
+	if err != nil {
+		return nil, err
+	}
+
+	scripts := make([]ScriptInfo, 0, len(lines))
+	for _, line := range lines {
+		if len(line) == 0 || line[0].kind != tokenString {
+			continue
+		}
+		info := ScriptInfo{Name: line[0].value}
+		if len(line) > 1 && line[1].kind == tokenAtom && strings.EqualFold(line[1].value, "ACTIVE") {
+			info.Active = true
+		}
+		scripts = append(scripts, info)
+	}
+	return scripts, nil
+}
+
+// GetScript returns the content of a script
+func (c *Client) GetScript(name string) (string, error) {
+	lines, err := c.command("GETSCRIPT " + quoteString(name))
+	if err != nil {
+		return "", err
+	}
+	for _, line := range lines {
+		if len(line) > 0 && line[0].kind == tokenString {
+			return line[0].value, nil
+		}
+	}
+	return "", fmt.Errorf("server returned no script content")
+}
+
+// PutScript uploads a script, replacing any script with the same name. The
+// server validates the script and rejects it with a *ResponseError on
+// syntax errors.
+func (c *Client) PutScript(name, content string) error {
+	_, err := c.command("PUTSCRIPT " + quoteString(name) + " " + literal(content))
+	return err
+}
+
+// CheckScript validates a script without storing it. Returns a
+// *ResponseError describing the problem if the script is invalid.
+func (c *Client) CheckScript(content string) error {
+	if c.caps.Version == "" {
+		return fmt.Errorf("server does not support CHECKSCRIPT")
+	}
+	_, err := c.command("CHECKSCRIPT " + literal(content))
+	return err
+}
+
+// SetActive makes a script the active one. An empty name deactivates all
+// scripts.
+func (c *Client) SetActive(name string) error {
+	_, err := c.command("SETACTIVE " + quoteString(name))
+	return err
+}
+
+// DeleteScript removes a script. The active script can't be deleted.
+func (c *Client) DeleteScript(name string) error {
+	_, err := c.command("DELETESCRIPT " + quoteString(name))
+	return err
+}
+
+// Close logs out and closes the connection
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+
+	if _, err := c.command("LOGOUT"); err != nil {
+		c.log.Debug().Err(err).Msg("LOGOUT failed")
+	}
+
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// authenticate runs a SASL exchange with AUTHENTICATE
+func (c *Client) authenticate(saslClient sasl.Client) error {
+	mech, ir, err := saslClient.Start()
+	if err != nil {
+		return err
+	}
+
+	cmd := "AUTHENTICATE " + quoteString(mech)
+	if ir != nil {
+		cmd += " " + quoteString(base64.StdEncoding.EncodeToString(ir))
+	}
+	if err := c.send(cmd); err != nil {
+		return err
+	}
+
+	for {
+		tokens, err := c.readLine()
+		if err != nil {
+			return err
+		}
+
+		if resp, ok := parseResponse(tokens); ok {
+			return resp.err()
+		}
+
+		// Anything else is a challenge string
+		if len(tokens) == 0 || tokens[0].kind != tokenString {
+			return fmt.Errorf("unexpected response during authentication")
+		}
+		challenge, err := base64.StdEncoding.DecodeString(tokens[0].value)
+		if err != nil {
+			return fmt.Errorf("invalid authentication challenge: %w", err)
+		}
+
+		reply, err := saslClient.Next(challenge)
+		if err != nil {
+			// Abort the exchange so the connection stays usable
+			c.send(`"*"`)
+			c.readResponse()
+			return err
+		}
+		if err := c.send(quoteString(base64.StdEncoding.EncodeToString(reply))); err != nil {
+			return err
+		}
+	}
+}
+
+// refreshCapabilities re-requests the capability list
+func (c *Client) refreshCapabilities() error {
+	if err := c.send("CAPABILITY"); err != nil {
+		return err
+	}
+	return c.readCapabilities()
+}
+
+// readCapabilities parses a capability listing up to its OK line
+func (c *Client) readCapabilities() error {
+	lines, resp, err := c.readResponse()
+	if err != nil {
+		return err
+	}
+	if err := resp.err(); err != nil {
+		return err
+	}
+
+	caps := Capabilities{}
+	for _, line := range lines {
+		if len(line) == 0 || line[0].kind != tokenString {
+			continue
+		}
+		var value string
+		if len(line) > 1 {
+			value = line[1].value
+		}
+
+		switch strings.ToUpper(line[0].value) {
+		case "IMPLEMENTATION":
+			caps.Implementation = value
+		case "SASL":
+			caps.SASL = strings.Fields(value)
+		case "SIEVE":
+			caps.Extensions = strings.Fields(value)
+		case "STARTTLS":
+			caps.StartTLS = true
+		case "VERSION":
+			caps.Version = value
+		case "MAXREDIRECTS":
+			caps.MaxRedirects, _ = strconv.Atoi(value)
+		}
+	}
+	c.caps = caps
+	return nil
+}
+
+// command sends a command and returns the data lines of its response, or a
+// *ResponseError if the server answered NO or BYE
+func (c *Client) command(cmd string) ([][]token, error) {
+	if c.conn == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+	if err := c.send(cmd); err != nil {
+		return nil, err
+	}
+
+	lines, resp, err := c.readResponse()
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.err(); err != nil {
+		return nil, err
+	}
+
+	if resp.code == "WARNINGS" {
+		c.log.Warn().Str("message", resp.message).Msg("Server reported script warnings")
+	}
+	return lines, nil
+}
+
+// send writes a command line
+func (c *Client) send(cmd string) error {
+	if c.config.WriteTimeout > 0 {
+		c.conn.SetWriteDeadline(time.Now().Add(c.config.WriteTimeout))
+	}
+	if _, err := c.conn.Write([]byte(cmd + "\r\n")); err != nil {
+		return fmt.Errorf("failed to send command: %w", err)
+	}
+	return nil
+}
+
+// readLine reads one response line with the read timeout applied
+func (c *Client) readLine() ([]token, error) {
+	if c.config.ReadTimeout > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(c.config.ReadTimeout))
+	}
+	tokens, err := readLine(c.r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return tokens, nil
+}
+
+// readResponse reads data lines up to and including the OK/NO/BYE line
+func (c *Client) readResponse() ([][]token, *response, error) {
+	var lines [][]token
+	for {
+		tokens, err := c.readLine()
+		if err != nil {
+			return nil, nil, err
+		}
+		if resp, ok := parseResponse(tokens); ok {
+			return lines, resp, nil
+		}
+		lines = append(lines, tokens)
+	}
+}
+
+// err converts a NO/BYE response to a *ResponseError
+func (r *response) err() error {
+	if r.status == "OK" {
+		return nil
+	}
+	return &ResponseError{Status: r.status, Code: r.code, Message: r.message}
+}
+
+// literal encodes a script body as a non-synchronizing literal
+func literal(s string) string {
+	return fmt.Sprintf("{%d+}\r\n%s", len(s), s)
+}
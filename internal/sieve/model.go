@@ -0,0 +1,71 @@
+package sieve
+
+import (
+	"errors"
+)
+
+// ManagedScriptName is the script Aerion stores structured rules in
+const ManagedScriptName = "aerion"
+
+// ErrUnsupportedScript is returned by Parse for scripts that use Sieve
+// features outside the structured model. Such scripts can still be edited as
+// text.
+var ErrUnsupportedScript = errors.New("script uses features that can't be edited as rules")
+
+// MatchType is the comparison a rule applies
+type MatchType string
+
+const (
+	MatchContains MatchType = "contains"
+	MatchIs       MatchType = "is"
+	MatchMatches  MatchType = "matches" // Sieve wildcards: * and ?
+)
+
+// Script is the structured form of a simple filter script: an ordered list
+// of fileinto rules and an optional vacation auto-reply
+type Script struct {
+	Rules    []Rule    `json:"rules"`
+	Vacation *Vacation `json:"vacation,omitempty"`
+}
+
+// Rule files messages whose header matches a value into a folder
+type Rule struct {
+	Name string `json:"name,omitempty"`
+
+	// Header is the header to test, e.g. "from", "subject" or "list-id".
+	// From, To and Cc are compared by address.
+	Header string    `json:"header"`
+	Match  MatchType `json:"match"`
+	Value  string    `json:"value"`
+
+	// FileInto is the destination mailbox path
+	FileInto string `json:"fileInto"`
+
+	// Stop prevents later rules (and the vacation reply) from running
+	Stop bool `json:"stop"`
+}
+
+// Vacation is an auto-reply sent at most once per sender every Days days
+type Vacation struct {
+	Enabled bool   `json:"enabled"`
+	Subject string `json:"subject,omitempty"`
+	Body    string `json:"body"`
+	Days    int    `json:"days,omitempty"`
+
+	// Addresses are the user's other addresses; mail sent only to them still
+	// gets a reply
+	Addresses []string `json:"addresses,omitempty"`
+
+	// From overrides the reply's From address
+	From string `json:"from,omitempty"`
+}
+
+// addressHeaders are tested with the "address" test rather than "header"
+var addressHeaders = map[string]bool{
+	"from":     true,
+	"to":       true,
+	"cc":       true,
+	"bcc":      true,
+	"sender":   true,
+	"reply-to": true,
+}
@@ -0,0 +1,207 @@
+package sieve
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxLiteralSize bounds server literals (scripts are small; this guards
+// against a misbehaving server)
+const maxLiteralSize = 16 * 1024 * 1024
+
+// tokenKind identifies a token in a ManageSieve response line
+type tokenKind int
+
+const (
+	tokenAtom tokenKind = iota
+	tokenString
+	tokenOpenParen
+	tokenCloseParen
+)
+
+// token is a single element of a response line
+type token struct {
+	kind  tokenKind
+	value string
+}
+
+// response is the final OK/NO/BYE line that ends a command
+type response struct {
+	status  string // "OK", "NO" or "BYE"
+	code    string // Response code, e.g. "WARNINGS" or "QUOTA/MAXSIZE"
+	message string
+}
+
+// ResponseError is returned when the server answers NO or BYE
+type ResponseError struct {
+	Status  string
+	Code    string
+	Message string
+}
+
+func (e *ResponseError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = "command failed"
+	}
+	if e.Code != "" {
+		return fmt.Sprintf("sieve server: %s (%s)", msg, e.Code)
+	}
+	return "sieve server: " + msg
+}
+
+// readLine reads one response line, including any literals it contains
+func readLine(r *bufio.Reader) ([]token, error) {
+	var tokens []token
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case b == ' ':
+			continue
+		case b == '\r':
+			if next, err := r.ReadByte(); err != nil {
+				return nil, err
+			} else if next != '\n' {
+				return nil, fmt.Errorf("malformed response: expected LF")
+			}
+			return tokens, nil
+		case b == '\n':
+			return tokens, nil
+		case b == '(':
+			tokens = append(tokens, token{kind: tokenOpenParen})
+		case b == ')':
+			tokens = append(tokens, token{kind: tokenCloseParen})
+		case b == '"':
+			s, err := readQuoted(r)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, value: s})
+		case b == '{':
+			s, err := readLiteral(r)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, value: s})
+		default:
+			var sb strings.Builder
+			sb.WriteByte(b)
+			for {
+				next, err := r.ReadByte()
+				if err != nil {
+					return nil, err
+				}
+				if next == ' ' || next == '(' || next == ')' || next == '\r' || next == '\n' {
+					r.UnreadByte()
+					break
+				}
+				sb.WriteByte(next)
+			}
+			tokens = append(tokens, token{kind: tokenAtom, value: sb.String()})
+		}
+	}
+}
+
+// readQuoted reads a quoted string after its opening quote
+func readQuoted(r *bufio.Reader) (string, error) {
+	var sb strings.Builder
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		switch b {
+		case '"':
+			return sb.String(), nil
+		case '\\':
+			escaped, err := r.ReadByte()
+			if err != nil {
+				return "", err
+			}
+			sb.WriteByte(escaped)
+		case '\r', '\n':
+			return "", fmt.Errorf("malformed response: newline in quoted string")
+		default:
+			sb.WriteByte(b)
+		}
+	}
+}
+
+// readLiteral reads a {n} or {n+} literal after its opening brace
+func readLiteral(r *bufio.Reader) (string, error) {
+	spec, err := r.ReadString('}')
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSuffix(spec, "}"), "+"))
+	if err != nil || n < 0 || n > maxLiteralSize {
+		return "", fmt.Errorf("malformed response: invalid literal size %q", spec)
+	}
+
+	// Literal data starts on the next line
+	crlf, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimRight(crlf, "\r\n") != "" {
+		return "", fmt.Errorf("malformed response: data after literal size")
+	}
+
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// parseResponse interprets a line as an OK/NO/BYE response. ok is false for
+// data lines.
+func parseResponse(tokens []token) (*response, bool) {
+	if len(tokens) == 0 || tokens[0].kind != tokenAtom {
+		return nil, false
+	}
+
+	status := strings.ToUpper(tokens[0].value)
+	if status != "OK" && status != "NO" && status != "BYE" {
+		return nil, false
+	}
+
+	resp := &response{status: status}
+	rest := tokens[1:]
+
+	// Optional response code: "(" atom [SP string] ")"
+	if len(rest) > 0 && rest[0].kind == tokenOpenParen {
+		var parts []string
+		i := 1
+		for ; i < len(rest) && rest[i].kind != tokenCloseParen; i++ {
+			parts = append(parts, rest[i].value)
+		}
+		if len(parts) > 0 {
+			resp.code = strings.ToUpper(parts[0])
+		}
+		rest = rest[min(i+1, len(rest)):]
+	}
+
+	if len(rest) > 0 && rest[0].kind == tokenString {
+		resp.message = rest[0].value
+	}
+	return resp, true
+}
+
+// quoteString encodes s as a ManageSieve string argument. Strings that can't
+// be quoted are sent as non-synchronizing literals.
+func quoteString(s string) string {
+	if strings.ContainsAny(s, "\r\n") || len(s) > 1024 {
+		return fmt.Sprintf("{%d+}\r\n%s", len(s), s)
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
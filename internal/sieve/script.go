@@ -0,0 +1,631 @@
+package sieve
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// rulePrefix marks the comment that carries a rule's name
+const rulePrefix = "rule:"
+
+// Generate renders a structured script as Sieve. The output is what Parse
+// accepts, so scripts round-trip.
+func Generate(s *Script) string {
+	var b strings.Builder
+	b.WriteString("# Generated by Aerion\n")
+
+	var requires []string
+	if len(s.Rules) > 0 {
+		requires = append(requires, "fileinto")
+	}
+	if s.Vacation != nil {
+		requires = append(requires, "vacation")
+	}
+	if len(requires) > 0 {
+		b.WriteString("require " + stringList(requires) + ";\n")
+	}
+
+	for _, r := range s.Rules {
+		b.WriteString("\n")
+		if name := strings.Join(strings.Fields(r.Name), " "); name != "" {
+			b.WriteString("# " + rulePrefix + " " + name + "\n")
+		}
+
+		test := "header"
+		if addressHeaders[strings.ToLower(r.Header)] {
+			test = "address"
+		}
+		match := r.Match
+		if match == "" {
+			match = MatchContains
+		}
+
+		fmt.Fprintf(&b, "if %s :%s %s %s {\n", test, match, quote(strings.ToLower(r.Header)), quote(r.Value))
+		fmt.Fprintf(&b, "    fileinto %s;\n", quote(r.FileInto))
+		if r.Stop {
+			b.WriteString("    stop;\n")
+		}
+		b.WriteString("}\n")
+	}
+
+	if v := s.Vacation; v != nil {
+		b.WriteString("\n")
+		indent := ""
+		if !v.Enabled {
+			// Kept in the script so the text isn't lost while switched off
+			b.WriteString("if false {\n")
+			indent = "    "
+		}
+
+		b.WriteString(indent + "vacation")
+		if v.Days > 0 {
+			fmt.Fprintf(&b, " :days %d", v.Days)
+		}
+		if v.Subject != "" {
+			b.WriteString(" :subject " + quote(v.Subject))
+		}
+		if v.From != "" {
+			b.WriteString(" :from " + quote(v.From))
+		}
+		if len(v.Addresses) > 0 {
+			b.WriteString(" :addresses " + stringList(v.Addresses))
+		}
+		b.WriteString(" " + quote(v.Body) + ";\n")
+
+		if !v.Enabled {
+			b.WriteString("}\n")
+		}
+	}
+
+	return b.String()
+}
+
+// Parse reads a script into the structured model. Returns
+// ErrUnsupportedScript if the script uses anything Generate wouldn't produce.
+func Parse(text string) (*Script, error) {
+	p := &parser{lex: &lexer{src: text}}
+	commands, err := p.parseCommands(false)
+	if err != nil {
+		return nil, err
+	}
+
+	script := &Script{}
+	for _, cmd := range commands {
+		switch cmd.name {
+		case "require":
+			// Extensions are implied by the commands that follow
+		case "if":
+			if len(cmd.tests) != 1 {
+				return nil, ErrUnsupportedScript
+			}
+			if cmd.tests[0].name == "false" && len(cmd.tests[0].args) == 0 {
+				if script.Vacation != nil || len(cmd.block) != 1 || cmd.block[0].name != "vacation" {
+					return nil, ErrUnsupportedScript
+				}
+				v, err := parseVacation(cmd.block[0])
+				if err != nil {
+					return nil, err
+				}
+				script.Vacation = v
+				continue
+			}
+
+			r, err := parseRule(cmd)
+			if err != nil {
+				return nil, err
+			}
+			script.Rules = append(script.Rules, *r)
+		case "vacation":
+			if script.Vacation != nil {
+				return nil, ErrUnsupportedScript
+			}
+			v, err := parseVacation(cmd)
+			if err != nil {
+				return nil, err
+			}
+			v.Enabled = true
+			script.Vacation = v
+		default:
+			return nil, ErrUnsupportedScript
+		}
+	}
+	return script, nil
+}
+
+// parseRule interprets `if header|address :match "name" "value" { fileinto
+// "folder"; [stop;] }`
+func parseRule(cmd *command) (*Rule, error) {
+	t := cmd.tests[0]
+	if (t.name != "header" && t.name != "address") || len(t.tests) > 0 {
+		return nil, ErrUnsupportedScript
+	}
+
+	r := &Rule{Match: MatchIs}
+	if strings.HasPrefix(cmd.comment, rulePrefix) {
+		r.Name = strings.TrimSpace(strings.TrimPrefix(cmd.comment, rulePrefix))
+	}
+
+	var values [][]string
+	for _, arg := range t.args {
+		switch {
+		case arg.tag == "contains" || arg.tag == "is" || arg.tag == "matches":
+			r.Match = MatchType(arg.tag)
+		case arg.tag == "all" && t.name == "address":
+			// Default address part
+		case arg.tag == "" && arg.strings != nil:
+			values = append(values, arg.strings)
+		default:
+			return nil, ErrUnsupportedScript
+		}
+	}
+	if len(values) != 2 || len(values[0]) != 1 || len(values[1]) != 1 {
+		return nil, ErrUnsupportedScript
+	}
+	r.Header = values[0][0]
+	r.Value = values[1][0]
+
+	for i, c := range cmd.block {
+		switch {
+		case c.name == "fileinto" && i == 0:
+			if len(c.args) != 1 || len(c.args[0].strings) != 1 || c.args[0].tag != "" {
+				return nil, ErrUnsupportedScript
+			}
+			r.FileInto = c.args[0].strings[0]
+		case c.name == "stop" && i == 1 && len(c.args) == 0:
+			r.Stop = true
+		default:
+			return nil, ErrUnsupportedScript
+		}
+	}
+	if r.FileInto == "" || len(cmd.block) > 2 {
+		return nil, ErrUnsupportedScript
+	}
+
+	return r, nil
+}
+
+// parseVacation interprets a vacation command
+func parseVacation(cmd *command) (*Vacation, error) {
+	v := &Vacation{}
+	args := cmd.args
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg.tag == "" {
+			if i != len(args)-1 || len(arg.strings) != 1 {
+				return nil, ErrUnsupportedScript
+			}
+			v.Body = arg.strings[0]
+			continue
+		}
+
+		if i+1 >= len(args) || args[i+1].tag != "" {
+			return nil, ErrUnsupportedScript
+		}
+		value := args[i+1]
+		i++
+
+		switch arg.tag {
+		case "days":
+			if value.strings != nil {
+				return nil, ErrUnsupportedScript
+			}
+			v.Days = value.number
+		case "subject", "from":
+			if len(value.strings) != 1 {
+				return nil, ErrUnsupportedScript
+			}
+			if arg.tag == "subject" {
+				v.Subject = value.strings[0]
+			} else {
+				v.From = value.strings[0]
+			}
+		case "addresses":
+			if value.strings == nil {
+				return nil, ErrUnsupportedScript
+			}
+			v.Addresses = value.strings
+		default:
+			return nil, ErrUnsupportedScript
+		}
+	}
+	if len(cmd.tests) > 0 || len(cmd.block) > 0 {
+		return nil, ErrUnsupportedScript
+	}
+	return v, nil
+}
+
+// quote encodes s as a Sieve quoted string
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// stringList encodes values as a Sieve string list
+func stringList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = quote(v)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// ============================================================================
+// Parser
+// ============================================================================
+
+// command is a parsed Sieve command with its arguments, tests and block
+type command struct {
+	name    string
+	comment string // Hash comment immediately before the command
+	args    []argument
+	tests   []*command
+	block   []*command
+}
+
+// argument is a tag, a number or a string list (a single string is a list
+// of one)
+type argument struct {
+	tag     string
+	number  int
+	strings []string
+}
+
+type parser struct {
+	lex  *lexer
+	peek *lexToken
+}
+
+func (p *parser) next() (*lexToken, error) {
+	if p.peek != nil {
+		t := p.peek
+		p.peek = nil
+		return t, nil
+	}
+	return p.lex.next()
+}
+
+func (p *parser) unread(t *lexToken) {
+	p.peek = t
+}
+
+// parseCommands parses commands until end of input, or until "}" when
+// inBlock is set
+func (p *parser) parseCommands(inBlock bool) ([]*command, error) {
+	var commands []*command
+	for {
+		t, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if t.kind == lexEOF {
+			if inBlock {
+				return nil, fmt.Errorf("unexpected end of script: missing }")
+			}
+			return commands, nil
+		}
+		if t.kind == lexPunct && t.value == "}" && inBlock {
+			return commands, nil
+		}
+		if t.kind != lexIdentifier {
+			return nil, fmt.Errorf("line %d: expected command, found %q", t.line, t.value)
+		}
+
+		cmd := &command{name: strings.ToLower(t.value), comment: t.comment}
+		if err := p.parseArguments(cmd); err != nil {
+			return nil, err
+		}
+
+		end, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case end.kind == lexPunct && end.value == ";":
+		case end.kind == lexPunct && end.value == "{":
+			cmd.block, err = p.parseCommands(true)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("line %d: expected ; or {, found %q", end.line, end.value)
+		}
+		commands = append(commands, cmd)
+	}
+}
+
+// parseArguments parses arguments followed by an optional test or test list
+func (p *parser) parseArguments(cmd *command) error {
+	for {
+		t, err := p.next()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case t.kind == lexTag:
+			cmd.args = append(cmd.args, argument{tag: strings.ToLower(t.value)})
+		case t.kind == lexNumber:
+			n, err := parseNumber(t.value)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", t.line, err)
+			}
+			cmd.args = append(cmd.args, argument{number: n})
+		case t.kind == lexString:
+			cmd.args = append(cmd.args, argument{strings: []string{t.value}})
+		case t.kind == lexPunct && t.value == "[":
+			list, err := p.parseStringList()
+			if err != nil {
+				return err
+			}
+			cmd.args = append(cmd.args, argument{strings: list})
+		case t.kind == lexIdentifier:
+			test, err := p.parseTest(t)
+			if err != nil {
+				return err
+			}
+			cmd.tests = append(cmd.tests, test)
+			return nil
+		case t.kind == lexPunct && t.value == "(":
+			for {
+				first, err := p.next()
+				if err != nil {
+					return err
+				}
+				if first.kind != lexIdentifier {
+					return fmt.Errorf("line %d: expected test, found %q", first.line, first.value)
+				}
+				test, err := p.parseTest(first)
+				if err != nil {
+					return err
+				}
+				cmd.tests = append(cmd.tests, test)
+
+				sep, err := p.next()
+				if err != nil {
+					return err
+				}
+				if sep.kind == lexPunct && sep.value == ")" {
+					return nil
+				}
+				if sep.kind != lexPunct || sep.value != "," {
+					return fmt.Errorf("line %d: expected , or ), found %q", sep.line, sep.value)
+				}
+			}
+		default:
+			p.unread(t)
+			return nil
+		}
+	}
+}
+
+// parseTest parses a test whose identifier has been read
+func (p *parser) parseTest(name *lexToken) (*command, error) {
+	test := &command{name: strings.ToLower(name.value)}
+	if err := p.parseArguments(test); err != nil {
+		return nil, err
+	}
+	return test, nil
+}
+
+// parseStringList parses the rest of a string list after "["
+func (p *parser) parseStringList() ([]string, error) {
+	list := []string{}
+	for {
+		t, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if t.kind != lexString {
+			return nil, fmt.Errorf("line %d: expected string, found %q", t.line, t.value)
+		}
+		list = append(list, t.value)
+
+		sep, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if sep.kind == lexPunct && sep.value == "]" {
+			return list, nil
+		}
+		if sep.kind != lexPunct || sep.value != "," {
+			return nil, fmt.Errorf("line %d: expected , or ], found %q", sep.line, sep.value)
+		}
+	}
+}
+
+// parseNumber parses a number with an optional K, M or G quantifier
+func parseNumber(s string) (int, error) {
+	mult := 1
+	switch strings.ToUpper(s[len(s)-1:]) {
+	case "K":
+		mult = 1 << 10
+	case "M":
+		mult = 1 << 20
+	case "G":
+		mult = 1 << 30
+	}
+	if mult > 1 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return n * mult, nil
+}
+
+// ============================================================================
+// Lexer
+// ============================================================================
+
+type lexKind int
+
+const (
+	lexEOF lexKind = iota
+	lexIdentifier
+	lexTag
+	lexString
+	lexNumber
+	lexPunct
+)
+
+type lexToken struct {
+	kind    lexKind
+	value   string
+	line    int
+	comment string // Hash comment on the line(s) directly before the token
+}
+
+type lexer struct {
+	src     string
+	pos     int
+	line    int
+	comment string
+}
+
+func (l *lexer) next() (*lexToken, error) {
+	if l.line == 0 {
+		l.line = 1
+	}
+
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\n':
+			l.line++
+			l.pos++
+		case c == ' ' || c == '\t' || c == '\r':
+			l.pos++
+		case c == '#':
+			end := strings.IndexByte(l.src[l.pos:], '\n')
+			if end < 0 {
+				end = len(l.src) - l.pos
+			}
+			l.comment = strings.TrimSpace(l.src[l.pos+1 : l.pos+end])
+			l.pos += end
+		case strings.HasPrefix(l.src[l.pos:], "/*"):
+			end := strings.Index(l.src[l.pos+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", l.line)
+			}
+			l.line += strings.Count(l.src[l.pos:l.pos+2+end], "\n")
+			l.pos += end + 4
+		default:
+			return l.token()
+		}
+	}
+	return &lexToken{kind: lexEOF, line: l.line}, nil
+}
+
+// token reads the token at the current position
+func (l *lexer) token() (*lexToken, error) {
+	t := &lexToken{line: l.line, comment: l.comment}
+	l.comment = ""
+
+	c := l.src[l.pos]
+	switch {
+	case strings.ContainsRune(";{}[](),", rune(c)):
+		t.kind, t.value = lexPunct, string(c)
+		l.pos++
+	case c == '"':
+		s, err := l.quoted()
+		if err != nil {
+			return nil, err
+		}
+		t.kind, t.value = lexString, s
+	case c == ':':
+		l.pos++
+		t.kind, t.value = lexTag, l.word()
+		if t.value == "" {
+			return nil, fmt.Errorf("line %d: empty tag", t.line)
+		}
+	case c >= '0' && c <= '9':
+		t.kind, t.value = lexNumber, l.word()
+	case isIdentStart(c):
+		word := l.word()
+		if strings.EqualFold(word, "text") && l.pos < len(l.src) && l.src[l.pos] == ':' {
+			l.pos++
+			s, err := l.multiline()
+			if err != nil {
+				return nil, err
+			}
+			t.kind, t.value = lexString, s
+			break
+		}
+		t.kind, t.value = lexIdentifier, word
+	default:
+		return nil, fmt.Errorf("line %d: unexpected character %q", l.line, c)
+	}
+	return t, nil
+}
+
+// word reads identifier characters
+func (l *lexer) word() string {
+	start := l.pos
+	for l.pos < len(l.src) && (isIdentStart(l.src[l.pos]) || (l.src[l.pos] >= '0' && l.src[l.pos] <= '9')) {
+		l.pos++
+	}
+	return l.src[start:l.pos]
+}
+
+// quoted reads a quoted string, which may span lines
+func (l *lexer) quoted() (string, error) {
+	var sb strings.Builder
+	l.pos++
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		l.pos++
+		switch c {
+		case '"':
+			return sb.String(), nil
+		case '\\':
+			if l.pos < len(l.src) {
+				sb.WriteByte(l.src[l.pos])
+				l.pos++
+			}
+		case '\n':
+			l.line++
+			sb.WriteByte(c)
+		case '\r':
+			// Normalise CRLF to LF
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("line %d: unterminated string", l.line)
+}
+
+// multiline reads a text: block up to the terminating "." line
+func (l *lexer) multiline() (string, error) {
+	// Rest of the "text:" line may only hold whitespace or a comment
+	end := strings.IndexByte(l.src[l.pos:], '\n')
+	if end < 0 {
+		return "", fmt.Errorf("line %d: unterminated text block", l.line)
+	}
+	l.pos += end + 1
+	l.line++
+
+	var lines []string
+	for l.pos < len(l.src) {
+		end := strings.IndexByte(l.src[l.pos:], '\n')
+		if end < 0 {
+			end = len(l.src) - l.pos
+		}
+		line := strings.TrimSuffix(l.src[l.pos:l.pos+end], "\r")
+		l.pos += min(end+1, len(l.src)-l.pos)
+		l.line++
+
+		if line == "." {
+			return strings.Join(lines, "\n") + "\n", nil
+		}
+		// Dot-stuffing
+		lines = append(lines, strings.TrimPrefix(line, "."))
+	}
+	return "", fmt.Errorf("line %d: unterminated text block", l.line)
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,108 @@
+package smtp
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"net/smtp"
+	"strings"
+
+	"github.com/emersion/go-sasl"
+	"github.com/hkdb/aerion/internal/auth"
+)
+
+// saslAuth adapts a SASL client to smtp.Auth
+type saslAuth struct {
+	client  sasl.Client
+	emptyIR bool // Send the empty initial response when asked for it
+}
+
+// Start begins the exchange. net/smtp can't send an empty initial response
+// with AUTH (RFC 4954 writes it as "="), so one is sent in answer to the
+// server's first empty challenge instead.
+func (a *saslAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	mech, ir, err := a.client.Start()
+	if err != nil {
+		return "", nil, err
+	}
+	a.emptyIR = ir != nil && len(ir) == 0
+	return mech, ir, nil
+}
+
+// Next answers a server challenge. On success (more is false) fromServer is
+// the 235 reply text, which may carry the mechanism's final data; SCRAM
+// needs it to check the server's signature, and fails if it never got one.
+func (a *saslAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		if data, ok := successData(fromServer); ok && auth.Verified(a.client) != nil {
+			if _, err := a.client.Next(data); err != nil {
+				return nil, err
+			}
+		}
+		return nil, auth.Verified(a.client)
+	}
+	if a.emptyIR {
+		a.emptyIR = false
+		return []byte{}, nil
+	}
+	return a.client.Next(fromServer)
+}
+
+// successData finds base64 SASL data in a 235 reply such as
+// "2.7.0 dj1ybUYvSGlyYz0=", skipping the enhanced status code
+func successData(text []byte) ([]byte, bool) {
+	for _, field := range strings.Fields(string(text)) {
+		data, err := base64.StdEncoding.DecodeString(field)
+		if err == nil && len(data) > 2 && data[1] == '=' {
+			return data, true
+		}
+	}
+	return nil, false
+}
+
+// saslCredentials returns what the config can log in with
+func (c *Client) saslCredentials() auth.Credentials {
+	creds := auth.Credentials{
+		Username:   c.config.Username,
+		Host:       c.config.Host,
+		Port:       c.config.Port,
+		ClientCert: auth.HasClientCert(c.config.TLSConfig),
+	}
+	if c.config.AuthType == AuthTypeOAuth2 {
+		creds.AccessToken = c.config.AccessToken
+	} else {
+		creds.Password = c.config.Password
+	}
+	return creds
+}
+
+// authFor returns the smtp.Auth for the mechanism chosen from the advertised
+// ones, or nil when the account doesn't pin a mechanism and the server
+// offers nothing better than PLAIN/LOGIN (or XOAUTH2 for OAuth accounts)
+func (c *Client) authFor(advertised string) (auth.Mechanism, smtp.Auth, error) {
+	mechanisms := strings.Fields(advertised)
+
+	var tlsState *tls.ConnectionState
+	if state, ok := c.client.TLSConnectionState(); ok {
+		tlsState = &state
+	}
+
+	creds := c.saslCredentials()
+	mech, err := auth.Choose(c.config.AuthMechanism, mechanisms, creds, tlsState)
+	if err != nil || mech == auth.MechanismAuto {
+		return mech, nil, err
+	}
+
+	switch mech {
+	case auth.MechanismPlain:
+		// net/smtp's PLAIN refuses to send the password unencrypted
+		return mech, smtp.PlainAuth("", creds.Username, creds.Password, c.config.Host), nil
+	case auth.MechanismLogin:
+		return mech, LoginAuth(creds.Username, creds.Password), nil
+	}
+
+	client, err := auth.NewClient(mech, mechanisms, creds, tlsState)
+	if err != nil {
+		return mech, nil, err
+	}
+	return mech, &saslAuth{client: client}, nil
+}
@@ -8,9 +8,11 @@ import (
 	"io"
 	"net"
 	"net/smtp"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/hkdb/aerion/internal/auth"
 	"github.com/hkdb/aerion/internal/logging"
 	"github.com/rs/zerolog"
 )
@@ -36,12 +38,16 @@ type ClientConfig struct {
 	AuthType    AuthType // "password" or "oauth2" (defaults to "password")
 	AccessToken string   // OAuth2 access token (when AuthType is "oauth2")
 
+	// AuthMechanism pins the SASL mechanism; empty picks the strongest the
+	// server offers
+	AuthMechanism auth.Mechanism
+
 	// Timeouts
 	ConnectTimeout time.Duration
 	ReadTimeout    time.Duration
 	WriteTimeout   time.Duration
 
-	// TLS config (optional)
+	// TLS config (optional, may present a client certificate)
 	TLSConfig *tls.Config
 }
 
@@ -73,7 +79,7 @@ func NewClient(config ClientConfig) *Client {
 
 // Connect establishes a connection to the SMTP server
 func (c *Client) Connect() error {
-	addr := fmt.Sprintf("%s:%d", c.config.Host, c.config.Port)
+	addr := net.JoinHostPort(c.config.Host, strconv.Itoa(c.config.Port))
 
 	c.log.Debug().
 		Str("host", c.config.Host).
@@ -155,15 +161,24 @@ func (c *Client) Login() error {
 		Msg("Authenticating")
 
 	// Check available auth mechanisms
-	if ok, mechanisms := c.client.Extension("AUTH"); !ok {
+	ok, mechanisms := c.client.Extension("AUTH")
+	if !ok {
 		return fmt.Errorf("server does not support authentication")
-	} else {
-		c.log.Debug().Str("mechanisms", mechanisms).Msg("Available auth mechanisms")
 	}
+	c.log.Debug().Str("mechanisms", mechanisms).Msg("Available auth mechanisms")
 
-	var err error
-	switch authType {
-	case AuthTypeOAuth2:
+	mech, mechAuth, err := c.authFor(mechanisms)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case mechAuth != nil:
+		c.log.Debug().Str("mechanism", string(mech)).Msg("Authenticating with SASL")
+		if err := c.client.Auth(mechAuth); err != nil {
+			return fmt.Errorf("%s authentication failed: %w", mech, err)
+		}
+	case authType == AuthTypeOAuth2:
 		err = c.loginOAuth2()
 	default:
 		err = c.loginPassword()
@@ -230,6 +245,12 @@ func (c *Client) Close() error {
 
 // SendMail sends an email message
 func (c *Client) SendMail(from string, to []string, msg []byte) error {
+	return c.SendMailDSN(from, to, msg, nil)
+}
+
+// SendMailDSN sends an email message, requesting delivery status
+// notifications if dsn is set and the server supports them
+func (c *Client) SendMailDSN(from string, to []string, msg []byte, dsn *DSNOptions) error {
 	if c.client == nil {
 		return fmt.Errorf("not connected")
 	}
@@ -240,14 +261,21 @@ func (c *Client) SendMail(from string, to []string, msg []byte) error {
 		Int("size", len(msg)).
 		Msg("Sending message")
 
+	if dsn != nil {
+		if ok, _ := c.client.Extension("DSN"); !ok {
+			c.log.Debug().Msg("Server doesn't support DSN, sending without notification request")
+			dsn = nil
+		}
+	}
+
 	// Set the sender
-	if err := c.client.Mail(from); err != nil {
+	if err := c.mail(from, dsn); err != nil {
 		return fmt.Errorf("failed to set sender: %w", err)
 	}
 
 	// Set recipients
 	for _, recipient := range to {
-		if err := c.client.Rcpt(recipient); err != nil {
+		if err := c.rcpt(recipient, dsn); err != nil {
 			return fmt.Errorf("failed to add recipient %s: %w", recipient, err)
 		}
 	}
@@ -274,6 +302,52 @@ func (c *Client) SendMail(from string, to []string, msg []byte) error {
 	return nil
 }
 
+// mail issues MAIL FROM. net/smtp can't add DSN parameters, so with DSN
+// the command is written directly, keeping the parameters net/smtp adds.
+func (c *Client) mail(from string, dsn *DSNOptions) error {
+	if dsn == nil {
+		return c.client.Mail(from)
+	}
+	if strings.ContainsAny(from, "\r\n") {
+		return fmt.Errorf("smtp: A line must not contain CR or LF")
+	}
+
+	cmd := "MAIL FROM:<" + from + ">"
+	if ok, _ := c.client.Extension("8BITMIME"); ok {
+		cmd += " BODY=8BITMIME"
+	}
+	if ok, _ := c.client.Extension("SMTPUTF8"); ok {
+		cmd += " SMTPUTF8"
+	}
+	if params := dsn.mailParams(); params != "" {
+		cmd += " " + params
+	}
+	return c.cmd(250, cmd)
+}
+
+// rcpt issues RCPT TO, with DSN parameters if requested
+func (c *Client) rcpt(to string, dsn *DSNOptions) error {
+	if dsn == nil {
+		return c.client.Rcpt(to)
+	}
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("smtp: A line must not contain CR or LF")
+	}
+	return c.cmd(25, "RCPT TO:<"+to+"> "+dsn.rcptParams(to))
+}
+
+// cmd sends a command and checks the reply code
+func (c *Client) cmd(expectCode int, line string) error {
+	id, err := c.client.Text.Cmd("%s", line)
+	if err != nil {
+		return err
+	}
+	c.client.Text.StartResponse(id)
+	defer c.client.Text.EndResponse(id)
+	_, _, err = c.client.Text.ReadResponse(expectCode)
+	return err
+}
+
 // Reset resets the SMTP session, allowing a new message to be sent
 func (c *Client) Reset() error {
 	if c.client == nil {
@@ -0,0 +1,113 @@
+package smtp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DSNNotify is a condition a delivery status notification is requested for
+// (RFC 3461 NOTIFY parameter)
+type DSNNotify string
+
+const (
+	DSNNotifySuccess DSNNotify = "success"
+	DSNNotifyFailure DSNNotify = "failure"
+	DSNNotifyDelay   DSNNotify = "delay"
+	DSNNotifyNever   DSNNotify = "never" // Must not be combined with the others
+)
+
+// DSNReturn is how much of the message a failure notification returns
+// (RFC 3461 RET parameter)
+type DSNReturn string
+
+const (
+	DSNReturnFull    DSNReturn = "full"
+	DSNReturnHeaders DSNReturn = "hdrs"
+)
+
+// DSNOptions requests delivery status notifications for a message. They're
+// only sent to servers that advertise the DSN extension.
+type DSNOptions struct {
+	Notify     []DSNNotify `json:"notify,omitempty"`      // Empty leaves it to the server (usually failure only)
+	Return     DSNReturn   `json:"return,omitempty"`      // Empty leaves it to the server
+	EnvelopeID string      `json:"envelope_id,omitempty"` // Echoed back in notifications; set from the Message-ID when sending
+}
+
+// mailParams returns the DSN parameters for MAIL FROM
+func (o *DSNOptions) mailParams() string {
+	var params []string
+	if o.Return != "" {
+		params = append(params, "RET="+strings.ToUpper(string(o.Return)))
+	}
+	if o.EnvelopeID != "" {
+		params = append(params, "ENVID="+EncodeXText(o.EnvelopeID))
+	}
+	return strings.Join(params, " ")
+}
+
+// rcptParams returns the DSN parameters for RCPT TO
+func (o *DSNOptions) rcptParams(recipient string) string {
+	var params []string
+	if len(o.Notify) > 0 {
+		notify := make([]string, len(o.Notify))
+		for i, n := range o.Notify {
+			notify[i] = strings.ToUpper(string(n))
+		}
+		params = append(params, "NOTIFY="+strings.Join(notify, ","))
+	}
+	params = append(params, "ORCPT=rfc822;"+EncodeXText(recipient))
+	return strings.Join(params, " ")
+}
+
+// Validate checks the options can be sent as RFC 3461 parameters
+func (o *DSNOptions) Validate() error {
+	for _, n := range o.Notify {
+		switch n {
+		case DSNNotifySuccess, DSNNotifyFailure, DSNNotifyDelay:
+		case DSNNotifyNever:
+			if len(o.Notify) > 1 {
+				return fmt.Errorf("DSN notify \"never\" can't be combined with other conditions")
+			}
+		default:
+			return fmt.Errorf("unknown DSN notify condition: %s", n)
+		}
+	}
+	switch o.Return {
+	case "", DSNReturnFull, DSNReturnHeaders:
+	default:
+		return fmt.Errorf("unknown DSN return type: %s", o.Return)
+	}
+	return nil
+}
+
+// EncodeXText encodes s as RFC 3461 xtext: "+", "=" and characters outside
+// printable ASCII become "+XX"
+func EncodeXText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < '!' || c > '~' || c == '+' || c == '=' {
+			fmt.Fprintf(&b, "+%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// DecodeXText decodes RFC 3461 xtext. Malformed escapes are kept as is.
+func DecodeXText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '+' && i+2 < len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
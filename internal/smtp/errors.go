@@ -1,7 +1,12 @@
 // Package smtp provides SMTP client functionality for Aerion
 package smtp
 
-import "errors"
+import (
+	"errors"
+	"io"
+	"net"
+	"net/textproto"
+)
 
 var (
 	// ErrNotConnected indicates the client is not connected
@@ -25,3 +30,28 @@ var (
 	// ErrTimeout indicates a timeout occurred
 	ErrTimeout = errors.New("operation timed out")
 )
+
+// IsTemporaryError reports whether a send failure is worth retrying later:
+// the server couldn't be reached, the connection dropped, or the server
+// answered with a transient 4xx reply.
+func IsTemporaryError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrTimeout) {
+		return true
+	}
+
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code >= 400 && protoErr.Code < 500
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	// Dial, DNS and read/write failures
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -69,6 +69,9 @@ type ComposeMessage struct {
 	EncryptMessage      bool `json:"encrypt_message"` // S/MIME encrypt this message
 	PGPSignMessage      bool `json:"pgp_sign_message"`    // PGP sign this message
 	PGPEncryptMessage   bool `json:"pgp_encrypt_message"` // PGP encrypt this message
+
+	// Delivery status notifications to request from the server (nil for none)
+	DSN *DSNOptions `json:"dsn,omitempty"`
 }
 
 // AllRecipients returns all recipients (To + Cc + Bcc)
@@ -139,8 +142,9 @@ func (m *ComposeMessage) ToRFC822() ([]byte, error) {
 
 	// Choose message structure based on content
 	switch {
-	case hasAttachments && (hasHTML || hasText):
-		// multipart/mixed with multipart/alternative or just text
+	case hasAttachments:
+		// multipart/mixed with multipart/alternative, just text, or only
+		// attachments (forwarding as attachment)
 		if err := writeMultipartMixed(&buf, m, regularAttachments, inlineAttachments); err != nil {
 			return nil, err
 		}
@@ -377,6 +381,9 @@ func writeAttachment(w *multipart.Writer, att Attachment) error {
 	if contentType == "" {
 		contentType = "application/octet-stream"
 	}
+	if strings.EqualFold(contentType, "message/rfc822") {
+		return writeMessageAttachment(w, att)
+	}
 
 	header := textproto.MIMEHeader{}
 	header.Set("Content-Type", contentType)
@@ -397,6 +404,34 @@ func writeAttachment(w *multipart.Writer, att Attachment) error {
 	return encoder.Close()
 }
 
+// writeMessageAttachment writes an attached email (message/rfc822). RFC 2046
+// doesn't allow base64 for message parts, so the message goes in as is,
+// with its line endings normalized to CRLF.
+func writeMessageAttachment(w *multipart.Writer, att Attachment) error {
+	content := bytes.ReplaceAll(att.Content, []byte("\r\n"), []byte("\n"))
+	content = bytes.ReplaceAll(content, []byte("\n"), []byte("\r\n"))
+
+	encoding := "7bit"
+	for _, b := range content {
+		if b > 127 {
+			encoding = "8bit"
+			break
+		}
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", "message/rfc822")
+	header.Set("Content-Transfer-Encoding", encoding)
+	header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", att.Filename))
+
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(content)
+	return err
+}
+
 // writeInlineAttachment writes an inline attachment (for HTML images)
 func writeInlineAttachment(w *multipart.Writer, att Attachment) error {
 	contentType := att.ContentType
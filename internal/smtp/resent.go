@@ -0,0 +1,26 @@
+package smtp
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Resent returns a message to redirect (bounce) to new recipients: the
+// original bytes unchanged, with a block of Resent-* headers (RFC 5322
+// section 3.6.6) prepended. The original From, To and Message-ID stay as
+// they are, so replies still go to the original sender.
+func Resent(raw []byte, from Address, to []Address, date time.Time) []byte {
+	messageID := fmt.Sprintf("<%s@aerion>", uuid.New().String())
+
+	var buf bytes.Buffer
+	writeHeader(&buf, "Resent-From", from.String())
+	writeHeader(&buf, "Resent-To", formatAddresses(to))
+	writeHeader(&buf, "Resent-Date", date.Format(time.RFC1123Z))
+	writeHeader(&buf, "Resent-Message-ID", messageID)
+	buf.Write(raw)
+
+	return buf.Bytes()
+}
@@ -210,8 +210,17 @@ func decodeMIMEWord(s string) string {
 	if s == "" {
 		return s
 	}
-	// Use mime.WordDecoder with charset fallback support
-	dec := &mime.WordDecoder{
+	decoded, err := newWordDecoder().DecodeHeader(s)
+	if err != nil {
+		// If decoding fails, return original string
+		return s
+	}
+	return decoded
+}
+
+// newWordDecoder returns a mime.WordDecoder with charset fallback support
+func newWordDecoder() *mime.WordDecoder {
+	return &mime.WordDecoder{
 		CharsetReader: func(charsetName string, r io.Reader) (io.Reader, error) {
 			// First try the go-message charset package
 			if reader, err := msgcharset.Reader(charsetName, r); err == nil {
@@ -225,10 +234,4 @@ func decodeMIMEWord(s string) string {
 			return enc.NewDecoder().Reader(r), nil
 		},
 	}
-	decoded, err := dec.DecodeHeader(s)
-	if err != nil {
-		// If decoding fails, return original string
-		return s
-	}
-	return decoded
 }
@@ -9,9 +9,11 @@ import (
 	"github.com/hkdb/aerion/internal/email"
 	"github.com/hkdb/aerion/internal/folder"
 	imapPkg "github.com/hkdb/aerion/internal/imap"
+	"github.com/hkdb/aerion/internal/jmap"
 	"github.com/hkdb/aerion/internal/logging"
 	"github.com/hkdb/aerion/internal/message"
 	"github.com/hkdb/aerion/internal/pgp"
+	"github.com/hkdb/aerion/internal/pop3"
 	"github.com/hkdb/aerion/internal/smime"
 	"github.com/rs/zerolog"
 )
@@ -89,6 +91,10 @@ type Engine struct {
 	progressCallback ProgressCallback
 	smimeVerifier    *smime.Verifier
 	pgpVerifier      *pgp.Verifier
+	jmap             *jmap.Manager
+	jmapStates       *jmap.Store
+	pop3UIDLs        *pop3.Store
+	pop3Config       func(accountID string) (*pop3.ClientConfig, error)
 }
 
 // NewEngine creates a new sync engine
@@ -120,6 +126,19 @@ func (e *Engine) SetPGPVerifier(verifier *pgp.Verifier) {
 	e.pgpVerifier = verifier
 }
 
+// SetJMAP sets the client manager and state store used to sync JMAP accounts
+func (e *Engine) SetJMAP(manager *jmap.Manager, states *jmap.Store) {
+	e.jmap = manager
+	e.jmapStates = states
+}
+
+// SetPOP3 sets the UIDL store and the connection settings used to download
+// mail for POP3 accounts
+func (e *Engine) SetPOP3(uidls *pop3.Store, getConfig func(accountID string) (*pop3.ClientConfig, error)) {
+	e.pop3UIDLs = uidls
+	e.pop3Config = getConfig
+}
+
 // ParseRawBody parses raw message bytes into body text/HTML.
 // This is a convenience wrapper around ParseDecryptedBody for callers that only need text.
 func (e *Engine) ParseRawBody(raw []byte) (bodyHTML, bodyText string) {
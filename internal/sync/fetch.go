@@ -33,6 +33,13 @@ type ProcessedBody struct {
 // FetchMessageBody fetches the body for a single message on-demand.
 // Uses streaming fetch internally to avoid blocking on .Collect().
 func (e *Engine) FetchMessageBody(ctx context.Context, accountID, messageID string) (*message.Message, error) {
+	if e.isJMAP(accountID) {
+		return e.fetchJMAPMessageBody(ctx, accountID, messageID)
+	}
+	if e.hasLocalFolders(accountID) {
+		return e.fetchLocalMessageBody(messageID)
+	}
+
 	// Get message from store to get UID and folder
 	uid, folderID, err := e.messageStore.GetMessageUIDAndFolder(messageID)
 	if err != nil {
@@ -276,37 +283,7 @@ func (e *Engine) fetchMessageBodiesBatch(ctx context.Context, client *imapclient
 			Int("bodySize", len(rawBytes)).
 			Msg("Processing message body")
 
-		// Parse body content with timeout, extracting attachments in the same pass
-		parsed := e.parseMessageBodyFull(rawBytes, messageID, 30*time.Second)
-
-		// Sanitize HTML
-		bodyHTML := parsed.BodyHTML
-		if bodyHTML != "" {
-			bodyHTML = e.sanitizer.Sanitize(bodyHTML)
-		}
-
-		// Generate snippet
-		var snippet string
-		if parsed.BodyText != "" {
-			snippet = generateSnippet(parsed.BodyText, 200)
-		} else if bodyHTML != "" {
-			snippet = generateSnippet(stripHTMLTags(bodyHTML), 200)
-		}
-
-		results[uid] = &ProcessedBody{
-			MessageID:      messageID,
-			BodyHTML:       bodyHTML,
-			BodyText:       parsed.BodyText,
-			Snippet:        snippet,
-			HasAttachments: parsed.HasAttachments,
-			Attachments:    parsed.Attachments,
-			RawBytes:       rawBytes,
-			SMIMEResult:    parsed.SMIMEResult,
-			SMIMERawBody:   parsed.SMIMERawBody,
-			SMIMEEncrypted: parsed.SMIMEEncrypted,
-			PGPRawBody:     parsed.PGPRawBody,
-			PGPEncrypted:   parsed.PGPEncrypted,
-		}
+		results[uid] = e.processRawBody(messageID, rawBytes)
 	}
 
 	if err := fetchCmd.Close(); err != nil {
@@ -326,6 +303,49 @@ func (e *Engine) fetchMessageBodiesBatch(ctx context.Context, client *imapclient
 	return results, nil
 }
 
+// processRawBody parses, sanitizes and snippets a raw RFC822 message,
+// extracting attachments in the same pass
+func (e *Engine) processRawBody(messageID string, rawBytes []byte) *ProcessedBody {
+	// Parse body content with timeout, extracting attachments in the same pass
+	parsed := e.parseMessageBodyFull(rawBytes, messageID, 30*time.Second)
+
+	// Delivery reports and read receipts are linked to the sent message
+	if reports := parseDeliveryReports(rawBytes); len(reports) > 0 {
+		if err := e.messageStore.SaveDeliveryReports(messageID, reports); err != nil {
+			e.log.Warn().Err(err).Str("messageId", messageID).Msg("Failed to save delivery reports")
+		}
+	}
+
+	// Sanitize HTML
+	bodyHTML := parsed.BodyHTML
+	if bodyHTML != "" {
+		bodyHTML = e.sanitizer.Sanitize(bodyHTML)
+	}
+
+	// Generate snippet
+	var snippet string
+	if parsed.BodyText != "" {
+		snippet = generateSnippet(parsed.BodyText, 200)
+	} else if bodyHTML != "" {
+		snippet = generateSnippet(stripHTMLTags(bodyHTML), 200)
+	}
+
+	return &ProcessedBody{
+		MessageID:      messageID,
+		BodyHTML:       bodyHTML,
+		BodyText:       parsed.BodyText,
+		Snippet:        snippet,
+		HasAttachments: parsed.HasAttachments,
+		Attachments:    parsed.Attachments,
+		RawBytes:       rawBytes,
+		SMIMEResult:    parsed.SMIMEResult,
+		SMIMERawBody:   parsed.SMIMERawBody,
+		SMIMEEncrypted: parsed.SMIMEEncrypted,
+		PGPRawBody:     parsed.PGPRawBody,
+		PGPEncrypted:   parsed.PGPEncrypted,
+	}
+}
+
 // FetchBodiesInBackground fetches bodies for messages that don't have them yet.
 // This is called after headers sync to fetch bodies in the background.
 // syncPeriodDays limits body fetching to messages within the sync period (0 = all messages).
@@ -357,6 +377,15 @@ func (e *Engine) FetchBodiesInBackground(ctx context.Context, accountID, folderI
 		return ctx.Err()
 	}
 
+	if e.isJMAP(accountID) {
+		return e.fetchJMAPBodies(ctx, accountID, folderID, syncPeriodDays)
+	}
+	if e.hasLocalFolders(accountID) {
+		// Bodies are parsed when local messages are stored
+		e.emitProgress(accountID, folderID, 1, 1, "bodies")
+		return nil
+	}
+
 	// Get folder to get path
 	f, err := e.folderStore.Get(folderID)
 	if err != nil {
@@ -795,6 +824,13 @@ func (e *Engine) FetchBodiesInBackground(ctx context.Context, accountID, folderI
 // FetchRawMessage fetches the raw RFC822 content of a message from the IMAP server.
 // Uses streaming fetch to avoid blocking on .Collect().
 func (e *Engine) FetchRawMessage(ctx context.Context, accountID, folderID string, uid uint32) ([]byte, error) {
+	if e.isJMAP(accountID) {
+		return e.fetchJMAPRawMessage(ctx, accountID, folderID, uid)
+	}
+	if e.hasLocalFolders(accountID) {
+		return e.fetchLocalRawMessage(folderID, uid)
+	}
+
 	// Get folder path
 	f, err := e.folderStore.Get(folderID)
 	if err != nil {
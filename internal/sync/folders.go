@@ -23,6 +23,13 @@ const folderStatusWorkers = 5
 
 // SyncFolders synchronizes the folder list for an account
 func (e *Engine) SyncFolders(ctx context.Context, accountID string) error {
+	if e.isJMAP(accountID) {
+		return e.syncJMAPFolders(ctx, accountID)
+	}
+	if e.hasLocalFolders(accountID) {
+		return e.syncLocalFolders(accountID)
+	}
+
 	e.log.Debug().Str("account", accountID).Msg("Syncing folders")
 
 	// Get a connection from the pool for LIST
@@ -61,23 +68,7 @@ func (e *Engine) SyncFolders(ctx context.Context, accountID string) error {
 
 	// Build path→type override map from account folder mappings
 	// This ensures user-configured folder mappings take precedence over IMAP auto-detection
-	pathTypeOverrides := make(map[string]folder.Type)
-	if acc, err := e.accountStore.Get(accountID); err == nil && acc != nil {
-		mappings := map[string]folder.Type{
-			acc.SentFolderPath:    folder.TypeSent,
-			acc.DraftsFolderPath:  folder.TypeDrafts,
-			acc.TrashFolderPath:   folder.TypeTrash,
-			acc.SpamFolderPath:    folder.TypeSpam,
-			acc.ArchiveFolderPath: folder.TypeArchive,
-			acc.AllMailFolderPath: folder.TypeAll,
-			acc.StarredFolderPath: folder.TypeStarred,
-		}
-		for path, fType := range mappings {
-			if path != "" {
-				pathTypeOverrides[path] = fType
-			}
-		}
-	}
+	pathTypeOverrides := e.folderTypeOverrides(accountID)
 
 	// Fetch STATUS for all folders in parallel
 	results := e.fetchFolderStatusParallel(ctx, accountID, mailboxes)
@@ -131,6 +122,7 @@ func (e *Engine) SyncFolders(ctx context.Context, accountID string) error {
 			// Update existing folder
 			existing.Name = extractFolderName(mb.Name, mb.Delimiter)
 			existing.Type = folderType
+			existing.Subscribed = mb.Subscribed
 
 			// Recompute parent (self-healing for broken parent links)
 			if mb.Delimiter != "" {
@@ -157,10 +149,11 @@ func (e *Engine) SyncFolders(ctx context.Context, accountID string) error {
 		} else {
 			// Create new folder
 			f := &folder.Folder{
-				AccountID: accountID,
-				Name:      extractFolderName(mb.Name, mb.Delimiter),
-				Path:      mb.Name,
-				Type:      folderType,
+				AccountID:  accountID,
+				Name:       extractFolderName(mb.Name, mb.Delimiter),
+				Path:       mb.Name,
+				Type:       folderType,
+				Subscribed: mb.Subscribed,
 			}
 			if status != nil {
 				f.UIDValidity = status.UIDValidity
@@ -205,6 +198,31 @@ func (e *Engine) SyncFolders(ctx context.Context, accountID string) error {
 	return nil
 }
 
+// folderTypeOverrides returns the folder types the user mapped to paths in
+// the account settings
+func (e *Engine) folderTypeOverrides(accountID string) map[string]folder.Type {
+	overrides := make(map[string]folder.Type)
+	acc, err := e.accountStore.Get(accountID)
+	if err != nil || acc == nil {
+		return overrides
+	}
+	mappings := map[string]folder.Type{
+		acc.SentFolderPath:    folder.TypeSent,
+		acc.DraftsFolderPath:  folder.TypeDrafts,
+		acc.TrashFolderPath:   folder.TypeTrash,
+		acc.SpamFolderPath:    folder.TypeSpam,
+		acc.ArchiveFolderPath: folder.TypeArchive,
+		acc.AllMailFolderPath: folder.TypeAll,
+		acc.StarredFolderPath: folder.TypeStarred,
+	}
+	for path, fType := range mappings {
+		if path != "" {
+			overrides[path] = fType
+		}
+	}
+	return overrides
+}
+
 // fetchFolderStatusParallel fetches STATUS for multiple folders concurrently
 func (e *Engine) fetchFolderStatusParallel(ctx context.Context, accountID string, mailboxes []*imapPkg.Mailbox) []folderStatusResult {
 	results := make([]folderStatusResult, len(mailboxes))
@@ -0,0 +1,97 @@
+package sync
+
+import (
+	"strings"
+
+	"github.com/hkdb/aerion/internal/folder"
+	imapPkg "github.com/hkdb/aerion/internal/imap"
+	"github.com/hkdb/aerion/internal/message"
+)
+
+// syncGmailAttrs fetches Gmail's message IDs, thread IDs and labels for new
+// messages, and for existing messages whose metadata changed since
+// sinceModSeq (all of them when sinceModSeq is 0). Gmail exposes every label
+// as a folder, so this is what ties the copies of a message together.
+func (e *Engine) syncGmailAttrs(accountID string, f *folder.Folder, newUIDs, existingUIDs []uint32, sinceModSeq uint64) error {
+	if len(newUIDs) == 0 && len(existingUIDs) == 0 {
+		return nil
+	}
+
+	var attrs map[uint32]*imapPkg.GmailAttrs
+	err := e.pool.WithGmail(accountID, func(gm *imapPkg.GmailConn) error {
+		if err := gm.Select(f.Path); err != nil {
+			return err
+		}
+
+		var err error
+		attrs, err = gm.FetchAttrs(newUIDs, 0)
+		if err != nil {
+			return err
+		}
+		if len(existingUIDs) > 0 {
+			changed, err := gm.FetchAttrs(existingUIDs, sinceModSeq)
+			if err != nil {
+				return err
+			}
+			for uid, a := range changed {
+				attrs[uid] = a
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// X-GM-LABELS leaves out the label of the mailbox being fetched from
+	folderLabel := gmailFolderLabel(f)
+
+	updates := make([]message.GmailAttrsUpdate, 0, len(attrs))
+	for uid, a := range attrs {
+		labels := a.Labels
+		if folderLabel != "" {
+			labels = append(labels, folderLabel)
+		}
+		updates = append(updates, message.GmailAttrsUpdate{
+			UID:      uid,
+			MsgID:    a.MsgID,
+			ThreadID: a.ThreadID,
+			Labels:   labels,
+		})
+	}
+
+	if err := e.messageStore.ApplyGmailAttrs(accountID, f.ID, updates); err != nil {
+		return err
+	}
+
+	e.log.Debug().
+		Str("folder", f.Path).
+		Int("count", len(updates)).
+		Msg("Synced Gmail labels and threads")
+
+	return nil
+}
+
+// gmailFolderLabel returns the label a Gmail folder represents, or "" for
+// folders that aren't labels (All Mail, Spam, Trash)
+func gmailFolderLabel(f *folder.Folder) string {
+	switch f.Type {
+	case folder.TypeInbox:
+		return `\Inbox`
+	case folder.TypeSent:
+		return `\Sent`
+	case folder.TypeDrafts:
+		return `\Draft`
+	case folder.TypeStarred:
+		return `\Starred`
+	case folder.TypeFolder:
+		if strings.HasPrefix(f.Path, "[Gmail]/") || strings.HasPrefix(f.Path, "[Google Mail]/") {
+			if strings.HasSuffix(f.Path, "/Important") {
+				return `\Important`
+			}
+			return ""
+		}
+		return f.Path
+	}
+	return ""
+}
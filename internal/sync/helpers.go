@@ -12,9 +12,14 @@ import (
 	"github.com/hkdb/aerion/internal/message"
 )
 
-// applyFlagsToMessage sets boolean flag fields on a Message from IMAP flags
+// applyFlagsToMessage sets boolean flag fields and tags on a Message from
+// IMAP flags
 func applyFlagsToMessage(m *message.Message, flags []imap.Flag) {
 	for _, flag := range flags {
+		if message.IsTagKeyword(string(flag)) {
+			m.Tags = append(m.Tags, string(flag))
+			continue
+		}
 		switch flag {
 		case imap.FlagSeen:
 			m.IsRead = true
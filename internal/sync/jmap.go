@@ -0,0 +1,900 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-imap/v2"
+	"github.com/hkdb/aerion/internal/folder"
+	"github.com/hkdb/aerion/internal/jmap"
+	"github.com/hkdb/aerion/internal/message"
+)
+
+// jmapEmailState is the data type whose state delta sync resumes from
+const jmapEmailState = "Email"
+
+// errJMAPUnsupported is returned by IMAP-only operations on JMAP accounts
+var errJMAPUnsupported = errors.New("not supported for JMAP accounts")
+
+// isJMAP reports whether an account syncs over JMAP instead of IMAP
+func (e *Engine) isJMAP(accountID string) bool {
+	if e.jmap == nil {
+		return false
+	}
+	acc, err := e.accountStore.Get(accountID)
+	return err == nil && acc != nil && acc.IsJMAP()
+}
+
+// syncJMAPFolders mirrors an account's JMAP mailboxes into local folders.
+// Folders are matched by mailbox ID, so server-side renames keep their
+// messages.
+func (e *Engine) syncJMAPFolders(ctx context.Context, accountID string) error {
+	e.log.Debug().Str("account", accountID).Msg("Syncing JMAP mailboxes")
+
+	client, err := e.jmap.Client(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+
+	mailboxes, _, err := client.GetMailboxes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list mailboxes: %w", err)
+	}
+
+	totalFolders := len(mailboxes)
+	if totalFolders == 0 {
+		e.log.Info().Str("account", accountID).Msg("No folders found")
+		return nil
+	}
+
+	e.emitProgress(accountID, "", 0, totalFolders, "folders")
+
+	localFolders, err := e.folderStore.List(accountID)
+	if err != nil {
+		return fmt.Errorf("failed to list local folders: %w", err)
+	}
+	remoteIDs, err := e.folderStore.ListRemoteIDs(accountID)
+	if err != nil {
+		return err
+	}
+
+	// Folders without a mailbox ID are adopted by path
+	localByRemote := make(map[string]*folder.Folder)
+	localByPath := make(map[string]*folder.Folder)
+	for _, f := range localFolders {
+		if remoteID := remoteIDs[f.ID]; remoteID != "" {
+			localByRemote[remoteID] = f
+		} else {
+			localByPath[f.Path] = f
+		}
+	}
+
+	pathTypeOverrides := e.folderTypeOverrides(accountID)
+	paths := jmap.MailboxPaths(mailboxes)
+
+	// Parents before children so parent IDs can be resolved
+	sort.SliceStable(mailboxes, func(i, j int) bool {
+		return strings.Count(paths[mailboxes[i].ID], "/") < strings.Count(paths[mailboxes[j].ID], "/")
+	})
+
+	seen := make(map[string]bool)
+	for i, mb := range mailboxes {
+		e.emitProgress(accountID, "", i+1, totalFolders, "folders")
+
+		path := paths[mb.ID]
+		folderType := jmapFolderType(mb.Role)
+		if override, ok := pathTypeOverrides[path]; ok {
+			folderType = override
+		}
+
+		var parentID string
+		if parent, ok := localByRemote[mb.ParentID]; ok && mb.ParentID != "" {
+			parentID = parent.ID
+		}
+
+		f, ok := localByRemote[mb.ID]
+		if !ok {
+			f, ok = localByPath[path]
+		}
+
+		if ok {
+			if f.Path != path {
+				// Renamed or moved on the server
+				if err := e.folderStore.Rename(f.ID, mb.Name, path, parentID, "/"); err != nil {
+					e.log.Warn().Err(err).Str("path", path).Msg("Failed to rename folder")
+				}
+				f.Path = path
+			}
+			f.Name = mb.Name
+			f.Type = folderType
+			f.ParentID = parentID
+			f.Subscribed = mb.IsSubscribed
+			f.TotalCount = mb.TotalEmails
+			f.UnreadCount = mb.UnreadEmails
+			if err := e.folderStore.Update(f); err != nil {
+				e.log.Warn().Err(err).Str("path", path).Msg("Failed to update folder")
+			}
+		} else {
+			f = &folder.Folder{
+				AccountID:   accountID,
+				Name:        mb.Name,
+				Path:        path,
+				Type:        folderType,
+				ParentID:    parentID,
+				Subscribed:  mb.IsSubscribed,
+				TotalCount:  mb.TotalEmails,
+				UnreadCount: mb.UnreadEmails,
+			}
+			if err := e.folderStore.Create(f); err != nil {
+				e.log.Warn().Err(err).Str("path", path).Msg("Failed to create folder")
+				continue
+			}
+		}
+
+		if remoteIDs[f.ID] != mb.ID {
+			if err := e.folderStore.SetRemoteID(f.ID, mb.ID); err != nil {
+				e.log.Warn().Err(err).Str("path", path).Msg("Failed to set mailbox ID")
+			}
+		}
+		localByRemote[mb.ID] = f
+		seen[f.ID] = true
+	}
+
+	// Delete folders whose mailbox no longer exists
+	for _, f := range localFolders {
+		if !seen[f.ID] {
+			e.log.Debug().Str("path", f.Path).Msg("Deleting removed folder")
+			if err := e.folderStore.Delete(f.ID); err != nil {
+				e.log.Warn().Err(err).Str("path", f.Path).Msg("Failed to delete folder")
+			}
+		}
+	}
+
+	e.log.Info().Str("account", accountID).Int("folders", totalFolders).Msg("Folder sync complete")
+
+	return nil
+}
+
+// syncJMAPMessages brings a folder of a JMAP account up to date. Email
+// changes are tracked per account, so one /changes call covers every
+// folder; a folder that has never been synced is listed in full instead.
+func (e *Engine) syncJMAPMessages(ctx context.Context, accountID, folderID string, syncPeriodDays int) error {
+	f, err := e.folderStore.Get(folderID)
+	if err != nil {
+		return fmt.Errorf("failed to get folder: %w", err)
+	}
+	if f == nil {
+		return fmt.Errorf("folder not found: %s", folderID)
+	}
+
+	mailboxID, err := e.folderStore.GetRemoteID(folderID)
+	if err != nil {
+		return err
+	}
+	if mailboxID == "" {
+		return fmt.Errorf("folder has no JMAP mailbox: %s", f.Path)
+	}
+
+	e.log.Debug().
+		Str("account", accountID).
+		Str("folder", f.Path).
+		Int("syncPeriodDays", syncPeriodDays).
+		Msg("Syncing messages (JMAP)")
+
+	client, err := e.jmap.Client(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+
+	var sinceDate time.Time
+	if syncPeriodDays > 0 {
+		sinceDate = time.Now().AddDate(0, 0, -syncPeriodDays)
+
+		deleted, err := e.messageStore.DeleteOlderThan(accountID, sinceDate)
+		if err != nil {
+			e.log.Warn().Err(err).Msg("Failed to delete old messages")
+		} else if deleted > 0 {
+			e.log.Info().Int("deleted", deleted).Msg("Deleted messages older than sync period")
+		}
+	}
+
+	e.emitProgress(accountID, folderID, 0, 0, "messages")
+
+	state, err := e.jmapStates.GetState(accountID, jmapEmailState)
+	if err != nil {
+		return err
+	}
+
+	if state == "" || f.LastSync == nil {
+		if err := e.reconcileJMAPFolder(ctx, client, accountID, f, mailboxID, sinceDate, state == ""); err != nil {
+			return err
+		}
+	} else {
+		err := e.applyJMAPChanges(ctx, client, accountID, state)
+		if jmap.IsCannotCalculateChanges(err) {
+			// The server no longer has the history; start over for every folder
+			e.log.Info().Str("account", accountID).Msg("JMAP state too old, full resync required")
+			if err := e.resyncJMAPAccount(ctx, client, accountID, sinceDate); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return fmt.Errorf("failed to sync changes: %w", err)
+		}
+	}
+
+	// Counts come from the server, since the sync period may leave older
+	// messages out locally
+	now := time.Now()
+	f.LastSync = &now
+	if mailboxes, _, err := client.GetMailboxes(ctx); err == nil {
+		for _, mb := range mailboxes {
+			if mb.ID == mailboxID {
+				f.TotalCount = mb.TotalEmails
+				f.UnreadCount = mb.UnreadEmails
+				break
+			}
+		}
+	} else if unreadCount, err := e.messageStore.CountUnreadByFolder(folderID); err == nil {
+		f.UnreadCount = unreadCount
+	}
+	if err := e.folderStore.Update(f); err != nil {
+		e.log.Warn().Err(err).Msg("Failed to update folder sync state")
+	}
+
+	e.emitProgress(accountID, folderID, 1, 1, "headers")
+
+	e.log.Info().Str("folder", f.Path).Msg("Message sync complete (JMAP)")
+
+	return nil
+}
+
+// reconcileJMAPFolder lists every Email in a mailbox (since sinceDate) and
+// makes the folder match. When saveState is set the Email state from
+// before the listing is stored, so later changes are picked up by /changes.
+func (e *Engine) reconcileJMAPFolder(ctx context.Context, client *jmap.Client, accountID string, f *folder.Folder, mailboxID string, sinceDate time.Time, saveState bool) error {
+	var newState string
+	if saveState {
+		state, err := client.EmailState(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get email state: %w", err)
+		}
+		newState = state
+	}
+
+	filter := jmap.EmailFilter{InMailbox: mailboxID}
+	if !sinceDate.IsZero() {
+		filter.After = &sinceDate
+	}
+	remoteIDs, err := client.QueryEmails(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to query emails: %w", err)
+	}
+
+	localRefs, err := e.messageStore.ListRemoteRefsByFolder(f.ID, sinceDate)
+	if err != nil {
+		return err
+	}
+
+	e.log.Debug().
+		Str("folder", f.Path).
+		Int("localCount", len(localRefs)).
+		Int("remoteCount", len(remoteIDs)).
+		Msg("Email comparison")
+
+	remoteSet := make(map[string]bool, len(remoteIDs))
+	for _, id := range remoteIDs {
+		remoteSet[id] = true
+	}
+
+	// Same safeguard as IMAP sync: an empty listing with local messages is
+	// more likely a server problem than an emptied mailbox
+	if len(remoteIDs) == 0 && len(localRefs) > 0 && sinceDate.IsZero() {
+		e.log.Warn().
+			Str("folder", f.Path).
+			Int("localCount", len(localRefs)).
+			Msg("Server returned 0 messages but we have local messages - skipping deletion to prevent data loss")
+		return nil
+	}
+
+	for _, ref := range localRefs {
+		if !remoteSet[ref.RemoteID] {
+			if err := e.messageStore.Delete(ref.ID); err != nil {
+				e.log.Warn().Err(err).Str("messageId", ref.ID).Msg("Failed to delete message")
+			}
+		}
+	}
+
+	for i := 0; i < len(remoteIDs); i += headerBatchSize {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		end := i + headerBatchSize
+		if end > len(remoteIDs) {
+			end = len(remoteIDs)
+		}
+		e.emitProgress(accountID, f.ID, i, len(remoteIDs), "headers")
+
+		emails, err := client.GetEmails(ctx, remoteIDs[i:end])
+		if err != nil {
+			return fmt.Errorf("failed to get emails: %w", err)
+		}
+		if err := e.applyJMAPEmails(accountID, emails); err != nil {
+			return err
+		}
+	}
+
+	if saveState {
+		if err := e.jmapStates.SetState(accountID, jmapEmailState, newState); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resyncJMAPAccount lists every folder of an account again, for when the
+// server can no longer tell what changed since the stored state. The Email
+// state is taken before the first listing, so changes made meanwhile are
+// picked up by the next /changes call.
+func (e *Engine) resyncJMAPAccount(ctx context.Context, client *jmap.Client, accountID string, sinceDate time.Time) error {
+	// Folders left unsynced if this fails are listed again on their next sync
+	if err := e.resetJMAPSync(accountID); err != nil {
+		return err
+	}
+
+	state, err := client.EmailState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get email state: %w", err)
+	}
+
+	folders, err := e.folderStore.List(accountID)
+	if err != nil {
+		return fmt.Errorf("failed to list folders: %w", err)
+	}
+	mailboxIDs, err := e.folderStore.ListRemoteIDs(accountID)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range folders {
+		mailboxID := mailboxIDs[f.ID]
+		if mailboxID == "" {
+			continue
+		}
+		if err := e.reconcileJMAPFolder(ctx, client, accountID, f, mailboxID, sinceDate, false); err != nil {
+			return err
+		}
+		now := time.Now()
+		f.LastSync = &now
+		if err := e.folderStore.Update(f); err != nil {
+			e.log.Warn().Err(err).Str("folder", f.Path).Msg("Failed to update folder sync state")
+		}
+	}
+
+	return e.jmapStates.SetState(accountID, jmapEmailState, state)
+}
+
+// applyJMAPChanges applies every Email change since sinceState, across all
+// folders of the account
+func (e *Engine) applyJMAPChanges(ctx context.Context, client *jmap.Client, accountID, sinceState string) error {
+	changes, err := client.EmailChanges(ctx, sinceState)
+	if err != nil {
+		return err
+	}
+	if changes.NewState == sinceState {
+		return nil
+	}
+
+	changed := append(changes.Created, changes.Updated...)
+	emails, err := client.GetEmails(ctx, changed)
+	if err != nil {
+		return fmt.Errorf("failed to get emails: %w", err)
+	}
+	if err := e.applyJMAPEmails(accountID, emails); err != nil {
+		return err
+	}
+
+	// Emails missing from Email/get were destroyed after /changes ran
+	returned := make(map[string]bool, len(emails))
+	for _, em := range emails {
+		returned[em.ID] = true
+	}
+	destroyed := changes.Destroyed
+	for _, id := range changed {
+		if !returned[id] {
+			destroyed = append(destroyed, id)
+		}
+	}
+	if err := e.messageStore.DeleteByRemoteIDs(accountID, destroyed); err != nil {
+		return err
+	}
+
+	e.log.Debug().
+		Str("account", accountID).
+		Int("created", len(changes.Created)).
+		Int("updated", len(changes.Updated)).
+		Int("destroyed", len(destroyed)).
+		Msg("Applied JMAP email changes")
+
+	return e.jmapStates.SetState(accountID, jmapEmailState, changes.NewState)
+}
+
+// applyJMAPEmails stores Emails as one message row per mailbox they're in.
+// Rows in mailboxes an Email has left are deleted, missing rows created
+// and the flags of the rest updated.
+func (e *Engine) applyJMAPEmails(accountID string, emails []*jmap.Email) error {
+	if len(emails) == 0 {
+		return nil
+	}
+
+	mailboxFolders, err := e.jmapMailboxFolders(accountID)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, len(emails))
+	for i, em := range emails {
+		ids[i] = em.ID
+	}
+	refs, err := e.messageStore.ListByRemoteIDs(accountID, ids)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]map[string]message.RemoteRef)
+	for _, ref := range refs {
+		if existing[ref.RemoteID] == nil {
+			existing[ref.RemoteID] = make(map[string]message.RemoteRef)
+		}
+		existing[ref.RemoteID][ref.FolderID] = ref
+	}
+
+	// Synthetic UIDs: JMAP has none, but rows are keyed by folder and UID
+	nextUIDs := make(map[string]uint32)
+	nextUID := func(folderID string) (uint32, error) {
+		uid, ok := nextUIDs[folderID]
+		if !ok {
+			highest, err := e.messageStore.GetHighestUID(folderID)
+			if err != nil {
+				return 0, err
+			}
+			uid = highest
+		}
+		uid++
+		nextUIDs[folderID] = uid
+		return uid, nil
+	}
+
+	flagUpdates := make(map[string][]message.FlagUpdate)
+	var created []*message.Message
+
+	for _, em := range emails {
+		m := jmapMessage(accountID, em)
+
+		wanted := make(map[string]bool)
+		for mailboxID, in := range em.MailboxIDs {
+			if folderID, ok := mailboxFolders[mailboxID]; ok && in {
+				wanted[folderID] = true
+			}
+		}
+
+		for folderID, ref := range existing[em.ID] {
+			if !wanted[folderID] {
+				if err := e.messageStore.Delete(ref.ID); err != nil {
+					e.log.Warn().Err(err).Str("messageId", ref.ID).Msg("Failed to delete message")
+				}
+				continue
+			}
+			if ref.UID == 0 {
+				// Left by a local move; give it a real UID so it survives
+				// the temporary row cleanup
+				uid, err := nextUID(folderID)
+				if err != nil {
+					return err
+				}
+				if err := e.messageStore.SetRemoteID(ref.ID, uid, em.ID); err != nil {
+					return err
+				}
+				ref.UID = uid
+			}
+			flagUpdates[folderID] = append(flagUpdates[folderID], message.FlagUpdate{
+				UID:         ref.UID,
+				IsRead:      m.IsRead,
+				IsStarred:   m.IsStarred,
+				IsAnswered:  m.IsAnswered,
+				IsForwarded: m.IsForwarded,
+				IsDraft:     m.IsDraft,
+				IsDeleted:   m.IsDeleted,
+				Tags:        m.Tags,
+			})
+		}
+
+		for folderID := range wanted {
+			if _, ok := existing[em.ID][folderID]; ok {
+				continue
+			}
+			uid, err := nextUID(folderID)
+			if err != nil {
+				return err
+			}
+			row := *m
+			row.FolderID = folderID
+			row.UID = uid
+			if err := e.messageStore.Create(&row); err != nil {
+				e.log.Warn().Err(err).Str("emailId", em.ID).Msg("Failed to save message header")
+				continue
+			}
+			if err := e.messageStore.SetRemoteID(row.ID, uid, em.ID); err != nil {
+				return err
+			}
+			created = append(created, &row)
+		}
+	}
+
+	for folderID, updates := range flagUpdates {
+		if err := e.messageStore.UpdateFlagsByUIDBatch(folderID, updates); err != nil {
+			e.log.Warn().Err(err).Str("folder", folderID).Msg("Failed to update message flags")
+		}
+	}
+
+	for _, m := range created {
+		if err := e.messageStore.ReconcileThreadsForNewMessage(accountID, m.ID, m.MessageID, m.ThreadID, m.InReplyTo); err != nil {
+			e.log.Warn().Err(err).Str("messageId", m.ID).Msg("Failed to reconcile threads")
+		}
+	}
+
+	return nil
+}
+
+// jmapMailboxFolders maps mailbox IDs to local folder IDs
+func (e *Engine) jmapMailboxFolders(accountID string) (map[string]string, error) {
+	remoteIDs, err := e.folderStore.ListRemoteIDs(accountID)
+	if err != nil {
+		return nil, err
+	}
+	folders := make(map[string]string, len(remoteIDs))
+	for folderID, mailboxID := range remoteIDs {
+		folders[mailboxID] = folderID
+	}
+	return folders, nil
+}
+
+// resetJMAPSync forgets the Email state and marks every folder unsynced, so
+// each is listed in full on its next sync
+func (e *Engine) resetJMAPSync(accountID string) error {
+	if err := e.jmapStates.DeleteState(accountID, jmapEmailState); err != nil {
+		return err
+	}
+	folders, err := e.folderStore.List(accountID)
+	if err != nil {
+		return fmt.Errorf("failed to list folders: %w", err)
+	}
+	for _, f := range folders {
+		if f.LastSync == nil {
+			continue
+		}
+		f.LastSync = nil
+		if err := e.folderStore.Update(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jmapMessage builds a message header row from an Email. Folder and UID
+// are filled in by the caller.
+func jmapMessage(accountID string, em *jmap.Email) *message.Message {
+	m := &message.Message{
+		AccountID:      accountID,
+		Subject:        em.Subject,
+		ThreadID:       message.JMAPThreadID(em.ThreadID),
+		Date:           em.ReceivedAt.UTC(),
+		ReceivedAt:     em.ReceivedAt.UTC(),
+		Size:           em.Size,
+		HasAttachments: em.HasAttachment,
+		Snippet:        em.Preview,
+	}
+	if em.SentAt != nil {
+		m.Date = em.SentAt.UTC()
+	}
+	if len(em.MessageID) > 0 {
+		m.MessageID = em.MessageID[0]
+	}
+	if len(em.InReplyTo) > 0 {
+		m.InReplyTo = em.InReplyTo[0]
+	}
+
+	// Stored with angle brackets, like References parsed from IMAP headers
+	if len(em.References) > 0 {
+		refs := make([]string, len(em.References))
+		for i, ref := range em.References {
+			refs[i] = "<" + ref + ">"
+		}
+		refsJSON, _ := json.Marshal(refs)
+		m.References = string(refsJSON)
+	}
+
+	if len(em.From) > 0 {
+		m.FromName = em.From[0].Name
+		m.FromEmail = em.From[0].Email
+	}
+	if len(em.To) > 0 {
+		m.ToList = addressListToJSON(jmapAddresses(em.To))
+	}
+	if len(em.Cc) > 0 {
+		m.CcList = addressListToJSON(jmapAddresses(em.Cc))
+	}
+	if len(em.Bcc) > 0 {
+		m.BccList = addressListToJSON(jmapAddresses(em.Bcc))
+	}
+	if len(em.ReplyTo) > 0 {
+		m.ReplyTo = em.ReplyTo[0].Email
+	}
+
+	applyFlagsToMessage(m, jmapFlags(em))
+
+	return m
+}
+
+// jmapAddresses converts JMAP addresses for addressListToJSON
+func jmapAddresses(addrs []jmap.EmailAddress) []imap.Address {
+	list := make([]imap.Address, len(addrs))
+	for i, a := range addrs {
+		mailbox, host, _ := strings.Cut(a.Email, "@")
+		list[i] = imap.Address{Name: a.Name, Mailbox: mailbox, Host: host}
+	}
+	return list
+}
+
+// jmapFlags maps an Email's keywords to the IMAP flags they stand for
+func jmapFlags(em *jmap.Email) []imap.Flag {
+	var flags []imap.Flag
+	for _, keyword := range em.SortedKeywords() {
+		switch keyword {
+		case jmap.KeywordSeen:
+			flags = append(flags, imap.FlagSeen)
+		case jmap.KeywordFlagged:
+			flags = append(flags, imap.FlagFlagged)
+		case jmap.KeywordAnswered:
+			flags = append(flags, imap.FlagAnswered)
+		case jmap.KeywordDraft:
+			flags = append(flags, imap.FlagDraft)
+		case jmap.KeywordForwarded:
+			flags = append(flags, "$Forwarded")
+		default:
+			flags = append(flags, imap.Flag(keyword))
+		}
+	}
+	return flags
+}
+
+// jmapFolderType converts a JMAP mailbox role to our folder type
+func jmapFolderType(role string) folder.Type {
+	switch role {
+	case jmap.RoleInbox:
+		return folder.TypeInbox
+	case jmap.RoleSent:
+		return folder.TypeSent
+	case jmap.RoleDrafts:
+		return folder.TypeDrafts
+	case jmap.RoleTrash:
+		return folder.TypeTrash
+	case jmap.RoleJunk:
+		return folder.TypeSpam
+	case jmap.RoleArchive:
+		return folder.TypeArchive
+	case jmap.RoleAll:
+		return folder.TypeAll
+	case jmap.RoleFlagged:
+		return folder.TypeStarred
+	default:
+		return folder.TypeFolder
+	}
+}
+
+// fetchJMAPBodies downloads the bodies of messages that don't have them
+// yet, like FetchBodiesInBackground does over IMAP
+func (e *Engine) fetchJMAPBodies(ctx context.Context, accountID, folderID string, syncPeriodDays int) error {
+	var sinceDate time.Time
+	if syncPeriodDays > 0 {
+		sinceDate = time.Now().AddDate(0, 0, -syncPeriodDays)
+	}
+
+	totalWithoutBody, err := e.messageStore.CountMessagesWithoutBody(folderID, sinceDate)
+	if err != nil {
+		return fmt.Errorf("failed to count messages without body: %w", err)
+	}
+	if totalWithoutBody == 0 {
+		e.emitProgress(accountID, folderID, 1, 1, "bodies")
+		return nil
+	}
+
+	client, err := e.jmap.Client(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+
+	e.log.Info().Int("count", totalWithoutBody).Msg("Fetching message bodies (JMAP)")
+	e.emitProgress(accountID, folderID, 0, totalWithoutBody, "bodies")
+
+	// Messages that couldn't be downloaded are skipped for the rest of the run
+	skipped := make(map[string]bool)
+	fetched := 0
+
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		candidates, err := e.messageStore.GetMessagesWithoutBody(folderID, bodyBatchQueryLimit, sinceDate)
+		if err != nil {
+			return fmt.Errorf("failed to get messages without body: %w", err)
+		}
+		var batch []string
+		for _, id := range candidates {
+			if !skipped[id] && len(batch) < bodyBatchMaxMessages {
+				batch = append(batch, id)
+			}
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		emails, err := e.jmapEmailsFor(ctx, client, batch)
+		if err != nil {
+			return err
+		}
+
+		var bodyUpdates []message.BodyUpdate
+		var attachments []*message.Attachment
+		for _, id := range batch {
+			em := emails[id]
+			if em == nil {
+				skipped[id] = true
+				continue
+			}
+			raw, err := client.Download(ctx, em.BlobID, "message.eml", "message/rfc822")
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				e.log.Warn().Err(err).Str("messageID", id).Msg("Failed to download message")
+				skipped[id] = true
+				continue
+			}
+
+			pb := e.processRawBody(id, raw)
+			bodyUpdates = append(bodyUpdates, message.BodyUpdate{
+				MessageID:      pb.MessageID,
+				BodyHTML:       pb.BodyHTML,
+				BodyText:       pb.BodyText,
+				Snippet:        pb.Snippet,
+				SMIMERawBody:   pb.SMIMERawBody,
+				SMIMEEncrypted: pb.SMIMEEncrypted,
+				PGPRawBody:     pb.PGPRawBody,
+				PGPEncrypted:   pb.PGPEncrypted,
+			})
+			attachments = append(attachments, pb.Attachments...)
+		}
+
+		if err := e.messageStore.UpdateBodiesBatch(bodyUpdates); err != nil {
+			return err
+		}
+		if len(attachments) > 0 {
+			if err := e.attachmentStore.CreateBatch(attachments); err != nil {
+				e.log.Warn().Err(err).Msg("Failed to batch create attachments")
+			}
+		}
+
+		fetched += len(bodyUpdates)
+		e.emitProgress(accountID, folderID, fetched, totalWithoutBody, "bodies")
+	}
+
+	e.log.Info().
+		Int("fetched", fetched).
+		Int("failed", len(skipped)).
+		Int("total", totalWithoutBody).
+		Msg("Body fetch complete (JMAP)")
+
+	return nil
+}
+
+// fetchJMAPMessageBody downloads and stores the body of one message
+func (e *Engine) fetchJMAPMessageBody(ctx context.Context, accountID, messageID string) (*message.Message, error) {
+	client, err := e.jmap.Client(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	raw, err := e.downloadJMAPMessage(ctx, client, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := e.processRawBody(messageID, raw)
+	if err := e.messageStore.UpdateBody(messageID, result.BodyHTML, result.BodyText, result.Snippet); err != nil {
+		return nil, fmt.Errorf("failed to update message body: %w", err)
+	}
+
+	if result.HasAttachments && e.attachmentStore != nil {
+		for _, att := range result.Attachments {
+			if err := e.attachmentStore.Create(att); err != nil {
+				e.log.Debug().Err(err).Str("filename", att.Filename).Msg("Failed to save attachment metadata")
+			}
+		}
+	}
+
+	return e.messageStore.Get(messageID)
+}
+
+// fetchJMAPRawMessage returns the raw RFC822 content of the message at
+// folderID/uid
+func (e *Engine) fetchJMAPRawMessage(ctx context.Context, accountID, folderID string, uid uint32) ([]byte, error) {
+	m, err := e.messageStore.GetByUID(folderID, uid)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, fmt.Errorf("message not found: UID %d", uid)
+	}
+
+	client, err := e.jmap.Client(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	return e.downloadJMAPMessage(ctx, client, m.ID)
+}
+
+// downloadJMAPMessage downloads the raw blob of a stored message
+func (e *Engine) downloadJMAPMessage(ctx context.Context, client *jmap.Client, messageID string) ([]byte, error) {
+	emails, err := e.jmapEmailsFor(ctx, client, []string{messageID})
+	if err != nil {
+		return nil, err
+	}
+	em := emails[messageID]
+	if em == nil {
+		return nil, fmt.Errorf("message not found on server")
+	}
+	return client.Download(ctx, em.BlobID, "message.eml", "message/rfc822")
+}
+
+// jmapEmailsFor looks up the Emails behind local messages, keyed by message
+// ID. Messages without a server-side copy are omitted.
+func (e *Engine) jmapEmailsFor(ctx context.Context, client *jmap.Client, messageIDs []string) (map[string]*jmap.Email, error) {
+	remoteIDs, err := e.messageStore.GetRemoteIDs(messageIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(remoteIDs) == 0 {
+		return map[string]*jmap.Email{}, nil
+	}
+
+	ids := make([]string, 0, len(remoteIDs))
+	seen := make(map[string]bool)
+	for _, remoteID := range remoteIDs {
+		if !seen[remoteID] {
+			seen[remoteID] = true
+			ids = append(ids, remoteID)
+		}
+	}
+	emails, err := client.GetEmails(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get emails: %w", err)
+	}
+
+	byRemoteID := make(map[string]*jmap.Email, len(emails))
+	for _, em := range emails {
+		byRemoteID[em.ID] = em
+	}
+	result := make(map[string]*jmap.Email, len(remoteIDs))
+	for messageID, remoteID := range remoteIDs {
+		if em := byRemoteID[remoteID]; em != nil {
+			result[messageID] = em
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,379 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	netmail "net/mail"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-imap/v2"
+	gomessage "github.com/emersion/go-message"
+	"github.com/emersion/go-message/mail"
+	"github.com/hkdb/aerion/internal/folder"
+	"github.com/hkdb/aerion/internal/message"
+)
+
+// errLocalUnsupported is returned by server-only operations on accounts
+// whose folders exist only locally
+var errLocalUnsupported = errors.New("not supported for local folders")
+
+// defaultLocalFolders are the folders every local-folder account starts with
+var defaultLocalFolders = []struct {
+	Name string
+	Type folder.Type
+}{
+	{"INBOX", folder.TypeInbox},
+	{"Sent", folder.TypeSent},
+	{"Drafts", folder.TypeDrafts},
+	{"Trash", folder.TypeTrash},
+	{"Archive", folder.TypeArchive},
+	{"Junk", folder.TypeSpam},
+}
+
+// hasLocalFolders reports whether an account's folders live only in the
+// local database, with no server copy to sync against
+func (e *Engine) hasLocalFolders(accountID string) bool {
+	acc, err := e.accountStore.Get(accountID)
+	return err == nil && acc != nil && acc.HasLocalFolders()
+}
+
+// syncLocalFolders makes sure the standard folders exist and refreshes the
+// counts of every folder of a local-folder account. Accounts kept in a
+// Maildir tree also pick up folders created there, and get a directory for
+// each of their folders.
+func (e *Engine) syncLocalFolders(accountID string) error {
+	existing, err := e.folderStore.List(accountID)
+	if err != nil {
+		return fmt.Errorf("failed to list local folders: %w", err)
+	}
+
+	tree, hasTree := e.maildirTree(accountID)
+	if hasTree {
+		if err := tree.Check(); err != nil {
+			return err
+		}
+		added, err := e.addMaildirFolders(accountID, tree, existing)
+		if err != nil {
+			return err
+		}
+		existing = append(existing, added...)
+	}
+
+	byType := make(map[folder.Type]bool)
+	byPath := make(map[string]bool)
+	for _, f := range existing {
+		byType[f.Type] = true
+		byPath[f.Path] = true
+	}
+
+	for _, lf := range defaultLocalFolders {
+		if byType[lf.Type] || byPath[lf.Name] {
+			continue
+		}
+		f := &folder.Folder{
+			AccountID:  accountID,
+			Name:       lf.Name,
+			Path:       lf.Name,
+			Type:       lf.Type,
+			Subscribed: true,
+		}
+		if err := e.folderStore.Create(f); err != nil {
+			return err
+		}
+		existing = append(existing, f)
+	}
+
+	if hasTree {
+		for _, f := range existing {
+			dir, err := tree.Folder(f.Path)
+			if err == nil {
+				err = dir.Create()
+			}
+			if err != nil {
+				e.log.Warn().Err(err).Str("path", f.Path).Msg("Failed to create Maildir folder")
+			}
+		}
+	}
+
+	for _, f := range existing {
+		if err := e.recountLocalFolder(f); err != nil {
+			e.log.Warn().Err(err).Str("path", f.Path).Msg("Failed to update folder counts")
+		}
+	}
+
+	return nil
+}
+
+// recountLocalFolder recomputes a folder's counts from the messages stored
+// in it and marks it as synced
+func (e *Engine) recountLocalFolder(f *folder.Folder) error {
+	total, err := e.messageStore.CountByFolder(f.ID)
+	if err != nil {
+		return err
+	}
+	unread, err := e.messageStore.CountUnreadByFolder(f.ID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	f.TotalCount = total
+	f.UnreadCount = unread
+	f.LastSync = &now
+	return e.folderStore.Update(f)
+}
+
+// syncLocalMessages brings a local folder up to date. A POP3 INBOX
+// downloads new mail and folders kept in a Maildir tree are rescanned;
+// other folders are just recounted.
+func (e *Engine) syncLocalMessages(ctx context.Context, accountID, folderID string) error {
+	f, err := e.folderStore.Get(folderID)
+	if err != nil {
+		return fmt.Errorf("failed to get folder: %w", err)
+	}
+	if f == nil {
+		return fmt.Errorf("folder not found: %s", folderID)
+	}
+
+	acc, err := e.accountStore.Get(accountID)
+	if err != nil {
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+
+	if acc != nil && acc.IsPOP3() && f.Type == folder.TypeInbox {
+		if err := e.syncPOP3Inbox(ctx, acc, f); err != nil {
+			return err
+		}
+	}
+	if tree, ok := e.maildirTree(accountID); ok {
+		if err := tree.Check(); err != nil {
+			return err
+		}
+		if err := e.syncMaildirFolder(ctx, acc, f, tree); err != nil {
+			return err
+		}
+	}
+
+	if err := e.recountLocalFolder(f); err != nil {
+		return fmt.Errorf("failed to update folder counts: %w", err)
+	}
+
+	e.emitProgress(accountID, folderID, 1, 1, "headers")
+	return nil
+}
+
+// fetchLocalMessageBody parses a message's body from its stored source
+func (e *Engine) fetchLocalMessageBody(messageID string) (*message.Message, error) {
+	m, err := e.messageStore.Get(messageID)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil || m.BodyFetched {
+		return m, nil
+	}
+
+	raw, err := e.readLocalRaw(m)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		// Nothing to parse; return what we have
+		return m, nil
+	}
+
+	if err := e.storeLocalBody(messageID, raw); err != nil {
+		return nil, err
+	}
+	return e.messageStore.Get(messageID)
+}
+
+// fetchLocalRawMessage returns the stored source of the message at
+// folderID/uid
+func (e *Engine) fetchLocalRawMessage(folderID string, uid uint32) ([]byte, error) {
+	m, err := e.messageStore.GetByUID(folderID, uid)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, fmt.Errorf("message not found: UID %d", uid)
+	}
+
+	raw, err := e.readLocalRaw(m)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("message source not found: UID %d", uid)
+	}
+	return raw, nil
+}
+
+// StoreLocalMessage saves a raw RFC822 message into a local folder, with
+// its headers, body, attachments and source, and threads it. The message
+// gets the next free UID in the folder. On accounts kept in a Maildir tree
+// the source is written to the folder's directory.
+func (e *Engine) StoreLocalMessage(accountID, folderID string, raw []byte, flags []imap.Flag) (*message.Message, error) {
+	var key string
+	if tree, ok := e.maildirTree(accountID); ok {
+		dir, err := e.maildirFolder(tree, folderID)
+		if err != nil {
+			return nil, err
+		}
+		if err := dir.Create(); err != nil {
+			return nil, err
+		}
+		if key, err = dir.Deliver(raw, maildirFlagsFromIMAP(flags)); err != nil {
+			return nil, err
+		}
+	}
+	return e.storeLocalMessage(accountID, folderID, raw, flags, key)
+}
+
+// storeLocalMessage saves a message into a local folder. maildirKey names
+// its file in a Maildir tree, which then holds the source instead of the
+// database.
+func (e *Engine) storeLocalMessage(accountID, folderID string, raw []byte, flags []imap.Flag, maildirKey string) (*message.Message, error) {
+	m, err := e.parseLocalHeaders(raw)
+	if err != nil {
+		return nil, err
+	}
+	m.AccountID = accountID
+	m.FolderID = folderID
+	applyFlagsToMessage(m, flags)
+
+	uid, err := e.messageStore.GetHighestUID(folderID)
+	if err != nil {
+		return nil, err
+	}
+	m.UID = uid + 1
+
+	if err := e.messageStore.Create(m); err != nil {
+		return nil, err
+	}
+	if maildirKey != "" {
+		err = e.messageStore.SetRemoteID(m.ID, m.UID, maildirKey)
+	} else {
+		err = e.messageStore.SaveRaw(m.ID, raw)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := e.storeLocalBody(m.ID, raw); err != nil {
+		return nil, err
+	}
+
+	threadID := e.computeThreadID(accountID, m)
+	if threadID != "" && threadID != m.ThreadID {
+		m.ThreadID = threadID
+		if err := e.messageStore.UpdateThreadID(m.ID, threadID); err != nil {
+			e.log.Warn().Err(err).Str("messageId", m.ID).Msg("Failed to update thread ID")
+		}
+	}
+	if err := e.messageStore.ReconcileThreadsForNewMessage(accountID, m.ID, m.MessageID, m.ThreadID, m.InReplyTo); err != nil {
+		e.log.Warn().Err(err).Str("messageId", m.ID).Msg("Failed to reconcile threads")
+	}
+
+	return m, nil
+}
+
+// storeLocalBody parses a raw message and stores its body and attachments
+func (e *Engine) storeLocalBody(messageID string, raw []byte) error {
+	result := e.processRawBody(messageID, raw)
+
+	update := message.BodyUpdate{
+		MessageID:      messageID,
+		BodyHTML:       result.BodyHTML,
+		BodyText:       result.BodyText,
+		Snippet:        result.Snippet,
+		SMIMERawBody:   result.SMIMERawBody,
+		SMIMEEncrypted: result.SMIMEEncrypted,
+		PGPRawBody:     result.PGPRawBody,
+		PGPEncrypted:   result.PGPEncrypted,
+	}
+	if err := e.messageStore.UpdateBodiesBatch([]message.BodyUpdate{update}); err != nil {
+		return fmt.Errorf("failed to update message body: %w", err)
+	}
+
+	if len(result.Attachments) > 0 && e.attachmentStore != nil {
+		if err := e.attachmentStore.CreateBatch(result.Attachments); err != nil {
+			e.log.Debug().Err(err).Str("messageId", messageID).Msg("Failed to save attachment metadata")
+		}
+	}
+	return nil
+}
+
+// parseLocalHeaders builds a message from the header of a raw message, the
+// way an IMAP envelope would describe it
+func (e *Engine) parseLocalHeaders(raw []byte) (*message.Message, error) {
+	entity, err := gomessage.Read(bytes.NewReader(raw))
+	if entity == nil {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+	h := mail.Header{Header: entity.Header}
+
+	m := &message.Message{
+		Subject:    decodeMIMEWord(h.Get("Subject")),
+		ReceivedAt: time.Now().UTC(),
+		Size:       len(raw),
+	}
+
+	m.MessageID, _ = h.MessageID()
+	if inReplyTo, err := h.MsgIDList("In-Reply-To"); err == nil && len(inReplyTo) > 0 {
+		m.InReplyTo = inReplyTo[0]
+	}
+	if date, err := h.Date(); err == nil && !date.IsZero() {
+		m.Date = date.UTC()
+	} else {
+		m.Date = m.ReceivedAt
+	}
+
+	if from := parseHeaderAddresses(h.Get("From")); len(from) > 0 {
+		m.FromName = from[0].Name
+		m.FromEmail = from[0].Addr()
+	}
+	if to := parseHeaderAddresses(h.Get("To")); len(to) > 0 {
+		m.ToList = addressListToJSON(to)
+	}
+	if cc := parseHeaderAddresses(h.Get("Cc")); len(cc) > 0 {
+		m.CcList = addressListToJSON(cc)
+	}
+	if bcc := parseHeaderAddresses(h.Get("Bcc")); len(bcc) > 0 {
+		m.BccList = addressListToJSON(bcc)
+	}
+	if replyTo := parseHeaderAddresses(h.Get("Reply-To")); len(replyTo) > 0 {
+		m.ReplyTo = replyTo[0].Addr()
+	}
+
+	if references := e.extractReferences(raw); len(references) > 0 {
+		refsJSON, _ := json.Marshal(references)
+		m.References = string(refsJSON)
+	}
+	m.ReadReceiptTo = e.extractDispositionNotificationTo(raw)
+
+	return m, nil
+}
+
+// parseHeaderAddresses parses an address header, decoding encoded display
+// names. Malformed headers yield no addresses rather than an error.
+func parseHeaderAddresses(value string) []imap.Address {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+
+	parser := netmail.AddressParser{WordDecoder: newWordDecoder()}
+	list, err := parser.ParseList(value)
+	if err != nil {
+		return nil
+	}
+
+	addrs := make([]imap.Address, 0, len(list))
+	for _, a := range list {
+		mailbox, host, _ := strings.Cut(a.Address, "@")
+		addrs = append(addrs, imap.Address{Name: a.Name, Mailbox: mailbox, Host: host})
+	}
+	return addrs
+}
@@ -0,0 +1,609 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-imap/v2"
+	"github.com/hkdb/aerion/internal/account"
+	"github.com/hkdb/aerion/internal/folder"
+	"github.com/hkdb/aerion/internal/maildir"
+	"github.com/hkdb/aerion/internal/message"
+)
+
+// A local folders account with a Maildir path keeps every folder as a
+// Maildir directory and every message as a file in it. The database mirrors
+// the tree: each message row records the file's unique name (its key) as
+// its remote ID, and a sync picks up whatever other Maildir tools changed.
+
+// maildirTree returns the Maildir tree of an account, if it keeps one
+func (e *Engine) maildirTree(accountID string) (maildir.Tree, bool) {
+	acc, err := e.accountStore.Get(accountID)
+	if err != nil || acc == nil || !acc.IsLocal() || acc.MaildirPath == "" {
+		return "", false
+	}
+	return maildir.Tree(acc.MaildirPath), true
+}
+
+// maildirFolder returns the Maildir directory of a folder
+func (e *Engine) maildirFolder(tree maildir.Tree, folderID string) (maildir.Dir, error) {
+	f, err := e.folderStore.Get(folderID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get folder: %w", err)
+	}
+	if f == nil {
+		return "", fmt.Errorf("folder not found: %s", folderID)
+	}
+	return tree.Folder(f.Path)
+}
+
+// maildirIndexes lists the Maildir folders of a batch of messages once
+// each, rather than once per message
+type maildirIndexes struct {
+	e        *Engine
+	tree     maildir.Tree
+	byFolder map[string]*maildir.Index
+}
+
+func (e *Engine) maildirIndexes(tree maildir.Tree) *maildirIndexes {
+	return &maildirIndexes{e: e, tree: tree, byFolder: make(map[string]*maildir.Index)}
+}
+
+// get returns the index of a folder, listing it on first use
+func (x *maildirIndexes) get(folderID string) (*maildir.Index, error) {
+	if idx, ok := x.byFolder[folderID]; ok {
+		return idx, nil
+	}
+	dir, err := x.e.maildirFolder(x.tree, folderID)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := dir.Index()
+	if err != nil {
+		return nil, err
+	}
+	x.byFolder[folderID] = idx
+	return idx, nil
+}
+
+// addMaildirFolders creates a folder for every Maildir folder in the tree
+// that isn't known yet, along with any missing parents. Top-level folders
+// named like the standard folders take their role.
+func (e *Engine) addMaildirFolders(accountID string, tree maildir.Tree, existing []*folder.Folder) ([]*folder.Folder, error) {
+	paths, err := tree.Folders()
+	if err != nil {
+		return nil, err
+	}
+
+	byPath := make(map[string]*folder.Folder)
+	byType := make(map[folder.Type]bool)
+	for _, f := range existing {
+		byPath[f.Path] = f
+		byType[f.Type] = true
+	}
+
+	var added []*folder.Folder
+	var add func(p string) (*folder.Folder, error)
+	add = func(p string) (*folder.Folder, error) {
+		if f := byPath[p]; f != nil {
+			return f, nil
+		}
+
+		f := &folder.Folder{
+			AccountID:  accountID,
+			Name:       path.Base(p),
+			Path:       p,
+			Type:       folder.TypeFolder,
+			Subscribed: true,
+		}
+		if parentPath := path.Dir(p); parentPath != "." {
+			parent, err := add(parentPath)
+			if err != nil {
+				return nil, err
+			}
+			f.ParentID = parent.ID
+		} else {
+			for _, lf := range defaultLocalFolders {
+				if strings.EqualFold(p, lf.Name) && !byType[lf.Type] {
+					f.Type = lf.Type
+					byType[lf.Type] = true
+					break
+				}
+			}
+		}
+
+		if err := e.folderStore.Create(f); err != nil {
+			return nil, err
+		}
+		byPath[p] = f
+		added = append(added, f)
+		return f, nil
+	}
+
+	for _, p := range paths {
+		if _, err := add(p); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(added) > 0 {
+		e.log.Info().Str("account", accountID).Int("count", len(added)).Msg("Added folders from Maildir tree")
+	}
+	return added, nil
+}
+
+// syncMaildirFolder brings a folder in line with its Maildir directory:
+// new files are imported, rows whose file is gone are deleted, and flags
+// changed by other tools are picked up. Messages stored before the account
+// had a Maildir tree are written out to it.
+func (e *Engine) syncMaildirFolder(ctx context.Context, acc *account.Account, f *folder.Folder, tree maildir.Tree) error {
+	dir, err := tree.Folder(f.Path)
+	if err != nil {
+		return err
+	}
+	if err := dir.Create(); err != nil {
+		return err
+	}
+
+	refs, err := e.messageStore.ListRemoteRefsByFolder(f.ID, time.Time{})
+	if err != nil {
+		return err
+	}
+	flags, err := e.messageStore.ListFlagsByFolder(f.ID)
+	if err != nil {
+		return err
+	}
+
+	byKey := make(map[string]message.RemoteRef, len(refs))
+	for _, ref := range refs {
+		byKey[ref.RemoteID] = ref
+	}
+
+	if err := e.exportToMaildir(dir, flags, byKey); err != nil {
+		return err
+	}
+
+	idx, err := dir.Index()
+	if err != nil {
+		return err
+	}
+	entries := idx.Entries()
+
+	var added []maildir.Entry
+	onDisk := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		onDisk[entry.Key] = true
+
+		ref, ok := byKey[entry.Key]
+		if !ok {
+			added = append(added, entry)
+			continue
+		}
+
+		want := flagsFromMaildir(entry.Flags)
+		have := flags[ref.ID]
+		if want.IsRead != have.IsRead || want.IsStarred != have.IsStarred ||
+			want.IsAnswered != have.IsAnswered || want.IsForwarded != have.IsForwarded ||
+			want.IsDraft != have.IsDraft || want.IsDeleted != have.IsDeleted {
+			if err := e.messageStore.UpdateFlags(ref.ID, want.IsRead, want.IsStarred, want.IsAnswered, want.IsForwarded, want.IsDraft, want.IsDeleted); err != nil {
+				e.log.Warn().Err(err).Str("key", entry.Key).Msg("Failed to update flags from Maildir")
+			}
+		}
+		if entry.New {
+			if err := idx.SetFlags(entry.Key, entry.Flags); err != nil {
+				e.log.Warn().Err(err).Str("key", entry.Key).Msg("Failed to move Maildir file to cur")
+			}
+		}
+	}
+
+	var gone []string
+	for key, ref := range byKey {
+		if !onDisk[key] {
+			gone = append(gone, ref.ID)
+		}
+	}
+	if err := e.messageStore.DeleteBatch(gone); err != nil {
+		return err
+	}
+
+	e.emitProgress(acc.ID, f.ID, 0, len(added), "headers")
+	for i, entry := range added {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		raw, err := idx.Read(entry.Key)
+		if err != nil {
+			e.log.Warn().Err(err).Str("key", entry.Key).Msg("Failed to read Maildir file")
+			continue
+		}
+		if _, err := e.storeLocalMessage(acc.ID, f.ID, raw, imapFlagsFromMaildir(entry.Flags), entry.Key); err != nil {
+			e.log.Warn().Err(err).Str("key", entry.Key).Msg("Failed to import Maildir file")
+			continue
+		}
+		if entry.New {
+			if err := idx.SetFlags(entry.Key, entry.Flags); err != nil {
+				e.log.Warn().Err(err).Str("key", entry.Key).Msg("Failed to move Maildir file to cur")
+			}
+		}
+		e.emitProgress(acc.ID, f.ID, i+1, len(added), "headers")
+	}
+
+	if len(added) > 0 || len(gone) > 0 {
+		e.log.Info().
+			Str("account", acc.ID).
+			Str("folder", f.Path).
+			Int("imported", len(added)).
+			Int("removed", len(gone)).
+			Msg("Synced Maildir folder")
+	}
+	return nil
+}
+
+// exportToMaildir writes the messages of a folder that have no Maildir file
+// yet, such as those stored before the account had a Maildir path
+func (e *Engine) exportToMaildir(dir maildir.Dir, flags map[string]message.FlagUpdate, byKey map[string]message.RemoteRef) error {
+	withKey := make(map[string]bool, len(byKey))
+	for _, ref := range byKey {
+		withKey[ref.ID] = true
+	}
+
+	for id, f := range flags {
+		if withKey[id] {
+			continue
+		}
+		raw, err := e.messageStore.GetRaw(id)
+		if err != nil {
+			return err
+		}
+		if raw == nil {
+			continue
+		}
+
+		key, err := dir.Deliver(raw, maildirFlagsFor(f))
+		if err != nil {
+			return err
+		}
+		if err := e.messageStore.SetRemoteID(id, f.UID, key); err != nil {
+			return err
+		}
+		byKey[key] = message.RemoteRef{ID: id, UID: f.UID, RemoteID: key}
+	}
+	return nil
+}
+
+// readLocalRaw returns the source of a local message: its Maildir file if
+// it has one, or the copy in the database
+func (e *Engine) readLocalRaw(m *message.Message) ([]byte, error) {
+	if tree, ok := e.maildirTree(m.AccountID); ok {
+		keys, err := e.messageStore.GetRemoteIDs([]string{m.ID})
+		if err != nil {
+			return nil, err
+		}
+		if key := keys[m.ID]; key != "" {
+			dir, err := e.maildirFolder(tree, m.FolderID)
+			if err != nil {
+				return nil, err
+			}
+			raw, err := dir.Read(key)
+			if err == nil {
+				return raw, nil
+			}
+			if !errors.Is(err, maildir.ErrNotFound) {
+				return nil, err
+			}
+		}
+	}
+	return e.messageStore.GetRaw(m.ID)
+}
+
+// ============================================================================
+// Write-through from local changes
+// ============================================================================
+
+// maildirKeys returns the messages that have Maildir files, with their keys
+func (e *Engine) maildirKeys(messageIDs []string) ([]*message.Message, map[string]string, error) {
+	messages, err := e.messageStore.GetByIDs(messageIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	keys, err := e.messageStore.GetRemoteIDs(messageIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return messages, keys, nil
+}
+
+// WriteMaildirFlags renames the Maildir files of messages to match their
+// flags in the database
+func (e *Engine) WriteMaildirFlags(messageIDs []string) error {
+	if len(messageIDs) == 0 {
+		return nil
+	}
+	messages, keys, err := e.maildirKeys(messageIDs)
+	if err != nil || len(messages) == 0 {
+		return err
+	}
+	tree, ok := e.maildirTree(messages[0].AccountID)
+	if !ok {
+		return nil
+	}
+
+	indexes := e.maildirIndexes(tree)
+	for _, m := range messages {
+		key := keys[m.ID]
+		if key == "" {
+			continue
+		}
+		idx, err := indexes.get(m.FolderID)
+		if err != nil {
+			return err
+		}
+		f := message.FlagUpdate{
+			IsRead:      m.IsRead,
+			IsStarred:   m.IsStarred,
+			IsAnswered:  m.IsAnswered,
+			IsForwarded: m.IsForwarded,
+			IsDraft:     m.IsDraft,
+			IsDeleted:   m.IsDeleted,
+		}
+		if err := idx.SetFlags(key, maildirFlagsFor(f)); err != nil && !errors.Is(err, maildir.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// MoveMaildirMessages moves the Maildir files of messages into another
+// folder. Call it before moving the messages in the database.
+func (e *Engine) MoveMaildirMessages(messageIDs []string, destFolderID string) error {
+	if len(messageIDs) == 0 {
+		return nil
+	}
+	messages, keys, err := e.maildirKeys(messageIDs)
+	if err != nil || len(messages) == 0 {
+		return err
+	}
+	tree, ok := e.maildirTree(messages[0].AccountID)
+	if !ok {
+		return nil
+	}
+	dest, err := e.maildirFolder(tree, destFolderID)
+	if err != nil {
+		return err
+	}
+	if err := dest.Create(); err != nil {
+		return err
+	}
+
+	indexes := e.maildirIndexes(tree)
+	for _, m := range messages {
+		key := keys[m.ID]
+		if key == "" || m.FolderID == destFolderID {
+			continue
+		}
+		src, err := indexes.get(m.FolderID)
+		if err != nil {
+			return err
+		}
+		newKey, err := src.MoveTo(key, dest)
+		if errors.Is(err, maildir.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := e.messageStore.SetRemoteID(m.ID, m.UID, newKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CopyMaildirMessages copies the Maildir files of messages for the copies
+// made of them in the database. copyIDs lists the copies in the same order
+// as sourceIDs.
+func (e *Engine) CopyMaildirMessages(sourceIDs, copyIDs []string) error {
+	if len(sourceIDs) == 0 || len(sourceIDs) != len(copyIDs) {
+		return nil
+	}
+	messages, keys, err := e.maildirKeys(sourceIDs)
+	if err != nil || len(messages) == 0 {
+		return err
+	}
+	tree, ok := e.maildirTree(messages[0].AccountID)
+	if !ok {
+		return nil
+	}
+	copies, err := e.messageStore.GetMessageUIDsAndFolder(copyIDs)
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[string]*message.Message, len(messages))
+	for _, m := range messages {
+		byID[m.ID] = m
+	}
+
+	indexes := e.maildirIndexes(tree)
+	for i, id := range sourceIDs {
+		m, key := byID[id], keys[id]
+		info, ok := copies[copyIDs[i]]
+		if m == nil || key == "" || !ok {
+			continue
+		}
+
+		src, err := indexes.get(m.FolderID)
+		if err != nil {
+			return err
+		}
+		dest, err := e.maildirFolder(tree, info.FolderID)
+		if err != nil {
+			return err
+		}
+		if err := dest.Create(); err != nil {
+			return err
+		}
+
+		newKey, err := src.CopyTo(key, dest)
+		if errors.Is(err, maildir.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := e.messageStore.SetRemoteID(copyIDs[i], info.UID, newKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveMaildirMessages deletes the Maildir files of messages. Call it
+// before deleting the messages from the database.
+func (e *Engine) RemoveMaildirMessages(messageIDs []string) error {
+	if len(messageIDs) == 0 {
+		return nil
+	}
+	messages, keys, err := e.maildirKeys(messageIDs)
+	if err != nil || len(messages) == 0 {
+		return err
+	}
+	tree, ok := e.maildirTree(messages[0].AccountID)
+	if !ok {
+		return nil
+	}
+
+	indexes := e.maildirIndexes(tree)
+	for _, m := range messages {
+		key := keys[m.ID]
+		if key == "" {
+			continue
+		}
+		idx, err := indexes.get(m.FolderID)
+		if err != nil {
+			return err
+		}
+		if err := idx.Remove(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateMaildirFolder creates the Maildir directory of a new folder
+func (e *Engine) CreateMaildirFolder(accountID, folderPath string) error {
+	tree, ok := e.maildirTree(accountID)
+	if !ok {
+		return nil
+	}
+	dir, err := tree.Folder(folderPath)
+	if err != nil {
+		return err
+	}
+	return dir.Create()
+}
+
+// RenameMaildirFolder renames the Maildir directory of a folder, along with
+// its subfolders
+func (e *Engine) RenameMaildirFolder(accountID, oldPath, newPath string) error {
+	tree, ok := e.maildirTree(accountID)
+	if !ok {
+		return nil
+	}
+	return tree.RenameFolder(oldPath, newPath)
+}
+
+// RemoveMaildirFolder deletes the Maildir directory of a folder, along with
+// its subfolders and messages
+func (e *Engine) RemoveMaildirFolder(accountID, folderPath string) error {
+	tree, ok := e.maildirTree(accountID)
+	if !ok {
+		return nil
+	}
+	return tree.RemoveFolder(folderPath)
+}
+
+// ============================================================================
+// Flags
+// ============================================================================
+
+// maildirFlagsFor returns the Maildir flag letters for a message's flags
+func maildirFlagsFor(f message.FlagUpdate) string {
+	var b strings.Builder
+	if f.IsDraft {
+		b.WriteByte(maildir.FlagDraft)
+	}
+	if f.IsStarred {
+		b.WriteByte(maildir.FlagFlagged)
+	}
+	if f.IsForwarded {
+		b.WriteByte(maildir.FlagPassed)
+	}
+	if f.IsAnswered {
+		b.WriteByte(maildir.FlagReplied)
+	}
+	if f.IsRead {
+		b.WriteByte(maildir.FlagSeen)
+	}
+	if f.IsDeleted {
+		b.WriteByte(maildir.FlagTrashed)
+	}
+	return b.String()
+}
+
+// flagsFromMaildir returns the message flags a Maildir file's flag letters
+// stand for
+func flagsFromMaildir(letters string) message.FlagUpdate {
+	var m message.Message
+	applyFlagsToMessage(&m, imapFlagsFromMaildir(letters))
+	return message.FlagUpdate{
+		IsRead:      m.IsRead,
+		IsStarred:   m.IsStarred,
+		IsAnswered:  m.IsAnswered,
+		IsForwarded: m.IsForwarded,
+		IsDraft:     m.IsDraft,
+		IsDeleted:   m.IsDeleted,
+	}
+}
+
+// imapFlagsFromMaildir maps Maildir flag letters to IMAP flags. Unknown
+// letters, such as Dovecot's keyword letters, are ignored.
+func imapFlagsFromMaildir(letters string) []imap.Flag {
+	var flags []imap.Flag
+	for _, c := range letters {
+		switch c {
+		case maildir.FlagDraft:
+			flags = append(flags, imap.FlagDraft)
+		case maildir.FlagFlagged:
+			flags = append(flags, imap.FlagFlagged)
+		case maildir.FlagPassed:
+			flags = append(flags, "$Forwarded")
+		case maildir.FlagReplied:
+			flags = append(flags, imap.FlagAnswered)
+		case maildir.FlagSeen:
+			flags = append(flags, imap.FlagSeen)
+		case maildir.FlagTrashed:
+			flags = append(flags, imap.FlagDeleted)
+		}
+	}
+	return flags
+}
+
+// maildirFlagsFromIMAP returns the Maildir flag letters for IMAP flags
+func maildirFlagsFromIMAP(flags []imap.Flag) string {
+	var m message.Message
+	applyFlagsToMessage(&m, flags)
+	return maildirFlagsFor(message.FlagUpdate{
+		IsRead:      m.IsRead,
+		IsStarred:   m.IsStarred,
+		IsAnswered:  m.IsAnswered,
+		IsForwarded: m.IsForwarded,
+		IsDraft:     m.IsDraft,
+		IsDeleted:   m.IsDeleted,
+	})
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/emersion/go-imap/v2"
 	"github.com/emersion/go-imap/v2/imapclient"
+	"github.com/hkdb/aerion/internal/folder"
 	imapPkg "github.com/hkdb/aerion/internal/imap"
 	"github.com/hkdb/aerion/internal/message"
 )
@@ -30,6 +31,13 @@ func (e *Engine) SyncMessages(ctx context.Context, accountID, folderID string, s
 		return ctx.Err()
 	}
 
+	if e.isJMAP(accountID) {
+		return e.syncJMAPMessages(ctx, accountID, folderID, syncPeriodDays)
+	}
+	if e.hasLocalFolders(accountID) {
+		return e.syncLocalMessages(ctx, accountID, folderID)
+	}
+
 	// Get folder from store
 	f, err := e.folderStore.Get(folderID)
 	if err != nil {
@@ -61,6 +69,11 @@ func (e *Engine) SyncMessages(ctx context.Context, accountID, folderID string, s
 		return fmt.Errorf("failed to select mailbox: %w", err)
 	}
 
+	// Servers that can't store new keywords get local-only tags
+	if err := e.folderStore.SetKeywordsAllowed(folderID, mailbox.AllowsKeywords); err != nil {
+		e.log.Warn().Err(err).Str("folder", f.Path).Msg("Failed to record keyword support")
+	}
+
 	// Get mailbox status for accurate unseen count (SELECT doesn't return this)
 	mailboxStatus, err := conn.Client().GetMailboxStatus(ctx, f.Path)
 	if err != nil {
@@ -81,6 +94,7 @@ func (e *Engine) SyncMessages(ctx context.Context, accountID, folderID string, s
 			return fmt.Errorf("failed to delete messages: %w", err)
 		}
 		f.UIDValidity = mailbox.UIDValidity
+		f.HighestModSeq = 0
 	}
 
 	// Calculate sync date cutoff
@@ -111,6 +125,16 @@ func (e *Engine) SyncMessages(ctx context.Context, accountID, folderID string, s
 		localUIDSet[uid] = true
 	}
 
+	// CONDSTORE (RFC 7162) lets us ask only for flags changed since the last
+	// HIGHESTMODSEQ we persisted, instead of re-fetching FLAGS for every UID.
+	// Only usable when we have a baseline from a previous sync of the same
+	// UIDVALIDITY epoch (HighestModSeq is reset above if the mailbox changed).
+	// Expunges are found from UIDNEXT and the message count; see
+	// fetchRemoteUIDsIncremental.
+	useCondStore := conn.Client().SupportsCondStore() &&
+		f.HighestModSeq > 0 && mailbox.HighestModSeq > 0 &&
+		f.UIDValidity == mailbox.UIDValidity
+
 	// Check context before fetching UIDs
 	if ctx.Err() != nil {
 		e.log.Debug().Msg("Header sync cancelled before fetching UIDs")
@@ -122,7 +146,9 @@ func (e *Engine) SyncMessages(ctx context.Context, accountID, folderID string, s
 
 	// Fetch UIDs from server (filtered by date if syncPeriodDays > 0)
 	var remoteUIDs []uint32
-	if syncPeriodDays > 0 {
+	if useCondStore && syncPeriodDays == 0 {
+		remoteUIDs, err = e.fetchRemoteUIDsIncremental(ctx, conn.Client().RawClient(), f, mailbox, localUIDs)
+	} else if syncPeriodDays > 0 {
 		remoteUIDs, err = e.fetchUIDsSince(ctx, conn.Client().RawClient(), sinceDate)
 	} else {
 		remoteUIDs, err = e.fetchAllUIDs(ctx, conn.Client().RawClient())
@@ -201,11 +227,26 @@ func (e *Engine) SyncMessages(ctx context.Context, accountID, folderID string, s
 		}
 	}
 
-	if len(existingUIDs) > 0 {
+	// Track whether flags are current so HIGHESTMODSEQ is only advanced
+	// once the changes it covers have actually been applied locally
+	flagsSynced := true
+	if useCondStore {
+		if mailbox.HighestModSeq != f.HighestModSeq {
+			e.log.Debug().
+				Uint64("sinceModSeq", f.HighestModSeq).
+				Uint64("highestModSeq", mailbox.HighestModSeq).
+				Msg("Syncing changed flags (CONDSTORE)")
+			if err := e.syncChangedFlags(ctx, conn.Client().RawClient(), folderID, f.HighestModSeq); err != nil {
+				e.log.Warn().Err(err).Msg("Failed to sync changed message flags")
+				flagsSynced = false
+			}
+		}
+	} else if len(existingUIDs) > 0 {
 		e.log.Debug().Int("count", len(existingUIDs)).Msg("Syncing flags for existing messages")
 		if err := e.syncMessageFlags(ctx, conn.Client().RawClient(), folderID, existingUIDs); err != nil {
 			e.log.Warn().Err(err).Msg("Failed to sync message flags")
 			// Continue with sync even if flag sync fails
+			flagsSynced = false
 		}
 	}
 
@@ -307,11 +348,36 @@ func (e *Engine) SyncMessages(ctx context.Context, accountID, folderID string, s
 		e.emitProgress(accountID, folderID, 1, 1, "headers")
 	}
 
+	// Gmail labels and threads. With CONDSTORE only existing messages whose
+	// metadata changed are fetched; label changes bump the mod-sequence, so
+	// when it hasn't moved there's nothing to fetch for them.
+	if conn.Client().HasCap(imapPkg.CapGmailExt) {
+		gmailExisting := existingUIDs
+		if f.HighestModSeq > 0 && mailbox.HighestModSeq == f.HighestModSeq {
+			gmailExisting = nil
+		}
+		var sinceModSeq uint64
+		if useCondStore {
+			sinceModSeq = f.HighestModSeq
+		}
+		if err := e.syncGmailAttrs(accountID, f, newUIDs, gmailExisting, sinceModSeq); err != nil {
+			e.log.Warn().Err(err).Str("folder", f.Path).Msg("Failed to sync Gmail labels")
+			flagsSynced = false
+		}
+		if len(deletedUIDs) > 0 {
+			if err := e.messageStore.PruneGmailLabels(accountID); err != nil {
+				e.log.Warn().Err(err).Msg("Failed to prune Gmail labels")
+			}
+		}
+	}
+
 	// Update sync state
 	now := time.Now()
 	f.UIDValidity = mailbox.UIDValidity
 	f.UIDNext = mailbox.UIDNext
-	f.HighestModSeq = mailbox.HighestModSeq
+	if flagsSynced {
+		f.HighestModSeq = mailbox.HighestModSeq
+	}
 	f.TotalCount = int(mailbox.Messages)
 	f.LastSync = &now
 
@@ -374,75 +440,217 @@ func (e *Engine) syncMessageFlags(ctx context.Context, client *imapclient.Client
 		fetchCmd := client.Fetch(uidSet, fetchOptions)
 
 		// Collect all flag updates for batch DB update
-		var flagUpdates []message.FlagUpdate
+		flagUpdates := collectFlagUpdates(fetchCmd)
 
-		for {
-			msg := fetchCmd.Next()
-			if msg == nil {
-				break
+		if err := fetchCmd.Close(); err != nil {
+			return fmt.Errorf("failed to fetch flags: %w", err)
+		}
+
+		// Batch update all flags in a single transaction
+		if len(flagUpdates) > 0 {
+			if err := e.messageStore.UpdateFlagsByUIDBatch(folderID, flagUpdates); err != nil {
+				e.log.Warn().Err(err).Int("count", len(flagUpdates)).Msg("Failed to batch update message flags")
 			}
+		}
+	}
+
+	e.log.Debug().Int("count", len(uids)).Msg("Synced message flags")
+	return nil
+}
+
+// syncChangedFlags fetches flags only for messages whose MODSEQ is greater than
+// sinceModSeq (UID FETCH 1:* (FLAGS) (CHANGEDSINCE n), RFC 7162) and applies them
+// locally. UIDs the server reports that aren't stored locally are ignored by the
+// batch update.
+func (e *Engine) syncChangedFlags(ctx context.Context, client *imapclient.Client, folderID string, sinceModSeq uint64) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 
-			// Collect the fetch data
-			var fetchedUID uint32
-			var isRead, isStarred, isAnswered, isForwarded, isDraft, isDeleted bool
+	// 1:* - a zero Stop means "*"
+	uidSet := imap.UIDSet{imap.UIDRange{Start: 1, Stop: 0}}
 
-			for {
-				item := msg.Next()
-				if item == nil {
-					break
-				}
+	fetchOptions := &imap.FetchOptions{
+		UID:          true,
+		Flags:        true,
+		ModSeq:       true,
+		ChangedSince: sinceModSeq,
+	}
+
+	fetchCmd := client.Fetch(uidSet, fetchOptions)
+	flagUpdates := collectFlagUpdates(fetchCmd)
+	if err := fetchCmd.Close(); err != nil {
+		return fmt.Errorf("failed to fetch changed flags: %w", err)
+	}
+
+	if len(flagUpdates) > 0 {
+		if err := e.messageStore.UpdateFlagsByUIDBatch(folderID, flagUpdates); err != nil {
+			return fmt.Errorf("failed to update changed flags: %w", err)
+		}
+	}
+
+	e.log.Debug().Int("changed", len(flagUpdates)).Msg("Synced changed message flags")
+	return nil
+}
+
+// collectFlagUpdates drains a FLAGS fetch into per-UID flag updates
+func collectFlagUpdates(fetchCmd *imapclient.FetchCommand) []message.FlagUpdate {
+	var flagUpdates []message.FlagUpdate
+
+	for {
+		msg := fetchCmd.Next()
+		if msg == nil {
+			break
+		}
 
-				switch data := item.(type) {
-				case imapclient.FetchItemDataUID:
-					fetchedUID = uint32(data.UID)
-				case imapclient.FetchItemDataFlags:
-					for _, flag := range data.Flags {
-						switch flag {
-						case imap.FlagSeen:
-							isRead = true
-						case imap.FlagFlagged:
-							isStarred = true
-						case imap.FlagAnswered:
-							isAnswered = true
-						case imap.FlagDraft:
-							isDraft = true
-						case imap.FlagDeleted:
-							isDeleted = true
-						case "$Forwarded", "\\Forwarded":
-							isForwarded = true
-						}
+		// Collect the fetch data
+		var fetchedUID uint32
+		var isRead, isStarred, isAnswered, isForwarded, isDraft, isDeleted bool
+		var tags []string
+
+		for {
+			item := msg.Next()
+			if item == nil {
+				break
+			}
+
+			switch data := item.(type) {
+			case imapclient.FetchItemDataUID:
+				fetchedUID = uint32(data.UID)
+			case imapclient.FetchItemDataFlags:
+				for _, flag := range data.Flags {
+					if message.IsTagKeyword(string(flag)) {
+						tags = append(tags, string(flag))
+						continue
+					}
+					switch flag {
+					case imap.FlagSeen:
+						isRead = true
+					case imap.FlagFlagged:
+						isStarred = true
+					case imap.FlagAnswered:
+						isAnswered = true
+					case imap.FlagDraft:
+						isDraft = true
+					case imap.FlagDeleted:
+						isDeleted = true
+					case "$Forwarded", "\\Forwarded":
+						isForwarded = true
 					}
 				}
 			}
+		}
 
-			// Collect flag update for batch processing
-			if fetchedUID > 0 {
-				flagUpdates = append(flagUpdates, message.FlagUpdate{
-					UID:         fetchedUID,
-					IsRead:      isRead,
-					IsStarred:   isStarred,
-					IsAnswered:  isAnswered,
-					IsForwarded: isForwarded,
-					IsDraft:     isDraft,
-					IsDeleted:   isDeleted,
-				})
-			}
+		// Collect flag update for batch processing
+		if fetchedUID > 0 {
+			flagUpdates = append(flagUpdates, message.FlagUpdate{
+				UID:         fetchedUID,
+				IsRead:      isRead,
+				IsStarred:   isStarred,
+				IsAnswered:  isAnswered,
+				IsForwarded: isForwarded,
+				IsDraft:     isDraft,
+				IsDeleted:   isDeleted,
+				Tags:        tags,
+			})
 		}
+	}
 
-		if err := fetchCmd.Close(); err != nil {
-			return fmt.Errorf("failed to fetch flags: %w", err)
+	return flagUpdates
+}
+
+// fetchRemoteUIDsIncremental returns the server's UID list without a full
+// UID SEARCH ALL when the server's counters prove what changed. The local
+// copy must have matched the server at the last sync, with every UID below
+// the UIDNEXT recorded then. If UIDNEXT hasn't moved nothing was appended,
+// so an unchanged message count means nothing was expunged either. If it
+// has moved, only UIDs from the old UIDNEXT on are searched, and local plus
+// new must add up to the server's count exactly. Anything else falls back to
+// a full UID search.
+func (e *Engine) fetchRemoteUIDsIncremental(ctx context.Context, client *imapclient.Client, f *folder.Folder, mailbox *imapPkg.Mailbox, localUIDs []uint32) ([]uint32, error) {
+	if f.UIDNext == 0 || mailbox.UIDNext == 0 || len(localUIDs) != f.TotalCount {
+		return e.fetchAllUIDs(ctx, client)
+	}
+	for _, uid := range localUIDs {
+		if uid >= f.UIDNext {
+			return e.fetchAllUIDs(ctx, client)
 		}
+	}
 
-		// Batch update all flags in a single transaction
-		if len(flagUpdates) > 0 {
-			if err := e.messageStore.UpdateFlagsByUIDBatch(folderID, flagUpdates); err != nil {
-				e.log.Warn().Err(err).Int("count", len(flagUpdates)).Msg("Failed to batch update message flags")
-			}
+	if mailbox.UIDNext == f.UIDNext {
+		if len(localUIDs) != int(mailbox.Messages) {
+			e.log.Debug().
+				Int("local", len(localUIDs)).
+				Uint32("server", mailbox.Messages).
+				Msg("Message count dropped, expunges detected - fetching full UID list")
+			return e.fetchAllUIDs(ctx, client)
 		}
+		return localUIDs, nil
 	}
 
-	e.log.Debug().Int("count", len(uids)).Msg("Synced message flags")
-	return nil
+	newUIDs, err := e.fetchUIDsFrom(ctx, client, f.UIDNext)
+	if err != nil {
+		return nil, err
+	}
+	for _, uid := range newUIDs {
+		if uid >= mailbox.UIDNext {
+			// Appended after SELECT; the counts below can't account for it
+			return e.fetchAllUIDs(ctx, client)
+		}
+	}
+
+	if len(localUIDs)+len(newUIDs) != int(mailbox.Messages) {
+		e.log.Debug().
+			Int("local", len(localUIDs)).
+			Int("new", len(newUIDs)).
+			Uint32("server", mailbox.Messages).
+			Msg("Message count mismatch, expunges detected - fetching full UID list")
+		return e.fetchAllUIDs(ctx, client)
+	}
+
+	remoteUIDs := make([]uint32, 0, len(localUIDs)+len(newUIDs))
+	remoteUIDs = append(remoteUIDs, localUIDs...)
+	remoteUIDs = append(remoteUIDs, newUIDs...)
+	return remoteUIDs, nil
+}
+
+// fetchUIDsFrom fetches UIDs greater than or equal to minUID.
+// Uses a goroutine to allow context cancellation since Wait() blocks indefinitely.
+func (e *Engine) fetchUIDsFrom(ctx context.Context, client *imapclient.Client, minUID uint32) ([]uint32, error) {
+	// UID SEARCH UID n:* always matches the highest UID, even when it's below n
+	searchCmd := client.UIDSearch(&imap.SearchCriteria{
+		UID: []imap.UIDSet{{imap.UIDRange{Start: imap.UID(minUID), Stop: 0}}},
+	}, nil)
+
+	type searchResult struct {
+		data *imap.SearchData
+		err  error
+	}
+	resultCh := make(chan searchResult, 1)
+	go func() {
+		data, err := searchCmd.Wait()
+		resultCh <- searchResult{data, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		e.log.Debug().Msg("UID search (from UID) cancelled by context")
+		return nil, ctx.Err()
+	case result := <-resultCh:
+		if result.err != nil {
+			return nil, fmt.Errorf("UID search failed: %w", result.err)
+		}
+
+		var uids []uint32
+		for _, uid := range result.data.AllUIDs() {
+			if uint32(uid) >= minUID {
+				uids = append(uids, uint32(uid))
+			}
+		}
+
+		e.log.Debug().Int("count", len(uids)).Uint32("minUID", minUID).Msg("Fetched new UIDs")
+		return uids, nil
+	}
 }
 
 // fetchUIDsSince fetches UIDs of messages since the given date.
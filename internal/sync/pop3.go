@@ -0,0 +1,164 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	gomessage "github.com/emersion/go-message"
+	"github.com/hkdb/aerion/internal/account"
+	"github.com/hkdb/aerion/internal/folder"
+	"github.com/hkdb/aerion/internal/pop3"
+)
+
+// syncPOP3Inbox downloads new messages from a POP3 maildrop into the local
+// INBOX. Messages are recognised by UIDL, so each is downloaded once no
+// matter how long it stays on the server. What stays there is decided by
+// the account's leave-on-server and delete-after-days settings.
+func (e *Engine) syncPOP3Inbox(ctx context.Context, acc *account.Account, inbox *folder.Folder) error {
+	if e.pop3Config == nil || e.pop3UIDLs == nil {
+		return fmt.Errorf("POP3 support is not configured")
+	}
+
+	e.log.Debug().Str("account", acc.ID).Msg("Checking POP3 maildrop")
+
+	config, err := e.pop3Config(acc.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get POP3 config: %w", err)
+	}
+
+	client := pop3.NewClient(*config)
+	if err := client.Connect(); err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	// QUIT commits the deletions of this session. Messages are only marked
+	// for deletion once stored locally, so committing after a partial run
+	// loses nothing.
+	defer func() {
+		if err := client.Close(); err != nil {
+			e.log.Warn().Err(err).Str("account", acc.ID).Msg("Failed to end POP3 session, deletions not applied")
+		}
+	}()
+
+	if err := client.Login(); err != nil {
+		return fmt.Errorf("failed to log in: %w", err)
+	}
+
+	messages, err := client.ListMessages()
+	if err != nil {
+		return fmt.Errorf("failed to list messages: %w", err)
+	}
+
+	known, err := e.pop3UIDLs.ListUIDLs(acc.ID)
+	if err != nil {
+		return err
+	}
+
+	var newCount int
+	for i := range messages {
+		if messages[i].UID == "" {
+			uid, err := headerUID(client, messages[i].Number)
+			if err != nil {
+				return err
+			}
+			messages[i].UID = uid
+		}
+		if _, ok := known[messages[i].UID]; !ok {
+			newCount++
+		}
+	}
+
+	var cutoff time.Time
+	if acc.POP3LeaveOnServer && acc.POP3DeleteAfterDays > 0 {
+		cutoff = time.Now().AddDate(0, 0, -acc.POP3DeleteAfterDays)
+	}
+
+	e.emitProgress(acc.ID, inbox.ID, 0, newCount, "headers")
+
+	listed := make(map[string]bool, len(messages))
+	var fetched, deleted int
+	for _, info := range messages {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		listed[info.UID] = true
+
+		downloadedAt, ok := known[info.UID]
+		if !ok {
+			if info.Size > maxMessageSize {
+				e.log.Warn().
+					Int("number", info.Number).
+					Int("size", info.Size).
+					Msg("Skipping POP3 message larger than the size limit")
+				continue
+			}
+
+			raw, err := client.Retrieve(info.Number)
+			if err != nil {
+				return fmt.Errorf("failed to download message %d: %w", info.Number, err)
+			}
+			if _, err := e.StoreLocalMessage(acc.ID, inbox.ID, raw, nil); err != nil {
+				return fmt.Errorf("failed to store message: %w", err)
+			}
+
+			downloadedAt = time.Now().UTC()
+			if err := e.pop3UIDLs.AddUIDL(acc.ID, info.UID, downloadedAt); err != nil {
+				return err
+			}
+
+			fetched++
+			e.emitProgress(acc.ID, inbox.ID, fetched, newCount, "headers")
+		}
+
+		if !acc.POP3LeaveOnServer || (!cutoff.IsZero() && downloadedAt.Before(cutoff)) {
+			if err := client.Delete(info.Number); err != nil {
+				e.log.Warn().Err(err).Int("number", info.Number).Msg("Failed to delete POP3 message")
+				continue
+			}
+			deleted++
+		}
+	}
+
+	// Forget messages that are gone from the maildrop
+	var gone []string
+	for uidl := range known {
+		if !listed[uidl] {
+			gone = append(gone, uidl)
+		}
+	}
+	if err := e.pop3UIDLs.DeleteUIDLs(acc.ID, gone); err != nil {
+		e.log.Warn().Err(err).Msg("Failed to prune POP3 UIDLs")
+	}
+
+	e.log.Info().
+		Str("account", acc.ID).
+		Int("maildrop", len(messages)).
+		Int("downloaded", fetched).
+		Int("deleted", deleted).
+		Msg("POP3 download complete")
+
+	return nil
+}
+
+// headerUID identifies a message on a server without UIDL. The header is
+// read with TOP and the Message-ID and Date are used when present, since
+// some servers add status headers once a message has been retrieved.
+func headerUID(client *pop3.Client, number int) (string, error) {
+	header, err := client.Top(number, 0)
+	if err != nil {
+		return "", fmt.Errorf("failed to read header of message %d: %w", number, err)
+	}
+
+	key := header
+	if entity, _ := gomessage.Read(bytes.NewReader(header)); entity != nil {
+		if msgID := entity.Header.Get("Message-Id"); msgID != "" {
+			key = []byte(msgID + "\x00" + entity.Header.Get("Date"))
+		}
+	}
+
+	sum := sha1.Sum(key)
+	return "sha1:" + hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,167 @@
+package sync
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	gomessage "github.com/emersion/go-message"
+	"github.com/hkdb/aerion/internal/message"
+	"github.com/hkdb/aerion/internal/smtp"
+)
+
+// parseDeliveryReports extracts the per-recipient results of a delivery
+// status notification (RFC 3464) or read receipt (RFC 8098). Returns nil for
+// any other message, or a report that can't be linked to a sent message.
+func parseDeliveryReports(raw []byte) []*message.DeliveryReport {
+	entity, err := gomessage.Read(bytes.NewReader(raw))
+	if err != nil && entity == nil {
+		return nil
+	}
+
+	contentType, params, _ := mime.ParseMediaType(entity.Header.Get("Content-Type"))
+	if contentType != "multipart/report" {
+		return nil
+	}
+	reportType := strings.ToLower(params["report-type"])
+	if reportType != "delivery-status" && reportType != "disposition-notification" &&
+		reportType != "global-delivery-status" && reportType != "global-disposition-notification" {
+		return nil
+	}
+
+	mr := entity.MultipartReader()
+	if mr == nil {
+		return nil
+	}
+
+	var reportedAt time.Time
+	if date, err := netmail.ParseDate(entity.Header.Get("Date")); err == nil {
+		reportedAt = date.UTC()
+	}
+
+	var reports []*message.DeliveryReport
+	// Read receipts name the original; delivery reports return its headers
+	// and echo the envelope ID, which Aerion sets to the Message-ID
+	var originalID, headersID, envelopeID string
+
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			break
+		}
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		body, err := io.ReadAll(io.LimitReader(part.Body, maxPartSize))
+		if err != nil && len(body) == 0 {
+			continue
+		}
+
+		switch partType {
+		case "message/delivery-status", "message/global-delivery-status":
+			groups := readFieldGroups(body)
+			if len(groups) == 0 {
+				continue
+			}
+			perMessage := groups[0]
+			envelopeID = strings.Trim(smtp.DecodeXText(strings.TrimSpace(perMessage.Get("Original-Envelope-Id"))), "<>")
+			for _, fields := range groups[1:] {
+				r := &message.DeliveryReport{
+					Kind:       message.ReportDSN,
+					Recipient:  reportRecipient(fields),
+					Action:     strings.ToLower(strings.TrimSpace(fields.Get("Action"))),
+					Status:     strings.TrimSpace(fields.Get("Status")),
+					Diagnostic: typedValue(fields.Get("Diagnostic-Code")),
+					ReportedAt: reportedAt,
+				}
+				if date, err := netmail.ParseDate(fields.Get("Last-Attempt-Date")); err == nil {
+					r.ReportedAt = date.UTC()
+				}
+				if r.Action == "" {
+					continue
+				}
+				reports = append(reports, r)
+			}
+
+		case "message/disposition-notification", "message/global-disposition-notification":
+			groups := readFieldGroups(body)
+			if len(groups) == 0 {
+				continue
+			}
+			fields := groups[0]
+			originalID = strings.Trim(strings.TrimSpace(fields.Get("Original-Message-ID")), "<>")
+
+			// "manual-action/MDN-sent-manually; displayed" -> "displayed"
+			disposition := typedValue(fields.Get("Disposition"))
+			disposition, _, _ = strings.Cut(disposition, "/")
+			disposition = strings.ToLower(strings.TrimSpace(disposition))
+			if disposition == "" {
+				continue
+			}
+			reports = append(reports, &message.DeliveryReport{
+				Kind:       message.ReportMDN,
+				Recipient:  reportRecipient(fields),
+				Action:     disposition,
+				ReportedAt: reportedAt,
+			})
+
+		case "message/rfc822", "text/rfc822-headers", "message/global", "message/global-headers":
+			// The returned original (or just its headers)
+			tp := textproto.NewReader(bufio.NewReader(bytes.NewReader(body)))
+			if h, _ := tp.ReadMIMEHeader(); h != nil {
+				headersID = strings.Trim(strings.TrimSpace(h.Get("Message-Id")), "<>")
+			}
+		}
+	}
+
+	if originalID == "" {
+		originalID = headersID
+	}
+	if originalID == "" {
+		originalID = envelopeID
+	}
+	if originalID == "" || len(reports) == 0 {
+		return nil
+	}
+	for _, r := range reports {
+		r.OriginalMessageID = originalID
+	}
+	return reports
+}
+
+// readFieldGroups splits a delivery-status or disposition-notification body
+// into its blank-line separated groups of header-style fields
+func readFieldGroups(body []byte) []textproto.MIMEHeader {
+	tp := textproto.NewReader(bufio.NewReader(bytes.NewReader(body)))
+	var groups []textproto.MIMEHeader
+	for {
+		h, err := tp.ReadMIMEHeader()
+		if len(h) > 0 {
+			groups = append(groups, h)
+		}
+		if err != nil {
+			return groups
+		}
+	}
+}
+
+// reportRecipient returns the address a report is about, preferring the
+// recipient as originally addressed over the one finally delivered to
+func reportRecipient(fields textproto.MIMEHeader) string {
+	if r := typedValue(fields.Get("Original-Recipient")); r != "" {
+		return smtp.DecodeXText(r)
+	}
+	return typedValue(fields.Get("Final-Recipient"))
+}
+
+// typedValue strips the type from a "type; value" field, e.g.
+// "rfc822; user@example.com" or "smtp; 550 5.1.1 User unknown"
+func typedValue(field string) string {
+	if _, value, ok := strings.Cut(field, ";"); ok {
+		field = value
+	}
+	return strings.TrimSpace(field)
+}
@@ -2,6 +2,7 @@ package sync
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
@@ -414,11 +415,55 @@ func (s *Scheduler) SyncAccountInboxBlocking(accountID string) (*NewMailInfo, er
 		}
 	}
 
+	return s.syncFolderForNewMail(ctx, acc, inbox)
+}
+
+// SyncFolderBlocking syncs a single folder synchronously and returns new mail info.
+// Used for push updates on watched folders other than INBOX.
+func (s *Scheduler) SyncFolderBlocking(accountID, folderID string) (*NewMailInfo, error) {
+	acc, err := s.accountStore.Get(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := s.folderStore.Get(folderID)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return nil, fmt.Errorf("folder not found: %s", folderID)
+	}
+
+	// Prevent concurrent syncs for the same folder. Keyed separately from the
+	// account so a watched folder doesn't block the scheduled INBOX sync.
+	syncKey := acc.ID + ":" + f.ID
+	s.syncingMu.Lock()
+	if s.syncing[syncKey] {
+		s.syncingMu.Unlock()
+		s.log.Debug().Str("account", acc.Name).Str("folder", f.Path).Msg("Sync already in progress, skipping")
+		return nil, nil
+	}
+	s.syncing[syncKey] = true
+	s.syncingMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(s.ctx, 30*time.Minute)
+	defer func() {
+		cancel()
+		s.syncingMu.Lock()
+		delete(s.syncing, syncKey)
+		s.syncingMu.Unlock()
+	}()
+
+	return s.syncFolderForNewMail(ctx, acc, f)
+}
+
+// syncFolderForNewMail syncs a folder's messages and reports how many arrived
+func (s *Scheduler) syncFolderForNewMail(ctx context.Context, acc *account.Account, f *folder.Folder) (*NewMailInfo, error) {
 	// Get current message count before sync
-	previousCount := inbox.TotalCount
+	previousCount := f.TotalCount
 
 	// Sync messages (use account's sync period setting)
-	if err := s.engine.SyncMessages(ctx, acc.ID, inbox.ID, acc.SyncPeriodDays); err != nil {
+	if err := s.engine.SyncMessages(ctx, acc.ID, f.ID, acc.SyncPeriodDays); err != nil {
 		if ctx.Err() != nil {
 			s.log.Info().Str("account", acc.Name).Msg("Sync cancelled during message sync")
 			return nil, ctx.Err()
@@ -427,18 +472,18 @@ func (s *Scheduler) SyncAccountInboxBlocking(accountID string) (*NewMailInfo, er
 	}
 
 	// Get updated folder info
-	updatedInbox, err := s.folderStore.Get(inbox.ID)
+	updated, err := s.folderStore.Get(f.ID)
 	if err != nil {
 		return nil, err
 	}
 
 	// Check if there are new messages
-	if updatedInbox != nil && updatedInbox.TotalCount > previousCount {
-		newCount := updatedInbox.TotalCount - previousCount
+	if updated != nil && updated.TotalCount > previousCount {
+		newCount := updated.TotalCount - previousCount
 		return &NewMailInfo{
 			AccountID:   acc.ID,
 			AccountName: acc.Name,
-			FolderID:    inbox.ID,
+			FolderID:    f.ID,
 			Count:       newCount,
 		}, nil
 	}
@@ -79,6 +79,13 @@ func buildSearchCriteria(query string) *imap.SearchCriteria {
 // Non-local messages get envelope data fetched from the server.
 // When limit > 0, only the newest `limit` UIDs are processed but TotalCount reflects all matches.
 func (e *Engine) IMAPSearch(ctx context.Context, accountID, folderID, query string, limit int) (*IMAPSearchResponse, error) {
+	if e.isJMAP(accountID) {
+		return nil, errJMAPUnsupported
+	}
+	if e.hasLocalFolders(accountID) {
+		return nil, errLocalUnsupported
+	}
+
 	// Get folder path
 	f, err := e.folderStore.Get(folderID)
 	if err != nil {
@@ -303,6 +310,13 @@ func (e *Engine) fetchEnvelopesForSearch(ctx context.Context, client *imapclient
 // FetchServerMessage fetches a full message by UID from IMAP, parses it, saves to local DB,
 // and returns it. Used when a user interacts with a non-local server search result.
 func (e *Engine) FetchServerMessage(ctx context.Context, accountID, folderID string, uid uint32) (*message.Message, error) {
+	if e.isJMAP(accountID) {
+		return nil, errJMAPUnsupported
+	}
+	if e.hasLocalFolders(accountID) {
+		return nil, errLocalUnsupported
+	}
+
 	// Get folder path
 	f, err := e.folderStore.Get(folderID)
 	if err != nil {
@@ -0,0 +1,52 @@
+// Package template provides reusable message templates and canned
+// responses, with variables filled in when a template is used
+package template
+
+import (
+	"time"
+
+	"github.com/hkdb/aerion/internal/smtp"
+)
+
+// Template is a saved message that can start or be inserted into a new one.
+// Subject and bodies may contain variables such as {{recipient.firstName}}.
+type Template struct {
+	ID        string `json:"id"`
+	AccountID string `json:"accountId"` // Empty for templates shared by all accounts
+	Name      string `json:"name"`
+
+	Subject  string `json:"subject"`
+	HTMLBody string `json:"htmlBody"`
+	TextBody string `json:"textBody"`
+
+	// Default recipients, added to any already on the message
+	To  []smtp.Address `json:"to"`
+	Cc  []smtp.Address `json:"cc"`
+	Bcc []smtp.Address `json:"bcc"`
+
+	Attachments []smtp.Attachment `json:"attachments"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// IsGlobal returns true if the template is available to every account
+func (t *Template) IsGlobal() bool {
+	return t.AccountID == ""
+}
+
+// FromMessage creates a template from a composed message or draft. Threading
+// headers and per-message security options aren't kept.
+func FromMessage(accountID, name string, msg *smtp.ComposeMessage) *Template {
+	return &Template{
+		AccountID:   accountID,
+		Name:        name,
+		Subject:     msg.Subject,
+		HTMLBody:    msg.HTMLBody,
+		TextBody:    msg.TextBody,
+		To:          msg.To,
+		Cc:          msg.Cc,
+		Bcc:         msg.Bcc,
+		Attachments: msg.Attachments,
+	}
+}
@@ -0,0 +1,177 @@
+package template
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hkdb/aerion/internal/smtp"
+)
+
+// variablePattern matches {{name}}, allowing spaces inside the braces.
+// Names may use any letters, for mail merge columns in other scripts.
+var variablePattern = regexp.MustCompile(`\{\{\s*([\p{L}\p{N}_.]+)\s*\}\}`)
+
+// Vars holds the values substituted for template variables. Names are
+// matched case-insensitively; unknown variables are left in place so they
+// stand out in the composer.
+type Vars map[string]string
+
+// NewVars creates the variables available to every template
+func NewVars(now time.Time) Vars {
+	v := Vars{}
+	v.Set("date", now.Format("January 2, 2006"))
+	v.Set("time", now.Format("3:04 PM"))
+	return v
+}
+
+// Set sets a variable
+func (v Vars) Set(name, value string) {
+	v[strings.ToLower(name)] = value
+}
+
+// Lookup returns a variable's value
+func (v Vars) Lookup(name string) (string, bool) {
+	value, ok := v[strings.ToLower(name)]
+	return value, ok
+}
+
+// SetRecipient sets the recipient.* variables from the message's first
+// recipient
+func (v Vars) SetRecipient(addr smtp.Address) {
+	first, last := splitName(addr.Name)
+	v.Set("recipient.name", addr.Name)
+	v.Set("recipient.firstName", first)
+	v.Set("recipient.lastName", last)
+	v.Set("recipient.email", addr.Address)
+}
+
+// SetSender sets the sender.* variables from the From address
+func (v Vars) SetSender(addr smtp.Address) {
+	first, last := splitName(addr.Name)
+	v.Set("sender.name", addr.Name)
+	v.Set("sender.firstName", first)
+	v.Set("sender.lastName", last)
+	v.Set("sender.email", addr.Address)
+}
+
+// SetOriginal sets the original.* variables from the message being replied
+// to or forwarded
+func (v Vars) SetOriginal(subject, fromName, fromEmail string, date time.Time) {
+	from := fromName
+	if from == "" {
+		from = fromEmail
+	}
+	v.Set("original.subject", subject)
+	v.Set("original.from", from)
+	v.Set("original.email", fromEmail)
+	if !date.IsZero() {
+		v.Set("original.date", date.Format("January 2, 2006"))
+	}
+}
+
+// Expand substitutes variables in s. Values are HTML-escaped when escape is
+// true.
+func (v Vars) Expand(s string, escape bool) string {
+	return variablePattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := variablePattern.FindStringSubmatch(match)[1]
+		value, ok := v.Lookup(name)
+		if !ok {
+			return match
+		}
+		if escape {
+			return html.EscapeString(value)
+		}
+		return value
+	})
+}
+
+// Render fills in a template's variables, returning its subject, bodies,
+// recipients and attachments as a message ready to merge with Apply. The
+// recipient.* variables come from the template's own first recipient if
+// vars doesn't set them.
+func Render(t *Template, vars Vars) *smtp.ComposeMessage {
+	if _, ok := vars.Lookup("recipient.email"); !ok && len(t.To) > 0 {
+		vars.SetRecipient(t.To[0])
+	}
+
+	htmlBody := t.HTMLBody
+	if htmlBody == "" && t.TextBody != "" {
+		htmlBody = textToHTML(t.TextBody)
+	}
+
+	return &smtp.ComposeMessage{
+		To:          t.To,
+		Cc:          t.Cc,
+		Bcc:         t.Bcc,
+		Subject:     vars.Expand(t.Subject, false),
+		HTMLBody:    vars.Expand(htmlBody, true),
+		TextBody:    vars.Expand(t.TextBody, false),
+		Attachments: t.Attachments,
+	}
+}
+
+// Apply merges a rendered template into msg: its body goes above any quoted
+// text, its recipients and attachments are added, and its subject is used
+// if msg doesn't have one
+func Apply(msg, rendered *smtp.ComposeMessage) {
+	if msg.Subject == "" {
+		msg.Subject = rendered.Subject
+	}
+	msg.HTMLBody = rendered.HTMLBody + msg.HTMLBody
+	if rendered.TextBody != "" {
+		msg.TextBody = rendered.TextBody + msg.TextBody
+	}
+
+	msg.To = mergeAddresses(msg.To, rendered.To)
+	msg.Cc = mergeAddresses(msg.Cc, rendered.Cc)
+	msg.Bcc = mergeAddresses(msg.Bcc, rendered.Bcc)
+	msg.Attachments = append(msg.Attachments, rendered.Attachments...)
+}
+
+// mergeAddresses appends the addresses in add that aren't already in list
+func mergeAddresses(list, add []smtp.Address) []smtp.Address {
+	seen := make(map[string]bool, len(list))
+	for _, addr := range list {
+		seen[strings.ToLower(addr.Address)] = true
+	}
+	for _, addr := range add {
+		key := strings.ToLower(addr.Address)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		list = append(list, addr)
+	}
+	return list
+}
+
+// splitName splits a display name into first and last names, understanding
+// the "Last, First" form
+func splitName(name string) (first, last string) {
+	name = strings.TrimSpace(name)
+	if l, f, ok := strings.Cut(name, ","); ok {
+		return strings.TrimSpace(f), strings.TrimSpace(l)
+	}
+	fields := strings.Fields(name)
+	switch len(fields) {
+	case 0:
+		return "", ""
+	case 1:
+		return fields[0], ""
+	default:
+		return fields[0], fields[len(fields)-1]
+	}
+}
+
+// textToHTML converts a plain text template body to HTML paragraphs
+func textToHTML(text string) string {
+	var b strings.Builder
+	for _, para := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(para), "\n", "<br>"))
+		b.WriteString("</p>")
+	}
+	return b.String()
+}
@@ -0,0 +1,169 @@
+package template
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hkdb/aerion/internal/database"
+	"github.com/hkdb/aerion/internal/logging"
+	"github.com/rs/zerolog"
+)
+
+// Store provides template persistence
+type Store struct {
+	db  *database.DB
+	log zerolog.Logger
+}
+
+// NewStore creates a new template store
+func NewStore(db *database.DB) *Store {
+	return &Store{
+		db:  db,
+		log: logging.WithComponent("template-store"),
+	}
+}
+
+const selectColumns = `
+	SELECT id, account_id, name, subject, html_body, text_body,
+		to_list, cc_list, bcc_list, attachments, created_at, updated_at
+	FROM templates
+`
+
+// List returns the templates available to an account, its own and the
+// shared ones, sorted by name
+func (s *Store) List(accountID string) ([]*Template, error) {
+	rows, err := s.db.Query(selectColumns+" WHERE account_id = ? OR account_id IS NULL ORDER BY name COLLATE NOCASE", accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	defer rows.Close()
+
+	var list []*Template
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
+
+// Get returns a template by ID, or nil if it doesn't exist
+func (s *Store) Get(id string) (*Template, error) {
+	rows, err := s.db.Query(selectColumns+" WHERE id = ?", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	return scanTemplate(rows)
+}
+
+// Save creates a template or updates an existing one
+func (s *Store) Save(t *Template) error {
+	to, err := json.Marshal(t.To)
+	if err != nil {
+		return fmt.Errorf("failed to marshal recipients: %w", err)
+	}
+	cc, err := json.Marshal(t.Cc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal recipients: %w", err)
+	}
+	bcc, err := json.Marshal(t.Bcc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal recipients: %w", err)
+	}
+	var attachments []byte
+	if len(t.Attachments) > 0 {
+		if attachments, err = json.Marshal(t.Attachments); err != nil {
+			return fmt.Errorf("failed to marshal attachments: %w", err)
+		}
+	}
+
+	now := time.Now()
+	t.UpdatedAt = now
+
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+		t.CreatedAt = now
+
+		_, err = s.db.Exec(`
+			INSERT INTO templates (
+				id, account_id, name, subject, html_body, text_body,
+				to_list, cc_list, bcc_list, attachments, created_at, updated_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, t.ID, nullString(t.AccountID), t.Name, t.Subject, t.HTMLBody, t.TextBody,
+			string(to), string(cc), string(bcc), attachments, t.CreatedAt, t.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create template: %w", err)
+		}
+
+		s.log.Debug().Str("id", t.ID).Str("account_id", t.AccountID).Msg("Created template")
+		return nil
+	}
+
+	result, err := s.db.Exec(`
+		UPDATE templates SET
+			account_id = ?, name = ?, subject = ?, html_body = ?, text_body = ?,
+			to_list = ?, cc_list = ?, bcc_list = ?, attachments = ?, updated_at = ?
+		WHERE id = ?
+	`, nullString(t.AccountID), t.Name, t.Subject, t.HTMLBody, t.TextBody,
+		string(to), string(cc), string(bcc), attachments, t.UpdatedAt, t.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update template: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("template not found: %s", t.ID)
+	}
+
+	return nil
+}
+
+// Delete removes a template
+func (s *Store) Delete(id string) error {
+	if _, err := s.db.Exec("DELETE FROM templates WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	return nil
+}
+
+func scanTemplate(rows *sql.Rows) (*Template, error) {
+	t := &Template{}
+	var accountID sql.NullString
+	var to, cc, bcc string
+	var attachments []byte
+	if err := rows.Scan(
+		&t.ID, &accountID, &t.Name, &t.Subject, &t.HTMLBody, &t.TextBody,
+		&to, &cc, &bcc, &attachments, &t.CreatedAt, &t.UpdatedAt,
+	); err != nil {
+		return nil, fmt.Errorf("failed to scan template: %w", err)
+	}
+	t.AccountID = accountID.String
+
+	if err := json.Unmarshal([]byte(to), &t.To); err != nil {
+		return nil, fmt.Errorf("failed to parse template recipients: %w", err)
+	}
+	if err := json.Unmarshal([]byte(cc), &t.Cc); err != nil {
+		return nil, fmt.Errorf("failed to parse template recipients: %w", err)
+	}
+	if err := json.Unmarshal([]byte(bcc), &t.Bcc); err != nil {
+		return nil, fmt.Errorf("failed to parse template recipients: %w", err)
+	}
+	if len(attachments) > 0 {
+		if err := json.Unmarshal(attachments, &t.Attachments); err != nil {
+			return nil, fmt.Errorf("failed to parse template attachments: %w", err)
+		}
+	}
+	return t, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -2,7 +2,9 @@ package undo
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/emersion/go-imap/v2"
 	imapPkg "github.com/hkdb/aerion/internal/imap"
@@ -117,6 +119,10 @@ func (c *FlagChangeCommand) Undo() error {
 	return nil
 }
 
+// ErrMoveInProgress is returned when undoing a move whose server side is
+// still running
+var ErrMoveInProgress = errors.New("move still in progress")
+
 // MoveCommand handles moving messages between folders
 type MoveCommand struct {
 	BaseCommand
@@ -124,12 +130,17 @@ type MoveCommand struct {
 	undoCtx          UndoContext
 	accountID        string
 	messageIDs       []string
-	uids             []uint32
+	headerIDs        []string // Message-ID headers, to find the messages without COPYUID
 	sourceFolderID   string
 	sourceFolderPath string
 	destFolderID     string
 	destFolderPath   string
-	newUIDs          []uint32 // UIDs in destination folder after move
+
+	mu       sync.Mutex
+	done     chan struct{} // Closed once the server move finished or failed
+	finished bool
+	newUIDs  []uint32 // UIDs in destination folder after move
+	moveErr  error    // Why the server move didn't happen
 }
 
 // NewMoveCommand creates a new MoveCommand
@@ -138,7 +149,7 @@ func NewMoveCommand(
 	undoCtx UndoContext,
 	accountID string,
 	messageIDs []string,
-	uids []uint32,
+	headerIDs []string,
 	sourceFolderID, sourceFolderPath string,
 	destFolderID, destFolderPath string,
 	description string,
@@ -149,25 +160,62 @@ func NewMoveCommand(
 		undoCtx:          undoCtx,
 		accountID:        accountID,
 		messageIDs:       messageIDs,
-		uids:             uids,
+		headerIDs:        headerIDs,
 		sourceFolderID:   sourceFolderID,
 		sourceFolderPath: sourceFolderPath,
 		destFolderID:     destFolderID,
 		destFolderPath:   destFolderPath,
+		done:             make(chan struct{}),
 	}
 }
 
-// SetNewUIDs sets the UIDs of messages in the destination folder
-// This should be called after the move is complete if UIDPLUS info is available
+// SetNewUIDs sets the UIDs of messages in the destination folder, in the same
+// order as the original UIDs, and marks the move done. Called once the IMAP
+// move completes with the COPYUID mapping (nil without one), which may be
+// after the command was pushed.
 func (c *MoveCommand) SetNewUIDs(uids []uint32) {
+	c.finish(uids, nil)
+}
+
+// Fail marks a move that didn't happen on the server, so undoing it reports
+// err instead of waiting. It does nothing once the move finished.
+func (c *MoveCommand) Fail(err error) {
+	c.finish(nil, err)
+}
+
+// finish records the outcome of the server move, once
+func (c *MoveCommand) finish(uids []uint32, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.finished {
+		return
+	}
+	c.finished = true
 	c.newUIDs = uids
+	c.moveErr = err
+	close(c.done)
 }
 
 // Execute performs the action (already done at creation time)
 func (c *MoveCommand) Execute() error { return nil }
 
-// Undo reverses the move operation
+// Undo reverses the move operation. It fails with ErrMoveInProgress until
+// the server move has finished, since the messages can only be moved back
+// once they're there.
 func (c *MoveCommand) Undo() error {
+	select {
+	case <-c.done:
+	default:
+		return ErrMoveInProgress
+	}
+
+	c.mu.Lock()
+	uidsToUse, moveErr := c.newUIDs, c.moveErr
+	c.mu.Unlock()
+	if moveErr != nil {
+		return fmt.Errorf("move didn't complete on the server: %w", moveErr)
+	}
+
 	// Get IMAP connection
 	client, release, err := c.undoCtx.GetIMAPConnectionForUndo(c.ctx, c.accountID)
 	if err != nil {
@@ -180,13 +228,13 @@ func (c *MoveCommand) Undo() error {
 		return fmt.Errorf("failed to select mailbox: %w", err)
 	}
 
-	// Use newUIDs if available, otherwise use original UIDs
-	// Note: Original UIDs may not work after move - this is a limitation
-	// For proper undo, we'd need the new UIDs from UIDPLUS or a resync
-	uidsToUse := c.newUIDs
+	// Without COPYUID the destination UIDs are unknown; the source UIDs
+	// name unrelated messages there, so look the messages up by Message-ID
 	if len(uidsToUse) == 0 {
-		// Fallback: try original UIDs (may not work reliably)
-		uidsToUse = c.uids
+		uidsToUse, err = c.findByHeaderIDs(client)
+		if err != nil {
+			return err
+		}
 	}
 
 	imapUIDs := make([]imap.UID, len(uidsToUse))
@@ -194,14 +242,9 @@ func (c *MoveCommand) Undo() error {
 		imapUIDs[i] = imap.UID(uid)
 	}
 
-	// Copy back to source folder
-	if _, err := client.CopyMessages(imapUIDs, c.sourceFolderPath); err != nil {
-		return fmt.Errorf("failed to copy messages: %w", err)
-	}
-
-	// Delete from destination
-	if err := client.DeleteMessagesByUID(imapUIDs); err != nil {
-		return fmt.Errorf("failed to delete messages from destination: %w", err)
+	// Move back to source folder
+	if _, err := client.MoveMessages(imapUIDs, c.sourceFolderPath); err != nil {
+		return fmt.Errorf("failed to move messages back: %w", err)
 	}
 
 	// Update local database
@@ -211,3 +254,38 @@ func (c *MoveCommand) Undo() error {
 
 	return nil
 }
+
+// findByHeaderIDs finds the moved messages in the selected destination
+// folder by their Message-ID headers. A message without one, or that can't
+// be found, fails the undo rather than risking moving the wrong message.
+func (c *MoveCommand) findByHeaderIDs(client *imapPkg.Client) ([]uint32, error) {
+	if len(c.headerIDs) != len(c.messageIDs) {
+		return nil, fmt.Errorf("can't find the moved messages: server didn't report their new UIDs")
+	}
+
+	taken := make(map[imap.UID]bool)
+	uids := make([]uint32, 0, len(c.headerIDs))
+	for _, headerID := range c.headerIDs {
+		if headerID == "" {
+			return nil, fmt.Errorf("can't find a moved message without a Message-ID")
+		}
+		found, err := client.FindByMessageID(headerID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find moved message: %w", err)
+		}
+
+		// Copies of the same message: the newest one is the moved one
+		var uid imap.UID
+		for _, u := range found {
+			if !taken[u] && u > uid {
+				uid = u
+			}
+		}
+		if uid == 0 {
+			return nil, fmt.Errorf("moved message %s not found in destination folder", headerID)
+		}
+		taken[uid] = true
+		uids = append(uids, uint32(uid))
+	}
+	return uids, nil
+}
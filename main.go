@@ -29,6 +29,7 @@ var (
 	mode        = flag.String("mode", "new", "Compose mode: new, reply, reply-all, forward")
 	messageID   = flag.String("message-id", "", "Original message ID for reply/forward")
 	draftID     = flag.String("draft-id", "", "Draft ID to resume editing")
+	templateID  = flag.String("template-id", "", "Template to start the message from")
 	dbusNotify  = flag.Bool("dbus-notify", false, "Use direct D-Bus notifications instead of portal (Linux only)")
 )
 
@@ -205,6 +206,7 @@ func runComposerMode() {
 		Mode:       *mode,
 		MessageID:  *messageID,
 		DraftID:    *draftID,
+		TemplateID: *templateID,
 	}
 
 	// Create composer app